@@ -0,0 +1,189 @@
+// Package apierror is K-View's error taxonomy: the one JSON body every API error response
+// has, with a machine-readable reason next to the message, and the mapping of errors from the
+// Kubernetes API to HTTP status codes and reasons.
+//
+//	{"error": "Failed to list pods: pods is forbidden: ...", "reason": "Forbidden", "code": 403}
+package apierror
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k-view/k8s"
+)
+
+// Reason is the machine-readable cause of an error response. Reasons shared with the
+// Kubernetes API have its names, so a Kubernetes error keeps its reason on the way through.
+type Reason string
+
+const (
+	BadRequest         Reason = "BadRequest"
+	Unauthorized       Reason = "Unauthorized"
+	Forbidden          Reason = "Forbidden"
+	NotFound           Reason = "NotFound"
+	MethodNotAllowed   Reason = "MethodNotAllowed"
+	AlreadyExists      Reason = "AlreadyExists"
+	Conflict           Reason = "Conflict"
+	Gone               Reason = "Gone"
+	TooLarge           Reason = "RequestEntityTooLarge"
+	Invalid            Reason = "Invalid"
+	Locked             Reason = "Locked"
+	TooManyRequests    Reason = "TooManyRequests"
+	Internal           Reason = "InternalError"
+	NotImplemented     Reason = "NotImplemented"
+	BadGateway         Reason = "BadGateway"
+	ServiceUnavailable Reason = "ServiceUnavailable"
+	Timeout            Reason = "Timeout"
+)
+
+// statusReasons are the reasons of the status codes K-View answers with.
+var statusReasons = map[int]Reason{
+	http.StatusBadRequest:            BadRequest,
+	http.StatusUnauthorized:          Unauthorized,
+	http.StatusForbidden:             Forbidden,
+	http.StatusNotFound:              NotFound,
+	http.StatusMethodNotAllowed:      MethodNotAllowed,
+	http.StatusConflict:              Conflict,
+	http.StatusGone:                  Gone,
+	http.StatusRequestEntityTooLarge: TooLarge,
+	http.StatusUnprocessableEntity:   Invalid,
+	http.StatusLocked:                Locked,
+	http.StatusTooManyRequests:       TooManyRequests,
+	http.StatusInternalServerError:   Internal,
+	http.StatusNotImplemented:        NotImplemented,
+	http.StatusBadGateway:            BadGateway,
+	http.StatusServiceUnavailable:    ServiceUnavailable,
+	http.StatusGatewayTimeout:        Timeout,
+}
+
+// kubernetesStatuses are the status codes of the Kubernetes reasons whose code is not the one
+// K-View answers with: the API server reports timeouts as 500 and 504 alike.
+var kubernetesStatuses = map[metav1.StatusReason]int{
+	metav1.StatusReasonTimeout:       http.StatusGatewayTimeout,
+	metav1.StatusReasonServerTimeout: http.StatusGatewayTimeout,
+	metav1.StatusReasonInvalid:       http.StatusUnprocessableEntity,
+}
+
+// Response is the body of every API error response. Error is for people and is what the UI
+// shows; Reason is for code and Code repeats the HTTP status.
+type Response struct {
+	Error  string  `json:"error"`
+	Reason Reason  `json:"reason"`
+	Code   int     `json:"code"`
+	Fields []Field `json:"fields,omitempty"` // Invalid: the fields the Kubernetes API rejected
+}
+
+// Field is a field of an object the Kubernetes API rejected, and why.
+type Field struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ReasonFor returns the reason of a status code; server errors without one of their own are
+// InternalError and client errors BadRequest.
+func ReasonFor(status int) Reason {
+	if reason, ok := statusReasons[status]; ok {
+		return reason
+	}
+	if status >= http.StatusInternalServerError {
+		return Internal
+	}
+	return BadRequest
+}
+
+// Classify returns the status code and reason to answer an error with. Kubernetes API errors
+// keep their code, so a forbidden list is a 403 rather than a 500, except that a 401 from the
+// API server is a 502: 401 is kept for K-View's own authentication. Calls throttled by the API
+// server or the client-side rate limit are 429, calls that timed out 504, and anything else
+// is a 500.
+func Classify(err error) (int, Reason) {
+	switch {
+	case k8s.IsThrottled(err):
+		return http.StatusTooManyRequests, TooManyRequests
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, Timeout
+	}
+	var status k8serrors.APIStatus
+	if !errors.As(err, &status) {
+		return http.StatusInternalServerError, Internal
+	}
+	reason := status.Status().Reason
+	code, ok := kubernetesStatuses[reason]
+	if !ok {
+		code = int(status.Status().Code)
+	}
+	if code == http.StatusUnauthorized {
+		// The cluster rejected K-View's credentials (an expired kubeconfig, say); the user is
+		// still signed in, and the UI logs out on a 401
+		code = http.StatusBadGateway
+	}
+	if code < http.StatusBadRequest || code > 599 {
+		return http.StatusInternalServerError, Internal
+	}
+	if reason == metav1.StatusReasonAlreadyExists {
+		return code, AlreadyExists
+	}
+	return code, ReasonFor(code)
+}
+
+// New returns the response for a status code and message.
+func New(status int, message string) Response {
+	return Response{Error: message, Reason: ReasonFor(status), Code: status}
+}
+
+// FromError returns the response for err, with its status code and reason from Classify and
+// the message prefixed by what failed, if anything.
+func FromError(message string, err error) Response {
+	code, reason := Classify(err)
+	r := Response{Error: err.Error(), Reason: reason, Code: code}
+	if message != "" {
+		r.Error = message + ": " + r.Error
+	}
+	var status k8serrors.APIStatus
+	if reason == Invalid && errors.As(err, &status) && status.Status().Details != nil {
+		for _, cause := range status.Status().Details.Causes {
+			r.Fields = append(r.Fields, Field{Field: cause.Field, Message: cause.Message})
+		}
+	}
+	return r
+}
+
+// With returns the response with extra fields, for error responses that carry more than the
+// message, such as the containers to choose from.
+func (r Response) With(extra gin.H) gin.H {
+	h := gin.H{"error": r.Error, "reason": r.Reason, "code": r.Code}
+	if len(r.Fields) > 0 {
+		h["fields"] = r.Fields
+	}
+	for k, v := range extra {
+		h[k] = v
+	}
+	return h
+}
+
+// Write answers the request with an error response of a status code and message.
+func Write(c *gin.Context, status int, message string) {
+	c.JSON(status, New(status, message))
+}
+
+// Abort answers the request with an error response of a status code and message, and stops
+// the handler chain.
+func Abort(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, New(status, message))
+}
+
+// Fail answers the request with the error response for err, as FromError, passing on how
+// long the API server asked to wait when it throttled the call.
+func Fail(c *gin.Context, message string, err error) {
+	r := FromError(message, err)
+	if seconds, ok := k8serrors.SuggestsClientDelay(err); ok && r.Code == http.StatusTooManyRequests {
+		c.Header("Retry-After", strconv.Itoa(seconds))
+	}
+	c.JSON(r.Code, r)
+}
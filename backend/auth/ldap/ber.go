@@ -0,0 +1,176 @@
+package ldap
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// A minimal BER codec covering what LDAPv3 (RFC 4511) needs: single-byte tags, definite
+// lengths, integers, booleans and octet strings.
+
+// BER tag classes and flags.
+const (
+	classUniversal   = 0x00
+	classApplication = 0x40
+	classContext     = 0x80
+	constructed      = 0x20
+)
+
+// Universal tags.
+const (
+	tagBoolean     = 0x01
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagEnumerated  = 0x0a
+	tagSequence    = 0x30
+	tagSet         = 0x31
+)
+
+// maxPacket bounds a single response; directory entries are small.
+const maxPacket = 16 << 20
+
+func berLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var b []byte
+	for v := n; v > 0; v >>= 8 {
+		b = append([]byte{byte(v)}, b...)
+	}
+	return append([]byte{0x80 | byte(len(b))}, b...)
+}
+
+// tlv encodes one element.
+func tlv(tag byte, content []byte) []byte {
+	out := append([]byte{tag}, berLength(len(content))...)
+	return append(out, content...)
+}
+
+func seq(tag byte, parts ...[]byte) []byte {
+	var content []byte
+	for _, p := range parts {
+		content = append(content, p...)
+	}
+	return tlv(tag, content)
+}
+
+func octets(tag byte, s string) []byte {
+	return tlv(tag, []byte(s))
+}
+
+func integer(tag byte, v int64) []byte {
+	// Minimal two's complement
+	b := []byte{byte(v)}
+	for v > 127 || v < -128 {
+		v >>= 8
+		b = append([]byte{byte(v)}, b...)
+	}
+	return tlv(tag, b)
+}
+
+func boolean(tag byte, v bool) []byte {
+	if v {
+		return tlv(tag, []byte{0xff})
+	}
+	return tlv(tag, []byte{0x00})
+}
+
+// element is a decoded TLV.
+type element struct {
+	tag  byte
+	data []byte
+}
+
+// children decodes the elements inside a constructed element.
+func (e element) children() ([]element, error) {
+	var out []element
+	data := e.data
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return nil, errors.New("ldap: truncated element")
+		}
+		tag := data[0]
+		n, size, err := decodeLength(data[1:])
+		if err != nil {
+			return nil, err
+		}
+		start := 1 + size
+		if n > len(data)-start {
+			return nil, errors.New("ldap: element length exceeds its container")
+		}
+		out = append(out, element{tag: tag, data: data[start : start+n]})
+		data = data[start+n:]
+	}
+	return out, nil
+}
+
+func (e element) int() int64 {
+	var v int64
+	for i, b := range e.data {
+		if i == 0 && b&0x80 != 0 {
+			v = -1
+		}
+		v = v<<8 | int64(b)
+	}
+	return v
+}
+
+func (e element) str() string {
+	return string(e.data)
+}
+
+// decodeLength reads a definite length and returns it with the number of bytes it used.
+func decodeLength(b []byte) (int, int, error) {
+	if len(b) == 0 {
+		return 0, 0, errors.New("ldap: missing length")
+	}
+	if b[0] < 0x80 {
+		return int(b[0]), 1, nil
+	}
+	size := int(b[0] & 0x7f)
+	if size == 0 || size > 4 || len(b) < 1+size {
+		return 0, 0, fmt.Errorf("ldap: unsupported length encoding")
+	}
+	n := 0
+	for _, v := range b[1 : 1+size] {
+		n = n<<8 | int(v)
+	}
+	return n, 1 + size, nil
+}
+
+// readElement reads one complete top-level element from the connection.
+func readElement(r *bufio.Reader) (element, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return element{}, err
+	}
+	first, err := r.ReadByte()
+	if err != nil {
+		return element{}, err
+	}
+	n := int(first)
+	if first >= 0x80 {
+		size := int(first & 0x7f)
+		if size == 0 || size > 4 {
+			return element{}, errors.New("ldap: unsupported length encoding")
+		}
+		n = 0
+		for i := 0; i < size; i++ {
+			b, err := r.ReadByte()
+			if err != nil {
+				return element{}, err
+			}
+			n = n<<8 | int(b)
+		}
+	}
+	if n > maxPacket {
+		return element{}, fmt.Errorf("ldap: response of %d bytes is too large", n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return element{}, err
+	}
+	return element{tag: tag, data: data}, nil
+}
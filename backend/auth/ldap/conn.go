@@ -0,0 +1,290 @@
+package ldap
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Protocol operation tags (RFC 4511 section 4.2 onwards).
+const (
+	opBindRequest      = classApplication | constructed | 0
+	opBindResponse     = classApplication | constructed | 1
+	opUnbindRequest    = classApplication | 2
+	opSearchRequest    = classApplication | constructed | 3
+	opSearchEntry      = classApplication | constructed | 4
+	opSearchDone       = classApplication | constructed | 5
+	opSearchReference  = classApplication | constructed | 19
+	opExtendedRequest  = classApplication | constructed | 23
+	opExtendedResponse = classApplication | constructed | 24
+)
+
+const (
+	startTLSOID         = "1.3.6.1.4.1.1466.20037"
+	resultSuccess       = 0
+	resultInvalidCreds  = 49
+	scopeWholeSubtree   = 2
+	derefNever          = 0
+	searchSizeLimit     = 1000
+	searchTimeLimitSecs = 10
+)
+
+// ResultError is an LDAP result other than success.
+type ResultError struct {
+	Code    int64
+	Message string
+}
+
+func (e *ResultError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("ldap: result code %d", e.Code)
+	}
+	return fmt.Sprintf("ldap: result code %d: %s", e.Code, e.Message)
+}
+
+// Entry is a search result.
+type Entry struct {
+	DN         string
+	Attributes map[string][]string // Keyed by lower-case attribute name
+}
+
+// Get returns the first value of an attribute, or "". Attribute names are case-insensitive.
+func (e Entry) Get(attr string) string {
+	if v := e.Attributes[strings.ToLower(attr)]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// conn is one LDAP connection. Operations are sequential; there is no multiplexing.
+type conn struct {
+	nc      net.Conn
+	r       *bufio.Reader
+	timeout time.Duration
+	nextID  int64
+}
+
+// dial connects to an ldap:// or ldaps:// URL, upgrading ldap:// with StartTLS when asked.
+func dial(rawURL string, startTLS bool, tlsConfig *tls.Config, timeout time.Duration) (*conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("ldap: invalid URL %q: %v", rawURL, err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		port := "389"
+		if u.Scheme == "ldaps" {
+			port = "636"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+	cfg := tlsConfig.Clone()
+	if cfg == nil {
+		cfg = &tls.Config{}
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = u.Hostname()
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var nc net.Conn
+	switch u.Scheme {
+	case "ldaps":
+		nc, err = tls.DialWithDialer(dialer, "tcp", host, cfg)
+	case "ldap":
+		nc, err = dialer.Dial("tcp", host)
+	default:
+		return nil, fmt.Errorf("ldap: unsupported URL scheme %q (use ldap or ldaps)", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &conn{nc: nc, r: bufio.NewReader(nc), timeout: timeout}
+	if startTLS && u.Scheme == "ldap" {
+		if err := c.startTLS(cfg); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *conn) close() {
+	// Unbind is a courtesy; the server tears the connection down either way
+	c.nextID++
+	_ = c.nc.SetDeadline(time.Now().Add(time.Second))
+	_, _ = c.nc.Write(seq(tagSequence, integer(tagInteger, c.nextID), tlv(opUnbindRequest, nil)))
+	c.nc.Close()
+}
+
+// send writes a request and returns its message ID.
+func (c *conn) send(op []byte) (int64, error) {
+	c.nextID++
+	if err := c.nc.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	_, err := c.nc.Write(seq(tagSequence, integer(tagInteger, c.nextID), op))
+	return c.nextID, err
+}
+
+// receive reads the next message for a request and returns its protocol operation.
+func (c *conn) receive(id int64) (element, error) {
+	for {
+		msg, err := readElement(c.r)
+		if err != nil {
+			return element{}, err
+		}
+		parts, err := msg.children()
+		if err != nil {
+			return element{}, err
+		}
+		if len(parts) < 2 || parts[0].tag != tagInteger {
+			return element{}, errors.New("ldap: malformed message")
+		}
+		if parts[0].int() == id {
+			return parts[1], nil
+		}
+		// Anything else (such as a notice of disconnection, ID 0) is skipped
+	}
+}
+
+// result decodes the LDAPResult at the start of a response.
+func result(op element) error {
+	parts, err := op.children()
+	if err != nil {
+		return err
+	}
+	if len(parts) < 3 {
+		return errors.New("ldap: malformed result")
+	}
+	if code := parts[0].int(); code != resultSuccess {
+		return &ResultError{Code: code, Message: parts[2].str()}
+	}
+	return nil
+}
+
+func (c *conn) startTLS(cfg *tls.Config) error {
+	id, err := c.send(seq(opExtendedRequest, octets(classContext|0, startTLSOID)))
+	if err != nil {
+		return err
+	}
+	op, err := c.receive(id)
+	if err != nil {
+		return err
+	}
+	if op.tag != opExtendedResponse {
+		return errors.New("ldap: unexpected response to StartTLS")
+	}
+	if err := result(op); err != nil {
+		return fmt.Errorf("ldap: StartTLS refused: %v", err)
+	}
+	tc := tls.Client(c.nc, cfg)
+	if err := tc.Handshake(); err != nil {
+		return err
+	}
+	c.nc, c.r = tc, bufio.NewReader(tc)
+	return nil
+}
+
+// bind performs a simple bind. Empty passwords are refused: servers treat them as an
+// unauthenticated bind, which succeeds for any DN.
+func (c *conn) bind(dn, password string) error {
+	if password == "" {
+		return &ResultError{Code: resultInvalidCreds, Message: "empty password"}
+	}
+	id, err := c.send(seq(opBindRequest,
+		integer(tagInteger, 3),
+		octets(tagOctetString, dn),
+		octets(classContext|0, password),
+	))
+	if err != nil {
+		return err
+	}
+	op, err := c.receive(id)
+	if err != nil {
+		return err
+	}
+	if op.tag != opBindResponse {
+		return errors.New("ldap: unexpected response to bind")
+	}
+	return result(op)
+}
+
+// search runs a subtree search and returns the entries found.
+func (c *conn) search(baseDN, filter string, attributes []string) ([]Entry, error) {
+	encoded, err := compileFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	var attrs [][]byte
+	for _, a := range attributes {
+		attrs = append(attrs, octets(tagOctetString, a))
+	}
+	id, err := c.send(seq(opSearchRequest,
+		octets(tagOctetString, baseDN),
+		integer(tagEnumerated, scopeWholeSubtree),
+		integer(tagEnumerated, derefNever),
+		integer(tagInteger, searchSizeLimit),
+		integer(tagInteger, searchTimeLimitSecs),
+		boolean(tagBoolean, false),
+		encoded,
+		seq(tagSequence, attrs...),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for {
+		op, err := c.receive(id)
+		if err != nil {
+			return nil, err
+		}
+		switch op.tag {
+		case opSearchEntry:
+			entry, err := decodeEntry(op)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		case opSearchReference:
+			// Referrals to other servers are not followed
+		case opSearchDone:
+			return entries, result(op)
+		default:
+			return nil, fmt.Errorf("ldap: unexpected response 0x%02x to search", op.tag)
+		}
+	}
+}
+
+func decodeEntry(op element) (Entry, error) {
+	parts, err := op.children()
+	if err != nil || len(parts) < 2 {
+		return Entry{}, errors.New("ldap: malformed search entry")
+	}
+	entry := Entry{DN: parts[0].str(), Attributes: map[string][]string{}}
+	attrs, err := parts[1].children()
+	if err != nil {
+		return Entry{}, err
+	}
+	for _, a := range attrs {
+		pair, err := a.children()
+		if err != nil || len(pair) < 2 {
+			return Entry{}, errors.New("ldap: malformed attribute")
+		}
+		vals, err := pair[1].children()
+		if err != nil {
+			return Entry{}, err
+		}
+		name := strings.ToLower(pair[0].str())
+		for _, v := range vals {
+			entry.Attributes[name] = append(entry.Attributes[name], v.str())
+		}
+	}
+	return entry, nil
+}
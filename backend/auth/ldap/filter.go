@@ -0,0 +1,226 @@
+package ldap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Filter choice tags (RFC 4511 section 4.5.1).
+const (
+	filterAnd        = classContext | constructed | 0
+	filterOr         = classContext | constructed | 1
+	filterNot        = classContext | constructed | 2
+	filterEquality   = classContext | constructed | 3
+	filterSubstrings = classContext | constructed | 4
+	filterGreater    = classContext | constructed | 5
+	filterLess       = classContext | constructed | 6
+	filterPresent    = classContext | 7
+	filterApprox     = classContext | constructed | 8
+	filterExtensible = classContext | constructed | 9
+)
+
+// EscapeFilter escapes a value for use inside a search filter (RFC 4515), so user input such
+// as a login name cannot change the filter's meaning.
+func EscapeFilter(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', '*', '(', ')', 0:
+			fmt.Fprintf(&b, "\\%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// compileFilter encodes a string filter such as "(&(objectClass=user)(sAMAccountName=jdoe))".
+func compileFilter(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "(") {
+		s = "(" + s + ")" // Allow the outer parentheses to be left out
+	}
+	p := &filterParser{s: s}
+	out, err := p.filter()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.s) {
+		return nil, fmt.Errorf("ldap: unexpected %q at the end of filter %q", p.s[p.pos:], s)
+	}
+	return out, nil
+}
+
+type filterParser struct {
+	s   string
+	pos int
+}
+
+func (p *filterParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("ldap: invalid filter %q at offset %d: %s", p.s, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *filterParser) filter() ([]byte, error) {
+	if p.pos >= len(p.s) || p.s[p.pos] != '(' {
+		return nil, p.errorf("expected (")
+	}
+	p.pos++
+	if p.pos >= len(p.s) {
+		return nil, p.errorf("unexpected end")
+	}
+	var out []byte
+	var err error
+	switch p.s[p.pos] {
+	case '&', '|':
+		tag := byte(filterAnd)
+		if p.s[p.pos] == '|' {
+			tag = filterOr
+		}
+		p.pos++
+		var parts [][]byte
+		for p.pos < len(p.s) && p.s[p.pos] == '(' {
+			part, err := p.filter()
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, part)
+		}
+		out = seq(tag, parts...)
+	case '!':
+		p.pos++
+		var inner []byte
+		if inner, err = p.filter(); err != nil {
+			return nil, err
+		}
+		out = seq(filterNot, inner)
+	default:
+		end := strings.IndexByte(p.s[p.pos:], ')')
+		if end < 0 {
+			return nil, p.errorf("missing )")
+		}
+		if out, err = p.item(p.s[p.pos : p.pos+end]); err != nil {
+			return nil, err
+		}
+		p.pos += end
+	}
+	if p.pos >= len(p.s) || p.s[p.pos] != ')' {
+		return nil, p.errorf("expected )")
+	}
+	p.pos++
+	return out, nil
+}
+
+// item encodes a simple filter: attr=value, attr=*, attr=a*b*c, attr>=v, attr<=v, attr~=v, or
+// an extensible match such as member:1.2.840.113556.1.4.1941:=<dn>.
+func (p *filterParser) item(s string) ([]byte, error) {
+	i := strings.IndexByte(s, '=')
+	if i <= 0 {
+		return nil, p.errorf("expected attribute=value")
+	}
+	if s[i-1] == ':' {
+		return p.extensible(s[:i-1], s[i+1:])
+	}
+	attr, value := s[:i], s[i+1:]
+	tag := byte(filterEquality)
+	switch attr[len(attr)-1] {
+	case '>':
+		tag, attr = filterGreater, attr[:len(attr)-1]
+	case '<':
+		tag, attr = filterLess, attr[:len(attr)-1]
+	case '~':
+		tag, attr = filterApprox, attr[:len(attr)-1]
+	}
+	if tag == filterEquality && value == "*" {
+		return octets(filterPresent, attr), nil
+	}
+	if tag == filterEquality && strings.Contains(value, "*") {
+		return p.substrings(attr, value)
+	}
+	v, err := unescapeFilterValue(value)
+	if err != nil {
+		return nil, p.errorf("%v", err)
+	}
+	return seq(tag, octets(tagOctetString, attr), octets(tagOctetString, v)), nil
+}
+
+func (p *filterParser) substrings(attr, value string) ([]byte, error) {
+	pieces := strings.Split(value, "*")
+	var subs [][]byte
+	for i, piece := range pieces {
+		if piece == "" {
+			continue
+		}
+		v, err := unescapeFilterValue(piece)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		tag := byte(classContext | 1) // any
+		switch i {
+		case 0:
+			tag = classContext | 0 // initial
+		case len(pieces) - 1:
+			tag = classContext | 2 // final
+		}
+		subs = append(subs, octets(tag, v))
+	}
+	return seq(filterSubstrings, octets(tagOctetString, attr), seq(tagSequence, subs...)), nil
+}
+
+func (p *filterParser) extensible(left, value string) ([]byte, error) {
+	var attr, rule string
+	dnAttributes := false
+	for i, part := range strings.Split(left, ":") {
+		switch {
+		case i == 0:
+			attr = part
+		case strings.EqualFold(part, "dn"):
+			dnAttributes = true
+		case part != "":
+			rule = part
+		}
+	}
+	if attr == "" && rule == "" {
+		return nil, p.errorf("extensible match needs an attribute or a matching rule")
+	}
+	v, err := unescapeFilterValue(value)
+	if err != nil {
+		return nil, p.errorf("%v", err)
+	}
+	var parts [][]byte
+	if rule != "" {
+		parts = append(parts, octets(classContext|1, rule))
+	}
+	if attr != "" {
+		parts = append(parts, octets(classContext|2, attr))
+	}
+	parts = append(parts, octets(classContext|3, v))
+	if dnAttributes {
+		parts = append(parts, boolean(classContext|4, true))
+	}
+	return seq(filterExtensible, parts...), nil
+}
+
+// unescapeFilterValue decodes \XX escapes.
+func unescapeFilterValue(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+3 > len(s) {
+			return "", fmt.Errorf("truncated escape in %q", s)
+		}
+		c, err := hex.DecodeString(s[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in %q", s)
+		}
+		b.Write(c)
+		i += 2
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,216 @@
+// Package ldap authenticates users against an LDAP directory or Active Directory with simple
+// binds and looks up their groups, so directory groups can be mapped to K-View roles.
+//
+// It speaks the small subset of LDAPv3 needed for that (bind, search, StartTLS) and has no
+// dependencies beyond the standard library.
+package ldap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCredentials is returned for an unknown user or a wrong password.
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// Config describes the directory. Filters use {username} and {dn} placeholders, which are
+// escaped before substitution.
+type Config struct {
+	URL                string // ldap://host:389 or ldaps://host:636
+	StartTLS           bool
+	InsecureSkipVerify bool
+	CAFile             string
+	// BindDN and BindPassword are the service account used to find users and groups
+	BindDN       string
+	BindPassword string
+	UserBaseDN   string
+	UserFilter   string
+	// EmailAttribute names the attribute used as the K-View identity (RBAC user entries)
+	EmailAttribute string
+	// GroupBaseDN enables a group search; without it groups come from the user's memberOf
+	GroupBaseDN        string
+	GroupFilter        string
+	GroupNameAttribute string
+	Timeout            time.Duration
+}
+
+// ConfigFromEnv reads the KVIEW_LDAP_* environment variables. It returns nil when LDAP is not
+// configured.
+func ConfigFromEnv() (*Config, error) {
+	cfg := &Config{
+		URL:                os.Getenv("KVIEW_LDAP_URL"),
+		StartTLS:           os.Getenv("KVIEW_LDAP_START_TLS") == "true",
+		InsecureSkipVerify: os.Getenv("KVIEW_LDAP_INSECURE_SKIP_VERIFY") == "true",
+		CAFile:             os.Getenv("KVIEW_LDAP_CA_FILE"),
+		BindDN:             os.Getenv("KVIEW_LDAP_BIND_DN"),
+		BindPassword:       os.Getenv("KVIEW_LDAP_BIND_PASSWORD"),
+		UserBaseDN:         os.Getenv("KVIEW_LDAP_USER_BASE_DN"),
+		UserFilter:         os.Getenv("KVIEW_LDAP_USER_FILTER"),
+		EmailAttribute:     os.Getenv("KVIEW_LDAP_EMAIL_ATTRIBUTE"),
+		GroupBaseDN:        os.Getenv("KVIEW_LDAP_GROUP_BASE_DN"),
+		GroupFilter:        os.Getenv("KVIEW_LDAP_GROUP_FILTER"),
+		GroupNameAttribute: os.Getenv("KVIEW_LDAP_GROUP_NAME_ATTRIBUTE"),
+		Timeout:            10 * time.Second,
+	}
+	if cfg.URL == "" {
+		return nil, nil
+	}
+	if cfg.UserBaseDN == "" {
+		return nil, errors.New("KVIEW_LDAP_USER_BASE_DN is required")
+	}
+	// Defaults that work for both Active Directory and OpenLDAP
+	if cfg.UserFilter == "" {
+		cfg.UserFilter = "(|(sAMAccountName={username})(uid={username}))"
+	}
+	if cfg.EmailAttribute == "" {
+		cfg.EmailAttribute = "mail"
+	}
+	if cfg.GroupFilter == "" {
+		cfg.GroupFilter = "(|(member={dn})(uniqueMember={dn}))"
+	}
+	if cfg.GroupNameAttribute == "" {
+		cfg.GroupNameAttribute = "cn"
+	}
+	if _, err := compileFilter(strings.NewReplacer("{username}", "x", "{dn}", "x").Replace(cfg.UserFilter)); err != nil {
+		return nil, fmt.Errorf("KVIEW_LDAP_USER_FILTER: %v", err)
+	}
+	if _, err := compileFilter(strings.NewReplacer("{username}", "x", "{dn}", "x").Replace(cfg.GroupFilter)); err != nil {
+		return nil, fmt.Errorf("KVIEW_LDAP_GROUP_FILTER: %v", err)
+	}
+	return cfg, nil
+}
+
+// User is an authenticated directory user.
+type User struct {
+	DN       string
+	Username string
+	Email    string // Falls back to the username when the directory has no email attribute
+	Groups   []string
+}
+
+// Authenticator verifies credentials against the directory.
+type Authenticator struct {
+	cfg Config
+	tls *tls.Config
+}
+
+func New(cfg Config) (*Authenticator, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read LDAP CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return &Authenticator{cfg: cfg, tls: tlsConfig}, nil
+}
+
+// URL returns the directory address, for logs.
+func (a *Authenticator) URL() string {
+	return a.cfg.URL
+}
+
+// Authenticate finds the user with the service account, proves the password by binding as the
+// user, and then reads their groups.
+func (a *Authenticator) Authenticate(username, password string) (*User, error) {
+	username = strings.TrimSpace(username)
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+	c, err := dial(a.cfg.URL, a.cfg.StartTLS, a.tls, a.cfg.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to LDAP: %v", err)
+	}
+	defer c.close()
+
+	if err := a.serviceBind(c); err != nil {
+		return nil, err
+	}
+	filter := strings.ReplaceAll(a.cfg.UserFilter, "{username}", EscapeFilter(username))
+	entries, err := c.search(a.cfg.UserBaseDN, filter, []string{a.cfg.EmailAttribute, "memberOf"})
+	if err != nil {
+		return nil, fmt.Errorf("LDAP user search failed: %v", err)
+	}
+	if len(entries) != 1 {
+		if len(entries) > 1 {
+			return nil, fmt.Errorf("LDAP user filter matched %d entries for %q", len(entries), username)
+		}
+		return nil, ErrInvalidCredentials
+	}
+	entry := entries[0]
+
+	if err := c.bind(entry.DN, password); err != nil {
+		var re *ResultError
+		if errors.As(err, &re) && re.Code == resultInvalidCreds {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("LDAP bind failed: %v", err)
+	}
+
+	user := &User{DN: entry.DN, Username: username, Email: entry.Get(a.cfg.EmailAttribute)}
+	if user.Email == "" {
+		user.Email = username
+	}
+	if a.cfg.GroupBaseDN == "" {
+		for _, dn := range entry.Attributes["memberof"] {
+			user.Groups = append(user.Groups, firstRDNValue(dn))
+		}
+		return user, nil
+	}
+
+	// Back to the service account: users often cannot search groups themselves
+	if err := a.serviceBind(c); err != nil {
+		return nil, err
+	}
+	filter = strings.ReplaceAll(a.cfg.GroupFilter, "{dn}", EscapeFilter(entry.DN))
+	filter = strings.ReplaceAll(filter, "{username}", EscapeFilter(username))
+	groups, err := c.search(a.cfg.GroupBaseDN, filter, []string{a.cfg.GroupNameAttribute})
+	if err != nil {
+		return nil, fmt.Errorf("LDAP group search failed: %v", err)
+	}
+	for _, g := range groups {
+		if name := g.Get(a.cfg.GroupNameAttribute); name != "" {
+			user.Groups = append(user.Groups, name)
+		}
+	}
+	return user, nil
+}
+
+func (a *Authenticator) serviceBind(c *conn) error {
+	if a.cfg.BindDN == "" {
+		return nil // Anonymous search
+	}
+	if err := c.bind(a.cfg.BindDN, a.cfg.BindPassword); err != nil {
+		return fmt.Errorf("LDAP service account bind failed: %v", err)
+	}
+	return nil
+}
+
+// firstRDNValue returns "Admins" for "CN=Admins,OU=Groups,DC=corp,DC=example".
+func firstRDNValue(dn string) string {
+	rdn := dn
+	for i := 0; i < len(dn); i++ {
+		if dn[i] == '\\' {
+			i++
+			continue
+		}
+		if dn[i] == ',' {
+			rdn = dn[:i]
+			break
+		}
+	}
+	if _, value, ok := strings.Cut(rdn, "="); ok {
+		return strings.ReplaceAll(value, "\\", "")
+	}
+	return dn
+}
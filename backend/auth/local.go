@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -15,11 +16,18 @@ import (
 type StaticUser struct {
 	Username     string `json:"username" yaml:"username"`
 	PasswordHash string `json:"password_hash" yaml:"password_hash"`
+	Disabled     bool   `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+	// PasswordChangedAt invalidates sessions issued before a password change or reset
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty" yaml:"-"`
 }
 
+// MinPasswordLength is enforced when passwords are set through the API.
+const MinPasswordLength = 10
+
 // LocalAuthenticator handles checking credentials against a local static list.
 type LocalAuthenticator struct {
-	Users      map[string]StaticUser
+	mu         sync.RWMutex
+	users      map[string]StaticUser
 	JWTSecret  []byte
 }
 
@@ -34,7 +42,7 @@ func NewLocalAuthenticator(jwtSecret string) (*LocalAuthenticator, error) {
 	}
 
 	auth := &LocalAuthenticator{
-		Users:     make(map[string]StaticUser),
+		users:     make(map[string]StaticUser),
 		JWTSecret: []byte(jwtSecret),
 	}
 
@@ -47,13 +55,23 @@ func NewLocalAuthenticator(jwtSecret string) (*LocalAuthenticator, error) {
 
 // LoadUsers loads static users from KVIEW_STATIC_USERS env var or users.yaml
 func (a *LocalAuthenticator) LoadUsers() error {
+	usersList, err := ConfiguredUsers()
+	if err != nil {
+		return err
+	}
+	a.SetUsers(usersList)
+	return nil
+}
+
+// ConfiguredUsers reads the static users from KVIEW_STATIC_USERS or users.yaml.
+func ConfiguredUsers() ([]StaticUser, error) {
 	var usersList []StaticUser
 
 	// 1. Try environment variable first (JSON format)
 	envUsers := os.Getenv("KVIEW_STATIC_USERS")
 	if envUsers != "" {
 		if err := json.Unmarshal([]byte(envUsers), &usersList); err != nil {
-			return fmt.Errorf("invalid JSON in KVIEW_STATIC_USERS: %v", err)
+			return nil, fmt.Errorf("invalid JSON in KVIEW_STATIC_USERS: %v", err)
 		}
 	} else {
 		// 2. Fallback to YAML file if env is empty
@@ -68,27 +86,59 @@ func (a *LocalAuthenticator) LoadUsers() error {
 				Users []StaticUser `yaml:"users"`
 			}
 			if err := yaml.Unmarshal(data, &yamlConfig); err != nil {
-				return fmt.Errorf("invalid YAML in %s: %v", yamlPath, err)
+				return nil, fmt.Errorf("invalid YAML in %s: %v", yamlPath, err)
 			}
 			usersList = yamlConfig.Users
 		} else if !os.IsNotExist(err) {
-			return fmt.Errorf("error reading %s: %v", yamlPath, err)
+			return nil, fmt.Errorf("error reading %s: %v", yamlPath, err)
 		}
 	}
 
-	// Populate map
-	a.Users = make(map[string]StaticUser, len(usersList))
+	return usersList, nil
+}
+
+// SetUsers replaces the set of users that can sign in.
+func (a *LocalAuthenticator) SetUsers(usersList []StaticUser) {
+	users := make(map[string]StaticUser, len(usersList))
 	for _, u := range usersList {
-		a.Users[u.Username] = u
+		users[u.Username] = u
 	}
+	a.mu.Lock()
+	a.users = users
+	a.mu.Unlock()
+}
 
-	return nil
+// User returns a user by name.
+func (a *LocalAuthenticator) User(username string) (StaticUser, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	u, ok := a.users[username]
+	return u, ok
+}
+
+// Count returns the number of users, including disabled ones.
+func (a *LocalAuthenticator) Count() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.users)
+}
+
+// HashPassword returns the bcrypt hash of a password.
+func HashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
 }
 
 // Authenticate checks if a given plaintext password matches the stored bcrypt hash for the username.
 func (a *LocalAuthenticator) Authenticate(username, password string) bool {
-	user, exists := a.Users[username]
-	if !exists {
+	user, exists := a.User(username)
+	if !exists || user.Disabled {
 		return false
 	}
 
@@ -96,14 +146,31 @@ func (a *LocalAuthenticator) Authenticate(username, password string) bool {
 	return err == nil
 }
 
+// Session is the identity carried by a K-View issued JWT.
+type Session struct {
+	Username string
+	Provider string   // "" for local users, "ldap" for directory users
+	Groups   []string // Directory groups, for RBAC group assignments
+}
+
 // GenerateJWT creates a new JWT token for a successfully authenticated user.
 func (a *LocalAuthenticator) GenerateJWT(username string) (string, error) {
+	return a.GenerateSessionJWT(Session{Username: username})
+}
+
+// GenerateSessionJWT creates a JWT for a user authenticated by K-View itself, locally or
+// against a directory.
+func (a *LocalAuthenticator) GenerateSessionJWT(session Session) (string, error) {
 	claims := jwt.MapClaims{
-		"username": username,
+		"username": session.Username,
 		"exp":      time.Now().Add(time.Hour * 24).Unix(), // 24 hours expiry
 		"iat":      time.Now().Unix(),
 		"iss":      "k-view-auth",
 	}
+	if session.Provider != "" {
+		claims["provider"] = session.Provider
+		claims["groups"] = session.Groups
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(a.JWTSecret)
@@ -111,6 +178,15 @@ func (a *LocalAuthenticator) GenerateJWT(username string) (string, error) {
 
 // VerifyJWT checks a token string and returns the username if valid.
 func (a *LocalAuthenticator) VerifyJWT(tokenString string) (string, error) {
+	session, err := a.VerifySession(tokenString)
+	if err != nil {
+		return "", err
+	}
+	return session.Username, nil
+}
+
+// VerifySession checks a token string and returns the session it carries.
+func (a *LocalAuthenticator) VerifySession(tokenString string) (*Session, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -119,15 +195,41 @@ func (a *LocalAuthenticator) VerifyJWT(tokenString string) (string, error) {
 	})
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		if username, ok := claims["username"].(string); ok {
-			return username, nil
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token claims")
+	}
+	username, ok := claims["username"].(string)
+	if !ok {
+		return nil, fmt.Errorf("jwt missing username claim")
+	}
+	session := &Session{Username: username}
+	if provider, _ := claims["provider"].(string); provider != "" {
+		// Directory users are checked by the directory at sign-in only, but a directory session
+		// never stands in for a local account of the same name
+		if _, local := a.User(username); local {
+			return nil, fmt.Errorf("%s session for local account %q", provider, username)
 		}
-		return "", fmt.Errorf("jwt missing username claim")
+		session.Provider = provider
+		groups, _ := claims["groups"].([]interface{})
+		for _, g := range groups {
+			if name, ok := g.(string); ok {
+				session.Groups = append(session.Groups, name)
+			}
+		}
+		return session, nil
 	}
 
-	return "", fmt.Errorf("invalid token claims")
+	// Sessions end when the account is disabled or its password changes
+	user, exists := a.User(username)
+	if !exists || user.Disabled {
+		return nil, fmt.Errorf("user %s is disabled or no longer exists", username)
+	}
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil && user.PasswordChangedAt != nil && iat.Unix() < user.PasswordChangedAt.Unix() {
+		return nil, fmt.Errorf("token was issued before the last password change")
+	}
+	return session, nil
 }
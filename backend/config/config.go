@@ -0,0 +1,346 @@
+// Package config loads K-View's settings from a config.yaml file. Every setting also has an
+// environment variable, which takes precedence over the file, so existing deployments keep
+// working and secrets can stay in the environment. The file's values are exported to the
+// environment of variables that are unset, which is where the rest of the code reads them.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultPath is read when KVIEW_CONFIG_PATH is not set; it may be missing.
+const DefaultPath = "/etc/kview/config.yaml"
+
+// Value types, which decide how a setting is validated and how a YAML value is turned into
+// the string form of its environment variable.
+const (
+	String   = "string"
+	Bool     = "bool"
+	Int      = "int"      // Non-negative
+	Duration = "duration" // Go duration such as 90s or 168h; "0" where it disables something
+	Ratio    = "ratio"    // Number between 0 and 1
+	URL      = "url"      // Absolute URL
+	List     = "list"     // YAML list or comma-separated string
+	CIDRs    = "cidrs"    // List of CIDRs or bare addresses
+	Pairs    = "pairs"    // YAML map or comma-separated key=value pairs
+	JSON     = "json"     // Any YAML value, passed on as JSON
+)
+
+// Setting is one configuration value: its key in config.yaml, its environment variable and
+// its default as the code applies it ("" when there is none or it is computed).
+type Setting struct {
+	Key     string
+	Env     string
+	Type    string
+	Default string
+	Secret  bool
+}
+
+// Settings are all of K-View's settings. Keys are the dotted path in config.yaml.
+var Settings = []Setting{
+	{Key: "server.port", Env: "PORT", Type: Int, Default: "8080"},
+	{Key: "server.basePath", Env: "KVIEW_BASE_PATH", Type: String},
+	{Key: "server.devMode", Env: "DEV_MODE", Type: Bool, Default: "false"},
+	{Key: "server.dataDir", Env: "KVIEW_DATA_DIR", Type: String, Default: "/data"},
+	{Key: "server.databaseURL", Env: "KVIEW_DATABASE_URL", Type: String, Secret: true},
+	{Key: "server.namespace", Env: "KVIEW_NAMESPACE", Type: String},
+	{Key: "clusters.localName", Env: "KVIEW_CLUSTER_NAME", Type: String, Default: "local"},
+	{Key: "clusters.kubeconfig", Env: "KVIEW_CLUSTERS_KUBECONFIG", Type: String},
+	{Key: "kubernetes.timeout", Env: "KVIEW_K8S_TIMEOUT", Type: Duration, Default: "30s"},
+	{Key: "kubernetes.qps", Env: "KVIEW_K8S_QPS", Type: Int, Default: "5"},
+	{Key: "kubernetes.burst", Env: "KVIEW_K8S_BURST", Type: Int, Default: "10"},
+	{Key: "server.readOnly", Env: "KVIEW_READ_ONLY", Type: Bool, Default: "false"},
+	{Key: "server.disabledFeatures", Env: "KVIEW_DISABLED_FEATURES", Type: List},
+	{Key: "rbac.configPath", Env: "RBAC_CONFIG_PATH", Type: String, Default: "/etc/kview/rbac/assignments.yaml"},
+	{Key: "policy.configPath", Env: "KVIEW_POLICY_CONFIG_PATH", Type: String, Default: "/etc/kview/policy/policies.yaml"},
+	{Key: "columns.configPath", Env: "KVIEW_COLUMNS_CONFIG_PATH", Type: String, Default: "/etc/kview/columns/columns.yaml"},
+
+	{Key: "auth.authorizedUsers", Env: "KVIEW_AUTHORIZED_USERS", Type: List},
+	{Key: "auth.jwtSecret", Env: "KVIEW_JWT_SECRET", Type: String, Secret: true},
+	{Key: "auth.staticUsers", Env: "KVIEW_STATIC_USERS", Type: JSON, Secret: true},
+	{Key: "auth.usersFile", Env: "KVIEW_AUTH_FILE_PATH", Type: String, Default: "/etc/kview/auth/users.yaml"},
+	{Key: "auth.oidc.enabled", Env: "KVIEW_ENABLE_SSO", Type: Bool, Default: "false"},
+	{Key: "auth.oidc.issuer", Env: "KVIEW_OIDC_ISSUER", Type: URL, Default: "https://accounts.google.com"},
+	{Key: "auth.oidc.clientId", Env: "KVIEW_GOOGLE_CLIENT_ID", Type: String},
+	{Key: "auth.oidc.clientSecret", Env: "KVIEW_GOOGLE_CLIENT_SECRET", Type: String, Secret: true},
+	{Key: "auth.oidc.redirectUrl", Env: "KVIEW_OAUTH_REDIRECT_URL", Type: URL},
+	{Key: "auth.oidc.endSessionUrl", Env: "KVIEW_OIDC_END_SESSION_URL", Type: URL},
+	{Key: "auth.oidc.postLogoutRedirectUrl", Env: "KVIEW_OIDC_POST_LOGOUT_REDIRECT_URL", Type: URL},
+	{Key: "auth.proxy.enabled", Env: "KVIEW_AUTH_PROXY_ENABLED", Type: Bool, Default: "false"},
+	{Key: "auth.proxy.userHeader", Env: "KVIEW_AUTH_PROXY_USER_HEADER", Type: String, Default: "X-Forwarded-User"},
+	{Key: "auth.proxy.groupsHeader", Env: "KVIEW_AUTH_PROXY_GROUPS_HEADER", Type: String, Default: "X-Forwarded-Groups"},
+	{Key: "auth.proxy.trustedCidrs", Env: "KVIEW_AUTH_PROXY_TRUSTED_CIDRS", Type: CIDRs},
+	{Key: "auth.proxy.logoutUrl", Env: "KVIEW_AUTH_PROXY_LOGOUT_URL", Type: URL},
+	{Key: "auth.ldap.url", Env: "KVIEW_LDAP_URL", Type: URL},
+	{Key: "auth.ldap.startTls", Env: "KVIEW_LDAP_START_TLS", Type: Bool, Default: "false"},
+	{Key: "auth.ldap.insecureSkipVerify", Env: "KVIEW_LDAP_INSECURE_SKIP_VERIFY", Type: Bool, Default: "false"},
+	{Key: "auth.ldap.caFile", Env: "KVIEW_LDAP_CA_FILE", Type: String},
+	{Key: "auth.ldap.bindDn", Env: "KVIEW_LDAP_BIND_DN", Type: String},
+	{Key: "auth.ldap.bindPassword", Env: "KVIEW_LDAP_BIND_PASSWORD", Type: String, Secret: true},
+	{Key: "auth.ldap.userBaseDn", Env: "KVIEW_LDAP_USER_BASE_DN", Type: String},
+	{Key: "auth.ldap.userFilter", Env: "KVIEW_LDAP_USER_FILTER", Type: String, Default: "(|(sAMAccountName={username})(uid={username}))"},
+	{Key: "auth.ldap.emailAttribute", Env: "KVIEW_LDAP_EMAIL_ATTRIBUTE", Type: String, Default: "mail"},
+	{Key: "auth.ldap.groupBaseDn", Env: "KVIEW_LDAP_GROUP_BASE_DN", Type: String},
+	{Key: "auth.ldap.groupFilter", Env: "KVIEW_LDAP_GROUP_FILTER", Type: String, Default: "(|(member={dn})(uniqueMember={dn}))"},
+	{Key: "auth.ldap.groupNameAttribute", Env: "KVIEW_LDAP_GROUP_NAME_ATTRIBUTE", Type: String, Default: "cn"},
+	{Key: "auth.anonymous.enabled", Env: "KVIEW_ANONYMOUS_ACCESS", Type: Bool, Default: "false"},
+	{Key: "auth.anonymous.namespaces", Env: "KVIEW_ANONYMOUS_NAMESPACES", Type: List},
+	{Key: "auth.anonymous.kinds", Env: "KVIEW_ANONYMOUS_KINDS", Type: List},
+
+	{Key: "terminal.idleTimeout", Env: "KVIEW_TERMINAL_IDLE_TIMEOUT", Type: Duration, Default: "15m"},
+	{Key: "terminal.maxSessions", Env: "KVIEW_TERMINAL_MAX_SESSIONS", Type: Int, Default: "5"},
+	{Key: "terminal.nodeShellNamespace", Env: "KVIEW_NODE_SHELL_NAMESPACE", Type: String, Default: "kube-system"},
+	{Key: "terminal.nodeShellImage", Env: "KVIEW_NODE_SHELL_IMAGE", Type: String, Default: "busybox:1.36"},
+	{Key: "serviceProxy.roles", Env: "KVIEW_SERVICE_PROXY_ROLES", Type: List, Default: "kview-cluster-admin,admin"},
+
+	{Key: "events.archive", Env: "KVIEW_EVENT_ARCHIVE", Type: Bool, Default: "false"},
+	{Key: "events.retention", Env: "KVIEW_EVENT_RETENTION", Type: Duration, Default: "168h"},
+	{Key: "audit.retention", Env: "KVIEW_AUDIT_RETENTION", Type: Duration, Default: "2160h"},
+	{Key: "audit.syslog", Env: "KVIEW_AUDIT_SYSLOG", Type: URL},
+	{Key: "audit.syslogFormat", Env: "KVIEW_AUDIT_SYSLOG_FORMAT", Type: String, Default: "cef"},
+	{Key: "retention.slowLog", Env: "KVIEW_SLOWLOG_RETENTION", Type: Duration, Default: "168h"},
+	{Key: "retention.sessions", Env: "KVIEW_SESSION_RETENTION", Type: Duration, Default: "24h"},
+	{Key: "retention.pruneInterval", Env: "KVIEW_PRUNE_INTERVAL", Type: Duration, Default: "1h"},
+	{Key: "server.cacheRefreshInterval", Env: "KVIEW_CACHE_REFRESH_INTERVAL", Type: Duration, Default: "5s"},
+	{Key: "slowLog.threshold", Env: "KVIEW_SLOW_REQUEST_THRESHOLD", Type: Duration, Default: "2s"},
+	{Key: "slowLog.routeThresholds", Env: "KVIEW_SLOW_ROUTE_THRESHOLDS", Type: Pairs},
+	{Key: "usage.sampleInterval", Env: "KVIEW_USAGE_SAMPLE_INTERVAL", Type: Duration, Default: "5m"},
+	{Key: "kubeStateMetrics.target", Env: "KVIEW_KSM_TARGET", Type: String},
+	{Key: "kubeStateMetrics.interval", Env: "KVIEW_KSM_INTERVAL", Type: Duration, Default: "1m"},
+
+	{Key: "smtp.address", Env: "KVIEW_SMTP_ADDR", Type: String},
+	{Key: "smtp.username", Env: "KVIEW_SMTP_USERNAME", Type: String},
+	{Key: "smtp.password", Env: "KVIEW_SMTP_PASSWORD", Type: String, Secret: true},
+	{Key: "smtp.from", Env: "KVIEW_SMTP_FROM", Type: String},
+	{Key: "slack.signingSecret", Env: "KVIEW_SLACK_SIGNING_SECRET", Type: String, Secret: true},
+
+	{Key: "tracing.endpoint", Env: "OTEL_EXPORTER_OTLP_ENDPOINT", Type: URL},
+	{Key: "tracing.tracesEndpoint", Env: "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", Type: URL},
+	{Key: "tracing.headers", Env: "OTEL_EXPORTER_OTLP_HEADERS", Type: Pairs, Secret: true},
+	{Key: "tracing.serviceName", Env: "OTEL_SERVICE_NAME", Type: String, Default: "k-view"},
+	{Key: "tracing.sampleRatio", Env: "OTEL_TRACES_SAMPLER_ARG", Type: Ratio, Default: "1"},
+}
+
+// Sources of an effective value.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+)
+
+// Entry is the effective value of a setting.
+type Entry struct {
+	Key    string `json:"key"`
+	Env    string `json:"env"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+// Config is the configuration K-View started with.
+type Config struct {
+	Path    string // The config file read; "" when there was none
+	entries []Entry
+}
+
+// Load reads the config file at path, or DefaultPath when path is empty, exports its values to
+// the environment variables that are not set, and validates every setting. A missing file is
+// only an error when the path was given explicitly. All problems are reported together.
+func Load(path string) (*Config, error) {
+	explicit := path != ""
+	if !explicit {
+		path = DefaultPath
+	}
+	file := map[string]string{}
+	var problems []string
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err) && !explicit:
+		path = ""
+	case err != nil:
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	default:
+		if file, problems, err = parse(data); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+
+	cfg := &Config{Path: path}
+	for _, s := range Settings {
+		e := Entry{Key: s.Key, Env: s.Env, Value: s.Default, Source: SourceDefault, Secret: s.Secret}
+		if v, ok := os.LookupEnv(s.Env); ok && v != "" {
+			e.Value, e.Source = v, SourceEnv
+		} else if v, ok := file[s.Key]; ok {
+			e.Value, e.Source = v, SourceFile
+			if err := os.Setenv(s.Env, v); err != nil {
+				return nil, err
+			}
+		}
+		if e.Source != SourceDefault && e.Value != "" {
+			if err := validate(s.Type, e.Value); err != nil {
+				where := s.Key
+				if e.Source == SourceEnv {
+					where = s.Env
+				}
+				problems = append(problems, fmt.Sprintf("%s: %v", where, err))
+			}
+		}
+		cfg.entries = append(cfg.entries, e)
+	}
+	if len(problems) > 0 {
+		return nil, errors.New("invalid configuration:\n  " + strings.Join(problems, "\n  "))
+	}
+	return cfg, nil
+}
+
+// parse flattens a config file to dotted keys with environment-variable values. Unknown keys
+// are reported as problems, so typos do not go unnoticed.
+func parse(data []byte) (map[string]string, []string, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+	types := map[string]string{}
+	for _, s := range Settings {
+		types[s.Key] = s.Type
+	}
+	values := map[string]string{}
+	var problems []string
+	var walk func(prefix string, node map[string]interface{})
+	walk = func(prefix string, node map[string]interface{}) {
+		for k, v := range node {
+			key := prefix + k
+			typ, known := types[key]
+			if child, ok := v.(map[string]interface{}); ok && (!known || typ != Pairs && typ != JSON) {
+				walk(key+".", child)
+				continue
+			}
+			if !known {
+				problems = append(problems, key+": unknown setting")
+				continue
+			}
+			if v == nil {
+				continue // An empty key keeps the default
+			}
+			s, err := envValue(typ, v)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+				continue
+			}
+			values[key] = s
+		}
+	}
+	walk("", doc)
+	sort.Strings(problems)
+	return values, problems, nil
+}
+
+// envValue turns a YAML value into the string its environment variable takes.
+func envValue(typ string, v interface{}) (string, error) {
+	switch typ {
+	case JSON:
+		b, err := json.Marshal(v)
+		return string(b), err
+	case List, CIDRs:
+		if items, ok := v.([]interface{}); ok {
+			parts := make([]string, 0, len(items))
+			for _, item := range items {
+				parts = append(parts, fmt.Sprint(item))
+			}
+			return strings.Join(parts, ","), nil
+		}
+	case Pairs:
+		if m, ok := v.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(m))
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			parts := make([]string, 0, len(keys))
+			for _, k := range keys {
+				parts = append(parts, fmt.Sprintf("%s=%v", k, m[k]))
+			}
+			return strings.Join(parts, ","), nil
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("expected a %s", typ)
+}
+
+// validate checks the string form of a value.
+func validate(typ, v string) error {
+	switch typ {
+	case Bool:
+		if v != "true" && v != "false" {
+			return fmt.Errorf("%q is not true or false", v)
+		}
+	case Int:
+		if n, err := strconv.Atoi(v); err != nil || n < 0 {
+			return fmt.Errorf("%q is not a non-negative number", v)
+		}
+	case Duration:
+		if d, err := time.ParseDuration(v); err != nil || d < 0 {
+			return fmt.Errorf("%q is not a duration such as 90s or 168h", v)
+		}
+	case Ratio:
+		if r, err := strconv.ParseFloat(v, 64); err != nil || r < 0 || r > 1 {
+			return fmt.Errorf("%q is not a ratio between 0 and 1", v)
+		}
+	case URL:
+		if u, err := url.Parse(v); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("%q is not an absolute URL", v)
+		}
+	case CIDRs:
+		for _, s := range strings.Split(v, ",") {
+			s = strings.TrimSpace(s)
+			if s == "" {
+				continue
+			}
+			if _, _, err := net.ParseCIDR(s); err != nil && net.ParseIP(s) == nil {
+				return fmt.Errorf("%q is not a CIDR or address", s)
+			}
+		}
+	case Pairs:
+		for _, pair := range strings.Split(v, ",") {
+			if strings.TrimSpace(pair) != "" && !strings.Contains(pair, "=") {
+				return fmt.Errorf("%q is not a key=value pair", pair)
+			}
+		}
+	case JSON:
+		if !json.Valid([]byte(v)) {
+			return errors.New("not valid JSON")
+		}
+	}
+	return nil
+}
+
+// Effective returns every setting with its effective value and where it came from. Secrets
+// that are set read "[redacted]".
+func (c *Config) Effective() []Entry {
+	entries := make([]Entry, 0, len(c.entries))
+	for _, e := range c.entries {
+		if e.Secret && e.Value != "" {
+			e.Value = "[redacted]"
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
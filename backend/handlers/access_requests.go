@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k-view/apierror"
+	"k-view/k8s"
+	"k-view/rbac"
+	"k-view/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const accessRequestsDoc = "namespace-access"
+
+var errAccessRequestNotFound = errors.New("access request not found")
+
+// accessLevels are the namespace roles users can ask for, from kview-namespace-<level>.
+var accessLevels = []string{"viewer", "developer", "admin"}
+
+// AccessRequest asks for a role in one namespace. Approved requests become RBAC assignments.
+type AccessRequest struct {
+	ID        string `json:"id"`
+	User      string `json:"user"`
+	Namespace string `json:"namespace"`
+	Level     string `json:"level"` // viewer, developer or admin
+	Reason    string `json:"reason"`
+	// Status is Pending, Approved, Denied or Revoked
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requestedAt"`
+	DecidedBy   string     `json:"decidedBy,omitempty"`
+	DecidedAt   *time.Time `json:"decidedAt,omitempty"`
+	Comment     string     `json:"comment,omitempty"`
+}
+
+func (r AccessRequest) assignment() rbac.Assignment {
+	return rbac.Assignment{User: r.User, Role: "kview-namespace-" + r.Level, Namespace: r.Namespace}
+}
+
+// AccessRequestHandler runs the namespace access workflow: a user asks for a role in a
+// namespace, an admin approves or denies it, and approved requests are applied to the RBAC
+// config (and, against a real cluster, as a RoleBinding) without editing the static config.
+//
+// K-View gives each user one role and namespace, so approving a request replaces the user's
+// earlier grant. Users with a static assignment by name are managed in the config only.
+type AccessRequestHandler struct {
+	mu        sync.Mutex
+	requests  []AccessRequest
+	store     *store.Store
+	rbac      *rbac.RBACConfig
+	k8sClient k8s.KubernetesProvider
+}
+
+func NewAccessRequestHandler(st *store.Store, config *rbac.RBACConfig, k8sClient k8s.KubernetesProvider) *AccessRequestHandler {
+	h := &AccessRequestHandler{store: st, rbac: config, k8sClient: k8sClient}
+	if err := st.Load(accessRequestsDoc, &h.requests); err != nil {
+		log.Printf("Failed to load access requests: %v", err)
+	}
+	h.applyGrants()
+	return h
+}
+
+func (h *AccessRequestHandler) Document() string { return accessRequestsDoc }
+
+// Reload refreshes the cached requests from the store and re-applies the approved ones.
+func (h *AccessRequestHandler) Reload() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var requests []AccessRequest
+	if err := h.store.Load(accessRequestsDoc, &requests); err != nil {
+		return err
+	}
+	h.requests = requests
+	h.applyGrants()
+	return nil
+}
+
+// applyGrants pushes the approved requests into the RBAC config. Callers hold h.mu, except
+// during construction.
+func (h *AccessRequestHandler) applyGrants() {
+	var granted []rbac.Assignment
+	for _, r := range h.requests {
+		if r.Status == "Approved" {
+			granted = append(granted, r.assignment())
+		}
+	}
+	h.rbac.SetGranted(granted)
+}
+
+// update applies fn to the stored requests, refreshes the cache and re-applies the grants.
+func (h *AccessRequestHandler) update(fn func([]AccessRequest) ([]AccessRequest, error)) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var stored []AccessRequest
+	err := h.store.Update(accessRequestsDoc, &stored, func() error {
+		var err error
+		stored, err = fn(stored)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	h.requests = stored
+	h.applyGrants()
+	return nil
+}
+
+// accessBindingName names the RoleBinding that carries a user's granted role.
+func accessBindingName(user string) string {
+	return k8s.NameFor("kview-access-", user)
+}
+
+// Request files an access request for the current user.
+func (h *AccessRequestHandler) Request(c *gin.Context) {
+	var req struct {
+		Namespace string `json:"namespace" binding:"required"`
+		Level     string `json:"level"`
+		Reason    string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "namespace and reason are required")
+		return
+	}
+	if req.Level == "" {
+		req.Level = "viewer"
+	}
+	if !contains(accessLevels, req.Level) {
+		apierror.Write(c, http.StatusBadRequest, "level must be one of "+strings.Join(accessLevels, ", "))
+		return
+	}
+
+	email, _ := c.Get("email")
+	user, _ := email.(string)
+	role, _ := c.Get("role")
+	if isAdminRole(role.(string)) {
+		apierror.Write(c, http.StatusBadRequest, "admins already have full access")
+		return
+	}
+	if h.rbac.HasUserAssignment(user) {
+		apierror.Write(c, http.StatusConflict, "your access is set in the RBAC configuration; ask an admin to change it there")
+		return
+	}
+
+	r := AccessRequest{ID: newID(), User: user, Namespace: req.Namespace, Level: req.Level, Reason: req.Reason, Status: "Pending", RequestedAt: time.Now().UTC()}
+	err := h.update(func(all []AccessRequest) ([]AccessRequest, error) {
+		for _, existing := range all {
+			if existing.User == user && existing.Status == "Pending" {
+				return nil, errors.New("you already have a pending access request")
+			}
+			if existing.User == user && existing.Status == "Approved" && existing.Namespace == req.Namespace && existing.Level == req.Level {
+				return nil, errors.New("you already have this access")
+			}
+		}
+		return append(all, r), nil
+	})
+	if err != nil {
+		apierror.Write(c, http.StatusConflict, err.Error())
+		return
+	}
+	auditf("namespace access requested by %s: namespace=%s level=%s reason=%q", user, r.Namespace, r.Level, r.Reason)
+	c.JSON(http.StatusCreated, r)
+}
+
+// List returns the caller's own requests.
+func (h *AccessRequestHandler) List(c *gin.Context) {
+	email, _ := c.Get("email")
+	h.list(c, email.(string))
+}
+
+// AdminList returns everyone's requests (admin only). ?status=Pending shows the queue.
+func (h *AccessRequestHandler) AdminList(c *gin.Context) {
+	h.list(c, "")
+}
+
+func (h *AccessRequestHandler) list(c *gin.Context, user string) {
+	filter := c.Query("status")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	result := []AccessRequest{}
+	for _, r := range h.requests {
+		if (user != "" && r.User != user) || (filter != "" && r.Status != filter) {
+			continue
+		}
+		result = append(result, r)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].RequestedAt.After(result[j].RequestedAt) })
+	c.JSON(http.StatusOK, result)
+}
+
+// find returns a copy of a request.
+func (h *AccessRequestHandler) find(id string) (AccessRequest, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.requests {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return AccessRequest{}, false
+}
+
+// decide moves a request from one state to another (admin only), recording who decided and
+// why, and keeps the user's RoleBinding in line with the outcome.
+func (h *AccessRequestHandler) decide(c *gin.Context, from, to string) {
+	id := c.Param("id")
+	var body struct {
+		Comment string `json:"comment"`
+	}
+	_ = c.ShouldBindJSON(&body) // The comment is optional
+	email, _ := c.Get("email")
+	admin, _ := email.(string)
+
+	r, ok := h.find(id)
+	if !ok {
+		apierror.Write(c, http.StatusNotFound, "access request "+id+" not found")
+		return
+	}
+	if r.Status != from {
+		apierror.Write(c, http.StatusConflict, "request is "+r.Status+", not "+from)
+		return
+	}
+
+	// Kubernetes RBAC first: a grant K-View shows but the cluster refuses helps nobody
+	if binder, ok := h.k8sClient.(k8s.RoleBinder); ok {
+		var err error
+		switch to {
+		case "Approved":
+			err = binder.Bind(c.Request.Context(), r.Namespace, accessBindingName(r.User), r.assignment().Role, []string{r.User}, nil)
+		case "Revoked":
+			err = binder.Unbind(c.Request.Context(), r.Namespace, accessBindingName(r.User))
+		}
+		if err != nil {
+			apierror.Fail(c, "", err)
+			return
+		}
+	}
+
+	var decided AccessRequest
+	var replaced []AccessRequest
+	err := h.update(func(all []AccessRequest) ([]AccessRequest, error) {
+		now := time.Now().UTC()
+		found := false
+		for i := range all {
+			if all[i].ID != id {
+				continue
+			}
+			if all[i].Status != from {
+				return nil, errors.New("request is " + all[i].Status + ", not " + from)
+			}
+			all[i].Status = to
+			all[i].DecidedBy = admin
+			all[i].DecidedAt = &now
+			all[i].Comment = body.Comment
+			decided = all[i]
+			found = true
+		}
+		if !found {
+			return nil, errAccessRequestNotFound
+		}
+		if to != "Approved" {
+			return all, nil
+		}
+		// One grant per user: the new one replaces any earlier one
+		for i := range all {
+			if all[i].User == decided.User && all[i].Status == "Approved" && all[i].ID != id {
+				all[i].Status = "Revoked"
+				all[i].DecidedBy = admin
+				all[i].DecidedAt = &now
+				all[i].Comment = "replaced by request " + id
+				replaced = append(replaced, all[i])
+			}
+		}
+		return all, nil
+	})
+	if err == errAccessRequestNotFound {
+		apierror.Write(c, http.StatusNotFound, "access request "+id+" not found")
+		return
+	}
+	if err != nil {
+		apierror.Write(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	for _, old := range replaced {
+		auditf("namespace access Revoked for %s by %s: namespace=%s level=%s (replaced)", old.User, admin, old.Namespace, old.Level)
+		if binder, ok := h.k8sClient.(k8s.RoleBinder); ok && old.Namespace != decided.Namespace {
+			if err := binder.Unbind(c.Request.Context(), old.Namespace, accessBindingName(old.User)); err != nil {
+				log.Printf("Failed to remove the role binding of replaced access request %s: %v", old.ID, err)
+			}
+		}
+	}
+	auditf("namespace access %s for %s by %s: namespace=%s level=%s comment=%q", to, decided.User, admin, decided.Namespace, decided.Level, decided.Comment)
+	c.JSON(http.StatusOK, decided)
+}
+
+// Approve grants a pending request.
+func (h *AccessRequestHandler) Approve(c *gin.Context) { h.decide(c, "Pending", "Approved") }
+
+// Deny rejects a pending request.
+func (h *AccessRequestHandler) Deny(c *gin.Context) { h.decide(c, "Pending", "Denied") }
+
+// Revoke removes an approved grant.
+func (h *AccessRequestHandler) Revoke(c *gin.Context) { h.decide(c, "Approved", "Revoked") }
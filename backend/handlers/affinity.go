@@ -0,0 +1,340 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"k-view/apierror"
+)
+
+// NodeEligibility explains whether a workload's pods can be scheduled onto one node.
+type NodeEligibility struct {
+	Node           string   `json:"node"`
+	OS             string   `json:"os"` // linux or windows
+	Eligible       bool     `json:"eligible"`
+	Reasons        []string `json:"reasons"`        // Why the node is excluded
+	PreferredScore int32    `json:"preferredScore"` // Sum of matching preferred term weights
+}
+
+type SchedulingReport struct {
+	Kind     string            `json:"kind"`
+	Name     string            `json:"name"`
+	Eligible int               `json:"eligible"`
+	Nodes    []NodeEligibility `json:"nodes"`
+}
+
+// matchNodeRequirement evaluates one NodeSelectorRequirement against a set of labels (or fields).
+func matchNodeRequirement(req corev1.NodeSelectorRequirement, values map[string]string) bool {
+	value, exists := values[req.Key]
+	switch req.Operator {
+	case corev1.NodeSelectorOpIn:
+		if !exists {
+			return false
+		}
+		for _, v := range req.Values {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	case corev1.NodeSelectorOpNotIn:
+		for _, v := range req.Values {
+			if v == value && exists {
+				return false
+			}
+		}
+		return true
+	case corev1.NodeSelectorOpExists:
+		return exists
+	case corev1.NodeSelectorOpDoesNotExist:
+		return !exists
+	case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+		if !exists || len(req.Values) != 1 {
+			return false
+		}
+		have, err1 := strconv.ParseInt(value, 10, 64)
+		want, err2 := strconv.ParseInt(req.Values[0], 10, 64)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		if req.Operator == corev1.NodeSelectorOpGt {
+			return have > want
+		}
+		return have < want
+	}
+	return false
+}
+
+// matchNodeSelectorTerm ANDs the expressions and field requirements of one term.
+func matchNodeSelectorTerm(term corev1.NodeSelectorTerm, node corev1.Node) bool {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+	for _, req := range term.MatchExpressions {
+		if !matchNodeRequirement(req, node.Labels) {
+			return false
+		}
+	}
+	fields := map[string]string{"metadata.name": node.Name}
+	for _, req := range term.MatchFields {
+		if !matchNodeRequirement(req, fields) {
+			return false
+		}
+	}
+	return true
+}
+
+func describeRequirement(req corev1.NodeSelectorRequirement) string {
+	switch req.Operator {
+	case corev1.NodeSelectorOpExists, corev1.NodeSelectorOpDoesNotExist:
+		return fmt.Sprintf("%s %s", req.Key, req.Operator)
+	}
+	return fmt.Sprintf("%s %s [%s]", req.Key, req.Operator, strings.Join(req.Values, ", "))
+}
+
+// toleratesTaint reports whether any toleration matches the taint.
+func toleratesTaint(tolerations []corev1.Toleration, taint corev1.Taint) bool {
+	for _, t := range tolerations {
+		if t.Effect != "" && t.Effect != taint.Effect {
+			continue
+		}
+		if t.Operator == corev1.TolerationOpExists && (t.Key == "" || t.Key == taint.Key) {
+			return true
+		}
+		if t.Key == taint.Key && t.Value == taint.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// podAffinityMatches reports whether a pod matching the term runs in the same topology domain as the node.
+func podAffinityMatches(term corev1.PodAffinityTerm, namespace string, node corev1.Node, pods []corev1.Pod, nodesByName map[string]corev1.Node) bool {
+	domain, ok := node.Labels[term.TopologyKey]
+	if !ok {
+		return false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(term.LabelSelector)
+	if err != nil || term.LabelSelector == nil {
+		return false
+	}
+	namespaces := term.Namespaces
+	if len(namespaces) == 0 && term.NamespaceSelector == nil {
+		namespaces = []string{namespace}
+	}
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		if len(namespaces) > 0 && !contains(namespaces, pod.Namespace) {
+			continue
+		}
+		if other, ok := nodesByName[pod.Spec.NodeName]; ok && other.Labels[term.TopologyKey] == domain {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateScheduling checks a pod spec against every node: readiness, cordons, nodeSelector,
+// required node affinity, taints and required pod (anti-)affinity.
+func evaluateScheduling(spec *corev1.PodSpec, namespace string, nodes []corev1.Node, pods []corev1.Pod) []NodeEligibility {
+	nodesByName := make(map[string]corev1.Node, len(nodes))
+	for _, n := range nodes {
+		nodesByName[n.Name] = n
+	}
+
+	var results []NodeEligibility
+	for _, node := range nodes {
+		res := NodeEligibility{Node: node.Name, OS: nodeOS(node), Reasons: []string{}}
+
+		if nodeStatus(node) != "Ready" {
+			res.Reasons = append(res.Reasons, "node is not Ready")
+		}
+		if node.Spec.Unschedulable {
+			res.Reasons = append(res.Reasons, "node is cordoned")
+		}
+		// The kubelet rejects pods whose spec.os differs from its own
+		if spec.OS != nil && spec.OS.Name != "" && string(spec.OS.Name) != res.OS {
+			res.Reasons = append(res.Reasons, fmt.Sprintf("pod runs on %s, node runs %s", spec.OS.Name, res.OS))
+		}
+		for key, want := range spec.NodeSelector {
+			if have, ok := node.Labels[key]; !ok || have != want {
+				res.Reasons = append(res.Reasons, fmt.Sprintf("nodeSelector %s=%s does not match", key, want))
+			}
+		}
+
+		affinity := spec.Affinity
+		if affinity != nil && affinity.NodeAffinity != nil {
+			if required := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil {
+				matched := false
+				var failed []string
+				for _, term := range required.NodeSelectorTerms {
+					if matchNodeSelectorTerm(term, node) {
+						matched = true
+						break
+					}
+					for _, req := range term.MatchExpressions {
+						if !matchNodeRequirement(req, node.Labels) {
+							failed = append(failed, describeRequirement(req))
+						}
+					}
+				}
+				if !matched {
+					res.Reasons = append(res.Reasons, "required node affinity not satisfied: "+strings.Join(failed, "; "))
+				}
+			}
+			for _, pref := range affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+				if matchNodeSelectorTerm(pref.Preference, node) {
+					res.PreferredScore += pref.Weight
+				}
+			}
+		}
+
+		for _, taint := range node.Spec.Taints {
+			if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+				continue
+			}
+			if !toleratesTaint(spec.Tolerations, taint) {
+				res.Reasons = append(res.Reasons, fmt.Sprintf("untolerated taint %s=%s:%s", taint.Key, taint.Value, taint.Effect))
+			}
+		}
+
+		if affinity != nil && affinity.PodAffinity != nil {
+			for _, term := range affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+				if !podAffinityMatches(term, namespace, node, pods, nodesByName) {
+					res.Reasons = append(res.Reasons, fmt.Sprintf("pod affinity: no matching pod in the same %s domain", term.TopologyKey))
+				}
+			}
+			for _, pref := range affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+				if podAffinityMatches(pref.PodAffinityTerm, namespace, node, pods, nodesByName) {
+					res.PreferredScore += pref.Weight
+				}
+			}
+		}
+		if affinity != nil && affinity.PodAntiAffinity != nil {
+			for _, term := range affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+				if podAffinityMatches(term, namespace, node, pods, nodesByName) {
+					res.Reasons = append(res.Reasons, fmt.Sprintf("pod anti-affinity: a matching pod already runs in the same %s domain", term.TopologyKey))
+				}
+			}
+			for _, pref := range affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+				if podAffinityMatches(pref.PodAffinityTerm, namespace, node, pods, nodesByName) {
+					res.PreferredScore -= pref.Weight
+				}
+			}
+		}
+
+		res.Eligible = len(res.Reasons) == 0
+		results = append(results, res)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Eligible != results[j].Eligible {
+			return results[i].Eligible
+		}
+		return results[i].PreferredScore > results[j].PreferredScore
+	})
+	return results
+}
+
+// mockSchedulingSpec is a typical production pod spec: linux/amd64 only, spread across hosts,
+// preferring worker nodes.
+func mockSchedulingSpec(name string) *corev1.PodSpec {
+	return &corev1.PodSpec{
+		NodeSelector: map[string]string{"kubernetes.io/os": "linux"},
+		Affinity: &corev1.Affinity{
+			NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "kubernetes.io/arch", Operator: corev1.NodeSelectorOpIn, Values: []string{"arm64"}}},
+				}}},
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{{
+					Weight:     50,
+					Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "node-role.kubernetes.io/worker", Operator: corev1.NodeSelectorOpExists}}},
+				}},
+			},
+			PodAntiAffinity: &corev1.PodAntiAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+					TopologyKey:   "kubernetes.io/hostname",
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+				}},
+			},
+		},
+	}
+}
+
+// GetScheduling evaluates a workload's node selector, node/pod affinity and tolerations against
+// the current nodes and explains which nodes are eligible and why the others are excluded.
+func (h *ResourceHandler) GetScheduling(c *gin.Context) {
+	kind := strings.ToLower(c.Param("kind"))
+	name := c.Param("name")
+	ns := c.Param("namespace")
+
+	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
+		if ns != rbacNs.(string) {
+			apierror.Write(c, http.StatusForbidden, "access denied to namespace "+ns)
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	var spec *corev1.PodSpec
+	if h.devMode {
+		spec = mockSchedulingSpec(name)
+	} else {
+		dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+		if err != nil {
+			apierror.Fail(c, "Failed to get dynamic client", err)
+			return
+		}
+		item, err := dynClient.Resource(getGVR(kind)).Namespace(ns).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			apierror.Fail(c, "Failed to get resource", err)
+			return
+		}
+		spec, err = podSpecFromObject(item)
+		if err != nil {
+			apierror.Write(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	nodes, err := h.k8sClient.ListNodes(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to list nodes", err)
+		return
+	}
+	// Pod affinity terms may reference pods in other namespaces; fall back to the workload's own
+	pods, err := h.k8sClient.ListPods(ctx, "")
+	if err != nil {
+		pods, _ = h.k8sClient.ListPods(ctx, ns)
+	}
+
+	report := SchedulingReport{Kind: kind, Name: name, Nodes: evaluateScheduling(spec, ns, nodes, pods)}
+	for _, n := range report.Nodes {
+		if n.Eligible {
+			report.Eligible++
+		}
+	}
+	if report.Nodes == nil {
+		report.Nodes = []NodeEligibility{}
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"k-view/apierror"
+	"k-view/k8s"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnonymousUser is the identity of visitors without credentials. It is also the user K-View
+// impersonates for them, so Kubernetes RBAC can be granted to it per namespace.
+const AnonymousUser = "kview:anonymous"
+
+const anonymousRole = "kview-namespace-viewer"
+
+// defaultAnonymousKinds are shown to anonymous visitors when KVIEW_ANONYMOUS_KINDS is not set.
+var defaultAnonymousKinds = []string{"pods", "deployments", "statefulsets", "daemonsets", "services"}
+
+// anonymousRoutes are the only endpoints anonymous visitors may call (GET only), with where
+// the resource kind comes from: a fixed kind, the :kind parameter, or none.
+var anonymousRoutes = map[string]string{
+	"/api/auth/me":                          "",
+	"/api/read-only":                        "",
+	"/api/namespaces":                       "",
+	"/api/pods":                             "pods",
+	"/api/resources/:kind":                  ":kind",
+	"/api/resources/:kind/:namespace/:name": ":kind",
+	"/api/resources/:kind/:namespace/:name/events": ":kind",
+}
+
+// AnonymousAccess lets visitors without credentials browse a few namespaces read-only, for
+// public status-page style deployments.
+type AnonymousAccess struct {
+	Namespaces []string
+	Kinds      []string
+}
+
+// anonymousAccessFromEnv reads KVIEW_ANONYMOUS_ACCESS and its namespace and kind whitelists.
+// It returns nil when anonymous access is not enabled.
+func anonymousAccessFromEnv() (*AnonymousAccess, error) {
+	if os.Getenv("KVIEW_ANONYMOUS_ACCESS") != "true" {
+		return nil, nil
+	}
+	a := &AnonymousAccess{
+		Namespaces: splitList(os.Getenv("KVIEW_ANONYMOUS_NAMESPACES")),
+		Kinds:      splitList(strings.ToLower(os.Getenv("KVIEW_ANONYMOUS_KINDS"))),
+	}
+	if len(a.Namespaces) == 0 {
+		return nil, fmt.Errorf("KVIEW_ANONYMOUS_NAMESPACES is required")
+	}
+	if len(a.Kinds) == 0 {
+		a.Kinds = defaultAnonymousKinds
+	}
+	if contains(a.Kinds, "secrets") {
+		return nil, fmt.Errorf("secrets cannot be shown to anonymous visitors")
+	}
+	return a, nil
+}
+
+// splitList splits a comma-separated list, dropping blanks.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// authorize checks an anonymous request against the whitelists and returns the namespace it
+// is restricted to. It aborts the request when it is not allowed.
+func (a *AnonymousAccess) authorize(c *gin.Context) (string, bool) {
+	kindSource, ok := anonymousRoutes[c.FullPath()]
+	if !ok || (c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead) {
+		// 403 rather than 401: the visitor stays on the page instead of being sent to the login
+		apierror.Abort(c, http.StatusForbidden, "Sign in to view this page")
+		return "", false
+	}
+	if kindSource != "" {
+		kind := kindSource
+		if kind == ":kind" {
+			kind = strings.ToLower(c.Param("kind"))
+		}
+		if !contains(a.Kinds, kind) {
+			apierror.Abort(c, http.StatusForbidden, "Sign in to view "+kind)
+			return "", false
+		}
+	}
+
+	ns := requestedNamespace(c)
+	switch {
+	case ns != "":
+		if !contains(a.Namespaces, ns) {
+			apierror.Abort(c, http.StatusForbidden, "Sign in to view namespace "+ns)
+			return "", false
+		}
+	case kindSource == "" || len(a.Namespaces) == 1:
+		// Always restricted to some namespace: an empty restriction means cluster-wide
+		ns = a.Namespaces[0]
+	default:
+		apierror.Abort(c, http.StatusBadRequest, "Select a namespace: one of "+strings.Join(a.Namespaces, ", "))
+		return "", false
+	}
+	return ns, true
+}
+
+// anonymousRequest serves a request without credentials as the anonymous viewer.
+func (h *AuthHandler) anonymousRequest(c *gin.Context) {
+	namespace, ok := h.anonymous.authorize(c)
+	if !ok {
+		return
+	}
+	c.Set("anonymous", true)
+	c.Set("allowedNamespaces", h.anonymous.Namespaces)
+	h.setUser(c, k8s.UserContext{Email: AnonymousUser, Role: anonymousRole}, namespace, nil)
+	c.Next()
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"k-view/apierror"
+	"k-view/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const approvalsDoc = "approvals"
+
+// approvalTTL is how long a pending action waits for a second admin before it lapses.
+const approvalTTL = 24 * time.Hour
+
+var errApprovalNotFound = errors.New("approval not found")
+
+// PendingAction is a destructive action held back until a second admin approves it.
+type PendingAction struct {
+	ID          string `json:"id"`
+	Action      string `json:"action"` // delete
+	Kind        string `json:"kind"`
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name"`
+	Force       bool   `json:"force,omitempty"`
+	RequestedBy string `json:"requestedBy"`
+	// Status is Pending, Executed, Failed, Rejected or Expired
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requestedAt"`
+	DecidedBy   string     `json:"decidedBy,omitempty"`
+	DecidedAt   *time.Time `json:"decidedAt,omitempty"`
+	Result      string     `json:"result,omitempty"`
+}
+
+func (a PendingAction) effectiveStatus(now time.Time) string {
+	if a.Status == "Pending" && now.Sub(a.RequestedAt) > approvalTTL {
+		return "Expired"
+	}
+	return a.Status
+}
+
+func (a PendingAction) sameTarget(b PendingAction) bool {
+	return a.Action == b.Action && a.Kind == b.Kind && a.Namespace == b.Namespace && a.Name == b.Name
+}
+
+// approvalQueue persists pending actions in the data store.
+type approvalQueue struct {
+	mu    sync.Mutex // Held while an approved action executes so it cannot run twice
+	store *store.Store
+}
+
+// policyNamespace is the namespace policies judge an object by: a Namespace object counts as its own.
+func policyNamespace(kind, ns, name string) string {
+	if kind == "namespaces" {
+		return name
+	}
+	return ns
+}
+
+// submit queues an action. If the same target is already pending, that entry is returned
+// with created=false.
+func (q *approvalQueue) submit(action PendingAction) (PendingAction, bool, error) {
+	action.ID = newID()
+	action.Status = "Pending"
+	action.RequestedAt = time.Now().UTC()
+
+	created := true
+	var all []PendingAction
+	err := q.store.Update(approvalsDoc, &all, func() error {
+		now := time.Now()
+		kept := all[:0]
+		for _, a := range all {
+			// Keep a week of decided actions for the audit trail
+			if a.effectiveStatus(now) != "Pending" && now.Sub(a.RequestedAt) > 7*24*time.Hour {
+				continue
+			}
+			if a.effectiveStatus(now) == "Pending" && a.sameTarget(action) {
+				action, created = a, false
+			}
+			kept = append(kept, a)
+		}
+		all = kept
+		if created {
+			all = append(all, action)
+		}
+		return nil
+	})
+	if err == nil && created {
+		auditf("%s of %s %s/%s by %s queued for approval", action.Action, action.Kind, action.Namespace, action.Name, action.RequestedBy)
+	}
+	return action, created, err
+}
+
+// ListApprovals returns the approval queue, newest first. ?status= filters it (e.g. Pending).
+func (h *ResourceHandler) ListApprovals(c *gin.Context) {
+	var all []PendingAction
+	if err := h.approvals.store.Load(approvalsDoc, &all); err != nil {
+		apierror.Fail(c, "Failed to load approvals", err)
+		return
+	}
+	filter := c.Query("status")
+	now := time.Now()
+	result := []PendingAction{}
+	for _, a := range all {
+		a.Status = a.effectiveStatus(now)
+		if filter == "" || a.Status == filter {
+			result = append(result, a)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].RequestedAt.After(result[j].RequestedAt) })
+	c.JSON(http.StatusOK, result)
+}
+
+// decideApproval records a decision on a pending action. On approval the action is executed
+// with the approving admin's credentials.
+func (h *ResourceHandler) decideApproval(c *gin.Context, approve bool) {
+	id := c.Param("id")
+	email, _ := c.Get("email")
+	admin, _ := email.(string)
+
+	h.approvals.mu.Lock()
+	defer h.approvals.mu.Unlock()
+
+	var all []PendingAction
+	if err := h.approvals.store.Load(approvalsDoc, &all); err != nil {
+		apierror.Fail(c, "Failed to load approvals", err)
+		return
+	}
+	var action *PendingAction
+	for i := range all {
+		if all[i].ID == id {
+			action = &all[i]
+		}
+	}
+	if action == nil {
+		apierror.Write(c, http.StatusNotFound, "approval "+id+" not found")
+		return
+	}
+	if status := action.effectiveStatus(time.Now()); status != "Pending" {
+		apierror.Write(c, http.StatusConflict, "action is already "+status)
+		return
+	}
+	if action.RequestedBy == admin {
+		apierror.Write(c, http.StatusForbidden, "a second admin must approve this action")
+		return
+	}
+
+	decided := *action
+	now := time.Now().UTC()
+	decided.DecidedBy, decided.DecidedAt = admin, &now
+	switch {
+	case !approve:
+		decided.Status = "Rejected"
+	default:
+		decided.Status = "Executed"
+		if err := h.deleteResource(c.Request.Context(), decided.Kind, decided.Namespace, decided.Name, decided.Force); err != nil {
+			decided.Status = "Failed"
+			decided.Result = err.Error()
+		}
+	}
+
+	err := h.approvals.store.Update(approvalsDoc, &all, func() error {
+		for i := range all {
+			if all[i].ID == id {
+				all[i] = decided
+				return nil
+			}
+		}
+		return errApprovalNotFound
+	})
+	if err != nil {
+		apierror.Fail(c, "Failed to save approval", err)
+		return
+	}
+	auditf("%s of %s %s/%s requested by %s: %s by %s", decided.Action, decided.Kind, decided.Namespace, decided.Name, decided.RequestedBy, decided.Status, admin)
+	if decided.Status == "Failed" {
+		c.JSON(http.StatusInternalServerError, apierror.New(http.StatusInternalServerError, "Failed to delete resource: "+decided.Result).With(gin.H{"approval": decided}))
+		return
+	}
+	c.JSON(http.StatusOK, decided)
+}
+
+// ApproveAction approves and executes a pending action. The requester cannot approve it.
+func (h *ResourceHandler) ApproveAction(c *gin.Context) { h.decideApproval(c, true) }
+
+// RejectAction rejects a pending action.
+func (h *ResourceHandler) RejectAction(c *gin.Context) { h.decideApproval(c, false) }
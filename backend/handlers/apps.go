@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// appKinds are the kinds an application is assembled from, in display order.
+var appKinds = []string{"deployments", "statefulsets", "daemonsets", "services", "ingresses", "configmaps"}
+
+// appLabels name an application, most specific grouping first: a part-of label groups the
+// components of a larger application, otherwise the instance, otherwise the name.
+var appLabels = []string{"app.kubernetes.io/part-of", "app.kubernetes.io/instance", "app.kubernetes.io/name"}
+
+// AppComponent is one resource of an application.
+type AppComponent struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Status string `json:"status,omitempty"`
+	Ready  string `json:"ready,omitempty"` // Workloads only, e.g. "2/3"
+}
+
+// App is a set of resources in one namespace sharing the recommended app.kubernetes.io labels.
+type App struct {
+	Name       string         `json:"name"`
+	Namespace  string         `json:"namespace"`
+	Label      string         `json:"label"` // The label the application was grouped by
+	Version    string         `json:"version,omitempty"`
+	ManagedBy  string         `json:"managedBy,omitempty"`
+	Status     string         `json:"status"` // Healthy, Degraded, Unhealthy, or Unknown without workloads
+	Components []AppComponent `json:"components"`
+}
+
+// workloadReadiness reads ready and desired replicas from a workload row.
+func workloadReadiness(kind string, item ResourceItem) (int, int, bool) {
+	var ready, desired int
+	switch kind {
+	case "deployments", "statefulsets":
+		if _, err := fmt.Sscanf(item.Extra["ready"], "%d/%d", &ready, &desired); err != nil {
+			return 0, 0, false
+		}
+	case "daemonsets":
+		if _, err := fmt.Sscanf(item.Extra["ready"]+" "+item.Extra["desired"], "%d %d", &ready, &desired); err != nil {
+			return 0, 0, false
+		}
+	default:
+		return 0, 0, false
+	}
+	return ready, desired, true
+}
+
+// ListApps groups deployments, statefulsets, daemonsets, services, ingresses and configmaps by
+// their app.kubernetes.io labels into applications, each with an overall health: Unhealthy when
+// a workload has no ready replicas, Degraded when one is short of replicas. Resources without
+// these labels are left out. Kinds that cannot be listed are reported in errors.
+func (h *ResourceHandler) ListApps(c *gin.Context) {
+	ns := c.Query("namespace")
+	if rbacNs := rbacNamespace(c); rbacNs != "" {
+		ns = rbacNs
+	}
+	ctx := c.Request.Context()
+
+	lists := make([][]ResourceItem, len(appKinds))
+	errs := map[string]string{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, kind := range appKinds {
+		wg.Add(1)
+		go func(i int, kind string) {
+			defer wg.Done()
+			items, err := h.listItems(ctx, kind, ns)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[kind] = err.Error()
+				return
+			}
+			lists[i] = items
+		}(i, kind)
+	}
+	wg.Wait()
+
+	apps := map[string]*App{}
+	var keys []string
+	for i, kind := range appKinds {
+		for _, item := range lists[i] {
+			var label, name string
+			for _, l := range appLabels {
+				if v := item.Labels[l]; v != "" {
+					label, name = l, v
+					break
+				}
+			}
+			if name == "" {
+				continue
+			}
+			key := item.Namespace + "/" + name
+			app, ok := apps[key]
+			if !ok {
+				app = &App{Name: name, Namespace: item.Namespace, Label: label, Status: "Unknown", Components: []AppComponent{}}
+				apps[key] = app
+				keys = append(keys, key)
+			}
+			if v := item.Labels["app.kubernetes.io/version"]; v != "" && app.Version == "" {
+				app.Version = v
+			}
+			if v := item.Labels["app.kubernetes.io/managed-by"]; v != "" && app.ManagedBy == "" {
+				app.ManagedBy = v
+			}
+
+			component := AppComponent{Kind: kind, Name: item.Name, Status: item.Status}
+			if ready, desired, ok := workloadReadiness(kind, item); ok {
+				component.Ready = fmt.Sprintf("%d/%d", ready, desired)
+				switch {
+				case desired > 0 && ready == 0:
+					app.Status = "Unhealthy"
+				case ready < desired && app.Status != "Unhealthy":
+					app.Status = "Degraded"
+				case app.Status == "Unknown":
+					app.Status = "Healthy"
+				}
+			}
+			app.Components = append(app.Components, component)
+		}
+	}
+
+	sort.Strings(keys)
+	result := make([]App, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, *apps[key])
+	}
+	resp := gin.H{"apps": result}
+	if len(errs) > 0 {
+		resp["errors"] = errs
+	}
+	c.JSON(http.StatusOK, resp)
+}
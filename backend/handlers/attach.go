@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"k-view/apierror"
+	"k-view/k8s"
+)
+
+// wsStreamWriter forwards one output stream of a container to the WebSocket. Both streams share
+// a lock because the connection allows only one writer at a time.
+type wsStreamWriter struct {
+	mu     *sync.Mutex
+	conn   *websocket.Conn
+	prefix string // ANSI colour for the stream, e.g. red for stderr
+}
+
+func (w *wsStreamWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	msg := p
+	if w.prefix != "" {
+		msg = append([]byte(w.prefix), append(p, []byte("\033[0m")...)...)
+	}
+	if err := w.conn.WriteMessage(websocket.BinaryMessage, msg); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// HandleAttach streams the stdout and stderr of a running container's main process over a
+// WebSocket (pods/attach without stdin). Unlike exec no new process is started, which suits
+// interactive programs whose output is not written to the log.
+func (h *ExecHandler) HandleAttach(c *gin.Context) {
+	namespace := c.Param("namespace")
+	pod := c.Param("name")
+	container := c.Param("container")
+
+	attacher, ok := h.k8sClient.(k8s.Attacher)
+	if !ok {
+		apierror.Write(c, http.StatusNotImplemented, "attach is not supported by this Kubernetes provider")
+		return
+	}
+
+	// pods/attach is granted together with pods/exec in Kubernetes, so it needs the same role
+	email, _ := c.Get("email")
+	role, _ := c.Get("role")
+	if !requireEditAccess(c, namespace) {
+		auditf("attach to %s/%s/%s denied for %v (role %v)", namespace, pod, container, email, role)
+		return
+	}
+	containers, defaultContainer, _, ok := h.podContainers(c, namespace, pod)
+	if !ok {
+		return
+	}
+	if container == "" {
+		container = defaultContainer
+	}
+	info := findContainer(containers, container)
+	if info == nil {
+		c.JSON(http.StatusBadRequest, apierror.New(http.StatusBadRequest, "container "+container+" not found in pod "+pod).With(gin.H{"containers": containers}))
+		return
+	}
+	if info.State == "Waiting" || info.State == "Terminated" {
+		apierror.Write(c, http.StatusConflict, "container "+container+" is not running")
+		return
+	}
+	auditf("attach to %s/%s/%s by %v (role %v)", namespace, pod, container, email, role)
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Attach Upgrade Error: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	// Input is discarded; reading is still needed to notice when the browser goes away
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	stdout := &wsStreamWriter{mu: &mu, conn: conn}
+	var stderr io.Writer = &wsStreamWriter{mu: &mu, conn: conn, prefix: "\033[31m"}
+	if info.TTY {
+		stderr = nil // A TTY merges both streams into stdout
+	}
+	err = attacher.Attach(ctx, namespace, pod, container, stdout, stderr)
+	if ctx.Err() != nil {
+		return // The browser closed the stream
+	}
+	if err != nil {
+		log.Printf("Attach error on %s/%s/%s: %v", namespace, pod, container, err)
+		_, _ = stdout.Write([]byte("\r\n\033[31mDetached: " + err.Error() + "\r\n"))
+		return
+	}
+	_, _ = stdout.Write([]byte("\r\n\033[33mDetached: the process has exited\r\n"))
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"k-view/apierror"
+	"k-view/store"
+)
+
+const auditJournal = "audit-log"
+
+// auditMarker prefixes the log lines that record user and admin actions.
+const auditMarker = "AUDIT: "
+
+// AuditEntry is one recorded action.
+type AuditEntry struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// cefEscaper escapes CEF extension values.
+var cefEscaper = strings.NewReplacer(`\`, `\\`, "=", `\=`, "\n", `\n`, "\r", `\r`)
+
+// cef formats an entry as an ArcSight Common Event Format record.
+func (e AuditEntry) cef() string {
+	return fmt.Sprintf("CEF:0|K-View|K-View|1.0|audit|K-View audit event|3|rt=%d msg=%s", e.Time.UnixMilli(), cefEscaper.Replace(e.Message))
+}
+
+// AuditLog keeps audit events in a journal of the data store, so they can be exported for a
+// SIEM, and optionally forwards them to syslog. Handlers record events with auditf; only
+// those calls reach the journal, so text that merely looks like an audit line in the process
+// log (a logged header value, say) cannot forge an entry. The Pruner applies the retention.
+type AuditLog struct {
+	store *store.Store
+
+	syslog       chan string // Formatted messages for the forwarder; nil when not forwarding
+	syslogFormat string      // cef or json
+}
+
+// auditLog receives the events recorded with auditf; nil until SetAuditLog is called.
+var auditLog *AuditLog
+
+// SetAuditLog makes a the destination of the audit events handlers record.
+func SetAuditLog(a *AuditLog) {
+	auditLog = a
+}
+
+// auditf records an audit event: it is logged with the AUDIT marker and kept in the audit log.
+func auditf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	log.Print(auditMarker + msg)
+	if auditLog != nil {
+		auditLog.Record(msg)
+	}
+}
+
+// NewAuditLog returns an audit log. A syslog target of the form udp://host:port or
+// tcp://host:port forwards every entry there in RFC 5424 framing, with the message in CEF or
+// JSON (format).
+func NewAuditLog(st *store.Store, syslogTarget, format string) (*AuditLog, error) {
+	a := &AuditLog{store: st}
+	if syslogTarget == "" {
+		return a, nil
+	}
+	u, err := url.Parse(syslogTarget)
+	if err != nil || (u.Scheme != "udp" && u.Scheme != "tcp") || u.Host == "" {
+		return nil, fmt.Errorf("syslog target %q must be udp://host:port or tcp://host:port", syslogTarget)
+	}
+	if format == "" {
+		format = "cef"
+	}
+	if format != "cef" && format != "json" {
+		return nil, fmt.Errorf("syslog format must be cef or json")
+	}
+	a.syslog = make(chan string, 1000)
+	a.syslogFormat = format
+	go a.forward(u.Scheme, u.Host)
+	return a, nil
+}
+
+// Record stores an audit event and queues it for syslog.
+func (a *AuditLog) Record(message string) {
+	entry := AuditEntry{Time: time.Now().UTC(), Message: message}
+	if err := a.store.Append(auditJournal, entry.Time, entry); err != nil {
+		log.Printf("Audit log: %v", err)
+	}
+	if a.syslog != nil {
+		msg := entry.cef()
+		if a.syslogFormat == "json" {
+			b, _ := json.Marshal(entry)
+			msg = string(b)
+		}
+		select {
+		case a.syslog <- msg:
+		default:
+			log.Println("Audit log: syslog queue full, entry not forwarded")
+		}
+	}
+}
+
+// forward sends queued messages to the syslog server, reconnecting after errors. Entries
+// that cannot be delivered are dropped; the journal still has them.
+func (a *AuditLog) forward(network, addr string) {
+	hostname, _ := os.Hostname()
+	var conn net.Conn
+	for msg := range a.syslog {
+		// <86>: facility authpriv, severity informational
+		frame := fmt.Sprintf("<86>1 %s %s k-view - audit - %s", time.Now().UTC().Format(time.RFC3339), hostname, msg)
+		if network == "tcp" {
+			frame = fmt.Sprintf("%d %s", len(frame), frame) // RFC 6587 octet counting
+		}
+		for attempt := 0; attempt < 2; attempt++ {
+			if conn == nil {
+				c, err := net.DialTimeout(network, addr, 5*time.Second)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Audit log: syslog %s: %v\n", addr, err)
+					break
+				}
+				conn = c
+			}
+			conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+			if _, err := conn.Write([]byte(frame)); err == nil {
+				break
+			}
+			conn.Close()
+			conn = nil
+		}
+	}
+}
+
+// Export streams the audit entries between ?since= and ?until= (RFC3339; default the last 24
+// hours) as JSON Lines, or as CEF with ?format=cef (admin only).
+func (a *AuditLog) Export(c *gin.Context) {
+	now := time.Now().UTC()
+	since, until := now.Add(-24*time.Hour), now
+	for param, t := range map[string]*time.Time{"since": &since, "until": &until} {
+		if v := c.Query(param); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				apierror.Write(c, http.StatusBadRequest, param+" must be an RFC3339 time")
+				return
+			}
+			*t = parsed
+		}
+	}
+	if !since.Before(until) {
+		apierror.Write(c, http.StatusBadRequest, "since must be before until")
+		return
+	}
+	format := c.DefaultQuery("format", "jsonl")
+	if format != "jsonl" && format != "cef" {
+		apierror.Write(c, http.StatusBadRequest, "format must be jsonl or cef")
+		return
+	}
+
+	contentType, ext := "application/x-ndjson", "jsonl"
+	if format == "cef" {
+		contentType, ext = "text/plain; charset=utf-8", "cef"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="k-view-audit-%s.%s"`, since.UTC().Format("20060102T150405Z"), ext))
+	c.Status(http.StatusOK)
+
+	var buf bytes.Buffer
+	err := a.store.Scan(auditJournal, since, func(raw json.RawMessage) error {
+		var e AuditEntry
+		if json.Unmarshal(raw, &e) != nil || e.Time.Before(since) || !e.Time.Before(until) {
+			return nil
+		}
+		buf.Reset()
+		if format == "cef" {
+			buf.WriteString(e.cef())
+		} else {
+			buf.Write(raw)
+		}
+		buf.WriteByte('\n')
+		if _, err := c.Writer.Write(buf.Bytes()); err != nil {
+			return err // Client went away
+		}
+		return nil
+	})
+	if err != nil {
+		// The status line is sent already; the export just ends early
+		log.Printf("Audit log export: %v", err)
+	}
+	c.Writer.Flush()
+}
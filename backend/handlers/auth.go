@@ -7,15 +7,18 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
+	"k-view/apierror"
 	"k-view/rbac"
 	"k-view/k8s"
 	"k-view/auth"
+	"k-view/auth/ldap"
 
 	"github.com/coreos/go-oidc/v3/oidc"
 	"github.com/gin-gonic/gin"
@@ -31,8 +34,23 @@ type AuthHandler struct {
 	verifier     *oidc.IDTokenVerifier
 	rbacConfig   *rbac.RBACConfig
 	localAuth       *auth.LocalAuthenticator
+	ldap            *ldap.Authenticator
+	proxy           *ProxyAuth
+	anonymous       *AnonymousAccess
 	authorizedUsers []string
 	devMode         bool
+	elevations      *ElevationHandler
+	revocations     *SessionRevocations
+	mfa             *MFAHandler
+	tokens          *TokenHandler
+	stats           *UsageStats
+	features        *FeatureFlags
+	basePath        string // Subpath K-View is hosted under, "" for the root
+
+	issuer             string
+	logoutVerifier     *oidc.IDTokenVerifier // Accepts logout tokens, which need not carry exp
+	endSessionURL      string
+	postLogoutRedirect string
 }
 
 // NewAuthHandler creates an AuthHandler. In DEV_MODE, it skips connecting to Google OIDC.
@@ -63,14 +81,52 @@ func NewAuthHandler() (*AuthHandler, error) {
 	fmt.Printf("DEBUG: Loading KVIEW_STATIC_USERS: %s\n", os.Getenv("KVIEW_STATIC_USERS"))
 	var localAuth *auth.LocalAuthenticator
 	la, err := auth.NewLocalAuthenticator("")
-	if err == nil && len(la.Users) > 0 {
+	if err == nil {
+		// Kept without static users too: admins can create local users later
 		localAuth = la
-		fmt.Printf("Local Authentication enabled with %d static users.\n", len(la.Users))
+		if la.Count() > 0 {
+			fmt.Printf("Local Authentication enabled with %d static users.\n", la.Count())
+		}
+	} else {
+		fmt.Printf("❌ Local Authentication error: %v\n", err)
+	}
+
+	// LDAP / Active Directory
+	var ldapAuth *ldap.Authenticator
+	if ldapConfig, err := ldap.ConfigFromEnv(); err != nil {
+		fmt.Printf("❌ LDAP configuration error: %v\n", err)
+	} else if ldapConfig != nil {
+		if ldapAuth, err = ldap.New(*ldapConfig); err != nil {
+			fmt.Printf("❌ LDAP configuration error: %v\n", err)
+		} else {
+			fmt.Printf("✅ LDAP authentication enabled against %s\n", ldapAuth.URL())
+		}
+	}
+
+	// Authenticating reverse proxy (oauth2-proxy, Pomerium)
+	proxyAuth, err := proxyAuthFromEnv()
+	if err != nil {
+		fmt.Printf("❌ Proxy authentication error: %v\n", err)
+	} else if proxyAuth != nil {
+		fmt.Printf("✅ Proxy authentication enabled using the %s header\n", proxyAuth.UserHeader)
+	}
+
+	// Anonymous read-only access
+	anonymous, err := anonymousAccessFromEnv()
+	if err != nil {
+		fmt.Printf("❌ Anonymous access error: %v\n", err)
+	} else if anonymous != nil {
+		fmt.Printf("⚠️  Anonymous read-only access enabled for namespaces %v (kinds %v)\n", anonymous.Namespaces, anonymous.Kinds)
 	}
 
 	// SSO Initialization
 	var oauth2Config oauth2.Config
-	var verifier *oidc.IDTokenVerifier
+	var verifier, logoutVerifier *oidc.IDTokenVerifier
+	var endSessionURL string
+	issuer := os.Getenv("KVIEW_OIDC_ISSUER")
+	if issuer == "" {
+		issuer = "https://accounts.google.com"
+	}
 	enableSSO := os.Getenv("KVIEW_ENABLE_SSO") == "true"
 
 	if enableSSO {
@@ -80,7 +136,7 @@ func NewAuthHandler() (*AuthHandler, error) {
 
 		if clientID != "" && clientSecret != "" {
 			ctx := context.Background()
-			provider, err := oidc.NewProvider(ctx, "https://accounts.google.com")
+			provider, err := oidc.NewProvider(ctx, issuer)
 			if err != nil {
 				fmt.Printf("❌ OIDC Provider error: %v\n", err)
 			} else {
@@ -90,6 +146,13 @@ func NewAuthHandler() (*AuthHandler, error) {
 
 				oidcConfig := &oidc.Config{ClientID: clientID}
 				verifier = provider.Verifier(oidcConfig)
+				logoutVerifier = provider.Verifier(&oidc.Config{ClientID: clientID, SkipExpiryCheck: true})
+
+				// RP-initiated logout; Google publishes no end-session endpoint, other IdPs do
+				endSessionURL = os.Getenv("KVIEW_OIDC_END_SESSION_URL")
+				if endSessionURL == "" {
+					endSessionURL = discoverEndSession(provider)
+				}
 
 				oauth2Config = oauth2.Config{
 					ClientID:     clientID,
@@ -112,8 +175,16 @@ func NewAuthHandler() (*AuthHandler, error) {
 		verifier:        verifier,
 		rbacConfig:      rbacConfig,
 		localAuth:       localAuth,
+		ldap:            ldapAuth,
+		proxy:           proxyAuth,
+		anonymous:       anonymous,
 		authorizedUsers: authorizedUsers,
 		devMode:         devMode,
+
+		issuer:             issuer,
+		logoutVerifier:     logoutVerifier,
+		endSessionURL:      endSessionURL,
+		postLogoutRedirect: os.Getenv("KVIEW_OIDC_POST_LOGOUT_REDIRECT_URL"),
 	}, nil
 }
 
@@ -137,10 +208,10 @@ func generateStateOauthCookie(w http.ResponseWriter) string {
 func (h *AuthHandler) Login(c *gin.Context) {
 	if h.verifier == nil {
 		if h.devMode {
-			c.Redirect(http.StatusTemporaryRedirect, "/")
+			c.Redirect(http.StatusTemporaryRedirect, h.basePath+"/")
 			return
 		}
-		c.JSON(http.StatusNotFound, gin.H{"error": "OIDC is not configured"})
+		apierror.Write(c, http.StatusNotFound, "OIDC is not configured")
 		return
 	}
 	state := generateStateOauthCookie(c.Writer)
@@ -164,31 +235,31 @@ func (h *AuthHandler) isAuthorized(email string) bool {
 // Callback handles the OAuth2 callback from Google.
 func (h *AuthHandler) Callback(c *gin.Context) {
 	if h.verifier == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "OIDC is not configured"})
+		apierror.Write(c, http.StatusBadRequest, "OIDC is not configured")
 		return
 	}
 
 	state, err := c.Cookie("oauthstate")
 	if err != nil || c.Query("state") != state {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid OAuth state"})
+		apierror.Write(c, http.StatusBadRequest, "Invalid OAuth state")
 		return
 	}
 
 	oauth2Token, err := h.oauth2Config.Exchange(c, c.Query("code"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to exchange token: " + err.Error()})
+		apierror.Fail(c, "Failed to exchange token", err)
 		return
 	}
 
 	rawIDToken, ok := oauth2Token.Extra("id_token").(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "No id_token field in oauth2 token."})
+		apierror.Write(c, http.StatusInternalServerError, "No id_token field in oauth2 token.")
 		return
 	}
 
 	idToken, err := h.verifier.Verify(c, rawIDToken)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify ID Token: " + err.Error()})
+		apierror.Fail(c, "Failed to verify ID Token", err)
 		return
 	}
 
@@ -196,14 +267,14 @@ func (h *AuthHandler) Callback(c *gin.Context) {
 		Email string `json:"email"`
 	}
 	if err := idToken.Claims(&claims); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Fail(c, "", err)
 		return
 	}
 
 	// Whitelist Check
 	if !h.isAuthorized(claims.Email) {
 		fmt.Printf("UNAUTHORIZED LOGIN ATTEMPT: Google user %s is not in the whitelist.\n", claims.Email)
-		c.Redirect(http.StatusTemporaryRedirect, "/?error=unauthorized")
+		c.Redirect(http.StatusTemporaryRedirect, h.basePath+"/?error=unauthorized")
 		return
 	}
 
@@ -214,14 +285,14 @@ func (h *AuthHandler) Callback(c *gin.Context) {
 		HttpOnly: true,
 		Path:     "/",
 	})
-	c.Redirect(http.StatusTemporaryRedirect, "/")
+	c.Redirect(http.StatusTemporaryRedirect, h.basePath+"/")
 }
 
 // DevLogin is a special endpoint for dev mode. It issues a signed session token for a mock admin user.
 // Returns 403 if DEV_MODE is not active.
 func (h *AuthHandler) DevLogin(c *gin.Context) {
 	if !h.devMode {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Dev login is only available in DEV_MODE"})
+		apierror.Write(c, http.StatusForbidden, "Dev login is only available in DEV_MODE")
 		return
 	}
 
@@ -247,35 +318,44 @@ func (h *AuthHandler) DevLogin(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"email": devEmail, "role": devRole})
 }
 
-// Logout clears the auth cookie.
-func (h *AuthHandler) Logout(c *gin.Context) {
-	http.SetCookie(c.Writer, &http.Cookie{
-		Name:     "auth_token",
-		Value:    "",
-		Expires:  time.Unix(0, 0),
-		HttpOnly: true,
-		Path:     "/",
-	})
-	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
-}
-
 // Me returns the currently authenticated user's email and role.
 func (h *AuthHandler) Me(c *gin.Context) {
 	email, exists := c.Get("email")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		apierror.Write(c, http.StatusUnauthorized, "Not authenticated")
 		return
 	}
-	role, _ := h.rbacConfig.GetRoleForUser(email.(string), []string{})
+	role, _ := h.rbacConfig.Resolve(email.(string), c.GetStringSlice("groups"))
 	if role == "" {
 		role = "viewer"
 	}
-	devMode := h.devMode
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"email":   email,
 		"role":    role,
-		"devMode": devMode,
-	})
+		"devMode": h.devMode,
+	}
+	if h.features != nil {
+		resp["features"] = h.features.States()
+	}
+	if c.GetBool("anonymous") {
+		resp["role"] = anonymousRole
+		resp["anonymous"] = true
+		resp["namespaces"] = h.anonymous.Namespaces
+		resp["kinds"] = h.anonymous.Kinds
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	if teams := h.rbacConfig.TeamsOf(email.(string), c.GetStringSlice("groups")); len(teams) > 0 {
+		resp["teams"] = teams
+	}
+	if namespaces := c.GetStringSlice("allowedNamespaces"); len(namespaces) > 0 {
+		resp["namespaces"] = namespaces
+	}
+	if e := h.elevations.Active(email.(string)); e != nil {
+		resp["role"] = e.Role
+		resp["elevation"] = e
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // verifyDevToken validates a dev-mode session token.
@@ -303,17 +383,68 @@ func verifyDevToken(token string) (string, bool) {
 	return email, ok
 }
 
+// ResolveUser determines the role and namespaces of an authenticated user from the static
+// config, their teams and directory groups, honouring an active break-glass grant.
+func (h *AuthHandler) ResolveUser(email string, groups []string) (k8s.UserContext, []string) {
+	role, namespaces := h.rbacConfig.Resolve(email, groups)
+
+	// An approved break-glass grant overrides the static role until it expires
+	if e := h.elevations.Active(email); e != nil {
+		role, namespaces = e.Role, e.scope()
+	}
+	return k8s.UserContext{Email: email, Role: role}, namespaces
+}
+
+// SlackUser returns the K-View user a Slack user ID is mapped to in the RBAC config.
+func (h *AuthHandler) SlackUser(slackID string) string {
+	return h.rbacConfig.UserForSlack(slackID)
+}
+
+// HasDirectRole reports whether a user's role is assigned to their email itself, through a
+// static assignment, a runtime grant or team membership, rather than through IdP groups.
+func (h *AuthHandler) HasDirectRole(email string) bool {
+	return h.rbacConfig.HasAssignment(email, nil)
+}
+
 // AuthMiddleware validates the auth cookie or a Bearer token.
 func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var email string
 		var ok bool
+		var tokenScope string
+		var groups []string
+		var provider string // How the user signed in: local, ldap, token, proxy, oidc or dev
+
+		// Identity asserted by a trusted authenticating proxy
+		if user, proxyGroups, found := h.proxy.identity(c.Request); found {
+			if !h.isAuthorized(user) && !h.rbacConfig.HasAssignment(user, proxyGroups) {
+				apierror.Abort(c, http.StatusForbidden, "Your account is not authorized to access this dashboard. Please contact your administrator.")
+				return
+			}
+			email, groups, ok = user, proxyGroups, true
+			provider = "proxy"
+		}
+
+		// Personal access tokens, in the Authorization header or the token query param
+		if raw := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "); !ok && (strings.HasPrefix(raw, tokenPrefix) || strings.HasPrefix(c.Query("token"), tokenPrefix)) {
+			if !strings.HasPrefix(raw, tokenPrefix) {
+				raw = c.Query("token")
+			}
+			var token APIToken
+			token, ok = h.tokens.Authenticate(raw)
+			email, tokenScope, groups = token.User, token.Scope, token.Groups
+			provider = "token"
+			if !ok || !h.tokenOwnerActive(email, groups) {
+				apierror.Abort(c, http.StatusUnauthorized, "Invalid or expired API token")
+				return
+			}
+		}
 
 		// 0. Check for token query param (used by WebSocket connections which can't set headers)
-		if tokenParam := c.Query("token"); tokenParam != "" && h.localAuth != nil {
-			username, err := h.localAuth.VerifyJWT(tokenParam)
-			if err == nil && username != "" {
-				email = username
+		if tokenParam := c.Query("token"); !ok && tokenParam != "" && h.localAuth != nil {
+			session, err := h.localAuth.VerifySession(tokenParam)
+			if err == nil && session.Username != "" {
+				email, groups, provider = session.Username, session.Groups, sessionProvider(session)
 				ok = true
 			}
 		}
@@ -323,9 +454,10 @@ func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
 			authHeader := c.GetHeader("Authorization")
 			if strings.HasPrefix(authHeader, "Bearer ") && h.localAuth != nil {
 				tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-				username, err := h.localAuth.VerifyJWT(tokenStr)
-				if err == nil && username != "" {
-					email = username // For static local users, 'email' is just their username string
+				session, err := h.localAuth.VerifySession(tokenStr)
+				if err == nil && session.Username != "" {
+					// For static local users, 'email' is just their username string
+					email, groups, provider = session.Username, session.Groups, sessionProvider(session)
 					ok = true
 				}
 			}
@@ -334,8 +466,13 @@ func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
 		// 2. Fallback to Cookie (OIDC or Dev Mode)
 		if !ok {
 			tokenStr, err := c.Cookie("auth_token")
+			if err != nil && h.anonymous != nil && c.GetHeader("Authorization") == "" && c.Query("token") == "" {
+				// Visitors without any credentials browse as the anonymous viewer
+				h.anonymousRequest(c)
+				return
+			}
 			if err != nil {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+				apierror.Abort(c, http.StatusUnauthorized, "Not authenticated")
 				return
 			}
 
@@ -344,9 +481,10 @@ func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
 				if err == nil {
 					var claims struct {
 						Email string `json:"email"`
+						SID   string `json:"sid"`
 					}
-					if err := idToken.Claims(&claims); err == nil {
-						email = claims.Email
+					if err := idToken.Claims(&claims); err == nil && !h.revocations.Revoked(tokenStr, idToken.Subject, claims.SID, idToken.IssuedAt) {
+						email, provider = claims.Email, "oidc"
 						ok = true
 					}
 				}
@@ -355,34 +493,77 @@ func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
 			// 3. Fallback to Dev Token if OIDC failed (only if in dev mode)
 			if !ok && h.devMode {
 				email, ok = verifyDevToken(tokenStr)
+				provider = "dev"
 			}
 		}
 
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			apierror.Abort(c, http.StatusUnauthorized, "Invalid token")
 			return
 		}
 
-		// Determine Role based on static config
-		role, namespace := h.rbacConfig.GetRoleForUser(email, []string{})
-		
-		userCtx := k8s.UserContext{
-			Email: email,
-			Role:  role,
+		userCtx, namespaces := h.ResolveUser(email, groups)
+		namespace, allowed := scopeNamespace(c, namespaces)
+		if !allowed {
+			apierror.Abort(c, http.StatusForbidden, "access denied to namespace "+requestedNamespace(c))
+			return
+		}
+		h.setUser(c, userCtx, namespace, groups)
+		c.Set("authProvider", provider)
+		if tokenScope != "" {
+			c.Set("tokenScope", tokenScope)
+			if !checkTokenScope(c, tokenScope) {
+				return
+			}
 		}
 
-		// Store in Gin context for handlers
-		c.Set("email", email)
-		c.Set("role", role)
-		c.Set("namespace", namespace)
-		c.Set("userCtx", userCtx)
+		c.Next()
+	}
+}
 
-		// Also wrap the Go context for downstream K8s calls
-		ctx := context.WithValue(c.Request.Context(), "user", userCtx)
-		c.Request = c.Request.WithContext(ctx)
+// setUser stores the authenticated user in the Gin context for handlers.
+func (h *AuthHandler) setUser(c *gin.Context, userCtx k8s.UserContext, namespace string, groups []string) {
+	c.Set("email", userCtx.Email)
+	c.Set("role", userCtx.Role)
+	c.Set("namespace", namespace)
+	c.Set("userCtx", userCtx)
+	c.Set("groups", groups)
+
+	// Also wrap the Go context for downstream K8s calls
+	ctx := context.WithValue(c.Request.Context(), "user", userCtx)
+	c.Request = c.Request.WithContext(ctx)
+}
 
-		c.Next()
+// requestedNamespace returns the namespace a request names in its path or query, or "" for
+// all namespaces.
+func requestedNamespace(c *gin.Context) string {
+	ns := c.Param("namespace")
+	if ns == "" {
+		ns = c.Query("namespace")
 	}
+	if ns == "-" {
+		return ""
+	}
+	return ns
+}
+
+// scopeNamespace picks the namespace restriction for a user limited to some namespaces: the
+// one the request names, or the first when it names none. Requests for any other namespace
+// are refused. A user limited to several namespaces also gets them as "allowedNamespaces".
+func scopeNamespace(c *gin.Context, namespaces []string) (string, bool) {
+	switch len(namespaces) {
+	case 0:
+		return "", true
+	case 1:
+		// Handlers pin single-namespace users to their namespace themselves
+		return namespaces[0], true
+	}
+	c.Set("allowedNamespaces", namespaces)
+	ns := requestedNamespace(c)
+	if ns == "" {
+		return namespaces[0], true
+	}
+	return ns, contains(namespaces, ns)
 }
 
 // AdminMiddleware ensures the user has the 'kview-cluster-admin' role or 'admin' fallback role.
@@ -390,7 +571,7 @@ func (h *AuthHandler) AdminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		role, exists := c.Get("role")
 		if !exists {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+			apierror.Abort(c, http.StatusUnauthorized, "Not authenticated")
 			return
 		}
 		
@@ -398,7 +579,11 @@ func (h *AuthHandler) AdminMiddleware() gin.HandlerFunc {
 		if roleStr != "kview-cluster-admin" && roleStr != "admin" {
 			email, _ := c.Get("email")
 			fmt.Printf("UNAUTHORIZED ACCESS ATTEMPT: User %s with role %s tried to access an admin-only endpoint\n", email, roleStr)
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			apierror.Abort(c, http.StatusForbidden, "Admin access required")
+			return
+		}
+		if scope := c.GetString("tokenScope"); scope != "" && !scopeAllows(scope, "admin") {
+			apierror.Abort(c, http.StatusForbidden, "API token scope "+scope+" does not allow admin endpoints")
 			return
 		}
 		
@@ -406,48 +591,149 @@ func (h *AuthHandler) AdminMiddleware() gin.HandlerFunc {
 	}
 }
 
+// SetElevations enables break-glass role overrides from approved elevation requests.
+func (h *AuthHandler) SetElevations(e *ElevationHandler) {
+	h.elevations = e
+}
+
+// SetMFA enables TOTP second factors for local users.
+func (h *AuthHandler) SetMFA(m *MFAHandler) {
+	h.mfa = m
+}
+
+// SetTokens enables personal access tokens.
+func (h *AuthHandler) SetTokens(t *TokenHandler) {
+	h.tokens = t
+}
+
+// SetStats counts failed logins in the admin statistics.
+func (h *AuthHandler) SetStats(s *UsageStats) {
+	h.stats = s
+}
+
+// SetFeatures reports the feature flags to the UI through Me.
+func (h *AuthHandler) SetFeatures(f *FeatureFlags) {
+	h.features = f
+}
+
+// SetBasePath sets the subpath K-View is hosted under, for the redirects back to the UI.
+func (h *AuthHandler) SetBasePath(p string) {
+	h.basePath = p
+}
+
+// tokenOwnerActive reports whether the owner of an API token may still sign in: a local user
+// must not be disabled and an SSO, directory or proxy user must still be allowed in.
+func (h *AuthHandler) tokenOwnerActive(email string, groups []string) bool {
+	if h.localAuth != nil {
+		if u, ok := h.localAuth.User(email); ok {
+			return !u.Disabled
+		}
+	}
+	return h.devMode || h.isAuthorized(email) || ((h.ldap != nil || h.proxy != nil) && h.rbacConfig.HasAssignment(email, groups))
+}
+
+// localEnabled reports whether anyone can sign in with a local password.
+func (h *AuthHandler) localEnabled() bool {
+	return h.localAuth != nil && h.localAuth.Count() > 0
+}
+
+// IsLocalUser reports whether a user signs in with a local password.
+func (h *AuthHandler) IsLocalUser(username string) bool {
+	if h.localAuth == nil {
+		return false
+	}
+	_, ok := h.localAuth.User(username)
+	return ok
+}
+
+// sessionProvider names the sign-in method of a K-View session token.
+func sessionProvider(s *auth.Session) string {
+	if s.Provider == "" {
+		return "local"
+	}
+	return s.Provider
+}
+
+// LocalSession returns the local user the request is signed in as. A directory or SSO user
+// with the same name is not that user, so the sign-in method is checked along with the name.
+func (h *AuthHandler) LocalSession(c *gin.Context) (string, bool) {
+	user := c.GetString("email")
+	return user, c.GetString("authProvider") == "local" && h.IsLocalUser(user)
+}
+
 // GetRBACConfig returns the loaded static RBAC config.
 func (h *AuthHandler) GetRBACConfig() *rbac.RBACConfig {
 	return h.rbacConfig
 }
 
+// OIDCClient returns the configured OIDC issuer and client ID; the client ID is empty when SSO
+// is not set up.
+func (h *AuthHandler) OIDCClient() (string, string) {
+	return h.issuer, h.oauth2Config.ClientID
+}
+
 // GetProviders returns the available authentication methods to the frontend.
 func (h *AuthHandler) GetProviders(c *gin.Context) {
-	fmt.Printf("DEBUG: GetProviders called. OIDC: %v, Local: %v, Dev: %v\n", h.verifier != nil, h.localAuth != nil, h.devMode)
+	fmt.Printf("DEBUG: GetProviders called. OIDC: %v, Local: %v, Dev: %v\n", h.verifier != nil, h.localEnabled(), h.devMode)
 	c.JSON(http.StatusOK, gin.H{
-		"oidc":  h.verifier != nil, // True if OIDC was successfully initialized
-		"local": h.localAuth != nil, // True if static local users are loaded
-		"dev":   h.devMode,          // True if running in DEV_MODE
+		"oidc":      h.verifier != nil,  // True if OIDC was successfully initialized
+		"local":     h.localEnabled(),   // True if any local users exist
+		"ldap":      h.ldap != nil,      // True if LDAP / Active Directory sign-in is configured
+		"proxy":     h.proxy != nil,     // True if an authenticating reverse proxy signs users in
+		"anonymous": h.anonymous != nil, // True if visitors can browse without signing in
+		"dev":       h.devMode,          // True if running in DEV_MODE
 	})
 }
 
 // LocalLogin handles traditional username/password authentication.
 func (h *AuthHandler) LocalLogin(c *gin.Context) {
-	if h.localAuth == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Local authentication is not enabled"})
+	if !h.localEnabled() && h.ldap == nil {
+		apierror.Write(c, http.StatusNotFound, "Local authentication is not enabled")
 		return
 	}
 
 	var req struct {
 		Username string `json:"username"`
 		Password string `json:"password"`
+		Code     string `json:"code"` // TOTP code, when MFA is enabled
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		apierror.Write(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	// Local accounts take precedence over directory accounts of the same name
+	if h.ldap != nil && !h.IsLocalUser(req.Username) {
+		h.ldapLogin(c, req.Username, req.Password)
 		return
 	}
 
 	if !h.localAuth.Authenticate(req.Username, req.Password) {
 		// Log failed attempts for security tracking
 		fmt.Printf("FAILED LOGIN ATTEMPT for user %s\n", req.Username)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		h.stats.failedLogin(req.Username)
+		apierror.Write(c, http.StatusUnauthorized, "Invalid username or password")
 		return
 	}
 
+	// Second factor for users who enrolled an authenticator
+	if h.mfa.Enabled(req.Username) {
+		if req.Code == "" {
+			c.JSON(http.StatusUnauthorized, apierror.New(http.StatusUnauthorized, "MFA code required").With(gin.H{"mfaRequired": true}))
+			return
+		}
+		if err := h.mfa.Verify(req.Username, req.Code); err != nil {
+			fmt.Printf("FAILED MFA ATTEMPT for user %s\n", req.Username)
+			h.stats.failedLogin(req.Username)
+			c.JSON(http.StatusUnauthorized, apierror.New(http.StatusUnauthorized, "Invalid MFA code").With(gin.H{"mfaRequired": true}))
+			return
+		}
+	}
+
 	token, err := h.localAuth.GenerateJWT(req.Username)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate session token"})
+		apierror.Write(c, http.StatusInternalServerError, "Failed to generate session token")
 		return
 	}
 
@@ -456,3 +742,41 @@ func (h *AuthHandler) LocalLogin(c *gin.Context) {
 		"token": token,
 	})
 }
+
+// ldapLogin authenticates against the directory and issues a session carrying the user's
+// groups. Directory users need an RBAC assignment, by user or by group, or a whitelist entry.
+func (h *AuthHandler) ldapLogin(c *gin.Context, username, password string) {
+	user, err := h.ldap.Authenticate(username, password)
+	if errors.Is(err, ldap.ErrInvalidCredentials) {
+		fmt.Printf("FAILED LOGIN ATTEMPT for LDAP user %s\n", username)
+		h.stats.failedLogin(username)
+		apierror.Write(c, http.StatusUnauthorized, "Invalid username or password")
+		return
+	}
+	if err != nil {
+		fmt.Printf("LDAP login for %s failed: %v\n", username, err)
+		apierror.Write(c, http.StatusServiceUnavailable, "The directory is unavailable, please try again later")
+		return
+	}
+	// A directory identity must not take over the session, RBAC assignment or MFA of a local account
+	if h.IsLocalUser(user.Email) {
+		fmt.Printf("REFUSED LOGIN: LDAP user %s (%s) has the name of a local account.\n", user.Email, user.DN)
+		apierror.Write(c, http.StatusForbidden, "Your directory account has the same name as a local account. Please contact your administrator.")
+		return
+	}
+	if !h.isAuthorized(user.Email) && !h.rbacConfig.HasAssignment(user.Email, user.Groups) {
+		fmt.Printf("UNAUTHORIZED LOGIN ATTEMPT: LDAP user %s (groups %v) has no role assignment.\n", user.Email, user.Groups)
+		apierror.Write(c, http.StatusForbidden, "Your account is not authorized to access this dashboard. Please contact your administrator.")
+		return
+	}
+
+	token, err := h.localAuth.GenerateSessionJWT(auth.Session{Username: user.Email, Provider: "ldap", Groups: user.Groups})
+	if err != nil {
+		apierror.Write(c, http.StatusInternalServerError, "Failed to generate session token")
+		return
+	}
+	fmt.Printf("LDAP user %s (%s) successfully logged in.\n", user.Email, user.DN)
+	c.JSON(http.StatusOK, gin.H{
+		"token": token,
+	})
+}
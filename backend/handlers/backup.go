@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"k-view/apierror"
+	"k-view/store"
+)
+
+// maxBackupSize bounds uploaded backup archives.
+const maxBackupSize = 1 << 30
+
+// journals are the store journals K-View writes; a backup naming any other is refused.
+var journals = []string{auditJournal, slowLogJournal, eventJournal}
+
+// BackupHandler takes and restores snapshots of the data store: teams, local users, API tokens,
+// dashboards, schedules and the other documents, plus the history journals. Role assignments
+// from the RBAC config file are not part of it; they live in the Helm values.
+type BackupHandler struct {
+	store *store.Store
+}
+
+func NewBackupHandler(st *store.Store) *BackupHandler {
+	return &BackupHandler{store: st}
+}
+
+// Download returns a gzipped tar archive of the data store, taken under the data directory
+// lock so it is consistent (admin only). ?journals=false leaves out the audit log, event
+// archive and slow log, which are usually most of its size.
+func (h *BackupHandler) Download(c *gin.Context) {
+	withJournals := c.DefaultQuery("journals", "true") != "false"
+	// Staged in a file, so a failure is reported properly rather than as a truncated download
+	tmp, err := os.CreateTemp("", "k-view-backup-*.tar.gz")
+	if err != nil {
+		apierror.Fail(c, "Failed to create backup", err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	m, err := h.store.Backup(tmp, withJournals)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		apierror.Fail(c, "Failed to create backup", err)
+		return
+	}
+	email, _ := c.Get("email")
+	auditf("data store backup (schema version %d, %d documents, %d journals) downloaded by %v", m.SchemaVersion, len(m.Documents), len(m.Journals), email)
+	c.FileAttachment(tmp.Name(), fmt.Sprintf("k-view-backup-%s.tar.gz", m.CreatedAt.Format("20060102T150405Z")))
+}
+
+// Restore replaces the data store with an uploaded backup, sent as the request body or as a
+// multipart "file" field (admin only). Backups from an older K-View are migrated after the
+// restore; those from a newer one are refused. Handlers that cache a document reload it through
+// the CacheRefresher, on this replica and the others alike.
+func (h *BackupHandler) Restore(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBackupSize)
+	var archive io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			apierror.Write(c, http.StatusBadRequest, "the backup must be uploaded as the \"file\" field")
+			return
+		}
+		f, err := fh.Open()
+		if err != nil {
+			apierror.Write(c, http.StatusBadRequest, "Failed to read upload: "+err.Error())
+			return
+		}
+		defer f.Close()
+		archive = f
+	}
+
+	m, err := h.store.Restore(archive, store.Migrations, journals)
+	if errors.Is(err, store.ErrIncompatibleBackup) {
+		apierror.Write(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err != nil {
+		apierror.Fail(c, "Failed to restore backup", err)
+		return
+	}
+	email, _ := c.Get("email")
+	auditf("data store restored from a backup of %s (schema version %d) by %v", m.CreatedAt.Format("2006-01-02T15:04:05Z"), m.SchemaVersion, email)
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Backup restored. Every replica picks up the restored data within the cache refresh interval (KVIEW_CACHE_REFRESH_INTERVAL); no restart is needed.",
+		"manifest": m,
+	})
+}
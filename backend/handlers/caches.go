@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"k-view/store"
+)
+
+// Cache is implemented by handlers that keep a store document in memory because it is read on
+// every request (tokens, elevations, session logouts, ...).
+type Cache interface {
+	// Document is the name of the cached store document.
+	Document() string
+	// Reload replaces the cached copy with the stored document.
+	Reload() error
+}
+
+// CacheRefresher reloads cached documents that changed in the store, so a token revoked, a session
+// logged out or a user disabled on one replica takes effect on the others within one interval.
+type CacheRefresher struct {
+	store  *store.Store
+	caches []Cache
+	stamps map[string]store.Stamp
+}
+
+func NewCacheRefresher(st *store.Store, caches ...Cache) *CacheRefresher {
+	r := &CacheRefresher{store: st, caches: caches, stamps: map[string]store.Stamp{}}
+	for _, c := range caches {
+		r.stamps[c.Document()] = st.Stamp(c.Document())
+	}
+	return r
+}
+
+// Run checks the cached documents every interval until ctx ends.
+func (r *CacheRefresher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.refresh()
+		}
+	}
+}
+
+// refresh reloads the caches whose document changed since the last check. A reload after a
+// write of this replica is redundant but harmless.
+func (r *CacheRefresher) refresh() {
+	for _, c := range r.caches {
+		doc := c.Document()
+		stamp := r.store.Stamp(doc)
+		if stamp == r.stamps[doc] {
+			continue
+		}
+		if err := c.Reload(); err != nil {
+			log.Printf("Failed to reload %s: %v", doc, err)
+			continue
+		}
+		r.stamps[doc] = stamp
+	}
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"k-view/apierror"
+)
+
+// CloneRequest is the body of a POST /api/resources/:kind/:namespace/:name/clone request.
+type CloneRequest struct {
+	TargetNamespace   string            `json:"targetNamespace" binding:"required"`
+	NewName           string            `json:"newName"`
+	Labels            map[string]string `json:"labels"` // Label overrides applied to metadata, selector and pod template
+	IncludeConfigMaps bool              `json:"includeConfigMaps"`
+	IncludeSecrets    bool              `json:"includeSecrets"`
+	IncludeServices   bool              `json:"includeServices"`
+	DryRun            bool              `json:"dryRun"`
+}
+
+// CloneResult reports what happened to each object of a clone operation.
+type CloneResult struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Status string `json:"status"` // Created, Exists, Failed, DryRun
+	Error  string `json:"error,omitempty"`
+}
+
+// sanitizeForClone strips server-populated fields so the object can be created elsewhere.
+func sanitizeForClone(obj *unstructured.Unstructured, namespace, name string) {
+	for _, field := range []string{"uid", "resourceVersion", "creationTimestamp", "generation", "managedFields", "ownerReferences", "selfLink"} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(obj.Object, "status")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "annotations", "deployment.kubernetes.io/revision")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "annotations", "kubectl.kubernetes.io/last-applied-configuration")
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+}
+
+// rewriteLabels applies label overrides to every label set present on the object.
+func rewriteLabels(obj *unstructured.Unstructured, overrides map[string]string) {
+	if len(overrides) == 0 {
+		return
+	}
+	paths := [][]string{
+		{"metadata", "labels"},
+		{"spec", "selector", "matchLabels"},
+		{"spec", "template", "metadata", "labels"},
+		{"spec", "selector"}, // Services use a plain map selector
+	}
+	for i, path := range paths {
+		labels, found, err := unstructured.NestedStringMap(obj.Object, path...)
+		if err != nil || (!found && i > 0) {
+			continue
+		}
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		for k, v := range overrides {
+			labels[k] = v
+		}
+		unstructured.SetNestedStringMap(obj.Object, labels, path...)
+	}
+}
+
+// referencedConfig collects ConfigMap and Secret names referenced by a pod spec.
+func referencedConfig(spec *corev1.PodSpec) (configMaps, secrets []string) {
+	for _, v := range spec.Volumes {
+		if v.ConfigMap != nil {
+			configMaps = appendUnique(configMaps, v.ConfigMap.Name)
+		}
+		if v.Secret != nil {
+			secrets = appendUnique(secrets, v.Secret.SecretName)
+		}
+		if v.Projected != nil {
+			for _, src := range v.Projected.Sources {
+				if src.ConfigMap != nil {
+					configMaps = appendUnique(configMaps, src.ConfigMap.Name)
+				}
+				if src.Secret != nil {
+					secrets = appendUnique(secrets, src.Secret.Name)
+				}
+			}
+		}
+	}
+	for _, ctr := range append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...) {
+		for _, from := range ctr.EnvFrom {
+			if from.ConfigMapRef != nil {
+				configMaps = appendUnique(configMaps, from.ConfigMapRef.Name)
+			}
+			if from.SecretRef != nil {
+				secrets = appendUnique(secrets, from.SecretRef.Name)
+			}
+		}
+		for _, env := range ctr.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				configMaps = appendUnique(configMaps, env.ValueFrom.ConfigMapKeyRef.Name)
+			}
+			if env.ValueFrom.SecretKeyRef != nil {
+				secrets = appendUnique(secrets, env.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+	}
+	return configMaps, secrets
+}
+
+// createClone creates (or dry-runs) a sanitized object in the target namespace.
+func createClone(c *gin.Context, dc dynamic.ResourceInterface, obj *unstructured.Unstructured, dryRun bool) CloneResult {
+	result := CloneResult{Kind: obj.GetKind(), Name: obj.GetName(), Status: "Created"}
+	opts := metav1.CreateOptions{}
+	if dryRun {
+		opts.DryRun = []string{metav1.DryRunAll}
+		result.Status = "DryRun"
+	}
+	if _, err := dc.Create(c.Request.Context(), obj, opts); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			result.Status = "Exists"
+		} else {
+			result.Status = "Failed"
+			result.Error = err.Error()
+		}
+	}
+	return result
+}
+
+// Clone copies a Deployment or StatefulSet (and optionally its ConfigMaps, Secrets and Services)
+// into another namespace, rewriting the name and labels on the way.
+func (h *ResourceHandler) Clone(c *gin.Context) {
+	kind := strings.ToLower(c.Param("kind"))
+	name := c.Param("name")
+	ns := c.Param("namespace")
+
+	if kind != "deployments" && kind != "statefulsets" {
+		apierror.Write(c, http.StatusBadRequest, "Only deployments and statefulsets can be cloned")
+		return
+	}
+
+	var req CloneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "targetNamespace is required")
+		return
+	}
+	if req.NewName == "" {
+		req.NewName = name
+	}
+	if req.TargetNamespace == ns && req.NewName == name {
+		apierror.Write(c, http.StatusBadRequest, "Clone target must differ from the source (namespace or name)")
+		return
+	}
+
+	// Apply RBAC namespace restriction to both source and target
+	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
+		if ns != rbacNs.(string) || req.TargetNamespace != rbacNs.(string) {
+			apierror.Write(c, http.StatusForbidden, "access denied to namespace "+req.TargetNamespace)
+			return
+		}
+	}
+
+	// Verify Edit Permissions
+	role, _ := c.Get("role")
+	if role.(string) != "kview-cluster-admin" && role.(string) != "admin" && role.(string) != "edit" {
+		apierror.Write(c, http.StatusForbidden, "Admin/Edit permissions required")
+		return
+	}
+	if h.rejectProtected(c, kind, req.TargetNamespace, req.NewName) {
+		return
+	}
+
+	if h.devMode {
+		results := []CloneResult{{Kind: "Deployment", Name: req.NewName, Status: "Created"}}
+		if req.IncludeConfigMaps {
+			results = append(results, CloneResult{Kind: "ConfigMap", Name: "app-config", Status: "Created"})
+		}
+		if req.IncludeSecrets {
+			results = append(results, CloneResult{Kind: "Secret", Name: "app-secret", Status: "Created"})
+		}
+		if req.IncludeServices {
+			results = append(results, CloneResult{Kind: "Service", Name: req.NewName, Status: "Created"})
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Clone completed (mocked)", "results": results})
+		return
+	}
+
+	dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+
+	source, err := dynClient.Resource(getGVR(kind)).Namespace(ns).Get(c.Request.Context(), name, metav1.GetOptions{})
+	if err != nil {
+		apierror.Fail(c, "Failed to get resource", err)
+		return
+	}
+	spec, err := podSpecFromObject(source)
+	if err != nil {
+		apierror.Write(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	templateLabels, _, _ := unstructured.NestedStringMap(source.Object, "spec", "template", "metadata", "labels")
+
+	var results []CloneResult
+
+	// Dependencies first so the workload's pods can start immediately
+	configMaps, secrets := referencedConfig(spec)
+	deps := map[string][]string{}
+	if req.IncludeConfigMaps {
+		deps["configmaps"] = configMaps
+	}
+	if req.IncludeSecrets {
+		deps["secrets"] = secrets
+	}
+	for depKind, names := range deps {
+		for _, depName := range names {
+			dep, err := dynClient.Resource(getGVR(depKind)).Namespace(ns).Get(c.Request.Context(), depName, metav1.GetOptions{})
+			if err != nil {
+				results = append(results, CloneResult{Kind: depKind, Name: depName, Status: "Failed", Error: err.Error()})
+				continue
+			}
+			if h.policies.IsProtected(depKind, req.TargetNamespace) {
+				results = append(results, CloneResult{Kind: depKind, Name: depName, Status: "Failed", Error: depKind + " are protected and cannot be created through K-View"})
+				continue
+			}
+			sanitizeForClone(dep, req.TargetNamespace, depName)
+			results = append(results, createClone(c, dynClient.Resource(getGVR(depKind)).Namespace(req.TargetNamespace), dep, req.DryRun))
+		}
+	}
+
+	if req.IncludeServices {
+		svcs, err := dynClient.Resource(getGVR("services")).Namespace(ns).List(c.Request.Context(), metav1.ListOptions{})
+		if err == nil {
+			for i := range svcs.Items {
+				svc := &svcs.Items[i]
+				selector, _, _ := unstructured.NestedStringMap(svc.Object, "spec", "selector")
+				if len(selector) == 0 || !labelsMatch(selector, templateLabels) {
+					continue
+				}
+				svcName := svc.GetName()
+				if req.NewName != name {
+					svcName = strings.Replace(svcName, name, req.NewName, 1)
+				}
+				if h.policies.IsProtected("services", req.TargetNamespace) {
+					results = append(results, CloneResult{Kind: "services", Name: svcName, Status: "Failed", Error: "services are protected and cannot be created through K-View"})
+					continue
+				}
+				sanitizeForClone(svc, req.TargetNamespace, svcName)
+				// Cluster IPs are allocated per service and cannot be copied
+				unstructured.RemoveNestedField(svc.Object, "spec", "clusterIP")
+				unstructured.RemoveNestedField(svc.Object, "spec", "clusterIPs")
+				rewriteLabels(svc, req.Labels)
+				results = append(results, createClone(c, dynClient.Resource(getGVR("services")).Namespace(req.TargetNamespace), svc, req.DryRun))
+			}
+		}
+	}
+
+	sanitizeForClone(source, req.TargetNamespace, req.NewName)
+	rewriteLabels(source, req.Labels)
+	results = append(results, createClone(c, dynClient.Resource(getGVR(kind)).Namespace(req.TargetNamespace), source, req.DryRun))
+
+	status := http.StatusOK
+	for _, r := range results {
+		if r.Status == "Failed" {
+			status = http.StatusMultiStatus
+			break
+		}
+	}
+	c.JSON(status, gin.H{"message": fmt.Sprintf("Cloned %s/%s to %s/%s", ns, name, req.TargetNamespace, req.NewName), "results": results})
+}
+
+// labelsMatch reports whether every key/value of the selector is present in labels.
+func labelsMatch(selector, labels map[string]string) bool {
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// CustomColumn is an extra list column: a JSONPath evaluated against every object, written
+// as for kubectl's custom-columns (.spec.vmSize) or as a template ({.spec.ports[*].port}).
+type CustomColumn struct {
+	Name     string `yaml:"name" json:"name"`
+	JSONPath string `yaml:"jsonPath" json:"jsonPath"`
+
+	path *jsonpath.JSONPath
+}
+
+// ColumnKind is the custom columns of a kind, which uses the API URL names (deployments,
+// pvcs, ...). For custom resources it is their plural resource name, with their group and
+// version, which also makes K-View list them.
+type ColumnKind struct {
+	Kind          string         `yaml:"kind" json:"kind"`
+	Group         string         `yaml:"group,omitempty" json:"group,omitempty"`
+	Version       string         `yaml:"version,omitempty" json:"version,omitempty"`
+	ClusterScoped bool           `yaml:"clusterScoped,omitempty" json:"clusterScoped,omitempty"`
+	Columns       []CustomColumn `yaml:"columns" json:"columns"`
+}
+
+// ColumnConfig is the custom columns configuration (KVIEW_COLUMNS_CONFIG_PATH).
+type ColumnConfig struct {
+	Kinds []ColumnKind `yaml:"kinds" json:"kinds"`
+}
+
+// LoadColumnConfig reads the custom columns from a YAML file and compiles their JSONPaths.
+// A missing file configures none.
+func LoadColumnConfig(path string) (*ColumnConfig, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return &ColumnConfig{}, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns config: %v", err)
+	}
+	var config ColumnConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal columns config: %v", err)
+	}
+
+	seen := map[string]bool{}
+	for i := range config.Kinds {
+		k := &config.Kinds[i]
+		k.Kind = strings.ToLower(k.Kind)
+		if k.Kind == "" {
+			return nil, fmt.Errorf("columns config entry %d has no kind", i+1)
+		}
+		if seen[k.Kind] {
+			return nil, fmt.Errorf("kind %q is configured twice", k.Kind)
+		}
+		seen[k.Kind] = true
+		if k.Group != "" && k.Version == "" {
+			return nil, fmt.Errorf("kind %q has a group but no version", k.Kind)
+		}
+		for j := range k.Columns {
+			col := &k.Columns[j]
+			if col.Name == "" {
+				return nil, fmt.Errorf("column %d of kind %q has no name", j+1, k.Kind)
+			}
+			col.path = jsonpath.New(col.Name).AllowMissingKeys(true)
+			if err := col.path.Parse(relaxedJSONPath(col.JSONPath)); err != nil {
+				return nil, fmt.Errorf("invalid jsonPath for column %q of kind %q: %v", col.Name, k.Kind, err)
+			}
+		}
+	}
+	return &config, nil
+}
+
+// relaxedJSONPath turns kubectl's custom-columns form (.spec.vmSize, or spec.vmSize) into a
+// JSONPath template; templates in braces are kept.
+func relaxedJSONPath(path string) string {
+	path = strings.TrimSpace(path)
+	if strings.HasPrefix(path, "{") && strings.HasSuffix(path, "}") {
+		return path
+	}
+	if !strings.HasPrefix(path, ".") {
+		path = "." + path
+	}
+	return "{" + path + "}"
+}
+
+// forKind returns the custom columns configuration of a kind, or nil.
+func (c *ColumnConfig) forKind(kind string) *ColumnKind {
+	if c == nil {
+		return nil
+	}
+	for i := range c.Kinds {
+		if c.Kinds[i].Kind == kind {
+			return &c.Kinds[i]
+		}
+	}
+	return nil
+}
+
+// gvr returns the GroupVersionResource of a kind: the configured one for custom resources,
+// the built-in mapping otherwise.
+func (c *ColumnConfig) gvr(kind string) (schema.GroupVersionResource, bool) {
+	if k := c.forKind(kind); k != nil && k.Group != "" {
+		return schema.GroupVersionResource{Group: k.Group, Version: k.Version, Resource: k.Kind}, k.ClusterScoped
+	}
+	return getGVR(kind), isClusterScoped(kind)
+}
+
+// apply evaluates the custom columns of a kind against an object into the item's extra
+// columns, overriding built-in ones of the same name. Columns the object has no value for
+// are left empty.
+func (k *ColumnKind) apply(obj map[string]interface{}, extra map[string]string) {
+	if k == nil {
+		return
+	}
+	for _, col := range k.Columns {
+		var buf bytes.Buffer
+		if err := col.path.Execute(&buf, obj); err != nil {
+			extra[col.Name] = ""
+			continue
+		}
+		extra[col.Name] = buf.String()
+	}
+}
+
+// SetColumns configures the custom list columns.
+func (h *ResourceHandler) SetColumns(columns *ColumnConfig) {
+	h.columns = columns
+}
+
+// ListColumns returns the custom columns configuration, which the resource lists show and
+// which adds the configured custom resources to the navigation.
+func (h *ResourceHandler) ListColumns(c *gin.Context) {
+	kinds := []ColumnKind{}
+	if h.columns != nil {
+		kinds = h.columns.Kinds
+	}
+	c.JSON(http.StatusOK, kinds)
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"k-view/apierror"
+	"k-view/k8s"
+)
+
+// compareKinds are the workload kinds compared between clusters, with the path to their pod
+// template spec. Jobs and pods come and go and are left out.
+var compareKinds = []struct {
+	kind, resource string
+	podSpec        []string
+}{
+	{"Deployment", "deployments", []string{"spec", "template", "spec"}},
+	{"StatefulSet", "statefulsets", []string{"spec", "template", "spec"}},
+	{"DaemonSet", "daemonsets", []string{"spec", "template", "spec"}},
+	{"CronJob", "cronjobs", []string{"spec", "jobTemplate", "spec", "template", "spec"}},
+}
+
+// WorkloadShape is what is compared of a workload: its replica count and images.
+type WorkloadShape struct {
+	Replicas *int64            `json:"replicas,omitempty"` // Unset for DaemonSets and CronJobs
+	Images   map[string]string `json:"images"`             // Container name (init containers as init:<name>) to image
+}
+
+// WorkloadDiff compares one workload between two clusters.
+type WorkloadDiff struct {
+	Kind        string         `json:"kind"`
+	Name        string         `json:"name"`
+	Status      string         `json:"status"` // same, different, only-left, only-right
+	Left        *WorkloadShape `json:"left,omitempty"`
+	Right       *WorkloadShape `json:"right,omitempty"`
+	Differences []string       `json:"differences,omitempty"`
+}
+
+// NamespaceComparison is the workload diff of a namespace between two clusters.
+type NamespaceComparison struct {
+	Namespace string         `json:"namespace"`
+	Left      string         `json:"left"`
+	Right     string         `json:"right"`
+	Summary   map[string]int `json:"summary"` // Workloads per status
+	Workloads []WorkloadDiff `json:"workloads"`
+}
+
+// CompareHandler compares resources between the clusters K-View can reach.
+type CompareHandler struct {
+	devMode  bool
+	clusters *k8s.Clusters
+}
+
+func NewCompareHandler(devMode bool, clusters *k8s.Clusters) *CompareHandler {
+	return &CompareHandler{devMode: devMode, clusters: clusters}
+}
+
+// ListClusters returns the cluster names, K-View's own first.
+func (h *CompareHandler) ListClusters(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"local": h.clusters.Local(), "clusters": h.clusters.Names()})
+}
+
+// CompareNamespace diffs the Deployments, StatefulSets, DaemonSets and CronJobs of a namespace
+// between ?left= (default: K-View's cluster) and ?right= (default: the first other cluster) by
+// name, replica count and container images, e.g. to verify staging and production match.
+// ?ignoreReplicas=true leaves replica counts out, for clusters sized differently on purpose.
+func (h *CompareHandler) CompareNamespace(c *gin.Context) {
+	ns := c.Param("namespace")
+	if rbacNs := c.GetString("namespace"); rbacNs != "" && ns != rbacNs {
+		apierror.Write(c, http.StatusForbidden, "access denied to namespace "+ns)
+		return
+	}
+	names := h.clusters.Names()
+	left, right := c.DefaultQuery("left", h.clusters.Local()), c.Query("right")
+	if right == "" {
+		for _, name := range names {
+			if name != left {
+				right = name
+				break
+			}
+		}
+	}
+	if right == "" || left == right {
+		apierror.Write(c, http.StatusBadRequest, "comparing needs two different clusters; configured clusters are "+strings.Join(names, ", "))
+		return
+	}
+	ignoreReplicas := c.Query("ignoreReplicas") == "true"
+
+	shapes := map[string]map[string]WorkloadShape{}
+	for _, cluster := range []string{left, right} {
+		provider, ok := h.clusters.Get(cluster)
+		if !ok {
+			apierror.Write(c, http.StatusNotFound, fmt.Sprintf("unknown cluster %q; configured clusters are %s", cluster, strings.Join(names, ", ")))
+			return
+		}
+		if h.devMode {
+			shapes[cluster] = mockWorkloadShapes(cluster != h.clusters.Local())
+			continue
+		}
+		s, err := workloadShapes(c.Request.Context(), provider, ns)
+		if err != nil {
+			apierror.Write(c, http.StatusBadGateway, fmt.Sprintf("Failed to list workloads in cluster %s: %v", cluster, err))
+			return
+		}
+		shapes[cluster] = s
+	}
+
+	result := NamespaceComparison{Namespace: ns, Left: left, Right: right, Summary: map[string]int{"same": 0, "different": 0, "only-left": 0, "only-right": 0}, Workloads: []WorkloadDiff{}}
+	keys := map[string]bool{}
+	for _, s := range shapes {
+		for key := range s {
+			keys[key] = true
+		}
+	}
+	for key := range keys {
+		kind, name, _ := strings.Cut(key, "/")
+		diff := WorkloadDiff{Kind: kind, Name: name}
+		l, inLeft := shapes[left][key]
+		r, inRight := shapes[right][key]
+		if inLeft {
+			diff.Left = &l
+		}
+		if inRight {
+			diff.Right = &r
+		}
+		switch {
+		case !inRight:
+			diff.Status = "only-left"
+		case !inLeft:
+			diff.Status = "only-right"
+		default:
+			diff.Differences = compareShapes(l, r, ignoreReplicas)
+			diff.Status = "same"
+			if len(diff.Differences) > 0 {
+				diff.Status = "different"
+			}
+		}
+		result.Summary[diff.Status]++
+		result.Workloads = append(result.Workloads, diff)
+	}
+	// Differences first, then by kind and name
+	rank := map[string]int{"different": 0, "only-left": 1, "only-right": 2, "same": 3}
+	sort.Slice(result.Workloads, func(i, j int) bool {
+		a, b := result.Workloads[i], result.Workloads[j]
+		if rank[a.Status] != rank[b.Status] {
+			return rank[a.Status] < rank[b.Status]
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	c.JSON(http.StatusOK, result)
+}
+
+// workloadShapes lists the compared workloads of a namespace, keyed by kind/name.
+func workloadShapes(ctx context.Context, provider k8s.KubernetesProvider, ns string) (map[string]WorkloadShape, error) {
+	dynClient, err := provider.GetDynamicClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	shapes := map[string]WorkloadShape{}
+	for _, ck := range compareKinds {
+		list, err := dynClient.Resource(getGVR(ck.resource)).Namespace(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list.Items {
+			shape := WorkloadShape{Images: map[string]string{}}
+			if replicas, found, _ := unstructured.NestedInt64(item.Object, "spec", "replicas"); found {
+				shape.Replicas = &replicas
+			}
+			for field, prefix := range map[string]string{"containers": "", "initContainers": "init:"} {
+				containers, _, _ := unstructured.NestedSlice(item.Object, append(append([]string{}, ck.podSpec...), field)...)
+				for _, raw := range containers {
+					container, _ := raw.(map[string]interface{})
+					name, _ := container["name"].(string)
+					image, _ := container["image"].(string)
+					shape.Images[prefix+name] = image
+				}
+			}
+			shapes[ck.kind+"/"+item.GetName()] = shape
+		}
+	}
+	return shapes, nil
+}
+
+// compareShapes describes how two workloads differ, as "what: left → right" lines.
+func compareShapes(l, r WorkloadShape, ignoreReplicas bool) []string {
+	var diffs []string
+	if !ignoreReplicas && l.Replicas != nil && r.Replicas != nil && *l.Replicas != *r.Replicas {
+		diffs = append(diffs, fmt.Sprintf("replicas: %d → %d", *l.Replicas, *r.Replicas))
+	}
+	containers := map[string]bool{}
+	for name := range l.Images {
+		containers[name] = true
+	}
+	for name := range r.Images {
+		containers[name] = true
+	}
+	sorted := make([]string, 0, len(containers))
+	for name := range containers {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		li, inLeft := l.Images[name]
+		ri, inRight := r.Images[name]
+		switch {
+		case !inRight:
+			diffs = append(diffs, fmt.Sprintf("container %s: only in left (%s)", name, li))
+		case !inLeft:
+			diffs = append(diffs, fmt.Sprintf("container %s: only in right (%s)", name, ri))
+		case li != ri:
+			diffs = append(diffs, fmt.Sprintf("image %s: %s → %s", name, li, ri))
+		}
+	}
+	return diffs
+}
+
+// mockWorkloadShapes returns the workloads of the mock clusters for DEV_MODE; the remote one
+// lags behind on a release and lacks a worker.
+func mockWorkloadShapes(remote bool) map[string]WorkloadShape {
+	replicas := func(n int64) *int64 { return &n }
+	shapes := map[string]WorkloadShape{
+		"Deployment/frontend-web": {Replicas: replicas(3), Images: map[string]string{"web": "registry.example.com/frontend:2.4.1"}},
+		"Deployment/backend-api":  {Replicas: replicas(4), Images: map[string]string{"api": "registry.example.com/backend:1.18.0", "init:migrate": "registry.example.com/backend:1.18.0"}},
+		"StatefulSet/cache-redis": {Replicas: replicas(3), Images: map[string]string{"redis": "redis:7.2"}},
+		"CronJob/nightly-report":  {Images: map[string]string{"report": "registry.example.com/reports:0.9.2"}},
+		"Deployment/worker":       {Replicas: replicas(2), Images: map[string]string{"worker": "registry.example.com/worker:1.3.0"}},
+	}
+	if remote {
+		shapes["Deployment/frontend-web"] = WorkloadShape{Replicas: replicas(1), Images: map[string]string{"web": "registry.example.com/frontend:2.4.1"}}
+		shapes["Deployment/backend-api"] = WorkloadShape{Replicas: replicas(2), Images: map[string]string{"api": "registry.example.com/backend:1.19.0-rc.1", "init:migrate": "registry.example.com/backend:1.19.0-rc.1"}}
+		delete(shapes, "Deployment/worker")
+		shapes["Deployment/feature-flags"] = WorkloadShape{Replicas: replicas(1), Images: map[string]string{"flags": "registry.example.com/flags:0.2.0"}}
+	}
+	return shapes
+}
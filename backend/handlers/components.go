@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"k-view/k8s"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ComponentHealth is the health of one control-plane component.
+type ComponentHealth struct {
+	Name    string        `json:"name"`
+	Status  string        `json:"status"` // Healthy, Unhealthy, Unknown
+	Source  string        `json:"source"` // livez, lease
+	Message string        `json:"message,omitempty"`
+	Checks  []HealthCheck `json:"checks,omitempty"`
+}
+
+// HealthCheck is a single line of the API server's verbose /livez output.
+type HealthCheck struct {
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	Message string `json:"message,omitempty"`
+}
+
+// parseVerboseHealth parses "[+]name ok" / "[-]name failed: reason" lines.
+func parseVerboseHealth(body string) []HealthCheck {
+	var checks []HealthCheck
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) < 4 || line[0] != '[' || line[2] != ']' {
+			continue
+		}
+		name, msg, _ := strings.Cut(line[3:], " ")
+		checks = append(checks, HealthCheck{Name: name, Healthy: line[1] == '+', Message: msg})
+	}
+	return checks
+}
+
+// leaseHealth reports a leader-elected component as healthy if its lease was renewed recently.
+func leaseHealth(lease *unstructured.Unstructured, name string) ComponentHealth {
+	health := ComponentHealth{Name: name, Status: "Unknown", Source: "lease"}
+	holder, _, _ := unstructured.NestedString(lease.Object, "spec", "holderIdentity")
+	renew, _, _ := unstructured.NestedString(lease.Object, "spec", "renewTime")
+	duration, found, _ := unstructured.NestedInt64(lease.Object, "spec", "leaseDurationSeconds")
+	if !found || duration == 0 {
+		duration = 15
+	}
+	renewTime, err := time.Parse(time.RFC3339Nano, renew)
+	if err != nil {
+		health.Message = "lease has no renew time"
+		return health
+	}
+
+	since := time.Since(renewTime)
+	if since <= 2*time.Duration(duration)*time.Second {
+		health.Status = "Healthy"
+		health.Message = "leader " + holder + ", renewed " + since.Round(time.Second).String() + " ago"
+	} else {
+		health.Status = "Unhealthy"
+		health.Message = "leader " + holder + " has not renewed its lease for " + getAge(renewTime)
+	}
+	return health
+}
+
+// checkComponents probes the API server, etcd (through the API server's health checks) and the
+// leader-elected scheduler and controller-manager. Inaccessible checks are reported as Unknown.
+func (h *ResourceHandler) checkComponents(ctx context.Context) []ComponentHealth {
+	apiserver := ComponentHealth{Name: "kube-apiserver", Status: "Unknown", Source: "livez"}
+	etcd := ComponentHealth{Name: "etcd", Status: "Unknown", Source: "livez"}
+
+	if info, ok := h.k8sClient.(k8s.ClusterInfoProvider); ok {
+		// A failing /livez returns 500 together with the verbose body, so parse whatever came back
+		body, err := info.GetRaw(ctx, "/livez?verbose")
+		apiserver.Checks = parseVerboseHealth(string(body))
+		switch {
+		case len(apiserver.Checks) == 0 && err != nil:
+			apiserver.Message = err.Error()
+		case len(apiserver.Checks) == 0:
+			apiserver.Message = "no checks reported"
+		default:
+			apiserver.Status = "Healthy"
+			for _, check := range apiserver.Checks {
+				if !check.Healthy {
+					apiserver.Status = "Unhealthy"
+					apiserver.Message = appendMessage(apiserver.Message, check.Name+" failed")
+				}
+				if check.Name == "etcd" {
+					etcd.Status = "Healthy"
+					if !check.Healthy {
+						etcd.Status = "Unhealthy"
+						etcd.Message = check.Message
+					}
+				}
+			}
+		}
+	}
+
+	components := []ComponentHealth{apiserver, etcd}
+	for _, name := range []string{"kube-scheduler", "kube-controller-manager"} {
+		if h.devMode {
+			components = append(components, ComponentHealth{Name: name, Status: "Healthy", Source: "lease", Message: "leader master-01, renewed 2s ago"})
+			continue
+		}
+		health := ComponentHealth{Name: name, Status: "Unknown", Source: "lease"}
+		dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+		if err == nil {
+			var lease *unstructured.Unstructured
+			lease, err = dynClient.Resource(getGVR("leases")).Namespace("kube-system").Get(ctx, name, metav1.GetOptions{})
+			if err == nil {
+				health = leaseHealth(lease, name)
+			}
+		}
+		if err != nil {
+			// Managed control planes often hide these leases
+			health.Message = err.Error()
+		}
+		components = append(components, health)
+	}
+	return components
+}
+
+func appendMessage(msg, add string) string {
+	if msg == "" {
+		return add
+	}
+	return msg + ", " + add
+}
+
+// GetComponents returns the health of the control-plane components.
+func (h *ResourceHandler) GetComponents(c *gin.Context) {
+	c.JSON(http.StatusOK, h.checkComponents(c.Request.Context()))
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"k-view/config"
+)
+
+// ConfigHandler shows the configuration K-View started with.
+type ConfigHandler struct {
+	config *config.Config
+}
+
+func NewConfigHandler(cfg *config.Config) *ConfigHandler {
+	return &ConfigHandler{config: cfg}
+}
+
+// Get returns every setting with its effective value and its source (default, file or env),
+// with secrets redacted (admin only). Changes take effect on restart.
+func (h *ConfigHandler) Get(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"path":     h.config.Path,
+		"settings": h.config.Effective(),
+	})
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/exec"
@@ -9,77 +10,114 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v2"
 
+	"k-view/apierror"
 	"k-view/k8s"
+	"k-view/policy"
 )
 
 // ConsoleHandler handles kubectl command execution.
 type ConsoleHandler struct {
-	devMode bool
+	devMode  bool
+	policies *policy.PolicyConfig
 }
 
-func NewConsoleHandler(devMode bool) *ConsoleHandler {
-	return &ConsoleHandler{devMode: devMode}
+func NewConsoleHandler(devMode bool, policies *policy.PolicyConfig) *ConsoleHandler {
+	return &ConsoleHandler{devMode: devMode, policies: policies}
 }
 
 // ExecRequest is the body of a POST /api/console/exec request.
 type ExecRequest struct {
 	Command string `json:"command" binding:"required"`
+	Stdin   string `json:"stdin"` // Manifests for commands reading "-f -"
 }
 
 // Exec executes a kubectl command and returns its output.
 func (h *ConsoleHandler) Exec(c *gin.Context) {
 	var req ExecRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "command is required"})
+		apierror.Write(c, http.StatusBadRequest, "command is required")
 		return
 	}
 
-	cmd := strings.TrimSpace(req.Command)
+	// Extract user context from Gin
+	userCtxValue, exists := c.Get("userCtx")
+	var user k8s.UserContext
+	if exists {
+		if u, ok := userCtxValue.(k8s.UserContext); ok {
+			user = u
+		}
+	}
+
+	output, exitCode, allowed := h.RunInput(req.Command, req.Stdin, user)
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{
+			"output":   output,
+			"exitCode": exitCode,
+		})
+		return
+	}
 
-	// Expand the `k` alias to `kubectl`
+	c.JSON(http.StatusOK, gin.H{
+		"output":   output,
+		"exitCode": exitCode,
+	})
+}
+
+// normalizeKubectl trims a console command and expands the `k` alias to `kubectl`.
+func normalizeKubectl(cmd string) string {
+	cmd = strings.TrimSpace(cmd)
 	if strings.HasPrefix(cmd, "k ") {
 		cmd = "kubectl " + cmd[2:]
 	} else if cmd == "k" {
 		cmd = "kubectl"
 	}
+	return cmd
+}
+
+// Run executes a console command on behalf of user. allowed is false when the command was
+// refused before running (not kubectl, or touching a protected resource); output then explains why.
+func (h *ConsoleHandler) Run(cmd string, user k8s.UserContext) (output string, exitCode int, allowed bool) {
+	return h.RunInput(cmd, "", user)
+}
+
+// RunInput is Run with stdin for the command, the manifests of "kubectl apply -f -".
+func (h *ConsoleHandler) RunInput(cmd, stdin string, user k8s.UserContext) (output string, exitCode int, allowed bool) {
+	cmd = normalizeKubectl(cmd)
 
 	// Security: only allow kubectl commands
 	if !strings.HasPrefix(cmd, "kubectl") {
-		c.JSON(http.StatusForbidden, gin.H{
-			"output": fmt.Sprintf("bash: %s: command not found\nOnly kubectl commands are supported.", strings.Fields(cmd)[0]),
-			"exitCode": 127,
-		})
-		return
+		name := cmd
+		if fields := strings.Fields(cmd); len(fields) > 0 {
+			name = fields[0]
+		}
+		return fmt.Sprintf("bash: %s: command not found\nOnly kubectl commands are supported.", name), 127, false
 	}
 
-	var output string
-	var exitCode int
-
-	// Extract user context from Gin
-	userCtxValue, exists := c.Get("userCtx")
-	var user k8s.UserContext
-	if exists {
-		if u, ok := userCtxValue.(k8s.UserContext); ok {
-			user = u
-		}
+	// Protected namespaces and kinds cannot be changed from the console either
+	if msg := h.protectedCommand(cmd); msg != "" {
+		return msg, 1, false
+	}
+	if msg := h.protectedManifests(cmd, stdin); msg != "" {
+		return msg, 1, false
+	}
+	// Deletes that need a second admin's approval cannot bypass the queue through the console
+	if msg := h.approvalCommand(cmd, stdin); msg != "" {
+		return msg, 1, false
 	}
 
 	if h.devMode {
-		output, exitCode = mockKubectl(cmd, user)
+		output, exitCode = mockKubectl(cmd, stdin, user)
 	} else {
-		output, exitCode = realKubectl(cmd, user)
+		output, exitCode = realKubectl(cmd, stdin, user)
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"output":   output,
-		"exitCode": exitCode,
-	})
+	return output, exitCode, true
 }
 
 // realKubectl executes kubectl against the real cluster using the in-cluster service account,
 // while impersonating the logged-in user if they are not an administrator.
-func realKubectl(cmd string, user k8s.UserContext) (string, int) {
+func realKubectl(cmd, stdin string, user k8s.UserContext) (string, int) {
 	parts := strings.Fields(cmd)
 	if len(parts) == 0 {
 		return "", 0
@@ -118,7 +156,11 @@ func realKubectl(cmd string, user k8s.UserContext) (string, int) {
 		parts = newParts
 	}
 
-	out, err := exec.Command(parts[0], parts[1:]...).CombinedOutput()
+	command := exec.Command(parts[0], parts[1:]...)
+	if stdin != "" {
+		command.Stdin = strings.NewReader(stdin)
+	}
+	out, err := command.CombinedOutput()
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			return string(out), exitErr.ExitCode()
@@ -130,7 +172,7 @@ func realKubectl(cmd string, user k8s.UserContext) (string, int) {
 
 // mockKubectl parses kubectl commands and returns realistic fake output,
 // simulating RBAC rejections for viewer roles on mutating commands.
-func mockKubectl(cmd string, user k8s.UserContext) (string, int) {
+func mockKubectl(cmd, stdin string, user k8s.UserContext) (string, int) {
 	parts := strings.Fields(cmd)
 	if len(parts) < 2 {
 		return kubectlHelp(), 0
@@ -144,6 +186,11 @@ func mockKubectl(cmd string, user k8s.UserContext) (string, int) {
 		switch sub {
 		case "apply", "delete", "edit", "create", "scale", "auth", "replace", "patch":
 			return fmt.Sprintf("Error from server (Forbidden): %ss is forbidden: User %q cannot %s resource in API group", sub, user.Email, sub), 1
+		case "rollout":
+			// Status and history only read
+			if len(args) > 0 && args[0] != "status" && args[0] != "history" {
+				return fmt.Sprintf("Error from server (Forbidden): deployments.apps is forbidden: User %q cannot patch resource \"deployments\" in API group \"apps\"", user.Email), 1
+			}
 		}
 	}
 
@@ -164,7 +211,11 @@ func mockKubectl(cmd string, user k8s.UserContext) (string, int) {
 	case "logs":
 		return mockLogs(args)
 	case "apply":
-		return "configured\n(dry-run mode: no real changes applied in DEV_MODE)", 0
+		return mockApply(args, stdin)
+	case "rollout":
+		return mockRollout(args)
+	case "scale":
+		return mockScale(args)
 	case "delete":
 		if len(args) > 1 {
 			return fmt.Sprintf("%s \"%s\" deleted", args[0], args[1]), 0
@@ -179,10 +230,10 @@ func mockKubectl(cmd string, user k8s.UserContext) (string, int) {
 	case "config":
 		return mockConfig(args), 0
 	case "top":
-		if len(args) > 0 && args[0] == "nodes" {
+		if len(args) > 0 && (args[0] == "nodes" || args[0] == "node" || args[0] == "no") {
 			return mockTopNodes(), 0
 		}
-		return mockTopPods(ns), 0
+		return mockTopPods(ns, containsAny(args, "-A", "--all-namespaces"))
 	case "api-resources":
 		return mockAPIResources(), 0
 	default:
@@ -397,15 +448,208 @@ worker-02   3400m        42%    21800Mi         67%
 worker-03   2100m        13%    28000Mi         43%`
 }
 
-func mockTopPods(ns string) string {
-	header := "NAME                     CPU(cores)   MEMORY(bytes)"
-	rows := []string{
-		"frontend-web-5d8f7b      12m          45Mi",
-		"backend-api-6c9f8c       85m          210Mi",
-		"cache-redis-001          5m           128Mi",
-		"worker-job-abc12         245m         512Mi",
+// mockPodUsage is the usage of the mock pods for "kubectl top pods".
+var mockPodUsage = []struct{ namespace, name, cpu, memory string }{
+	{"default", "frontend-web-5d8f7b", "12m", "45Mi"},
+	{"default", "backend-api-6c9f8c", "85m", "210Mi"},
+	{"default", "cache-redis-001", "5m", "128Mi"},
+	{"default", "worker-job-abc12", "245m", "512Mi"},
+	{"auth", "auth-service-xyz", "31m", "96Mi"},
+	{"auth", "oauth-proxy-001", "3m", "24Mi"},
+	{"database", "postgres-primary-0", "410m", "1840Mi"},
+	{"database", "postgres-replica-0", "160m", "1210Mi"},
+	{"monitoring", "prometheus-0", "520m", "2650Mi"},
+	{"kube-system", "coredns-5d78c9b4", "4m", "18Mi"},
+	{"kube-system", "kube-proxy-abc12", "1m", "21Mi"},
+}
+
+// mockTopPods returns the usage of the pods in ns (default when empty), or of all pods.
+func mockTopPods(ns string, allNs bool) (string, int) {
+	if ns == "" {
+		ns = "default"
 	}
-	return header + "\n" + strings.Join(rows, "\n")
+	var lines []string
+	if allNs {
+		lines = append(lines, fmt.Sprintf("%-15s %-24s %-12s %s", "NAMESPACE", "NAME", "CPU(cores)", "MEMORY(bytes)"))
+	} else {
+		lines = append(lines, fmt.Sprintf("%-24s %-12s %s", "NAME", "CPU(cores)", "MEMORY(bytes)"))
+	}
+	for _, p := range mockPodUsage {
+		switch {
+		case allNs:
+			lines = append(lines, fmt.Sprintf("%-15s %-24s %-12s %s", p.namespace, p.name, p.cpu, p.memory))
+		case p.namespace == ns:
+			lines = append(lines, fmt.Sprintf("%-24s %-12s %s", p.name, p.cpu, p.memory))
+		}
+	}
+	if len(lines) == 1 {
+		return fmt.Sprintf("No resources found in %s namespace.", ns), 0
+	}
+	return strings.Join(lines, "\n"), 0
+}
+
+// mockWorkloadRef reads the workload of "rollout" and "scale", given as kind/name or as
+// kind name, and returns its kubectl name (deployment.apps) with the name.
+func mockWorkloadRef(args []string) (resource, name string, ok bool) {
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		if strings.HasPrefix(args[i], "-") {
+			if kubectlValueFlags[args[i]] {
+				i++
+			}
+			continue
+		}
+		positional = append(positional, args[i])
+	}
+	if len(positional) == 0 {
+		return "", "", false
+	}
+	kind := positional[0]
+	if i := strings.Index(kind, "/"); i >= 0 {
+		kind, name = kind[:i], kind[i+1:]
+	} else if len(positional) > 1 {
+		name = positional[1]
+	}
+	if i := strings.Index(kind, "."); i >= 0 {
+		kind = kind[:i]
+	}
+	switch strings.ToLower(kind) {
+	case "deploy", "deployment", "deployments":
+		resource = "deployment.apps"
+	case "sts", "statefulset", "statefulsets":
+		resource = "statefulset.apps"
+	case "ds", "daemonset", "daemonsets":
+		resource = "daemonset.apps"
+	case "rs", "replicaset", "replicasets":
+		resource = "replicaset.apps"
+	default:
+		resource = strings.ToLower(kind)
+	}
+	return resource, name, name != ""
+}
+
+// mockRollout simulates "kubectl rollout" on deployments, statefulsets and daemonsets.
+func mockRollout(args []string) (string, int) {
+	if len(args) == 0 {
+		return "error: must specify a rollout subcommand: history, pause, restart, resume, status or undo", 1
+	}
+	action := args[0]
+	resource, name, ok := mockWorkloadRef(args[1:])
+	if !ok {
+		return "error: required resource not specified", 1
+	}
+	if resource != "deployment.apps" && resource != "statefulset.apps" && resource != "daemonset.apps" {
+		return fmt.Sprintf("error: no rollout support for %s", resource), 1
+	}
+	switch action {
+	case "status":
+		switch resource {
+		case "statefulset.apps":
+			return fmt.Sprintf("statefulset rolling update complete 3 pods at revision %s-7d9f8c6b5...", name), 0
+		case "daemonset.apps":
+			return fmt.Sprintf("daemon set %q successfully rolled out", name), 0
+		}
+		return fmt.Sprintf("deployment %q successfully rolled out", name), 0
+	case "history":
+		return fmt.Sprintf("%s/%s \nREVISION  CHANGE-CAUSE\n1         <none>\n2         <none>\n3         <none>", resource, name), 0
+	case "restart":
+		return fmt.Sprintf("%s/%s restarted", resource, name), 0
+	case "undo":
+		return fmt.Sprintf("%s/%s rolled back", resource, name), 0
+	case "pause", "resume":
+		if resource != "deployment.apps" {
+			return fmt.Sprintf("error: %s %q %sing is not supported", strings.Replace(resource, ".", "s.", 1), name, strings.TrimSuffix(action, "e")), 1
+		}
+		return fmt.Sprintf("%s/%s %sd", resource, name, strings.TrimSuffix(action, "e")), 0
+	default:
+		return fmt.Sprintf("error: unknown command %q for \"kubectl rollout\"", action), 1
+	}
+}
+
+// mockScale simulates "kubectl scale" on scalable workloads.
+func mockScale(args []string) (string, int) {
+	replicas := extractFlag(args, "--replicas", "--replicas")
+	if replicas == "" {
+		return "error: required flag(s) \"replicas\" not set", 1
+	}
+	resource, name, ok := mockWorkloadRef(args)
+	if !ok {
+		return "error: resource(s) were provided, but no name was specified", 1
+	}
+	if resource != "deployment.apps" && resource != "statefulset.apps" && resource != "replicaset.apps" {
+		return "Error from server (NotFound): the server could not find the requested resource", 1
+	}
+	return fmt.Sprintf("%s/%s scaled", resource, name), 0
+}
+
+// manifestObject is the identity of one document of an applied manifest.
+type manifestObject struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+}
+
+// parseManifests reads the objects of a multi-document YAML manifest, skipping empty documents.
+func parseManifests(manifest string) ([]manifestObject, error) {
+	var objects []manifestObject
+	decoder := yaml.NewDecoder(strings.NewReader(manifest))
+	for {
+		var obj manifestObject
+		err := decoder.Decode(&obj)
+		if err == io.EOF {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if obj.Kind != "" {
+			objects = append(objects, obj)
+		}
+	}
+}
+
+// mockApply simulates "kubectl apply" of the manifests piped to "-f -". Files cannot be
+// read from the server, so other -f paths fail as they would for a missing file.
+func mockApply(args []string, stdin string) (string, int) {
+	file := extractFlag(args, "-f", "--filename")
+	switch {
+	case file == "":
+		return "error: must specify one of -f and -k", 1
+	case file != "-":
+		return fmt.Sprintf("error: the path %q does not exist", file), 1
+	}
+	objects, err := parseManifests(stdin)
+	if err != nil {
+		return "error: error parsing STDIN: " + err.Error(), 1
+	}
+	if len(objects) == 0 {
+		return "error: no objects passed to apply", 1
+	}
+	suffix := ""
+	for _, a := range args {
+		switch a {
+		case "--dry-run", "--dry-run=client":
+			suffix = " (dry run)"
+		case "--dry-run=server":
+			suffix = " (server dry run)"
+		}
+	}
+	var lines []string
+	for _, obj := range objects {
+		if obj.Metadata.Name == "" {
+			return "error: error when retrieving current configuration: resource name may not be empty", 1
+		}
+		resource := strings.ToLower(obj.Kind)
+		if i := strings.Index(obj.APIVersion, "/"); i >= 0 {
+			resource += "." + obj.APIVersion[:i]
+		}
+		lines = append(lines, fmt.Sprintf("%s/%s configured%s", resource, obj.Metadata.Name, suffix))
+	}
+	lines = append(lines, "(DEV_MODE: no real changes applied)")
+	return strings.Join(lines, "\n"), 0
 }
 
 func mockAPIResources() string {
@@ -430,9 +674,13 @@ Basic Commands:
   describe      Show details of a specific resource
   logs          Print the logs for a container in a pod
   exec          Execute a command in a container
-  apply         Apply a configuration to a resource
+  apply         Apply a configuration to a resource (-f - reads the manifest sent with the command)
   delete        Delete resources
 
+Deploy Commands:
+  rollout       Manage the rollout of a resource
+  scale         Set a new size for a deployment, replica set or stateful set
+
 Cluster Management:
   top           Display resource usage
   version       Print the client and server version
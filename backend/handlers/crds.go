@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	"k-view/apierror"
+)
+
+// maxSkeletonDepth guards against self-referencing or pathologically deep schemas.
+const maxSkeletonDepth = 12
+
+// crdVersion selects the requested version of a CRD, defaulting to the storage version.
+func crdVersion(crd map[string]interface{}, requested string) (map[string]interface{}, bool) {
+	versions, _, _ := unstructured.NestedSlice(crd, "spec", "versions")
+	var storage map[string]interface{}
+	for _, raw := range versions {
+		v, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if requested != "" && v["name"] == requested {
+			return v, true
+		}
+		if isStorage, _ := v["storage"].(bool); isStorage {
+			storage = v
+		}
+	}
+	return storage, requested == "" && storage != nil
+}
+
+// skeletonValue builds a placeholder value for a schema, filling in only required fields.
+func skeletonValue(schema map[string]interface{}, depth int) interface{} {
+	if def, ok := schema["default"]; ok {
+		return def
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	if intOrString, _ := schema["x-kubernetes-int-or-string"].(bool); intOrString {
+		return ""
+	}
+	if depth > maxSkeletonDepth {
+		return nil
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj := map[string]interface{}{}
+		props, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]interface{})
+		for _, r := range required {
+			name, _ := r.(string)
+			if prop, ok := props[name].(map[string]interface{}); ok {
+				obj[name] = skeletonValue(prop, depth+1)
+			} else {
+				obj[name] = ""
+			}
+		}
+		return obj
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		minItems, _ := schema["minItems"].(int64)
+		if minItems == 0 {
+			if f, ok := schema["minItems"].(float64); ok {
+				minItems = int64(f)
+			}
+		}
+		list := []interface{}{}
+		if items != nil && minItems > 0 {
+			list = append(list, skeletonValue(items, depth+1))
+		}
+		return list
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	case "string":
+		return ""
+	}
+	return map[string]interface{}{}
+}
+
+// crdSkeleton builds a manifest for the CRD version with metadata and required spec fields.
+func crdSkeleton(crd map[string]interface{}, version map[string]interface{}) map[string]interface{} {
+	group, _, _ := unstructured.NestedString(crd, "spec", "group")
+	kind, _, _ := unstructured.NestedString(crd, "spec", "names", "kind")
+	scope, _, _ := unstructured.NestedString(crd, "spec", "scope")
+	versionName, _ := version["name"].(string)
+
+	metadata := map[string]interface{}{"name": "my-" + kindToName(kind)}
+	if scope == "Namespaced" {
+		metadata["namespace"] = "default"
+	}
+	manifest := map[string]interface{}{
+		"apiVersion": group + "/" + versionName,
+		"kind":       kind,
+		"metadata":   metadata,
+	}
+
+	schema, _, _ := unstructured.NestedMap(version, "schema", "openAPIV3Schema")
+	props, _ := schema["properties"].(map[string]interface{})
+	skeleton, _ := skeletonValue(schema, 0).(map[string]interface{})
+	for name, value := range skeleton {
+		if name != "apiVersion" && name != "kind" && name != "metadata" && name != "status" {
+			manifest[name] = value
+		}
+	}
+	// spec is rarely marked required but almost always expected
+	if spec, ok := props["spec"].(map[string]interface{}); ok {
+		if _, exists := manifest["spec"]; !exists {
+			manifest["spec"] = skeletonValue(spec, 1)
+		}
+	}
+	return manifest
+}
+
+func kindToName(kind string) string {
+	out := make([]rune, 0, len(kind)+4)
+	for i, r := range kind {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				out = append(out, '-')
+			}
+			r += 'a' - 'A'
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
+
+// GetCRDSchema returns the OpenAPI v3 schema of a CRD version (?version=, default: storage version)
+// together with a skeleton manifest that has every required field filled in.
+func (h *ResourceHandler) GetCRDSchema(c *gin.Context) {
+	name := c.Param("name")
+
+	var crd map[string]interface{}
+	if h.devMode {
+		crd = mockCRD()
+	} else {
+		dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
+		if err != nil {
+			apierror.Fail(c, "Failed to get dynamic client", err)
+			return
+		}
+		item, err := dynClient.Resource(getGVR("crds")).Get(c.Request.Context(), name, metav1.GetOptions{})
+		if err != nil {
+			apierror.Fail(c, "Failed to get resource", err)
+			return
+		}
+		crd = item.Object
+	}
+
+	version, ok := crdVersion(crd, c.Query("version"))
+	if !ok {
+		apierror.Write(c, http.StatusNotFound, "version not found: "+c.Query("version"))
+		return
+	}
+
+	var versions []string
+	rawVersions, _, _ := unstructured.NestedSlice(crd, "spec", "versions")
+	for _, raw := range rawVersions {
+		if v, ok := raw.(map[string]interface{}); ok {
+			if served, _ := v["served"].(bool); served {
+				versions = append(versions, v["name"].(string))
+			}
+		}
+	}
+	sort.Strings(versions)
+
+	skeleton := crdSkeleton(crd, version)
+	skeletonYAML, err := yaml.Marshal(skeleton)
+	if err != nil {
+		apierror.Fail(c, "Failed to render skeleton", err)
+		return
+	}
+
+	schema, _, _ := unstructured.NestedMap(version, "schema", "openAPIV3Schema")
+	c.JSON(http.StatusOK, gin.H{
+		"name":     name,
+		"version":  version["name"],
+		"versions": versions,
+		"schema":   schema,
+		"skeleton": string(skeletonYAML),
+	})
+}
+
+func mockCRD() map[string]interface{} {
+	str := map[string]interface{}{"type": "string"}
+	return map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": "certificates.cert-manager.io"},
+		"spec": map[string]interface{}{
+			"group": "cert-manager.io",
+			"scope": "Namespaced",
+			"names": map[string]interface{}{"kind": "Certificate", "plural": "certificates"},
+			"versions": []interface{}{
+				map[string]interface{}{
+					"name": "v1", "served": true, "storage": true,
+					"schema": map[string]interface{}{"openAPIV3Schema": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"spec": map[string]interface{}{
+								"type":     "object",
+								"required": []interface{}{"issuerRef", "secretName"},
+								"properties": map[string]interface{}{
+									"secretName": str,
+									"dnsNames":   map[string]interface{}{"type": "array", "items": str},
+									"duration":   str,
+									"issuerRef": map[string]interface{}{
+										"type":     "object",
+										"required": []interface{}{"name"},
+										"properties": map[string]interface{}{
+											"name":  str,
+											"kind":  map[string]interface{}{"type": "string", "enum": []interface{}{"Issuer", "ClusterIssuer"}},
+											"group": str,
+										},
+									},
+									"privateKey": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"algorithm": map[string]interface{}{"type": "string", "enum": []interface{}{"RSA", "ECDSA", "Ed25519"}},
+										},
+									},
+								},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,413 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"k-view/apierror"
+)
+
+// Crossplane resources are identified by CRD category rather than by group: every provider CRD
+// for a cloud resource is in the "managed" category, and every XRD-generated composite in
+// "composite".
+var (
+	crossplaneProvidersGVR    = schema.GroupVersionResource{Group: "pkg.crossplane.io", Version: "v1", Resource: "providers"}
+	crossplaneCompositionsGVR = schema.GroupVersionResource{Group: "apiextensions.crossplane.io", Version: "v1", Resource: "compositions"}
+)
+
+// crossplaneKind is a CRD found by category, with the version to read it through.
+type crossplaneKind struct {
+	Kind       string
+	GVR        schema.GroupVersionResource
+	Namespaced bool
+}
+
+// CrossplaneProvider is an installed Crossplane provider package.
+type CrossplaneProvider struct {
+	Name      string `json:"name"`
+	Package   string `json:"package"`
+	Installed string `json:"installed"` // Installed condition: True, False or Unknown
+	Healthy   string `json:"healthy"`
+	Message   string `json:"message,omitempty"`
+	Age       string `json:"age"`
+}
+
+// CrossplaneStatus tells the UI whether Crossplane views have anything to show.
+type CrossplaneStatus struct {
+	Installed      bool                 `json:"installed"`
+	Providers      []CrossplaneProvider `json:"providers"`
+	ManagedKinds   []string             `json:"managedKinds"`
+	CompositeKinds []string             `json:"compositeKinds"`
+}
+
+// CrossplaneResource is a managed or composite resource with its Synced and Ready conditions.
+type CrossplaneResource struct {
+	Kind           string `json:"kind"`
+	APIVersion     string `json:"apiVersion"`
+	Name           string `json:"name"`
+	Namespace      string `json:"namespace,omitempty"`
+	ExternalName   string `json:"externalName,omitempty"`   // Managed: crossplane.io/external-name
+	ProviderConfig string `json:"providerConfig,omitempty"` // Managed
+	Composition    string `json:"composition,omitempty"`    // Composite
+	Claim          string `json:"claim,omitempty"`          // Composite: namespace/name of its claim
+	Resources      int    `json:"resources,omitempty"`      // Composite: composed resources
+	Synced         string `json:"synced"`
+	Ready          string `json:"ready"`
+	Reason         string `json:"reason,omitempty"` // Of the first condition that is not True
+	Message        string `json:"message,omitempty"`
+	Age            string `json:"age"`
+}
+
+// CrossplaneSummary counts resources by condition.
+type CrossplaneSummary struct {
+	Total     int `json:"total"`
+	NotSynced int `json:"notSynced"`
+	NotReady  int `json:"notReady"`
+}
+
+// CrossplaneComposition is a Composition with the number of composites using it.
+type CrossplaneComposition struct {
+	Name          string `json:"name"`
+	CompositeKind string `json:"compositeKind"`
+	CompositeAPI  string `json:"compositeApiVersion"`
+	Mode          string `json:"mode"`
+	Steps         int    `json:"steps"` // Pipeline steps, or resources in Resources mode
+	Instances     int    `json:"instances"`
+	Age           string `json:"age"`
+}
+
+// crossplaneKinds lists the CRDs in a category (managed, composite, claim).
+func crossplaneKinds(ctx context.Context, dynClient dynamic.Interface, category string) ([]crossplaneKind, error) {
+	list, err := dynClient.Resource(getGVR("crds")).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var kinds []crossplaneKind
+	for _, crd := range list.Items {
+		categories, _, _ := unstructured.NestedStringSlice(crd.Object, "spec", "names", "categories")
+		if !contains(categories, category) {
+			continue
+		}
+		version, ok := crdVersion(crd.Object, "")
+		if !ok {
+			continue
+		}
+		group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+		plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+		kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+		scope, _, _ := unstructured.NestedString(crd.Object, "spec", "scope")
+		versionName, _ := version["name"].(string)
+		kinds = append(kinds, crossplaneKind{
+			Kind:       kind,
+			GVR:        schema.GroupVersionResource{Group: group, Version: versionName, Resource: plural},
+			Namespaced: scope == "Namespaced",
+		})
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i].Kind < kinds[j].Kind })
+	return kinds, nil
+}
+
+// crossplaneConditions reads the Synced and Ready conditions, and the reason and message of the
+// first one that is not True.
+func crossplaneConditions(obj map[string]interface{}) (synced, ready, reason, message string) {
+	synced, ready = "Unknown", "Unknown"
+	conditions, _, _ := unstructured.NestedSlice(obj, "status", "conditions")
+	for _, raw := range conditions {
+		cond, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		status, _ := cond["status"].(string)
+		switch cond["type"] {
+		case "Synced":
+			synced = status
+		case "Ready":
+			ready = status
+		default:
+			continue
+		}
+		if status != "True" && reason == "" {
+			reason, _ = cond["reason"].(string)
+			message, _ = cond["message"].(string)
+		}
+	}
+	return synced, ready, reason, message
+}
+
+func crossplaneResource(item unstructured.Unstructured) CrossplaneResource {
+	r := CrossplaneResource{
+		Kind:         item.GetKind(),
+		APIVersion:   item.GetAPIVersion(),
+		Name:         item.GetName(),
+		Namespace:    item.GetNamespace(),
+		ExternalName: item.GetAnnotations()["crossplane.io/external-name"],
+		Age:          getAge(item.GetCreationTimestamp().Time),
+	}
+	r.Synced, r.Ready, r.Reason, r.Message = crossplaneConditions(item.Object)
+	r.ProviderConfig, _, _ = unstructured.NestedString(item.Object, "spec", "providerConfigRef", "name")
+	// Crossplane v2 moved the composite machinery under spec.crossplane
+	spec, _, _ := unstructured.NestedMap(item.Object, "spec")
+	if nested, ok, _ := unstructured.NestedMap(spec, "crossplane"); ok {
+		spec = nested
+	}
+	r.Composition, _, _ = unstructured.NestedString(spec, "compositionRef", "name")
+	refs, _, _ := unstructured.NestedSlice(spec, "resourceRefs")
+	r.Resources = len(refs)
+	claimName, _, _ := unstructured.NestedString(spec, "claimRef", "name")
+	if claimName != "" {
+		claimNs, _, _ := unstructured.NestedString(spec, "claimRef", "namespace")
+		r.Claim = claimNs + "/" + claimName
+	}
+	return r
+}
+
+// listCrossplaneResources lists every kind in a category, skipping kinds that fail to list
+// (e.g. a provider being upgraded) rather than failing the whole view.
+func listCrossplaneResources(ctx context.Context, dynClient dynamic.Interface, category, ns string) ([]CrossplaneResource, error) {
+	kinds, err := crossplaneKinds(ctx, dynClient, category)
+	if err != nil {
+		return nil, err
+	}
+	resources := []CrossplaneResource{}
+	for _, k := range kinds {
+		var list *unstructured.UnstructuredList
+		if k.Namespaced && ns != "" {
+			list, err = dynClient.Resource(k.GVR).Namespace(ns).List(ctx, metav1.ListOptions{})
+		} else {
+			list, err = dynClient.Resource(k.GVR).List(ctx, metav1.ListOptions{})
+		}
+		if err != nil {
+			continue
+		}
+		for _, item := range list.Items {
+			resources = append(resources, crossplaneResource(item))
+		}
+	}
+	return resources, nil
+}
+
+func summarizeCrossplane(resources []CrossplaneResource) CrossplaneSummary {
+	s := CrossplaneSummary{Total: len(resources)}
+	for _, r := range resources {
+		if r.Synced != "True" {
+			s.NotSynced++
+		}
+		if r.Ready != "True" {
+			s.NotReady++
+		}
+	}
+	return s
+}
+
+// sortCrossplane puts unhealthy resources first, then orders by kind and name.
+func sortCrossplane(resources []CrossplaneResource) {
+	sort.Slice(resources, func(i, j int) bool {
+		a, b := resources[i], resources[j]
+		aOK, bOK := a.Synced == "True" && a.Ready == "True", b.Synced == "True" && b.Ready == "True"
+		if aOK != bOK {
+			return bOK
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+}
+
+// GetCrossplaneStatus detects Crossplane and lists its providers and the managed and composite
+// kinds they installed, so the UI only shows the Crossplane views when they can return data.
+func (h *ResourceHandler) GetCrossplaneStatus(c *gin.Context) {
+	if h.devMode {
+		c.JSON(http.StatusOK, mockCrossplaneStatus())
+		return
+	}
+	ctx := c.Request.Context()
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+	status := CrossplaneStatus{Providers: []CrossplaneProvider{}, ManagedKinds: []string{}, CompositeKinds: []string{}}
+	if _, err := dynClient.Resource(getGVR("crds")).Get(ctx, "providers.pkg.crossplane.io", metav1.GetOptions{}); err != nil {
+		c.JSON(http.StatusOK, status)
+		return
+	}
+	status.Installed = true
+
+	providers, err := dynClient.Resource(crossplaneProvidersGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		apierror.Fail(c, "Failed to list providers", err)
+		return
+	}
+	for _, item := range providers.Items {
+		p := CrossplaneProvider{Name: item.GetName(), Installed: "Unknown", Healthy: "Unknown", Age: getAge(item.GetCreationTimestamp().Time)}
+		p.Package, _, _ = unstructured.NestedString(item.Object, "spec", "package")
+		conditions, _, _ := unstructured.NestedSlice(item.Object, "status", "conditions")
+		for _, raw := range conditions {
+			cond, _ := raw.(map[string]interface{})
+			status, _ := cond["status"].(string)
+			switch cond["type"] {
+			case "Installed":
+				p.Installed = status
+			case "Healthy":
+				p.Healthy = status
+			default:
+				continue
+			}
+			if status != "True" && p.Message == "" {
+				p.Message, _ = cond["message"].(string)
+			}
+		}
+		status.Providers = append(status.Providers, p)
+	}
+
+	for category, target := range map[string]*[]string{"managed": &status.ManagedKinds, "composite": &status.CompositeKinds} {
+		kinds, err := crossplaneKinds(ctx, dynClient, category)
+		if err != nil {
+			apierror.Fail(c, "Failed to list CRDs", err)
+			return
+		}
+		for _, k := range kinds {
+			*target = append(*target, k.Kind+"."+k.GVR.Group)
+		}
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// ListManagedResources lists Crossplane managed resources of all providers with their Synced and
+// Ready conditions, unhealthy ones first. ?kind= filters by kind and ?problems=true keeps only
+// resources that are not both synced and ready.
+func (h *ResourceHandler) ListManagedResources(c *gin.Context) {
+	var resources []CrossplaneResource
+	if h.devMode {
+		resources = mockManagedResources()
+	} else {
+		ctx := c.Request.Context()
+		dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+		if err != nil {
+			apierror.Fail(c, "Failed to get dynamic client", err)
+			return
+		}
+		resources, err = listCrossplaneResources(ctx, dynClient, "managed", rbacNamespace(c))
+		if err != nil {
+			apierror.Fail(c, "Failed to list managed resources", err)
+			return
+		}
+	}
+
+	filtered := []CrossplaneResource{}
+	for _, r := range resources {
+		if kind := c.Query("kind"); kind != "" && !strings.EqualFold(r.Kind, kind) {
+			continue
+		}
+		if c.Query("problems") == "true" && r.Synced == "True" && r.Ready == "True" {
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	sortCrossplane(filtered)
+	c.JSON(http.StatusOK, gin.H{"summary": summarizeCrossplane(resources), "items": filtered})
+}
+
+// ListCompositions lists Compositions together with the composite resources (XRs) built from
+// them and the XRs' conditions.
+func (h *ResourceHandler) ListCompositions(c *gin.Context) {
+	if h.devMode {
+		composites := mockCompositeResources()
+		sortCrossplane(composites)
+		c.JSON(http.StatusOK, gin.H{"compositions": mockCompositions(), "composites": composites, "summary": summarizeCrossplane(composites)})
+		return
+	}
+	ctx := c.Request.Context()
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+	list, err := dynClient.Resource(crossplaneCompositionsGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		apierror.Fail(c, "Failed to list compositions", err)
+		return
+	}
+	composites, err := listCrossplaneResources(ctx, dynClient, "composite", rbacNamespace(c))
+	if err != nil {
+		apierror.Fail(c, "Failed to list composite resources", err)
+		return
+	}
+	instances := map[string]int{}
+	for _, r := range composites {
+		instances[r.Composition]++
+	}
+
+	compositions := []CrossplaneComposition{}
+	for _, item := range list.Items {
+		comp := CrossplaneComposition{Name: item.GetName(), Instances: instances[item.GetName()], Age: getAge(item.GetCreationTimestamp().Time)}
+		comp.CompositeKind, _, _ = unstructured.NestedString(item.Object, "spec", "compositeTypeRef", "kind")
+		comp.CompositeAPI, _, _ = unstructured.NestedString(item.Object, "spec", "compositeTypeRef", "apiVersion")
+		comp.Mode, _, _ = unstructured.NestedString(item.Object, "spec", "mode")
+		if comp.Mode == "" {
+			comp.Mode = "Resources"
+		}
+		if comp.Mode == "Pipeline" {
+			steps, _, _ := unstructured.NestedSlice(item.Object, "spec", "pipeline")
+			comp.Steps = len(steps)
+		} else {
+			resources, _, _ := unstructured.NestedSlice(item.Object, "spec", "resources")
+			comp.Steps = len(resources)
+		}
+		compositions = append(compositions, comp)
+	}
+	sort.Slice(compositions, func(i, j int) bool { return compositions[i].Name < compositions[j].Name })
+	sortCrossplane(composites)
+	c.JSON(http.StatusOK, gin.H{"compositions": compositions, "composites": composites, "summary": summarizeCrossplane(composites)})
+}
+
+func mockCrossplaneStatus() CrossplaneStatus {
+	return CrossplaneStatus{
+		Installed: true,
+		Providers: []CrossplaneProvider{
+			{Name: "provider-aws-s3", Package: "xpkg.upbound.io/upbound/provider-aws-s3:v1.14.0", Installed: "True", Healthy: "True", Age: "40d"},
+			{Name: "provider-aws-rds", Package: "xpkg.upbound.io/upbound/provider-aws-rds:v1.14.0", Installed: "True", Healthy: "True", Age: "40d"},
+			{Name: "provider-gcp-storage", Package: "xpkg.upbound.io/upbound/provider-gcp-storage:v1.8.0", Installed: "True", Healthy: "False", Message: "cannot get package revision health: provider pod is not ready", Age: "2d"},
+		},
+		ManagedKinds:   []string{"Bucket.s3.aws.upbound.io", "BucketVersioning.s3.aws.upbound.io", "Instance.rds.aws.upbound.io", "SubnetGroup.rds.aws.upbound.io"},
+		CompositeKinds: []string{"XDatabase.platform.example.com", "XBucket.platform.example.com"},
+	}
+}
+
+func mockManagedResources() []CrossplaneResource {
+	s3, rds := "s3.aws.upbound.io/v1beta2", "rds.aws.upbound.io/v1beta3"
+	return []CrossplaneResource{
+		{Kind: "Bucket", APIVersion: s3, Name: "team-a-artifacts-x7k2p", ExternalName: "team-a-artifacts", ProviderConfig: "default", Synced: "True", Ready: "True", Age: "30d"},
+		{Kind: "Bucket", APIVersion: s3, Name: "team-b-logs-9dfq1", ExternalName: "team-b-logs", ProviderConfig: "default", Synced: "False", Ready: "True", Reason: "ReconcileError",
+			Message: "update failed: operation error S3: PutBucketTagging, https response error StatusCode: 403, AccessDenied", Age: "12d"},
+		{Kind: "BucketVersioning", APIVersion: s3, Name: "team-a-artifacts-versioning", ExternalName: "team-a-artifacts", ProviderConfig: "default", Synced: "True", Ready: "True", Age: "30d"},
+		{Kind: "Instance", APIVersion: rds, Name: "orders-db-h2m8s", ExternalName: "orders-db-h2m8s", ProviderConfig: "default", Synced: "True", Ready: "False", Reason: "Creating",
+			Message: "DB instance is being created", Age: "6m"},
+		{Kind: "SubnetGroup", APIVersion: rds, Name: "orders-db-subnets", ExternalName: "orders-db-subnets", ProviderConfig: "default", Synced: "True", Ready: "True", Age: "6m"},
+	}
+}
+
+func mockCompositeResources() []CrossplaneResource {
+	return []CrossplaneResource{
+		{Kind: "XDatabase", APIVersion: "platform.example.com/v1alpha1", Name: "orders-db-h2m8s", Composition: "xdatabases.aws.platform.example.com", Claim: "orders/orders-db",
+			Resources: 2, Synced: "True", Ready: "False", Reason: "Creating", Message: "Unready resources: instance", Age: "6m"},
+		{Kind: "XBucket", APIVersion: "platform.example.com/v1alpha1", Name: "team-a-artifacts-x7k2p", Composition: "xbuckets.aws.platform.example.com", Claim: "team-a/artifacts",
+			Resources: 2, Synced: "True", Ready: "True", Age: "30d"},
+	}
+}
+
+func mockCompositions() []CrossplaneComposition {
+	return []CrossplaneComposition{
+		{Name: "xbuckets.aws.platform.example.com", CompositeKind: "XBucket", CompositeAPI: "platform.example.com/v1alpha1", Mode: "Pipeline", Steps: 2, Instances: 1, Age: "40d"},
+		{Name: "xdatabases.aws.platform.example.com", CompositeKind: "XDatabase", CompositeAPI: "platform.example.com/v1alpha1", Mode: "Pipeline", Steps: 3, Instances: 1, Age: "40d"},
+	}
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"k-view/apierror"
+)
+
+var csrGVR = schema.GroupVersionResource{Group: "certificates.k8s.io", Version: "v1", Resource: "certificatesigningrequests"}
+
+// Signers with built-in meaning, see https://kubernetes.io/docs/reference/access-authn-authz/certificate-signing-requests/#kubernetes-signers
+const (
+	kubeletServingSigner = "kubernetes.io/kubelet-serving"
+	kubeletClientSigner  = "kubernetes.io/kube-apiserver-client-kubelet"
+	apiClientSigner      = "kubernetes.io/kube-apiserver-client"
+)
+
+// CSRInfo summarizes a CertificateSigningRequest and the certificate it asks for.
+type CSRInfo struct {
+	Name              string    `json:"name"`
+	SignerName        string    `json:"signerName"`
+	Requestor         string    `json:"requestor"` // spec.username: who submitted it
+	RequestorGroups   []string  `json:"requestorGroups,omitempty"`
+	Status            string    `json:"status"` // Pending, Approved, Issued, Denied, Failed
+	Reason            string    `json:"reason,omitempty"`
+	Message           string    `json:"message,omitempty"`
+	CommonName        string    `json:"commonName,omitempty"`
+	Organizations     []string  `json:"organizations,omitempty"`
+	DNSNames          []string  `json:"dnsNames,omitempty"`
+	IPAddresses       []string  `json:"ipAddresses,omitempty"`
+	Usages            []string  `json:"usages,omitempty"`
+	ExpirationSeconds int64     `json:"expirationSeconds,omitempty"`
+	CreatedAt         time.Time `json:"createdAt"`
+	// Warnings are reasons to look twice before approving, such as a kubelet asking for a
+	// serving certificate for another node or for addresses its node does not have
+	Warnings []string `json:"warnings"`
+}
+
+// csrStatus derives the state of a CSR from its conditions and issued certificate.
+func csrStatus(obj map[string]interface{}) (status, reason, message string) {
+	status = "Pending"
+	conditions, _, _ := unstructured.NestedSlice(obj, "status", "conditions")
+	for _, raw := range conditions {
+		cond, _ := raw.(map[string]interface{})
+		if s, _ := cond["status"].(string); s == "False" {
+			continue
+		}
+		t, _ := cond["type"].(string)
+		switch t {
+		case "Denied", "Failed":
+			status = t
+		case "Approved":
+			if status == "Pending" {
+				status = "Approved"
+			}
+		default:
+			continue
+		}
+		reason, _ = cond["reason"].(string)
+		message, _ = cond["message"].(string)
+	}
+	if cert, _, _ := unstructured.NestedString(obj, "status", "certificate"); status == "Approved" && cert != "" {
+		status = "Issued"
+	}
+	return status, reason, message
+}
+
+// toCSRInfo summarizes a CSR; nodeAddresses maps node names to their addresses for the
+// kubelet checks.
+func toCSRInfo(obj unstructured.Unstructured, nodeAddresses map[string][]string) CSRInfo {
+	spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+	info := CSRInfo{Name: obj.GetName(), CreatedAt: obj.GetCreationTimestamp().Time, Warnings: []string{}}
+	info.SignerName, _ = spec["signerName"].(string)
+	info.Requestor, _ = spec["username"].(string)
+	info.RequestorGroups, _, _ = unstructured.NestedStringSlice(spec, "groups")
+	info.Usages, _, _ = unstructured.NestedStringSlice(spec, "usages")
+	info.ExpirationSeconds, _, _ = unstructured.NestedInt64(spec, "expirationSeconds")
+	info.Status, info.Reason, info.Message = csrStatus(obj.Object)
+
+	// spec.request is the base64 PEM; unstructured keeps it as the base64 string
+	encoded, _ := spec["request"].(string)
+	req, err := parseCSRRequest(encoded)
+	if err != nil {
+		info.Warnings = append(info.Warnings, "the request could not be decoded: "+err.Error())
+		return info
+	}
+	info.CommonName, info.Organizations, info.DNSNames = req.Subject.CommonName, req.Subject.Organization, req.DNSNames
+	for _, ip := range req.IPAddresses {
+		info.IPAddresses = append(info.IPAddresses, ip.String())
+	}
+
+	switch info.SignerName {
+	case kubeletServingSigner, kubeletClientSigner:
+		node, isNode := strings.CutPrefix(info.CommonName, "system:node:")
+		if !isNode || !contains(info.Organizations, "system:nodes") {
+			info.Warnings = append(info.Warnings, "the subject is not a node identity (CN=system:node:<name>, O=system:nodes)")
+			break
+		}
+		// A node's first client certificate is requested with a bootstrap token; renewals and
+		// serving certificates come from the node itself
+		if (info.SignerName == kubeletServingSigner || strings.HasPrefix(info.Requestor, "system:node:")) && info.Requestor != info.CommonName {
+			info.Warnings = append(info.Warnings, fmt.Sprintf("requested by %s for %s", info.Requestor, info.CommonName))
+		}
+		if info.SignerName != kubeletServingSigner || nodeAddresses == nil {
+			break
+		}
+		addresses, known := nodeAddresses[node]
+		if !known {
+			info.Warnings = append(info.Warnings, "node "+node+" does not exist")
+			break
+		}
+		for _, san := range append(append([]string{}, info.DNSNames...), info.IPAddresses...) {
+			if !contains(addresses, san) {
+				info.Warnings = append(info.Warnings, san+" is not an address of node "+node)
+			}
+		}
+	case apiClientSigner:
+		if contains(info.Organizations, "system:masters") {
+			info.Warnings = append(info.Warnings, "the certificate would grant cluster-admin (O=system:masters)")
+		}
+	}
+	return info
+}
+
+func parseCSRRequest(encoded string) (*x509.CertificateRequest, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("not a PEM certificate request")
+	}
+	return x509.ParseCertificateRequest(block.Bytes)
+}
+
+// ListCSRs lists CertificateSigningRequests, pending ones first, with what each certificate
+// would contain. ?status=pending limits the list to those awaiting a decision.
+func (h *ResourceHandler) ListCSRs(c *gin.Context) {
+	filter := strings.ToLower(c.Query("status"))
+	var csrs []CSRInfo
+	if h.devMode {
+		csrs = mockCSRs()
+	} else {
+		ctx := c.Request.Context()
+		dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+		if err != nil {
+			apierror.Fail(c, "Failed to get dynamic client", err)
+			return
+		}
+		list, err := dynClient.Resource(csrGVR).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			apierror.Fail(c, "Failed to list certificate signing requests", err)
+			return
+		}
+		// Node addresses back the kubelet checks; without them those checks are skipped
+		var nodeAddresses map[string][]string
+		if nodes, err := h.k8sClient.ListNodes(ctx); err == nil {
+			nodeAddresses = map[string][]string{}
+			for _, n := range nodes {
+				for _, a := range n.Status.Addresses {
+					nodeAddresses[n.Name] = append(nodeAddresses[n.Name], a.Address)
+				}
+			}
+		}
+		for _, item := range list.Items {
+			csrs = append(csrs, toCSRInfo(item, nodeAddresses))
+		}
+	}
+
+	result := []CSRInfo{}
+	for _, csr := range csrs {
+		if filter == "" || strings.ToLower(csr.Status) == filter {
+			result = append(result, csr)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if pi, pj := result[i].Status == "Pending", result[j].Status == "Pending"; pi != pj {
+			return pi
+		}
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	c.JSON(http.StatusOK, result)
+}
+
+// ApproveCSR approves a pending CertificateSigningRequest (admin only); its signer then
+// issues the certificate.
+func (h *ResourceHandler) ApproveCSR(c *gin.Context) {
+	h.decideCSR(c, "Approved")
+}
+
+// DenyCSR denies a pending CertificateSigningRequest (admin only).
+func (h *ResourceHandler) DenyCSR(c *gin.Context) {
+	h.decideCSR(c, "Denied")
+}
+
+func (h *ResourceHandler) decideCSR(c *gin.Context, decision string) {
+	name := c.Param("name")
+	var req struct {
+		Message string `json:"message"`
+	}
+	_ = c.ShouldBindJSON(&req)
+	email, _ := c.Get("email")
+	if req.Message == "" {
+		req.Message = fmt.Sprintf("%s in K-View by %v", decision, email)
+	}
+	verb, reason := "approve", "KViewApprove"
+	if decision == "Denied" {
+		verb, reason = "deny", "KViewDeny"
+	}
+
+	if h.devMode {
+		auditf("certificate signing request %s %s by %v (mocked)", name, strings.ToLower(decision), email)
+		c.JSON(http.StatusOK, gin.H{"message": "Certificate signing request " + name + " " + strings.ToLower(decision) + " (mocked)"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+	obj, err := dynClient.Resource(csrGVR).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		apierror.Fail(c, "Failed to get resource", err)
+		return
+	}
+	if status, _, _ := csrStatus(obj.Object); status != "Pending" {
+		apierror.Write(c, http.StatusConflict, "certificate signing request "+name+" is already "+strings.ToLower(status))
+		return
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	now := time.Now().UTC().Format(time.RFC3339)
+	conditions = append(conditions, map[string]interface{}{
+		"type":               decision,
+		"status":             "True",
+		"reason":             reason,
+		"message":            req.Message,
+		"lastUpdateTime":     now,
+		"lastTransitionTime": now,
+	})
+	if err := unstructured.SetNestedSlice(obj.Object, conditions, "status", "conditions"); err != nil {
+		apierror.Fail(c, "", err)
+		return
+	}
+	if _, err := dynClient.Resource(csrGVR).Update(ctx, obj, metav1.UpdateOptions{}, "approval"); err != nil {
+		if apierrors.IsConflict(err) {
+			apierror.Write(c, http.StatusConflict, "certificate signing request "+name+" changed meanwhile; reload and try again")
+			return
+		}
+		apierror.Fail(c, "Failed to "+verb+" certificate signing request", err)
+		return
+	}
+	signer, _, _ := unstructured.NestedString(obj.Object, "spec", "signerName")
+	auditf("certificate signing request %s (signer %s) %s by %v", name, signer, strings.ToLower(decision), email)
+	c.JSON(http.StatusOK, gin.H{"message": "Certificate signing request " + name + " " + strings.ToLower(decision)})
+}
+
+func mockCSRs() []CSRInfo {
+	now := time.Now().UTC()
+	return []CSRInfo{
+		{Name: "csr-8xk2p", SignerName: kubeletServingSigner, Requestor: "system:node:worker-1", RequestorGroups: []string{"system:nodes", "system:authenticated"},
+			Status: "Pending", CommonName: "system:node:worker-1", Organizations: []string{"system:nodes"}, DNSNames: []string{"worker-1"}, IPAddresses: []string{"10.0.1.11"},
+			Usages: []string{"digital signature", "server auth"}, CreatedAt: now.Add(-3 * time.Minute), Warnings: []string{}},
+		{Name: "csr-q7w4d", SignerName: kubeletServingSigner, Requestor: "system:node:worker-2", RequestorGroups: []string{"system:nodes", "system:authenticated"},
+			Status: "Pending", CommonName: "system:node:worker-2", Organizations: []string{"system:nodes"}, DNSNames: []string{"worker-2"}, IPAddresses: []string{"10.0.1.12", "203.0.113.7"},
+			Usages: []string{"digital signature", "server auth"}, CreatedAt: now.Add(-9 * time.Minute), Warnings: []string{"203.0.113.7 is not an address of node worker-2"}},
+		{Name: "jane-developer", SignerName: apiClientSigner, Requestor: "admin@kview.local", RequestorGroups: []string{"system:authenticated"},
+			Status: "Issued", Reason: "KViewApprove", Message: "Approved in K-View by admin@kview.local", CommonName: "jane", Organizations: []string{"developers"},
+			Usages: []string{"client auth"}, ExpirationSeconds: 86400, CreatedAt: now.Add(-26 * time.Hour), Warnings: []string{}},
+		{Name: "csr-b9m1z", SignerName: kubeletClientSigner, Requestor: "system:bootstrap:abcdef", RequestorGroups: []string{"system:bootstrappers"},
+			Status: "Denied", Reason: "KViewDeny", Message: "Unknown machine", CommonName: "system:node:rogue", Organizations: []string{"system:nodes"},
+			Usages: []string{"digital signature", "client auth"}, CreatedAt: now.Add(-50 * time.Hour), Warnings: []string{}},
+	}
+}
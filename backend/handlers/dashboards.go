@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"k-view/apierror"
+	"k-view/rbac"
+	"k-view/store"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const dashboardsDoc = "dashboards"
+
+var errDashboardNotFound = errors.New("dashboard not found")
+
+// Widget types and the chart metrics they can show.
+var (
+	widgetTypes   = []string{"stat", "table", "chart"}
+	chartMetrics  = []string{"cpu", "memory", "restarts", "unschedulable", "unavailable-deployments"}
+	statAggregate = []string{"count", "running", "failed", "pending"}
+)
+
+// DashboardWidget is one tile of a dashboard. Stat cards and tables read a resource kind
+// through the resource API, so users only see what they could list anyway; charts read a
+// series from the stats API.
+type DashboardWidget struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"` // stat, table or chart
+	Title string `json:"title"`
+	// Kind is the resource kind of stat and table widgets, as in /api/resources/:kind
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"` // Empty: all namespaces the user can see
+	Selector  string `json:"selector,omitempty"`  // Label selector
+	// Filter keeps table rows whose name or status contains it
+	Filter    string   `json:"filter,omitempty"`
+	Columns   []string `json:"columns,omitempty"`   // Table columns; empty shows the defaults
+	Aggregate string   `json:"aggregate,omitempty"` // Stat: count, running, failed or pending
+	Metric    string   `json:"metric,omitempty"`    // Chart: cpu, memory, restarts, unschedulable or unavailable-deployments
+	// Position on a 12-column grid
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Dashboard is a user-defined landing page. It belongs to its owner and, when Team is set, is
+// shared read-only with the members of that team.
+type Dashboard struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name" binding:"required"`
+	Description string            `json:"description,omitempty"`
+	Owner       string            `json:"owner"`
+	Team        string            `json:"team,omitempty"`
+	Widgets     []DashboardWidget `json:"widgets"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	UpdatedAt   time.Time         `json:"updatedAt"`
+}
+
+// validate normalises the widgets, giving new ones an ID and a default size.
+func (d *Dashboard) validate() error {
+	if d.Widgets == nil {
+		d.Widgets = []DashboardWidget{}
+	}
+	for i := range d.Widgets {
+		w := &d.Widgets[i]
+		if w.ID == "" {
+			w.ID = newID()
+		}
+		w.Type = strings.ToLower(w.Type)
+		switch w.Type {
+		case "stat", "table":
+			if w.Kind == "" {
+				return fmt.Errorf("widget %q needs a resource kind", w.Title)
+			}
+			w.Kind = strings.ToLower(w.Kind)
+			if w.Type == "stat" {
+				if w.Aggregate == "" {
+					w.Aggregate = "count"
+				}
+				if !contains(statAggregate, w.Aggregate) {
+					return fmt.Errorf("widget %q: aggregate must be one of %s", w.Title, strings.Join(statAggregate, ", "))
+				}
+			}
+		case "chart":
+			if !contains(chartMetrics, w.Metric) {
+				return fmt.Errorf("widget %q: metric must be one of %s", w.Title, strings.Join(chartMetrics, ", "))
+			}
+		default:
+			return fmt.Errorf("widget type must be one of %s", strings.Join(widgetTypes, ", "))
+		}
+		if w.Selector != "" {
+			if _, err := metav1.ParseToLabelSelector(w.Selector); err != nil {
+				return fmt.Errorf("widget %q: invalid selector: %v", w.Title, err)
+			}
+		}
+		if w.Width <= 0 {
+			w.Width = 4
+		}
+		if w.Height <= 0 {
+			w.Height = 2
+		}
+		if w.X < 0 || w.Y < 0 || w.X+w.Width > 12 {
+			return fmt.Errorf("widget %q does not fit the 12-column grid", w.Title)
+		}
+	}
+	return nil
+}
+
+// DashboardHandler stores user-defined dashboards. Owners and admins may change a dashboard;
+// members of its team may view it.
+type DashboardHandler struct {
+	store *store.Store
+	rbac  *rbac.RBACConfig
+}
+
+func NewDashboardHandler(st *store.Store, config *rbac.RBACConfig) *DashboardHandler {
+	return &DashboardHandler{store: st, rbac: config}
+}
+
+// caller returns the signed-in user, their teams and whether they are an admin.
+func (h *DashboardHandler) caller(c *gin.Context) (string, []string, bool) {
+	email := c.GetString("email")
+	role, _ := c.Get("role")
+	r, _ := role.(string)
+	return email, h.rbac.TeamsOf(email, c.GetStringSlice("groups")), isAdminRole(r)
+}
+
+func (h *DashboardHandler) load(c *gin.Context) ([]Dashboard, bool) {
+	var dashboards []Dashboard
+	if err := h.store.Load(dashboardsDoc, &dashboards); err != nil {
+		apierror.Fail(c, "Failed to load dashboards", err)
+		return nil, false
+	}
+	return dashboards, true
+}
+
+// List returns the caller's dashboards and those shared with their teams. Admins get every
+// dashboard with ?all=true.
+func (h *DashboardHandler) List(c *gin.Context) {
+	dashboards, ok := h.load(c)
+	if !ok {
+		return
+	}
+	user, teams, admin := h.caller(c)
+	all := admin && c.Query("all") == "true"
+	result := []Dashboard{}
+	for _, d := range dashboards {
+		if all || d.Owner == user || (d.Team != "" && contains(teams, d.Team)) {
+			result = append(result, d)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return strings.ToLower(result[i].Name) < strings.ToLower(result[j].Name) })
+	c.JSON(http.StatusOK, result)
+}
+
+// Get returns one dashboard the caller may view.
+func (h *DashboardHandler) Get(c *gin.Context) {
+	dashboards, ok := h.load(c)
+	if !ok {
+		return
+	}
+	user, teams, admin := h.caller(c)
+	for _, d := range dashboards {
+		if d.ID == c.Param("id") && (admin || d.Owner == user || (d.Team != "" && contains(teams, d.Team))) {
+			c.JSON(http.StatusOK, d)
+			return
+		}
+	}
+	apierror.Write(c, http.StatusNotFound, "dashboard "+c.Param("id")+" not found")
+}
+
+// bind reads and validates a dashboard from the request body. Only members of a team, or
+// admins, may share a dashboard with it.
+func (h *DashboardHandler) bind(c *gin.Context) (Dashboard, bool) {
+	var d Dashboard
+	if err := c.ShouldBindJSON(&d); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "name is required")
+		return d, false
+	}
+	if err := d.validate(); err != nil {
+		apierror.Write(c, http.StatusBadRequest, err.Error())
+		return d, false
+	}
+	_, teams, admin := h.caller(c)
+	if d.Team == "" {
+		return d, true
+	}
+	exists := false
+	for _, t := range h.rbac.Teams() {
+		exists = exists || t.Name == d.Team
+	}
+	if !exists {
+		apierror.Write(c, http.StatusBadRequest, "team "+d.Team+" not found")
+		return d, false
+	}
+	if !admin && !contains(teams, d.Team) {
+		apierror.Write(c, http.StatusForbidden, "you are not a member of team "+d.Team)
+		return d, false
+	}
+	return d, true
+}
+
+// Create saves a new dashboard owned by the caller.
+func (h *DashboardHandler) Create(c *gin.Context) {
+	d, ok := h.bind(c)
+	if !ok {
+		return
+	}
+	d.ID = newID()
+	d.Owner = c.GetString("email")
+	d.CreatedAt = time.Now().UTC()
+	d.UpdatedAt = d.CreatedAt
+
+	var dashboards []Dashboard
+	err := h.store.Update(dashboardsDoc, &dashboards, func() error {
+		dashboards = append(dashboards, d)
+		return nil
+	})
+	if err != nil {
+		apierror.Fail(c, "Failed to save dashboard", err)
+		return
+	}
+	c.JSON(http.StatusCreated, d)
+}
+
+// Update replaces a dashboard's name, team and widgets (owner or admin).
+func (h *DashboardHandler) Update(c *gin.Context) {
+	id := c.Param("id")
+	input, ok := h.bind(c)
+	if !ok {
+		return
+	}
+	user, _, admin := h.caller(c)
+
+	var dashboards []Dashboard
+	var updated Dashboard
+	err := h.store.Update(dashboardsDoc, &dashboards, func() error {
+		for i := range dashboards {
+			if dashboards[i].ID != id || (!admin && dashboards[i].Owner != user) {
+				continue
+			}
+			input.ID, input.Owner, input.CreatedAt = dashboards[i].ID, dashboards[i].Owner, dashboards[i].CreatedAt
+			input.UpdatedAt = time.Now().UTC()
+			dashboards[i] = input
+			updated = input
+			return nil
+		}
+		return errDashboardNotFound
+	})
+	if err == errDashboardNotFound {
+		apierror.Write(c, http.StatusNotFound, "dashboard "+id+" not found")
+		return
+	}
+	if err != nil {
+		apierror.Fail(c, "Failed to save dashboard", err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// Delete removes a dashboard (owner or admin).
+func (h *DashboardHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+	user, _, admin := h.caller(c)
+	var dashboards []Dashboard
+	var removed Dashboard
+	err := h.store.Update(dashboardsDoc, &dashboards, func() error {
+		for i := range dashboards {
+			if dashboards[i].ID == id && (admin || dashboards[i].Owner == user) {
+				removed = dashboards[i]
+				dashboards = append(dashboards[:i], dashboards[i+1:]...)
+				return nil
+			}
+		}
+		return errDashboardNotFound
+	})
+	if err == errDashboardNotFound {
+		apierror.Write(c, http.StatusNotFound, "dashboard "+id+" not found")
+		return
+	}
+	if err != nil {
+		apierror.Fail(c, "Failed to save dashboard", err)
+		return
+	}
+	if removed.Owner != user {
+		auditf("dashboard %q of %s deleted by %s", removed.Name, removed.Owner, user)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Dashboard deleted"})
+}
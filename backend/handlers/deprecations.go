@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"k-view/apierror"
+	"k-view/k8s"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// apiDeprecation is an API version that is removed in a given Kubernetes release.
+type apiDeprecation struct {
+	GroupVersion string
+	Kind         string
+	RemovedIn    string
+	Replacement  string
+}
+
+// apiDeprecations is the built-in table of removed API versions, following the
+// upstream deprecated API migration guide.
+var apiDeprecations = []apiDeprecation{
+	{"extensions/v1beta1", "Deployment", "1.16", "apps/v1"},
+	{"extensions/v1beta1", "DaemonSet", "1.16", "apps/v1"},
+	{"extensions/v1beta1", "ReplicaSet", "1.16", "apps/v1"},
+	{"extensions/v1beta1", "NetworkPolicy", "1.16", "networking.k8s.io/v1"},
+	{"extensions/v1beta1", "PodSecurityPolicy", "1.16", "policy/v1beta1"},
+	{"extensions/v1beta1", "Ingress", "1.22", "networking.k8s.io/v1"},
+	{"apps/v1beta1", "Deployment", "1.16", "apps/v1"},
+	{"apps/v1beta1", "StatefulSet", "1.16", "apps/v1"},
+	{"apps/v1beta2", "Deployment", "1.16", "apps/v1"},
+	{"apps/v1beta2", "StatefulSet", "1.16", "apps/v1"},
+	{"apps/v1beta2", "DaemonSet", "1.16", "apps/v1"},
+	{"apps/v1beta2", "ReplicaSet", "1.16", "apps/v1"},
+	{"networking.k8s.io/v1beta1", "Ingress", "1.22", "networking.k8s.io/v1"},
+	{"networking.k8s.io/v1beta1", "IngressClass", "1.22", "networking.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "*", "1.22", "rbac.authorization.k8s.io/v1"},
+	{"admissionregistration.k8s.io/v1beta1", "*", "1.22", "admissionregistration.k8s.io/v1"},
+	{"apiextensions.k8s.io/v1beta1", "CustomResourceDefinition", "1.22", "apiextensions.k8s.io/v1"},
+	{"apiregistration.k8s.io/v1beta1", "APIService", "1.22", "apiregistration.k8s.io/v1"},
+	{"certificates.k8s.io/v1beta1", "CertificateSigningRequest", "1.22", "certificates.k8s.io/v1"},
+	{"coordination.k8s.io/v1beta1", "Lease", "1.22", "coordination.k8s.io/v1"},
+	{"scheduling.k8s.io/v1beta1", "PriorityClass", "1.22", "scheduling.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "StorageClass", "1.22", "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "VolumeAttachment", "1.22", "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "CSIDriver", "1.22", "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "CSINode", "1.22", "storage.k8s.io/v1"},
+	{"batch/v1beta1", "CronJob", "1.25", "batch/v1"},
+	{"discovery.k8s.io/v1beta1", "EndpointSlice", "1.25", "discovery.k8s.io/v1"},
+	{"events.k8s.io/v1beta1", "Event", "1.25", "events.k8s.io/v1"},
+	{"autoscaling/v2beta1", "HorizontalPodAutoscaler", "1.25", "autoscaling/v2"},
+	{"policy/v1beta1", "PodDisruptionBudget", "1.25", "policy/v1"},
+	{"policy/v1beta1", "PodSecurityPolicy", "1.25", "Pod Security Admission"},
+	{"node.k8s.io/v1beta1", "RuntimeClass", "1.25", "node.k8s.io/v1"},
+	{"autoscaling/v2beta2", "HorizontalPodAutoscaler", "1.26", "autoscaling/v2"},
+	{"flowcontrol.apiserver.k8s.io/v1beta1", "*", "1.26", "flowcontrol.apiserver.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "CSIStorageCapacity", "1.27", "storage.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "*", "1.29", "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", "*", "1.32", "flowcontrol.apiserver.k8s.io/v1"},
+}
+
+// DeprecatedUsage is an object (or API request pattern) that relies on a removed API version.
+type DeprecatedUsage struct {
+	Kind        string `json:"kind"`
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name,omitempty"`
+	APIVersion  string `json:"apiVersion"`
+	RemovedIn   string `json:"removedIn"`
+	Replacement string `json:"replacement"`
+	Source      string `json:"source"` // managedFields, last-applied, apiserver-metrics
+	Detail      string `json:"detail,omitempty"`
+}
+
+// lookupDeprecation returns the deprecation entry for an apiVersion/kind pair, if any.
+func lookupDeprecation(apiVersion, kind string) (apiDeprecation, bool) {
+	for _, d := range apiDeprecations {
+		if d.GroupVersion == apiVersion && (d.Kind == "*" || d.Kind == kind) {
+			return d, true
+		}
+	}
+	return apiDeprecation{}, false
+}
+
+// pendingRemoval reports whether an API removed in removedIn is still served by the current
+// release but gone in the target release. Empty bounds are open-ended.
+func pendingRemoval(removedIn, current, target string) bool {
+	_, removedMinor, ok := parseMinor(removedIn)
+	if !ok {
+		return false
+	}
+	if _, currentMinor, ok := parseMinor(current); ok && removedMinor <= currentMinor {
+		return false
+	}
+	if _, targetMinor, ok := parseMinor(target); ok && removedMinor > targetMinor {
+		return false
+	}
+	return true
+}
+
+// scannedKinds are the resource kinds inspected for deprecated apiVersions in their manifests.
+var scannedKinds = []string{
+	"deployments", "statefulsets", "daemonsets", "replicasets", "cronjobs", "ingresses", "ingress-classes", "hpas", "pdbs",
+	"networkpolicies", "storage-classes", "crds", "roles", "role-bindings", "cluster-roles", "cluster-role-bindings",
+}
+
+// scanDeprecatedUsage inspects the apiVersions recorded in managedFields and in the
+// kubectl last-applied annotation of every object, which reveal the version clients used.
+// Only APIs removed after current and up to target are reported.
+func scanDeprecatedUsage(ctx context.Context, dynClient dynamic.Interface, ns, current, target string) []DeprecatedUsage {
+	usages := []DeprecatedUsage{}
+	for _, kind := range scannedKinds {
+		var resInterface dynamic.ResourceInterface
+		if ns != "" && !isClusterScoped(kind) {
+			resInterface = dynClient.Resource(getGVR(kind)).Namespace(ns)
+		} else {
+			resInterface = dynClient.Resource(getGVR(kind))
+		}
+		list, err := resInterface.List(ctx, metav1.ListOptions{})
+		if err != nil {
+			continue // Kinds that are not served or not readable are skipped
+		}
+		for _, item := range list.Items {
+			usages = append(usages, objectDeprecations(item.Object, current, target)...)
+		}
+	}
+	return usages
+}
+
+// objectDeprecations returns deprecated apiVersions recorded on a single object.
+func objectDeprecations(obj map[string]interface{}, current, target string) []DeprecatedUsage {
+	var usages []DeprecatedUsage
+	kind, _ := obj["kind"].(string)
+	meta, _ := obj["metadata"].(map[string]interface{})
+	name, _ := meta["name"].(string)
+	namespace, _ := meta["namespace"].(string)
+	seen := map[string]bool{}
+
+	record := func(apiVersion, source, detail string) {
+		d, ok := lookupDeprecation(apiVersion, kind)
+		if !ok || seen[apiVersion+source] || !pendingRemoval(d.RemovedIn, current, target) {
+			return
+		}
+		seen[apiVersion+source] = true
+		usages = append(usages, DeprecatedUsage{Kind: kind, Namespace: namespace, Name: name, APIVersion: apiVersion, RemovedIn: d.RemovedIn, Replacement: d.Replacement, Source: source, Detail: detail})
+	}
+
+	if managed, ok := meta["managedFields"].([]interface{}); ok {
+		for _, m := range managed {
+			entry, _ := m.(map[string]interface{})
+			apiVersion, _ := entry["apiVersion"].(string)
+			manager, _ := entry["manager"].(string)
+			record(apiVersion, "managedFields", "last written by "+manager)
+		}
+	}
+	if annotations, ok := meta["annotations"].(map[string]interface{}); ok {
+		if applied, ok := annotations["kubectl.kubernetes.io/last-applied-configuration"].(string); ok {
+			var manifest struct {
+				APIVersion string `json:"apiVersion"`
+			}
+			if json.Unmarshal([]byte(applied), &manifest) == nil {
+				record(manifest.APIVersion, "last-applied", "applied with kubectl")
+			}
+		}
+	}
+	return usages
+}
+
+var metricLabel = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// requestedDeprecatedAPIs parses the apiserver_requested_deprecated_apis metric, which the
+// API server sets for every deprecated API that received requests since it started.
+func requestedDeprecatedAPIs(ctx context.Context, client k8s.KubernetesProvider, current, target string) []DeprecatedUsage {
+	info, ok := client.(k8s.ClusterInfoProvider)
+	if !ok {
+		return nil
+	}
+	body, err := info.GetRaw(ctx, "/metrics")
+	if err != nil {
+		return nil
+	}
+
+	var usages []DeprecatedUsage
+	for _, line := range strings.Split(string(body), "\n") {
+		if !strings.HasPrefix(line, "apiserver_requested_deprecated_apis{") {
+			continue
+		}
+		labels := map[string]string{}
+		for _, m := range metricLabel.FindAllStringSubmatch(line, -1) {
+			labels[m[1]] = m[2]
+		}
+		if !pendingRemoval(labels["removed_release"], current, target) {
+			continue
+		}
+		apiVersion := labels["version"]
+		if labels["group"] != "" {
+			apiVersion = labels["group"] + "/" + apiVersion
+		}
+		resource := labels["resource"]
+		if labels["subresource"] != "" {
+			resource += "/" + labels["subresource"]
+		}
+		replacement := ""
+		for _, d := range apiDeprecations {
+			if d.GroupVersion == apiVersion {
+				replacement = d.Replacement
+				break
+			}
+		}
+		usages = append(usages, DeprecatedUsage{Kind: resource, APIVersion: apiVersion, RemovedIn: labels["removed_release"], Replacement: replacement, Source: "apiserver-metrics", Detail: "requested since the API server started"})
+	}
+	return usages
+}
+
+// crdStoredVersionIssues flags CRDs that still store objects in a version that is deprecated or
+// no longer served. Those objects must be rewritten before the version can be dropped.
+func crdStoredVersionIssues(ctx context.Context, dynClient dynamic.Interface) []DeprecatedUsage {
+	usages := []DeprecatedUsage{}
+	crds, err := dynClient.Resource(getGVR("crds")).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return usages
+	}
+	for _, crd := range crds.Items {
+		group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+		kind, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "kind")
+		stored, _, _ := unstructured.NestedStringSlice(crd.Object, "status", "storedVersions")
+		versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+
+		storageVersion := ""
+		served := map[string]bool{}
+		deprecated := map[string]bool{}
+		for _, raw := range versions {
+			v, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := v["name"].(string)
+			served[name], _ = v["served"].(bool)
+			deprecated[name], _ = v["deprecated"].(bool)
+			if isStorage, _ := v["storage"].(bool); isStorage {
+				storageVersion = name
+			}
+		}
+
+		for _, version := range stored {
+			if version == storageVersion {
+				continue
+			}
+			detail := ""
+			switch {
+			case !served[version]:
+				detail = "objects are still stored as " + version + ", which is no longer served"
+			case deprecated[version]:
+				detail = "objects are still stored as deprecated version " + version
+			default:
+				continue
+			}
+			usages = append(usages, DeprecatedUsage{
+				Kind:        kind,
+				Name:        crd.GetName(),
+				APIVersion:  group + "/" + version,
+				Replacement: group + "/" + storageVersion,
+				Source:      "crd-stored-versions",
+				Detail:      detail + "; rewrite them and remove it from status.storedVersions",
+			})
+		}
+	}
+	return usages
+}
+
+// ListDeprecatedAPIs lists objects and API requests that depend on API versions removed after the
+// current cluster version (optionally only up to ?target=), plus CRDs with stale stored versions.
+func (h *DiagnosticsHandler) ListDeprecatedAPIs(c *gin.Context) {
+	ns := c.Query("namespace")
+	if ns == "-" {
+		ns = ""
+	}
+	target := strings.TrimPrefix(c.Query("target"), "v")
+
+	// Apply RBAC namespace restriction
+	restricted := false
+	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
+		ns = rbacNs.(string)
+		restricted = true
+	}
+
+	if h.devMode {
+		c.JSON(http.StatusOK, []DeprecatedUsage{
+			{Kind: "FlowSchema", Name: "custom-tenant-limits", APIVersion: "flowcontrol.apiserver.k8s.io/v1beta3", RemovedIn: "1.32", Replacement: "flowcontrol.apiserver.k8s.io/v1", Source: "managedFields", Detail: "last written by helm"},
+			{Kind: "Certificate", Name: "certificates.cert-manager.io", APIVersion: "cert-manager.io/v1alpha2", Replacement: "cert-manager.io/v1", Source: "crd-stored-versions", Detail: "objects are still stored as v1alpha2, which is no longer served; rewrite them and remove it from status.storedVersions"},
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	current := controlPlaneVersion(ctx, h.k8sClient, nil)
+
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+
+	usages := scanDeprecatedUsage(ctx, dynClient, ns, current, target)
+	// Request metrics and CRDs are cluster-wide and not shown to namespace-restricted users
+	if !restricted {
+		usages = append(usages, requestedDeprecatedAPIs(ctx, h.k8sClient, current, target)...)
+		usages = append(usages, crdStoredVersionIssues(ctx, dynClient)...)
+	}
+
+	c.JSON(http.StatusOK, usages)
+}
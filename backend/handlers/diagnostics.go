@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"k-view/apierror"
+	"k-view/k8s"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DiagnosticsHandler serves cluster health checks that correlate several resource kinds.
+type DiagnosticsHandler struct {
+	devMode   bool
+	k8sClient k8s.KubernetesProvider
+	usage     *usageHistory
+}
+
+func NewDiagnosticsHandler(devMode bool, client k8s.KubernetesProvider) *DiagnosticsHandler {
+	return &DiagnosticsHandler{devMode: devMode, k8sClient: client, usage: newUsageHistory()}
+}
+
+// PullSecretCheck is the verification result of one imagePullSecret reference.
+type PullSecretCheck struct {
+	Namespace  string   `json:"namespace"`
+	Name       string   `json:"name"`
+	Exists     bool     `json:"exists"`
+	Valid      bool     `json:"valid"`
+	Type       string   `json:"type,omitempty"`
+	Registries []string `json:"registries"`
+	Problem    string   `json:"problem,omitempty"`
+	UsedBy     []string `json:"usedBy"`
+}
+
+// PullFailure is a container that is currently unable to pull its image.
+type PullFailure struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	Image     string `json:"image"`
+	Registry  string `json:"registry"`
+	Reason    string `json:"reason"`
+	Message   string `json:"message,omitempty"`
+	// HasCredential is true if one of the pod's pull secrets has an entry for the registry
+	HasCredential bool `json:"hasCredential"`
+}
+
+type PullSecretReport struct {
+	Secrets  []PullSecretCheck `json:"secrets"`
+	Failures []PullFailure     `json:"failures"`
+}
+
+// dockerConfigRegistries decodes a dockerconfigjson (or legacy dockercfg) payload and returns its registries.
+func dockerConfigRegistries(secretType string, data []byte) ([]string, error) {
+	var auths map[string]json.RawMessage
+	if secretType == string(corev1.SecretTypeDockercfg) {
+		if err := json.Unmarshal(data, &auths); err != nil {
+			return nil, err
+		}
+	} else {
+		var cfg struct {
+			Auths map[string]json.RawMessage `json:"auths"`
+		}
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, err
+		}
+		auths = cfg.Auths
+	}
+
+	registries := []string{}
+	for registry := range auths {
+		registries = append(registries, normalizeRegistry(registry))
+	}
+	sort.Strings(registries)
+	return registries, nil
+}
+
+// normalizeRegistry reduces docker config keys like "https://index.docker.io/v1/" to a bare host.
+func normalizeRegistry(registry string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+	if host == "index.docker.io" || host == "registry-1.docker.io" {
+		host = "docker.io"
+	}
+	return host
+}
+
+// checkPullSecret fetches a secret and validates it as registry credentials.
+func (h *DiagnosticsHandler) checkPullSecret(c *gin.Context, namespace, name string) PullSecretCheck {
+	check := PullSecretCheck{Namespace: namespace, Name: name, Registries: []string{}, UsedBy: []string{}}
+
+	dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
+	if err != nil {
+		check.Problem = "Failed to get dynamic client: " + err.Error()
+		return check
+	}
+
+	secret, err := dynClient.Resource(getGVR("secrets")).Namespace(namespace).Get(c.Request.Context(), name, metav1.GetOptions{})
+	if err != nil {
+		check.Problem = "secret not found or not readable: " + err.Error()
+		return check
+	}
+	check.Exists = true
+
+	secretType, _, _ := unstructured.NestedString(secret.Object, "type")
+	check.Type = secretType
+	key := corev1.DockerConfigJsonKey
+	switch secretType {
+	case string(corev1.SecretTypeDockerConfigJson):
+	case string(corev1.SecretTypeDockercfg):
+		key = corev1.DockerConfigKey
+	default:
+		check.Problem = "secret type must be " + string(corev1.SecretTypeDockerConfigJson)
+		return check
+	}
+
+	encoded, found, _ := unstructured.NestedString(secret.Object, "data", key)
+	if !found {
+		check.Problem = "secret has no " + key + " key"
+		return check
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		check.Problem = "data is not valid base64"
+		return check
+	}
+	registries, err := dockerConfigRegistries(secretType, raw)
+	if err != nil {
+		check.Problem = "invalid docker config JSON: " + err.Error()
+		return check
+	}
+	if len(registries) == 0 {
+		check.Problem = "docker config contains no registry credentials"
+		return check
+	}
+
+	check.Registries = registries
+	check.Valid = true
+	return check
+}
+
+// CheckPullSecrets verifies every imagePullSecret referenced by pods and lists pods stuck pulling images.
+func (h *DiagnosticsHandler) CheckPullSecrets(c *gin.Context) {
+	ns := c.Query("namespace")
+	if ns == "-" {
+		ns = ""
+	}
+
+	// Apply RBAC namespace restriction
+	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
+		ns = rbacNs.(string)
+	}
+
+	if h.devMode {
+		c.JSON(http.StatusOK, PullSecretReport{
+			Secrets: []PullSecretCheck{
+				{Namespace: "default", Name: "registry-example-com", Exists: true, Valid: true, Type: "kubernetes.io/dockerconfigjson", Registries: []string{"registry.example.com"}, UsedBy: []string{"backend-api-6c9f8c", "worker-job-abc12"}},
+				{Namespace: "auth", Name: "ghcr-pull", Exists: false, Registries: []string{}, Problem: "secret not found or not readable: secrets \"ghcr-pull\" not found", UsedBy: []string{"auth-service-xyz"}},
+			},
+			Failures: []PullFailure{
+				{Namespace: "default", Pod: "worker-job-abc12", Container: "main", Image: "registry.example.com/worker:latest", Registry: "registry.example.com", Reason: "ImagePullBackOff", Message: "Back-off pulling image \"registry.example.com/worker:latest\"", HasCredential: true},
+			},
+		})
+		return
+	}
+
+	pods, err := h.k8sClient.ListPods(c.Request.Context(), ns)
+	if err != nil {
+		apierror.Fail(c, "Failed to list pods", err)
+		return
+	}
+
+	// Pull secrets of the pod's ServiceAccount are injected into pod.spec by admission,
+	// so the pod spec is the complete list of credentials the kubelet will try.
+	checks := make(map[string]*PullSecretCheck)
+	report := PullSecretReport{Secrets: []PullSecretCheck{}, Failures: []PullFailure{}}
+	for _, p := range pods {
+		var podRegistries []string
+		for _, ref := range p.Spec.ImagePullSecrets {
+			key := p.Namespace + "/" + ref.Name
+			check, ok := checks[key]
+			if !ok {
+				result := h.checkPullSecret(c, p.Namespace, ref.Name)
+				check = &result
+				checks[key] = check
+			}
+			check.UsedBy = appendUnique(check.UsedBy, p.Name)
+			podRegistries = append(podRegistries, check.Registries...)
+		}
+
+		images := make(map[string]string)
+		for _, ctr := range podImages(p) {
+			images[ctr.Name] = ctr.Image
+		}
+		statuses := append(append([]corev1.ContainerStatus{}, p.Status.InitContainerStatuses...), p.Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			if cs.State.Waiting == nil {
+				continue
+			}
+			reason := cs.State.Waiting.Reason
+			if reason != "ImagePullBackOff" && reason != "ErrImagePull" && reason != "InvalidImageName" {
+				continue
+			}
+			image := images[cs.Name]
+			if image == "" {
+				image = cs.Image
+			}
+			registry := parseImageRef(image).Registry
+			hasCredential := false
+			for _, r := range podRegistries {
+				if r == registry {
+					hasCredential = true
+					break
+				}
+			}
+			report.Failures = append(report.Failures, PullFailure{
+				Namespace:     p.Namespace,
+				Pod:           p.Name,
+				Container:     cs.Name,
+				Image:         image,
+				Registry:      registry,
+				Reason:        reason,
+				Message:       cs.State.Waiting.Message,
+				HasCredential: hasCredential,
+			})
+		}
+	}
+
+	for _, check := range checks {
+		report.Secrets = append(report.Secrets, *check)
+	}
+	sort.Slice(report.Secrets, func(i, j int) bool {
+		if report.Secrets[i].Namespace != report.Secrets[j].Namespace {
+			return report.Secrets[i].Namespace < report.Secrets[j].Namespace
+		}
+		return report.Secrets[i].Name < report.Secrets[j].Name
+	})
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"k-view/apierror"
+	"k-view/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const elevationsDoc = "elevations"
+
+// maxElevation caps how long a break-glass grant can last.
+const maxElevation = 8 * time.Hour
+
+var (
+	errElevationNotFound      = errors.New("elevation request not found")
+	errClusterWideUnconfirmed = errors.New("this grant is cluster-wide; approve it with ?confirmClusterWide=true")
+)
+
+// Elevation is a break-glass request for temporary edit or admin rights.
+type Elevation struct {
+	ID          string   `json:"id"`
+	User        string   `json:"user"`
+	Role        string   `json:"role"`                 // edit or admin
+	Namespace   string   `json:"namespace,omitempty"`  // The namespace asked for
+	Namespaces  []string `json:"namespaces,omitempty"` // The requester's own namespaces, when none was asked for
+	ClusterWide bool     `json:"clusterWide,omitempty"`
+	Reason      string   `json:"reason"`
+	Duration    string   `json:"duration"`
+	// Status is Pending, Approved, Denied, Revoked or Expired
+	Status      string     `json:"status"`
+	RequestedAt time.Time  `json:"requestedAt"`
+	DecidedBy   string     `json:"decidedBy,omitempty"`
+	DecidedAt   *time.Time `json:"decidedAt,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+}
+
+// scope returns the namespaces the grant covers; nil means every namespace.
+func (e Elevation) scope() []string {
+	if e.Namespace != "" {
+		return []string{e.Namespace}
+	}
+	return e.Namespaces
+}
+
+// effectiveStatus turns approved grants past their expiry into Expired.
+func (e Elevation) effectiveStatus(now time.Time) string {
+	if e.Status == "Approved" && e.ExpiresAt != nil && !now.Before(*e.ExpiresAt) {
+		return "Expired"
+	}
+	return e.Status
+}
+
+// ElevationHandler runs the break-glass workflow: a user requests temporary rights, a second
+// admin approves them, and the role reverts on its own once the grant expires. Every decision is
+// persisted and written to the log.
+type ElevationHandler struct {
+	mu         sync.RWMutex
+	elevations []Elevation // Cached copy of the store document; AuthMiddleware reads it on every request
+	store      *store.Store
+}
+
+func NewElevationHandler(st *store.Store) *ElevationHandler {
+	h := &ElevationHandler{store: st}
+	if err := st.Load(elevationsDoc, &h.elevations); err != nil {
+		log.Printf("Failed to load elevation requests: %v", err)
+	}
+	return h
+}
+
+func (h *ElevationHandler) Document() string { return elevationsDoc }
+
+// Reload refreshes the cached elevations from the store.
+func (h *ElevationHandler) Reload() error {
+	var elevations []Elevation
+	if err := h.store.Load(elevationsDoc, &elevations); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	h.elevations = elevations
+	h.mu.Unlock()
+	return nil
+}
+
+// Active returns the approved, unexpired grant for a user, if any.
+func (h *ElevationHandler) Active(user string) *Elevation {
+	if h == nil {
+		return nil
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	now := time.Now()
+	for i := range h.elevations {
+		e := h.elevations[i]
+		if e.User == user && e.effectiveStatus(now) == "Approved" {
+			return &e
+		}
+	}
+	return nil
+}
+
+// update applies fn to the stored requests and refreshes the cache.
+func (h *ElevationHandler) update(fn func([]Elevation) ([]Elevation, error)) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var stored []Elevation
+	err := h.store.Update(elevationsDoc, &stored, func() error {
+		var err error
+		stored, err = fn(stored)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	h.elevations = stored
+	return nil
+}
+
+func isAdminRole(role string) bool {
+	return role == "kview-cluster-admin" || role == "admin"
+}
+
+// Request files a break-glass request for the current user. An edit grant covers the namespace
+// asked for or, without one, the requester's own namespaces; edit rights in every namespace
+// have to be asked for with "clusterWide": true. Admin grants are always cluster-wide.
+func (h *ElevationHandler) Request(c *gin.Context) {
+	var req struct {
+		Role        string `json:"role" binding:"required"`
+		Namespace   string `json:"namespace"`
+		ClusterWide bool   `json:"clusterWide"`
+		Reason      string `json:"reason" binding:"required"`
+		Duration    string `json:"duration"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "role and reason are required")
+		return
+	}
+	if req.Role != "edit" && req.Role != "admin" {
+		apierror.Write(c, http.StatusBadRequest, "role must be edit or admin")
+		return
+	}
+	if req.Duration == "" {
+		req.Duration = "1h"
+	}
+	d, err := time.ParseDuration(req.Duration)
+	if err != nil || d <= 0 || d > maxElevation {
+		apierror.Write(c, http.StatusBadRequest, "duration must be between 1m and "+maxElevation.String())
+		return
+	}
+
+	email, _ := c.Get("email")
+	user, _ := email.(string)
+	role, _ := c.Get("role")
+	if isAdminRole(role.(string)) {
+		apierror.Write(c, http.StatusBadRequest, "admins already have full access")
+		return
+	}
+
+	e := Elevation{ID: newID(), User: user, Role: req.Role, Reason: req.Reason, Duration: d.String(), Status: "Pending", RequestedAt: time.Now().UTC()}
+	switch {
+	case req.Role == "admin" || (req.Namespace == "" && req.ClusterWide):
+		e.ClusterWide = true
+	case req.Namespace != "":
+		e.Namespace = req.Namespace
+	default:
+		e.Namespaces = c.GetStringSlice("allowedNamespaces")
+		if ns := c.GetString("namespace"); len(e.Namespaces) == 0 && ns != "" {
+			e.Namespaces = []string{ns}
+		}
+		if len(e.Namespaces) == 0 {
+			apierror.Write(c, http.StatusBadRequest, "namespace is required; set clusterWide to request edit rights in every namespace")
+			return
+		}
+	}
+	err = h.update(func(all []Elevation) ([]Elevation, error) {
+		now := time.Now()
+		for _, existing := range all {
+			s := existing.effectiveStatus(now)
+			if existing.User == user && (s == "Pending" || s == "Approved") {
+				return nil, errors.New("you already have a " + s + " elevation request")
+			}
+		}
+		return append(all, e), nil
+	})
+	if err != nil {
+		apierror.Write(c, http.StatusConflict, err.Error())
+		return
+	}
+	auditf("break-glass requested by %s: role=%s namespaces=%q clusterWide=%t duration=%s reason=%q", user, e.Role, e.scope(), e.ClusterWide, e.Duration, e.Reason)
+	c.JSON(http.StatusCreated, e)
+}
+
+// List returns the caller's own requests; admins see everyone's. ?status= filters the result.
+func (h *ElevationHandler) List(c *gin.Context) {
+	email, _ := c.Get("email")
+	role, _ := c.Get("role")
+	filter := c.Query("status")
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	now := time.Now()
+	result := []Elevation{}
+	for _, e := range h.elevations {
+		e.Status = e.effectiveStatus(now)
+		if !isAdminRole(role.(string)) && e.User != email {
+			continue
+		}
+		if filter != "" && e.Status != filter {
+			continue
+		}
+		result = append(result, e)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].RequestedAt.After(result[j].RequestedAt) })
+	c.JSON(http.StatusOK, result)
+}
+
+// decide moves a request from one of the allowed states to a new one (admin only). Approving a
+// cluster-wide grant needs ?confirmClusterWide=true.
+func (h *ElevationHandler) decide(c *gin.Context, from, to string) {
+	id := c.Param("id")
+	confirmed := c.Query("confirmClusterWide") == "true"
+	email, _ := c.Get("email")
+	admin, _ := email.(string)
+
+	var decided Elevation
+	err := h.update(func(all []Elevation) ([]Elevation, error) {
+		now := time.Now().UTC()
+		for i := range all {
+			if all[i].ID != id {
+				continue
+			}
+			if all[i].effectiveStatus(now) != from {
+				return nil, errors.New("request is " + all[i].effectiveStatus(now) + ", not " + from)
+			}
+			if to == "Approved" && len(all[i].scope()) == 0 && !confirmed {
+				return nil, errClusterWideUnconfirmed
+			}
+			all[i].Status = to
+			all[i].DecidedBy = admin
+			all[i].DecidedAt = &now
+			if to == "Approved" {
+				d, _ := time.ParseDuration(all[i].Duration)
+				expires := now.Add(d)
+				all[i].ExpiresAt = &expires
+			}
+			if to == "Revoked" {
+				all[i].ExpiresAt = &now
+			}
+			decided = all[i]
+			return all, nil
+		}
+		return nil, errElevationNotFound
+	})
+	if err == errElevationNotFound {
+		apierror.Write(c, http.StatusNotFound, "elevation request "+id+" not found")
+		return
+	}
+	if err == errClusterWideUnconfirmed {
+		apierror.Write(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		apierror.Write(c, http.StatusConflict, err.Error())
+		return
+	}
+	auditf("break-glass %s for %s by %s: role=%s namespaces=%q", to, decided.User, admin, decided.Role, decided.scope())
+	c.JSON(http.StatusOK, decided)
+}
+
+// Approve grants a pending request; the grant starts now and lasts for the requested duration.
+func (h *ElevationHandler) Approve(c *gin.Context) { h.decide(c, "Pending", "Approved") }
+
+// Deny rejects a pending request.
+func (h *ElevationHandler) Deny(c *gin.Context) { h.decide(c, "Pending", "Denied") }
+
+// Revoke ends an active grant early.
+func (h *ElevationHandler) Revoke(c *gin.Context) { h.decide(c, "Approved", "Revoked") }
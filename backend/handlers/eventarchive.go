@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"k-view/apierror"
+	"k-view/k8s"
+	"k-view/store"
+)
+
+const eventJournal = "events"
+
+// EventArchive records cluster events into the data store so they outlive the ~1h event TTL of
+// the API server, for postmortems. It is optional (KVIEW_EVENT_ARCHIVE=true).
+type EventArchive struct {
+	devMode   bool
+	enabled   bool
+	k8sClient k8s.KubernetesProvider
+	store     *store.Store
+	retention time.Duration
+
+	mu   sync.Mutex
+	seen map[string]int // Last recorded count per event UID, to skip unchanged re-lists
+}
+
+func NewEventArchive(devMode, enabled bool, client k8s.KubernetesProvider, st *store.Store, retention time.Duration) *EventArchive {
+	return &EventArchive{devMode: devMode, enabled: enabled, k8sClient: client, store: st, retention: retention, seen: map[string]int{}}
+}
+
+// record appends the events that are new or changed since they were last recorded.
+func (a *EventArchive) record(objs ...map[string]interface{}) {
+	var records []interface{}
+	a.mu.Lock()
+	for _, obj := range objs {
+		e := eventRecordFromObject(obj)
+		if e.UID == "" || a.seen[e.UID] == e.Count {
+			continue
+		}
+		a.seen[e.UID] = e.Count
+		records = append(records, e)
+	}
+	a.mu.Unlock()
+	if len(records) == 0 {
+		return
+	}
+	if err := a.store.Append(eventJournal, time.Now(), records...); err != nil {
+		log.Printf("Event archive: %v", err)
+	}
+}
+
+// forget drops the recorded UIDs once there are many, most of them of events too old to change
+// again. The Pruner applies the retention to the archive itself.
+func (a *EventArchive) forget() {
+	a.mu.Lock()
+	if len(a.seen) > 100000 {
+		a.seen = map[string]int{}
+	}
+	a.mu.Unlock()
+}
+
+// RunRecorder lists and then watches events in all namespaces with K-View's own service account,
+// reconnecting when the watch ends.
+func (a *EventArchive) RunRecorder(ctx context.Context) {
+	lastForget := time.Now()
+	for {
+		if err := a.watchOnce(ctx); err != nil {
+			log.Printf("Event archive: %v", err)
+		}
+		if time.Since(lastForget) > time.Hour {
+			a.forget()
+			lastForget = time.Now()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (a *EventArchive) watchOnce(ctx context.Context) error {
+	dynClient, err := a.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		return err
+	}
+	events := dynClient.Resource(getGVR("events"))
+	list, err := events.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	objs := make([]map[string]interface{}, 0, len(list.Items))
+	for _, item := range list.Items {
+		objs = append(objs, item.Object)
+	}
+	a.record(objs...)
+
+	w, err := events.Watch(ctx, metav1.ListOptions{ResourceVersion: list.GetResourceVersion()})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+	timeout := time.After(time.Hour) // Re-list now and then to recover missed events
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timeout:
+			return nil
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				return nil
+			}
+			if ev.Type != watch.Added && ev.Type != watch.Modified {
+				continue
+			}
+			if obj, ok := ev.Object.(*unstructured.Unstructured); ok {
+				a.record(obj.Object)
+			}
+		}
+	}
+}
+
+// History returns archived events since ?since= (default 24h), newest first. An event recorded
+// several times as its count grew appears once, with its latest state. Results can be narrowed
+// with ?namespace=, ?kind=, ?name=, ?reason= and ?type=, and are capped by ?limit= (default 1000).
+func (a *EventArchive) History(c *gin.Context) {
+	if !a.enabled {
+		apierror.Write(c, http.StatusNotImplemented, "the event archive is disabled (set KVIEW_EVENT_ARCHIVE=true)")
+		return
+	}
+	window, err := time.ParseDuration(c.DefaultQuery("since", "24h"))
+	if err != nil || window <= 0 {
+		apierror.Write(c, http.StatusBadRequest, "since must be a duration such as 72h")
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "1000"))
+	if err != nil || limit < 1 {
+		apierror.Write(c, http.StatusBadRequest, "limit must be a positive number")
+		return
+	}
+	ns := c.Query("namespace")
+	if rbacNs := rbacNamespace(c); rbacNs != "" {
+		if ns != "" && ns != rbacNs {
+			apierror.Write(c, http.StatusForbidden, "access denied to namespace "+ns)
+			return
+		}
+		ns = rbacNs
+	}
+	matches := func(e EventRecord) bool {
+		return (ns == "" || e.Namespace == ns) &&
+			(c.Query("kind") == "" || e.Kind == c.Query("kind")) &&
+			(c.Query("name") == "" || e.Name == c.Query("name")) &&
+			(c.Query("reason") == "" || e.Reason == c.Query("reason")) &&
+			(c.Query("type") == "" || e.Type == c.Query("type"))
+	}
+
+	since := time.Now().Add(-window)
+	latest := map[string]EventRecord{}
+	if a.devMode {
+		for i, e := range mockEventRecords(time.Now()) {
+			e.UID = strconv.Itoa(i)
+			latest[e.UID] = e
+		}
+	} else {
+		err = a.store.Scan(eventJournal, since, func(raw json.RawMessage) error {
+			var e EventRecord
+			if json.Unmarshal(raw, &e) == nil {
+				latest[e.UID] = e // Later lines carry newer state
+			}
+			return nil
+		})
+		if err != nil {
+			apierror.Fail(c, "Failed to read event archive", err)
+			return
+		}
+	}
+
+	result := []EventRecord{}
+	for _, e := range latest {
+		if !e.LastSeen.Before(since) && matches(e) {
+			result = append(result, e)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].LastSeen.After(result[j].LastSeen) })
+	truncated := len(result) > limit
+	if truncated {
+		result = result[:limit]
+	}
+	c.JSON(http.StatusOK, gin.H{"since": since.UTC(), "retention": a.retention.String(), "events": result, "truncated": truncated})
+}
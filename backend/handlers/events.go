@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"k-view/apierror"
+)
+
+// EventRecord is a flattened core/v1 Event.
+type EventRecord struct {
+	UID       string    `json:"uid"`
+	Type      string    `json:"type"`
+	Reason    string    `json:"reason"`
+	Kind      string    `json:"kind"` // Kind of the involved object
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name"` // Name of the involved object
+	Message   string    `json:"message"`
+	Source    string    `json:"source,omitempty"`
+	Count     int       `json:"count"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+func eventRecordFromObject(obj map[string]interface{}) EventRecord {
+	str := func(fields ...string) string {
+		v, _, _ := unstructured.NestedString(obj, fields...)
+		return v
+	}
+	e := EventRecord{
+		UID:       str("metadata", "uid"),
+		Type:      str("type"),
+		Reason:    str("reason"),
+		Kind:      str("involvedObject", "kind"),
+		Namespace: str("involvedObject", "namespace"),
+		Name:      str("involvedObject", "name"),
+		Message:   str("message"),
+		Source:    str("source", "component"),
+		LastSeen:  eventTimestamp(obj),
+		Count:     1,
+	}
+	if e.Source == "" {
+		e.Source = str("reportingComponent")
+	}
+	// Repeated events are folded into one object: count (legacy) or series.count (events.k8s.io)
+	if count, ok, _ := unstructured.NestedInt64(obj, "count"); ok && count > 1 {
+		e.Count = int(count)
+	} else if count, ok, _ := unstructured.NestedInt64(obj, "series", "count"); ok && count > 1 {
+		e.Count = int(count)
+	}
+	e.FirstSeen, _ = time.Parse(time.RFC3339, str("firstTimestamp"))
+	if e.FirstSeen.IsZero() {
+		e.FirstSeen = e.LastSeen
+	}
+	return e
+}
+
+// EventGroup aggregates the events sharing a reason, involved kind and type.
+type EventGroup struct {
+	Reason     string    `json:"reason"`
+	Kind       string    `json:"kind"`
+	Type       string    `json:"type"`
+	Count      int       `json:"count"`   // Occurrences in the window
+	Objects    int       `json:"objects"` // Distinct involved objects
+	Namespaces []string  `json:"namespaces"`
+	LastSeen   time.Time `json:"lastSeen"`
+	Message    string    `json:"message"` // Most recent message, as an example
+	Buckets    []int     `json:"buckets"` // Occurrences per bucket, oldest first
+	PeakCount  int       `json:"peakCount"`
+	PeakAt     time.Time `json:"peakAt"`
+	// Spike marks a burst: a bucket at the spike threshold, or five times the group's usual rate
+	Spike bool `json:"spike"`
+}
+
+// EventStats is the event rollup over a time window.
+type EventStats struct {
+	Window         string       `json:"window"`
+	Bucket         string       `json:"bucket"`
+	Since          time.Time    `json:"since"`
+	Total          int          `json:"total"`
+	Warnings       int          `json:"warnings"`
+	SpikeThreshold int          `json:"spikeThreshold"`
+	Groups         []EventGroup `json:"groups"` // Spikes first, then by count
+}
+
+// aggregateEvents groups events seen since `since` into buckets of the given size. A repeated
+// event contributes its full count to the bucket of its last occurrence.
+func aggregateEvents(events []EventRecord, since time.Time, bucket time.Duration, spikeThreshold int) EventStats {
+	n := int(time.Since(since)/bucket) + 1
+	stats := EventStats{Since: since, SpikeThreshold: spikeThreshold, Groups: []EventGroup{}}
+	type key struct{ reason, kind, typ string }
+	groups := map[key]*EventGroup{}
+	objects := map[key]map[string]bool{}
+	namespaces := map[key]map[string]bool{}
+	for _, e := range events {
+		if e.LastSeen.Before(since) {
+			continue
+		}
+		k := key{e.Reason, e.Kind, e.Type}
+		g, ok := groups[k]
+		if !ok {
+			g = &EventGroup{Reason: e.Reason, Kind: e.Kind, Type: e.Type, Buckets: make([]int, n)}
+			groups[k], objects[k], namespaces[k] = g, map[string]bool{}, map[string]bool{}
+		}
+		g.Count += e.Count
+		objects[k][e.Namespace+"/"+e.Name] = true
+		if e.Namespace != "" {
+			namespaces[k][e.Namespace] = true
+		}
+		if e.LastSeen.After(g.LastSeen) {
+			g.LastSeen, g.Message = e.LastSeen, e.Message
+		}
+		i := int(e.LastSeen.Sub(since) / bucket)
+		if i >= n {
+			i = n - 1
+		}
+		g.Buckets[i] += e.Count
+		stats.Total += e.Count
+		if e.Type == "Warning" {
+			stats.Warnings += e.Count
+		}
+	}
+
+	for k, g := range groups {
+		g.Objects = len(objects[k])
+		g.Namespaces = []string{}
+		for ns := range namespaces[k] {
+			g.Namespaces = append(g.Namespaces, ns)
+		}
+		sort.Strings(g.Namespaces)
+		for i, count := range g.Buckets {
+			if count > g.PeakCount {
+				g.PeakCount, g.PeakAt = count, since.Add(time.Duration(i)*bucket)
+			}
+		}
+		// Compare the peak with the average of the other buckets
+		others := float64(g.Count-g.PeakCount) / float64(max(n-1, 1))
+		g.Spike = g.PeakCount >= spikeThreshold || (g.PeakCount >= 20 && float64(g.PeakCount) >= 5*others)
+		stats.Groups = append(stats.Groups, *g)
+	}
+	sort.Slice(stats.Groups, func(i, j int) bool {
+		a, b := stats.Groups[i], stats.Groups[j]
+		if a.Spike != b.Spike {
+			return a.Spike
+		}
+		return a.Count > b.Count
+	})
+	return stats
+}
+
+// mockEventRecords simulates a noisy cluster for DEV_MODE, including a scheduling storm.
+func mockEventRecords(now time.Time) []EventRecord {
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+	return []EventRecord{
+		{Type: "Warning", Reason: "FailedScheduling", Kind: "Pod", Namespace: "default", Name: "worker-job-abc12", Message: "0/7 nodes are available: 3 Insufficient cpu, 4 node(s) had untolerated taint", Count: 312, FirstSeen: ago(9 * time.Minute), LastSeen: ago(time.Minute)},
+		{Type: "Warning", Reason: "FailedScheduling", Kind: "Pod", Namespace: "default", Name: "worker-job-def34", Message: "0/7 nodes are available: 3 Insufficient cpu", Count: 204, FirstSeen: ago(8 * time.Minute), LastSeen: ago(2 * time.Minute)},
+		{Type: "Warning", Reason: "BackOff", Kind: "Pod", Namespace: "default", Name: "worker-job-abc12", Message: "Back-off restarting failed container main", Count: 14, FirstSeen: ago(50 * time.Minute), LastSeen: ago(3 * time.Minute)},
+		{Type: "Warning", Reason: "Unhealthy", Kind: "Pod", Namespace: "database", Name: "postgres-replica-0", Message: "Readiness probe failed: connection refused", Count: 6, FirstSeen: ago(40 * time.Minute), LastSeen: ago(25 * time.Minute)},
+		{Type: "Normal", Reason: "ScalingReplicaSet", Kind: "Deployment", Namespace: "default", Name: "frontend-web", Message: "Scaled up replica set frontend-web-5d8f7b to 3", Count: 1, FirstSeen: ago(10 * time.Minute), LastSeen: ago(10 * time.Minute)},
+		{Type: "Normal", Reason: "Pulled", Kind: "Pod", Namespace: "default", Name: "frontend-web-5d8f7b", Message: "Container image \"nginx:1.25\" already present on machine", Count: 3, FirstSeen: ago(10 * time.Minute), LastSeen: ago(9 * time.Minute)},
+		{Type: "Warning", Reason: "NodeNotReady", Kind: "Node", Name: "worker-04", Message: "Node worker-04 status is now: NodeNotReady", Count: 1, FirstSeen: ago(35 * time.Minute), LastSeen: ago(35 * time.Minute)},
+	}
+}
+
+// GetEventStats groups the events of the last ?window= (default 1h) by reason and involved
+// kind in ?bucket= intervals (default 10m) and flags spikes, e.g. hundreds of FailedScheduling
+// events in one bucket. ?spike= sets the per-bucket threshold (default 100).
+func (h *ResourceHandler) GetEventStats(c *gin.Context) {
+	window, err := time.ParseDuration(c.DefaultQuery("window", "1h"))
+	if err != nil || window <= 0 {
+		apierror.Write(c, http.StatusBadRequest, "window must be a duration such as 1h")
+		return
+	}
+	bucket, err := time.ParseDuration(c.DefaultQuery("bucket", "10m"))
+	if err != nil || bucket < time.Minute || window/bucket > 500 {
+		apierror.Write(c, http.StatusBadRequest, "bucket must be a duration of at least 1m, and at most 500 buckets per window")
+		return
+	}
+	spike, err := strconv.Atoi(c.DefaultQuery("spike", "100"))
+	if err != nil || spike < 1 {
+		apierror.Write(c, http.StatusBadRequest, "spike must be a positive number")
+		return
+	}
+	ns := c.Query("namespace")
+	if rbacNs := rbacNamespace(c); rbacNs != "" {
+		if ns != "" && ns != rbacNs {
+			apierror.Write(c, http.StatusForbidden, "access denied to namespace "+ns)
+			return
+		}
+		ns = rbacNs
+	}
+
+	var events []EventRecord
+	if h.devMode {
+		for _, e := range mockEventRecords(time.Now()) {
+			if ns == "" || e.Namespace == ns {
+				events = append(events, e)
+			}
+		}
+	} else {
+		dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
+		if err != nil {
+			apierror.Fail(c, "Failed to get dynamic client", err)
+			return
+		}
+		list, err := dynClient.Resource(getGVR("events")).Namespace(ns).List(c.Request.Context(), metav1.ListOptions{})
+		if err != nil {
+			apierror.Fail(c, "Failed to list events", err)
+			return
+		}
+		for _, item := range list.Items {
+			events = append(events, eventRecordFromObject(item.Object))
+		}
+	}
+
+	since := time.Now().Add(-window).Truncate(bucket)
+	stats := aggregateEvents(events, since, bucket, spike)
+	stats.Window, stats.Bucket = window.String(), bucket.String()
+	c.JSON(http.StatusOK, stats)
+}
@@ -1,14 +1,22 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/remotecommand"
 
+	"k-view/apierror"
 	"k-view/k8s"
 )
 
@@ -20,14 +28,26 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
+// TerminalConfig holds the terminal limits and the node shell settings.
+type TerminalConfig struct {
+	IdleTimeout time.Duration // Terminals without input for this long are closed; 0 disables it
+	MaxPerUser  int           // Concurrent terminals per user; 0 means unlimited
+	// NodeShellNamespace and NodeShellImage define the privileged debug pods behind node shells
+	NodeShellNamespace string
+	NodeShellImage     string
+}
+
 // ExecHandler handles the websocket connections for the terminal
 type ExecHandler struct {
+	devMode   bool
 	k8sClient k8s.KubernetesProvider
+	sessions  *sessionRegistry
+	config    TerminalConfig
 }
 
 // NewExecHandler creates a new handler
-func NewExecHandler(client k8s.KubernetesProvider) *ExecHandler {
-	return &ExecHandler{k8sClient: client}
+func NewExecHandler(devMode bool, client k8s.KubernetesProvider, config TerminalConfig) *ExecHandler {
+	return &ExecHandler{devMode: devMode, k8sClient: client, sessions: newSessionRegistry(config.IdleTimeout, config.MaxPerUser), config: config}
 }
 
 // TerminalMessage is the JSON structure sent from the JS xterm instance for resizing
@@ -40,9 +60,11 @@ type TerminalMessage struct {
 
 // wsPtyHandler implements the k8s.PtyHandler interface
 type wsPtyHandler struct {
-	conn      *websocket.Conn
-	sizeChan  chan remotecommand.TerminalSize
-	doneChan  chan struct{}
+	conn     *websocket.Conn
+	sizeChan chan remotecommand.TerminalSize
+	doneChan chan struct{}
+	writeMu  sync.Mutex // The websocket allows only one concurrent writer
+	onInput  func()
 }
 
 func (t *wsPtyHandler) Read(p []byte) (int, error) {
@@ -58,11 +80,13 @@ func (t *wsPtyHandler) Read(p []byte) (int, error) {
 			return 0, nil
 		}
 		if xtermMsg.Op == "stdin" {
+			t.onInput()
 			return copyBytes(p, []byte(xtermMsg.Data)), nil
 		}
 	}
 
 	// Fallback to raw bytes if not JSON
+	t.onInput()
 	return copyBytes(p, msg), nil
 }
 
@@ -72,6 +96,8 @@ func copyBytes(dst, src []byte) int {
 }
 
 func (t *wsPtyHandler) Write(p []byte) (int, error) {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
 	err := t.conn.WriteMessage(websocket.BinaryMessage, p)
 	if err != nil {
 		return 0, err
@@ -88,6 +114,13 @@ func (t *wsPtyHandler) Next() *remotecommand.TerminalSize {
 	}
 }
 
+// notice prints a status line in the terminal.
+func (t *wsPtyHandler) notice(msg string) {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_ = t.conn.WriteMessage(websocket.TextMessage, []byte(msg))
+}
+
 func (t *wsPtyHandler) Done() {
 	close(t.doneChan)
 }
@@ -96,13 +129,74 @@ func (t *wsPtyHandler) Done() {
 func (h *ExecHandler) HandleExec(c *gin.Context) {
 	namespace := c.Param("namespace")
 	pod := c.Param("name")
-	container := c.Param("container")
+	container := c.Param("container") // Optional: defaults to the pod's default container
+	shell := c.Query("shell")         // Optional: preferred shell, see k8s.ShellsFor
 
-	if namespace == "" || pod == "" || container == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "namespace, pod, and container are required"})
+	if namespace == "" || pod == "" {
+		apierror.Write(c, http.StatusBadRequest, "namespace and pod are required")
 		return
 	}
 
+	// Impersonation alone is not enough: the K-View role must allow exec before we upgrade
+	email, _ := c.Get("email")
+	role, _ := c.Get("role")
+	if !requireEditAccess(c, namespace) {
+		auditf("exec into %s/%s/%s denied for %v (role %v)", namespace, pod, container, email, role)
+		return
+	}
+	containers, defaultContainer, os, ok := h.podContainers(c, namespace, pod)
+	if !ok {
+		return
+	}
+	// Windows containers have no /bin/sh to detect shells with, so PowerShell is the default
+	shells := k8s.ShellsFor(os)
+	if shell != "" && !contains(shells, shell) {
+		apierror.Write(c, http.StatusBadRequest, "shell must be one of "+strings.Join(shells, ", ")+" on "+os)
+		return
+	}
+	if shell == "" && os == "windows" {
+		shell = shells[0]
+	}
+	if container == "" {
+		container = defaultContainer
+	}
+	if !containerExists(containers, container) {
+		c.JSON(http.StatusBadRequest, apierror.New(http.StatusBadRequest, "container "+container+" not found in pod "+pod).With(gin.H{"containers": containers}))
+		return
+	}
+	auditf("exec into %s/%s/%s by %v (role %v)", namespace, pod, container, email, role)
+
+	session := &TerminalSession{Namespace: namespace, Pod: pod, Container: container}
+	h.runTerminal(c, session, func(ctx context.Context, pty *wsPtyHandler) {
+		// We pass the gin request context which has the 'user' injected by auth middleware
+		err := h.k8sClient.Exec(ctx, namespace, pod, container, shell, pty)
+		if errors.Is(err, k8s.ErrNoShell) {
+			pty.notice(fmt.Sprintf("\r\n\033[33mContainer %s has no shell (the image is probably distroless).\r\n"+
+				"Start an ephemeral debug container instead:\r\n  kubectl debug -it -n %s %s --image=busybox --target=%s\033[0m\r\n",
+				container, namespace, pod, container))
+			return
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Exec error on %s/%s/%s: %v", namespace, pod, container, err)
+			pty.notice("\r\n\033[31mTerminal Disconnected: " + err.Error() + "\033[0m\r\n")
+		}
+	})
+}
+
+// runTerminal registers the session (enforcing the per-user limit), upgrades the connection and
+// runs the terminal until it ends, is killed or goes idle.
+func (h *ExecHandler) runTerminal(c *gin.Context, session *TerminalSession, run func(ctx context.Context, pty *wsPtyHandler)) {
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	email, _ := c.Get("email")
+	session.User, _ = email.(string)
+	session.cancel = cancel
+	if err := h.sessions.open(session); err != nil {
+		apierror.Write(c, http.StatusTooManyRequests, "You already have the maximum number of open terminals; close one first")
+		return
+	}
+	defer h.sessions.close(session.ID)
+
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("Terminal Upgrade Error: %v", err)
@@ -114,12 +208,98 @@ func (h *ExecHandler) HandleExec(c *gin.Context) {
 		conn:     conn,
 		sizeChan: make(chan remotecommand.TerminalSize),
 		doneChan: make(chan struct{}),
+		onInput:  func() { h.sessions.touch(session.ID) },
 	}
+	h.sessions.attach(session.ID, pty)
+	go h.sessions.watchIdle(ctx, session.ID)
+	run(ctx, pty)
+}
 
-	// We pass the gin request context which has the 'user' injected by auth middleware
-	err = h.k8sClient.Exec(c.Request.Context(), namespace, pod, container, pty)
+// ContainerInfo describes a container a terminal can be opened in.
+type ContainerInfo struct {
+	Name  string `json:"name"`
+	Image string `json:"image"`
+	State string `json:"state"` // Running, Waiting, Terminated or Unknown (no status reported yet)
+	Ready bool   `json:"ready"`
+	TTY   bool   `json:"tty,omitempty"`
+	// Ephemeral marks debug containers added with kubectl debug
+	Ephemeral bool `json:"ephemeral,omitempty"`
+}
+
+func containerExists(containers []ContainerInfo, name string) bool {
+	return findContainer(containers, name) != nil
+}
+
+func findContainer(containers []ContainerInfo, name string) *ContainerInfo {
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i]
+		}
+	}
+	return nil
+}
+
+// podContainers lists the containers of a pod and picks the default one: the
+// kubectl.kubernetes.io/default-container annotation if set, otherwise the first container.
+// It also returns the OS the pod runs on. It writes the error response and returns false if
+// the pod cannot be found.
+func (h *ExecHandler) podContainers(c *gin.Context, namespace, name string) ([]ContainerInfo, string, string, bool) {
+	pods, err := h.k8sClient.ListPods(c.Request.Context(), namespace)
 	if err != nil {
-		log.Printf("Exec error on %s/%s/%s: %v", namespace, pod, container, err)
-		_ = conn.WriteMessage(websocket.TextMessage, []byte("\r\n\033[31mTerminal Disconnected: "+err.Error()+"\033[0m\r\n"))
+		apierror.Fail(c, "Failed to list pods", err)
+		return nil, "", "", false
+	}
+	for _, pod := range pods {
+		if pod.Name != name {
+			continue
+		}
+		statuses := map[string]corev1.ContainerStatus{}
+		for _, cs := range append(pod.Status.ContainerStatuses, pod.Status.EphemeralContainerStatuses...) {
+			statuses[cs.Name] = cs
+		}
+		info := func(name, image string, tty, ephemeral bool) ContainerInfo {
+			ci := ContainerInfo{Name: name, Image: image, State: "Unknown", TTY: tty, Ephemeral: ephemeral}
+			if cs, ok := statuses[name]; ok {
+				ci.Ready = cs.Ready
+				switch {
+				case cs.State.Running != nil:
+					ci.State = "Running"
+				case cs.State.Terminated != nil:
+					ci.State = "Terminated"
+				default:
+					ci.State = "Waiting"
+				}
+			}
+			return ci
+		}
+		containers := []ContainerInfo{}
+		for _, ct := range pod.Spec.Containers {
+			containers = append(containers, info(ct.Name, ct.Image, ct.TTY, false))
+		}
+		for _, ct := range pod.Spec.EphemeralContainers {
+			containers = append(containers, info(ct.Name, ct.Image, ct.TTY, true))
+		}
+		defaultContainer := pod.Annotations["kubectl.kubernetes.io/default-container"]
+		if !containerExists(containers, defaultContainer) && len(containers) > 0 {
+			defaultContainer = containers[0].Name
+		}
+		return containers, defaultContainer, podOS(c.Request.Context(), h.k8sClient, pod), true
+	}
+	apierror.Write(c, http.StatusNotFound, "resource not found: pod "+name)
+	return nil, "", "", false
+}
+
+// ListContainers returns the containers a terminal can be opened in, the default container, the
+// pod's OS and the shells that can be requested with ?shell= on it.
+func (h *ExecHandler) ListContainers(c *gin.Context) {
+	namespace := c.Param("namespace")
+	if rbacNs := rbacNamespace(c); rbacNs != "" && namespace != rbacNs {
+		apierror.Write(c, http.StatusForbidden, "access denied to namespace "+namespace)
+		return
+	}
+	containers, defaultContainer, os, ok := h.podContainers(c, namespace, c.Param("name"))
+	if !ok {
+		return
 	}
+	c.JSON(http.StatusOK, gin.H{"containers": containers, "default": defaultContainer, "os": os, "shells": k8s.ShellsFor(os)})
 }
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+
+	"k-view/apierror"
+)
+
+// standardResources are the resources of every node; the rest of a ResourceList are extended
+// resources such as nvidia.com/gpu or hugepages-2Mi.
+var standardResources = map[corev1.ResourceName]bool{
+	corev1.ResourceCPU:              true,
+	corev1.ResourceMemory:           true,
+	corev1.ResourcePods:             true,
+	corev1.ResourceEphemeralStorage: true,
+	corev1.ResourceStorage:          true,
+}
+
+// extendedResources returns the extended resources of a list as quantity strings, or nil when
+// there are none.
+func extendedResources(list corev1.ResourceList) map[string]string {
+	var result map[string]string
+	for name, q := range list {
+		if standardResources[name] {
+			continue
+		}
+		if result == nil {
+			result = map[string]string{}
+		}
+		result[string(name)] = q.String()
+	}
+	return result
+}
+
+// isGPUResource reports whether an extended resource is a GPU or a slice of one, e.g.
+// nvidia.com/gpu, nvidia.com/mig-1g.5gb, amd.com/gpu or gpu.intel.com/i915.
+func isGPUResource(name corev1.ResourceName) bool {
+	return strings.Contains(string(name), "gpu") || strings.HasPrefix(string(name), "nvidia.com/mig-")
+}
+
+// podExtendedRequests returns the extended resources a pod asks the scheduler for: the sum of
+// its containers, or the largest init container when that is more.
+func podExtendedRequests(p corev1.Pod) corev1.ResourceList {
+	total := corev1.ResourceList{}
+	for _, ctr := range p.Spec.Containers {
+		for name, q := range ctr.Resources.Requests {
+			if standardResources[name] {
+				continue
+			}
+			sum := total[name]
+			sum.Add(q)
+			total[name] = sum
+		}
+	}
+	for _, ctr := range p.Spec.InitContainers {
+		for name, q := range ctr.Resources.Requests {
+			if standardResources[name] {
+				continue
+			}
+			if current, ok := total[name]; !ok || q.Cmp(current) > 0 {
+				total[name] = q
+			}
+		}
+	}
+	return total
+}
+
+// GPUCount is the number of devices of a GPU resource.
+type GPUCount struct {
+	Capacity    int64 `json:"capacity"`
+	Allocatable int64 `json:"allocatable"`
+	Requested   int64 `json:"requested"` // By pods scheduled to the node(s) that have not finished
+}
+
+// GPUNode is a node with GPUs.
+type GPUNode struct {
+	Name      string              `json:"name"`
+	Status    string              `json:"status"`
+	Resources map[string]GPUCount `json:"resources"`
+}
+
+// GPUPod is a pod asking for GPUs.
+type GPUPod struct {
+	Name      string           `json:"name"`
+	Namespace string           `json:"namespace"`
+	Node      string           `json:"node,omitempty"` // Unset while waiting to be scheduled
+	Phase     string           `json:"phase"`
+	Requests  map[string]int64 `json:"requests"`
+}
+
+// GPUSummary is the GPU allocation of the cluster.
+type GPUSummary struct {
+	Resources         map[string]GPUCount `json:"resources"` // Cluster totals per GPU resource
+	AllocationPercent int                 `json:"allocationPercent"`
+	Nodes             []GPUNode           `json:"nodes"`
+	Pods              []GPUPod            `json:"pods"`
+	Pending           int                 `json:"pending"` // GPU pods not scheduled yet
+}
+
+// GetGPUSummary reports the GPUs of the cluster: capacity, allocatable and requested devices
+// per GPU resource and node, and the pods using them. Utilization is the share of allocatable
+// devices requested by pods; how busy the devices are needs the vendor's exporter (DCGM).
+// Namespace-pinned users only see their own namespace's pods, the totals cover all of them.
+func (h *NodeHandler) GetGPUSummary(c *gin.Context) {
+	ctx := c.Request.Context()
+	nodes, err := h.k8sClient.ListNodes(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to list nodes", err)
+		return
+	}
+	pods, err := h.k8sClient.ListPods(ctx, "")
+	if err != nil {
+		apierror.Fail(c, "Failed to list pods", err)
+		return
+	}
+	rbacNs := c.GetString("namespace")
+
+	summary := GPUSummary{Resources: map[string]GPUCount{}, Nodes: []GPUNode{}, Pods: []GPUPod{}}
+	byNode := map[string]*GPUNode{}
+	for _, n := range nodes {
+		node := GPUNode{Name: n.Name, Status: nodeStatus(n), Resources: map[string]GPUCount{}}
+		for name, q := range n.Status.Capacity {
+			if !isGPUResource(name) || q.IsZero() {
+				continue
+			}
+			count := GPUCount{Capacity: q.Value()}
+			if alloc, ok := n.Status.Allocatable[name]; ok {
+				count.Allocatable = alloc.Value()
+			}
+			node.Resources[string(name)] = count
+		}
+		if len(node.Resources) > 0 {
+			summary.Nodes = append(summary.Nodes, node)
+			byNode[n.Name] = &summary.Nodes[len(summary.Nodes)-1]
+		}
+	}
+
+	for _, p := range pods {
+		if p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		requests := map[string]int64{}
+		for name, q := range podExtendedRequests(p) {
+			if isGPUResource(name) {
+				requests[string(name)] = q.Value()
+			}
+		}
+		if len(requests) == 0 {
+			continue
+		}
+		if node := byNode[p.Spec.NodeName]; node != nil {
+			for name, n := range requests {
+				count := node.Resources[name]
+				count.Requested += n
+				node.Resources[name] = count
+			}
+		}
+		if p.Spec.NodeName == "" {
+			summary.Pending++
+		}
+		if rbacNs == "" || p.Namespace == rbacNs {
+			summary.Pods = append(summary.Pods, GPUPod{Name: p.Name, Namespace: p.Namespace, Node: p.Spec.NodeName, Phase: string(p.Status.Phase), Requests: requests})
+		}
+	}
+
+	var allocatable, requested int64
+	for _, node := range summary.Nodes {
+		for name, count := range node.Resources {
+			total := summary.Resources[name]
+			total.Capacity += count.Capacity
+			total.Allocatable += count.Allocatable
+			total.Requested += count.Requested
+			summary.Resources[name] = total
+			allocatable += count.Allocatable
+			requested += count.Requested
+		}
+	}
+	if allocatable > 0 {
+		summary.AllocationPercent = int(requested * 100 / allocatable)
+	}
+	sort.Slice(summary.Pods, func(i, j int) bool {
+		return summary.Pods[i].Namespace+"/"+summary.Pods[i].Name < summary.Pods[j].Namespace+"/"+summary.Pods[j].Name
+	})
+	c.JSON(http.StatusOK, summary)
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"k-view/apierror"
+	"k-view/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const featureFlagsDoc = "feature-flags"
+
+// features are the subsystems a deployment can switch off, in display order.
+var features = []struct{ name, description string }{
+	{"console", "kubectl console, in the UI and through the Slack command"},
+	{"exec", "Pod terminals, attach and node shells"},
+	{"edit", "Editing resource YAML"},
+	{"proxy", "HTTP proxy to in-cluster services"},
+}
+
+// featureOf returns the subsystem a route belongs to, or "" for routes that are always on.
+func featureOf(c *gin.Context) string {
+	path := c.FullPath()
+	switch {
+	case path == "/api/console/exec" || path == "/api/integrations/slack":
+		return "console"
+	case strings.HasPrefix(path, "/api/exec/") || strings.HasPrefix(path, "/api/attach/") || path == "/api/nodes/:name/shell":
+		return "exec"
+	case path == "/api/resources/:kind/:namespace/:name/yaml" && c.Request.Method == http.MethodPut:
+		return "edit"
+	case strings.HasPrefix(path, "/api/proxy/"):
+		return "proxy"
+	}
+	return ""
+}
+
+// FeatureFlag is the state of one subsystem.
+type FeatureFlag struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Enabled     bool       `json:"enabled"`
+	SetBy       string     `json:"setBy,omitempty"`
+	SetAt       *time.Time `json:"setAt,omitempty"`
+	// Enforced is true when KVIEW_DISABLED_FEATURES pins the subsystem off and the admin API
+	// cannot turn it on
+	Enforced bool `json:"enforced"`
+}
+
+// featureToggle is an admin's last change to a flag, as stored.
+type featureToggle struct {
+	Enabled bool      `json:"enabled"`
+	SetBy   string    `json:"setBy"`
+	SetAt   time.Time `json:"setAt"`
+}
+
+// FeatureFlags switches subsystems on and off per deployment. Subsystems are on unless an
+// admin turned them off or the configuration disables them.
+type FeatureFlags struct {
+	mu       sync.RWMutex
+	toggles  map[string]featureToggle
+	disabled map[string]bool // From KVIEW_DISABLED_FEATURES
+	store    *store.Store
+}
+
+// NewFeatureFlags restores the admin toggles from the store. The subsystems in the
+// comma-separated disabled list stay off regardless; unknown names are an error so typos are
+// caught at startup.
+func NewFeatureFlags(st *store.Store, disabled string) (*FeatureFlags, error) {
+	f := &FeatureFlags{store: st, toggles: map[string]featureToggle{}, disabled: map[string]bool{}}
+	for _, name := range splitList(strings.ToLower(disabled)) {
+		if !knownFeature(name) {
+			return nil, fmt.Errorf("unknown feature %q (features are %s)", name, featureNames())
+		}
+		f.disabled[name] = true
+	}
+	if err := st.Load(featureFlagsDoc, &f.toggles); err != nil {
+		log.Printf("Failed to load feature flags: %v", err)
+	}
+	return f, nil
+}
+
+func (f *FeatureFlags) Document() string { return featureFlagsDoc }
+
+// Reload refreshes the admin toggles from the store.
+func (f *FeatureFlags) Reload() error {
+	toggles := map[string]featureToggle{}
+	if err := f.store.Load(featureFlagsDoc, &toggles); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	f.toggles = toggles
+	f.mu.Unlock()
+	return nil
+}
+
+func knownFeature(name string) bool {
+	for _, ft := range features {
+		if ft.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func featureNames() string {
+	names := make([]string, 0, len(features))
+	for _, ft := range features {
+		names = append(names, ft.name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// flags returns the state of every subsystem.
+func (f *FeatureFlags) flags() []FeatureFlag {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	result := make([]FeatureFlag, 0, len(features))
+	for _, ft := range features {
+		flag := FeatureFlag{Name: ft.name, Description: ft.description, Enabled: true}
+		if t, ok := f.toggles[ft.name]; ok {
+			at := t.SetAt
+			flag.Enabled, flag.SetBy, flag.SetAt = t.Enabled, t.SetBy, &at
+		}
+		if f.disabled[ft.name] {
+			flag.Enabled, flag.Enforced = false, true
+		}
+		result = append(result, flag)
+	}
+	return result
+}
+
+// Enabled reports whether a subsystem is on.
+func (f *FeatureFlags) Enabled(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.disabled[name] {
+		return false
+	}
+	t, ok := f.toggles[name]
+	return !ok || t.Enabled
+}
+
+// States maps every subsystem to whether it is on, for the UI to hide what is off.
+func (f *FeatureFlags) States() map[string]bool {
+	states := map[string]bool{}
+	for _, flag := range f.flags() {
+		states[flag.Name] = flag.Enabled
+	}
+	return states
+}
+
+// Middleware rejects requests to switched-off subsystems with 403 Forbidden.
+func (f *FeatureFlags) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := featureOf(c)
+		if name == "" || f.Enabled(name) {
+			c.Next()
+			return
+		}
+		msg := fmt.Sprintf("the %s feature is disabled on this K-View", name)
+		if name == "console" {
+			c.AbortWithStatusJSON(http.StatusForbidden, apierror.New(http.StatusForbidden, msg).With(gin.H{"output": "error: " + msg, "exitCode": 1}))
+			return
+		}
+		apierror.Abort(c, http.StatusForbidden, msg)
+	}
+}
+
+// List returns every subsystem and whether it is on.
+func (f *FeatureFlags) List(c *gin.Context) {
+	c.JSON(http.StatusOK, f.flags())
+}
+
+// Set turns a subsystem on or off (admin only). Subsystems disabled by the configuration
+// cannot be turned on.
+func (f *FeatureFlags) Set(c *gin.Context) {
+	name := c.Param("name")
+	if !knownFeature(name) {
+		apierror.Write(c, http.StatusNotFound, fmt.Sprintf("unknown feature %q (features are %s)", name, featureNames()))
+		return
+	}
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Enabled == nil {
+		apierror.Write(c, http.StatusBadRequest, "enabled is required")
+		return
+	}
+	if f.disabled[name] && *req.Enabled {
+		apierror.Write(c, http.StatusConflict, "the "+name+" feature is disabled by KVIEW_DISABLED_FEATURES and cannot be turned on from the API")
+		return
+	}
+
+	email, _ := c.Get("email")
+	toggle := featureToggle{Enabled: *req.Enabled, SetAt: time.Now().UTC()}
+	toggle.SetBy, _ = email.(string)
+
+	f.mu.Lock()
+	var stored map[string]featureToggle
+	err := f.store.Update(featureFlagsDoc, &stored, func() error {
+		if stored == nil {
+			stored = map[string]featureToggle{}
+		}
+		stored[name] = toggle
+		return nil
+	})
+	if err == nil {
+		f.toggles = stored
+	}
+	f.mu.Unlock()
+	if err != nil {
+		apierror.Fail(c, "Failed to save feature flag", err)
+		return
+	}
+	state := "off"
+	if toggle.Enabled {
+		state = "on"
+	}
+	auditf("feature %s turned %s by %v", name, state, email)
+	for _, flag := range f.flags() {
+		if flag.Name == name {
+			c.JSON(http.StatusOK, flag)
+			return
+		}
+	}
+}
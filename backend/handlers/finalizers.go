@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"k-view/apierror"
+)
+
+// stuckAfter is how long an object may be Terminating before it is reported as stuck.
+const stuckAfter = 5 * time.Minute
+
+// terminatingKinds are the kinds scanned for objects stuck in Terminating, besides the
+// custom resources of the columns configuration.
+var terminatingKinds = []string{"namespaces", "pvs", "pvcs", "pods", "deployments", "statefulsets", "daemonsets", "replicasets", "jobs", "services", "ingresses", "configmaps", "secrets"}
+
+// finalizerHints explain what the well-known finalizers wait for.
+var finalizerHints = map[string]string{
+	"kubernetes":                                  "Removed by the namespace controller once all content is deleted; check the namespace conditions for what is left",
+	"kubernetes.io/pvc-protection":                "Removed once no pod uses the claim",
+	"kubernetes.io/pv-protection":                 "Removed once the volume is no longer bound to a claim",
+	"foregroundDeletion":                          "Removed once the dependents blocking owner deletion are deleted",
+	"orphan":                                      "Removed once the dependents are orphaned",
+	"batch.kubernetes.io/job-tracking":            "Removed by the job controller once the pod's completion is recorded",
+	"service.kubernetes.io/load-balancer-cleanup": "Removed once the cloud provider deletes the load balancer",
+}
+
+// TerminatingObject is an object with a deletion timestamp that still exists because of its
+// finalizers.
+type TerminatingObject struct {
+	Kind       string    `json:"kind"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Name       string    `json:"name"`
+	DeletedAt  time.Time `json:"deletedAt"`
+	Stuck      bool      `json:"stuck"` // Terminating for longer than five minutes
+	Finalizers []string  `json:"finalizers"`
+	// SpecFinalizers are a namespace's spec.finalizers, removed through its finalize subresource
+	SpecFinalizers []string          `json:"specFinalizers,omitempty"`
+	Hints          map[string]string `json:"hints,omitempty"` // Per finalizer
+}
+
+// TerminatingReport is every object in Terminating, and the kinds that could not be listed.
+type TerminatingReport struct {
+	Objects []TerminatingObject `json:"objects"`
+	Errors  map[string]string   `json:"errors,omitempty"`
+}
+
+// ListTerminating lists the objects stuck in Terminating with their finalizers, longest
+// Terminating first. Namespace-pinned users only see their namespace's objects.
+func (h *ResourceHandler) ListTerminating(c *gin.Context) {
+	ns := c.Query("namespace")
+	rbacNs := rbacNamespace(c)
+	if rbacNs != "" {
+		ns = rbacNs
+	}
+
+	report := TerminatingReport{Objects: []TerminatingObject{}}
+	if h.devMode {
+		for _, obj := range mockTerminating() {
+			if ns == "" || obj.Namespace == ns || (obj.Namespace == "" && rbacNs == "") {
+				report.Objects = append(report.Objects, obj)
+			}
+		}
+	} else {
+		ctx := c.Request.Context()
+		dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+		if err != nil {
+			apierror.Fail(c, "Failed to get dynamic client", err)
+			return
+		}
+		kinds := append([]string{}, terminatingKinds...)
+		if h.columns != nil {
+			for _, k := range h.columns.Kinds {
+				if k.Group != "" {
+					kinds = append(kinds, k.Kind)
+				}
+			}
+		}
+		for _, kind := range kinds {
+			gvr, clusterScoped := h.columns.gvr(kind)
+			if clusterScoped && rbacNs != "" {
+				continue
+			}
+			var res dynamic.ResourceInterface = dynClient.Resource(gvr)
+			if !clusterScoped {
+				res = dynClient.Resource(gvr).Namespace(ns)
+			}
+			list, err := res.List(ctx, metav1.ListOptions{})
+			if err != nil {
+				if !k8serrors.IsNotFound(err) {
+					if report.Errors == nil {
+						report.Errors = map[string]string{}
+					}
+					report.Errors[kind] = err.Error()
+				}
+				continue
+			}
+			for i := range list.Items {
+				if obj, ok := terminatingObject(kind, &list.Items[i]); ok {
+					report.Objects = append(report.Objects, obj)
+				}
+			}
+		}
+	}
+
+	now := time.Now()
+	for i := range report.Objects {
+		obj := &report.Objects[i]
+		obj.Stuck = now.Sub(obj.DeletedAt) > stuckAfter
+		for _, f := range append(append([]string{}, obj.Finalizers...), obj.SpecFinalizers...) {
+			if hint, ok := finalizerHints[f]; ok {
+				if obj.Hints == nil {
+					obj.Hints = map[string]string{}
+				}
+				obj.Hints[f] = hint
+			}
+		}
+	}
+	sort.SliceStable(report.Objects, func(i, j int) bool {
+		return report.Objects[i].DeletedAt.Before(report.Objects[j].DeletedAt)
+	})
+	c.JSON(http.StatusOK, report)
+}
+
+// terminatingObject returns an object's finalizers if it is Terminating.
+func terminatingObject(kind string, item *unstructured.Unstructured) (TerminatingObject, bool) {
+	deleted := item.GetDeletionTimestamp()
+	if deleted == nil {
+		return TerminatingObject{}, false
+	}
+	obj := TerminatingObject{Kind: kind, Namespace: item.GetNamespace(), Name: item.GetName(), DeletedAt: deleted.Time, Finalizers: item.GetFinalizers()}
+	if obj.Finalizers == nil {
+		obj.Finalizers = []string{}
+	}
+	if kind == "namespaces" {
+		obj.SpecFinalizers, _, _ = unstructured.NestedStringSlice(item.Object, "spec", "finalizers")
+	}
+	return obj, true
+}
+
+// RemoveFinalizer removes one finalizer from an object stuck in Terminating (admin only), so
+// its deletion completes without whatever the finalizer waited for. The body names the
+// finalizer and repeats the object's name to confirm; only Terminating objects are changed,
+// and the change is audited. A namespace's "kubernetes" finalizer is in its spec and is
+// removed through the finalize subresource.
+func (h *ResourceHandler) RemoveFinalizer(c *gin.Context) {
+	kind := strings.ToLower(c.Param("kind"))
+	name := c.Param("name")
+	ns := c.Param("namespace")
+	if ns == "-" {
+		ns = ""
+	}
+	var req struct {
+		Finalizer string `json:"finalizer" binding:"required"`
+		Confirm   string `json:"confirm"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	if req.Confirm != name {
+		apierror.Write(c, http.StatusBadRequest, "confirm must repeat the name of the object, "+name)
+		return
+	}
+	if rbacNs := rbacNamespace(c); rbacNs != "" && !isClusterScoped(kind) && ns != rbacNs {
+		apierror.Write(c, http.StatusForbidden, "access denied to namespace "+ns)
+		return
+	}
+	if h.rejectProtected(c, kind, ns, name) {
+		return
+	}
+	email, _ := c.Get("email")
+
+	if h.devMode {
+		auditf("finalizer %s removed from %s %s/%s by %v (mocked)", req.Finalizer, kind, ns, name, email)
+		c.JSON(http.StatusOK, gin.H{"message": "Finalizer " + req.Finalizer + " removed (mocked)"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+	gvr, clusterScoped := h.columns.gvr(kind)
+	var res dynamic.ResourceInterface = dynClient.Resource(gvr)
+	if !clusterScoped {
+		res = dynClient.Resource(gvr).Namespace(ns)
+	}
+	obj, err := res.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			apierror.Write(c, http.StatusNotFound, "resource not found: "+err.Error())
+			return
+		}
+		apierror.Fail(c, "Failed to get resource", err)
+		return
+	}
+	if obj.GetDeletionTimestamp() == nil {
+		apierror.Write(c, http.StatusConflict, name+" is not being deleted; finalizers are only removed from Terminating objects")
+		return
+	}
+
+	if contains(obj.GetFinalizers(), req.Finalizer) {
+		err = removeMetadataFinalizer(ctx, res, obj, req.Finalizer)
+	} else if specFinalizers, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "finalizers"); kind == "namespaces" && contains(specFinalizers, req.Finalizer) {
+		err = removeNamespaceFinalizer(ctx, res, obj, specFinalizers, req.Finalizer)
+	} else {
+		apierror.Write(c, http.StatusNotFound, name+" has no finalizer "+req.Finalizer)
+		return
+	}
+	if err != nil {
+		if k8serrors.IsConflict(err) || k8serrors.IsInvalid(err) {
+			apierror.Write(c, http.StatusConflict, name+" changed meanwhile; reload and try again")
+			return
+		}
+		apierror.Fail(c, "Failed to remove finalizer", err)
+		return
+	}
+	auditf("finalizer %s removed from %s %s/%s (Terminating since %s) by %v", req.Finalizer, kind, ns, name, obj.GetDeletionTimestamp().UTC().Format(time.RFC3339), email)
+	c.JSON(http.StatusOK, gin.H{"message": "Finalizer " + req.Finalizer + " removed"})
+}
+
+// removeMetadataFinalizer patches one finalizer out of metadata.finalizers. The patch tests
+// the finalizers are unchanged, so one added or removed meanwhile is not lost.
+func removeMetadataFinalizer(ctx context.Context, res dynamic.ResourceInterface, obj *unstructured.Unstructured, finalizer string) error {
+	remaining := []string{}
+	for _, f := range obj.GetFinalizers() {
+		if f != finalizer {
+			remaining = append(remaining, f)
+		}
+	}
+	patch, err := json.Marshal([]map[string]interface{}{
+		{"op": "test", "path": "/metadata/finalizers", "value": obj.GetFinalizers()},
+		{"op": "replace", "path": "/metadata/finalizers", "value": remaining},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = res.Patch(ctx, obj.GetName(), types.JSONPatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// removeNamespaceFinalizer updates a namespace's spec.finalizers through the finalize
+// subresource, the only way to change them.
+func removeNamespaceFinalizer(ctx context.Context, res dynamic.ResourceInterface, obj *unstructured.Unstructured, specFinalizers []string, finalizer string) error {
+	remaining := []interface{}{}
+	for _, f := range specFinalizers {
+		if f != finalizer {
+			remaining = append(remaining, f)
+		}
+	}
+	if err := unstructured.SetNestedSlice(obj.Object, remaining, "spec", "finalizers"); err != nil {
+		return err
+	}
+	_, err := res.Update(ctx, obj, metav1.UpdateOptions{}, "finalize")
+	return err
+}
+
+// mockTerminating returns objects stuck in Terminating for DEV_MODE.
+func mockTerminating() []TerminatingObject {
+	now := time.Now().UTC()
+	return []TerminatingObject{
+		{Kind: "namespaces", Name: "legacy-billing", DeletedAt: now.Add(-72 * time.Hour), Finalizers: []string{}, SpecFinalizers: []string{"kubernetes"}},
+		{Kind: "pvcs", Namespace: "production", Name: "data-postgres-primary-1", DeletedAt: now.Add(-3 * time.Hour), Finalizers: []string{"kubernetes.io/pvc-protection"}},
+		{Kind: "services", Namespace: "production", Name: "legacy-lb", DeletedAt: now.Add(-26 * time.Hour), Finalizers: []string{"service.kubernetes.io/load-balancer-cleanup"}},
+		{Kind: "deployments", Namespace: "staging", Name: "search-indexer", DeletedAt: now.Add(-90 * time.Second), Finalizers: []string{"foregroundDeletion"}},
+	}
+}
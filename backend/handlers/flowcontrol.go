@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k-view/apierror"
+	"k-view/k8s"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// flowcontrol.apiserver.k8s.io/v1 is GA since 1.29; older clusters only serve v1beta3.
+const flowControlFallbackVersion = "v1beta3"
+
+// FlowSchemaInfo summarizes a FlowSchema and the requests it has rejected.
+type FlowSchemaInfo struct {
+	Name               string   `json:"name"`
+	PriorityLevel      string   `json:"priorityLevel"`
+	MatchingPrecedence int64    `json:"matchingPrecedence"`
+	DistinguisherType  string   `json:"distinguisherType,omitempty"`
+	Subjects           []string `json:"subjects"`
+	Rejected           float64  `json:"rejected"`
+	InQueue            float64  `json:"inQueue"`
+}
+
+// PriorityLevelInfo summarizes a PriorityLevelConfiguration and its current load.
+type PriorityLevelInfo struct {
+	Name                     string  `json:"name"`
+	Type                     string  `json:"type"` // Exempt, Limited
+	NominalConcurrencyShares int64   `json:"nominalConcurrencyShares,omitempty"`
+	LimitResponse            string  `json:"limitResponse,omitempty"` // Queue, Reject
+	NominalSeats             float64 `json:"nominalSeats"`
+	Executing                float64 `json:"executing"`
+	InQueue                  float64 `json:"inQueue"`
+	Rejected                 float64 `json:"rejected"`
+	Throttled                bool    `json:"throttled"`
+}
+
+// FlowRejection is one apiserver_flowcontrol_rejected_requests_total series.
+type FlowRejection struct {
+	FlowSchema    string  `json:"flowSchema"`
+	PriorityLevel string  `json:"priorityLevel"`
+	Reason        string  `json:"reason"` // queue-full, concurrency-limit, time-out, cancelled
+	Count         float64 `json:"count"`
+}
+
+type FlowControlReport struct {
+	FlowSchemas    []FlowSchemaInfo    `json:"flowSchemas"`
+	PriorityLevels []PriorityLevelInfo `json:"priorityLevels"`
+	Rejections     []FlowRejection     `json:"rejections"`
+	MetricsAvail   bool                `json:"metricsAvailable"`
+}
+
+// flowControlMetrics holds the APF series scraped from the API server /metrics endpoint.
+type flowControlMetrics struct {
+	rejections   []FlowRejection
+	inQueue      map[string]float64 // keyed by flow schema and by "pl:"+priority level
+	executing    map[string]float64 // keyed by priority level
+	nominalSeats map[string]float64 // keyed by priority level
+}
+
+// parseMetricLine splits a Prometheus text line into its name, labels and value.
+func parseMetricLine(line string) (string, map[string]string, float64, bool) {
+	brace := strings.IndexByte(line, '{')
+	end := strings.LastIndexByte(line, '}')
+	if brace < 0 || end < brace {
+		return "", nil, 0, false
+	}
+	fields := strings.Fields(line[end+1:])
+	if len(fields) == 0 {
+		return "", nil, 0, false
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, false
+	}
+	labels := map[string]string{}
+	for _, m := range metricLabel.FindAllStringSubmatch(line[brace:end], -1) {
+		labels[m[1]] = m[2]
+	}
+	return line[:brace], labels, value, true
+}
+
+func scrapeFlowControlMetrics(ctx context.Context, client k8s.KubernetesProvider) (*flowControlMetrics, bool) {
+	info, ok := client.(k8s.ClusterInfoProvider)
+	if !ok {
+		return nil, false
+	}
+	body, err := info.GetRaw(ctx, "/metrics")
+	if err != nil {
+		return nil, false
+	}
+
+	m := &flowControlMetrics{inQueue: map[string]float64{}, executing: map[string]float64{}, nominalSeats: map[string]float64{}}
+	for _, line := range strings.Split(string(body), "\n") {
+		if !strings.HasPrefix(line, "apiserver_flowcontrol_") {
+			continue
+		}
+		name, labels, value, ok := parseMetricLine(line)
+		if !ok {
+			continue
+		}
+		fs, pl := labels["flow_schema"], labels["priority_level"]
+		switch name {
+		case "apiserver_flowcontrol_rejected_requests_total":
+			if value > 0 {
+				m.rejections = append(m.rejections, FlowRejection{FlowSchema: fs, PriorityLevel: pl, Reason: labels["reason"], Count: value})
+			}
+		case "apiserver_flowcontrol_current_inqueue_requests":
+			m.inQueue[fs] += value
+			m.inQueue["pl:"+pl] += value
+		case "apiserver_flowcontrol_current_executing_requests":
+			m.executing[pl] += value
+		case "apiserver_flowcontrol_nominal_limit_seats":
+			m.nominalSeats[pl] = value
+		}
+	}
+	return m, true
+}
+
+// listFlowControl lists a flowcontrol resource, falling back to v1beta3 on older clusters.
+func (h *DiagnosticsHandler) listFlowControl(ctx context.Context, kind string) ([]unstructured.Unstructured, error) {
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gvr := getGVR(kind)
+	list, err := dynClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		list, err = dynClient.Resource(schema.GroupVersionResource{Group: gvr.Group, Version: flowControlFallbackVersion, Resource: gvr.Resource}).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return list.Items, nil
+}
+
+func flowSchemaSubjects(obj map[string]interface{}) []string {
+	subjects := []string{}
+	rules, _, _ := unstructured.NestedSlice(obj, "spec", "rules")
+	for _, raw := range rules {
+		rule, _ := raw.(map[string]interface{})
+		list, _, _ := unstructured.NestedSlice(rule, "subjects")
+		for _, s := range list {
+			sm, _ := s.(map[string]interface{})
+			kind, _ := sm["kind"].(string)
+			var name string
+			switch kind {
+			case "User":
+				name, _, _ = unstructured.NestedString(sm, "user", "name")
+			case "Group":
+				name, _, _ = unstructured.NestedString(sm, "group", "name")
+			case "ServiceAccount":
+				ns, _, _ := unstructured.NestedString(sm, "serviceAccount", "namespace")
+				sa, _, _ := unstructured.NestedString(sm, "serviceAccount", "name")
+				name = ns + "/" + sa
+			}
+			subjects = append(subjects, kind+"/"+name)
+		}
+	}
+	return subjects
+}
+
+// GetFlowControl exposes API Priority and Fairness configuration together with the current
+// rejection and queueing metrics, so admins can see which clients are being throttled.
+func (h *DiagnosticsHandler) GetFlowControl(c *gin.Context) {
+	report := FlowControlReport{FlowSchemas: []FlowSchemaInfo{}, PriorityLevels: []PriorityLevelInfo{}, Rejections: []FlowRejection{}}
+
+	if h.devMode {
+		report.MetricsAvail = true
+		report.FlowSchemas = []FlowSchemaInfo{
+			{Name: "exempt", PriorityLevel: "exempt", MatchingPrecedence: 1, Subjects: []string{"Group/system:masters"}},
+			{Name: "system-leader-election", PriorityLevel: "leader-election", MatchingPrecedence: 100, DistinguisherType: "ByUser", Subjects: []string{"User/system:kube-controller-manager", "User/system:kube-scheduler"}},
+			{Name: "kube-controller-manager", PriorityLevel: "workload-high", MatchingPrecedence: 800, DistinguisherType: "ByNamespace", Subjects: []string{"User/system:kube-controller-manager"}},
+			{Name: "service-accounts", PriorityLevel: "workload-low", MatchingPrecedence: 9000, DistinguisherType: "ByUser", Subjects: []string{"Group/system:serviceaccounts"}, Rejected: 37, InQueue: 4},
+			{Name: "global-default", PriorityLevel: "global-default", MatchingPrecedence: 9900, DistinguisherType: "ByUser", Subjects: []string{"Group/system:unauthenticated", "Group/system:authenticated"}},
+		}
+		report.PriorityLevels = []PriorityLevelInfo{
+			{Name: "exempt", Type: "Exempt"},
+			{Name: "leader-election", Type: "Limited", NominalConcurrencyShares: 10, LimitResponse: "Queue", NominalSeats: 25, Executing: 1},
+			{Name: "workload-high", Type: "Limited", NominalConcurrencyShares: 40, LimitResponse: "Queue", NominalSeats: 98, Executing: 12},
+			{Name: "workload-low", Type: "Limited", NominalConcurrencyShares: 100, LimitResponse: "Queue", NominalSeats: 245, Executing: 245, InQueue: 4, Rejected: 37, Throttled: true},
+			{Name: "global-default", Type: "Limited", NominalConcurrencyShares: 20, LimitResponse: "Queue", NominalSeats: 49, Executing: 3},
+		}
+		report.Rejections = []FlowRejection{
+			{FlowSchema: "service-accounts", PriorityLevel: "workload-low", Reason: "queue-full", Count: 29},
+			{FlowSchema: "service-accounts", PriorityLevel: "workload-low", Reason: "time-out", Count: 8},
+		}
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	ctx := c.Request.Context()
+	schemas, err := h.listFlowControl(ctx, "flow-schemas")
+	if err != nil {
+		apierror.Fail(c, "Failed to list flow schemas", err)
+		return
+	}
+	levels, err := h.listFlowControl(ctx, "priority-levels")
+	if err != nil {
+		apierror.Fail(c, "Failed to list priority levels", err)
+		return
+	}
+
+	metrics, ok := scrapeFlowControlMetrics(ctx, h.k8sClient)
+	report.MetricsAvail = ok
+	if !ok {
+		metrics = &flowControlMetrics{inQueue: map[string]float64{}, executing: map[string]float64{}, nominalSeats: map[string]float64{}}
+	}
+	rejectedBySchema := map[string]float64{}
+	rejectedByLevel := map[string]float64{}
+	for _, r := range metrics.rejections {
+		rejectedBySchema[r.FlowSchema] += r.Count
+		rejectedByLevel[r.PriorityLevel] += r.Count
+	}
+	if metrics.rejections != nil {
+		report.Rejections = metrics.rejections
+	}
+
+	for _, fs := range schemas {
+		info := FlowSchemaInfo{Name: fs.GetName(), Subjects: flowSchemaSubjects(fs.Object), Rejected: rejectedBySchema[fs.GetName()], InQueue: metrics.inQueue[fs.GetName()]}
+		info.PriorityLevel, _, _ = unstructured.NestedString(fs.Object, "spec", "priorityLevelConfiguration", "name")
+		info.MatchingPrecedence, _, _ = unstructured.NestedInt64(fs.Object, "spec", "matchingPrecedence")
+		info.DistinguisherType, _, _ = unstructured.NestedString(fs.Object, "spec", "distinguisherMethod", "type")
+		report.FlowSchemas = append(report.FlowSchemas, info)
+	}
+	sort.Slice(report.FlowSchemas, func(i, j int) bool {
+		return report.FlowSchemas[i].MatchingPrecedence < report.FlowSchemas[j].MatchingPrecedence
+	})
+
+	for _, pl := range levels {
+		name := pl.GetName()
+		info := PriorityLevelInfo{Name: name, NominalSeats: metrics.nominalSeats[name], Executing: metrics.executing[name], InQueue: metrics.inQueue["pl:"+name], Rejected: rejectedByLevel[name]}
+		info.Type, _, _ = unstructured.NestedString(pl.Object, "spec", "type")
+		info.NominalConcurrencyShares, _, _ = unstructured.NestedInt64(pl.Object, "spec", "limited", "nominalConcurrencyShares")
+		if info.NominalConcurrencyShares == 0 {
+			// v1beta3 still calls it assuredConcurrencyShares
+			info.NominalConcurrencyShares, _, _ = unstructured.NestedInt64(pl.Object, "spec", "limited", "assuredConcurrencyShares")
+		}
+		info.LimitResponse, _, _ = unstructured.NestedString(pl.Object, "spec", "limited", "limitResponse", "type")
+		info.Throttled = info.Rejected > 0 || info.InQueue > 0
+		report.PriorityLevels = append(report.PriorityLevels, info)
+	}
+	sort.Slice(report.PriorityLevels, func(i, j int) bool {
+		if report.PriorityLevels[i].Rejected != report.PriorityLevels[j].Rejected {
+			return report.PriorityLevels[i].Rejected > report.PriorityLevels[j].Rejected
+		}
+		return report.PriorityLevels[i].Name < report.PriorityLevels[j].Name
+	})
+	sort.Slice(report.Rejections, func(i, j int) bool { return report.Rejections[i].Count > report.Rejections[j].Count })
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"k-view/apierror"
+	"k-view/k8s"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// usageRetention bounds how far back idle detection can look.
+const usageRetention = 7 * 24 * time.Hour
+
+// usageSample is the aggregated usage of one workload's pods at a point in time.
+type usageSample struct {
+	At        time.Time
+	CPUMillis float64 // Sum of the pods' current CPU usage
+	RxBytes   uint64  // Bytes received by the pods since the previous sample
+}
+
+// usageHistory keeps per-workload usage samples collected from the kubelet stats summary.
+type usageHistory struct {
+	mu      sync.Mutex
+	samples map[string][]usageSample // "namespace/workload" -> samples, oldest first
+	lastRx  map[string]uint64        // "namespace/pod" -> cumulative rxBytes at the previous sample
+}
+
+func newUsageHistory() *usageHistory {
+	return &usageHistory{samples: map[string][]usageSample{}, lastRx: map[string]uint64{}}
+}
+
+// statsSummary is the subset of the kubelet /stats/summary response used for sampling.
+type statsSummary struct {
+	Pods []struct {
+		PodRef struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"podRef"`
+		CPU struct {
+			UsageNanoCores uint64 `json:"usageNanoCores"`
+		} `json:"cpu"`
+		Network struct {
+			RxBytes uint64 `json:"rxBytes"`
+		} `json:"network"`
+	} `json:"pods"`
+}
+
+// RunUsageSampler records workload CPU and network usage every interval until ctx is cancelled.
+// It runs with the ServiceAccount's permissions and needs nodes/proxy access.
+func (h *DiagnosticsHandler) RunUsageSampler(ctx context.Context, interval time.Duration) {
+	provider, ok := h.k8sClient.(k8s.ClusterInfoProvider)
+	if !ok {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := h.sampleUsage(ctx, provider); err != nil {
+			log.Printf("Usage sampling failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *DiagnosticsHandler) sampleUsage(ctx context.Context, provider k8s.ClusterInfoProvider) error {
+	nodes, err := h.k8sClient.ListNodes(ctx)
+	if err != nil {
+		return err
+	}
+	pods, err := h.k8sClient.ListPods(ctx, "")
+	if err != nil {
+		return err
+	}
+	workloads := make(map[string]string, len(pods))
+	for _, p := range pods {
+		workloads[p.Namespace+"/"+p.Name] = podWorkload(p)
+	}
+
+	var summaries []statsSummary
+	for _, n := range nodes {
+		raw, err := provider.GetRaw(ctx, "/api/v1/nodes/"+n.Name+"/proxy/stats/summary")
+		if err != nil {
+			continue
+		}
+		var summary statsSummary
+		if err := json.Unmarshal(raw, &summary); err == nil {
+			summaries = append(summaries, summary)
+		}
+	}
+
+	now := time.Now()
+	current := map[string]*usageSample{}
+	seen := map[string]bool{}
+	h.usage.mu.Lock()
+	defer h.usage.mu.Unlock()
+	for _, summary := range summaries {
+		for _, p := range summary.Pods {
+			podKey := p.PodRef.Namespace + "/" + p.PodRef.Name
+			workload, ok := workloads[podKey]
+			if !ok {
+				continue
+			}
+			seen[podKey] = true
+			s, ok := current[workload]
+			if !ok {
+				s = &usageSample{At: now}
+				current[workload] = s
+			}
+			s.CPUMillis += float64(p.CPU.UsageNanoCores) / 1e6
+			// rxBytes is cumulative per pod; a lower value means the pod sandbox was recreated
+			if last, ok := h.usage.lastRx[podKey]; ok && p.Network.RxBytes >= last {
+				s.RxBytes += p.Network.RxBytes - last
+			}
+			h.usage.lastRx[podKey] = p.Network.RxBytes
+		}
+	}
+	for podKey := range h.usage.lastRx {
+		if !seen[podKey] {
+			delete(h.usage.lastRx, podKey)
+		}
+	}
+
+	cutoff := now.Add(-usageRetention)
+	for workload, s := range current {
+		h.usage.samples[workload] = append(h.usage.samples[workload], *s)
+	}
+	for workload, samples := range h.usage.samples {
+		i := 0
+		for i < len(samples) && samples[i].At.Before(cutoff) {
+			i++
+		}
+		if i == len(samples) {
+			delete(h.usage.samples, workload)
+			continue
+		}
+		h.usage.samples[workload] = samples[i:]
+	}
+	return nil
+}
+
+// IdleWorkload is a Deployment whose usage stayed below the idle thresholds for the whole window.
+type IdleWorkload struct {
+	Namespace string  `json:"namespace"`
+	Name      string  `json:"name"`
+	Replicas  int64   `json:"replicas"`
+	AvgCPU    float64 `json:"avgCpuMillicores"`
+	MaxCPU    float64 `json:"maxCpuMillicores"`
+	RxBytes   uint64  `json:"rxBytes"` // Total received over the window
+	Samples   int     `json:"samples"`
+	Since     string  `json:"since"` // Oldest sample considered
+}
+
+type IdleReport struct {
+	Window     string         `json:"window"`
+	CPUMax     float64        `json:"cpuThresholdMillicores"`
+	TrafficMax uint64         `json:"trafficThresholdBytes"`
+	Candidates []IdleWorkload `json:"candidates"`
+	// InsufficientHistory lists running Deployments without samples covering the window yet
+	InsufficientHistory []string `json:"insufficientHistory"`
+}
+
+// GetIdleWorkloads reports Deployments with near-zero CPU usage and no inbound traffic over
+// ?window= (default 24h) as candidates for scale-to-zero. Thresholds: ?cpu= (millicores, default 5)
+// and ?traffic= (bytes received over the window, default 1MiB).
+func (h *DiagnosticsHandler) GetIdleWorkloads(c *gin.Context) {
+	window := 24 * time.Hour
+	if w := c.Query("window"); w != "" {
+		d, err := time.ParseDuration(w)
+		if err != nil || d <= 0 || d > usageRetention {
+			apierror.Write(c, http.StatusBadRequest, "window must be a duration between 0 and 168h")
+			return
+		}
+		window = d
+	}
+	report := IdleReport{Window: window.String(), CPUMax: 5, TrafficMax: 1 << 20, Candidates: []IdleWorkload{}, InsufficientHistory: []string{}}
+	if v := c.Query("cpu"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 {
+			apierror.Write(c, http.StatusBadRequest, "cpu must be a non-negative number of millicores")
+			return
+		}
+		report.CPUMax = f
+	}
+	if v := c.Query("traffic"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			apierror.Write(c, http.StatusBadRequest, "traffic must be a number of bytes")
+			return
+		}
+		report.TrafficMax = n
+	}
+	ns := c.Query("namespace")
+	if ns == "-" {
+		ns = ""
+	}
+	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
+		ns = rbacNs.(string)
+	}
+
+	if h.devMode {
+		report.Candidates = []IdleWorkload{
+			{Namespace: "default", Name: "worker-job", Replicas: 2, AvgCPU: 1.2, MaxCPU: 3.4, RxBytes: 18432, Samples: 288, Since: "24h"},
+			{Namespace: "logging", Name: "log-rotate", Replicas: 1, AvgCPU: 0.4, MaxCPU: 0.9, RxBytes: 0, Samples: 288, Since: "24h"},
+		}
+		report.InsufficientHistory = []string{"default/auth-service"}
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	ctx := c.Request.Context()
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+	deployments, err := dynClient.Resource(getGVR("deployments")).Namespace(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		apierror.Fail(c, "Failed to list deployments", err)
+		return
+	}
+
+	now := time.Now()
+	cutoff := now.Add(-window)
+	h.usage.mu.Lock()
+	defer h.usage.mu.Unlock()
+	for _, d := range deployments.Items {
+		replicas, found, _ := unstructured.NestedInt64(d.Object, "spec", "replicas")
+		if !found {
+			replicas = 1
+		}
+		if replicas == 0 {
+			continue
+		}
+		key := d.GetNamespace() + "/" + d.GetName()
+		samples := h.usage.samples[key]
+		// Require history reaching back to (almost) the start of the window
+		if len(samples) < 2 || samples[0].At.After(cutoff.Add(window/10)) {
+			report.InsufficientHistory = append(report.InsufficientHistory, key)
+			continue
+		}
+
+		idle := IdleWorkload{Namespace: d.GetNamespace(), Name: d.GetName(), Replicas: replicas}
+		var total float64
+		for _, s := range samples {
+			if s.At.Before(cutoff) {
+				continue
+			}
+			if idle.Samples == 0 {
+				idle.Since = getAge(s.At)
+			}
+			idle.Samples++
+			total += s.CPUMillis
+			idle.RxBytes += s.RxBytes
+			if s.CPUMillis > idle.MaxCPU {
+				idle.MaxCPU = s.CPUMillis
+			}
+		}
+		if idle.Samples == 0 || idle.MaxCPU > report.CPUMax || idle.RxBytes > report.TrafficMax {
+			continue
+		}
+		idle.AvgCPU = total / float64(idle.Samples)
+		report.Candidates = append(report.Candidates, idle)
+	}
+
+	sort.Slice(report.Candidates, func(i, j int) bool {
+		a, b := report.Candidates[i], report.Candidates[j]
+		if a.Replicas != b.Replicas {
+			return a.Replicas > b.Replicas
+		}
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+	sort.Strings(report.InsufficientHistory)
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"k-view/apierror"
+	"k-view/k8s"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+)
+
+type ImageHandler struct {
+	k8sClient k8s.KubernetesProvider
+}
+
+func NewImageHandler(client k8s.KubernetesProvider) *ImageHandler {
+	return &ImageHandler{k8sClient: client}
+}
+
+// ImageRef is a parsed container image reference.
+type ImageRef struct {
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"`
+	Tag        string `json:"tag,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+}
+
+// ImageUsage aggregates how a single image reference is used across the cluster.
+type ImageUsage struct {
+	Image string `json:"image"`
+	ImageRef
+	UsesDigest     bool     `json:"usesDigest"`
+	ImplicitLatest bool     `json:"implicitLatest"` // No tag or ":latest"
+	PullPolicies   []string `json:"pullPolicies"`
+	PodCount       int      `json:"podCount"`
+	Namespaces     []string `json:"namespaces"`
+	CachedOnNodes  []string `json:"cachedOnNodes"`
+}
+
+// parseImageRef splits an image reference into registry, repository, tag and digest,
+// applying the same defaults as the container runtime (docker.io, library/, latest).
+func parseImageRef(image string) ImageRef {
+	ref := ImageRef{}
+	rest := image
+	if i := strings.Index(rest, "@"); i >= 0 {
+		ref.Digest = rest[i+1:]
+		rest = rest[:i]
+	}
+	if i := strings.LastIndex(rest, ":"); i >= 0 && !strings.Contains(rest[i:], "/") {
+		ref.Tag = rest[i+1:]
+		rest = rest[:i]
+	}
+
+	parts := strings.SplitN(rest, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry = parts[0]
+		ref.Repository = parts[1]
+	} else {
+		ref.Registry = "docker.io"
+		ref.Repository = rest
+	}
+	if ref.Registry == "docker.io" && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+	return ref
+}
+
+// normalizedImage returns the fully-qualified form used by the kubelet in node.status.images.
+func normalizedImage(ref ImageRef) string {
+	name := ref.Registry + "/" + ref.Repository
+	if ref.Digest != "" {
+		return name + "@" + ref.Digest
+	}
+	return name + ":" + ref.Tag
+}
+
+// podImages returns every container image (init, sidecar and regular) of a pod with its pull policy.
+func podImages(pod corev1.Pod) []corev1.Container {
+	containers := make([]corev1.Container, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+	containers = append(containers, pod.Spec.InitContainers...)
+	containers = append(containers, pod.Spec.Containers...)
+	return containers
+}
+
+func appendUnique(list []string, value string) []string {
+	if value == "" {
+		return list
+	}
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// ListImages aggregates all container images in use across the cluster (or the user's namespace).
+func (h *ImageHandler) ListImages(c *gin.Context) {
+	ns := c.Query("namespace")
+	if ns == "-" {
+		ns = ""
+	}
+
+	// Apply RBAC namespace restriction
+	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
+		ns = rbacNs.(string)
+	}
+
+	pods, err := h.k8sClient.ListPods(c.Request.Context(), ns)
+	if err != nil {
+		apierror.Fail(c, "Failed to list pods", err)
+		return
+	}
+
+	// Node image caches are best-effort: users without node access still get the pod view
+	cached := make(map[string][]string)
+	if nodes, err := h.k8sClient.ListNodes(c.Request.Context()); err == nil {
+		for _, n := range nodes {
+			for _, img := range n.Status.Images {
+				for _, name := range img.Names {
+					key := normalizedImage(parseImageRef(name))
+					cached[key] = appendUnique(cached[key], n.Name)
+				}
+			}
+		}
+	}
+
+	usage := make(map[string]*ImageUsage)
+	for _, p := range pods {
+		seen := make(map[string]bool)
+		for _, ctr := range podImages(p) {
+			u, ok := usage[ctr.Image]
+			if !ok {
+				ref := parseImageRef(ctr.Image)
+				u = &ImageUsage{
+					Image:          ctr.Image,
+					ImageRef:       ref,
+					UsesDigest:     ref.Digest != "",
+					ImplicitLatest: ref.Digest == "" && ref.Tag == "latest",
+					PullPolicies:   []string{},
+					Namespaces:     []string{},
+					CachedOnNodes:  cached[normalizedImage(ref)],
+				}
+				if u.CachedOnNodes == nil {
+					u.CachedOnNodes = []string{}
+				}
+				usage[ctr.Image] = u
+			}
+			u.PullPolicies = appendUnique(u.PullPolicies, string(ctr.ImagePullPolicy))
+			u.Namespaces = appendUnique(u.Namespaces, p.Namespace)
+			if !seen[ctr.Image] {
+				seen[ctr.Image] = true
+				u.PodCount++
+			}
+		}
+	}
+
+	result := make([]ImageUsage, 0, len(usage))
+	for _, u := range usage {
+		result = append(result, *u)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].PodCount != result[j].PodCount {
+			return result[i].PodCount > result[j].PodCount
+		}
+		return result[i].Image < result[j].Image
+	})
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,420 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"k-view/apierror"
+)
+
+// Effects of a delete on a dependent object.
+const (
+	EffectDeleted = "deleted" // Garbage-collected or deleted along with it
+	EffectBroken  = "broken"  // Left behind, referencing or serving something that is gone
+	EffectKept    = "kept"    // Left behind and intact, e.g. retained volumes
+)
+
+// Dependent is an object affected by a delete.
+type Dependent struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Effect    string `json:"effect"`
+	Reason    string `json:"reason"`
+}
+
+// DeleteImpact is the blast radius of deleting an object.
+type DeleteImpact struct {
+	Kind       string         `json:"kind"`
+	Namespace  string         `json:"namespace,omitempty"`
+	Name       string         `json:"name"`
+	Dependents []Dependent    `json:"dependents"`
+	Summary    map[string]int `json:"summary"` // Dependents per effect
+	// Errors holds the relations that could not be checked; the others are still returned
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// effectOrder sorts dependents with the ones deleted first.
+var effectOrder = map[string]int{EffectDeleted: 0, EffectBroken: 1, EffectKept: 2}
+
+// namespaceContents are the kinds listed when previewing a namespace delete, with their Kind.
+var namespaceContents = []struct{ kind, Kind string }{
+	{"deployments", "Deployment"}, {"statefulsets", "StatefulSet"}, {"daemonsets", "DaemonSet"},
+	{"cronjobs", "CronJob"}, {"jobs", "Job"}, {"services", "Service"}, {"ingresses", "Ingress"},
+	{"configmaps", "ConfigMap"}, {"secrets", "Secret"}, {"pvcs", "PersistentVolumeClaim"},
+}
+
+func (d *DeleteImpact) add(kind, ns, name, effect, reason string) {
+	d.Dependents = append(d.Dependents, Dependent{Kind: kind, Namespace: ns, Name: name, Effect: effect, Reason: reason})
+}
+
+func (d *DeleteImpact) fail(relation string, err error) {
+	if d.Errors == nil {
+		d.Errors = map[string]string{}
+	}
+	d.Errors[relation] = err.Error()
+}
+
+// GetDeleteImpact lists what deleting an object would take with it or leave broken, before the
+// delete is confirmed: the pods and replica sets of a workload, the claims of a statefulset,
+// the endpoints and ingresses of a service, the pods and workloads using a configmap or secret,
+// the volume of a claim, or the contents of a namespace.
+func (h *ResourceHandler) GetDeleteImpact(c *gin.Context) {
+	kind := strings.ToLower(c.Param("kind"))
+	name := c.Param("name")
+	ns := c.Param("namespace")
+	if ns == "-" {
+		ns = ""
+	}
+	if rbacNs := rbacNamespace(c); rbacNs != "" && !isClusterScoped(kind) && ns != rbacNs {
+		apierror.Write(c, http.StatusForbidden, "access denied to namespace "+ns)
+		return
+	}
+
+	var impact DeleteImpact
+	if h.devMode {
+		impact = mockDeleteImpact(kind, ns, name)
+	} else {
+		ctx := c.Request.Context()
+		dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+		if err != nil {
+			apierror.Fail(c, "Failed to get dynamic client", err)
+			return
+		}
+		gvr, clusterScoped := h.columns.gvr(kind)
+		res := dynClient.Resource(gvr)
+		var target *unstructured.Unstructured
+		if clusterScoped {
+			target, err = res.Get(ctx, name, metav1.GetOptions{})
+		} else {
+			target, err = res.Namespace(ns).Get(ctx, name, metav1.GetOptions{})
+		}
+		if k8serrors.IsNotFound(err) {
+			apierror.Write(c, http.StatusNotFound, "resource not found: "+err.Error())
+			return
+		}
+		if err != nil {
+			apierror.Fail(c, "Failed to get resource", err)
+			return
+		}
+		impact = h.deleteImpact(ctx, kind, target)
+	}
+
+	if impact.Dependents == nil {
+		impact.Dependents = []Dependent{}
+	}
+	sort.SliceStable(impact.Dependents, func(i, j int) bool {
+		return effectOrder[impact.Dependents[i].Effect] < effectOrder[impact.Dependents[j].Effect]
+	})
+	impact.Summary = map[string]int{}
+	for _, d := range impact.Dependents {
+		impact.Summary[d.Effect]++
+	}
+	c.JSON(http.StatusOK, impact)
+}
+
+// deleteImpact collects the dependents of an object by kind. Objects owned by it are found
+// for every namespaced kind, so custom controllers' children show up too.
+func (h *ResourceHandler) deleteImpact(ctx context.Context, kind string, target *unstructured.Unstructured) DeleteImpact {
+	ns, name := target.GetNamespace(), target.GetName()
+	impact := DeleteImpact{Kind: kind, Namespace: ns, Name: name}
+
+	if kind == "namespaces" {
+		for _, k := range namespaceContents {
+			items, err := h.listNamespaced(ctx, k.kind, name)
+			if err != nil {
+				impact.fail(k.kind, err)
+				continue
+			}
+			for _, item := range items {
+				impact.add(k.Kind, name, item.GetName(), EffectDeleted, "deleted with the namespace")
+			}
+		}
+		return impact
+	}
+	if ns == "" {
+		if kind == "pvs" {
+			if claim, ok, _ := unstructured.NestedString(target.Object, "spec", "claimRef", "name"); ok {
+				claimNs, _, _ := unstructured.NestedString(target.Object, "spec", "claimRef", "namespace")
+				impact.add("PersistentVolumeClaim", claimNs, claim, EffectBroken, "bound to this volume; it becomes Lost")
+			}
+		}
+		return impact
+	}
+
+	pods, err := h.k8sClient.ListPods(ctx, ns)
+	if err != nil {
+		impact.fail("pods", err)
+	}
+	h.ownedDependents(ctx, &impact, target.GetUID(), pods)
+
+	switch kind {
+	case "deployments", "statefulsets", "daemonsets":
+		var podLabels map[string]string
+		if spec, err := podSpecFromObject(target); err == nil && spec != nil {
+			podLabels, _, _ = unstructured.NestedStringMap(target.Object, "spec", "template", "metadata", "labels")
+		}
+		if hpas, err := h.workloadHPAs(ctx, ns, workloadKinds[kind], name); err != nil {
+			impact.fail("hpas", err)
+		} else {
+			for _, hpa := range hpas {
+				impact.add("HorizontalPodAutoscaler", ns, hpa.Name, EffectBroken, "scales a workload that no longer exists")
+			}
+		}
+		if len(podLabels) > 0 {
+			if pdbs, err := h.workloadPDBs(ctx, ns, podLabels); err != nil {
+				impact.fail("pdbs", err)
+			} else {
+				for _, pdb := range pdbs {
+					impact.add("PodDisruptionBudget", ns, pdb.Name, EffectBroken, "selects no pods")
+				}
+			}
+			services, ingresses, err := h.workloadServices(ctx, ns, podLabels)
+			if err != nil {
+				impact.fail("services", err)
+			}
+			for _, svc := range services {
+				impact.add("Service", ns, svc.Name, EffectBroken, "has no endpoints left unless other pods match its selector")
+			}
+			for _, ing := range ingresses {
+				impact.add("Ingress", ns, ing.Name, EffectBroken, "routes to "+strings.Join(ing.Services, ", ")+" without endpoints")
+			}
+		}
+		if kind == "statefulsets" {
+			h.statefulSetClaimDependents(ctx, &impact, target)
+		}
+	case "services":
+		impact.add("Endpoints", ns, name, EffectDeleted, "the service's endpoints")
+		if slices, err := h.listNamespaced(ctx, "endpointslices", ns); err != nil {
+			impact.fail("endpointslices", err)
+		} else {
+			for _, s := range slices {
+				if s.GetLabels()["kubernetes.io/service-name"] == name {
+					impact.add("EndpointSlice", ns, s.GetName(), EffectDeleted, "the service's endpoints")
+				}
+			}
+		}
+		if ingresses, err := h.listNamespaced(ctx, "ingresses", ns); err != nil {
+			impact.fail("ingresses", err)
+		} else {
+			for _, ing := range ingresses {
+				if ingressBackends(ing)[name] {
+					impact.add("Ingress", ns, ing.GetName(), EffectBroken, "routes to this service")
+				}
+			}
+		}
+	case "configmaps", "secrets":
+		h.configDependents(ctx, &impact, kind, pods)
+	case "pvcs":
+		for _, p := range pods {
+			for _, v := range p.Spec.Volumes {
+				if v.PersistentVolumeClaim != nil && v.PersistentVolumeClaim.ClaimName == name {
+					impact.add("Pod", ns, p.Name, EffectBroken, "mounts this claim, which stays Terminating until the pod is gone")
+				}
+			}
+		}
+		if volume, ok, _ := unstructured.NestedString(target.Object, "spec", "volumeName"); ok && volume != "" {
+			h.claimVolumeDependent(ctx, &impact, volume)
+		}
+	}
+	return impact
+}
+
+// ownedDependents adds the replica sets, jobs and pods the garbage collector deletes with an
+// object, following owner references two levels down (deployment → replicaset → pod).
+func (h *ResourceHandler) ownedDependents(ctx context.Context, impact *DeleteImpact, uid types.UID, pods []corev1.Pod) {
+	owners := map[types.UID]bool{uid: true}
+	ownedBy := func(refs []metav1.OwnerReference) bool {
+		for _, ref := range refs {
+			if owners[ref.UID] {
+				return true
+			}
+		}
+		return false
+	}
+	for _, k := range []string{"replicasets", "jobs"} {
+		items, err := h.listNamespaced(ctx, k, impact.Namespace)
+		if err != nil {
+			impact.fail(k, err)
+			continue
+		}
+		for _, item := range items {
+			if ownedBy(item.GetOwnerReferences()) {
+				owners[item.GetUID()] = true
+				impact.add(item.GetKind(), impact.Namespace, item.GetName(), EffectDeleted, "owned by it")
+			}
+		}
+	}
+	for _, p := range pods {
+		if ownedBy(p.OwnerReferences) {
+			impact.add("Pod", p.Namespace, p.Name, EffectDeleted, "owned by it")
+		}
+	}
+}
+
+// statefulSetClaimDependents adds the claims of a statefulset's volumeClaimTemplates, which
+// are kept unless its retention policy deletes them.
+func (h *ResourceHandler) statefulSetClaimDependents(ctx context.Context, impact *DeleteImpact, target *unstructured.Unstructured) {
+	templates, _, _ := unstructured.NestedSlice(target.Object, "spec", "volumeClaimTemplates")
+	if len(templates) == 0 {
+		return
+	}
+	effect, reason := EffectKept, "kept with its data (persistentVolumeClaimRetentionPolicy.whenDeleted is Retain)"
+	if policy, _, _ := unstructured.NestedString(target.Object, "spec", "persistentVolumeClaimRetentionPolicy", "whenDeleted"); policy == "Delete" {
+		effect, reason = EffectDeleted, "deleted with the statefulset (persistentVolumeClaimRetentionPolicy.whenDeleted is Delete)"
+	}
+	claims, err := h.listNamespaced(ctx, "pvcs", impact.Namespace)
+	if err != nil {
+		impact.fail("pvcs", err)
+		return
+	}
+	for _, raw := range templates {
+		tmpl, _ := raw.(map[string]interface{})
+		tmplName, _, _ := unstructured.NestedString(tmpl, "metadata", "name")
+		for _, claim := range claims {
+			if _, ok := statefulSetClaimOrdinal(claim.GetName(), tmplName, impact.Name); ok {
+				impact.add("PersistentVolumeClaim", impact.Namespace, claim.GetName(), effect, reason)
+			}
+		}
+	}
+}
+
+// configDependents adds the pods and workloads using a configmap or secret; running pods keep
+// working, new ones fail to start. Secrets are also used by ingresses for TLS.
+func (h *ResourceHandler) configDependents(ctx context.Context, impact *DeleteImpact, kind string, pods []corev1.Pod) {
+	uses := func(spec *corev1.PodSpec) bool {
+		configMaps, secrets := referencedConfig(spec)
+		if kind == "secrets" {
+			for _, ref := range spec.ImagePullSecrets {
+				secrets = appendUnique(secrets, ref.Name)
+			}
+			return contains(secrets, impact.Name)
+		}
+		return contains(configMaps, impact.Name)
+	}
+	for i := range pods {
+		if uses(&pods[i].Spec) {
+			impact.add("Pod", pods[i].Namespace, pods[i].Name, EffectBroken, "uses it; restarts and new pods fail to start")
+		}
+	}
+	for _, k := range []string{"deployments", "statefulsets", "daemonsets", "cronjobs"} {
+		items, err := h.listNamespaced(ctx, k, impact.Namespace)
+		if err != nil {
+			impact.fail(k, err)
+			continue
+		}
+		for i := range items {
+			if spec, err := podSpecFromObject(&items[i]); err == nil && uses(spec) {
+				impact.add(items[i].GetKind(), impact.Namespace, items[i].GetName(), EffectBroken, "its pod template uses it; new pods fail to start")
+			}
+		}
+	}
+	if kind != "secrets" {
+		return
+	}
+	ingresses, err := h.listNamespaced(ctx, "ingresses", impact.Namespace)
+	if err != nil {
+		impact.fail("ingresses", err)
+		return
+	}
+	for _, ing := range ingresses {
+		tls, _, _ := unstructured.NestedSlice(ing.Object, "spec", "tls")
+		for _, raw := range tls {
+			if entry, _ := raw.(map[string]interface{}); entry["secretName"] == impact.Name {
+				impact.add("Ingress", impact.Namespace, ing.GetName(), EffectBroken, "serves TLS with it")
+				break
+			}
+		}
+	}
+}
+
+// claimVolumeDependent adds the volume bound to a claim, which its reclaim policy deletes or
+// keeps as Released.
+func (h *ResourceHandler) claimVolumeDependent(ctx context.Context, impact *DeleteImpact, volume string) {
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		impact.fail("pvs", err)
+		return
+	}
+	pv, err := dynClient.Resource(getGVR("pvs")).Get(ctx, volume, metav1.GetOptions{})
+	if err != nil {
+		// Namespace-scoped users may not read volumes; the claim is still listed
+		impact.fail("pvs", err)
+		return
+	}
+	policy, _, _ := unstructured.NestedString(pv.Object, "spec", "persistentVolumeReclaimPolicy")
+	if policy == "Delete" {
+		impact.add("PersistentVolume", "", volume, EffectDeleted, "reclaim policy Delete: the volume and its data are deleted")
+		return
+	}
+	impact.add("PersistentVolume", "", volume, EffectKept, fmt.Sprintf("reclaim policy %s: kept as Released with its data", policy))
+}
+
+// ingressBackends returns the services an ingress routes to.
+func ingressBackends(ing unstructured.Unstructured) map[string]bool {
+	services := map[string]bool{}
+	if svc, ok, _ := unstructured.NestedString(ing.Object, "spec", "defaultBackend", "service", "name"); ok {
+		services[svc] = true
+	}
+	rules, _, _ := unstructured.NestedSlice(ing.Object, "spec", "rules")
+	for _, raw := range rules {
+		rule, _ := raw.(map[string]interface{})
+		paths, _, _ := unstructured.NestedSlice(rule, "http", "paths")
+		for _, rawPath := range paths {
+			path, _ := rawPath.(map[string]interface{})
+			if svc, ok, _ := unstructured.NestedString(path, "backend", "service", "name"); ok {
+				services[svc] = true
+			}
+		}
+	}
+	return services
+}
+
+// mockDeleteImpact returns the dependents of the mock resources for DEV_MODE.
+func mockDeleteImpact(kind, ns, name string) DeleteImpact {
+	impact := DeleteImpact{Kind: kind, Namespace: ns, Name: name}
+	switch kind {
+	case "deployments":
+		impact.add("ReplicaSet", ns, name+"-7d9f8c6b5", EffectDeleted, "owned by it")
+		for _, suffix := range []string{"x2k9p", "m4q7z", "t8w3n"} {
+			impact.add("Pod", ns, name+"-7d9f8c6b5-"+suffix, EffectDeleted, "owned by it")
+		}
+		impact.add("HorizontalPodAutoscaler", ns, name, EffectBroken, "scales a workload that no longer exists")
+		impact.add("Service", ns, name, EffectBroken, "has no endpoints left unless other pods match its selector")
+		impact.add("Ingress", ns, name, EffectBroken, "routes to "+name+" without endpoints")
+	case "statefulsets":
+		for i := 0; i < 3; i++ {
+			impact.add("Pod", ns, fmt.Sprintf("%s-%d", name, i), EffectDeleted, "owned by it")
+		}
+		for i := 0; i < 3; i++ {
+			impact.add("PersistentVolumeClaim", ns, fmt.Sprintf("data-%s-%d", name, i), EffectKept, "kept with its data (persistentVolumeClaimRetentionPolicy.whenDeleted is Retain)")
+		}
+	case "services":
+		impact.add("Endpoints", ns, name, EffectDeleted, "the service's endpoints")
+		impact.add("EndpointSlice", ns, name+"-abcde", EffectDeleted, "the service's endpoints")
+		impact.add("Ingress", ns, name, EffectBroken, "routes to this service")
+	case "configmaps", "secrets":
+		impact.add("Deployment", ns, "backend-api", EffectBroken, "its pod template uses it; new pods fail to start")
+		impact.add("Pod", ns, "backend-api-6c9f8c", EffectBroken, "uses it; restarts and new pods fail to start")
+	case "pvcs":
+		impact.add("Pod", ns, "postgres-primary-0", EffectBroken, "mounts this claim, which stays Terminating until the pod is gone")
+		impact.add("PersistentVolume", "", "pvc-"+name, EffectDeleted, "reclaim policy Delete: the volume and its data are deleted")
+	case "namespaces":
+		for _, k := range namespaceContents {
+			for _, item := range mockResourceList(k.kind, name) {
+				impact.add(k.Kind, name, item.Name, EffectDeleted, "deleted with the namespace")
+			}
+		}
+	}
+	return impact
+}
@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k-view/apierror"
+	"k-view/k8s"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ksmRetention bounds how far back kube-state-metrics insights can look.
+const ksmRetention = 24 * time.Hour
+
+// ksmSample is what one kube-state-metrics scrape says about the cluster.
+type ksmSample struct {
+	At            time.Time
+	Restarts      map[string]float64    // "namespace/pod" -> restarts of all its containers
+	Unschedulable map[string]bool       // "namespace/pod"
+	Deployments   map[string][2]float64 // "namespace/name" -> desired, available replicas
+}
+
+// KSMCollector scrapes kube-state-metrics periodically and keeps the samples in memory, so the
+// stats API can show trends metrics-server has no history for: restart rates, pods stuck
+// unschedulable and how long deployments were short of replicas.
+type KSMCollector struct {
+	k8sClient k8s.KubernetesProvider
+	target    string // URL, or namespace/service:port reached through the API server proxy
+	client    *http.Client
+
+	mu      sync.Mutex
+	samples []ksmSample // Oldest first
+	lastErr string
+}
+
+// NewKSMCollector returns a collector for kube-state-metrics at target: an http(s) URL of its
+// /metrics endpoint, or "namespace/service:port" to go through the API server service proxy,
+// which needs services/proxy access.
+func NewKSMCollector(k8sClient k8s.KubernetesProvider, target string) (*KSMCollector, error) {
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		ns, svc, ok := strings.Cut(target, "/")
+		if !ok || ns == "" || svc == "" {
+			return nil, fmt.Errorf("kube-state-metrics target %q must be a URL or namespace/service:port", target)
+		}
+	}
+	return &KSMCollector{k8sClient: k8sClient, target: target, client: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+// Run scrapes every interval until ctx is cancelled.
+func (k *KSMCollector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		err := k.scrape(ctx)
+		k.mu.Lock()
+		k.lastErr = ""
+		if err != nil {
+			k.lastErr = err.Error()
+		}
+		k.mu.Unlock()
+		if err != nil {
+			log.Printf("kube-state-metrics scrape failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (k *KSMCollector) fetch(ctx context.Context) ([]byte, error) {
+	if ns, svc, ok := strings.Cut(k.target, "/"); ok && !strings.Contains(k.target, "://") {
+		provider, ok := k.k8sClient.(k8s.ClusterInfoProvider)
+		if !ok {
+			return nil, fmt.Errorf("the API server proxy is not available")
+		}
+		return provider.GetRaw(ctx, "/api/v1/namespaces/"+ns+"/services/"+svc+"/proxy/metrics")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.target, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", k.target, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (k *KSMCollector) scrape(ctx context.Context) error {
+	body, err := k.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	s := parseKSM(string(body))
+	s.At = time.Now().UTC()
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.samples = append(k.samples, s)
+	cutoff := s.At.Add(-ksmRetention)
+	i := 0
+	for i < len(k.samples) && k.samples[i].At.Before(cutoff) {
+		i++
+	}
+	k.samples = k.samples[i:]
+	return nil
+}
+
+// parseKSM extracts the series the insights are derived from.
+func parseKSM(body string) ksmSample {
+	s := ksmSample{Restarts: map[string]float64{}, Unschedulable: map[string]bool{}, Deployments: map[string][2]float64{}}
+	for _, line := range strings.Split(body, "\n") {
+		if !strings.HasPrefix(line, "kube_pod_") && !strings.HasPrefix(line, "kube_deployment_") {
+			continue
+		}
+		name, labels, value, ok := parseMetricLine(line)
+		if !ok {
+			continue
+		}
+		pod := labels["namespace"] + "/" + labels["pod"]
+		deployment := labels["namespace"] + "/" + labels["deployment"]
+		switch name {
+		case "kube_pod_container_status_restarts_total":
+			s.Restarts[pod] += value
+		case "kube_pod_status_unschedulable":
+			if value > 0 {
+				s.Unschedulable[pod] = true
+			}
+		case "kube_deployment_spec_replicas":
+			d := s.Deployments[deployment]
+			d[0] = value
+			s.Deployments[deployment] = d
+		case "kube_deployment_status_replicas_available":
+			d := s.Deployments[deployment]
+			d[1] = value
+			s.Deployments[deployment] = d
+		}
+	}
+	return s
+}
+
+// PodRestartRate is how often a pod restarted within the window.
+type PodRestartRate struct {
+	Namespace string  `json:"namespace"`
+	Pod       string  `json:"pod"`
+	Restarts  float64 `json:"restarts"`
+	PerHour   float64 `json:"perHour"`
+}
+
+// UnschedulablePod is a pod the scheduler cannot place, and since when, as far as the samples go.
+type UnschedulablePod struct {
+	Namespace string    `json:"namespace"`
+	Pod       string    `json:"pod"`
+	Since     time.Time `json:"since"`
+}
+
+// DeploymentAvailability is the share of samples in which a deployment had all its replicas.
+type DeploymentAvailability struct {
+	Namespace    string  `json:"namespace"`
+	Deployment   string  `json:"deployment"`
+	Desired      float64 `json:"desired"`
+	Available    float64 `json:"available"`
+	Availability float64 `json:"availability"` // Percentage over the window
+}
+
+// KSMPoint is one scrape, for charts.
+type KSMPoint struct {
+	At                     time.Time `json:"at"`
+	Restarts               float64   `json:"restarts"` // Since the previous scrape
+	Unschedulable          int       `json:"unschedulable"`
+	UnavailableDeployments int       `json:"unavailableDeployments"`
+}
+
+func splitKey(key string) (string, string) {
+	ns, name, _ := strings.Cut(key, "/")
+	return ns, name
+}
+
+// Insights derives restart rates, unschedulable pods and deployment availability over
+// ?window= (default 1h, at most 24h). Users limited to a namespace only see that namespace.
+func (k *KSMCollector) Insights(c *gin.Context) {
+	window, err := time.ParseDuration(c.DefaultQuery("window", "1h"))
+	if err != nil || window <= 0 || window > ksmRetention {
+		apierror.Write(c, http.StatusBadRequest, "window must be a duration of at most 24h")
+		return
+	}
+	namespace := rbacNamespace(c)
+	visible := func(key string) bool {
+		return namespace == "" || strings.HasPrefix(key, namespace+"/")
+	}
+
+	since := time.Now().UTC().Add(-window)
+	k.mu.Lock()
+	var samples []ksmSample
+	for _, s := range k.samples {
+		if !s.At.Before(since) {
+			samples = append(samples, s)
+		}
+	}
+	lastErr := k.lastErr
+	k.mu.Unlock()
+
+	if len(samples) == 0 {
+		c.JSON(http.StatusOK, gin.H{"available": false, "error": lastErr, "restarts": []PodRestartRate{}, "unschedulable": []UnschedulablePod{}, "deployments": []DeploymentAvailability{}, "history": []KSMPoint{}})
+		return
+	}
+	first, last := samples[0], samples[len(samples)-1]
+	hours := last.At.Sub(first.At).Hours()
+
+	restarts := []PodRestartRate{}
+	for key, n := range last.Restarts {
+		if !visible(key) {
+			continue
+		}
+		// Restarts are cumulative per pod; a pod that was not there at the start, or whose
+		// count went down because it was recreated, counts from zero
+		delta := n
+		if before, ok := first.Restarts[key]; ok && n >= before {
+			delta = n - before
+		}
+		if delta == 0 {
+			continue
+		}
+		ns, pod := splitKey(key)
+		r := PodRestartRate{Namespace: ns, Pod: pod, Restarts: delta}
+		if hours > 0 {
+			r.PerHour = delta / hours
+		}
+		restarts = append(restarts, r)
+	}
+	sort.Slice(restarts, func(i, j int) bool { return restarts[i].Restarts > restarts[j].Restarts })
+
+	unschedulable := []UnschedulablePod{}
+	for key := range last.Unschedulable {
+		if !visible(key) {
+			continue
+		}
+		since := last.At
+		for i := len(samples) - 1; i >= 0 && samples[i].Unschedulable[key]; i-- {
+			since = samples[i].At
+		}
+		ns, pod := splitKey(key)
+		unschedulable = append(unschedulable, UnschedulablePod{Namespace: ns, Pod: pod, Since: since})
+	}
+	sort.Slice(unschedulable, func(i, j int) bool { return unschedulable[i].Since.Before(unschedulable[j].Since) })
+
+	deployments := []DeploymentAvailability{}
+	for key, d := range last.Deployments {
+		if !visible(key) {
+			continue
+		}
+		available, total := 0, 0
+		for _, s := range samples {
+			if sd, ok := s.Deployments[key]; ok {
+				total++
+				if sd[1] >= sd[0] {
+					available++
+				}
+			}
+		}
+		ns, name := splitKey(key)
+		deployments = append(deployments, DeploymentAvailability{Namespace: ns, Deployment: name, Desired: d[0], Available: d[1], Availability: float64(available) / float64(total) * 100})
+	}
+	sort.Slice(deployments, func(i, j int) bool {
+		if deployments[i].Availability != deployments[j].Availability {
+			return deployments[i].Availability < deployments[j].Availability
+		}
+		return deployments[i].Namespace+"/"+deployments[i].Deployment < deployments[j].Namespace+"/"+deployments[j].Deployment
+	})
+
+	history := make([]KSMPoint, 0, len(samples))
+	for i, s := range samples {
+		p := KSMPoint{At: s.At}
+		for key, n := range s.Restarts {
+			if i == 0 || !visible(key) {
+				continue
+			}
+			if before, ok := samples[i-1].Restarts[key]; ok && n >= before {
+				p.Restarts += n - before
+			} else {
+				p.Restarts += n
+			}
+		}
+		for key := range s.Unschedulable {
+			if visible(key) {
+				p.Unschedulable++
+			}
+		}
+		for key, d := range s.Deployments {
+			if visible(key) && d[1] < d[0] {
+				p.UnavailableDeployments++
+			}
+		}
+		history = append(history, p)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"available":     true,
+		"error":         lastErr,
+		"since":         first.At,
+		"scrapedAt":     last.At,
+		"restarts":      restarts,
+		"unschedulable": unschedulable,
+		"deployments":   deployments,
+		"history":       history,
+	})
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"k-view/apierror"
+	"k-view/k8s"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const maxKubeconfigTTL = 7 * 24 * time.Hour
+
+// KubeconfigRequest is the body of a POST /api/rbac/kubeconfig request.
+type KubeconfigRequest struct {
+	User     string   `json:"user" binding:"required"`
+	Groups   []string `json:"groups"`
+	Mode     string   `json:"mode"`     // "token" (ServiceAccount token, default) or "oidc" (exec plugin)
+	TTLHours int      `json:"ttlHours"` // Token lifetime, default 8h
+}
+
+// clusterRoleFor maps a K-View role to the ClusterRole installed by the Helm chart.
+// Namespace-restricted assignments use the kview-namespace-* variants.
+func clusterRoleFor(role string, namespaces []string) string {
+	level := "viewer"
+	switch role {
+	case "kview-cluster-admin", "kview-namespace-admin", "admin":
+		level = "admin"
+	case "kview-cluster-developer", "kview-namespace-developer", "edit":
+		level = "developer"
+	}
+	if len(namespaces) > 0 {
+		return "kview-namespace-" + level
+	}
+	return "kview-cluster-" + level
+}
+
+// SetOIDC sets the issuer and client ID the kubelogin plugin authenticates against; they must
+// match K-View's own SSO configuration so the API server trusts the same tokens.
+func (h *RBACHandler) SetOIDC(issuer, clientID string) {
+	h.oidcIssuer = issuer
+	h.oidcClientID = clientID
+}
+
+// GenerateKubeconfig builds a kubeconfig whose permissions match the user's K-View role, either
+// backed by a short-lived ServiceAccount token or by the kubelogin OIDC exec plugin.
+func (h *RBACHandler) GenerateKubeconfig(c *gin.Context) {
+	var req KubeconfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "user is required")
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = "token"
+	}
+	if req.Mode != "token" && req.Mode != "oidc" {
+		apierror.Write(c, http.StatusBadRequest, "mode must be token or oidc")
+		return
+	}
+	if req.Mode == "oidc" && h.oidcClientID == "" && !h.devMode {
+		apierror.Write(c, http.StatusBadRequest, "OIDC kubeconfigs require SSO to be configured")
+		return
+	}
+	ttl := time.Duration(req.TTLHours) * time.Hour
+	if ttl <= 0 {
+		ttl = 8 * time.Hour
+	}
+	if ttl > maxKubeconfigTTL {
+		ttl = maxKubeconfigTTL
+	}
+
+	role, namespaces := h.config.Resolve(req.User, req.Groups)
+	clusterRole := clusterRoleFor(role, namespaces)
+	namespace := ""
+	if len(namespaces) > 0 {
+		namespace = namespaces[0]
+	}
+
+	server, caData := "https://kubernetes.default.svc", []byte(nil)
+	if h.devMode {
+		server = "https://kubernetes.mock:6443"
+	} else if issuer, ok := h.k8sClient.(k8s.ServiceAccountIssuer); ok {
+		var err error
+		server, caData, err = issuer.ServerInfo()
+		if err != nil {
+			apierror.Fail(c, "", err)
+			return
+		}
+	}
+
+	authInfo := clientcmdapi.NewAuthInfo()
+	userName := req.User
+	switch req.Mode {
+	case "oidc":
+		// The API server must trust the same OIDC issuer; the user's email is then bound
+		// to the role by the chart's RBAC assignments.
+		authInfo.Exec = &clientcmdapi.ExecConfig{
+			APIVersion: "client.authentication.k8s.io/v1beta1",
+			Command:    "kubectl",
+			Args: []string{
+				"oidc-login", "get-token",
+				"--oidc-issuer-url=" + h.oidcIssuer,
+				"--oidc-client-id=" + h.oidcClientID,
+				"--oidc-extra-scope=email",
+			},
+			InstallHint:     "Install the kubelogin plugin: kubectl krew install oidc-login",
+			InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
+		}
+	case "token":
+		saName := k8s.ServiceAccountNameFor(req.User)
+		userName = saName
+		token := "mock-token-" + saName
+		if !h.devMode {
+			issuer, ok := h.k8sClient.(k8s.ServiceAccountIssuer)
+			if !ok {
+				apierror.Write(c, http.StatusNotImplemented, "token kubeconfigs require a real cluster connection")
+				return
+			}
+			var err error
+			token, err = issuer.IssueServiceAccountToken(c.Request.Context(), k8s.CurrentNamespace(), saName, clusterRole, namespaces, ttl)
+			if err != nil {
+				apierror.Fail(c, "", err)
+				return
+			}
+		}
+		authInfo.Token = token
+	}
+
+	contextName := "kview-" + strings.SplitN(req.User, "@", 2)[0]
+	config := clientcmdapi.NewConfig()
+	config.Clusters["kview"] = &clientcmdapi.Cluster{Server: server, CertificateAuthorityData: caData}
+	config.AuthInfos[userName] = authInfo
+	config.Contexts[contextName] = &clientcmdapi.Context{Cluster: "kview", AuthInfo: userName, Namespace: namespace}
+	config.CurrentContext = contextName
+
+	data, err := clientcmd.Write(*config)
+	if err != nil {
+		apierror.Fail(c, "Failed to render kubeconfig", err)
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=kubeconfig-"+contextName+".yaml")
+	c.Header("X-Kview-Role", clusterRole)
+	if req.Mode == "token" {
+		c.Header("X-Kview-Expires", time.Now().Add(ttl).UTC().Format(time.RFC3339))
+	}
+	c.Data(http.StatusOK, "application/yaml", data)
+}
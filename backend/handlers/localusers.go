@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k-view/apierror"
+	"k-view/auth"
+	"k-view/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const localUsersDoc = "local-users"
+
+var errLocalUserNotFound = errors.New("user not found")
+
+// LocalUser is a password account. Users from KVIEW_STATIC_USERS or users.yaml are copied in on
+// startup (source "config"); admins create the rest through the API (source "api").
+type LocalUser struct {
+	Username          string     `json:"username"`
+	PasswordHash      string     `json:"passwordHash,omitempty"` // Never returned by the API
+	Disabled          bool       `json:"disabled"`
+	Source            string     `json:"source"`
+	CreatedBy         string     `json:"createdBy,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	PasswordChangedAt *time.Time `json:"passwordChangedAt,omitempty"`
+	MFAEnabled        bool       `json:"mfaEnabled"`
+}
+
+// LocalUserHandler lets admins manage local accounts and users change their own password.
+// Accounts are persisted in the data store, with the static config as the bootstrap source: a
+// config user follows its configured hash until the password is changed through the API.
+type LocalUserHandler struct {
+	mu    sync.Mutex
+	store *store.Store
+	auth  *AuthHandler
+}
+
+func NewLocalUserHandler(st *store.Store, authHandler *AuthHandler) *LocalUserHandler {
+	h := &LocalUserHandler{store: st, auth: authHandler}
+	if authHandler.localAuth == nil {
+		return h
+	}
+	configured, err := auth.ConfiguredUsers()
+	if err != nil {
+		log.Printf("Failed to read configured local users: %v", err)
+	}
+	err = h.update(func(users []LocalUser) ([]LocalUser, error) {
+		for _, cu := range configured {
+			i := findLocalUser(users, cu.Username)
+			switch {
+			case i < 0:
+				users = append(users, LocalUser{Username: cu.Username, PasswordHash: cu.PasswordHash, Disabled: cu.Disabled, Source: "config", CreatedAt: time.Now().UTC()})
+			case users[i].Source == "config" && users[i].PasswordChangedAt == nil:
+				users[i].PasswordHash = cu.PasswordHash
+			}
+		}
+		return users, nil
+	})
+	if err != nil {
+		log.Printf("Failed to load local users: %v", err)
+	}
+	return h
+}
+
+func findLocalUser(users []LocalUser, username string) int {
+	for i := range users {
+		if users[i].Username == username {
+			return i
+		}
+	}
+	return -1
+}
+
+// update applies fn to the stored users and hands the result to the authenticator.
+func (h *LocalUserHandler) update(fn func([]LocalUser) ([]LocalUser, error)) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var stored []LocalUser
+	err := h.store.Update(localUsersDoc, &stored, func() error {
+		var err error
+		stored, err = fn(stored)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	h.setAccounts(stored)
+	return nil
+}
+
+// setAccounts hands the stored users to the authenticator.
+func (h *LocalUserHandler) setAccounts(stored []LocalUser) {
+	accounts := make([]auth.StaticUser, 0, len(stored))
+	for _, u := range stored {
+		accounts = append(accounts, auth.StaticUser{Username: u.Username, PasswordHash: u.PasswordHash, Disabled: u.Disabled, PasswordChangedAt: u.PasswordChangedAt})
+	}
+	h.auth.localAuth.SetUsers(accounts)
+}
+
+func (h *LocalUserHandler) Document() string { return localUsersDoc }
+
+// Reload hands the stored users to the authenticator again.
+func (h *LocalUserHandler) Reload() error {
+	if h.auth.localAuth == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var stored []LocalUser
+	if err := h.store.Load(localUsersDoc, &stored); err != nil {
+		return err
+	}
+	h.setAccounts(stored)
+	return nil
+}
+
+func (h *LocalUserHandler) enabled(c *gin.Context) bool {
+	if h.auth.localAuth == nil {
+		apierror.Write(c, http.StatusNotFound, "Local authentication is not enabled")
+		return false
+	}
+	return true
+}
+
+// List returns all local users without their password hashes (admin only).
+func (h *LocalUserHandler) List(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+	var users []LocalUser
+	if err := h.store.Load(localUsersDoc, &users); err != nil {
+		apierror.Fail(c, "Failed to load users", err)
+		return
+	}
+	result := make([]LocalUser, 0, len(users))
+	for _, u := range users {
+		u.PasswordHash = ""
+		u.MFAEnabled = h.auth.mfa.Enabled(u.Username)
+		result = append(result, u)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Username < result[j].Username })
+	c.JSON(http.StatusOK, result)
+}
+
+// Create adds a local user (admin only).
+func (h *LocalUserHandler) Create(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+	var req struct {
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "username and password are required")
+		return
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Username == "" || strings.ContainsAny(req.Username, " \t/") {
+		apierror.Write(c, http.StatusBadRequest, "username must not be empty or contain spaces or slashes")
+		return
+	}
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		apierror.Write(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	email, _ := c.Get("email")
+	admin, _ := email.(string)
+	now := time.Now().UTC()
+	user := LocalUser{Username: req.Username, PasswordHash: hash, Source: "api", CreatedBy: admin, CreatedAt: now, PasswordChangedAt: &now}
+	err = h.update(func(users []LocalUser) ([]LocalUser, error) {
+		if findLocalUser(users, user.Username) >= 0 {
+			return nil, errors.New("user " + user.Username + " already exists")
+		}
+		return append(users, user), nil
+	})
+	if err != nil {
+		apierror.Write(c, http.StatusConflict, err.Error())
+		return
+	}
+	auditf("local user %s created by %s", user.Username, admin)
+	user.PasswordHash = ""
+	c.JSON(http.StatusCreated, user)
+}
+
+// modify applies fn to one user and reports the outcome.
+func (h *LocalUserHandler) modify(c *gin.Context, username string, fn func(*LocalUser) error) bool {
+	err := h.update(func(users []LocalUser) ([]LocalUser, error) {
+		i := findLocalUser(users, username)
+		if i < 0 {
+			return nil, errLocalUserNotFound
+		}
+		return users, fn(&users[i])
+	})
+	switch {
+	case errors.Is(err, errLocalUserNotFound):
+		apierror.Write(c, http.StatusNotFound, err.Error())
+		return false
+	case err != nil:
+		apierror.Write(c, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+// setPassword hashes a new password and ends the user's existing sessions.
+func setPassword(u *LocalUser, password string) error {
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	u.PasswordHash, u.PasswordChangedAt = hash, &now
+	return nil
+}
+
+// ResetPassword sets a new password for a user (admin only).
+func (h *LocalUserHandler) ResetPassword(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+	var req struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "password is required")
+		return
+	}
+	username := c.Param("username")
+	if !h.modify(c, username, func(u *LocalUser) error { return setPassword(u, req.Password) }) {
+		return
+	}
+	admin, _ := c.Get("email")
+	auditf("password of local user %s reset by %v", username, admin)
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset for " + username})
+}
+
+// setDisabled disables or re-enables an account (admin only). Disabling ends its sessions.
+func (h *LocalUserHandler) setDisabled(c *gin.Context, disabled bool) {
+	if !h.enabled(c) {
+		return
+	}
+	username := c.Param("username")
+	email, _ := c.Get("email")
+	if disabled && email == username {
+		apierror.Write(c, http.StatusBadRequest, "you cannot disable your own account")
+		return
+	}
+	if !h.modify(c, username, func(u *LocalUser) error {
+		u.Disabled = disabled
+		return nil
+	}) {
+		return
+	}
+	action := "enabled"
+	if disabled {
+		action = "disabled"
+	}
+	auditf("local user %s %s by %v", username, action, email)
+	c.JSON(http.StatusOK, gin.H{"username": username, "disabled": disabled})
+}
+
+func (h *LocalUserHandler) Disable(c *gin.Context) { h.setDisabled(c, true) }
+func (h *LocalUserHandler) Enable(c *gin.Context)  { h.setDisabled(c, false) }
+
+// ChangePassword lets a local user change their own password. Other sessions of the user end;
+// the response carries a fresh token for this one.
+func (h *LocalUserHandler) ChangePassword(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+	var req struct {
+		CurrentPassword string `json:"currentPassword" binding:"required"`
+		NewPassword     string `json:"newPassword" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "currentPassword and newPassword are required")
+		return
+	}
+	username, ok := h.auth.LocalSession(c)
+	if !ok {
+		apierror.Write(c, http.StatusBadRequest, "Only local users have a K-View password")
+		return
+	}
+	if !h.auth.localAuth.Authenticate(username, req.CurrentPassword) {
+		apierror.Write(c, http.StatusUnauthorized, "Current password is incorrect")
+		return
+	}
+	if !h.modify(c, username, func(u *LocalUser) error { return setPassword(u, req.NewPassword) }) {
+		return
+	}
+	token, err := h.auth.localAuth.GenerateJWT(username)
+	if err != nil {
+		apierror.Write(c, http.StatusInternalServerError, "Failed to generate session token")
+		return
+	}
+	auditf("local user %s changed their password", username)
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
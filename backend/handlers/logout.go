@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"k-view/apierror"
+	"k-view/store"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gin-gonic/gin"
+)
+
+const sessionLogoutsDoc = "oidc-logouts"
+
+// sessionLifetime matches the auth cookie; revocations older than this match no live session.
+const sessionLifetime = 24 * time.Hour
+
+// backChannelLogoutEvent is the event a logout token must carry (OpenID Back-Channel Logout 1.0).
+const backChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+// maxLogoutTokenAge bounds how old the iat of a logout token may be, limiting replays.
+const maxLogoutTokenAge = 10 * time.Minute
+
+// SessionLogout ends OIDC sessions before their ID token expires: one signed-out token, an IdP
+// session (sid), or every session of a subject that started before the logout.
+type SessionLogout struct {
+	Token     string    `json:"token,omitempty"` // SHA-256 of the ID token, for K-View's own logout
+	SID       string    `json:"sid,omitempty"`
+	Subject   string    `json:"sub,omitempty"`
+	LoggedOut time.Time `json:"loggedOut"`
+}
+
+// SessionRevocations remembers IdP-side and local sign-outs so AuthMiddleware rejects ID tokens
+// that are still valid cryptographically.
+type SessionRevocations struct {
+	mu      sync.RWMutex
+	logouts []SessionLogout // Cached copy of the store document
+	index   revocationIndex // The logouts by key; AuthMiddleware reads it on every request
+	store   *store.Store
+}
+
+// revocationIndex looks logouts up by token fingerprint, sid and subject.
+type revocationIndex struct {
+	tokens   map[string]bool
+	sids     map[string]bool
+	subjects map[string]time.Time // Latest logout of every session of the subject
+}
+
+func NewSessionRevocations(st *store.Store) *SessionRevocations {
+	r := &SessionRevocations{store: st}
+	var logouts []SessionLogout
+	if err := st.Load(sessionLogoutsDoc, &logouts); err != nil {
+		log.Printf("Failed to load session logouts: %v", err)
+	}
+	r.set(logouts)
+	return r
+}
+
+// set replaces the cached logouts and rebuilds the index. Callers hold r.mu, except during
+// construction.
+func (r *SessionRevocations) set(logouts []SessionLogout) {
+	index := revocationIndex{tokens: map[string]bool{}, sids: map[string]bool{}, subjects: map[string]time.Time{}}
+	for _, l := range logouts {
+		switch {
+		case l.Token != "":
+			index.tokens[l.Token] = true
+		case l.SID != "":
+			index.sids[l.SID] = true
+		case l.Subject != "" && l.LoggedOut.After(index.subjects[l.Subject]):
+			index.subjects[l.Subject] = l.LoggedOut
+		}
+	}
+	r.logouts, r.index = logouts, index
+}
+
+func (r *SessionRevocations) Document() string { return sessionLogoutsDoc }
+
+// Reload refreshes the cached logouts from the store.
+func (r *SessionRevocations) Reload() error {
+	var logouts []SessionLogout
+	if err := r.store.Load(sessionLogoutsDoc, &logouts); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.set(logouts)
+	r.mu.Unlock()
+	return nil
+}
+
+func tokenFingerprint(rawToken string) string {
+	sum := sha256.Sum256([]byte(rawToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// Revoked reports whether an ID token belongs to a session that has been logged out.
+func (r *SessionRevocations) Revoked(rawToken, subject, sid string, issuedAt time.Time) bool {
+	if r == nil {
+		return false
+	}
+	fingerprint := tokenFingerprint(rawToken)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.index.tokens[fingerprint] || (sid != "" && r.index.sids[sid]) {
+		return true
+	}
+	loggedOut, ok := r.index.subjects[subject]
+	return ok && issuedAt.Before(loggedOut)
+}
+
+// add records a logout. The Pruner drops the ones past their retention.
+func (r *SessionRevocations) add(logout SessionLogout) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var logouts []SessionLogout
+	err := r.store.Update(sessionLogoutsDoc, &logouts, func() error {
+		logouts = append(logouts, logout)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	r.set(logouts)
+	return nil
+}
+
+// prune drops the logouts recorded before the cutoff and returns how many were removed.
+// Logouts within the session lifetime are always kept, as they still match live sessions.
+func (r *SessionRevocations) prune(before time.Time) (int, error) {
+	if r == nil {
+		return 0, nil
+	}
+	if limit := time.Now().Add(-sessionLifetime); before.After(limit) {
+		before = limit
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stale := false
+	for _, l := range r.logouts {
+		stale = stale || l.LoggedOut.Before(before)
+	}
+	if !stale {
+		return 0, nil // Nothing to drop; spare the rewrite
+	}
+	var logouts []SessionLogout
+	removed := 0
+	err := r.store.Update(sessionLogoutsDoc, &logouts, func() error {
+		kept := logouts[:0]
+		for _, l := range logouts {
+			if l.LoggedOut.Before(before) {
+				removed++
+				continue
+			}
+			kept = append(kept, l)
+		}
+		logouts = kept
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	r.set(logouts)
+	return removed, nil
+}
+
+// Logout clears the auth cookie and revokes the session's ID token. When the IdP has an
+// end-session endpoint the response carries a redirect URL that signs the user out there too
+// (OpenID RP-Initiated Logout 1.0).
+func (h *AuthHandler) Logout(c *gin.Context) {
+	resp := gin.H{"message": "Logged out"}
+	if tokenStr, err := c.Cookie("auth_token"); err == nil && tokenStr != "" && h.verifier != nil {
+		// An expired token cannot be reused anyway, and is not worth recording
+		if idToken, err := h.verifier.Verify(c, tokenStr); err == nil {
+			if err := h.revocations.add(SessionLogout{Token: tokenFingerprint(tokenStr), LoggedOut: time.Now().UTC()}); err != nil {
+				log.Printf("Failed to record logout: %v", err)
+			}
+			var claims struct {
+				Email string `json:"email"`
+			}
+			_ = idToken.Claims(&claims)
+			log.Printf("User %s logged out", claims.Email)
+		}
+		if h.endSessionURL != "" {
+			resp["redirect"] = h.endSessionRedirect(tokenStr)
+		}
+	}
+	// Proxy sessions end at the proxy, or the next request signs the user straight back in
+	if user, _, ok := h.proxy.identity(c.Request); ok && h.proxy.LogoutURL != "" {
+		log.Printf("User %s logged out", user)
+		resp["redirect"] = h.proxy.LogoutURL
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     "auth_token",
+		Value:    "",
+		Expires:  time.Unix(0, 0),
+		HttpOnly: true,
+		Path:     "/",
+	})
+	c.JSON(http.StatusOK, resp)
+}
+
+// endSessionRedirect builds the IdP end-session URL for an ID token.
+func (h *AuthHandler) endSessionRedirect(idToken string) string {
+	u, err := url.Parse(h.endSessionURL)
+	if err != nil {
+		return h.endSessionURL
+	}
+	q := u.Query()
+	q.Set("id_token_hint", idToken)
+	q.Set("client_id", h.oauth2Config.ClientID)
+	if h.postLogoutRedirect != "" {
+		q.Set("post_logout_redirect_uri", h.postLogoutRedirect)
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// BackChannelLogout receives logout tokens the IdP POSTs when a user signs out there, and ends
+// the matching K-View sessions (OpenID Back-Channel Logout 1.0).
+func (h *AuthHandler) BackChannelLogout(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	if h.logoutVerifier == nil {
+		apierror.Write(c, http.StatusNotFound, "OIDC is not configured")
+		return
+	}
+	rawToken := c.PostForm("logout_token")
+	if rawToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "logout_token is required"})
+		return
+	}
+	logout, err := h.verifyLogoutToken(c, rawToken)
+	if err != nil {
+		log.Printf("Rejected back-channel logout: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": err.Error()})
+		return
+	}
+	if err := h.revocations.add(logout); err != nil {
+		apierror.Fail(c, "Failed to record logout", err)
+		return
+	}
+	auditf("IdP back-channel logout for sub=%q sid=%q", logout.Subject, logout.SID)
+	c.Status(http.StatusOK)
+}
+
+// verifyLogoutToken checks a logout token's signature, issuer and audience, then the claims the
+// spec requires: the logout event, a sid or sub, and no nonce.
+func (h *AuthHandler) verifyLogoutToken(c *gin.Context, rawToken string) (SessionLogout, error) {
+	token, err := h.logoutVerifier.Verify(c, rawToken)
+	if err != nil {
+		return SessionLogout{}, err
+	}
+	var claims struct {
+		SID    string                     `json:"sid"`
+		Nonce  *string                    `json:"nonce"`
+		Events map[string]json.RawMessage `json:"events"`
+	}
+	if err := token.Claims(&claims); err != nil {
+		return SessionLogout{}, err
+	}
+	switch {
+	case claims.Events[backChannelLogoutEvent] == nil:
+		return SessionLogout{}, errors.New("logout_token lacks the back-channel logout event")
+	case claims.Nonce != nil:
+		return SessionLogout{}, errors.New("logout_token must not contain a nonce")
+	case claims.SID == "" && token.Subject == "":
+		return SessionLogout{}, errors.New("logout_token must contain sid or sub")
+	case time.Since(token.IssuedAt) > maxLogoutTokenAge:
+		return SessionLogout{}, errors.New("logout_token is too old")
+	}
+	return SessionLogout{SID: claims.SID, Subject: token.Subject, LoggedOut: time.Now().UTC()}, nil
+}
+
+// FrontChannelLogout is loaded by the IdP in a hidden iframe when a user signs out there
+// (OpenID Front-Channel Logout 1.0). It ends the IdP session given by ?sid= and clears the
+// cookie, which browsers send to the iframe only where third-party cookies are allowed. The
+// request is unauthenticated, so the sid is recorded only when it is the one of the caller's
+// own valid session cookie; anyone else could otherwise fill the store with made-up sids.
+func (h *AuthHandler) FrontChannelLogout(c *gin.Context) {
+	c.Header("Cache-Control", "no-cache, no-store")
+	if iss := c.Query("iss"); iss != "" && iss != h.issuer {
+		apierror.Write(c, http.StatusBadRequest, "Unknown issuer")
+		return
+	}
+	if sid := c.Query("sid"); sid != "" && sid == h.cookieSID(c) {
+		if err := h.revocations.add(SessionLogout{SID: sid, LoggedOut: time.Now().UTC()}); err != nil {
+			log.Printf("Failed to record logout: %v", err)
+		}
+		auditf("IdP front-channel logout for sid=%q", sid)
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     "auth_token",
+		Value:    "",
+		Expires:  time.Unix(0, 0),
+		HttpOnly: true,
+		Path:     "/",
+	})
+	c.Status(http.StatusOK)
+}
+
+// cookieSID returns the sid claim of the ID token in the request's auth cookie, or "" when
+// there is no valid one.
+func (h *AuthHandler) cookieSID(c *gin.Context) string {
+	tokenStr, err := c.Cookie("auth_token")
+	if err != nil || tokenStr == "" || h.verifier == nil {
+		return ""
+	}
+	idToken, err := h.verifier.Verify(c, tokenStr)
+	if err != nil {
+		return ""
+	}
+	var claims struct {
+		SID string `json:"sid"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return ""
+	}
+	return claims.SID
+}
+
+// discoverEndSession reads the end-session endpoint from the IdP's discovery document.
+func discoverEndSession(provider *oidc.Provider) string {
+	var meta struct {
+		EndSessionEndpoint string `json:"end_session_endpoint"`
+	}
+	if err := provider.Claims(&meta); err != nil {
+		return ""
+	}
+	return meta.EndSessionEndpoint
+}
+
+// SetRevocations enables logout tracking for OIDC sessions.
+func (h *AuthHandler) SetRevocations(r *SessionRevocations) {
+	h.revocations = r
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// logLevels orders the normalized severities; ?level= keeps lines at or above the given one.
+var logLevels = map[string]int{"trace": 0, "debug": 1, "info": 2, "warn": 3, "error": 4, "fatal": 5}
+
+var (
+	// textLevel finds a level word in a plain-text line: "[info]", "level=WARN", " ERROR ", ...
+	textLevel = regexp.MustCompile(`(?i)(?:^|[\s\[(|:=])(trace|debug|info|notice|warn|warning|error|err|fatal|panic|critical|crit)(?:$|[\s\])|:,])`)
+	// klogHeader matches the klog/glog prefix, e.g. "E0212 10:00:01.123456    1 file.go:12] msg"
+	klogHeader = regexp.MustCompile(`^([IWEF])(\d{4} \d{2}:\d{2}:\d{2}\.\d+)\s+\d+\s+[^\]]+\]\s?(.*)$`)
+	// textTimestamp matches a leading ISO-8601 timestamp, with a T or a space
+	textTimestamp = regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?)\]?\s*`)
+	// logfmtPair matches key=value, key="quoted value" in a logfmt line
+	logfmtPair = regexp.MustCompile(`([A-Za-z_][\w.\-/]*)=("(?:[^"\\]|\\.)*"|\S*)`)
+)
+
+// LogLine is one parsed log line.
+type LogLine struct {
+	Format    string            `json:"format"` // json, logfmt, klog or text; restart for a restart boundary
+	Level     string            `json:"level,omitempty"`
+	Timestamp string            `json:"timestamp,omitempty"`
+	Message   string            `json:"message"`
+	Fields    map[string]string `json:"fields,omitempty"` // Remaining JSON or logfmt fields
+	Raw       string            `json:"raw"`
+	Instance  string            `json:"instance,omitempty"` // previous or current, when merged across a restart
+}
+
+// normalizeLevel maps the many spellings of a severity onto the logLevels names.
+func normalizeLevel(level string) string {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "trace", "trc":
+		return "trace"
+	case "debug", "dbg", "d":
+		return "debug"
+	case "info", "inf", "information", "notice", "i":
+		return "info"
+	case "warn", "warning", "wrn", "w":
+		return "warn"
+	case "error", "err", "eror", "e":
+		return "error"
+	case "fatal", "panic", "critical", "crit", "dpanic", "emergency", "alert", "f":
+		return "fatal"
+	}
+	// Numeric levels (bunyan/pino): 10 trace ... 60 fatal
+	var n int
+	if _, err := fmt.Sscanf(level, "%d", &n); err == nil && n >= 10 && n <= 60 {
+		return []string{"trace", "debug", "info", "warn", "error", "fatal"}[n/10-1]
+	}
+	return ""
+}
+
+// firstField removes and returns the first of the given keys present in fields.
+func firstField(fields map[string]string, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := fields[k]; ok {
+			delete(fields, k)
+			return v
+		}
+	}
+	return ""
+}
+
+var (
+	logLevelKeys     = []string{"level", "lvl", "severity", "log.level", "loglevel"}
+	logTimestampKeys = []string{"ts", "time", "timestamp", "@timestamp", "t", "date"}
+	logMessageKeys   = []string{"msg", "message", "@message", "log"}
+)
+
+// structuredLine fills in a LogLine from decoded JSON or logfmt fields.
+func structuredLine(format, raw string, fields map[string]string) LogLine {
+	line := LogLine{Format: format, Raw: raw}
+	line.Level = normalizeLevel(firstField(fields, logLevelKeys...))
+	line.Timestamp = firstField(fields, logTimestampKeys...)
+	line.Message = firstField(fields, logMessageKeys...)
+	if len(fields) > 0 {
+		line.Fields = fields
+	}
+	return line
+}
+
+// parseLogfmt decodes a logfmt line; it requires at least two pairs and a message or level key
+// so that prose with an "=" in it is not mistaken for logfmt.
+func parseLogfmt(raw string) (map[string]string, bool) {
+	matches := logfmtPair.FindAllStringSubmatch(raw, -1)
+	if len(matches) < 2 {
+		return nil, false
+	}
+	fields := make(map[string]string, len(matches))
+	for _, m := range matches {
+		value := m[2]
+		if strings.HasPrefix(value, `"`) {
+			if unquoted, err := unquoteLogfmt(value); err == nil {
+				value = unquoted
+			}
+		}
+		fields[m[1]] = value
+	}
+	for _, k := range append(append([]string{}, logLevelKeys...), logMessageKeys...) {
+		if _, ok := fields[k]; ok {
+			return fields, true
+		}
+	}
+	return nil, false
+}
+
+func unquoteLogfmt(value string) (string, error) {
+	var s string
+	err := json.Unmarshal([]byte(value), &s)
+	return s, err
+}
+
+// parseLogLine detects the format of a line and extracts its level, timestamp and message.
+func parseLogLine(raw string) LogLine {
+	trimmed := strings.TrimSpace(raw)
+	if strings.HasPrefix(trimmed, "{") {
+		var obj map[string]interface{}
+		if json.Unmarshal([]byte(trimmed), &obj) == nil {
+			fields := make(map[string]string, len(obj))
+			for k, v := range obj {
+				if s, ok := v.(string); ok {
+					fields[k] = s
+				} else {
+					b, _ := json.Marshal(v)
+					fields[k] = string(b)
+				}
+			}
+			return structuredLine("json", raw, fields)
+		}
+	}
+	if m := klogHeader.FindStringSubmatch(raw); m != nil {
+		return LogLine{Format: "klog", Level: normalizeLevel(m[1]), Timestamp: m[2], Message: m[3], Raw: raw}
+	}
+	if fields, ok := parseLogfmt(trimmed); ok {
+		return structuredLine("logfmt", raw, fields)
+	}
+
+	line := LogLine{Format: "text", Message: raw, Raw: raw}
+	rest := raw
+	if m := textTimestamp.FindStringSubmatch(raw); m != nil {
+		line.Timestamp = m[1]
+		rest = raw[len(m[0]):]
+	}
+	// Only look for the level near the start, where loggers put it
+	head := rest
+	if len(head) > 40 {
+		head = head[:40]
+	}
+	if m := textLevel.FindStringSubmatch(head); m != nil {
+		line.Level = normalizeLevel(m[1])
+	}
+	line.Message = strings.TrimSpace(rest)
+	return line
+}
+
+// isContinuation reports whether a line continues the previous entry, like a stack trace frame.
+func isContinuation(raw string) bool {
+	return strings.HasPrefix(raw, " ") || strings.HasPrefix(raw, "\t") ||
+		strings.HasPrefix(raw, "Caused by:") || strings.HasPrefix(raw, "Traceback")
+}
+
+// parseLogs parses every line of a log. Continuation lines without a level of their own inherit
+// the level of the line they continue, so ?level=error keeps whole stack traces.
+func parseLogs(logs string) []LogLine {
+	raw := strings.Split(strings.TrimSuffix(logs, "\n"), "\n")
+	lines := make([]LogLine, 0, len(raw))
+	prevLevel := ""
+	for _, r := range raw {
+		if r == "" {
+			continue
+		}
+		line := parseLogLine(r)
+		if line.Level == "" && isContinuation(r) {
+			line.Level = prevLevel
+		}
+		prevLevel = line.Level
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// filterLogLevel keeps the lines at or above minLevel. Lines without a detectable level are dropped.
+func filterLogLevel(lines []LogLine, minLevel string) []LogLine {
+	threshold := logLevels[minLevel]
+	kept := lines[:0]
+	for _, line := range lines {
+		if rank, ok := logLevels[line.Level]; ok && rank >= threshold {
+			kept = append(kept, line)
+		}
+	}
+	return kept
+}
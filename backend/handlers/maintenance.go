@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"k-view/apierror"
+	"k-view/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maintenanceDoc = "maintenance-windows"
+
+var errWindowNotFound = errors.New("maintenance window not found")
+
+// MaintenanceWindow is a planned maintenance period for the whole cluster or one namespace.
+type MaintenanceWindow struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title" binding:"required"`
+	Message   string    `json:"message,omitempty"`
+	Namespace string    `json:"namespace,omitempty"` // Empty for cluster-wide maintenance
+	Start     time.Time `json:"start" binding:"required"`
+	End       time.Time `json:"end" binding:"required"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+	Active    bool      `json:"active"`
+}
+
+// MaintenanceHandler manages maintenance windows. Besides the banner API, other components use
+// it to suppress alerting for resources under maintenance.
+type MaintenanceHandler struct {
+	store *store.Store
+}
+
+func NewMaintenanceHandler(st *store.Store) *MaintenanceHandler {
+	return &MaintenanceHandler{store: st}
+}
+
+// windows returns the windows visible from namespace ns ("" sees all) that have not ended yet.
+func (h *MaintenanceHandler) windows(ns string, now time.Time) ([]MaintenanceWindow, error) {
+	var all []MaintenanceWindow
+	if err := h.store.Load(maintenanceDoc, &all); err != nil {
+		return nil, err
+	}
+	result := []MaintenanceWindow{}
+	for _, w := range all {
+		if !w.End.After(now) {
+			continue
+		}
+		if ns != "" && w.Namespace != "" && w.Namespace != ns {
+			continue
+		}
+		w.Active = !now.Before(w.Start)
+		result = append(result, w)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Start.Before(result[j].Start) })
+	return result, nil
+}
+
+// Active returns the maintenance windows in effect right now for namespace ns
+// (cluster-wide windows included). Errors are treated as "no maintenance".
+func (h *MaintenanceHandler) Active(ns string) []MaintenanceWindow {
+	active := []MaintenanceWindow{}
+	if h == nil {
+		return active
+	}
+	windows, err := h.windows(ns, time.Now())
+	if err != nil {
+		return active
+	}
+	for _, w := range windows {
+		if w.Active {
+			active = append(active, w)
+		}
+	}
+	return active
+}
+
+// Suppressed reports whether alerts about namespace ns ("" for cluster-level alerts) should be
+// held back because a maintenance window covering it is in effect.
+func (h *MaintenanceHandler) Suppressed(ns string) bool {
+	for _, w := range h.Active(ns) {
+		if w.Namespace == "" || w.Namespace == ns {
+			return true
+		}
+	}
+	return false
+}
+
+func rbacNamespace(c *gin.Context) string {
+	if rbacNs, exists := c.Get("namespace"); exists {
+		return rbacNs.(string)
+	}
+	return ""
+}
+
+// List returns current and upcoming maintenance windows. ?active=true limits the result to
+// windows in effect now, which is what the UI banner polls.
+func (h *MaintenanceHandler) List(c *gin.Context) {
+	windows, err := h.windows(rbacNamespace(c), time.Now())
+	if err != nil {
+		apierror.Fail(c, "Failed to load maintenance windows", err)
+		return
+	}
+	if c.Query("active") == "true" {
+		active := []MaintenanceWindow{}
+		for _, w := range windows {
+			if w.Active {
+				active = append(active, w)
+			}
+		}
+		windows = active
+	}
+	c.JSON(http.StatusOK, windows)
+}
+
+// Create declares a maintenance window (admin only).
+func (h *MaintenanceHandler) Create(c *gin.Context) {
+	var w MaintenanceWindow
+	if err := c.ShouldBindJSON(&w); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "title, start and end (RFC 3339) are required")
+		return
+	}
+	if !w.End.After(w.Start) {
+		apierror.Write(c, http.StatusBadRequest, "end must be after start")
+		return
+	}
+	if !w.End.After(time.Now()) {
+		apierror.Write(c, http.StatusBadRequest, "the window has already ended")
+		return
+	}
+	email, _ := c.Get("email")
+	w.ID = newID()
+	w.CreatedBy, _ = email.(string)
+	w.CreatedAt = time.Now().UTC()
+	w.Active = false
+
+	var all []MaintenanceWindow
+	now := time.Now()
+	err := h.store.Update(maintenanceDoc, &all, func() error {
+		// Drop windows that ended more than a week ago
+		kept := all[:0]
+		for _, existing := range all {
+			if existing.End.After(now.AddDate(0, 0, -7)) {
+				kept = append(kept, existing)
+			}
+		}
+		all = append(kept, w)
+		return nil
+	})
+	if err != nil {
+		apierror.Fail(c, "Failed to save maintenance window", err)
+		return
+	}
+	w.Active = !now.Before(w.Start)
+	c.JSON(http.StatusCreated, w)
+}
+
+// Delete removes a maintenance window, or ends an active one early (admin only).
+func (h *MaintenanceHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+	var all []MaintenanceWindow
+	err := h.store.Update(maintenanceDoc, &all, func() error {
+		for i := range all {
+			if all[i].ID == id {
+				all = append(all[:i], all[i+1:]...)
+				return nil
+			}
+		}
+		return errWindowNotFound
+	})
+	if err == errWindowNotFound {
+		apierror.Write(c, http.StatusNotFound, "maintenance window "+id+" not found")
+		return
+	}
+	if err != nil {
+		apierror.Fail(c, "Failed to save maintenance window", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Maintenance window deleted"})
+}
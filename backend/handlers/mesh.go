@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k-view/apierror"
+)
+
+// istioKinds maps the K-View kind of each supported Istio resource to its CRD.
+var istioKinds = []struct {
+	Kind string
+	CRD  string
+}{
+	{"virtual-services", "virtualservices.networking.istio.io"},
+	{"destination-rules", "destinationrules.networking.istio.io"},
+	{"istio-gateways", "gateways.networking.istio.io"},
+	{"peer-authentications", "peerauthentications.security.istio.io"},
+}
+
+type MeshKind struct {
+	Kind      string `json:"kind"`
+	CRD       string `json:"crd"`
+	Installed bool   `json:"installed"`
+}
+
+type MeshStatus struct {
+	Istio bool       `json:"istio"`
+	Kinds []MeshKind `json:"kinds"`
+}
+
+// GetMeshStatus detects which service-mesh CRDs are installed so the UI only shows
+// the mesh views and the mesh trace mode when they can return data.
+func (h *ResourceHandler) GetMeshStatus(c *gin.Context) {
+	status := MeshStatus{Kinds: []MeshKind{}}
+	if h.devMode {
+		for _, k := range istioKinds {
+			status.Kinds = append(status.Kinds, MeshKind{Kind: k.Kind, CRD: k.CRD, Installed: true})
+		}
+		status.Istio = true
+		c.JSON(http.StatusOK, status)
+		return
+	}
+
+	dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+	for _, k := range istioKinds {
+		_, err := dynClient.Resource(getGVR("crds")).Get(c.Request.Context(), k.CRD, metav1.GetOptions{})
+		status.Kinds = append(status.Kinds, MeshKind{Kind: k.Kind, CRD: k.CRD, Installed: err == nil})
+		if err == nil {
+			status.Istio = true
+		}
+	}
+	c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"k-view/apierror"
+	"k-view/auth"
+	"k-view/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const mfaDoc = "mfa"
+
+// mfaIssuer labels the account in authenticator apps.
+const mfaIssuer = "K-View"
+
+var errInvalidMFACode = errors.New("invalid MFA code")
+
+// TOTPEnrollment is a local user's authenticator. It is pending until the user proves the app
+// was set up by entering a code.
+type TOTPEnrollment struct {
+	Secret      string     `json:"secret"`
+	Confirmed   bool       `json:"confirmed"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	ConfirmedAt *time.Time `json:"confirmedAt,omitempty"`
+	LastStep    int64      `json:"lastStep,omitempty"` // Time step of the last accepted code, to stop replays
+}
+
+// MFAHandler manages TOTP second factors for local users. OIDC users get MFA from their IdP.
+type MFAHandler struct {
+	mu          sync.RWMutex
+	enrollments map[string]TOTPEnrollment // Cached copy of the store document, keyed by username
+	store       *store.Store
+	auth        *AuthHandler
+}
+
+func NewMFAHandler(st *store.Store, authHandler *AuthHandler) *MFAHandler {
+	h := &MFAHandler{store: st, auth: authHandler, enrollments: map[string]TOTPEnrollment{}}
+	if err := st.Load(mfaDoc, &h.enrollments); err != nil {
+		log.Printf("Failed to load MFA enrollments: %v", err)
+	}
+	return h
+}
+
+func (h *MFAHandler) Document() string { return mfaDoc }
+
+// Reload refreshes the cached enrollments from the store.
+func (h *MFAHandler) Reload() error {
+	enrollments := map[string]TOTPEnrollment{}
+	if err := h.store.Load(mfaDoc, &enrollments); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	h.enrollments = enrollments
+	h.mu.Unlock()
+	return nil
+}
+
+// Enabled reports whether a user has a confirmed authenticator.
+func (h *MFAHandler) Enabled(user string) bool {
+	if h == nil {
+		return false
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.enrollments[user].Confirmed
+}
+
+// update applies fn to the stored enrollments and refreshes the cache.
+func (h *MFAHandler) update(fn func(map[string]TOTPEnrollment) error) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	stored := map[string]TOTPEnrollment{}
+	err := h.store.Update(mfaDoc, &stored, func() error {
+		return fn(stored)
+	})
+	if err != nil {
+		return err
+	}
+	h.enrollments = stored
+	return nil
+}
+
+// Verify checks a code from a user's confirmed authenticator. A code is accepted only once.
+func (h *MFAHandler) Verify(user, code string) error {
+	return h.update(func(all map[string]TOTPEnrollment) error {
+		e, ok := all[user]
+		if !ok || !e.Confirmed {
+			return errors.New("MFA is not enabled")
+		}
+		step, valid := auth.ValidateTOTP(e.Secret, code, time.Now())
+		if !valid || step <= e.LastStep {
+			return errInvalidMFACode
+		}
+		e.LastStep = step
+		all[user] = e
+		return nil
+	})
+}
+
+// localUser returns the current user if they sign in with a local password.
+func (h *MFAHandler) localUser(c *gin.Context) (string, bool) {
+	user, ok := h.auth.LocalSession(c)
+	if !ok {
+		apierror.Write(c, http.StatusBadRequest, "MFA is only managed by K-View for local users; SSO users enroll with their identity provider")
+		return "", false
+	}
+	return user, true
+}
+
+// Status reports whether MFA is available to the current user and whether it is enabled.
+func (h *MFAHandler) Status(c *gin.Context) {
+	user, local := h.auth.LocalSession(c)
+	h.mu.RLock()
+	e, ok := h.enrollments[user]
+	h.mu.RUnlock()
+	if !local {
+		e, ok = TOTPEnrollment{}, false
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"available": local,
+		"enabled":   e.Confirmed,
+		"pending":   ok && !e.Confirmed,
+	})
+}
+
+// Setup starts enrollment with a new secret and returns it with the otpauth:// provisioning URI
+// to show as a QR code. Starting again replaces a pending secret.
+func (h *MFAHandler) Setup(c *gin.Context) {
+	user, ok := h.localUser(c)
+	if !ok {
+		return
+	}
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		apierror.Write(c, http.StatusInternalServerError, "Failed to generate secret")
+		return
+	}
+	err = h.update(func(all map[string]TOTPEnrollment) error {
+		if all[user].Confirmed {
+			return errors.New("MFA is already enabled; disable it before enrolling a new device")
+		}
+		all[user] = TOTPEnrollment{Secret: secret, CreatedAt: time.Now().UTC()}
+		return nil
+	})
+	if err != nil {
+		apierror.Write(c, http.StatusConflict, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"secret":     secret,
+		"otpauthUrl": auth.TOTPProvisioningURI(mfaIssuer, user, secret),
+	})
+}
+
+// Confirm enables MFA once the user enters a code from the newly set up authenticator.
+func (h *MFAHandler) Confirm(c *gin.Context) {
+	user, ok := h.localUser(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "code is required")
+		return
+	}
+	err := h.update(func(all map[string]TOTPEnrollment) error {
+		e, ok := all[user]
+		if !ok || e.Confirmed {
+			return errors.New("no pending MFA enrollment; start with setup")
+		}
+		step, valid := auth.ValidateTOTP(e.Secret, req.Code, time.Now())
+		if !valid {
+			return errInvalidMFACode
+		}
+		now := time.Now().UTC()
+		e.Confirmed, e.ConfirmedAt, e.LastStep = true, &now, step
+		all[user] = e
+		return nil
+	})
+	if err != nil {
+		apierror.Write(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	auditf("MFA enabled by %s", user)
+	c.JSON(http.StatusOK, gin.H{"enabled": true})
+}
+
+// Disable removes the current user's authenticator; it takes a valid code so a stolen session
+// cannot turn MFA off.
+func (h *MFAHandler) Disable(c *gin.Context) {
+	user, ok := h.localUser(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "code is required")
+		return
+	}
+	if err := h.Verify(user, req.Code); err != nil {
+		apierror.Write(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.remove(user); err != nil {
+		apierror.Fail(c, "Failed to disable MFA", err)
+		return
+	}
+	auditf("MFA disabled by %s", user)
+	c.JSON(http.StatusOK, gin.H{"enabled": false})
+}
+
+// Reset removes a user's authenticator, e.g. after a lost phone (admin only).
+func (h *MFAHandler) Reset(c *gin.Context) {
+	user := c.Param("username")
+	h.mu.RLock()
+	_, exists := h.enrollments[user]
+	h.mu.RUnlock()
+	if !exists {
+		apierror.Write(c, http.StatusNotFound, "user has no MFA enrollment")
+		return
+	}
+	if err := h.remove(user); err != nil {
+		apierror.Fail(c, "Failed to reset MFA", err)
+		return
+	}
+	admin, _ := c.Get("email")
+	auditf("MFA reset for %s by %v", user, admin)
+	c.JSON(http.StatusOK, gin.H{"message": "MFA reset for " + user})
+}
+
+func (h *MFAHandler) remove(user string) error {
+	return h.update(func(all map[string]TOTPEnrollment) error {
+		delete(all, user)
+		return nil
+	})
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"k-view/apierror"
+	"k-view/k8s"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ImageArchitectures reports which of the cluster's node architectures an image can run on.
+type ImageArchitectures struct {
+	Image     string   `json:"image"`
+	Platforms []string `json:"platforms"`            // As published by the registry, e.g. linux/arm64
+	Missing   []string `json:"missingArchitectures"` // Node architectures without a matching manifest
+	Workloads []string `json:"workloads"`            // namespace/name of the workloads using the image
+	// PinnedWorkloads constrain scheduling to supported architectures, so a missing manifest is harmless
+	PinnedWorkloads []string `json:"pinnedWorkloads"`
+	Status          string   `json:"status"` // OK, AtRisk, Unknown
+	Error           string   `json:"error,omitempty"`
+}
+
+type MultiArchReport struct {
+	NodeArchitectures map[string]int       `json:"nodeArchitectures"` // arch -> node count
+	Mixed             bool                 `json:"mixed"`
+	Images            []ImageArchitectures `json:"images"`
+}
+
+var errRegistryUnsupported = errors.New("image registry lookups are not supported by this client")
+
+// nodeArch reads the architecture label set by the kubelet, falling back to node info.
+func nodeArch(node corev1.Node) string {
+	if arch := node.Labels["kubernetes.io/arch"]; arch != "" {
+		return arch
+	}
+	return node.Status.NodeInfo.Architecture
+}
+
+// podWorkload names the controller of a pod, collapsing ReplicaSets into their Deployment.
+func podWorkload(pod corev1.Pod) string {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller == nil || !*ref.Controller {
+			continue
+		}
+		name := ref.Name
+		if ref.Kind == "ReplicaSet" {
+			if i := strings.LastIndex(name, "-"); i > 0 {
+				name = name[:i]
+			}
+		}
+		return pod.Namespace + "/" + name
+	}
+	return pod.Namespace + "/" + pod.Name
+}
+
+// pinnedArchitectures returns the architectures a pod is restricted to via nodeSelector or
+// required node affinity on kubernetes.io/arch, or nil if it can land on any node.
+func pinnedArchitectures(spec corev1.PodSpec) []string {
+	if arch := spec.NodeSelector["kubernetes.io/arch"]; arch != "" {
+		return []string{arch}
+	}
+	if spec.Affinity == nil || spec.Affinity.NodeAffinity == nil || spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return nil
+	}
+	var archs []string
+	for _, term := range spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		for _, req := range term.MatchExpressions {
+			if req.Key == "kubernetes.io/arch" && req.Operator == corev1.NodeSelectorOpIn {
+				for _, v := range req.Values {
+					archs = appendUnique(archs, v)
+				}
+			}
+		}
+	}
+	return archs
+}
+
+// GetMultiArchReport flags images that lack a manifest for one of the node architectures in
+// the cluster. On a mixed arm64/amd64 cluster such pods fail with ImagePullBackOff (or exec
+// format errors) as soon as they are scheduled onto the other architecture.
+func (h *ImageHandler) GetMultiArchReport(c *gin.Context) {
+	ns := c.Query("namespace")
+	if ns == "-" {
+		ns = ""
+	}
+	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
+		ns = rbacNs.(string)
+	}
+
+	ctx := c.Request.Context()
+	nodes, err := h.k8sClient.ListNodes(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to list nodes", err)
+		return
+	}
+	pods, err := h.k8sClient.ListPods(ctx, ns)
+	if err != nil {
+		apierror.Fail(c, "Failed to list pods", err)
+		return
+	}
+
+	report := MultiArchReport{NodeArchitectures: map[string]int{}, Images: []ImageArchitectures{}}
+	for _, n := range nodes {
+		if arch := nodeArch(n); arch != "" {
+			report.NodeArchitectures[arch]++
+		}
+	}
+	report.Mixed = len(report.NodeArchitectures) > 1
+
+	var clusterArchs []string
+	for arch := range report.NodeArchitectures {
+		clusterArchs = append(clusterArchs, arch)
+	}
+	sort.Strings(clusterArchs)
+
+	type usage struct {
+		workloads []string
+		pinned    map[string][]string
+	}
+	images := map[string]*usage{}
+	for _, p := range pods {
+		workload := podWorkload(p)
+		pinned := pinnedArchitectures(p.Spec)
+		for _, ctr := range podImages(p) {
+			u, ok := images[ctr.Image]
+			if !ok {
+				u = &usage{pinned: map[string][]string{}}
+				images[ctr.Image] = u
+			}
+			u.workloads = appendUnique(u.workloads, workload)
+			if pinned != nil {
+				u.pinned[workload] = pinned
+			}
+		}
+	}
+
+	resolver, _ := h.k8sClient.(k8s.ImagePlatformResolver)
+	for image, u := range images {
+		entry := ImageArchitectures{Image: image, Platforms: []string{}, Missing: []string{}, Workloads: u.workloads, PinnedWorkloads: []string{}, Status: "OK"}
+		sort.Strings(entry.Workloads)
+
+		var platforms []string
+		if resolver == nil {
+			err = errRegistryUnsupported
+		} else {
+			ref := parseImageRef(image)
+			reference := ref.Tag
+			if ref.Digest != "" {
+				reference = ref.Digest
+			}
+			platforms, err = resolver.ImagePlatforms(ctx, ref.Registry, ref.Repository, reference)
+		}
+		if err != nil {
+			entry.Status = "Unknown"
+			entry.Error = err.Error()
+			report.Images = append(report.Images, entry)
+			continue
+		}
+		entry.Platforms = platforms
+
+		supported := map[string]bool{}
+		for _, p := range platforms {
+			parts := strings.Split(p, "/")
+			if len(parts) >= 2 && parts[0] == "linux" {
+				supported[parts[1]] = true
+			}
+		}
+		for _, arch := range clusterArchs {
+			if !supported[arch] {
+				entry.Missing = append(entry.Missing, arch)
+			}
+		}
+		if len(entry.Missing) == 0 {
+			report.Images = append(report.Images, entry)
+			continue
+		}
+
+		// Workloads pinned to supported architectures cannot hit the missing manifest
+		atRisk := false
+		for _, w := range entry.Workloads {
+			pinned, ok := u.pinned[w]
+			safe := ok
+			for _, arch := range pinned {
+				if !supported[arch] {
+					safe = false
+				}
+			}
+			if safe {
+				entry.PinnedWorkloads = append(entry.PinnedWorkloads, w)
+			} else {
+				atRisk = true
+			}
+		}
+		if atRisk {
+			entry.Status = "AtRisk"
+		}
+		report.Images = append(report.Images, entry)
+	}
+
+	statusOrder := map[string]int{"AtRisk": 0, "Unknown": 1, "OK": 2}
+	sort.Slice(report.Images, func(i, j int) bool {
+		a, b := report.Images[i], report.Images[j]
+		if statusOrder[a.Status] != statusOrder[b.Status] {
+			return statusOrder[a.Status] < statusOrder[b.Status]
+		}
+		return a.Image < b.Image
+	})
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"k-view/apierror"
+	"k-view/k8s"
+
+	"github.com/gin-gonic/gin"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// maxRemainingNames is how many objects of each remaining resource are named.
+const maxRemainingNames = 10
+
+// NamespaceCondition is a condition the namespace controller reports while deleting.
+type NamespaceCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// RemainingResource is the objects of one API resource left in a namespace.
+type RemainingResource struct {
+	Group      string         `json:"group,omitempty"`
+	Version    string         `json:"version"`
+	Resource   string         `json:"resource"`
+	Kind       string         `json:"kind"`
+	Count      int            `json:"count"`
+	Names      []string       `json:"names"`                // The first ten
+	Finalizers map[string]int `json:"finalizers,omitempty"` // Objects holding each finalizer
+}
+
+// NamespaceDiagnosis pinpoints what keeps a namespace in Terminating: API groups the
+// namespace controller cannot discover, the controller's conditions, and the resources left
+// in it with the finalizers holding them.
+type NamespaceDiagnosis struct {
+	Namespace  string               `json:"namespace"`
+	Phase      string               `json:"phase"`
+	DeletedAt  *time.Time           `json:"deletedAt,omitempty"`
+	Finalizers []string             `json:"finalizers"` // spec.finalizers
+	Conditions []NamespaceCondition `json:"conditions"`
+	Remaining  []RemainingResource  `json:"remaining"`
+	// UnavailableGroups are group versions whose discovery failed, usually an aggregated API
+	// whose service is down; the namespace controller cannot delete their resources
+	UnavailableGroups map[string]string `json:"unavailableGroups,omitempty"`
+	Errors            map[string]string `json:"errors,omitempty"` // Resources that could not be listed
+	Findings          []string          `json:"findings"`
+}
+
+// namespacedResource is a listable namespaced API resource found through discovery.
+type namespacedResource struct {
+	gvr  schema.GroupVersionResource
+	kind string
+}
+
+// DiagnoseTerminatingNamespace explains why a namespace is stuck in Terminating by reading
+// its status conditions and listing every namespaced API resource left in it.
+func (h *DiagnosticsHandler) DiagnoseTerminatingNamespace(c *gin.Context) {
+	name := c.Param("name")
+	if rbacNs := rbacNamespace(c); rbacNs != "" && name != rbacNs {
+		apierror.Write(c, http.StatusForbidden, "access denied to namespace "+name)
+		return
+	}
+
+	if h.devMode {
+		diagnosis := mockNamespaceDiagnosis(name)
+		diagnosis.Findings = namespaceFindings(diagnosis)
+		c.JSON(http.StatusOK, diagnosis)
+		return
+	}
+
+	ctx := c.Request.Context()
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+	ns, err := dynClient.Resource(getGVR("namespaces")).Get(ctx, name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		apierror.Write(c, http.StatusNotFound, "namespace "+name+" not found; its deletion has completed")
+		return
+	}
+	if err != nil {
+		apierror.Fail(c, "Failed to get namespace", err)
+		return
+	}
+
+	diagnosis := NamespaceDiagnosis{Namespace: name, Conditions: []NamespaceCondition{}, Remaining: []RemainingResource{}}
+	diagnosis.Phase, _, _ = unstructured.NestedString(ns.Object, "status", "phase")
+	if deleted := ns.GetDeletionTimestamp(); deleted != nil {
+		diagnosis.DeletedAt = &deleted.Time
+	}
+	diagnosis.Finalizers, _, _ = unstructured.NestedStringSlice(ns.Object, "spec", "finalizers")
+	conditions, _, _ := unstructured.NestedSlice(ns.Object, "status", "conditions")
+	for _, raw := range conditions {
+		cond, _ := raw.(map[string]interface{})
+		nc := NamespaceCondition{}
+		nc.Type, _ = cond["type"].(string)
+		nc.Status, _ = cond["status"].(string)
+		nc.Reason, _ = cond["reason"].(string)
+		nc.Message, _ = cond["message"].(string)
+		diagnosis.Conditions = append(diagnosis.Conditions, nc)
+	}
+
+	info, ok := h.k8sClient.(k8s.ClusterInfoProvider)
+	if !ok {
+		apierror.Write(c, http.StatusNotImplemented, "API discovery is not available")
+		return
+	}
+	resources, unavailable, err := discoverNamespacedResources(ctx, info)
+	if err != nil {
+		apierror.Fail(c, "Failed to discover API resources", err)
+		return
+	}
+	if len(unavailable) > 0 {
+		diagnosis.UnavailableGroups = unavailable
+	}
+	for _, r := range resources {
+		list, err := dynClient.Resource(r.gvr).Namespace(name).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			if !k8serrors.IsNotFound(err) && !k8serrors.IsMethodNotSupported(err) {
+				if diagnosis.Errors == nil {
+					diagnosis.Errors = map[string]string{}
+				}
+				diagnosis.Errors[groupResource(r.gvr)] = err.Error()
+			}
+			continue
+		}
+		if len(list.Items) == 0 {
+			continue
+		}
+		remaining := RemainingResource{Group: r.gvr.Group, Version: r.gvr.Version, Resource: r.gvr.Resource, Kind: r.kind, Count: len(list.Items), Names: []string{}}
+		for _, item := range list.Items {
+			if len(remaining.Names) < maxRemainingNames {
+				remaining.Names = append(remaining.Names, item.GetName())
+			}
+			for _, f := range item.GetFinalizers() {
+				if remaining.Finalizers == nil {
+					remaining.Finalizers = map[string]int{}
+				}
+				remaining.Finalizers[f]++
+			}
+		}
+		diagnosis.Remaining = append(diagnosis.Remaining, remaining)
+	}
+	sort.Slice(diagnosis.Remaining, func(i, j int) bool {
+		return groupResource(diagnosis.Remaining[i].gvr()) < groupResource(diagnosis.Remaining[j].gvr())
+	})
+
+	diagnosis.Findings = namespaceFindings(diagnosis)
+	c.JSON(http.StatusOK, diagnosis)
+}
+
+func (r RemainingResource) gvr() schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: r.Group, Version: r.Version, Resource: r.Resource}
+}
+
+// groupResource names a resource as kubectl does: pods, or certificates.cert-manager.io.
+func groupResource(gvr schema.GroupVersionResource) string {
+	if gvr.Group == "" {
+		return gvr.Resource
+	}
+	return gvr.Resource + "." + gvr.Group
+}
+
+// discoverNamespacedResources returns the listable namespaced resources of the preferred
+// version of every API group, as the namespace controller deletes them, and the group
+// versions whose discovery failed.
+func discoverNamespacedResources(ctx context.Context, info k8s.ClusterInfoProvider) ([]namespacedResource, map[string]string, error) {
+	groupVersions := []string{"v1"}
+	body, err := info.GetRaw(ctx, "/apis")
+	if err != nil {
+		return nil, nil, err
+	}
+	var groups metav1.APIGroupList
+	if err := json.Unmarshal(body, &groups); err != nil {
+		return nil, nil, err
+	}
+	for _, g := range groups.Groups {
+		// Events are served by both the core and the events.k8s.io group
+		if g.Name != "events.k8s.io" {
+			groupVersions = append(groupVersions, g.PreferredVersion.GroupVersion)
+		}
+	}
+
+	var resources []namespacedResource
+	unavailable := map[string]string{}
+	for _, gv := range groupVersions {
+		path := "/apis/" + gv
+		if gv == "v1" {
+			path = "/api/v1"
+		}
+		body, err := info.GetRaw(ctx, path)
+		if err != nil {
+			unavailable[gv] = err.Error()
+			continue
+		}
+		var list metav1.APIResourceList
+		if err := json.Unmarshal(body, &list); err != nil {
+			unavailable[gv] = err.Error()
+			continue
+		}
+		parsed, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			unavailable[gv] = err.Error()
+			continue
+		}
+		for _, r := range list.APIResources {
+			if !r.Namespaced || strings.Contains(r.Name, "/") || !contains(r.Verbs, "list") {
+				continue
+			}
+			resources = append(resources, namespacedResource{gvr: parsed.WithResource(r.Name), kind: r.Kind})
+		}
+	}
+	return resources, unavailable, nil
+}
+
+// namespaceFindings summarises what blocks a namespace's deletion, most likely cause first.
+func namespaceFindings(d NamespaceDiagnosis) []string {
+	findings := []string{}
+	if d.DeletedAt == nil {
+		return append(findings, fmt.Sprintf("Namespace %s is %s, not being deleted", d.Namespace, d.Phase))
+	}
+
+	var gvs []string
+	for gv := range d.UnavailableGroups {
+		gvs = append(gvs, gv)
+	}
+	sort.Strings(gvs)
+	for _, gv := range gvs {
+		findings = append(findings, fmt.Sprintf("API %s is unavailable, so its resources cannot be deleted; fix or remove its APIService", gv))
+	}
+	for _, cond := range d.Conditions {
+		if cond.Status == "True" && cond.Message != "" && cond.Type != "NamespaceContentRemaining" && cond.Type != "NamespaceFinalizersRemaining" {
+			findings = append(findings, cond.Type+": "+cond.Message)
+		}
+	}
+	for _, r := range d.Remaining {
+		name := groupResource(r.gvr())
+		if len(r.Finalizers) == 0 {
+			findings = append(findings, fmt.Sprintf("%d %s left, still being deleted", r.Count, name))
+			continue
+		}
+		var finalizers []string
+		for f := range r.Finalizers {
+			finalizers = append(finalizers, f)
+		}
+		sort.Strings(finalizers)
+		for _, f := range finalizers {
+			findings = append(findings, fmt.Sprintf("%d %s held by finalizer %s; check that the controller removing it is running", r.Finalizers[f], name, f))
+		}
+	}
+	if len(d.Remaining) == 0 && len(d.UnavailableGroups) == 0 && len(d.Errors) == 0 && contains(d.Finalizers, "kubernetes") {
+		findings = append(findings, "Namespace is empty but still has the kubernetes finalizer; removing it completes the deletion")
+	}
+	if len(d.Errors) > 0 {
+		findings = append(findings, fmt.Sprintf("%d resources could not be listed with your permissions and may hold more objects", len(d.Errors)))
+	}
+	return findings
+}
+
+// mockNamespaceDiagnosis returns the diagnosis of the mock namespace stuck in Terminating for
+// DEV_MODE; other namespaces are active.
+func mockNamespaceDiagnosis(name string) NamespaceDiagnosis {
+	d := NamespaceDiagnosis{Namespace: name, Phase: "Active", Finalizers: []string{"kubernetes"}, Conditions: []NamespaceCondition{}, Remaining: []RemainingResource{}}
+	if name != "legacy-billing" {
+		return d
+	}
+	deleted := time.Now().UTC().Add(-72 * time.Hour)
+	d.Phase, d.DeletedAt = "Terminating", &deleted
+	d.Conditions = []NamespaceCondition{
+		{Type: "NamespaceDeletionDiscoveryFailure", Status: "True", Reason: "DiscoveryFailed", Message: "Discovery failed for some groups, 1 failing: unable to retrieve the complete list of server APIs: metrics.k8s.io/v1beta1: the server is currently unable to handle the request"},
+		{Type: "NamespaceDeletionContentFailure", Status: "False", Reason: "ContentDeleted", Message: "All content successfully deleted, may be waiting on finalization"},
+		{Type: "NamespaceContentRemaining", Status: "True", Reason: "SomeResourcesRemain", Message: "Some resources are remaining: certificates.cert-manager.io has 2 resource instances, persistentvolumeclaims. has 1 resource instances"},
+		{Type: "NamespaceFinalizersRemaining", Status: "True", Reason: "SomeFinalizersRemain", Message: "Some content in the namespace has finalizers remaining: finalizer.cert-manager.io in 2 resource instances, kubernetes.io/pvc-protection in 1 resource instances"},
+	}
+	d.UnavailableGroups = map[string]string{"metrics.k8s.io/v1beta1": "the server is currently unable to handle the request"}
+	d.Remaining = []RemainingResource{
+		{Group: "cert-manager.io", Version: "v1", Resource: "certificates", Kind: "Certificate", Count: 2, Names: []string{"billing-api-tls", "billing-web-tls"}, Finalizers: map[string]int{"finalizer.cert-manager.io": 2}},
+		{Version: "v1", Resource: "persistentvolumeclaims", Kind: "PersistentVolumeClaim", Count: 1, Names: []string{"data-billing-db-0"}, Finalizers: map[string]int{"kubernetes.io/pvc-protection": 1}},
+	}
+	return d
+}
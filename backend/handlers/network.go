@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"sort"
 
+	"k-view/apierror"
 	"k-view/k8s"
 
 	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
 )
 
 type NetworkHandler struct {
@@ -24,16 +28,275 @@ func (h *NetworkHandler) Trace(c *gin.Context) {
 	// Apply RBAC namespace restriction if needed (can be abstracted from resource handler)
 	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
 		if namespace != rbacNs.(string) {
-			c.JSON(http.StatusForbidden, gin.H{"error": "access denied to namespace " + namespace})
+			apierror.Write(c, http.StatusForbidden, "access denied to namespace "+namespace)
 			return
 		}
 	}
 
-	trace, err := k8s.TraceFlow(c.Request.Context(), h.k8sClient, resType, namespace, name)
+	mesh := c.Query("mesh") == "true"
+	trace, err := k8s.TraceFlow(c.Request.Context(), h.k8sClient, resType, namespace, name, mesh)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Fail(c, "", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, trace)
 }
+
+// EndpointAddress is one endpoint of an EndpointSlice with its readiness conditions.
+type EndpointAddress struct {
+	Addresses   []string `json:"addresses"`
+	Ready       bool     `json:"ready"`
+	Serving     bool     `json:"serving"`
+	Terminating bool     `json:"terminating"`
+	Pod         string   `json:"pod,omitempty"`
+	Node        string   `json:"node,omitempty"`
+	Zone        string   `json:"zone,omitempty"`
+	HintZones   []string `json:"hintZones,omitempty"`
+}
+
+type EndpointSliceInfo struct {
+	Name        string            `json:"name"`
+	AddressType string            `json:"addressType"`
+	Ports       []string          `json:"ports"`
+	Endpoints   []EndpointAddress `json:"endpoints"`
+}
+
+type ServiceEndpoints struct {
+	Service     string              `json:"service"`
+	Namespace   string              `json:"namespace"`
+	Ready       int                 `json:"ready"`
+	NotReady    int                 `json:"notReady"`
+	Terminating int                 `json:"terminating"`
+	Slices      []EndpointSliceInfo `json:"slices"`
+	Message     string              `json:"message,omitempty"`
+}
+
+// GetServiceEndpoints lists the EndpointSlices of a Service with a per-address readiness
+// breakdown, topology hints and terminating state.
+func (h *NetworkHandler) GetServiceEndpoints(c *gin.Context) {
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+
+	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
+		if namespace != rbacNs.(string) {
+			apierror.Write(c, http.StatusForbidden, "access denied to namespace "+namespace)
+			return
+		}
+	}
+
+	provider, ok := h.k8sClient.(k8s.EndpointSliceProvider)
+	if !ok {
+		apierror.Write(c, http.StatusNotImplemented, "EndpointSlices are not supported by this client")
+		return
+	}
+	slices, err := provider.ListEndpointSlices(c.Request.Context(), namespace, name)
+	if err != nil {
+		apierror.Fail(c, "Failed to list endpoint slices", err)
+		return
+	}
+
+	res := ServiceEndpoints{Service: name, Namespace: namespace, Slices: []EndpointSliceInfo{}}
+	for _, slice := range slices {
+		info := EndpointSliceInfo{Name: slice.Name, AddressType: string(slice.AddressType), Ports: []string{}, Endpoints: []EndpointAddress{}}
+		for _, p := range slice.Ports {
+			port := ""
+			if p.Name != nil && *p.Name != "" {
+				port = *p.Name + ":"
+			}
+			if p.Port != nil {
+				port += fmt.Sprintf("%d", *p.Port)
+			}
+			if p.Protocol != nil {
+				port += "/" + string(*p.Protocol)
+			}
+			info.Ports = append(info.Ports, port)
+		}
+		for _, ep := range slice.Endpoints {
+			// A nil condition means the state is unknown and should be treated as ready (KEP-1672)
+			addr := EndpointAddress{
+				Addresses:   ep.Addresses,
+				Ready:       ep.Conditions.Ready == nil || *ep.Conditions.Ready,
+				Serving:     ep.Conditions.Serving == nil || *ep.Conditions.Serving,
+				Terminating: ep.Conditions.Terminating != nil && *ep.Conditions.Terminating,
+			}
+			if ep.TargetRef != nil && ep.TargetRef.Kind == "Pod" {
+				addr.Pod = ep.TargetRef.Name
+			}
+			if ep.NodeName != nil {
+				addr.Node = *ep.NodeName
+			}
+			if ep.Zone != nil {
+				addr.Zone = *ep.Zone
+			}
+			if ep.Hints != nil {
+				for _, z := range ep.Hints.ForZones {
+					addr.HintZones = append(addr.HintZones, z.Name)
+				}
+			}
+
+			switch {
+			case addr.Terminating:
+				res.Terminating++
+			case addr.Ready:
+				res.Ready++
+			default:
+				res.NotReady++
+			}
+			info.Endpoints = append(info.Endpoints, addr)
+		}
+		res.Slices = append(res.Slices, info)
+	}
+
+	switch {
+	case len(res.Slices) == 0:
+		res.Message = "no EndpointSlices found; the service may have no selector or does not exist"
+	case res.Ready == 0 && res.NotReady+res.Terminating == 0:
+		res.Message = "service selector matches no pods"
+	case res.Ready == 0:
+		res.Message = "service has no ready endpoints; check pod readiness probes"
+	}
+
+	c.JSON(http.StatusOK, res)
+}
+
+// ExposedEndpoint is something reachable from outside the cluster.
+type ExposedEndpoint struct {
+	Kind      string   `json:"kind"` // Service, Ingress
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Type      string   `json:"type"` // NodePort, LoadBalancer, ExternalIP, Ingress
+	Addresses []string `json:"addresses"`
+	Ports     []string `json:"ports,omitempty"`
+	Hosts     []string `json:"hosts,omitempty"`
+	TLS       bool     `json:"tls"`
+	Backends  []string `json:"backends,omitempty"`
+}
+
+// nodeAddresses returns the external IPs of all nodes, falling back to internal IPs.
+func nodeAddresses(nodes []corev1.Node) []string {
+	var external, internal []string
+	for _, n := range nodes {
+		for _, a := range n.Status.Addresses {
+			switch a.Type {
+			case corev1.NodeExternalIP:
+				external = append(external, a.Address)
+			case corev1.NodeInternalIP:
+				internal = append(internal, a.Address)
+			}
+		}
+	}
+	if len(external) > 0 {
+		return external
+	}
+	return internal
+}
+
+// GetExposure lists every NodePort, LoadBalancer address, external IP and Ingress host,
+// i.e. everything reachable from outside the cluster.
+func (h *NetworkHandler) GetExposure(c *gin.Context) {
+	ns := ""
+	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
+		ns = rbacNs.(string)
+	}
+
+	lister, ok := h.k8sClient.(k8s.NetworkLister)
+	if !ok {
+		apierror.Write(c, http.StatusNotImplemented, "network listing is not supported by this client")
+		return
+	}
+	ctx := c.Request.Context()
+	services, err := lister.ListServices(ctx, ns)
+	if err != nil {
+		apierror.Fail(c, "Failed to list services", err)
+		return
+	}
+	ingresses, err := lister.ListIngresses(ctx, ns)
+	if err != nil {
+		apierror.Fail(c, "Failed to list ingresses", err)
+		return
+	}
+	var nodeIPs []string
+	if nodes, err := h.k8sClient.ListNodes(ctx); err == nil {
+		nodeIPs = nodeAddresses(nodes)
+	}
+
+	exposure := []ExposedEndpoint{}
+	for _, svc := range services {
+		if svc.Spec.Type == corev1.ServiceTypeNodePort || svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+			var ports []string
+			for _, p := range svc.Spec.Ports {
+				if p.NodePort != 0 {
+					ports = append(ports, fmt.Sprintf("%d/%s", p.NodePort, p.Protocol))
+				}
+			}
+			if len(ports) > 0 {
+				addrs := nodeIPs
+				if addrs == nil {
+					addrs = []string{}
+				}
+				exposure = append(exposure, ExposedEndpoint{Kind: "Service", Namespace: svc.Namespace, Name: svc.Name, Type: "NodePort", Addresses: addrs, Ports: ports})
+			}
+		}
+
+		var ports []string
+		for _, p := range svc.Spec.Ports {
+			ports = append(ports, fmt.Sprintf("%d/%s", p.Port, p.Protocol))
+		}
+		if svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+			addrs := []string{}
+			for _, lb := range svc.Status.LoadBalancer.Ingress {
+				if lb.IP != "" {
+					addrs = append(addrs, lb.IP)
+				}
+				if lb.Hostname != "" {
+					addrs = append(addrs, lb.Hostname)
+				}
+			}
+			exposure = append(exposure, ExposedEndpoint{Kind: "Service", Namespace: svc.Namespace, Name: svc.Name, Type: "LoadBalancer", Addresses: addrs, Ports: ports})
+		}
+		if len(svc.Spec.ExternalIPs) > 0 {
+			exposure = append(exposure, ExposedEndpoint{Kind: "Service", Namespace: svc.Namespace, Name: svc.Name, Type: "ExternalIP", Addresses: svc.Spec.ExternalIPs, Ports: ports})
+		}
+	}
+
+	for _, ing := range ingresses {
+		entry := ExposedEndpoint{Kind: "Ingress", Namespace: ing.Namespace, Name: ing.Name, Type: "Ingress", Addresses: []string{}, TLS: len(ing.Spec.TLS) > 0}
+		for _, lb := range ing.Status.LoadBalancer.Ingress {
+			if lb.IP != "" {
+				entry.Addresses = append(entry.Addresses, lb.IP)
+			}
+			if lb.Hostname != "" {
+				entry.Addresses = append(entry.Addresses, lb.Hostname)
+			}
+		}
+		if ing.Spec.DefaultBackend != nil && ing.Spec.DefaultBackend.Service != nil {
+			entry.Hosts = append(entry.Hosts, "*")
+			entry.Backends = append(entry.Backends, ing.Spec.DefaultBackend.Service.Name)
+		}
+		for _, rule := range ing.Spec.Rules {
+			host := rule.Host
+			if host == "" {
+				host = "*"
+			}
+			entry.Hosts = append(entry.Hosts, host)
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, path := range rule.HTTP.Paths {
+				if path.Backend.Service != nil {
+					entry.Backends = append(entry.Backends, host+path.Path+" -> "+path.Backend.Service.Name)
+				}
+			}
+		}
+		exposure = append(exposure, entry)
+	}
+
+	sort.SliceStable(exposure, func(i, j int) bool {
+		if exposure[i].Namespace != exposure[j].Namespace {
+			return exposure[i].Namespace < exposure[j].Namespace
+		}
+		return exposure[i].Name < exposure[j].Name
+	})
+	c.JSON(http.StatusOK, exposure)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"k-view/apierror"
+	"k-view/k8s"
+)
+
+var (
+	logListingHref = regexp.MustCompile(`href="([^"]+)"`)
+	// logQuerySource matches a systemd unit or a file name for NodeLogQuery
+	logQuerySource = regexp.MustCompile(`^[A-Za-z0-9._@:/-]+$`)
+)
+
+// tailLines keeps the last n lines of a log.
+func tailLines(data []byte, n int) []byte {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	if n > 0 && len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return bytes.Join(lines, nil)
+}
+
+// GetLogs reads node logs without SSH, through the kubelet /logs/ endpoint (admin only):
+//   - no parameters lists the files under /var/log
+//   - ?file=syslog (or a directory like journal/) returns that file, trimmed to ?tail= lines
+//   - ?query=kubelet uses NodeLogQuery (journal or service logs; Kubernetes 1.27+, feature gate)
+//     with optional ?tail=, ?since=1h, ?pattern= and ?boot=
+func (h *NodeHandler) GetLogs(c *gin.Context) {
+	node := c.Param("name")
+	reader, ok := h.k8sClient.(k8s.NodeLogReader)
+	if !ok {
+		apierror.Write(c, http.StatusNotImplemented, "node logs are not supported by this Kubernetes provider")
+		return
+	}
+	tail, _ := strconv.Atoi(c.DefaultQuery("tail", "500"))
+	file := strings.TrimPrefix(c.Query("file"), "/")
+	source := c.Query("query")
+	ctx := c.Request.Context()
+
+	switch {
+	case source != "":
+		if !logQuerySource.MatchString(source) || strings.Contains(source, "..") {
+			apierror.Write(c, http.StatusBadRequest, "query must be a service name or a file under /var/log")
+			return
+		}
+		query := url.Values{"query": {source}}
+		if tail > 0 {
+			query.Set("tailLines", strconv.Itoa(tail))
+		}
+		if since := c.Query("since"); since != "" {
+			d, err := time.ParseDuration(since)
+			if err != nil || d <= 0 {
+				apierror.Write(c, http.StatusBadRequest, "since must be a duration such as 30m or 2h")
+				return
+			}
+			query.Set("sinceTime", time.Now().Add(-d).UTC().Format(time.RFC3339))
+		}
+		if pattern := c.Query("pattern"); pattern != "" {
+			query.Set("pattern", pattern)
+		}
+		if boot := c.Query("boot"); boot != "" {
+			query.Set("boot", boot)
+		}
+		data, err := reader.NodeLogs(ctx, node, "", query)
+		if err != nil {
+			apierror.Write(c, http.StatusBadGateway, "Failed to get node logs: "+err.Error())
+			return
+		}
+		// Without the NodeLogQuery feature gate the kubelet ignores ?query= and returns the listing
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<pre>")) {
+			apierror.Write(c, http.StatusNotImplemented, "The kubelet on "+node+" does not support log queries "+
+				"(enable the NodeLogQuery feature gate and enableSystemLogQuery); use ?file= to read files under /var/log instead")
+			return
+		}
+		c.String(http.StatusOK, string(data))
+
+	case file != "":
+		for _, segment := range strings.Split(file, "/") {
+			if segment == ".." {
+				apierror.Write(c, http.StatusBadRequest, "file must be a path under /var/log")
+				return
+			}
+		}
+		data, err := reader.NodeLogs(ctx, node, file, nil)
+		if err != nil {
+			apierror.Write(c, http.StatusBadGateway, "Failed to get node logs: "+err.Error())
+			return
+		}
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<pre>")) {
+			c.JSON(http.StatusOK, gin.H{"node": node, "path": file, "files": logListing(data)})
+			return
+		}
+		c.String(http.StatusOK, string(tailLines(data, tail)))
+
+	default:
+		data, err := reader.NodeLogs(ctx, node, "", nil)
+		if err != nil {
+			apierror.Write(c, http.StatusBadGateway, "Failed to list node logs: "+err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"node": node, "path": "", "files": logListing(data)})
+	}
+}
+
+// logListing extracts the entries of a kubelet directory listing; directories end in "/".
+func logListing(data []byte) []string {
+	files := []string{}
+	for _, m := range logListingHref.FindAllSubmatch(data, -1) {
+		if name, err := url.PathUnescape(string(m[1])); err == nil {
+			files = append(files, name)
+		}
+	}
+	return files
+}
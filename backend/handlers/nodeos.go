@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"k-view/k8s"
+)
+
+// osLabel is the well-known node label with the node's operating system.
+const osLabel = "kubernetes.io/os"
+
+// nodeOS returns the operating system of a node, "linux" or "windows".
+func nodeOS(node corev1.Node) string {
+	if os := node.Labels[osLabel]; os != "" {
+		return os
+	}
+	if node.Status.NodeInfo.OperatingSystem != "" {
+		return node.Status.NodeInfo.OperatingSystem
+	}
+	return "linux"
+}
+
+// podOS returns the operating system a pod runs on: its spec.os, its kubernetes.io/os node
+// selector, or the OS of the node it is scheduled to, in that order. Pods default to linux.
+func podOS(ctx context.Context, client k8s.KubernetesProvider, pod corev1.Pod) string {
+	if pod.Spec.OS != nil && pod.Spec.OS.Name != "" {
+		return string(pod.Spec.OS.Name)
+	}
+	if os := pod.Spec.NodeSelector[osLabel]; os != "" {
+		return os
+	}
+	if pod.Spec.NodeName != "" {
+		// Users who may not list nodes get an error or nothing here; they are on linux then
+		nodes, _ := client.ListNodes(ctx)
+		for _, n := range nodes {
+			if n.Name == pod.Spec.NodeName {
+				return nodeOS(n)
+			}
+		}
+	}
+	return "linux"
+}
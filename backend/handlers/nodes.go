@@ -1,9 +1,9 @@
 package handlers
 
 import (
-	"context"
 	"net/http"
 
+	"k-view/apierror"
 	"k-view/k8s"
 
 	corev1 "k8s.io/api/core/v1"
@@ -26,11 +26,15 @@ type NodeResponse struct {
 	KubeletVersion   string            `json:"kubeletVersion"`
 	ContainerRuntime string            `json:"containerRuntime"`
 	OS               string            `json:"os"`
+	OperatingSystem  string            `json:"operatingSystem"` // linux or windows
 	Architecture     string            `json:"architecture"`
 	CPUCapacity      string            `json:"cpuCapacity"`
 	MemoryCapacity   string            `json:"memoryCapacity"`
 	CPUAllocatable   string            `json:"cpuAllocatable"`
 	MemoryAllocatable string           `json:"memoryAllocatable"`
+	// Extended resources such as nvidia.com/gpu or hugepages-2Mi
+	ExtendedCapacity    map[string]string `json:"extendedCapacity,omitempty"`
+	ExtendedAllocatable map[string]string `json:"extendedAllocatable,omitempty"`
 }
 
 func nodeRole(node corev1.Node) string {
@@ -56,9 +60,9 @@ func nodeStatus(node corev1.Node) string {
 }
 
 func (h *NodeHandler) ListNodes(c *gin.Context) {
-	nodes, err := h.k8sClient.ListNodes(context.Background())
+	nodes, err := h.k8sClient.ListNodes(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list nodes: " + err.Error()})
+		apierror.Fail(c, "Failed to list nodes", err)
 		return
 	}
 
@@ -77,11 +81,14 @@ func (h *NodeHandler) ListNodes(c *gin.Context) {
 			KubeletVersion:    n.Status.NodeInfo.KubeletVersion,
 			ContainerRuntime:  n.Status.NodeInfo.ContainerRuntimeVersion,
 			OS:                n.Status.NodeInfo.OSImage,
+			OperatingSystem:   nodeOS(n),
 			Architecture:      n.Status.NodeInfo.Architecture,
 			CPUCapacity:       cpu.String(),
 			MemoryCapacity:    mem.String(),
 			CPUAllocatable:    cpuAlloc.String(),
 			MemoryAllocatable: memAlloc.String(),
+			ExtendedCapacity:    extendedResources(n.Status.Capacity),
+			ExtendedAllocatable: extendedResources(n.Status.Allocatable),
 		})
 	}
 
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+
+	"k-view/apierror"
+	"k-view/k8s"
+)
+
+// nodeShellLifetime bounds how long a node shell pod can live, even if K-View never deletes it.
+const nodeShellLifetime = 2 * time.Hour
+
+// nodeShellCommand enters every namespace of the host's PID 1, giving a root shell on the node.
+var nodeShellCommand = []string{"nsenter", "-t", "1", "-m", "-u", "-i", "-n", "-p", "--",
+	"sh", "-c", "TERM=xterm-256color; export TERM; [ -x /bin/bash ] && exec /bin/bash || exec /bin/sh"}
+
+// nodeShellPod is the privileged debug pod pinned to a node.
+func nodeShellPod(node, namespace, image, user string) *corev1.Pod {
+	privileged := true
+	deadline := int64(nodeShellLifetime.Seconds())
+	return &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "kview-node-shell-",
+			Namespace:    namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "k-view",
+				"k-view.io/node-shell":         node,
+			},
+			Annotations: map[string]string{"k-view.io/requested-by": user},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:                      node,
+			HostPID:                       true,
+			HostNetwork:                   true,
+			HostIPC:                       true,
+			RestartPolicy:                 corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds:         &deadline,
+			TerminationGracePeriodSeconds: new(int64),
+			// Tolerate everything so tainted and cordoned nodes can be debugged too
+			Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			Containers: []corev1.Container{{
+				Name:            "shell",
+				Image:           image,
+				Command:         []string{"sleep", fmt.Sprint(deadline)},
+				SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+				Stdin:           true,
+				TTY:             true,
+			}},
+		},
+	}
+}
+
+// startNodeShellPod creates the debug pod and waits until it runs. It returns the pod name.
+func startNodeShellPod(ctx context.Context, pods dynamic.ResourceInterface, pod *corev1.Pod) (string, error) {
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+	if err != nil {
+		return "", err
+	}
+	created, err := pods.Create(ctx, &unstructured.Unstructured{Object: raw}, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to create debug pod: %v", err)
+	}
+	name := created.GetName()
+
+	timeout := time.After(90 * time.Second)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return name, ctx.Err()
+		case <-timeout:
+			return name, fmt.Errorf("debug pod %s did not start within 90s (image pull or admission problem?)", name)
+		case <-ticker.C:
+			obj, err := pods.Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				continue
+			}
+			phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+			switch phase {
+			case string(corev1.PodRunning):
+				return name, nil
+			case string(corev1.PodFailed), string(corev1.PodSucceeded):
+				return name, fmt.Errorf("debug pod %s ended with phase %s", name, phase)
+			}
+		}
+	}
+}
+
+// HandleNodeShell opens a root shell on a node (admin only, break-glass). A privileged pod with
+// the host PID, network and IPC namespaces is started on the node and the terminal runs nsenter
+// into PID 1. The pod is deleted when the terminal closes.
+func (h *ExecHandler) HandleNodeShell(c *gin.Context) {
+	node := c.Param("name")
+	email, _ := c.Get("email")
+
+	nodes, err := h.k8sClient.ListNodes(c.Request.Context())
+	if err != nil {
+		apierror.Fail(c, "Failed to list nodes", err)
+		return
+	}
+	var target *corev1.Node
+	for i := range nodes {
+		if nodes[i].Name == node {
+			target = &nodes[i]
+		}
+	}
+	if target == nil {
+		apierror.Write(c, http.StatusNotFound, "resource not found: node "+node)
+		return
+	}
+	// The debug pod enters the host namespaces with nsenter, which Windows does not have
+	if nodeOS(*target) == "windows" {
+		apierror.Write(c, http.StatusBadRequest, "node shells are not supported on Windows nodes; use a HostProcess pod instead")
+		return
+	}
+	executor, ok := h.k8sClient.(k8s.CommandExecutor)
+	if !ok {
+		apierror.Write(c, http.StatusNotImplemented, "node shells are not supported by this Kubernetes provider")
+		return
+	}
+	auditf("node shell on %s opened by %v", node, email)
+
+	session := &TerminalSession{Namespace: h.config.NodeShellNamespace, Pod: "node/" + node, Container: "shell"}
+	h.runTerminal(c, session, func(ctx context.Context, pty *wsPtyHandler) {
+		defer auditf("node shell on %s closed for %v", node, email)
+		if h.devMode {
+			_ = executor.ExecCommand(ctx, h.config.NodeShellNamespace, node, "shell", nodeShellCommand, pty)
+			return
+		}
+
+		pty.notice(fmt.Sprintf("\033[33mStarting privileged debug pod on %s...\033[0m\r\n", node))
+		dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+		if err != nil {
+			pty.notice("\r\n\033[31mFailed to get dynamic client: " + err.Error() + "\033[0m\r\n")
+			return
+		}
+		pods := dynClient.Resource(getGVR("pods")).Namespace(h.config.NodeShellNamespace)
+		user, _ := email.(string)
+		name, err := startNodeShellPod(ctx, pods, nodeShellPod(node, h.config.NodeShellNamespace, h.config.NodeShellImage, user))
+		if name != "" {
+			// The request context is gone by the time we clean up
+			defer func() {
+				if err := pods.Delete(context.Background(), name, metav1.DeleteOptions{}); err != nil {
+					log.Printf("Failed to delete node shell pod %s/%s: %v", h.config.NodeShellNamespace, name, err)
+				}
+			}()
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				pty.notice("\r\n\033[31m" + err.Error() + "\033[0m\r\n")
+			}
+			return
+		}
+
+		err = executor.ExecCommand(ctx, h.config.NodeShellNamespace, name, "shell", nodeShellCommand, pty)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Node shell error on %s: %v", node, err)
+			pty.notice("\r\n\033[31mTerminal Disconnected: " + err.Error() + "\033[0m\r\n")
+		}
+	})
+}
@@ -0,0 +1,468 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"k-view/apierror"
+	"k-view/k8s"
+	"k-view/store"
+)
+
+const webhooksDoc = "outbound-webhooks"
+
+var errWebhookNotFound = errors.New("webhook not found")
+
+// webhookEventTypes are the lifecycle changes a webhook can subscribe to.
+var webhookEventTypes = []string{"ADDED", "MODIFIED", "DELETED"}
+
+// OutboundWebhook delivers resource lifecycle changes to an external URL.
+type OutboundWebhook struct {
+	ID   string `json:"id"`
+	Name string `json:"name" binding:"required"`
+	URL  string `json:"url" binding:"required"`
+	// Kinds uses K-View kind names (deployments, pods, ...); at least one is required
+	Kinds      []string `json:"kinds" binding:"required"`
+	Namespaces []string `json:"namespaces,omitempty"` // Globs; empty matches all namespaces
+	EventTypes []string `json:"eventTypes,omitempty"` // ADDED, MODIFIED, DELETED; empty matches all
+	// Secret signs payloads (X-KView-Signature: sha256=<hmac>); it is never returned by the API
+	Secret    string    `json:"secret,omitempty"`
+	HasSecret bool      `json:"hasSecret"`
+	Enabled   bool      `json:"enabled"`
+	CreatedBy string    `json:"createdBy"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	LastDelivery *time.Time `json:"lastDelivery,omitempty"`
+	LastStatus   string     `json:"lastStatus,omitempty"`
+}
+
+func (w *OutboundWebhook) validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an http(s) URL")
+	}
+	if len(w.Kinds) == 0 {
+		return errors.New("at least one kind is required")
+	}
+	for i, kind := range w.Kinds {
+		w.Kinds[i] = strings.ToLower(kind)
+	}
+	for _, ns := range w.Namespaces {
+		if _, err := path.Match(ns, ""); err != nil {
+			return fmt.Errorf("invalid namespace pattern %q", ns)
+		}
+	}
+	for i, t := range w.EventTypes {
+		w.EventTypes[i] = strings.ToUpper(t)
+		if !contains(webhookEventTypes, w.EventTypes[i]) {
+			return fmt.Errorf("event type must be one of %s", strings.Join(webhookEventTypes, ", "))
+		}
+	}
+	return nil
+}
+
+func (w *OutboundWebhook) matches(kind, namespace, eventType string) bool {
+	if !w.Enabled || !contains(w.Kinds, kind) {
+		return false
+	}
+	if len(w.EventTypes) > 0 && !contains(w.EventTypes, eventType) {
+		return false
+	}
+	if len(w.Namespaces) == 0 {
+		return true
+	}
+	for _, pattern := range w.Namespaces {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookPayload is the JSON body POSTed to a webhook.
+type WebhookPayload struct {
+	Webhook   string                 `json:"webhook"`
+	Event     string                 `json:"event"` // ADDED, MODIFIED, DELETED or TEST
+	Kind      string                 `json:"kind"`
+	Namespace string                 `json:"namespace,omitempty"`
+	Name      string                 `json:"name"`
+	Timestamp time.Time              `json:"timestamp"`
+	Object    map[string]interface{} `json:"object,omitempty"`
+}
+
+// Notifier stores outbound webhooks and feeds them from watches on the kinds they subscribe to.
+// Deliveries are held back while a maintenance window covers the namespace.
+type Notifier struct {
+	devMode     bool
+	k8sClient   k8s.KubernetesProvider
+	store       *store.Store
+	maintenance *MaintenanceHandler
+	client      *http.Client
+
+	mu       sync.Mutex
+	watches  map[string]context.CancelFunc // Running watch per kind
+	statuses map[string]deliveryStatus     // Last delivery per webhook ID (not persisted)
+}
+
+type deliveryStatus struct {
+	at     time.Time
+	status string
+}
+
+func NewNotifier(devMode bool, client k8s.KubernetesProvider, st *store.Store, maintenance *MaintenanceHandler) *Notifier {
+	return &Notifier{
+		devMode:     devMode,
+		k8sClient:   client,
+		store:       st,
+		maintenance: maintenance,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		watches:     map[string]context.CancelFunc{},
+		statuses:    map[string]deliveryStatus{},
+	}
+}
+
+func (n *Notifier) webhooks() []OutboundWebhook {
+	var hooks []OutboundWebhook
+	if err := n.store.Load(webhooksDoc, &hooks); err != nil {
+		log.Printf("Webhooks: %v", err)
+	}
+	return hooks
+}
+
+// sign returns the HMAC-SHA256 of body, hex encoded.
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver POSTs the payload, retrying twice with backoff on network errors and 5xx answers.
+func (n *Notifier) deliver(ctx context.Context, hook OutboundWebhook, payload WebhookPayload) error {
+	payload.Webhook = hook.Name
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	var lastErr error
+	for attempt := 0; attempt < 3; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt*attempt) * 2 * time.Second):
+			}
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "k-view-webhooks")
+		req.Header.Set("X-KView-Event", payload.Event)
+		if hook.Secret != "" {
+			req.Header.Set("X-KView-Signature", "sha256="+sign(hook.Secret, body))
+		}
+		resp, err := n.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 300 {
+			lastErr = nil
+			break
+		}
+		lastErr = fmt.Errorf("HTTP %d", resp.StatusCode)
+		if resp.StatusCode < 500 {
+			break // The receiver rejected the payload; retrying will not help
+		}
+	}
+
+	status := "OK"
+	if lastErr != nil {
+		status = lastErr.Error()
+	}
+	n.mu.Lock()
+	n.statuses[hook.ID] = deliveryStatus{at: time.Now().UTC(), status: status}
+	n.mu.Unlock()
+	return lastErr
+}
+
+// dispatch sends a change to every matching webhook.
+func (n *Notifier) dispatch(ctx context.Context, kind, eventType string, obj *unstructured.Unstructured) {
+	ns := obj.GetNamespace()
+	if n.maintenance.Suppressed(ns) {
+		return
+	}
+	payload := WebhookPayload{Event: eventType, Kind: kind, Namespace: ns, Name: obj.GetName(), Timestamp: time.Now().UTC(), Object: obj.Object}
+	if kind == "secrets" {
+		// Never ship secret values to third parties. Annotations are left out too: the
+		// last-applied-configuration of a kubectl-applied Secret holds its data.
+		metadata := map[string]interface{}{"name": obj.GetName(), "namespace": ns}
+		if labels := obj.GetLabels(); len(labels) > 0 {
+			metadata["labels"] = labels
+		}
+		payload.Object = map[string]interface{}{"metadata": metadata, "type": obj.Object["type"]}
+	}
+	for _, hook := range n.webhooks() {
+		if hook.matches(kind, ns, eventType) {
+			go func(hook OutboundWebhook) {
+				if err := n.deliver(ctx, hook, payload); err != nil {
+					log.Printf("Webhook %q: delivery of %s %s %s/%s failed: %v", hook.Name, eventType, kind, ns, payload.Name, err)
+				}
+			}(hook)
+		}
+	}
+}
+
+// watchKind watches one kind with K-View's service account until ctx ends, re-listing when the
+// watch expires. Objects that exist when the watch starts are not reported as ADDED.
+func (n *Notifier) watchKind(ctx context.Context, kind string) {
+	for {
+		if err := n.watchKindOnce(ctx, kind); err != nil {
+			log.Printf("Webhooks: watch on %s: %v", kind, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (n *Notifier) watchKindOnce(ctx context.Context, kind string) error {
+	dynClient, err := n.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		return err
+	}
+	resource := dynClient.Resource(getGVR(kind))
+	list, err := resource.List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return err
+	}
+	w, err := resource.Watch(ctx, metav1.ListOptions{ResourceVersion: list.GetResourceVersion()})
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				return nil
+			}
+			if ev.Type == watch.Error {
+				return fmt.Errorf("watch expired")
+			}
+			if obj, ok := ev.Object.(*unstructured.Unstructured); ok {
+				n.dispatch(ctx, kind, string(ev.Type), obj)
+			}
+		}
+	}
+}
+
+// reconcile starts watches for newly subscribed kinds and stops those no webhook needs.
+func (n *Notifier) reconcile(ctx context.Context) {
+	wanted := map[string]bool{}
+	for _, hook := range n.webhooks() {
+		if hook.Enabled {
+			for _, kind := range hook.Kinds {
+				wanted[kind] = true
+			}
+		}
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for kind, cancel := range n.watches {
+		if !wanted[kind] {
+			cancel()
+			delete(n.watches, kind)
+		}
+	}
+	for kind := range wanted {
+		if _, running := n.watches[kind]; !running {
+			watchCtx, cancel := context.WithCancel(ctx)
+			n.watches[kind] = cancel
+			go n.watchKind(watchCtx, kind)
+		}
+	}
+}
+
+// RunWatcher keeps the watches in line with the configured webhooks. Changes to the webhook
+// list are picked up within 30 seconds.
+func (n *Notifier) RunWatcher(ctx context.Context) {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+	for {
+		n.reconcile(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// present hides the secret and adds the last delivery status.
+func (n *Notifier) present(hook OutboundWebhook) OutboundWebhook {
+	hook.HasSecret = hook.Secret != ""
+	hook.Secret = ""
+	n.mu.Lock()
+	if s, ok := n.statuses[hook.ID]; ok {
+		at := s.at
+		hook.LastDelivery, hook.LastStatus = &at, s.status
+	}
+	n.mu.Unlock()
+	return hook
+}
+
+// List returns the configured webhooks (admin only).
+func (n *Notifier) List(c *gin.Context) {
+	result := []OutboundWebhook{}
+	for _, hook := range n.webhooks() {
+		result = append(result, n.present(hook))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	c.JSON(http.StatusOK, result)
+}
+
+// Create adds a webhook (admin only). New webhooks are enabled unless "enabled": false is sent.
+func (n *Notifier) Create(c *gin.Context) {
+	hook := OutboundWebhook{Enabled: true}
+	if err := c.ShouldBindJSON(&hook); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "name, url and kinds are required")
+		return
+	}
+	if err := hook.validate(); err != nil {
+		apierror.Write(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	email, _ := c.Get("email")
+	hook.ID = newID()
+	hook.CreatedBy, _ = email.(string)
+	hook.CreatedAt = time.Now().UTC()
+	hook.LastDelivery, hook.LastStatus = nil, ""
+
+	var hooks []OutboundWebhook
+	if err := n.store.Update(webhooksDoc, &hooks, func() error {
+		hooks = append(hooks, hook)
+		return nil
+	}); err != nil {
+		apierror.Fail(c, "Failed to save webhook", err)
+		return
+	}
+	c.JSON(http.StatusCreated, n.present(hook))
+}
+
+// Update replaces a webhook's settings (admin only). An empty secret keeps the current one,
+// as does a missing "enabled".
+func (n *Notifier) Update(c *gin.Context) {
+	id := c.Param("id")
+	var req struct {
+		OutboundWebhook
+		Enabled *bool `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "name, url and kinds are required")
+		return
+	}
+	input := req.OutboundWebhook
+	if err := input.validate(); err != nil {
+		apierror.Write(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	var hooks []OutboundWebhook
+	var updated OutboundWebhook
+	err := n.store.Update(webhooksDoc, &hooks, func() error {
+		for i := range hooks {
+			if hooks[i].ID != id {
+				continue
+			}
+			input.ID, input.CreatedBy, input.CreatedAt = hooks[i].ID, hooks[i].CreatedBy, hooks[i].CreatedAt
+			input.LastDelivery, input.LastStatus = nil, ""
+			if input.Secret == "" {
+				input.Secret = hooks[i].Secret
+			}
+			input.Enabled = hooks[i].Enabled
+			if req.Enabled != nil {
+				input.Enabled = *req.Enabled
+			}
+			hooks[i] = input
+			updated = input
+			return nil
+		}
+		return errWebhookNotFound
+	})
+	if err == errWebhookNotFound {
+		apierror.Write(c, http.StatusNotFound, "webhook "+id+" not found")
+		return
+	}
+	if err != nil {
+		apierror.Fail(c, "Failed to save webhook", err)
+		return
+	}
+	c.JSON(http.StatusOK, n.present(updated))
+}
+
+// Delete removes a webhook (admin only).
+func (n *Notifier) Delete(c *gin.Context) {
+	id := c.Param("id")
+	var hooks []OutboundWebhook
+	err := n.store.Update(webhooksDoc, &hooks, func() error {
+		for i := range hooks {
+			if hooks[i].ID == id {
+				hooks = append(hooks[:i], hooks[i+1:]...)
+				return nil
+			}
+		}
+		return errWebhookNotFound
+	})
+	if err == errWebhookNotFound {
+		apierror.Write(c, http.StatusNotFound, "webhook "+id+" not found")
+		return
+	}
+	if err != nil {
+		apierror.Fail(c, "Failed to save webhook", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted"})
+}
+
+// Test sends a TEST payload to a webhook right away and reports the outcome (admin only).
+func (n *Notifier) Test(c *gin.Context) {
+	id := c.Param("id")
+	for _, hook := range n.webhooks() {
+		if hook.ID != id {
+			continue
+		}
+		payload := WebhookPayload{Event: "TEST", Kind: "webhooks", Name: hook.Name, Timestamp: time.Now().UTC()}
+		if err := n.deliver(c.Request.Context(), hook, payload); err != nil {
+			apierror.Write(c, http.StatusBadGateway, "Delivery failed: "+err.Error())
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Test payload delivered"})
+		return
+	}
+	apierror.Write(c, http.StatusNotFound, "webhook "+id+" not found")
+}
@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"k-view/apierror"
+)
+
+// OperatorSubscription is an OLM Subscription joined with the phase of its installed CSV and any
+// InstallPlan waiting for manual approval.
+type OperatorSubscription struct {
+	Name         string `json:"name"`
+	Namespace    string `json:"namespace"`
+	Package      string `json:"package"`
+	Channel      string `json:"channel"`
+	Source       string `json:"source"`
+	Approval     string `json:"approval"` // Automatic or Manual
+	State        string `json:"state"`    // AtLatestKnown, UpgradePending, UpgradeAvailable, ...
+	InstalledCSV string `json:"installedCSV,omitempty"`
+	CurrentCSV   string `json:"currentCSV,omitempty"` // Latest CSV in the channel
+	CSVPhase     string `json:"csvPhase,omitempty"`
+	PendingPlan  string `json:"pendingPlan,omitempty"` // InstallPlan awaiting approval
+	Age          string `json:"age"`
+}
+
+// OperatorCSV is a ClusterServiceVersion: one installed version of an operator.
+type OperatorCSV struct {
+	Name        string `json:"name"`
+	Namespace   string `json:"namespace"`
+	DisplayName string `json:"displayName"`
+	Version     string `json:"version"`
+	Replaces    string `json:"replaces,omitempty"`
+	Phase       string `json:"phase"`
+	Reason      string `json:"reason,omitempty"`
+	Message     string `json:"message,omitempty"`
+	Age         string `json:"age"`
+}
+
+// InstallPlan is an OLM InstallPlan, the set of resources OLM will create for an install or upgrade.
+type InstallPlan struct {
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace"`
+	CSVs      []string `json:"csvs"`
+	Approval  string   `json:"approval"`
+	Approved  bool     `json:"approved"`
+	Phase     string   `json:"phase"`
+	// Pending is true while the plan waits for a manual approval
+	Pending bool   `json:"pending"`
+	Age     string `json:"age"`
+}
+
+func operatorCSVFromObject(item unstructured.Unstructured) OperatorCSV {
+	csv := OperatorCSV{Name: item.GetName(), Namespace: item.GetNamespace(), Age: getAge(item.GetCreationTimestamp().Time)}
+	csv.DisplayName, _, _ = unstructured.NestedString(item.Object, "spec", "displayName")
+	csv.Version, _, _ = unstructured.NestedString(item.Object, "spec", "version")
+	csv.Replaces, _, _ = unstructured.NestedString(item.Object, "spec", "replaces")
+	csv.Phase, _, _ = unstructured.NestedString(item.Object, "status", "phase")
+	csv.Reason, _, _ = unstructured.NestedString(item.Object, "status", "reason")
+	csv.Message, _, _ = unstructured.NestedString(item.Object, "status", "message")
+	return csv
+}
+
+func installPlanFromObject(item unstructured.Unstructured) InstallPlan {
+	plan := InstallPlan{Name: item.GetName(), Namespace: item.GetNamespace(), Age: getAge(item.GetCreationTimestamp().Time)}
+	plan.CSVs, _, _ = unstructured.NestedStringSlice(item.Object, "spec", "clusterServiceVersionNames")
+	if plan.CSVs == nil {
+		plan.CSVs = []string{}
+	}
+	plan.Approval, _, _ = unstructured.NestedString(item.Object, "spec", "approval")
+	plan.Approved, _, _ = unstructured.NestedBool(item.Object, "spec", "approved")
+	plan.Phase, _, _ = unstructured.NestedString(item.Object, "status", "phase")
+	plan.Pending = plan.Phase == "RequiresApproval" && !plan.Approved
+	return plan
+}
+
+// listOLM lists an OLM kind in ns (all namespaces when empty).
+func listOLM(ctx context.Context, dynClient dynamic.Interface, kind, ns string) (*unstructured.UnstructuredList, error) {
+	return dynClient.Resource(getGVR(kind)).Namespace(ns).List(ctx, metav1.ListOptions{})
+}
+
+// GetOperators lists OLM Subscriptions with the state of their CSVs and pending InstallPlans,
+// or reports installed=false on clusters without OLM.
+func (h *ResourceHandler) GetOperators(c *gin.Context) {
+	ns := rbacNamespace(c)
+	var subs []OperatorSubscription
+	if h.devMode {
+		subs = mockSubscriptions()
+	} else {
+		ctx := c.Request.Context()
+		dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+		if err != nil {
+			apierror.Fail(c, "Failed to get dynamic client", err)
+			return
+		}
+		if _, err := dynClient.Resource(getGVR("crds")).Get(ctx, "subscriptions.operators.coreos.com", metav1.GetOptions{}); err != nil {
+			c.JSON(http.StatusOK, gin.H{"installed": false, "subscriptions": []OperatorSubscription{}})
+			return
+		}
+		list, err := listOLM(ctx, dynClient, "subscriptions", ns)
+		if err != nil {
+			apierror.Fail(c, "Failed to list subscriptions", err)
+			return
+		}
+		csvs, err := listOLM(ctx, dynClient, "cluster-service-versions", ns)
+		if err != nil {
+			apierror.Fail(c, "Failed to list cluster service versions", err)
+			return
+		}
+		phases := map[string]string{}
+		for _, item := range csvs.Items {
+			phases[item.GetNamespace()+"/"+item.GetName()], _, _ = unstructured.NestedString(item.Object, "status", "phase")
+		}
+		plans, err := listOLM(ctx, dynClient, "install-plans", ns)
+		if err != nil {
+			apierror.Fail(c, "Failed to list install plans", err)
+			return
+		}
+		pending := map[string]bool{}
+		for _, item := range plans.Items {
+			if installPlanFromObject(item).Pending {
+				pending[item.GetNamespace()+"/"+item.GetName()] = true
+			}
+		}
+
+		for _, item := range list.Items {
+			sub := OperatorSubscription{Name: item.GetName(), Namespace: item.GetNamespace(), Age: getAge(item.GetCreationTimestamp().Time)}
+			str := func(fields ...string) string {
+				v, _, _ := unstructured.NestedString(item.Object, fields...)
+				return v
+			}
+			sub.Package, sub.Channel, sub.Source = str("spec", "name"), str("spec", "channel"), str("spec", "source")
+			sub.Approval = str("spec", "installPlanApproval")
+			if sub.Approval == "" {
+				sub.Approval = "Automatic"
+			}
+			sub.State, sub.InstalledCSV, sub.CurrentCSV = str("status", "state"), str("status", "installedCSV"), str("status", "currentCSV")
+			sub.CSVPhase = phases[sub.Namespace+"/"+sub.InstalledCSV]
+			if plan := str("status", "installPlanRef", "name"); pending[sub.Namespace+"/"+plan] {
+				sub.PendingPlan = plan
+			}
+			subs = append(subs, sub)
+		}
+	}
+
+	upgrades, approvals := 0, 0
+	result := []OperatorSubscription{}
+	for _, sub := range subs {
+		if ns != "" && sub.Namespace != ns {
+			continue
+		}
+		if sub.CurrentCSV != "" && sub.CurrentCSV != sub.InstalledCSV {
+			upgrades++
+		}
+		if sub.PendingPlan != "" {
+			approvals++
+		}
+		result = append(result, sub)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Namespace+"/"+result[i].Name < result[j].Namespace+"/"+result[j].Name
+	})
+	c.JSON(http.StatusOK, gin.H{"installed": true, "subscriptions": result, "upgradesAvailable": upgrades, "pendingApprovals": approvals})
+}
+
+// ListCSVs lists ClusterServiceVersions. CSVs of operators watching all namespaces are copied
+// into every namespace; the copies are hidden unless ?copied=true.
+func (h *ResourceHandler) ListCSVs(c *gin.Context) {
+	ns := c.Query("namespace")
+	if rbacNs := rbacNamespace(c); rbacNs != "" {
+		ns = rbacNs
+	}
+	var csvs []OperatorCSV
+	if h.devMode {
+		csvs = mockCSVs()
+	} else {
+		dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
+		if err != nil {
+			apierror.Fail(c, "Failed to get dynamic client", err)
+			return
+		}
+		list, err := listOLM(c.Request.Context(), dynClient, "cluster-service-versions", ns)
+		if err != nil {
+			apierror.Fail(c, "Failed to list cluster service versions", err)
+			return
+		}
+		for _, item := range list.Items {
+			csvs = append(csvs, operatorCSVFromObject(item))
+		}
+	}
+
+	result := []OperatorCSV{}
+	for _, csv := range csvs {
+		if (ns != "" && csv.Namespace != ns) || (csv.Reason == "Copied" && c.Query("copied") != "true") {
+			continue
+		}
+		result = append(result, csv)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Namespace+"/"+result[i].Name < result[j].Namespace+"/"+result[j].Name
+	})
+	c.JSON(http.StatusOK, result)
+}
+
+// ListInstallPlans lists InstallPlans, pending approvals first. ?pending=true keeps only those.
+func (h *ResourceHandler) ListInstallPlans(c *gin.Context) {
+	ns := c.Query("namespace")
+	if rbacNs := rbacNamespace(c); rbacNs != "" {
+		ns = rbacNs
+	}
+	var plans []InstallPlan
+	if h.devMode {
+		plans = mockInstallPlans()
+	} else {
+		dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
+		if err != nil {
+			apierror.Fail(c, "Failed to get dynamic client", err)
+			return
+		}
+		list, err := listOLM(c.Request.Context(), dynClient, "install-plans", ns)
+		if err != nil {
+			apierror.Fail(c, "Failed to list install plans", err)
+			return
+		}
+		for _, item := range list.Items {
+			plans = append(plans, installPlanFromObject(item))
+		}
+	}
+
+	result := []InstallPlan{}
+	for _, plan := range plans {
+		if (ns != "" && plan.Namespace != ns) || (c.Query("pending") == "true" && !plan.Pending) {
+			continue
+		}
+		result = append(result, plan)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Pending != result[j].Pending {
+			return result[i].Pending
+		}
+		return result[i].Namespace+"/"+result[i].Name < result[j].Namespace+"/"+result[j].Name
+	})
+	c.JSON(http.StatusOK, result)
+}
+
+// ApproveInstallPlan approves an InstallPlan that waits for manual approval, letting OLM install
+// or upgrade the operator (admin only: operators usually bring cluster-wide CRDs and RBAC).
+func (h *ResourceHandler) ApproveInstallPlan(c *gin.Context) {
+	ns, name := c.Param("namespace"), c.Param("name")
+	if h.rejectProtected(c, "install-plans", ns, name) {
+		return
+	}
+	email, _ := c.Get("email")
+	if h.devMode {
+		auditf("%v approved InstallPlan %s/%s (mocked)", email, ns, name)
+		c.JSON(http.StatusOK, gin.H{"message": "InstallPlan " + name + " approved (mocked)"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+	plans := dynClient.Resource(getGVR("install-plans")).Namespace(ns)
+	item, err := plans.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		apierror.Fail(c, "Failed to get resource", err)
+		return
+	}
+	plan := installPlanFromObject(*item)
+	if plan.Approved {
+		apierror.Write(c, http.StatusConflict, "InstallPlan "+name+" is already approved")
+		return
+	}
+	if !plan.Pending {
+		apierror.Write(c, http.StatusConflict, "InstallPlan "+name+" is not waiting for approval (phase "+plan.Phase+")")
+		return
+	}
+	patch := []byte(`{"spec":{"approved":true}}`)
+	if _, err := plans.Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		apierror.Fail(c, "Failed to approve install plan", err)
+		return
+	}
+	auditf("%v approved InstallPlan %s/%s (%v)", email, ns, name, plan.CSVs)
+	c.JSON(http.StatusOK, gin.H{"message": "InstallPlan " + name + " approved"})
+}
+
+func mockSubscriptions() []OperatorSubscription {
+	return []OperatorSubscription{
+		{Name: "cert-manager", Namespace: "operators", Package: "cert-manager", Channel: "stable", Source: "operatorhubio-catalog", Approval: "Automatic",
+			State: "AtLatestKnown", InstalledCSV: "cert-manager.v1.14.4", CurrentCSV: "cert-manager.v1.14.4", CSVPhase: "Succeeded", Age: "60d"},
+		{Name: "prometheus", Namespace: "operators", Package: "prometheus", Channel: "beta", Source: "operatorhubio-catalog", Approval: "Manual",
+			State: "UpgradePending", InstalledCSV: "prometheusoperator.0.70.0", CurrentCSV: "prometheusoperator.0.72.0", CSVPhase: "Succeeded", PendingPlan: "install-7x2kq", Age: "45d"},
+		{Name: "postgresql", Namespace: "database", Package: "cloudnative-pg", Channel: "stable-v1", Source: "operatorhubio-catalog", Approval: "Automatic",
+			State: "AtLatestKnown", InstalledCSV: "cloudnative-pg.v1.22.1", CurrentCSV: "cloudnative-pg.v1.22.1", CSVPhase: "Failed", Age: "20d"},
+	}
+}
+
+func mockCSVs() []OperatorCSV {
+	return []OperatorCSV{
+		{Name: "cert-manager.v1.14.4", Namespace: "operators", DisplayName: "cert-manager", Version: "1.14.4", Replaces: "cert-manager.v1.14.3", Phase: "Succeeded", Reason: "InstallSucceeded", Message: "install strategy completed with no errors", Age: "12d"},
+		{Name: "cert-manager.v1.14.4", Namespace: "default", DisplayName: "cert-manager", Version: "1.14.4", Phase: "Succeeded", Reason: "Copied", Message: "The operator is running in operators but is managing this namespace", Age: "12d"},
+		{Name: "prometheusoperator.0.70.0", Namespace: "operators", DisplayName: "Prometheus Operator", Version: "0.70.0", Phase: "Succeeded", Reason: "InstallSucceeded", Message: "install strategy completed with no errors", Age: "45d"},
+		{Name: "cloudnative-pg.v1.22.1", Namespace: "database", DisplayName: "CloudNativePG", Version: "1.22.1", Replaces: "cloudnative-pg.v1.22.0", Phase: "Failed", Reason: "InstallCheckFailed",
+			Message: "install timeout: deployment cnpg-controller-manager not ready before timeout", Age: "3d"},
+	}
+}
+
+func mockInstallPlans() []InstallPlan {
+	return []InstallPlan{
+		{Name: "install-7x2kq", Namespace: "operators", CSVs: []string{"prometheusoperator.0.72.0"}, Approval: "Manual", Phase: "RequiresApproval", Pending: true, Age: "2d"},
+		{Name: "install-4hd9m", Namespace: "operators", CSVs: []string{"prometheusoperator.0.70.0"}, Approval: "Manual", Approved: true, Phase: "Complete", Age: "45d"},
+		{Name: "install-b8w2t", Namespace: "operators", CSVs: []string{"cert-manager.v1.14.4"}, Approval: "Automatic", Approved: true, Phase: "Complete", Age: "12d"},
+		{Name: "install-p5lcz", Namespace: "database", CSVs: []string{"cloudnative-pg.v1.22.1"}, Approval: "Automatic", Approved: true, Phase: "Complete", Age: "3d"},
+	}
+}
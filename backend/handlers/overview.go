@@ -0,0 +1,406 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"k-view/apierror"
+)
+
+// overviewEvents and overviewConsumers cap the lists of the namespace overview.
+const (
+	overviewEvents    = 20
+	overviewConsumers = 5
+)
+
+// WorkloadSummary is a deployment, statefulset or daemonset and how many of its pods are ready.
+type WorkloadSummary struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Ready  string `json:"ready"`  // "2/3"
+	Status string `json:"status"` // Healthy, Degraded or Scaled down
+}
+
+// PodHealth counts the pods of a namespace by state.
+type PodHealth struct {
+	Total     int            `json:"total"`
+	Running   int            `json:"running"`
+	Pending   int            `json:"pending"`
+	Failed    int            `json:"failed"`
+	Succeeded int            `json:"succeeded"`
+	Restarts  int32          `json:"restarts"`
+	Unhealthy []UnhealthyPod `json:"unhealthy"`
+}
+
+// UnhealthyPod is a pod that is neither running with all containers ready nor completed.
+type UnhealthyPod struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Restarts int32  `json:"restarts"`
+}
+
+// QuotaUsage is one resource of a ResourceQuota.
+type QuotaUsage struct {
+	Quota    string  `json:"quota"`
+	Resource string  `json:"resource"`
+	Used     string  `json:"used"`
+	Hard     string  `json:"hard"`
+	Percent  float64 `json:"percent"`
+}
+
+// PodConsumer is a pod's CPU and memory, measured by metrics-server or, without it, requested.
+type PodConsumer struct {
+	Pod         string `json:"pod"`
+	CPUMillis   int64  `json:"cpuMillis"`
+	MemoryBytes int64  `json:"memoryBytes"`
+}
+
+// NamespaceOverview is everything the namespace summary page shows.
+type NamespaceOverview struct {
+	Namespace    string            `json:"namespace"`
+	Workloads    []WorkloadSummary `json:"workloads"`
+	Pods         PodHealth         `json:"pods"`
+	Events       []EventRecord     `json:"events"`
+	Quotas       []QuotaUsage      `json:"quotas"`
+	TopConsumers []PodConsumer     `json:"topConsumers"`
+	UsageSource  string            `json:"usageSource"` // metrics-server or requests
+	// Errors holds the sections that could not be loaded; the others are still returned
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// GetNamespaceOverview returns workloads, pod health, recent events, quota usage and the top
+// consumers of a namespace in one response. Sections are loaded in parallel, and a section
+// that fails is reported in errors rather than failing the whole overview.
+func (h *ResourceHandler) GetNamespaceOverview(c *gin.Context) {
+	ns := c.Param("namespace")
+	if rbacNs := rbacNamespace(c); rbacNs != "" && rbacNs != ns {
+		apierror.Write(c, http.StatusForbidden, "access denied to namespace "+ns)
+		return
+	}
+	ctx := c.Request.Context()
+	o := NamespaceOverview{Namespace: ns, Workloads: []WorkloadSummary{}, Events: []EventRecord{}, Quotas: []QuotaUsage{}, TopConsumers: []PodConsumer{}}
+
+	var mu sync.Mutex
+	fail := func(section string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if o.Errors == nil {
+			o.Errors = map[string]string{}
+		}
+		o.Errors[section] = err.Error()
+	}
+	var pods []corev1.Pod
+	var wg sync.WaitGroup
+	run := func(section string, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				fail(section, err)
+			}
+		}()
+	}
+	run("pods", func() (err error) {
+		pods, err = h.k8sClient.ListPods(ctx, ns)
+		return err
+	})
+	run("workloads", func() (err error) {
+		o.Workloads, err = h.namespaceWorkloads(ctx, ns)
+		return err
+	})
+	run("events", func() (err error) {
+		o.Events, err = h.namespaceEvents(ctx, ns, nil)
+		return err
+	})
+	run("quotas", func() (err error) {
+		o.Quotas, err = h.namespaceQuotas(ctx, ns)
+		return err
+	})
+	wg.Wait()
+
+	o.Pods = podHealth(pods)
+	o.TopConsumers, o.UsageSource = h.topConsumers(ctx, ns, pods)
+	c.JSON(http.StatusOK, o)
+}
+
+func podHealth(pods []corev1.Pod) PodHealth {
+	health := PodHealth{Total: len(pods), Unhealthy: []UnhealthyPod{}}
+	for _, p := range pods {
+		var restarts int32
+		ready := true
+		status := string(p.Status.Phase)
+		for _, cs := range p.Status.ContainerStatuses {
+			restarts += cs.RestartCount
+			ready = ready && cs.Ready
+			if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+				status = cs.State.Waiting.Reason
+			}
+		}
+		health.Restarts += restarts
+		switch p.Status.Phase {
+		case corev1.PodRunning:
+			health.Running++
+		case corev1.PodPending:
+			health.Pending++
+		case corev1.PodFailed:
+			health.Failed++
+		case corev1.PodSucceeded:
+			health.Succeeded++
+			continue
+		}
+		if p.Status.Phase != corev1.PodRunning || !ready {
+			health.Unhealthy = append(health.Unhealthy, UnhealthyPod{Name: p.Name, Status: status, Restarts: restarts})
+		}
+	}
+	sort.Slice(health.Unhealthy, func(i, j int) bool { return health.Unhealthy[i].Restarts > health.Unhealthy[j].Restarts })
+	return health
+}
+
+func workloadStatus(ready, desired int64) string {
+	switch {
+	case desired == 0:
+		return "Scaled down"
+	case ready >= desired:
+		return "Healthy"
+	}
+	return "Degraded"
+}
+
+func (h *ResourceHandler) namespaceWorkloads(ctx context.Context, ns string) ([]WorkloadSummary, error) {
+	workloads := []WorkloadSummary{}
+	if h.devMode {
+		for _, kind := range []string{"deployments", "statefulsets", "daemonsets"} {
+			for _, item := range mockResourceList(kind, ns) {
+				ready := item.Extra["ready"]
+				if kind == "daemonsets" {
+					ready = item.Extra["ready"] + "/" + item.Extra["desired"]
+				}
+				var r, d int64
+				fmt.Sscanf(ready, "%d/%d", &r, &d)
+				workloads = append(workloads, WorkloadSummary{Kind: kind, Name: item.Name, Ready: ready, Status: workloadStatus(r, d)})
+			}
+		}
+		return workloads, nil
+	}
+
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Ready and desired replica fields per kind
+	fields := map[string][2]string{
+		"deployments":  {"readyReplicas", "replicas"},
+		"statefulsets": {"readyReplicas", "replicas"},
+		"daemonsets":   {"numberReady", "desiredNumberScheduled"},
+	}
+	for _, kind := range []string{"deployments", "statefulsets", "daemonsets"} {
+		list, err := dynClient.Resource(getGVR(kind)).Namespace(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return workloads, err
+		}
+		for _, item := range list.Items {
+			ready, _, _ := unstructured.NestedInt64(item.Object, "status", fields[kind][0])
+			desired, _, _ := unstructured.NestedInt64(item.Object, "status", fields[kind][1])
+			if kind != "daemonsets" {
+				desired, _, _ = unstructured.NestedInt64(item.Object, "spec", "replicas")
+			}
+			workloads = append(workloads, WorkloadSummary{Kind: kind, Name: item.GetName(), Ready: fmt.Sprintf("%d/%d", ready, desired), Status: workloadStatus(ready, desired)})
+		}
+	}
+	return workloads, nil
+}
+
+// namespaceEvents returns the most recent events, warnings first, of the objects match accepts
+// (all when nil).
+func (h *ResourceHandler) namespaceEvents(ctx context.Context, ns string, match func(EventRecord) bool) ([]EventRecord, error) {
+	var events []EventRecord
+	if h.devMode {
+		for _, e := range mockEventRecords(time.Now()) {
+			if e.Namespace == ns {
+				events = append(events, e)
+			}
+		}
+	} else {
+		dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		list, err := dynClient.Resource(getGVR("events")).Namespace(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list.Items {
+			events = append(events, eventRecordFromObject(item.Object))
+		}
+	}
+	if match != nil {
+		matched := events[:0]
+		for _, e := range events {
+			if match(e) {
+				matched = append(matched, e)
+			}
+		}
+		events = matched
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if (events[i].Type == "Warning") != (events[j].Type == "Warning") {
+			return events[i].Type == "Warning"
+		}
+		return events[i].LastSeen.After(events[j].LastSeen)
+	})
+	if len(events) > overviewEvents {
+		events = events[:overviewEvents]
+	}
+	if events == nil {
+		events = []EventRecord{}
+	}
+	return events, nil
+}
+
+func quotaUsage(quota, name, used, hard string) QuotaUsage {
+	q := QuotaUsage{Quota: quota, Resource: name, Used: used, Hard: hard}
+	u, uErr := resource.ParseQuantity(used)
+	h, hErr := resource.ParseQuantity(hard)
+	if uErr == nil && hErr == nil && !h.IsZero() {
+		q.Percent = float64(u.MilliValue()) / float64(h.MilliValue()) * 100
+	}
+	return q
+}
+
+// splitQuotaList reads the "cpu: 4, memory: 8Gi" lists of the mock quotas.
+func splitQuotaList(s string) map[string]string {
+	values := map[string]string{}
+	for _, part := range strings.Split(s, ",") {
+		if k, v, ok := strings.Cut(part, ":"); ok {
+			values[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return values
+}
+
+func (h *ResourceHandler) namespaceQuotas(ctx context.Context, ns string) ([]QuotaUsage, error) {
+	quotas := []QuotaUsage{}
+	if h.devMode {
+		for _, item := range mockResourceList("resourcequotas", ns) {
+			hard, used := splitQuotaList(item.Extra["hard"]), splitQuotaList(item.Extra["used"])
+			for name, v := range hard {
+				quotas = append(quotas, quotaUsage(item.Name, name, used[name], v))
+			}
+		}
+	} else {
+		dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		list, err := dynClient.Resource(getGVR("resourcequotas")).Namespace(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list.Items {
+			hard, _, _ := unstructured.NestedStringMap(item.Object, "status", "hard")
+			used, _, _ := unstructured.NestedStringMap(item.Object, "status", "used")
+			for name, v := range hard {
+				quotas = append(quotas, quotaUsage(item.GetName(), name, used[name], v))
+			}
+		}
+	}
+	// Closest to the limit first
+	sort.Slice(quotas, func(i, j int) bool {
+		if quotas[i].Percent != quotas[j].Percent {
+			return quotas[i].Percent > quotas[j].Percent
+		}
+		return quotas[i].Quota+quotas[i].Resource < quotas[j].Quota+quotas[j].Resource
+	})
+	return quotas, nil
+}
+
+// topConsumers ranks pods by CPU from metrics-server, or by requests when it is missing.
+func (h *ResourceHandler) topConsumers(ctx context.Context, ns string, pods []corev1.Pod) ([]PodConsumer, string) {
+	consumers, source := h.podUsage(ctx, ns, pods)
+	sort.Slice(consumers, func(i, j int) bool {
+		if consumers[i].CPUMillis != consumers[j].CPUMillis {
+			return consumers[i].CPUMillis > consumers[j].CPUMillis
+		}
+		return consumers[i].MemoryBytes > consumers[j].MemoryBytes
+	})
+	if len(consumers) > overviewConsumers {
+		consumers = consumers[:overviewConsumers]
+	}
+	return consumers, source
+}
+
+// podUsage returns the CPU and memory of pods from metrics-server, or their requests when it
+// is missing, with the source used.
+func (h *ResourceHandler) podUsage(ctx context.Context, ns string, pods []corev1.Pod) ([]PodConsumer, string) {
+	wanted := make(map[string]bool, len(pods))
+	for _, p := range pods {
+		wanted[p.Name] = true
+	}
+	var consumers []PodConsumer
+	source := "metrics-server"
+	add := func(name string, containers []interface{}) {
+		pc := PodConsumer{Pod: name}
+		for _, raw := range containers {
+			ctr, _ := raw.(map[string]interface{})
+			cpu, _, _ := unstructured.NestedString(ctr, "usage", "cpu")
+			mem, _, _ := unstructured.NestedString(ctr, "usage", "memory")
+			if q, err := resource.ParseQuantity(cpu); err == nil {
+				pc.CPUMillis += q.MilliValue()
+			}
+			if q, err := resource.ParseQuantity(mem); err == nil {
+				pc.MemoryBytes += q.Value()
+			}
+		}
+		consumers = append(consumers, pc)
+	}
+
+	if h.devMode {
+		for _, p := range pods {
+			if m, err := h.k8sClient.GetPodMetrics(ctx, ns, p.Name); err == nil && m != nil {
+				containers, _, _ := unstructured.NestedSlice(m, "containers")
+				add(p.Name, containers)
+			}
+		}
+	} else if dynClient, err := h.k8sClient.GetDynamicClient(ctx); err == nil {
+		metricsGVR := schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+		if list, err := dynClient.Resource(metricsGVR).Namespace(ns).List(ctx, metav1.ListOptions{}); err == nil {
+			for _, item := range list.Items {
+				if wanted[item.GetName()] {
+					containers, _, _ := unstructured.NestedSlice(item.Object, "containers")
+					add(item.GetName(), containers)
+				}
+			}
+		}
+	}
+
+	if consumers == nil {
+		source = "requests"
+		for _, p := range pods {
+			if p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+				continue
+			}
+			pc := PodConsumer{Pod: p.Name}
+			for _, ctr := range p.Spec.Containers {
+				pc.CPUMillis += ctr.Resources.Requests.Cpu().MilliValue()
+				pc.MemoryBytes += ctr.Resources.Requests.Memory().Value()
+			}
+			consumers = append(consumers, pc)
+		}
+	}
+	if consumers == nil {
+		consumers = []PodConsumer{}
+	}
+	return consumers, source
+}
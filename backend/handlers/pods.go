@@ -1,13 +1,19 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"k-view/apierror"
 	"k-view/k8s"
 
 	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
 )
 
 type PodHandler struct {
@@ -31,7 +37,7 @@ func (h *PodHandler) ListPods(c *gin.Context) {
 
 	pods, err := h.k8sClient.ListPods(c.Request.Context(), namespace)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list pods: " + err.Error()})
+		apierror.Fail(c, "Failed to list pods", err)
 		return
 	}
 
@@ -39,7 +45,10 @@ func (h *PodHandler) ListPods(c *gin.Context) {
 		Name      string `json:"name"`
 		Namespace string `json:"namespace"`
 		Status    string `json:"status"`
+		Init      string `json:"init,omitempty"`
 		Age       string `json:"age"`
+		// Extended resources such as nvidia.com/gpu or hugepages-2Mi
+		ExtendedRequests map[string]string `json:"extendedRequests,omitempty"`
 	}
 
 	var response []PodResponse
@@ -52,11 +61,18 @@ func (h *PodHandler) ListPods(c *gin.Context) {
 				break
 			}
 		}
+		// Pods still running init containers report kubectl-style "Init:x/y" statuses
+		initStatus, initProgress := podInitStatus(p)
+		if initStatus != "" {
+			status = initStatus
+		}
 		response = append(response, PodResponse{
-			Name:      p.Name,
-			Namespace: p.Namespace,
-			Status:    status,
-			Age:       p.CreationTimestamp.Time.String(),
+			Name:             p.Name,
+			Namespace:        p.Namespace,
+			Status:           status,
+			Init:             initProgress,
+			Age:              p.CreationTimestamp.Time.String(),
+			ExtendedRequests: extendedResources(podExtendedRequests(p)),
 		})
 	}
 
@@ -67,9 +83,19 @@ func (h *PodHandler) ListNamespaces(c *gin.Context) {
 	namespaces, err := h.k8sClient.ListNamespaces(c.Request.Context())
 	if err != nil {
 		log.Printf("ERROR: Failed to list namespaces: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list namespaces: " + err.Error()})
+		apierror.Fail(c, "Failed to list namespaces", err)
 		return
 	}
+	// Anonymous visitors and users limited to several namespaces only see those
+	if allowed := c.GetStringSlice("allowedNamespaces"); len(allowed) > 0 {
+		visible := []string{}
+		for _, ns := range namespaces {
+			if contains(allowed, ns) {
+				visible = append(visible, ns)
+			}
+		}
+		namespaces = visible
+	}
 	c.JSON(http.StatusOK, namespaces)
 }
 func (h *PodHandler) GetLogs(c *gin.Context) {
@@ -84,7 +110,7 @@ func (h *PodHandler) GetLogs(c *gin.Context) {
 	// Apply RBAC namespace restriction
 	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
 		if namespace != rbacNs.(string) {
-			c.JSON(http.StatusForbidden, gin.H{"error": "access denied to namespace " + namespace})
+			apierror.Write(c, http.StatusForbidden, "access denied to namespace "+namespace)
 			return
 		}
 	}
@@ -92,9 +118,245 @@ func (h *PodHandler) GetLogs(c *gin.Context) {
 
 	logs, err := h.k8sClient.GetPodLogs(c.Request.Context(), namespace, pod, container, tail)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get logs: " + err.Error()})
+		apierror.Fail(c, "Failed to get logs", err)
+		return
+	}
+
+	// ?previous=merge puts the logs of the container's previous instance before the current
+	// ones, separated by a line describing the restart; without a restart only the current
+	// logs are returned
+	var previous string
+	var restart *RestartBoundary
+	if c.Query("previous") == "merge" {
+		var status int
+		previous, restart, status, err = h.previousLogs(c.Request.Context(), namespace, pod, container, tail)
+		if err != nil {
+			apierror.Write(c, status, err.Error())
+			return
+		}
+	}
+
+	// Optional server-side parsing: ?format=parsed returns JSON lines with level, timestamp and
+	// message; ?level=warn keeps lines at or above that severity in either format
+	level := strings.ToLower(c.Query("level"))
+	if level != "" {
+		level = normalizeLevel(level)
+		if level == "" {
+			apierror.Write(c, http.StatusBadRequest, "level must be one of trace, debug, info, warn, error, fatal")
+			return
+		}
+	}
+	parsed := c.Query("format") == "parsed"
+	if !parsed && level == "" {
+		if restart != nil {
+			logs = strings.TrimSuffix(previous, "\n") + "\n" + restart.marker() + "\n" + logs
+		}
+		c.String(http.StatusOK, logs)
 		return
 	}
 
-	c.String(http.StatusOK, logs)
+	lines := parseLogs(logs)
+	total := len(lines)
+	if level != "" {
+		lines = filterLogLevel(lines, level)
+	}
+	if restart != nil {
+		previousLines := parseLogs(previous)
+		total += len(previousLines)
+		if level != "" {
+			previousLines = filterLogLevel(previousLines, level)
+		}
+		for i := range previousLines {
+			previousLines[i].Instance = "previous"
+		}
+		for i := range lines {
+			lines[i].Instance = "current"
+		}
+		// The boundary is kept whatever the level filter, so the restart stays visible
+		boundary := LogLine{Format: "restart", Message: restart.marker(), Raw: restart.marker()}
+		lines = append(append(previousLines, boundary), lines...)
+	}
+	if !parsed {
+		var b strings.Builder
+		for _, line := range lines {
+			b.WriteString(line.Raw)
+			b.WriteByte('\n')
+		}
+		c.String(http.StatusOK, b.String())
+		return
+	}
+	if restart != nil {
+		c.JSON(http.StatusOK, gin.H{"lines": lines, "total": total, "matched": len(lines) - 1, "restart": restart})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"lines": lines, "total": total, "matched": len(lines)})
+}
+
+// RestartBoundary describes the restart between the previous and the current instance of a
+// container. The kubelet keeps the logs of one previous instance only.
+type RestartBoundary struct {
+	Container  string `json:"container"`
+	Restarts   int32  `json:"restarts"`
+	ExitCode   int32  `json:"exitCode"`
+	Reason     string `json:"reason,omitempty"` // e.g. Error, OOMKilled
+	FinishedAt string `json:"finishedAt,omitempty"`
+	StartedAt  string `json:"startedAt,omitempty"` // Of the current instance, unset while it waits to restart
+}
+
+func (r *RestartBoundary) marker() string {
+	m := fmt.Sprintf("===== restart #%d of %s: exited with code %d", r.Restarts, r.Container, r.ExitCode)
+	if r.Reason != "" {
+		m += " (" + r.Reason + ")"
+	}
+	if r.FinishedAt != "" {
+		m += " at " + r.FinishedAt
+	}
+	if r.StartedAt != "" {
+		m += ", restarted at " + r.StartedAt
+	}
+	return m + " ====="
+}
+
+// previousLogs reads the logs of the previous instance of a container (the first one when
+// container is empty), and where it ended. Both are empty when the container never restarted.
+func (h *PodHandler) previousLogs(ctx context.Context, namespace, name, container string, tail int64) (string, *RestartBoundary, int, error) {
+	reader, ok := h.k8sClient.(k8s.PreviousLogReader)
+	if !ok {
+		return "", nil, http.StatusNotImplemented, fmt.Errorf("previous container logs are not supported by this Kubernetes provider")
+	}
+	pods, err := h.k8sClient.ListPods(ctx, namespace)
+	if err != nil {
+		return "", nil, http.StatusInternalServerError, fmt.Errorf("Failed to get pod: %v", err)
+	}
+	var pod *corev1.Pod
+	for i := range pods {
+		if pods[i].Name == name {
+			pod = &pods[i]
+			break
+		}
+	}
+	if pod == nil {
+		return "", nil, http.StatusNotFound, fmt.Errorf("pod %s not found in namespace %s", name, namespace)
+	}
+	if container == "" && len(pod.Spec.Containers) > 0 {
+		container = pod.Spec.Containers[0].Name
+	}
+
+	for _, cs := range append(append([]corev1.ContainerStatus{}, pod.Status.ContainerStatuses...), pod.Status.InitContainerStatuses...) {
+		if cs.Name != container || cs.LastTerminationState.Terminated == nil {
+			continue
+		}
+		last := cs.LastTerminationState.Terminated
+		restart := &RestartBoundary{Container: container, Restarts: cs.RestartCount, ExitCode: last.ExitCode, Reason: last.Reason}
+		if !last.FinishedAt.IsZero() {
+			restart.FinishedAt = last.FinishedAt.UTC().Format(time.RFC3339)
+		}
+		if cs.State.Running != nil {
+			restart.StartedAt = cs.State.Running.StartedAt.UTC().Format(time.RFC3339)
+		}
+		logs, err := reader.GetPreviousPodLogs(ctx, namespace, name, container, tail)
+		if err != nil {
+			return "", nil, http.StatusInternalServerError, fmt.Errorf("Failed to get previous logs: %v", err)
+		}
+		return logs, restart, http.StatusOK, nil
+	}
+	return "", nil, http.StatusOK, nil
+}
+
+// InitContainerStatus describes an init container (or restartable sidecar) in startup order.
+type InitContainerStatus struct {
+	Order        int    `json:"order"`
+	Name         string `json:"name"`
+	Image        string `json:"image"`
+	Sidecar      bool   `json:"sidecar"`
+	State        string `json:"state"`
+	Reason       string `json:"reason,omitempty"`
+	ExitCode     *int32 `json:"exitCode,omitempty"`
+	Ready        bool   `json:"ready"`
+	Started      bool   `json:"started"`
+	RestartCount int32  `json:"restartCount"`
+}
+
+// isSidecar reports whether an init container is a restartable sidecar (restartPolicy: Always).
+func isSidecar(c corev1.Container) bool {
+	return c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways
+}
+
+// summarizeInitContainers merges init container specs with their statuses, preserving startup order.
+func summarizeInitContainers(pod corev1.Pod) []InitContainerStatus {
+	statuses := make(map[string]corev1.ContainerStatus, len(pod.Status.InitContainerStatuses))
+	for _, cs := range pod.Status.InitContainerStatuses {
+		statuses[cs.Name] = cs
+	}
+
+	result := []InitContainerStatus{}
+	for i, c := range pod.Spec.InitContainers {
+		info := InitContainerStatus{
+			Order:   i + 1,
+			Name:    c.Name,
+			Image:   c.Image,
+			Sidecar: isSidecar(c),
+			State:   "Pending",
+		}
+		if cs, ok := statuses[c.Name]; ok {
+			info.Ready = cs.Ready
+			info.RestartCount = cs.RestartCount
+			if cs.Started != nil {
+				info.Started = *cs.Started
+			}
+			switch {
+			case cs.State.Running != nil:
+				info.State = "Running"
+				info.Started = true
+			case cs.State.Terminated != nil:
+				info.State = "Terminated"
+				info.Reason = cs.State.Terminated.Reason
+				exitCode := cs.State.Terminated.ExitCode
+				info.ExitCode = &exitCode
+			case cs.State.Waiting != nil:
+				info.State = "Waiting"
+				info.Reason = cs.State.Waiting.Reason
+			}
+		}
+		result = append(result, info)
+	}
+	return result
+}
+
+// podInitStatus mirrors kubectl's init-phase status column. It returns an empty status once
+// all regular init containers have completed and every sidecar has started.
+func podInitStatus(pod corev1.Pod) (string, string) {
+	total := len(pod.Spec.InitContainers)
+	if total == 0 {
+		return "", ""
+	}
+
+	done := 0
+	status := ""
+	for _, info := range summarizeInitContainers(pod) {
+		switch {
+		case info.Sidecar && info.Started:
+			done++
+			continue
+		case info.State == "Terminated" && info.ExitCode != nil && *info.ExitCode == 0:
+			done++
+			continue
+		case info.State == "Terminated":
+			status = "Init:" + info.Reason
+			if info.Reason == "" {
+				status = fmt.Sprintf("Init:ExitCode:%d", *info.ExitCode)
+			}
+		case info.State == "Waiting" && info.Reason != "" && info.Reason != "PodInitializing":
+			status = "Init:" + info.Reason
+		}
+		if status != "" {
+			break
+		}
+	}
+
+	progress := fmt.Sprintf("%d/%d", done, total)
+	if status == "" && done < total && pod.Status.Phase == corev1.PodPending {
+		status = "Init:" + progress
+	}
+	return status, progress
 }
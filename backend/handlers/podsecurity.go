@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k-view/apierror"
+)
+
+// psaLevels are the Pod Security Standards levels, least strict first.
+var psaLevels = []string{"privileged", "baseline", "restricted"}
+
+// psaModes are the modes a namespace sets a level for with pod-security.kubernetes.io/<mode>.
+var psaModes = []string{"enforce", "audit", "warn"}
+
+// baselineCapabilities may be added to containers at the baseline level.
+var baselineCapabilities = map[corev1.Capability]bool{
+	"AUDIT_WRITE": true, "CHOWN": true, "DAC_OVERRIDE": true, "FOWNER": true, "FSETID": true, "KILL": true, "MKNOD": true,
+	"NET_BIND_SERVICE": true, "SETFCAP": true, "SETGID": true, "SETPCAP": true, "SETUID": true, "SYS_CHROOT": true,
+}
+
+// safeSysctls may be set at the baseline level.
+var safeSysctls = map[string]bool{
+	"kernel.shm_rmid_forced": true, "net.ipv4.ip_local_port_range": true, "net.ipv4.ip_unprivileged_port_start": true,
+	"net.ipv4.tcp_syncookies": true, "net.ipv4.ping_group_range": true, "net.ipv4.ip_local_reserved_ports": true,
+	"net.ipv4.tcp_keepalive_time": true, "net.ipv4.tcp_fin_timeout": true, "net.ipv4.tcp_keepalive_intvl": true, "net.ipv4.tcp_keepalive_probes": true,
+}
+
+// selinuxTypes may be set at the baseline level, besides leaving the type unset.
+var selinuxTypes = map[string]bool{"container_t": true, "container_init_t": true, "container_kvm_t": true}
+
+// PSAViolation is a workload whose pods would be rejected at a Pod Security level.
+type PSAViolation struct {
+	Workload string   `json:"workload"` // Controller name, Deployments for ReplicaSet pods
+	Pods     int      `json:"pods"`
+	Checks   []string `json:"checks"` // Failed checks, e.g. "runAsNonRoot: container app"
+}
+
+// PSANamespace is the Pod Security admission configuration of a namespace, with the workloads
+// that would violate each level stricter than the enforced one.
+type PSANamespace struct {
+	Namespace  string                    `json:"namespace"`
+	Levels     map[string]string         `json:"levels"`             // Mode (enforce, audit, warn) to level; unset modes are privileged
+	Versions   map[string]string         `json:"versions,omitempty"` // Mode to pinned version, when not latest
+	Pods       int                       `json:"pods"`
+	Strictest  string                    `json:"strictest"` // Strictest level all running pods meet
+	Violations map[string][]PSAViolation `json:"violations"`
+}
+
+// podSecurityViolations returns the checks a pod fails at a level, as "check: detail" strings.
+func podSecurityViolations(pod corev1.Pod, level string) []string {
+	if level == "privileged" {
+		return nil
+	}
+	var failed []string
+	fail := func(check, format string, args ...interface{}) {
+		failed = append(failed, check+": "+fmt.Sprintf(format, args...))
+	}
+	spec := pod.Spec
+	podCtx := spec.SecurityContext
+	if podCtx == nil {
+		podCtx = &corev1.PodSecurityContext{}
+	}
+	containers := append(podImages(pod), ephemeralContainers(pod)...)
+
+	// Baseline
+	if spec.HostNetwork || spec.HostPID || spec.HostIPC {
+		fail("hostNamespaces", "hostNetwork, hostPID or hostIPC is set")
+	}
+	for _, v := range spec.Volumes {
+		if v.HostPath != nil {
+			fail("hostPathVolumes", "volume %s mounts %s", v.Name, v.HostPath.Path)
+		}
+	}
+	if podCtx.WindowsOptions != nil && podCtx.WindowsOptions.HostProcess != nil && *podCtx.WindowsOptions.HostProcess {
+		fail("hostProcess", "pod is a Windows HostProcess pod")
+	}
+	for _, s := range podCtx.Sysctls {
+		if !safeSysctls[s.Name] {
+			fail("sysctls", "%s is not a safe sysctl", s.Name)
+		}
+	}
+	if opts := podCtx.SELinuxOptions; opts != nil && ((opts.Type != "" && !selinuxTypes[opts.Type]) || opts.User != "" || opts.Role != "") {
+		fail("seLinuxOptions", "pod sets a custom SELinux type, user or role")
+	}
+	if podCtx.SeccompProfile != nil && podCtx.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+		fail("seccompProfile", "pod is Unconfined")
+	}
+	for key, value := range pod.Annotations {
+		if strings.HasPrefix(key, "container.apparmor.security.beta.kubernetes.io/") && value != "runtime/default" && !strings.HasPrefix(value, "localhost/") {
+			fail("appArmorProfile", "%s is %s", strings.TrimPrefix(key, "container.apparmor.security.beta.kubernetes.io/"), value)
+		}
+	}
+	for _, ctr := range containers {
+		ctx := ctr.SecurityContext
+		if ctx == nil {
+			ctx = &corev1.SecurityContext{}
+		}
+		if ctx.Privileged != nil && *ctx.Privileged {
+			fail("privileged", "container %s", ctr.Name)
+		}
+		if ctx.WindowsOptions != nil && ctx.WindowsOptions.HostProcess != nil && *ctx.WindowsOptions.HostProcess {
+			fail("hostProcess", "container %s", ctr.Name)
+		}
+		if ctx.Capabilities != nil {
+			for _, capability := range ctx.Capabilities.Add {
+				if !baselineCapabilities[capability] {
+					fail("capabilities", "container %s adds %s", ctr.Name, capability)
+				}
+			}
+		}
+		for _, port := range ctr.Ports {
+			if port.HostPort != 0 {
+				fail("hostPorts", "container %s uses host port %d", ctr.Name, port.HostPort)
+			}
+		}
+		if opts := ctx.SELinuxOptions; opts != nil && ((opts.Type != "" && !selinuxTypes[opts.Type]) || opts.User != "" || opts.Role != "") {
+			fail("seLinuxOptions", "container %s sets a custom SELinux type, user or role", ctr.Name)
+		}
+		if ctx.ProcMount != nil && *ctx.ProcMount != corev1.DefaultProcMount {
+			fail("procMount", "container %s uses the %s proc mount", ctr.Name, *ctx.ProcMount)
+		}
+		if ctx.SeccompProfile != nil && ctx.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+			fail("seccompProfile", "container %s is Unconfined", ctr.Name)
+		}
+	}
+	if level == "baseline" {
+		return failed
+	}
+
+	// Restricted
+	for _, v := range spec.Volumes {
+		src := v.VolumeSource
+		if src.ConfigMap == nil && src.CSI == nil && src.DownwardAPI == nil && src.EmptyDir == nil && src.Ephemeral == nil &&
+			src.PersistentVolumeClaim == nil && src.Projected == nil && src.Secret == nil && src.HostPath == nil {
+			fail("volumeTypes", "volume %s has a restricted type", v.Name)
+		}
+	}
+	podNonRoot := podCtx.RunAsNonRoot != nil && *podCtx.RunAsNonRoot
+	if podCtx.RunAsUser != nil && *podCtx.RunAsUser == 0 {
+		fail("runAsUser", "pod runs as UID 0")
+	}
+	podSeccomp := podCtx.SeccompProfile != nil
+	// The remaining checks are Linux-only; Windows pods are exempt from them
+	windows := spec.OS != nil && spec.OS.Name == corev1.Windows
+	for _, ctr := range containers {
+		ctx := ctr.SecurityContext
+		if ctx == nil {
+			ctx = &corev1.SecurityContext{}
+		}
+		if ctx.RunAsNonRoot != nil && !*ctx.RunAsNonRoot || ctx.RunAsNonRoot == nil && !podNonRoot {
+			fail("runAsNonRoot", "container %s", ctr.Name)
+		}
+		if ctx.RunAsUser != nil && *ctx.RunAsUser == 0 {
+			fail("runAsUser", "container %s runs as UID 0", ctr.Name)
+		}
+		if windows {
+			continue
+		}
+		if ctx.AllowPrivilegeEscalation == nil || *ctx.AllowPrivilegeEscalation {
+			fail("allowPrivilegeEscalation", "container %s does not set it to false", ctr.Name)
+		}
+		if ctx.SeccompProfile == nil && !podSeccomp {
+			fail("seccompProfile", "container %s sets no RuntimeDefault or Localhost profile", ctr.Name)
+		}
+		dropsAll := false
+		if ctx.Capabilities != nil {
+			for _, capability := range ctx.Capabilities.Drop {
+				dropsAll = dropsAll || capability == "ALL"
+			}
+			for _, capability := range ctx.Capabilities.Add {
+				if capability != "NET_BIND_SERVICE" && baselineCapabilities[capability] {
+					fail("capabilities", "container %s adds %s", ctr.Name, capability)
+				}
+			}
+		}
+		if !dropsAll {
+			fail("capabilities", "container %s does not drop ALL", ctr.Name)
+		}
+	}
+	return failed
+}
+
+func ephemeralContainers(pod corev1.Pod) []corev1.Container {
+	containers := make([]corev1.Container, 0, len(pod.Spec.EphemeralContainers))
+	for _, ec := range pod.Spec.EphemeralContainers {
+		containers = append(containers, corev1.Container(ec.EphemeralContainerCommon))
+	}
+	return containers
+}
+
+// GetPodSecurityAdmission lists the pod-security.kubernetes.io levels of every namespace the
+// user can see, and the running workloads that would be rejected at each level stricter than
+// the enforced one, to plan tightening them. ?namespace= limits it to one namespace.
+func (h *DiagnosticsHandler) GetPodSecurityAdmission(c *gin.Context) {
+	ns := c.Query("namespace")
+	if ns == "-" {
+		ns = ""
+	}
+	if rbacNs := rbacNamespace(c); rbacNs != "" {
+		ns = rbacNs
+	}
+	ctx := c.Request.Context()
+
+	labels := map[string]map[string]string{}
+	if h.devMode {
+		labels = mockNamespaceSecurityLabels()
+	} else {
+		dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+		if err != nil {
+			apierror.Fail(c, "Failed to get dynamic client", err)
+			return
+		}
+		if ns != "" {
+			item, err := dynClient.Resource(getGVR("namespaces")).Get(ctx, ns, metav1.GetOptions{})
+			if err != nil {
+				apierror.Fail(c, "Failed to get resource", err)
+				return
+			}
+			labels[ns] = item.GetLabels()
+		} else {
+			list, err := dynClient.Resource(getGVR("namespaces")).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				apierror.Fail(c, "Failed to list namespaces", err)
+				return
+			}
+			for _, item := range list.Items {
+				labels[item.GetName()] = item.GetLabels()
+			}
+		}
+	}
+	pods, err := h.k8sClient.ListPods(ctx, ns)
+	if err != nil {
+		apierror.Fail(c, "Failed to list pods", err)
+		return
+	}
+
+	result := []PSANamespace{}
+	for name, nsLabels := range labels {
+		if ns != "" && name != ns {
+			continue
+		}
+		result = append(result, podSecurityNamespace(name, nsLabels, pods))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Namespace < result[j].Namespace })
+	c.JSON(http.StatusOK, result)
+}
+
+// podSecurityNamespace evaluates the running pods of a namespace against the levels stricter
+// than its enforced one.
+func podSecurityNamespace(name string, labels map[string]string, pods []corev1.Pod) PSANamespace {
+	result := PSANamespace{Namespace: name, Levels: map[string]string{}, Violations: map[string][]PSAViolation{}}
+	for _, mode := range psaModes {
+		result.Levels[mode] = "privileged"
+		if level := labels["pod-security.kubernetes.io/"+mode]; contains(psaLevels, level) {
+			result.Levels[mode] = level
+		}
+		if version := labels["pod-security.kubernetes.io/"+mode+"-version"]; version != "" && version != "latest" {
+			if result.Versions == nil {
+				result.Versions = map[string]string{}
+			}
+			result.Versions[mode] = version
+		}
+	}
+
+	enforced := 0
+	for i, level := range psaLevels {
+		if level == result.Levels["enforce"] {
+			enforced = i
+		}
+	}
+	result.Strictest = psaLevels[enforced]
+	byWorkload := map[string]map[string]*PSAViolation{}
+	for _, p := range pods {
+		if p.Namespace != name || p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		result.Pods++
+		for _, level := range psaLevels[enforced+1:] {
+			checks := podSecurityViolations(p, level)
+			if len(checks) == 0 {
+				continue
+			}
+			if byWorkload[level] == nil {
+				byWorkload[level] = map[string]*PSAViolation{}
+			}
+			workload := strings.TrimPrefix(podWorkload(p), p.Namespace+"/")
+			v, ok := byWorkload[level][workload]
+			if !ok {
+				v = &PSAViolation{Workload: workload}
+				byWorkload[level][workload] = v
+			}
+			v.Pods++
+			for _, check := range checks {
+				v.Checks = appendUnique(v.Checks, check)
+			}
+		}
+	}
+
+	for _, level := range psaLevels[enforced+1:] {
+		violations := []PSAViolation{}
+		for _, v := range byWorkload[level] {
+			violations = append(violations, *v)
+		}
+		sort.Slice(violations, func(i, j int) bool { return violations[i].Workload < violations[j].Workload })
+		result.Violations[level] = violations
+	}
+	// Running pods were admitted at the enforced level; restricted is only met when baseline is
+	for _, level := range psaLevels[enforced+1:] {
+		if len(result.Violations[level]) > 0 {
+			break
+		}
+		result.Strictest = level
+	}
+	return result
+}
+
+// mockNamespaceSecurityLabels returns the Pod Security labels of the mock namespaces for
+// DEV_MODE: system namespaces stay privileged, applications are being tightened.
+func mockNamespaceSecurityLabels() map[string]map[string]string {
+	labels := map[string]map[string]string{}
+	for _, ns := range []string{"default", "auth", "database", "messaging", "monitoring", "logging", "ingress-nginx", "cert-manager", "kube-system", "kube-public", "kube-node-lease"} {
+		labels[ns] = map[string]string{"kubernetes.io/metadata.name": ns}
+	}
+	labels["default"]["pod-security.kubernetes.io/enforce"] = "baseline"
+	labels["default"]["pod-security.kubernetes.io/warn"] = "restricted"
+	labels["auth"]["pod-security.kubernetes.io/enforce"] = "baseline"
+	labels["auth"]["pod-security.kubernetes.io/enforce-version"] = "v1.28"
+	labels["auth"]["pod-security.kubernetes.io/audit"] = "restricted"
+	labels["kube-system"]["pod-security.kubernetes.io/enforce"] = "privileged"
+	labels["cert-manager"]["pod-security.kubernetes.io/enforce"] = "restricted"
+	return labels
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"k-view/apierror"
+)
+
+// PolicyViolation is a policy rule a resource fails.
+type PolicyViolation struct {
+	Engine   string `json:"engine"` // kyverno or gatekeeper
+	Policy   string `json:"policy"` // Kyverno policy, or Gatekeeper constraint as Kind/name
+	Rule     string `json:"rule,omitempty"`
+	Result   string `json:"result"` // fail, warn or error for Kyverno; the enforcement action (deny, dryrun, warn) for Gatekeeper
+	Severity string `json:"severity,omitempty"`
+	Message  string `json:"message"`
+}
+
+// PolicyWorkload is a resource with its policy violations.
+type PolicyWorkload struct {
+	Kind       string            `json:"kind"`
+	Name       string            `json:"name"`
+	Violations []PolicyViolation `json:"violations"`
+}
+
+// PolicyNamespace groups the resources of a namespace with violations; Namespace is empty for
+// cluster-scoped resources.
+type PolicyNamespace struct {
+	Namespace  string           `json:"namespace"`
+	Violations int              `json:"violations"`
+	Workloads  []PolicyWorkload `json:"workloads"`
+}
+
+// PolicyPosture is the policy engine view of the cluster: which engines are installed and the
+// violations they report.
+type PolicyPosture struct {
+	Kyverno    bool              `json:"kyverno"`
+	Gatekeeper bool              `json:"gatekeeper"`
+	Namespaces []PolicyNamespace `json:"namespaces"`
+	Unlisted   int               `json:"unlisted,omitempty"` // Gatekeeper violations beyond the audit's per-constraint limit
+}
+
+// policyFinding is a violation with the resource it was reported for.
+type policyFinding struct {
+	namespace, kind, name string
+	violation             PolicyViolation
+}
+
+// GetPolicyViolations lists the violations reported by Kyverno (PolicyReports and
+// ClusterPolicyReports) and Gatekeeper (the audit status of every constraint), grouped by
+// namespace and resource, most violations first. Engines that are not installed are reported
+// as false. ?namespace= limits it to one namespace; namespace-pinned users only see theirs.
+func (h *DiagnosticsHandler) GetPolicyViolations(c *gin.Context) {
+	ns := c.Query("namespace")
+	if ns == "-" {
+		ns = ""
+	}
+	if rbacNs := rbacNamespace(c); rbacNs != "" {
+		ns = rbacNs
+	}
+
+	posture := PolicyPosture{}
+	var findings []policyFinding
+	if h.devMode {
+		posture.Kyverno, posture.Gatekeeper = true, true
+		for _, report := range mockPolicyReports() {
+			findings = append(findings, kyvernoFindings(report)...)
+		}
+		for _, constraint := range mockConstraints() {
+			found, unlisted := gatekeeperFindings(constraint)
+			findings = append(findings, found...)
+			posture.Unlisted += unlisted
+		}
+	} else {
+		ctx := c.Request.Context()
+		dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+		if err != nil {
+			apierror.Fail(c, "Failed to get dynamic client", err)
+			return
+		}
+		var found []policyFinding
+		posture.Kyverno, found, err = listKyvernoFindings(ctx, dynClient, ns)
+		if err != nil {
+			apierror.Fail(c, "Failed to list policy reports", err)
+			return
+		}
+		findings = append(findings, found...)
+		var unlisted int
+		posture.Gatekeeper, found, unlisted, err = listGatekeeperFindings(ctx, dynClient)
+		if err != nil {
+			apierror.Fail(c, "Failed to list Gatekeeper constraints", err)
+			return
+		}
+		findings = append(findings, found...)
+		posture.Unlisted = unlisted
+	}
+
+	byNamespace := map[string]map[string]*PolicyWorkload{}
+	for _, f := range findings {
+		if ns != "" && f.namespace != ns {
+			continue
+		}
+		if byNamespace[f.namespace] == nil {
+			byNamespace[f.namespace] = map[string]*PolicyWorkload{}
+		}
+		key := f.kind + "/" + f.name
+		w, ok := byNamespace[f.namespace][key]
+		if !ok {
+			w = &PolicyWorkload{Kind: f.kind, Name: f.name}
+			byNamespace[f.namespace][key] = w
+		}
+		w.Violations = append(w.Violations, f.violation)
+	}
+	posture.Namespaces = []PolicyNamespace{}
+	for name, workloads := range byNamespace {
+		pn := PolicyNamespace{Namespace: name, Workloads: []PolicyWorkload{}}
+		for _, w := range workloads {
+			pn.Violations += len(w.Violations)
+			pn.Workloads = append(pn.Workloads, *w)
+		}
+		sort.Slice(pn.Workloads, func(i, j int) bool {
+			if len(pn.Workloads[i].Violations) != len(pn.Workloads[j].Violations) {
+				return len(pn.Workloads[i].Violations) > len(pn.Workloads[j].Violations)
+			}
+			return pn.Workloads[i].Kind+"/"+pn.Workloads[i].Name < pn.Workloads[j].Kind+"/"+pn.Workloads[j].Name
+		})
+		posture.Namespaces = append(posture.Namespaces, pn)
+	}
+	sort.Slice(posture.Namespaces, func(i, j int) bool {
+		if posture.Namespaces[i].Violations != posture.Namespaces[j].Violations {
+			return posture.Namespaces[i].Violations > posture.Namespaces[j].Violations
+		}
+		return posture.Namespaces[i].Namespace < posture.Namespaces[j].Namespace
+	})
+	c.JSON(http.StatusOK, posture)
+}
+
+// listKyvernoFindings reads the PolicyReports of a namespace (all namespaces when empty) and,
+// for all namespaces, the ClusterPolicyReports. installed is false without the wgpolicyk8s.io CRDs.
+func listKyvernoFindings(ctx context.Context, dynClient dynamic.Interface, ns string) (bool, []policyFinding, error) {
+	list, err := dynClient.Resource(getGVR("policyreports")).Namespace(ns).List(ctx, metav1.ListOptions{})
+	if k8serrors.IsNotFound(err) {
+		return false, nil, nil
+	}
+	if err != nil {
+		return true, nil, err
+	}
+	reports := list.Items
+	if ns == "" {
+		clusterList, err := dynClient.Resource(getGVR("clusterpolicyreports")).List(ctx, metav1.ListOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) && !k8serrors.IsForbidden(err) {
+			return true, nil, err
+		}
+		if err == nil {
+			reports = append(reports, clusterList.Items...)
+		}
+	}
+	var findings []policyFinding
+	for _, report := range reports {
+		findings = append(findings, kyvernoFindings(report)...)
+	}
+	return true, findings, nil
+}
+
+// kyvernoFindings returns the failed, warned and errored results of a policy report. Kyverno
+// 1.10+ writes one report per resource, named in scope; older reports list the resources of
+// each result.
+func kyvernoFindings(report unstructured.Unstructured) []policyFinding {
+	scope, _, _ := unstructured.NestedMap(report.Object, "scope")
+	results, _, _ := unstructured.NestedSlice(report.Object, "results")
+	var findings []policyFinding
+	for _, raw := range results {
+		result, _ := raw.(map[string]interface{})
+		outcome, _ := result["result"].(string)
+		if outcome != "fail" && outcome != "warn" && outcome != "error" {
+			continue
+		}
+		v := PolicyViolation{Engine: "kyverno", Result: outcome}
+		v.Policy, _ = result["policy"].(string)
+		v.Rule, _ = result["rule"].(string)
+		v.Severity, _ = result["severity"].(string)
+		v.Message, _ = result["message"].(string)
+
+		resources, _ := result["resources"].([]interface{})
+		if len(resources) == 0 && scope != nil {
+			resources = []interface{}{scope}
+		}
+		for _, r := range resources {
+			ref, _ := r.(map[string]interface{})
+			f := policyFinding{violation: v}
+			f.kind, _ = ref["kind"].(string)
+			f.name, _ = ref["name"].(string)
+			f.namespace, _ = ref["namespace"].(string)
+			if f.namespace == "" {
+				f.namespace = report.GetNamespace()
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// listGatekeeperFindings reads the audit results of every Gatekeeper constraint. Constraints
+// are cluster-scoped and their kinds come from the ConstraintTemplates; installed is false
+// without Gatekeeper, or for users who may not read them.
+func listGatekeeperFindings(ctx context.Context, dynClient dynamic.Interface) (bool, []policyFinding, int, error) {
+	templates, err := dynClient.Resource(getGVR("constrainttemplates")).List(ctx, metav1.ListOptions{})
+	if k8serrors.IsNotFound(err) || k8serrors.IsForbidden(err) {
+		return false, nil, 0, nil
+	}
+	if err != nil {
+		return true, nil, 0, err
+	}
+	var findings []policyFinding
+	unlisted := 0
+	for _, tmpl := range templates.Items {
+		kind, _, _ := unstructured.NestedString(tmpl.Object, "spec", "crd", "spec", "names", "kind")
+		if kind == "" {
+			continue
+		}
+		// Gatekeeper names the constraint resource after the lowercased kind
+		gvr := schema.GroupVersionResource{Group: "constraints.gatekeeper.sh", Version: "v1beta1", Resource: strings.ToLower(kind)}
+		constraints, err := dynClient.Resource(gvr).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			// The CRD of a template that just got created may not be served yet
+			continue
+		}
+		for _, constraint := range constraints.Items {
+			found, more := gatekeeperFindings(constraint)
+			findings = append(findings, found...)
+			unlisted += more
+		}
+	}
+	return true, findings, unlisted, nil
+}
+
+// gatekeeperFindings returns the violations in a constraint's audit status, and how many more
+// the audit found than it lists (--constraint-violations-limit, 20 by default).
+func gatekeeperFindings(constraint unstructured.Unstructured) ([]policyFinding, int) {
+	action, _, _ := unstructured.NestedString(constraint.Object, "spec", "enforcementAction")
+	if action == "" {
+		action = "deny"
+	}
+	violations, _, _ := unstructured.NestedSlice(constraint.Object, "status", "violations")
+	var findings []policyFinding
+	for _, raw := range violations {
+		violation, _ := raw.(map[string]interface{})
+		f := policyFinding{violation: PolicyViolation{Engine: "gatekeeper", Policy: constraint.GetKind() + "/" + constraint.GetName(), Result: action}}
+		f.kind, _ = violation["kind"].(string)
+		f.name, _ = violation["name"].(string)
+		f.namespace, _ = violation["namespace"].(string)
+		f.violation.Message, _ = violation["message"].(string)
+		if a, _ := violation["enforcementAction"].(string); a != "" {
+			f.violation.Result = a
+		}
+		findings = append(findings, f)
+	}
+	total, _, _ := unstructured.NestedInt64(constraint.Object, "status", "totalViolations")
+	if int(total) > len(findings) {
+		return findings, int(total) - len(findings)
+	}
+	return findings, 0
+}
+
+// policyResult builds a PolicyReport result for the mock reports.
+func policyResult(policy, rule, result, severity, message string) interface{} {
+	return map[string]interface{}{"policy": policy, "rule": rule, "result": result, "severity": severity, "message": message}
+}
+
+// mockPolicyReports returns Kyverno per-resource reports of the mock workloads for DEV_MODE.
+func mockPolicyReports() []unstructured.Unstructured {
+	report := func(ns, kind, name string, results ...interface{}) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "wgpolicyk8s.io/v1alpha2",
+			"kind":       "PolicyReport",
+			"metadata":   map[string]interface{}{"name": strings.ToLower(kind) + "-" + name, "namespace": ns},
+			"scope":      map[string]interface{}{"apiVersion": "apps/v1", "kind": kind, "name": name, "namespace": ns},
+			"results":    results,
+		}}
+	}
+	return []unstructured.Unstructured{
+		report("default", "Deployment", "backend-api",
+			policyResult("require-requests-limits", "autogen-validate-resources", "fail", "medium", "validation error: CPU and memory resource requests and limits are required. rule autogen-validate-resources failed at path /spec/template/spec/containers/0/resources/limits/"),
+			policyResult("disallow-latest-tag", "autogen-validate-image-tag", "pass", "medium", "validation rule 'autogen-validate-image-tag' passed.")),
+		report("default", "Deployment", "frontend-web",
+			policyResult("require-probes", "autogen-validate-probes", "warn", "medium", "Liveness and readiness probes are required."),
+			policyResult("require-requests-limits", "autogen-validate-resources", "fail", "medium", "validation error: CPU and memory resource requests and limits are required.")),
+		report("logging", "DaemonSet", "fluentbit-ds",
+			policyResult("disallow-host-path", "autogen-host-path", "fail", "high", "validation error: HostPath volumes are forbidden. The field spec.volumes[*].hostPath must be unset.")),
+		report("database", "StatefulSet", "postgres-primary",
+			policyResult("require-run-as-nonroot", "autogen-run-as-non-root", "fail", "high", "validation error: Running as root is not allowed. Either the field spec.securityContext.runAsNonRoot or all of spec.containers[*].securityContext.runAsNonRoot must be set to true.")),
+	}
+}
+
+// mockConstraints returns Gatekeeper constraints with audit results for DEV_MODE.
+func mockConstraints() []unstructured.Unstructured {
+	violation := func(kind, ns, name, message string) interface{} {
+		return map[string]interface{}{"kind": kind, "namespace": ns, "name": name, "message": message, "enforcementAction": "warn"}
+	}
+	return []unstructured.Unstructured{{Object: map[string]interface{}{
+		"apiVersion": "constraints.gatekeeper.sh/v1beta1",
+		"kind":       "K8sRequiredLabels",
+		"metadata":   map[string]interface{}{"name": "must-have-owner"},
+		"spec":       map[string]interface{}{"enforcementAction": "warn"},
+		"status": map[string]interface{}{
+			"totalViolations": int64(3),
+			"violations": []interface{}{
+				violation("Namespace", "", "messaging", `you must provide labels: {"owner"}`),
+				violation("Deployment", "database", "pgbouncer", `you must provide labels: {"owner"}`),
+			},
+		},
+	}}}
+}
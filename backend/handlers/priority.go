@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"k-view/apierror"
+)
+
+// PreemptedPod is a pod evicted by the scheduler to make room for a higher-priority pod.
+type PreemptedPod struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Message   string `json:"message"`
+	Age       string `json:"age"`
+	Count     int64  `json:"count"`
+}
+
+// UnprioritizedWorkload is a workload whose pod template sets no priorityClassName.
+type UnprioritizedWorkload struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// EffectiveClass is the globalDefault PriorityClass the pods receive instead, if any
+	EffectiveClass string `json:"effectiveClass,omitempty"`
+}
+
+type PriorityReport struct {
+	Window        string                  `json:"window"`
+	DefaultClass  string                  `json:"defaultClass,omitempty"`
+	Preempted     []PreemptedPod          `json:"preempted"`
+	Unprioritized []UnprioritizedWorkload `json:"unprioritized"`
+	ClassesInUse  map[string]int          `json:"classesInUse"` // PriorityClass -> workload count
+}
+
+// GetPriorityInsights lists pods preempted within ?window= (default 24h) and workloads that run
+// without an explicit PriorityClass.
+func (h *DiagnosticsHandler) GetPriorityInsights(c *gin.Context) {
+	window := 24 * time.Hour
+	if w := c.Query("window"); w != "" {
+		d, err := time.ParseDuration(w)
+		if err != nil || d <= 0 {
+			apierror.Write(c, http.StatusBadRequest, "window must be a duration such as 24h")
+			return
+		}
+		window = d
+	}
+	ns := c.Query("namespace")
+	if ns == "-" {
+		ns = ""
+	}
+	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
+		ns = rbacNs.(string)
+	}
+
+	report := PriorityReport{Window: window.String(), Preempted: []PreemptedPod{}, Unprioritized: []UnprioritizedWorkload{}, ClassesInUse: map[string]int{}}
+
+	if h.devMode {
+		report.Preempted = []PreemptedPod{
+			{Namespace: "default", Pod: "report-generator-28461840-x7k2p", Message: "Preempted by pod 3f1c9a2e-... on node worker-02", Age: "2h", Count: 1},
+			{Namespace: "logging", Pod: "log-rotate-28461600-q9w8e", Message: "Preempted by pod 9b7d0c41-... on node worker-01", Age: "6h", Count: 2},
+		}
+		report.Unprioritized = []UnprioritizedWorkload{
+			{Kind: "Deployment", Namespace: "default", Name: "frontend-web"},
+			{Kind: "Deployment", Namespace: "default", Name: "backend-api"},
+			{Kind: "Deployment", Namespace: "monitoring", Name: "grafana"},
+			{Kind: "StatefulSet", Namespace: "messaging", Name: "zookeeper"},
+		}
+		report.ClassesInUse = map[string]int{"system-node-critical": 2, "system-cluster-critical": 1, "high-priority": 3}
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	ctx := c.Request.Context()
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+
+	if classes, err := dynClient.Resource(getGVR("priority-classes")).List(ctx, metav1.ListOptions{}); err == nil {
+		for _, pc := range classes.Items {
+			if def, _, _ := unstructured.NestedBool(pc.Object, "globalDefault"); def {
+				report.DefaultClass = pc.GetName()
+			}
+		}
+	}
+
+	events, err := dynClient.Resource(getGVR("events")).Namespace(ns).List(ctx, metav1.ListOptions{FieldSelector: "reason=Preempted"})
+	if err == nil {
+		cutoff := time.Now().Add(-window)
+		for _, e := range events.Items {
+			t := eventTimestamp(e.Object)
+			if t.Before(cutoff) {
+				continue
+			}
+			p := PreemptedPod{Namespace: e.GetNamespace(), Age: getAge(t), Count: 1}
+			p.Pod, _, _ = unstructured.NestedString(e.Object, "involvedObject", "name")
+			p.Message, _, _ = unstructured.NestedString(e.Object, "message")
+			if count, found, _ := unstructured.NestedInt64(e.Object, "count"); found && count > 0 {
+				p.Count = count
+			}
+			report.Preempted = append(report.Preempted, p)
+		}
+	}
+
+	for _, kind := range []string{"deployments", "statefulsets", "daemonsets"} {
+		list, err := dynClient.Resource(getGVR(kind)).Namespace(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			continue
+		}
+		for _, item := range list.Items {
+			class, _, _ := unstructured.NestedString(item.Object, "spec", "template", "spec", "priorityClassName")
+			if class != "" {
+				report.ClassesInUse[class]++
+				continue
+			}
+			report.Unprioritized = append(report.Unprioritized, UnprioritizedWorkload{Kind: item.GetKind(), Namespace: item.GetNamespace(), Name: item.GetName(), EffectiveClass: report.DefaultClass})
+		}
+	}
+
+	sort.Slice(report.Unprioritized, func(i, j int) bool {
+		a, b := report.Unprioritized[i], report.Unprioritized[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"k-view/apierror"
+)
+
+// ProbeInfo is a flattened view of a single liveness/readiness/startup probe.
+type ProbeInfo struct {
+	Type             string `json:"type"`    // liveness, readiness, startup
+	Handler          string `json:"handler"` // httpGet, tcpSocket, exec, grpc
+	Target           string `json:"target"`
+	InitialDelay     int32  `json:"initialDelaySeconds"`
+	Period           int32  `json:"periodSeconds"`
+	Timeout          int32  `json:"timeoutSeconds"`
+	FailureThreshold int32  `json:"failureThreshold"`
+}
+
+type ContainerProbes struct {
+	Container string      `json:"container"`
+	Probes    []ProbeInfo `json:"probes"`
+	Warnings  []string    `json:"warnings,omitempty"`
+}
+
+type ProbeFailure struct {
+	Pod     string `json:"pod"`
+	Probe   string `json:"probe"`
+	Message string `json:"message"`
+	Count   int64  `json:"count"`
+	Age     string `json:"age"`
+}
+
+type ProbeReport struct {
+	Kind       string            `json:"kind"`
+	Namespace  string            `json:"namespace"`
+	Name       string            `json:"name"`
+	NoProbes   bool              `json:"noProbes"`
+	Containers []ContainerProbes `json:"containers"`
+	Failures   []ProbeFailure    `json:"failures"`
+}
+
+func describeProbe(probeType string, p *corev1.Probe) ProbeInfo {
+	info := ProbeInfo{
+		Type:             probeType,
+		InitialDelay:     p.InitialDelaySeconds,
+		Period:           p.PeriodSeconds,
+		Timeout:          p.TimeoutSeconds,
+		FailureThreshold: p.FailureThreshold,
+	}
+	switch {
+	case p.HTTPGet != nil:
+		info.Handler = "httpGet"
+		info.Target = fmt.Sprintf("%s:%s", p.HTTPGet.Path, p.HTTPGet.Port.String())
+	case p.TCPSocket != nil:
+		info.Handler = "tcpSocket"
+		info.Target = p.TCPSocket.Port.String()
+	case p.Exec != nil:
+		info.Handler = "exec"
+		info.Target = strings.Join(p.Exec.Command, " ")
+	case p.GRPC != nil:
+		info.Handler = "grpc"
+		info.Target = fmt.Sprintf("%d", p.GRPC.Port)
+	}
+	return info
+}
+
+// summarizeProbes lists the probes of every regular container and flags missing ones.
+// It returns true as the second value if no container has any probe configured.
+func summarizeProbes(spec *corev1.PodSpec) ([]ContainerProbes, bool) {
+	var result []ContainerProbes
+	noProbes := true
+	for _, c := range spec.Containers {
+		cp := ContainerProbes{Container: c.Name, Probes: []ProbeInfo{}}
+		if c.LivenessProbe != nil {
+			cp.Probes = append(cp.Probes, describeProbe("liveness", c.LivenessProbe))
+		} else {
+			cp.Warnings = append(cp.Warnings, "no liveness probe: hung processes will not be restarted")
+		}
+		if c.ReadinessProbe != nil {
+			cp.Probes = append(cp.Probes, describeProbe("readiness", c.ReadinessProbe))
+		} else {
+			cp.Warnings = append(cp.Warnings, "no readiness probe: traffic is sent before the container is ready")
+		}
+		if c.StartupProbe != nil {
+			cp.Probes = append(cp.Probes, describeProbe("startup", c.StartupProbe))
+		}
+		if len(cp.Probes) > 0 {
+			noProbes = false
+		}
+		result = append(result, cp)
+	}
+	return result, noProbes
+}
+
+// probeTypeFromMessage extracts the probe type from kubelet "Unhealthy" event messages
+// such as "Readiness probe failed: HTTP probe failed with statuscode: 503".
+func probeTypeFromMessage(msg string) string {
+	lower := strings.ToLower(msg)
+	for _, t := range []string{"liveness", "readiness", "startup"} {
+		if strings.HasPrefix(lower, t) {
+			return t
+		}
+	}
+	return "unknown"
+}
+
+// GetProbes summarizes the probes of a workload and correlates recent probe-failure events of its pods.
+func (h *ResourceHandler) GetProbes(c *gin.Context) {
+	kind := strings.ToLower(c.Param("kind"))
+	name := c.Param("name")
+	ns := c.Param("namespace")
+
+	// Apply RBAC namespace restriction
+	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
+		if ns != rbacNs.(string) {
+			apierror.Write(c, http.StatusForbidden, "access denied to namespace "+ns)
+			return
+		}
+	}
+
+	if h.devMode {
+		c.JSON(http.StatusOK, ProbeReport{
+			Kind:      kind,
+			Namespace: ns,
+			Name:      name,
+			Containers: []ContainerProbes{
+				{
+					Container: "main",
+					Probes: []ProbeInfo{
+						{Type: "liveness", Handler: "httpGet", Target: "/healthz:8080", InitialDelay: 10, Period: 10, Timeout: 1, FailureThreshold: 3},
+						{Type: "readiness", Handler: "httpGet", Target: "/ready:8080", Period: 5, Timeout: 1, FailureThreshold: 3},
+					},
+				},
+			},
+			Failures: []ProbeFailure{
+				{Pod: name + "-5d8f7b", Probe: "readiness", Message: "Readiness probe failed: HTTP probe failed with statuscode: 503", Count: 4, Age: "12m"},
+			},
+		})
+		return
+	}
+
+	dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+
+	item, err := dynClient.Resource(getGVR(kind)).Namespace(ns).Get(c.Request.Context(), name, metav1.GetOptions{})
+	if err != nil {
+		apierror.Fail(c, "Failed to get resource", err)
+		return
+	}
+
+	spec, err := podSpecFromObject(item)
+	if err != nil {
+		apierror.Write(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	containers, noProbes := summarizeProbes(spec)
+	report := ProbeReport{
+		Kind:       kind,
+		Namespace:  ns,
+		Name:       name,
+		NoProbes:   noProbes,
+		Containers: containers,
+		Failures:   []ProbeFailure{},
+	}
+
+	// Probe failures are reported by the kubelet as "Unhealthy" events on the pod.
+	// Pods of a workload are those it owns, directly or through its ReplicaSets or Jobs.
+	pods := map[string]bool{name: true}
+	if item.GetKind() != "Pod" {
+		pods, err = workloadPods(c.Request.Context(), dynClient, item)
+		if err != nil {
+			apierror.Fail(c, "Failed to list pods", err)
+			return
+		}
+	}
+	eventsGVR := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "events"}
+	eventList, err := dynClient.Resource(eventsGVR).Namespace(ns).List(c.Request.Context(), metav1.ListOptions{
+		FieldSelector: "reason=Unhealthy,involvedObject.kind=Pod",
+	})
+	if err == nil {
+		for _, e := range eventList.Items {
+			podName, _, _ := unstructured.NestedString(e.Object, "involvedObject", "name")
+			if !pods[podName] {
+				continue
+			}
+			message, _, _ := unstructured.NestedString(e.Object, "message")
+			count, _, _ := unstructured.NestedInt64(e.Object, "count")
+			report.Failures = append(report.Failures, ProbeFailure{
+				Pod:     podName,
+				Probe:   probeTypeFromMessage(message),
+				Message: message,
+				Count:   count,
+				Age:     getAge(eventTimestamp(e.Object)),
+			})
+		}
+		sort.SliceStable(report.Failures, func(i, j int) bool {
+			return report.Failures[i].Count > report.Failures[j].Count
+		})
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// workloadPods returns the names of the pods a workload owns, following owner references
+// through the ReplicaSets of a Deployment and the Jobs of a CronJob. Pods are listed with the
+// workload's selector where it has one.
+func workloadPods(ctx context.Context, dynClient dynamic.Interface, workload *unstructured.Unstructured) (map[string]bool, error) {
+	ns := workload.GetNamespace()
+	owners := map[types.UID]bool{workload.GetUID(): true}
+	intermediate := map[string]string{"Deployment": "replicasets", "CronJob": "jobs"}[workload.GetKind()]
+	if intermediate != "" {
+		list, err := dynClient.Resource(getGVR(intermediate)).Namespace(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range list.Items {
+			if ownedBy(&o, owners) {
+				owners[o.GetUID()] = true
+			}
+		}
+	}
+
+	opts := metav1.ListOptions{}
+	if raw, found, _ := unstructured.NestedMap(workload.Object, "spec", "selector"); found && workload.GetKind() != "CronJob" {
+		var ls metav1.LabelSelector
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &ls); err == nil {
+			if selector, err := metav1.LabelSelectorAsSelector(&ls); err == nil {
+				opts.LabelSelector = selector.String()
+			}
+		}
+	}
+	list, err := dynClient.Resource(getGVR("pods")).Namespace(ns).List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	pods := map[string]bool{}
+	for _, p := range list.Items {
+		if ownedBy(&p, owners) {
+			pods[p.GetName()] = true
+		}
+	}
+	return pods, nil
+}
+
+// ownedBy reports whether one of obj's owner references points at owners.
+func ownedBy(obj *unstructured.Unstructured, owners map[types.UID]bool) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if owners[ref.UID] {
+			return true
+		}
+	}
+	return false
+}
+
+// ListUnprobedWorkloads flags Deployments, StatefulSets and DaemonSets whose containers have no probes at all.
+func (h *ResourceHandler) ListUnprobedWorkloads(c *gin.Context) {
+	ns := c.Query("namespace")
+	if ns == "-" {
+		ns = ""
+	}
+
+	// Apply RBAC namespace restriction
+	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
+		ns = rbacNs.(string)
+	}
+
+	if h.devMode {
+		items := filter([]ResourceItem{
+			{Name: "cache-redis", Namespace: "default", Age: "30d", Status: "NoProbes", Extra: ex("kind", "Deployment")},
+			{Name: "zookeeper", Namespace: "messaging", Age: "20d", Status: "NoProbes", Extra: ex("kind", "StatefulSet")},
+		}, ns)
+		c.JSON(http.StatusOK, items)
+		return
+	}
+
+	dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+
+	items := []ResourceItem{}
+	for _, kind := range []string{"deployments", "statefulsets", "daemonsets"} {
+		list, err := dynClient.Resource(getGVR(kind)).Namespace(ns).List(c.Request.Context(), metav1.ListOptions{})
+		if err != nil {
+			continue // Kinds the user cannot list are skipped
+		}
+		for i := range list.Items {
+			item := &list.Items[i]
+			spec, err := podSpecFromObject(item)
+			if err != nil {
+				continue
+			}
+			if _, noProbes := summarizeProbes(spec); noProbes {
+				items = append(items, ResourceItem{
+					Name:              item.GetName(),
+					Namespace:         item.GetNamespace(),
+					Age:               getAge(item.GetCreationTimestamp().Time),
+					CreationTimestamp: timestamp(item.GetCreationTimestamp().Time),
+					Status:            "NoProbes",
+					Extra:             ex("kind", item.GetKind()),
+				})
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, items)
+}
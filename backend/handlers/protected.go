@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"k-view/apierror"
+	"k-view/policy"
+)
+
+// rejectProtected answers 403 and returns true when the policy protects the target from changes.
+func (h *ResourceHandler) rejectProtected(c *gin.Context, kind, ns, name string) bool {
+	return rejectProtected(c, h.policies, kind, ns, name)
+}
+
+func rejectProtected(c *gin.Context, policies *policy.PolicyConfig, kind, ns, name string) bool {
+	if !policies.IsProtected(kind, policyNamespace(kind, ns, name)) {
+		return false
+	}
+	target := kind
+	if ns != "" {
+		target += " in namespace " + ns
+	}
+	apierror.Write(c, http.StatusForbidden, target+" is protected and cannot be modified through K-View")
+	return true
+}
+
+// kubectlKinds maps kubectl short names and singular forms to the kinds used by the policy.
+var kubectlKinds = map[string]string{
+	"po": "pods", "pod": "pods",
+	"deploy": "deployments", "deployment": "deployments",
+	"rs": "replicasets", "replicaset": "replicasets",
+	"sts": "statefulsets", "statefulset": "statefulsets",
+	"ds": "daemonsets", "daemonset": "daemonsets",
+	"job": "jobs", "cj": "cronjobs", "cronjob": "cronjobs",
+	"svc": "services", "service": "services",
+	"ing": "ingresses", "ingress": "ingresses",
+	"cm": "configmaps", "configmap": "configmaps",
+	"secret": "secrets",
+	"ns":     "namespaces", "namespace": "namespaces",
+	"no": "nodes", "node": "nodes",
+	"pv": "pvs", "persistentvolume": "pvs", "persistentvolumes": "pvs",
+	"pvc": "pvcs", "persistentvolumeclaim": "pvcs", "persistentvolumeclaims": "pvcs",
+	"sc": "storage-classes", "storageclass": "storage-classes", "storageclasses": "storage-classes",
+	"sa": "serviceaccounts", "serviceaccount": "serviceaccounts",
+	"crd": "crds", "customresourcedefinition": "crds", "customresourcedefinitions": "crds",
+	"hpa": "hpas", "horizontalpodautoscaler": "hpas", "horizontalpodautoscalers": "hpas",
+	"pdb": "pdbs", "poddisruptionbudget": "pdbs", "poddisruptionbudgets": "pdbs",
+	"netpol": "networkpolicies", "networkpolicy": "networkpolicies",
+	"role": "roles", "rolebinding": "role-bindings", "rolebindings": "role-bindings",
+	"clusterrole": "cluster-roles", "clusterroles": "cluster-roles",
+	"clusterrolebinding": "cluster-role-bindings", "clusterrolebindings": "cluster-role-bindings",
+}
+
+// manifestKind maps the kind of a manifest object (Deployment, PriorityClass) to the kind
+// used by the policy.
+func manifestKind(kind string) string {
+	kind = strings.ToLower(kind)
+	if alias, ok := kubectlKinds[kind]; ok {
+		return alias
+	}
+	switch {
+	case strings.HasSuffix(kind, "s"):
+		return kind + "es"
+	case strings.HasSuffix(kind, "y"):
+		return strings.TrimSuffix(kind, "y") + "ies"
+	}
+	return kind + "s"
+}
+
+// kubectlValueFlags are flags whose value is a separate argument.
+var kubectlValueFlags = map[string]bool{
+	"-n": true, "--namespace": true, "-f": true, "--filename": true, "-l": true, "--selector": true,
+	"-o": true, "--output": true, "-c": true, "--container": true, "--context": true,
+	"--kubeconfig": true, "--cluster": true, "--user": true, "--as": true, "--as-group": true,
+	"--token": true, "-s": true, "--server": true, "--request-timeout": true, "-v": true, "--v": true,
+}
+
+// splitKubectl separates the subcommand of a kubectl command line from its arguments. Global
+// flags given before the subcommand ("kubectl -n kube-system delete ...") are kept in args.
+func splitKubectl(parts []string) (sub string, args []string) {
+	for i := 1; i < len(parts); i++ {
+		a := parts[i]
+		if strings.HasPrefix(a, "-") {
+			args = append(args, a)
+			if kubectlValueFlags[a] && i+1 < len(parts) {
+				i++
+				args = append(args, parts[i])
+			}
+			continue
+		}
+		return a, append(args, parts[i+1:]...)
+	}
+	return "", args
+}
+
+// kubectlNamespace returns the namespace selected by -n/--namespace in any of its spellings
+// ("-n x", "-n=x", "-nx", "--namespace=x"), and whether -A/--all-namespaces was given. ok is
+// false when a namespace flag has no usable value.
+func kubectlNamespace(args []string) (ns string, all, ok bool) {
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "-A" || a == "--all-namespaces" || a == "--all-namespaces=true":
+			all = true
+		case a == "-n" || a == "--namespace":
+			if i+1 >= len(args) {
+				return "", all, false
+			}
+			i++
+			ns = args[i]
+		case strings.HasPrefix(a, "--namespace="):
+			ns = strings.TrimPrefix(a, "--namespace=")
+		case strings.HasPrefix(a, "-n") && !strings.HasPrefix(a, "--"):
+			ns = strings.TrimPrefix(strings.TrimPrefix(a, "-n"), "=")
+		default:
+			continue
+		}
+		if !all && ns == "" {
+			return "", false, false
+		}
+	}
+	return ns, all, true
+}
+
+// kubectlTarget works out the kind and namespace a mutating kubectl command acts on. kind is
+// empty when it comes from a manifest (apply -f), in which case only the namespace is known.
+// all is true when the command spans every namespace, and ok is false when the namespace
+// flags cannot be read.
+func kubectlTarget(sub string, args []string) (kind, ns string, all, ok bool) {
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		if strings.HasPrefix(args[i], "-") {
+			if kubectlValueFlags[args[i]] {
+				i++
+			}
+			continue
+		}
+		positional = append(positional, args[i])
+	}
+	// "rollout restart deploy/x" and "set image deploy/x" name the resource after the action
+	if (sub == "rollout" || sub == "set") && len(positional) > 0 {
+		positional = positional[1:]
+	}
+
+	var name string
+	switch {
+	case sub == "drain" || sub == "cordon" || sub == "uncordon" || sub == "taint":
+		kind = "nodes"
+	case len(positional) > 0:
+		kind = positional[0]
+		if i := strings.Index(kind, "/"); i >= 0 {
+			kind, name = kind[:i], kind[i+1:]
+		} else if len(positional) > 1 {
+			name = positional[1]
+		}
+		// For "kind1,kind2" only the first kind is checked; the namespace check covers the rest
+		if i := strings.Index(kind, ","); i >= 0 {
+			kind = kind[:i]
+		}
+		if i := strings.Index(kind, "."); i >= 0 {
+			kind = kind[:i] // deployments.apps
+		}
+		kind = strings.ToLower(kind)
+		if alias, ok := kubectlKinds[kind]; ok {
+			kind = alias
+		}
+	}
+
+	ns, all, ok = kubectlNamespace(args)
+	if !ok {
+		return kind, "", false, false
+	}
+	if ns == "" {
+		ns = "default"
+	}
+	if isClusterScoped(kind) {
+		ns, all = "", false
+	}
+	return kind, policyNamespace(kind, ns, name), all, true
+}
+
+// protectedCommand returns an error message when a console command would modify a protected
+// namespace or kind, and "" otherwise.
+func (h *ConsoleHandler) protectedCommand(cmd string) string {
+	sub, args := splitKubectl(strings.Fields(cmd))
+	if sub == "" || readOnlyKubectl[sub] || sub == "auth" || sub == "config" {
+		return ""
+	}
+	kind, ns, all, ok := kubectlTarget(sub, args)
+	if !ok {
+		return fmt.Sprintf("Error: cannot determine the namespace of this %s command; K-View refuses it", sub)
+	}
+	if all && h.policies.ProtectsNamespaces() {
+		return fmt.Sprintf("Error: K-View policy protects some namespaces; %s across all namespaces is not allowed", sub)
+	}
+	if !h.policies.IsProtected(kind, ns) {
+		return ""
+	}
+	if ns != "" && (kind == "" || !h.policies.IsProtected(kind, "")) {
+		return fmt.Sprintf("Error: namespace %q is protected by K-View policy; %s is not allowed", ns, sub)
+	}
+	return fmt.Sprintf("Error: %s are protected by K-View policy; %s is not allowed", kind, sub)
+}
+
+// approvalCommand returns an error message when a console delete targets something the policy
+// sends through the approval queue, and "" otherwise. The console cannot queue a command, so
+// such deletes have to be made from the UI or API.
+func (h *ConsoleHandler) approvalCommand(cmd, stdin string) string {
+	sub, args := splitKubectl(strings.Fields(cmd))
+	if sub != "delete" || !h.policies.ApprovalsEnabled() {
+		return ""
+	}
+	const refused = "Error: deleting %s requires approval by a second admin under K-View policy; delete it from the UI to queue the request"
+	kind, ns, all, ok := kubectlTarget(sub, args)
+	if !ok {
+		return fmt.Sprintf("Error: cannot determine the namespace of this %s command; K-View refuses it", sub)
+	}
+	if all && h.policies.ApprovesNamespaces() {
+		return fmt.Sprintf(refused, "across all namespaces")
+	}
+	if kind == "" && stdin == "" {
+		// The objects come from a file K-View cannot inspect
+		return fmt.Sprintf(refused, "from a manifest file")
+	}
+	if kind != "" && h.policies.RequiresApproval(kind, ns, apiResource) {
+		return fmt.Sprintf(refused, kind)
+	}
+	if stdin == "" {
+		return ""
+	}
+	objects, err := parseManifests(stdin)
+	if err != nil {
+		return fmt.Sprintf(refused, "unreadable manifests")
+	}
+	cmdNs, _, _ := kubectlNamespace(args)
+	for _, obj := range objects {
+		kind := manifestKind(obj.Kind)
+		objNs := obj.Metadata.Namespace
+		if objNs == "" {
+			objNs = cmdNs
+		}
+		if objNs == "" {
+			objNs = "default"
+		}
+		if isClusterScoped(kind) {
+			objNs = ""
+		}
+		if h.policies.RequiresApproval(kind, policyNamespace(kind, objNs, obj.Metadata.Name), apiResource) {
+			return fmt.Sprintf(refused, obj.Kind+" "+obj.Metadata.Name)
+		}
+	}
+	return ""
+}
+
+// protectedManifests returns an error message when the manifests piped to a console command
+// create or change objects of a protected namespace or kind, and "" otherwise.
+func (h *ConsoleHandler) protectedManifests(cmd, stdin string) string {
+	sub, args := splitKubectl(strings.Fields(cmd))
+	if stdin == "" || sub == "" || readOnlyKubectl[sub] {
+		return ""
+	}
+	objects, err := parseManifests(stdin)
+	if err != nil {
+		// kubectl reports the parse error itself
+		return ""
+	}
+	cmdNs, _, ok := kubectlNamespace(args)
+	if !ok {
+		return fmt.Sprintf("Error: cannot determine the namespace of this %s command; K-View refuses it", sub)
+	}
+	for _, obj := range objects {
+		kind := manifestKind(obj.Kind)
+		ns := obj.Metadata.Namespace
+		if ns == "" {
+			ns = cmdNs
+		}
+		if ns == "" {
+			ns = "default"
+		}
+		if isClusterScoped(kind) {
+			ns = ""
+		}
+		ns = policyNamespace(kind, ns, obj.Metadata.Name)
+		if !h.policies.IsProtected(kind, ns) {
+			continue
+		}
+		if ns != "" && !h.policies.IsProtected(kind, "") {
+			return fmt.Sprintf("Error: namespace %q of %s %s is protected by K-View policy; %s is not allowed", ns, obj.Kind, obj.Metadata.Name, sub)
+		}
+		return fmt.Sprintf("Error: %s are protected by K-View policy; %s is not allowed", kind, sub)
+	}
+	return ""
+}
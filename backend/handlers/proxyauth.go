@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ProxyAuth trusts the identity headers of an authenticating reverse proxy such as
+// oauth2-proxy or Pomerium, so users skip the built-in login. The headers are only honoured on
+// connections from the trusted networks: anyone who can reach K-View directly could set them.
+type ProxyAuth struct {
+	UserHeader   string
+	GroupsHeader string
+	LogoutURL    string // Where the browser goes to end the proxy's session, e.g. /oauth2/sign_out
+	trusted      []*net.IPNet
+}
+
+// proxyAuthFromEnv reads the KVIEW_AUTH_PROXY_* environment variables. It returns nil when
+// proxy authentication is not enabled.
+func proxyAuthFromEnv() (*ProxyAuth, error) {
+	if os.Getenv("KVIEW_AUTH_PROXY_ENABLED") != "true" {
+		return nil, nil
+	}
+	p := &ProxyAuth{
+		UserHeader:   os.Getenv("KVIEW_AUTH_PROXY_USER_HEADER"),
+		GroupsHeader: os.Getenv("KVIEW_AUTH_PROXY_GROUPS_HEADER"),
+		LogoutURL:    os.Getenv("KVIEW_AUTH_PROXY_LOGOUT_URL"),
+	}
+	if p.UserHeader == "" {
+		p.UserHeader = "X-Forwarded-User"
+	}
+	if p.GroupsHeader == "" {
+		p.GroupsHeader = "X-Forwarded-Groups"
+	}
+	for _, s := range strings.Split(os.Getenv("KVIEW_AUTH_PROXY_TRUSTED_CIDRS"), ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			// A bare address trusts that host only
+			if ip := net.ParseIP(s); ip != nil && ip.To4() != nil {
+				s += "/32"
+			} else {
+				s += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy CIDR %q: %v", s, err)
+		}
+		p.trusted = append(p.trusted, ipNet)
+	}
+	if len(p.trusted) == 0 {
+		return nil, fmt.Errorf("KVIEW_AUTH_PROXY_TRUSTED_CIDRS is required")
+	}
+	return p, nil
+}
+
+// trustedPeer reports whether the connection comes from a trusted proxy. The TCP peer address
+// is used rather than X-Forwarded-For, which the client controls.
+func (p *ProxyAuth) trustedPeer(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range p.trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// identity returns the user and groups asserted by the proxy, if the request carries them
+// and comes from a trusted proxy. Groups are comma-separated, as oauth2-proxy sends them.
+func (p *ProxyAuth) identity(r *http.Request) (string, []string, bool) {
+	if p == nil {
+		return "", nil, false
+	}
+	user := strings.TrimSpace(r.Header.Get(p.UserHeader))
+	if user == "" {
+		return "", nil, false
+	}
+	if !p.trustedPeer(r) {
+		log.Printf("Ignoring %s header for %q from untrusted address %s", p.UserHeader, user, r.RemoteAddr)
+		return "", nil, false
+	}
+	var groups []string
+	for _, value := range r.Header.Values(p.GroupsHeader) {
+		for _, g := range strings.Split(value, ",") {
+			if g = strings.TrimSpace(g); g != "" {
+				groups = append(groups, g)
+			}
+		}
+	}
+	return user, groups, true
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/jsonpath"
+
+	"k-view/apierror"
+)
+
+// Query limits: rows returned by default and at most.
+const (
+	defaultQueryLimit = 500
+	maxQueryLimit     = 5000
+)
+
+// QueryRequest is an ad-hoc query over the objects of a kind. Where is a JSONPath filter
+// predicate an object must match, as inside [?( )]: @.spec.replicas > 1, or
+// @.metadata.labels.team == "payments". Columns are JSONPaths projected from each match, in
+// the custom-columns form of the columns configuration.
+type QueryRequest struct {
+	Kind      string         `json:"kind" binding:"required"`
+	Namespace string         `json:"namespace"`
+	Language  string         `json:"language"` // jsonpath, the default; cel is not supported
+	Where     string         `json:"where"`
+	Columns   []CustomColumn `json:"columns"`
+	Limit     int            `json:"limit"` // Default 500, at most 5000
+}
+
+// QueryRow is an object matching a query, with its projected columns.
+type QueryRow struct {
+	Namespace string            `json:"namespace,omitempty"`
+	Name      string            `json:"name"`
+	Values    map[string]string `json:"values,omitempty"`
+}
+
+// QueryResult is the rows of a query; Scanned objects were evaluated, Matched of them passed
+// the filter and the first Limit of those are returned.
+type QueryResult struct {
+	Kind      string     `json:"kind"`
+	Scanned   int        `json:"scanned"`
+	Matched   int        `json:"matched"`
+	Truncated bool       `json:"truncated,omitempty"`
+	Rows      []QueryRow `json:"rows"`
+}
+
+// Query evaluates a JSONPath filter and projection over the objects of a kind server-side,
+// as a lightweight ad-hoc query tool (POST /api/query). Objects are listed live with the
+// user's permissions, in one namespace or all; namespace-pinned users only query theirs.
+func (h *ResourceHandler) Query(c *gin.Context) {
+	var req QueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	switch strings.ToLower(req.Language) {
+	case "", "jsonpath":
+	case "cel":
+		apierror.Write(c, http.StatusNotImplemented, "CEL expressions are not supported; use a JSONPath filter such as @.spec.replicas > 1")
+		return
+	default:
+		apierror.Write(c, http.StatusBadRequest, "language must be jsonpath")
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultQueryLimit
+	}
+	if req.Limit > maxQueryLimit {
+		req.Limit = maxQueryLimit
+	}
+
+	var where *jsonpath.JSONPath
+	if strings.TrimSpace(req.Where) != "" {
+		where = jsonpath.New("where").AllowMissingKeys(true)
+		if err := where.Parse("{[?(" + req.Where + ")]}"); err != nil {
+			apierror.Write(c, http.StatusBadRequest, "invalid where: "+err.Error())
+			return
+		}
+	}
+	for i := range req.Columns {
+		col := &req.Columns[i]
+		if col.Name == "" {
+			apierror.Write(c, http.StatusBadRequest, "every column needs a name")
+			return
+		}
+		col.path = jsonpath.New(col.Name).AllowMissingKeys(true)
+		if err := col.path.Parse(relaxedJSONPath(col.JSONPath)); err != nil {
+			apierror.Write(c, http.StatusBadRequest, "invalid jsonPath for column "+col.Name+": "+err.Error())
+			return
+		}
+	}
+
+	kind := strings.ToLower(req.Kind)
+	ns := req.Namespace
+	if rbacNs := rbacNamespace(c); rbacNs != "" {
+		ns = rbacNs
+	}
+	objects, err := h.queryObjects(c.Request.Context(), kind, ns)
+	if err != nil {
+		apierror.Fail(c, "Failed to list resources", err)
+		return
+	}
+
+	result := QueryResult{Kind: kind, Scanned: len(objects), Rows: []QueryRow{}}
+	for _, obj := range objects {
+		if where != nil {
+			// Filters apply to lists, so the object is evaluated as a list of one
+			matches, err := where.FindResults([]interface{}{obj})
+			if err != nil || len(matches) == 0 || len(matches[0]) == 0 {
+				continue
+			}
+		}
+		result.Matched++
+		if len(result.Rows) == req.Limit {
+			result.Truncated = true
+			continue
+		}
+		row := QueryRow{}
+		if meta, ok := obj["metadata"].(map[string]interface{}); ok {
+			row.Name, _ = meta["name"].(string)
+			row.Namespace, _ = meta["namespace"].(string)
+		}
+		if len(req.Columns) > 0 {
+			row.Values = map[string]string{}
+			for _, col := range req.Columns {
+				var buf bytes.Buffer
+				if err := col.path.Execute(&buf, obj); err == nil {
+					row.Values[col.Name] = buf.String()
+				}
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	sort.SliceStable(result.Rows, func(i, j int) bool {
+		if result.Rows[i].Namespace != result.Rows[j].Namespace {
+			return result.Rows[i].Namespace < result.Rows[j].Namespace
+		}
+		return result.Rows[i].Name < result.Rows[j].Name
+	})
+	c.JSON(http.StatusOK, result)
+}
+
+// queryObjects lists the objects of a kind in ns, or in all namespaces when ns is empty. In
+// DEV_MODE pods and nodes are the mock client's; other kinds are built from the mock list
+// rows, with their status as status.phase.
+func (h *ResourceHandler) queryObjects(ctx context.Context, kind, ns string) ([]map[string]interface{}, error) {
+	if h.devMode {
+		var typed []interface{}
+		switch kind {
+		case "pods":
+			pods, err := h.k8sClient.ListPods(ctx, ns)
+			if err != nil {
+				return nil, err
+			}
+			for i := range pods {
+				pods[i].APIVersion, pods[i].Kind = "v1", "Pod"
+				typed = append(typed, &pods[i])
+			}
+		case "nodes":
+			nodes, err := h.k8sClient.ListNodes(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for i := range nodes {
+				nodes[i].APIVersion, nodes[i].Kind = "v1", "Node"
+				typed = append(typed, &nodes[i])
+			}
+		default:
+			var objects []map[string]interface{}
+			for _, item := range mockResourceList(kind, ns) {
+				meta := map[string]interface{}{"name": item.Name, "creationTimestamp": item.CreationTimestamp}
+				if item.Namespace != "" {
+					meta["namespace"] = item.Namespace
+				}
+				extra := map[string]interface{}{}
+				for k, v := range item.Extra {
+					extra[k] = v
+				}
+				objects = append(objects, map[string]interface{}{"metadata": meta, "status": map[string]interface{}{"phase": item.Status}, "extra": extra})
+			}
+			return objects, nil
+		}
+		objects := make([]map[string]interface{}, 0, len(typed))
+		for _, t := range typed {
+			obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(t)
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, obj)
+		}
+		return objects, nil
+	}
+
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gvr, clusterScoped := h.columns.gvr(kind)
+	var resInterface dynamic.ResourceInterface = dynClient.Resource(gvr)
+	if ns != "" && !clusterScoped {
+		resInterface = dynClient.Resource(gvr).Namespace(ns)
+	}
+	list, err := resInterface.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]map[string]interface{}, 0, len(list.Items))
+	for _, item := range list.Items {
+		objects = append(objects, item.Object)
+	}
+	return objects, nil
+}
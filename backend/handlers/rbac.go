@@ -4,17 +4,24 @@ import (
 	"net/http"
 	"strings"
 
+	"k-view/k8s"
 	"k-view/rbac"
 
 	"github.com/gin-gonic/gin"
 )
 
 type RBACHandler struct {
-	config *rbac.RBACConfig
+	config    *rbac.RBACConfig
+	devMode   bool
+	k8sClient k8s.KubernetesProvider
+	teams     *TeamHandler
+
+	oidcIssuer   string // Issuer and client ID written into OIDC kubeconfigs
+	oidcClientID string
 }
 
-func NewRBACHandler(config *rbac.RBACConfig) *RBACHandler {
-	return &RBACHandler{config: config}
+func NewRBACHandler(config *rbac.RBACConfig, devMode bool, k8sClient k8s.KubernetesProvider) *RBACHandler {
+	return &RBACHandler{config: config, devMode: devMode, k8sClient: k8sClient}
 }
 
 type Rule struct {
@@ -28,6 +35,7 @@ type StatusResponse struct {
 	Namespace   string            `json:"namespace"`
 	Rules       []Rule            `json:"rules"`
 	Assignments []rbac.Assignment `json:"assignments"`
+	Teams       []rbac.Team       `json:"teams"`
 }
 
 // GetStatus returns the RBAC assignments and the current user's computed permissions.
@@ -68,6 +76,7 @@ func (h *RBACHandler) GetStatus(c *gin.Context) {
 		Role:        role.(string),
 		Namespace:   namespace,
 		Rules:       rules,
-		Assignments: h.config.Assignments,
+		Assignments: append(append([]rbac.Assignment{}, h.config.Assignments...), h.config.Granted()...),
+		Teams:       h.config.Teams(),
 	})
 }
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"k-view/apierror"
+)
+
+// Grant is a single subject-to-role binding resolved to its rules.
+type Grant struct {
+	Subject   rbacv1.Subject      `json:"subject"`
+	Namespace string              `json:"namespace"` // Empty for cluster-wide grants
+	RoleRef   string              `json:"roleRef"`   // Role/name or ClusterRole/name
+	Binding   string              `json:"binding"`   // RoleBinding/ns/name or ClusterRoleBinding/name
+	Rules     []rbacv1.PolicyRule `json:"rules"`
+}
+
+// rbacModel holds every Role, ClusterRole and binding of the cluster.
+type rbacModel struct {
+	roles               map[string]rbacv1.Role // keyed by namespace/name
+	clusterRoles        map[string]rbacv1.ClusterRole
+	roleBindings        []rbacv1.RoleBinding
+	clusterRoleBindings []rbacv1.ClusterRoleBinding
+}
+
+// loadRBACModel fetches all RBAC objects through the dynamic client (or mock data in dev mode).
+func (h *RBACHandler) loadRBACModel(ctx context.Context) (*rbacModel, error) {
+	if h.devMode {
+		return mockRBACModel(), nil
+	}
+
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dynamic client: %v", err)
+	}
+
+	model := &rbacModel{roles: map[string]rbacv1.Role{}, clusterRoles: map[string]rbacv1.ClusterRole{}}
+	lists := []struct {
+		kind  string
+		store func(obj map[string]interface{}) error
+	}{
+		{"roles", func(obj map[string]interface{}) error {
+			var r rbacv1.Role
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &r); err != nil {
+				return err
+			}
+			model.roles[r.Namespace+"/"+r.Name] = r
+			return nil
+		}},
+		{"cluster-roles", func(obj map[string]interface{}) error {
+			var r rbacv1.ClusterRole
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &r); err != nil {
+				return err
+			}
+			model.clusterRoles[r.Name] = r
+			return nil
+		}},
+		{"role-bindings", func(obj map[string]interface{}) error {
+			var b rbacv1.RoleBinding
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &b); err != nil {
+				return err
+			}
+			model.roleBindings = append(model.roleBindings, b)
+			return nil
+		}},
+		{"cluster-role-bindings", func(obj map[string]interface{}) error {
+			var b rbacv1.ClusterRoleBinding
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &b); err != nil {
+				return err
+			}
+			model.clusterRoleBindings = append(model.clusterRoleBindings, b)
+			return nil
+		}},
+	}
+	for _, l := range lists {
+		list, err := dynClient.Resource(getGVR(l.kind)).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %v", l.kind, err)
+		}
+		for _, item := range list.Items {
+			if err := l.store(item.Object); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return model, nil
+}
+
+// resolveRef returns the rules referenced by a binding. RoleBindings may reference a
+// namespaced Role or a ClusterRole, which is then granted only in the binding's namespace.
+func (m *rbacModel) resolveRef(namespace string, ref rbacv1.RoleRef) []rbacv1.PolicyRule {
+	if ref.Kind == "Role" {
+		return m.roles[namespace+"/"+ref.Name].Rules
+	}
+	return m.clusterRoles[ref.Name].Rules
+}
+
+// grants flattens every binding into one Grant per subject.
+func (m *rbacModel) grants() []Grant {
+	var grants []Grant
+	for _, b := range m.clusterRoleBindings {
+		rules := m.resolveRef("", b.RoleRef)
+		for _, s := range b.Subjects {
+			grants = append(grants, Grant{Subject: s, RoleRef: b.RoleRef.Kind + "/" + b.RoleRef.Name, Binding: "ClusterRoleBinding/" + b.Name, Rules: rules})
+		}
+	}
+	for _, b := range m.roleBindings {
+		rules := m.resolveRef(b.Namespace, b.RoleRef)
+		for _, s := range b.Subjects {
+			grants = append(grants, Grant{Subject: s, Namespace: b.Namespace, RoleRef: b.RoleRef.Kind + "/" + b.RoleRef.Name, Binding: "RoleBinding/" + b.Namespace + "/" + b.Name, Rules: rules})
+		}
+	}
+	return grants
+}
+
+func matchesAny(values []string, want string) bool {
+	for _, v := range values {
+		if v == "*" || v == want {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleAllows reports whether a policy rule permits verb on resource (optionally "resource/subresource").
+func ruleAllows(rule rbacv1.PolicyRule, verb, apiGroup, resource string) bool {
+	if len(rule.NonResourceURLs) > 0 && len(rule.Resources) == 0 {
+		return false
+	}
+	return matchesAny(rule.Verbs, verb) && matchesAny(rule.APIGroups, apiGroup) && matchesAny(rule.Resources, resource)
+}
+
+// appliesIn reports whether a grant is effective in the namespace (an empty namespace matches every grant).
+func (g Grant) appliesIn(namespace string) bool {
+	return g.Namespace == "" || namespace == "" || g.Namespace == namespace
+}
+
+// subjectMatches compares a binding subject with the queried identity. ServiceAccounts are
+// addressed as "namespace/name" or "system:serviceaccount:namespace:name".
+func subjectMatches(s rbacv1.Subject, kind, name string) bool {
+	if kind != "" && !strings.EqualFold(s.Kind, kind) {
+		return false
+	}
+	if s.Kind == rbacv1.ServiceAccountKind {
+		sa := strings.TrimPrefix(name, "system:serviceaccount:")
+		sa = strings.Replace(sa, ":", "/", 1)
+		return sa == s.Namespace+"/"+s.Name
+	}
+	return s.Name == name
+}
+
+// Can answers "what can subject X do in namespace Y" by listing every grant that applies to
+// the subject (and the groups it belongs to) in that namespace.
+func (h *RBACHandler) Can(c *gin.Context) {
+	subject := c.Query("subject")
+	kind := c.Query("kind") // User, Group or ServiceAccount; empty matches any
+	ns := c.Query("namespace")
+	if subject == "" {
+		apierror.Write(c, http.StatusBadRequest, "subject is required")
+		return
+	}
+
+	model, err := h.loadRBACModel(c.Request.Context())
+	if err != nil {
+		apierror.Fail(c, "", err)
+		return
+	}
+
+	// Every authenticated identity is implicitly a member of system:authenticated
+	groups := []string{"system:authenticated"}
+	if g := c.Query("groups"); g != "" {
+		groups = append(groups, strings.Split(g, ",")...)
+	}
+
+	grants := []Grant{}
+	for _, g := range model.grants() {
+		if !g.appliesIn(ns) {
+			continue
+		}
+		direct := subjectMatches(g.Subject, kind, subject)
+		viaGroup := g.Subject.Kind == rbacv1.GroupKind && kind != rbacv1.GroupKind && matchesAny(groups, g.Subject.Name)
+		if direct || viaGroup {
+			grants = append(grants, g)
+		}
+	}
+	sort.SliceStable(grants, func(i, j int) bool { return grants[i].Namespace < grants[j].Namespace })
+
+	c.JSON(http.StatusOK, gin.H{"subject": subject, "namespace": ns, "grants": grants})
+}
+
+// WhoCan answers "who can <verb> <resource> in namespace Y".
+func (h *RBACHandler) WhoCan(c *gin.Context) {
+	verb := strings.ToLower(c.Query("verb"))
+	resource := strings.ToLower(c.Query("resource"))
+	apiGroup := c.Query("apiGroup") // Core group when empty
+	ns := c.Query("namespace")
+	if verb == "" || resource == "" {
+		apierror.Write(c, http.StatusBadRequest, "verb and resource are required")
+		return
+	}
+
+	model, err := h.loadRBACModel(c.Request.Context())
+	if err != nil {
+		apierror.Fail(c, "", err)
+		return
+	}
+
+	grants := []Grant{}
+	for _, g := range model.grants() {
+		if !g.appliesIn(ns) {
+			continue
+		}
+		for _, r := range g.Rules {
+			if ruleAllows(r, verb, apiGroup, resource) {
+				g.Rules = []rbacv1.PolicyRule{r}
+				grants = append(grants, g)
+				break
+			}
+		}
+	}
+	sort.SliceStable(grants, func(i, j int) bool {
+		if grants[i].Subject.Kind != grants[j].Subject.Kind {
+			return grants[i].Subject.Kind < grants[j].Subject.Kind
+		}
+		return grants[i].Subject.Name < grants[j].Subject.Name
+	})
+
+	c.JSON(http.StatusOK, gin.H{"verb": verb, "resource": resource, "apiGroup": apiGroup, "namespace": ns, "grants": grants})
+}
+
+func mockRBACModel() *rbacModel {
+	readOnly := []rbacv1.PolicyRule{{APIGroups: []string{"", "apps"}, Resources: []string{"pods", "services", "deployments"}, Verbs: []string{"get", "list", "watch"}}}
+	return &rbacModel{
+		roles: map[string]rbacv1.Role{
+			"default/secret-reader": {ObjectMeta: metav1.ObjectMeta{Name: "secret-reader", Namespace: "default"}, Rules: []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get", "list"}}}},
+		},
+		clusterRoles: map[string]rbacv1.ClusterRole{
+			"cluster-admin": {ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"}, Rules: []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}}},
+			"edit":          {ObjectMeta: metav1.ObjectMeta{Name: "edit"}, Rules: []rbacv1.PolicyRule{{APIGroups: []string{"", "apps", "batch"}, Resources: []string{"pods", "services", "deployments", "secrets", "configmaps", "jobs"}, Verbs: []string{"get", "list", "watch", "create", "update", "patch", "delete"}}}},
+			"view":          {ObjectMeta: metav1.ObjectMeta{Name: "view"}, Rules: readOnly},
+		},
+		clusterRoleBindings: []rbacv1.ClusterRoleBinding{
+			{ObjectMeta: metav1.ObjectMeta{Name: "platform-admins"}, RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"}, Subjects: []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "platform@example.com"}, {Kind: rbacv1.UserKind, Name: "admin@kview.local"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "everyone-view"}, RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "view"}, Subjects: []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "system:authenticated"}}},
+		},
+		roleBindings: []rbacv1.RoleBinding{
+			{ObjectMeta: metav1.ObjectMeta{Name: "developers-edit", Namespace: "default"}, RoleRef: rbacv1.RoleRef{Kind: "ClusterRole", Name: "edit"}, Subjects: []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "developer@kview.local"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "ci-secrets", Namespace: "default"}, RoleRef: rbacv1.RoleRef{Kind: "Role", Name: "secret-reader"}, Subjects: []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "ci-runner", Namespace: "default"}}},
+		},
+	}
+}
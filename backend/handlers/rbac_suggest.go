@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"k-view/apierror"
+	"k-view/k8s"
+	"k-view/rbac"
+
+	"github.com/gin-gonic/gin"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// Access levels derived from Kubernetes rules, indexes into rbac.TeamLevels.
+const (
+	levelNone      = -1
+	levelViewer    = 0
+	levelDeveloper = 1
+	levelAdmin     = 2
+)
+
+// RoleSuggestion is the K-View role matching what a user or group can already do according
+// to the cluster's own RBAC.
+type RoleSuggestion struct {
+	Subject    string   `json:"subject"`
+	Kind       string   `json:"kind"`  // User or Group
+	Level      string   `json:"level"` // viewer, developer or admin
+	Role       string   `json:"role"`
+	Namespaces []string `json:"namespaces,omitempty"` // Empty: cluster-wide
+	// Evidence lists the bindings the suggestion is based on
+	Evidence    []string `json:"evidence"`
+	CurrentRole string   `json:"currentRole"`
+	Matches     bool     `json:"matches"` // The subject already has the suggested role in K-View
+	// Notes explains access K-View cannot represent, such as namespace rights beyond a
+	// cluster-wide role
+	Notes []string `json:"notes,omitempty"`
+}
+
+// SetTeams lets suggestions be provisioned as teams.
+func (h *RBACHandler) SetTeams(t *TeamHandler) {
+	h.teams = t
+}
+
+// rulesLevel classifies rules by what they allow on the workloads K-View manages.
+func rulesLevel(rules []rbacv1.PolicyRule) int {
+	level := levelNone
+	for _, r := range rules {
+		switch {
+		case ruleAllows(r, "*", "*", "*") || ruleAllows(r, "escalate", rbacv1.GroupName, "roles"):
+			return levelAdmin
+		case ruleAllows(r, "delete", "", "pods") || ruleAllows(r, "update", "apps", "deployments") || ruleAllows(r, "patch", "apps", "deployments"):
+			level = max(level, levelDeveloper)
+		case ruleAllows(r, "list", "", "pods") || ruleAllows(r, "list", "apps", "deployments"):
+			level = max(level, levelViewer)
+		}
+	}
+	return level
+}
+
+// suggestRole derives a K-View role from the grants of one subject. K-View gives a subject
+// one level, cluster-wide or in a set of namespaces, so the highest level wins and anything
+// it cannot express is reported in the notes.
+func suggestRole(kind, subject string, grants []Grant) (RoleSuggestion, bool) {
+	s := RoleSuggestion{Subject: subject, Kind: kind, Evidence: []string{}}
+	clusterLevel := levelNone
+	nsLevels := map[string]int{}
+	for _, g := range grants {
+		level := rulesLevel(g.Rules)
+		if level == levelNone {
+			continue
+		}
+		s.Evidence = append(s.Evidence, g.Binding+" ("+g.RoleRef+")")
+		if g.Namespace == "" {
+			clusterLevel = max(clusterLevel, level)
+		} else {
+			nsLevels[g.Namespace] = max(nsLevels[g.Namespace], level)
+		}
+	}
+	nsLevel := levelNone
+	for _, l := range nsLevels {
+		nsLevel = max(nsLevel, l)
+	}
+	if clusterLevel == levelNone && nsLevel == levelNone {
+		return s, false
+	}
+
+	if clusterLevel >= nsLevel {
+		s.Level = rbac.TeamLevels[clusterLevel]
+	} else {
+		s.Level = rbac.TeamLevels[nsLevel]
+		for ns, l := range nsLevels {
+			if l == nsLevel {
+				s.Namespaces = append(s.Namespaces, ns)
+			} else {
+				s.Notes = append(s.Notes, rbac.TeamLevels[l]+" access in "+ns+" is not included")
+			}
+		}
+		sort.Strings(s.Namespaces)
+		sort.Strings(s.Notes)
+		if clusterLevel != levelNone {
+			s.Notes = append(s.Notes, "cluster-wide "+rbac.TeamLevels[clusterLevel]+" access is replaced by the namespaced role")
+		}
+	}
+	s.Role = rbac.Team{Level: s.Level, Namespaces: s.Namespaces}.Role()
+	return s, true
+}
+
+// suggestions computes suggestions for every user and group bound in the cluster, or for
+// one subject when subject is set. Built-in system: identities and ServiceAccounts are
+// skipped; they do not sign in to K-View.
+func (h *RBACHandler) suggestions(model *rbacModel, kind, subject string) []RoleSuggestion {
+	type key struct{ kind, name string }
+	bySubject := map[key][]Grant{}
+	for _, g := range model.grants() {
+		if g.Subject.Kind != rbacv1.UserKind && g.Subject.Kind != rbacv1.GroupKind {
+			continue
+		}
+		if strings.HasPrefix(g.Subject.Name, "system:") {
+			continue
+		}
+		if subject != "" && (g.Subject.Name != subject || (kind != "" && !strings.EqualFold(g.Subject.Kind, kind))) {
+			continue
+		}
+		k := key{g.Subject.Kind, g.Subject.Name}
+		bySubject[k] = append(bySubject[k], g)
+	}
+
+	result := []RoleSuggestion{}
+	for k, grants := range bySubject {
+		s, ok := suggestRole(k.kind, k.name, grants)
+		if !ok {
+			continue
+		}
+		if k.kind == rbacv1.UserKind {
+			current, namespaces := h.config.Resolve(k.name, nil)
+			s.CurrentRole = current
+			s.Matches = current == s.Role && strings.Join(namespaces, ",") == strings.Join(s.Namespaces, ",")
+		} else {
+			for _, t := range h.config.Teams() {
+				if contains(t.Groups, k.name) {
+					s.CurrentRole = t.Role()
+					s.Matches = t.Role() == s.Role && strings.Join(t.Namespaces, ",") == strings.Join(s.Namespaces, ",")
+				}
+			}
+		}
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Kind != result[j].Kind {
+			return result[i].Kind < result[j].Kind
+		}
+		return result[i].Subject < result[j].Subject
+	})
+	return result
+}
+
+// SuggestRoles lists the K-View roles that match existing Kubernetes RBAC, for users and
+// groups that already have access to the cluster. ?subject= (and ?kind=) narrows it to one.
+func (h *RBACHandler) SuggestRoles(c *gin.Context) {
+	model, err := h.loadRBACModel(c.Request.Context())
+	if err != nil {
+		apierror.Fail(c, "", err)
+		return
+	}
+	c.JSON(http.StatusOK, h.suggestions(model, c.Query("kind"), c.Query("subject")))
+}
+
+// ApplySuggestion provisions the suggested role for a subject as a team, "k8s-<subject>",
+// with the user as its member or the group as its group.
+func (h *RBACHandler) ApplySuggestion(c *gin.Context) {
+	var req struct {
+		Subject string `json:"subject" binding:"required"`
+		Kind    string `json:"kind"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "subject is required")
+		return
+	}
+	if h.teams == nil {
+		apierror.Write(c, http.StatusNotImplemented, "teams are not available")
+		return
+	}
+	model, err := h.loadRBACModel(c.Request.Context())
+	if err != nil {
+		apierror.Fail(c, "", err)
+		return
+	}
+	found := h.suggestions(model, req.Kind, req.Subject)
+	if len(found) == 0 {
+		apierror.Write(c, http.StatusNotFound, "no Kubernetes RBAC bindings found for "+req.Subject)
+		return
+	}
+	if len(found) > 1 {
+		apierror.Write(c, http.StatusBadRequest, req.Subject+" is bound both as a user and a group; set kind")
+		return
+	}
+	s := found[0]
+
+	name := "k8s-" + strings.TrimPrefix(k8s.ServiceAccountNameFor(s.Subject), "kview-")
+	if len(name) > 40 {
+		name = strings.TrimRight(name[:40], "-")
+	}
+	team := rbac.Team{Name: name, Description: "Provisioned from Kubernetes RBAC: " + strings.Join(s.Evidence, ", "), Level: s.Level, Namespaces: s.Namespaces}
+	if s.Kind == rbacv1.GroupKind {
+		team.Groups = []string{s.Subject}
+	} else {
+		team.Members = []string{s.Subject}
+	}
+	if err := h.teams.put(c, team); err != nil {
+		apierror.Fail(c, "", err)
+		return
+	}
+	email, _ := c.Get("email")
+	auditf("%s %s provisioned as team %s by %v: role=%s namespaces=%v", s.Kind, s.Subject, team.Name, email, team.Role(), team.Namespaces)
+	c.JSON(http.StatusOK, gin.H{"team": team, "suggestion": s})
+}
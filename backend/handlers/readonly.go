@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"k-view/apierror"
+	"k-view/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const readOnlyDoc = "read-only"
+
+// readOnlyKubectl lists the console subcommands that never modify the cluster.
+var readOnlyKubectl = map[string]bool{
+	"get": true, "describe": true, "logs": true, "top": true, "version": true, "cluster-info": true,
+	"api-resources": true, "api-versions": true, "explain": true, "events": true, "diff": true,
+}
+
+// ReadOnlyStatus is the persisted state of the read-only switch.
+type ReadOnlyStatus struct {
+	Enabled bool       `json:"enabled"`
+	Reason  string     `json:"reason,omitempty"`
+	SetBy   string     `json:"setBy,omitempty"`
+	SetAt   *time.Time `json:"setAt,omitempty"`
+	// Enforced is true when KVIEW_READ_ONLY=true pins the mode on and the admin API cannot lift it
+	Enforced bool `json:"enforced"`
+}
+
+// ReadOnlyMode disables every mutating endpoint for all roles, e.g. during a change freeze.
+type ReadOnlyMode struct {
+	mu       sync.RWMutex
+	status   ReadOnlyStatus
+	enforced bool
+	store    *store.Store
+}
+
+// NewReadOnlyMode restores the last admin toggle from the store; enforced (from the
+// KVIEW_READ_ONLY environment variable) keeps the mode on regardless.
+func NewReadOnlyMode(st *store.Store, enforced bool) *ReadOnlyMode {
+	m := &ReadOnlyMode{store: st, enforced: enforced}
+	_ = st.Load(readOnlyDoc, &m.status)
+	return m
+}
+
+func (m *ReadOnlyMode) Document() string { return readOnlyDoc }
+
+// Reload refreshes the admin toggle from the store.
+func (m *ReadOnlyMode) Reload() error {
+	var status ReadOnlyStatus
+	if err := m.store.Load(readOnlyDoc, &status); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.status = status
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *ReadOnlyMode) current() ReadOnlyStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	status := m.status
+	if m.enforced {
+		status.Enabled = true
+		status.Enforced = true
+		if status.Reason == "" {
+			status.Reason = "Read-only mode is enforced by the server configuration"
+		}
+	}
+	return status
+}
+
+// Enabled reports whether mutations are currently blocked.
+func (m *ReadOnlyMode) Enabled() bool {
+	return m.current().Enabled
+}
+
+// peekJSON decodes the request body into v without consuming it for the handler.
+func peekJSON(c *gin.Context, v interface{}) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	_ = json.Unmarshal(body, v)
+}
+
+// isMutation decides whether a request would change the cluster or K-View's state.
+func isMutation(c *gin.Context) bool {
+	path := c.FullPath()
+	switch {
+	case path == "/api/read-only":
+		return false // The switch itself must stay usable
+	case path == "/api/admin/terminal-sessions/:id":
+		return false // Closing a terminal never changes the cluster
+	case path == "/api/admin/webhooks/:id/test":
+		return false // Sends a test payload only
+	case strings.HasPrefix(path, "/api/auth/"):
+		return false // Users' own sign-in settings, such as MFA
+	case strings.HasPrefix(path, "/api/exec/") || path == "/api/nodes/:name/shell":
+		return true // Interactive shells can run anything
+	case c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions:
+		return false
+	case path == "/api/console/exec":
+		var req ExecRequest
+		peekJSON(c, &req)
+		return kubectlMutation(req.Command)
+	case path == "/api/templates/:name/render":
+		var req struct {
+			Apply bool `json:"apply"`
+		}
+		peekJSON(c, &req)
+		return req.Apply
+	}
+	return true
+}
+
+// kubectlMutation decides whether a console command could change the cluster.
+func kubectlMutation(cmd string) bool {
+	sub, args := splitKubectl(strings.Fields(normalizeKubectl(cmd)))
+	if sub == "" {
+		return false
+	}
+	if sub == "auth" && len(args) > 0 && args[0] == "can-i" {
+		return false
+	}
+	if sub == "config" && len(args) > 0 && args[0] == "view" {
+		return false
+	}
+	return !readOnlyKubectl[sub]
+}
+
+// Middleware rejects mutating requests with 423 Locked while read-only mode is on.
+func (m *ReadOnlyMode) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.Enabled() || !isMutation(c) {
+			c.Next()
+			return
+		}
+		status := m.current()
+		msg := "K-View is in read-only mode"
+		if status.Reason != "" {
+			msg += ": " + status.Reason
+		}
+		if strings.HasPrefix(c.FullPath(), "/api/console/") {
+			c.AbortWithStatusJSON(http.StatusLocked, apierror.New(http.StatusLocked, msg).With(gin.H{"output": "error: " + msg, "exitCode": 1}))
+			return
+		}
+		apierror.Abort(c, http.StatusLocked, msg)
+	}
+}
+
+// GetStatus returns the read-only state so the UI can show a banner and hide edit controls.
+func (m *ReadOnlyMode) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, m.current())
+}
+
+// SetStatus turns read-only mode on or off (admin only).
+func (m *ReadOnlyMode) SetStatus(c *gin.Context) {
+	var req struct {
+		Enabled bool   `json:"enabled"`
+		Reason  string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "Invalid input")
+		return
+	}
+	if m.enforced && !req.Enabled {
+		apierror.Write(c, http.StatusConflict, "read-only mode is enforced by KVIEW_READ_ONLY and cannot be turned off from the API")
+		return
+	}
+
+	email, _ := c.Get("email")
+	now := time.Now().UTC()
+	status := ReadOnlyStatus{Enabled: req.Enabled, Reason: req.Reason, SetAt: &now}
+	status.SetBy, _ = email.(string)
+	if !req.Enabled {
+		status.Reason = ""
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var stored ReadOnlyStatus
+	if err := m.store.Update(readOnlyDoc, &stored, func() error {
+		stored = status
+		return nil
+	}); err != nil {
+		apierror.Fail(c, "Failed to save read-only mode", err)
+		return
+	}
+	m.status = status
+	c.JSON(http.StatusOK, status)
+}
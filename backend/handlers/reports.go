@@ -0,0 +1,567 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"sort"
+	"strings"
+	"time"
+
+	"k-view/apierror"
+	"k-view/k8s"
+	"k-view/store"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const reportSchedulesDoc = "report-schedules"
+
+var errReportNotFound = errors.New("report not found")
+
+// reportSections are the parts a report can contain.
+var reportSections = []string{"health", "cost", "security"}
+
+// ReportSchedule emails a cluster summary to its recipients on a cron schedule.
+type ReportSchedule struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name" binding:"required"`
+	Recipients []string   `json:"recipients" binding:"required"`
+	Schedule   string     `json:"schedule"`            // Cron expression; defaults to Mondays at 08:00
+	Timezone   string     `json:"timezone"`            // IANA name; defaults to the server's TZ
+	Sections   []string   `json:"sections"`            // health, cost, security; defaults to all
+	Namespace  string     `json:"namespace,omitempty"` // Empty: the whole cluster
+	Enabled    bool       `json:"enabled"`
+	CreatedBy  string     `json:"createdBy"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastRun    *time.Time `json:"lastRun,omitempty"`
+	LastResult string     `json:"lastResult,omitempty"`
+	NextRun    *time.Time `json:"nextRun,omitempty"`
+}
+
+// validate normalises a schedule and checks its recipients, sections, schedule and timezone.
+func (r *ReportSchedule) validate() (*cronSchedule, *time.Location, error) {
+	if len(r.Recipients) == 0 {
+		return nil, nil, fmt.Errorf("at least one recipient is required")
+	}
+	for i, to := range r.Recipients {
+		addr, err := mail.ParseAddress(to)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid recipient %q", to)
+		}
+		r.Recipients[i] = addr.Address // "Name <a@b>" would be sent as the RCPT address
+	}
+	if len(r.Sections) == 0 {
+		r.Sections = append([]string(nil), reportSections...)
+	}
+	for i, s := range r.Sections {
+		r.Sections[i] = strings.ToLower(s)
+		if !contains(reportSections, r.Sections[i]) {
+			return nil, nil, fmt.Errorf("section must be one of %s", strings.Join(reportSections, ", "))
+		}
+	}
+	if r.Schedule == "" {
+		r.Schedule = "0 8 * * 1"
+	}
+	schedule, err := parseCron(r.Schedule)
+	if err != nil {
+		return nil, nil, err
+	}
+	loc := time.Local
+	if r.Timezone != "" {
+		if loc, err = time.LoadLocation(r.Timezone); err != nil {
+			return nil, nil, fmt.Errorf("unknown timezone %q", r.Timezone)
+		}
+	}
+	return schedule, loc, nil
+}
+
+// withNextRun fills in the next delivery time for API responses.
+func (r ReportSchedule) withNextRun(now time.Time) ReportSchedule {
+	schedule, loc, err := r.validate()
+	if err != nil || !r.Enabled {
+		return r
+	}
+	if next, ok := schedule.Next(now.In(loc)); ok {
+		r.NextRun = &next
+	}
+	return r
+}
+
+// Mailer sends HTML mail through an SMTP relay. PLAIN authentication is used when a username
+// is set; the connection is upgraded with STARTTLS when the server offers it.
+type Mailer struct {
+	addr     string // host:port
+	username string
+	password string
+	from     string
+}
+
+// NewMailer returns a mailer for the relay at addr, or nil when addr is empty.
+func NewMailer(addr, username, password, from string) *Mailer {
+	if addr == "" {
+		return nil
+	}
+	return &Mailer{addr: addr, username: username, password: password, from: from}
+}
+
+// Send mails an HTML body to the recipients.
+func (m *Mailer) Send(to []string, subject, body string) error {
+	if m == nil {
+		return errors.New("SMTP is not configured (KVIEW_SMTP_ADDR)")
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n")
+	msg.WriteString(body)
+
+	var auth smtp.Auth
+	if m.username != "" {
+		host, _, _ := net.SplitHostPort(m.addr)
+		auth = smtp.PlainAuth("", m.username, m.password, host)
+	}
+	return smtp.SendMail(m.addr, auth, m.from, to, msg.Bytes())
+}
+
+// ReportHealth summarises workload and node health.
+type ReportHealth struct {
+	Version    string        `json:"version,omitempty"`
+	Nodes      int           `json:"nodes"`
+	NodesReady int           `json:"nodesReady"`
+	Workloads  int           `json:"workloads"`
+	Degraded   []string      `json:"degraded"`  // namespace/name (kind)
+	Unhealthy  []string      `json:"unhealthy"` // namespace/name (kind)
+	Pods       ExportPodInfo `json:"pods"`
+}
+
+// NamespaceCost is what a namespace reserves: the requests of its running pods, and their share
+// of the cluster's allocatable capacity. K-View has no pricing data, so cost is reported as
+// reserved capacity rather than money.
+type NamespaceCost struct {
+	Namespace   string  `json:"namespace"`
+	Pods        int     `json:"pods"`
+	CPUCores    float64 `json:"cpuCores"`
+	MemoryGiB   float64 `json:"memoryGiB"`
+	CPUShare    float64 `json:"cpuShare"`    // Percentage of allocatable CPU
+	MemoryShare float64 `json:"memoryShare"` // Percentage of allocatable memory
+}
+
+// ClusterReport is the content of one report.
+type ClusterReport struct {
+	Name        string              `json:"name"`
+	Namespace   string              `json:"namespace,omitempty"`
+	GeneratedAt time.Time           `json:"generatedAt"`
+	Health      *ReportHealth       `json:"health,omitempty"`
+	Cost        []NamespaceCost     `json:"cost,omitempty"`
+	Security    []NamespaceSecurity `json:"security,omitempty"`
+	Errors      map[string]string   `json:"errors,omitempty"`
+}
+
+// namespaceCosts sums the requests of running pods by namespace, largest CPU reservation first.
+func namespaceCosts(pods []corev1.Pod, nodes []corev1.Node) []NamespaceCost {
+	var allocCPU, allocRAM int64
+	for _, n := range nodes {
+		allocCPU += n.Status.Allocatable.Cpu().MilliValue()
+		allocRAM += n.Status.Allocatable.Memory().Value()
+	}
+	costs := map[string]*NamespaceCost{}
+	reqCPU, reqRAM := map[string]int64{}, map[string]int64{}
+	for _, p := range pods {
+		if p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		c, ok := costs[p.Namespace]
+		if !ok {
+			c = &NamespaceCost{Namespace: p.Namespace}
+			costs[p.Namespace] = c
+		}
+		c.Pods++
+		for _, ctr := range p.Spec.Containers {
+			reqCPU[p.Namespace] += ctr.Resources.Requests.Cpu().MilliValue()
+			reqRAM[p.Namespace] += ctr.Resources.Requests.Memory().Value()
+		}
+	}
+	result := make([]NamespaceCost, 0, len(costs))
+	for ns, c := range costs {
+		c.CPUCores = float64(reqCPU[ns]) / 1000
+		c.MemoryGiB = float64(reqRAM[ns]) / (1 << 30)
+		if allocCPU > 0 {
+			c.CPUShare = float64(reqCPU[ns]) / float64(allocCPU) * 100
+		}
+		if allocRAM > 0 {
+			c.MemoryShare = float64(reqRAM[ns]) / float64(allocRAM) * 100
+		}
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CPUCores != result[j].CPUCores {
+			return result[i].CPUCores > result[j].CPUCores
+		}
+		return result[i].Namespace < result[j].Namespace
+	})
+	return result
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"pct": func(f float64) string { return fmt.Sprintf("%.1f%%", f) },
+	"num": func(f float64) string { return fmt.Sprintf("%.2f", f) },
+	"top": func(findings []SecurityFinding) []SecurityFinding {
+		if len(findings) > 5 {
+			return findings[:5]
+		}
+		return findings
+	},
+}).Parse(`<!DOCTYPE html>
+<html><body style="font-family: sans-serif; color: #1e293b">
+<h1>{{.Name}}</h1>
+<p>{{if .Namespace}}Namespace {{.Namespace}}{{else}}Cluster{{end}} report generated {{.GeneratedAt.Format "2006-01-02 15:04 MST"}}</p>
+{{with .Health}}
+<h2>Health</h2>
+<p>{{if .Version}}Kubernetes {{.Version}}, {{.NodesReady}} of {{.Nodes}} nodes ready. {{end}}{{.Workloads}} workloads: {{len .Unhealthy}} unhealthy, {{len .Degraded}} degraded.
+Pods: {{.Pods.Running}} running, {{.Pods.Pending}} pending, {{.Pods.Failed}} failed.</p>
+{{if .Unhealthy}}<p><b>Unhealthy:</b></p><ul>{{range .Unhealthy}}<li>{{.}}</li>{{end}}</ul>{{end}}
+{{if .Degraded}}<p><b>Degraded:</b></p><ul>{{range .Degraded}}<li>{{.}}</li>{{end}}</ul>{{end}}
+{{end}}
+{{with .Cost}}
+<h2>Reserved capacity</h2>
+<table border="1" cellpadding="4" cellspacing="0">
+<tr><th>Namespace</th><th>Pods</th><th>CPU (cores)</th><th>CPU share</th><th>Memory (GiB)</th><th>Memory share</th></tr>
+{{range .}}<tr><td>{{.Namespace}}</td><td>{{.Pods}}</td><td>{{num .CPUCores}}</td><td>{{pct .CPUShare}}</td><td>{{num .MemoryGiB}}</td><td>{{pct .MemoryShare}}</td></tr>
+{{end}}</table>
+{{end}}
+{{with .Security}}
+<h2>Security</h2>
+<table border="1" cellpadding="4" cellspacing="0">
+<tr><th>Namespace</th><th>Score</th><th>Pods</th><th>Top findings</th></tr>
+{{range .}}<tr><td>{{.Namespace}}</td><td>{{.Score}}</td><td>{{.Pods}}</td><td>{{range top .Findings}}[{{.Severity}}] {{.Pod}}{{if .Container}}/{{.Container}}{{end}}: {{.Message}}<br>{{end}}</td></tr>
+{{end}}</table>
+{{end}}
+{{with .Errors}}<p><i>Not included:</i></p><ul>{{range $section, $err := .}}<li>{{$section}}: {{$err}}</li>{{end}}</ul>{{end}}
+</body></html>
+`))
+
+// ReportHandler manages scheduled reports and delivers them from a background worker. Reports
+// are generated with the ServiceAccount's permissions, so only admins manage them.
+type ReportHandler struct {
+	resources *ResourceHandler
+	k8sClient k8s.KubernetesProvider
+	store     *store.Store
+	mailer    *Mailer
+}
+
+func NewReportHandler(resources *ResourceHandler, client k8s.KubernetesProvider, st *store.Store, mailer *Mailer) *ReportHandler {
+	return &ReportHandler{resources: resources, k8sClient: client, store: st, mailer: mailer}
+}
+
+// generate collects the requested sections. A section that cannot be loaded is listed in
+// Errors and the rest of the report is still produced.
+func (h *ReportHandler) generate(ctx context.Context, name, ns string, sections []string) ClusterReport {
+	report := ClusterReport{Name: name, Namespace: ns, GeneratedAt: time.Now().UTC()}
+	fail := func(section string, err error) {
+		if report.Errors == nil {
+			report.Errors = map[string]string{}
+		}
+		report.Errors[section] = err.Error()
+	}
+
+	if contains(sections, "health") {
+		doc := h.resources.statusDocument(ctx, ns)
+		health := &ReportHealth{Version: doc.Cluster.Version, Nodes: len(doc.Cluster.Nodes), Workloads: len(doc.Workloads), Pods: doc.Status, Degraded: []string{}, Unhealthy: []string{}}
+		for _, n := range doc.Cluster.Nodes {
+			if n.Ready {
+				health.NodesReady++
+			}
+		}
+		for _, w := range doc.Workloads {
+			name := fmt.Sprintf("%s/%s (%s)", w.ObjectMeta.Namespace, w.ObjectMeta.Name, w.TypeMeta["kind"])
+			switch w.Health {
+			case "Unhealthy":
+				health.Unhealthy = append(health.Unhealthy, name)
+			case "Degraded":
+				health.Degraded = append(health.Degraded, name)
+			}
+		}
+		for section, err := range doc.Errors {
+			fail("health: "+section, errors.New(err))
+		}
+		report.Health = health
+	}
+
+	if !contains(sections, "cost") && !contains(sections, "security") {
+		return report
+	}
+	pods, err := h.k8sClient.ListPods(ctx, ns)
+	if err != nil {
+		fail("pods", err)
+		return report
+	}
+	if contains(sections, "cost") {
+		nodes, err := h.k8sClient.ListNodes(ctx)
+		if err != nil {
+			fail("cost", err)
+		} else {
+			report.Cost = namespaceCosts(pods, nodes)
+		}
+	}
+	if contains(sections, "security") {
+		report.Security = securityPosture(pods)
+	}
+	return report
+}
+
+// deliver generates a schedule's report and mails it, returning a summary for LastResult.
+func (h *ReportHandler) deliver(ctx context.Context, r ReportSchedule) string {
+	report := h.generate(ctx, r.Name, r.Namespace, r.Sections)
+	var body bytes.Buffer
+	if err := reportTemplate.Execute(&body, report); err != nil {
+		return "Failed: " + err.Error()
+	}
+	subject := fmt.Sprintf("[K-View] %s – %s", r.Name, report.GeneratedAt.Format("2006-01-02"))
+	if err := h.mailer.Send(r.Recipients, subject, body.String()); err != nil {
+		return "Failed: " + err.Error()
+	}
+	return fmt.Sprintf("Sent to %d recipient(s)", len(r.Recipients))
+}
+
+// List returns all report schedules with their next delivery.
+func (h *ReportHandler) List(c *gin.Context) {
+	var reports []ReportSchedule
+	if err := h.store.Load(reportSchedulesDoc, &reports); err != nil {
+		apierror.Fail(c, "Failed to load reports", err)
+		return
+	}
+	now := time.Now()
+	result := make([]ReportSchedule, 0, len(reports))
+	for _, r := range reports {
+		result = append(result, r.withNextRun(now))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// Create adds a report schedule. New schedules are enabled unless "enabled": false is sent.
+func (h *ReportHandler) Create(c *gin.Context) {
+	report := ReportSchedule{Enabled: true}
+	if err := c.ShouldBindJSON(&report); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "name and recipients are required")
+		return
+	}
+	if _, _, err := report.validate(); err != nil {
+		apierror.Write(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	report.ID = newID()
+	report.CreatedBy = c.GetString("email")
+	report.CreatedAt = time.Now().UTC()
+	report.LastRun, report.LastResult, report.NextRun = nil, "", nil
+
+	var reports []ReportSchedule
+	err := h.store.Update(reportSchedulesDoc, &reports, func() error {
+		reports = append(reports, report)
+		return nil
+	})
+	if err != nil {
+		apierror.Fail(c, "Failed to save report", err)
+		return
+	}
+	auditf("report %q to %s created by %s", report.Name, strings.Join(report.Recipients, ", "), report.CreatedBy)
+	c.JSON(http.StatusCreated, report.withNextRun(time.Now()))
+}
+
+// Update replaces a report schedule, keeping its ID, author and delivery history.
+func (h *ReportHandler) Update(c *gin.Context) {
+	id := c.Param("id")
+	var input ReportSchedule
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "name and recipients are required")
+		return
+	}
+	if _, _, err := input.validate(); err != nil {
+		apierror.Write(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var reports []ReportSchedule
+	var updated ReportSchedule
+	err := h.store.Update(reportSchedulesDoc, &reports, func() error {
+		for i := range reports {
+			if reports[i].ID != id {
+				continue
+			}
+			input.ID, input.CreatedBy, input.CreatedAt = reports[i].ID, reports[i].CreatedBy, reports[i].CreatedAt
+			input.LastRun, input.LastResult, input.NextRun = reports[i].LastRun, reports[i].LastResult, nil
+			reports[i] = input
+			updated = input
+			return nil
+		}
+		return errReportNotFound
+	})
+	if err == errReportNotFound {
+		apierror.Write(c, http.StatusNotFound, "report "+id+" not found")
+		return
+	}
+	if err != nil {
+		apierror.Fail(c, "Failed to save report", err)
+		return
+	}
+	c.JSON(http.StatusOK, updated.withNextRun(time.Now()))
+}
+
+// Delete removes a report schedule.
+func (h *ReportHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+	var reports []ReportSchedule
+	err := h.store.Update(reportSchedulesDoc, &reports, func() error {
+		for i := range reports {
+			if reports[i].ID == id {
+				reports = append(reports[:i], reports[i+1:]...)
+				return nil
+			}
+		}
+		return errReportNotFound
+	})
+	if err == errReportNotFound {
+		apierror.Write(c, http.StatusNotFound, "report "+id+" not found")
+		return
+	}
+	if err != nil {
+		apierror.Fail(c, "Failed to save report", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Report deleted"})
+}
+
+// Send delivers a report immediately, regardless of its schedule.
+func (h *ReportHandler) Send(c *gin.Context) {
+	id := c.Param("id")
+	var reports []ReportSchedule
+	if err := h.store.Load(reportSchedulesDoc, &reports); err != nil {
+		apierror.Fail(c, "Failed to load reports", err)
+		return
+	}
+	for _, r := range reports {
+		if r.ID == id {
+			result := h.deliver(c.Request.Context(), r)
+			h.recordRun(id, result)
+			c.JSON(http.StatusOK, gin.H{"message": result})
+			return
+		}
+	}
+	apierror.Write(c, http.StatusNotFound, "report "+id+" not found")
+}
+
+// Preview renders a report without sending it: HTML by default, JSON with ?format=json.
+// ?sections= (comma-separated) and ?namespace= select the content.
+func (h *ReportHandler) Preview(c *gin.Context) {
+	r := ReportSchedule{Name: "Cluster report", Recipients: []string{"preview@localhost"}, Namespace: c.Query("namespace")}
+	if s := c.Query("sections"); s != "" {
+		r.Sections = strings.Split(s, ",")
+	}
+	if _, _, err := r.validate(); err != nil {
+		apierror.Write(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	report := h.generate(c.Request.Context(), r.Name, r.Namespace, r.Sections)
+	if c.Query("format") == "json" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	var body bytes.Buffer
+	if err := reportTemplate.Execute(&body, report); err != nil {
+		apierror.Fail(c, "Failed to render report", err)
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", body.Bytes())
+}
+
+// RunScheduler is the background worker: once a minute it delivers every enabled report whose
+// schedule matches. Replicas sharing the data volume claim a run through the store first, so a
+// report is mailed once.
+func (h *ReportHandler) RunScheduler(ctx context.Context) {
+	// Align to the start of the next minute so each schedule fires once
+	time.Sleep(time.Until(time.Now().Truncate(time.Minute).Add(time.Minute)))
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		h.runDue(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *ReportHandler) runDue(ctx context.Context, now time.Time) {
+	var reports []ReportSchedule
+	if err := h.store.Load(reportSchedulesDoc, &reports); err != nil {
+		log.Printf("Report scheduler: %v", err)
+		return
+	}
+	minute := now.UTC().Truncate(time.Minute)
+	for _, r := range reports {
+		if !r.Enabled {
+			continue
+		}
+		schedule, loc, err := r.validate()
+		if err != nil || !schedule.Matches(now.In(loc)) || !h.claim(r.ID, minute) {
+			continue
+		}
+		result := h.deliver(ctx, r)
+		log.Printf("Report %q: %s", r.Name, result)
+		h.recordRun(r.ID, result)
+	}
+}
+
+// claim marks a report as run for this minute, returning false if another replica already did.
+func (h *ReportHandler) claim(id string, minute time.Time) bool {
+	var reports []ReportSchedule
+	claimed := false
+	err := h.store.Update(reportSchedulesDoc, &reports, func() error {
+		for i := range reports {
+			if reports[i].ID != id {
+				continue
+			}
+			if reports[i].LastRun != nil && !reports[i].LastRun.Before(minute) {
+				return errReportNotFound // Already claimed; leave the document untouched
+			}
+			reports[i].LastRun = &minute
+			claimed = true
+			return nil
+		}
+		return errReportNotFound
+	})
+	if err != nil && err != errReportNotFound {
+		log.Printf("Report scheduler: failed to claim %s: %v", id, err)
+	}
+	return claimed
+}
+
+func (h *ReportHandler) recordRun(id, result string) {
+	var reports []ReportSchedule
+	now := time.Now().UTC()
+	err := h.store.Update(reportSchedulesDoc, &reports, func() error {
+		for i := range reports {
+			if reports[i].ID == id {
+				reports[i].LastRun = &now
+				reports[i].LastResult = result
+				return nil
+			}
+		}
+		return errReportNotFound // Deleted while running
+	})
+	if err != nil && err != errReportNotFound {
+		log.Printf("Report scheduler: failed to record run of %s: %v", id, err)
+	}
+}
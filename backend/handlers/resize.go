@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"k-view/apierror"
+	"k-view/k8s"
+)
+
+// ContainerResize is the new CPU/memory requests and limits of one container. Omitted values
+// are left unchanged.
+type ContainerResize struct {
+	Name     string            `json:"name" binding:"required"`
+	Requests map[string]string `json:"requests,omitempty"`
+	Limits   map[string]string `json:"limits,omitempty"`
+}
+
+// ResizeResult reports how the kubelet will apply the resize.
+type ResizeResult struct {
+	Message string `json:"message"`
+	// Method is "resize-subresource" (1.33+) or "pod-patch" (1.27-1.32 with the feature gate)
+	Method string `json:"method"`
+	// Restarts lists containers whose resizePolicy restarts them for the changed resources
+	Restarts []string `json:"restarts,omitempty"`
+	Status   string   `json:"status,omitempty"` // Pod resize status reported so far, if any
+}
+
+// resizePatch builds the strategic merge patch for the containers, validating the quantities.
+func resizePatch(containers []ContainerResize) ([]byte, error) {
+	patched := []map[string]interface{}{}
+	for _, ct := range containers {
+		resources := map[string]interface{}{}
+		for field, values := range map[string]map[string]string{"requests": ct.Requests, "limits": ct.Limits} {
+			if len(values) == 0 {
+				continue
+			}
+			for name, value := range values {
+				if name != "cpu" && name != "memory" {
+					return nil, errors.New("only cpu and memory can be resized in place, not " + name)
+				}
+				if _, err := resource.ParseQuantity(value); err != nil {
+					return nil, errors.New("invalid " + name + " quantity " + value + " for container " + ct.Name)
+				}
+			}
+			resources[field] = values
+		}
+		if len(resources) == 0 {
+			continue
+		}
+		patched = append(patched, map[string]interface{}{"name": ct.Name, "resources": resources})
+	}
+	if len(patched) == 0 {
+		return nil, errors.New("no cpu or memory changes given")
+	}
+	return json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"containers": patched}})
+}
+
+// restartingContainers lists the resized containers whose resizePolicy restarts them.
+func restartingContainers(pod *corev1.Pod, containers []ContainerResize) []string {
+	var restarts []string
+	for _, ct := range containers {
+		for _, spec := range pod.Spec.Containers {
+			if spec.Name != ct.Name {
+				continue
+			}
+			for _, policy := range spec.ResizePolicy {
+				_, inRequests := ct.Requests[string(policy.ResourceName)]
+				_, inLimits := ct.Limits[string(policy.ResourceName)]
+				if policy.RestartPolicy == corev1.RestartContainer && (inRequests || inLimits) {
+					restarts = append(restarts, ct.Name)
+					break
+				}
+			}
+		}
+	}
+	return restarts
+}
+
+// ResizePod changes the CPU/memory of a running pod's containers in place (no pod restart),
+// using the resize subresource on Kubernetes 1.33+ and a pod patch on 1.27-1.32 where the
+// InPlacePodVerticalScaling feature gate must be enabled.
+func (h *ResourceHandler) ResizePod(c *gin.Context) {
+	kind := strings.ToLower(c.Param("kind"))
+	ns := c.Param("namespace")
+	name := c.Param("name")
+	if kind != "pods" {
+		apierror.Write(c, http.StatusBadRequest, "Only pods can be resized in place")
+		return
+	}
+	var req struct {
+		Containers []ContainerResize `json:"containers" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "containers with a name and requests or limits are required")
+		return
+	}
+	if !requireEditAccess(c, ns) || h.rejectProtected(c, kind, ns, name) {
+		return
+	}
+	patch, err := resizePatch(req.Containers)
+	if err != nil {
+		apierror.Write(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if h.devMode {
+		c.JSON(http.StatusOK, ResizeResult{Message: "Resize requested (mocked)", Method: "resize-subresource", Status: "InProgress"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	minor := 0
+	if info, ok := h.k8sClient.(k8s.ClusterInfoProvider); ok {
+		if version, err := info.ServerVersion(ctx); err == nil {
+			_, minor, _ = parseMinor(version)
+		}
+	}
+	if minor > 0 && minor < 27 {
+		apierror.Write(c, http.StatusNotImplemented, "In-place pod resize requires Kubernetes 1.27 or later; change the resources on the owning workload instead (this restarts the pods)")
+		return
+	}
+
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+	dc := dynClient.Resource(getGVR("pods")).Namespace(ns)
+
+	method := "resize-subresource"
+	obj, err := dc.Patch(ctx, name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}, "resize")
+	if apierrors.IsNotFound(err) && minor < 33 {
+		// Before 1.33 the resize went through a plain pod update, accepted only with the feature gate
+		if _, getErr := dc.Get(ctx, name, metav1.GetOptions{}); getErr == nil {
+			method = "pod-patch"
+			obj, err = dc.Patch(ctx, name, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+		}
+	}
+	switch {
+	case apierrors.IsNotFound(err):
+		apierror.Write(c, http.StatusNotFound, "resource not found: "+err.Error())
+		return
+	case apierrors.IsInvalid(err) && strings.Contains(err.Error(), "may not change fields"):
+		apierror.Write(c, http.StatusNotImplemented, "The cluster does not allow in-place pod resize (InPlacePodVerticalScaling feature gate is off); change the resources on the owning workload instead (this restarts the pods)")
+		return
+	case err != nil:
+		apierror.Write(c, http.StatusUnprocessableEntity, "Failed to resize pod: "+err.Error())
+		return
+	}
+
+	var pod corev1.Pod
+	_ = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &pod)
+	result := ResizeResult{Message: "Resize requested", Method: method, Restarts: restartingContainers(&pod, req.Containers)}
+	result.Status = string(pod.Status.Resize) // Before 1.33; newer clusters report conditions
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == "PodResizePending" || cond.Type == "PodResizeInProgress" {
+			result.Status = strings.TrimPrefix(string(cond.Type), "PodResize")
+			if cond.Message != "" {
+				result.Message += ": " + cond.Message
+			}
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -13,23 +15,36 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"sigs.k8s.io/yaml"
 
+	"k-view/apierror"
 	"k-view/k8s"
+	"k-view/policy"
+	"k-view/store"
 )
 
 type ResourceHandler struct {
-	devMode    bool
-	k8sClient  k8s.KubernetesProvider
-	mu         sync.Mutex
-	cpuHistory []MetricHistory
-	ramHistory []MetricHistory
+	devMode     bool
+	k8sClient   k8s.KubernetesProvider
+	policies    *policy.PolicyConfig
+	maintenance *MaintenanceHandler
+	approvals   *approvalQueue
+	columns     *ColumnConfig
+	mu          sync.Mutex
+	cpuHistory  []MetricHistory
+	ramHistory  []MetricHistory
 }
 
-func NewResourceHandler(devMode bool, k8sClient k8s.KubernetesProvider) *ResourceHandler {
-	return &ResourceHandler{devMode: devMode, k8sClient: k8sClient}
+func NewResourceHandler(devMode bool, k8sClient k8s.KubernetesProvider, policies *policy.PolicyConfig, maintenance *MaintenanceHandler, st *store.Store) *ResourceHandler {
+	return &ResourceHandler{devMode: devMode, k8sClient: k8sClient, policies: policies, maintenance: maintenance, approvals: &approvalQueue{store: st}}
+}
+
+// apiResource returns the API resource name of a URL :kind, folding its aliases together.
+func apiResource(kind string) string {
+	return getGVR(kind).Resource
 }
 
 // getGVR maps frontend URL :kind parameters to K8s schema.GroupVersionResource
@@ -91,10 +106,54 @@ func getGVR(kind string) schema.GroupVersionResource {
 		return schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}
 	case "endpoints":
 		return schema.GroupVersionResource{Group: "", Version: "v1", Resource: "endpoints"}
+	case "endpointslices", "endpoint-slices":
+		return schema.GroupVersionResource{Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices"}
 	case "resourcequotas", "resource-quotas":
 		return schema.GroupVersionResource{Group: "", Version: "v1", Resource: "resourcequotas"}
 	case "limitranges", "limit-ranges":
 		return schema.GroupVersionResource{Group: "", Version: "v1", Resource: "limitranges"}
+	case "validating-webhooks", "validatingwebhookconfigurations":
+		return schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "validatingwebhookconfigurations"}
+	case "mutating-webhooks", "mutatingwebhookconfigurations":
+		return schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "mutatingwebhookconfigurations"}
+	case "priority-classes", "priorityclasses":
+		return schema.GroupVersionResource{Group: "scheduling.k8s.io", Version: "v1", Resource: "priorityclasses"}
+	case "events":
+		return schema.GroupVersionResource{Group: "", Version: "v1", Resource: "events"}
+	case "volume-snapshots", "volumesnapshots":
+		return schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshots"}
+	case "volume-snapshot-classes", "volumesnapshotclasses":
+		return schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshotclasses"}
+	case "leases":
+		return schema.GroupVersionResource{Group: "coordination.k8s.io", Version: "v1", Resource: "leases"}
+	case "virtual-services", "virtualservices":
+		return schema.GroupVersionResource{Group: "networking.istio.io", Version: "v1beta1", Resource: "virtualservices"}
+	case "destination-rules", "destinationrules":
+		return schema.GroupVersionResource{Group: "networking.istio.io", Version: "v1beta1", Resource: "destinationrules"}
+	case "istio-gateways":
+		return schema.GroupVersionResource{Group: "networking.istio.io", Version: "v1beta1", Resource: "gateways"}
+	case "peer-authentications", "peerauthentications":
+		return schema.GroupVersionResource{Group: "security.istio.io", Version: "v1beta1", Resource: "peerauthentications"}
+	case "subscriptions":
+		return schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "subscriptions"}
+	case "cluster-service-versions", "clusterserviceversions", "csvs":
+		return schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "clusterserviceversions"}
+	case "install-plans", "installplans":
+		return schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "installplans"}
+	case "catalog-sources", "catalogsources":
+		return schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "catalogsources"}
+	case "rollouts":
+		return schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}
+	case "policy-reports", "policyreports":
+		return schema.GroupVersionResource{Group: "wgpolicyk8s.io", Version: "v1alpha2", Resource: "policyreports"}
+	case "cluster-policy-reports", "clusterpolicyreports":
+		return schema.GroupVersionResource{Group: "wgpolicyk8s.io", Version: "v1alpha2", Resource: "clusterpolicyreports"}
+	case "constraint-templates", "constrainttemplates":
+		return schema.GroupVersionResource{Group: "templates.gatekeeper.sh", Version: "v1", Resource: "constrainttemplates"}
+	case "flow-schemas", "flowschemas":
+		return schema.GroupVersionResource{Group: "flowcontrol.apiserver.k8s.io", Version: "v1", Resource: "flowschemas"}
+	case "priority-levels", "prioritylevelconfigurations":
+		return schema.GroupVersionResource{Group: "flowcontrol.apiserver.k8s.io", Version: "v1", Resource: "prioritylevelconfigurations"}
 	default:
 		// Attempt a best-effort guess for unknown kinds
 		return schema.GroupVersionResource{Group: "", Version: "v1", Resource: kind}
@@ -103,14 +162,22 @@ func getGVR(kind string) schema.GroupVersionResource {
 
 // clusterScopedKinds is the set of resource kinds that are NOT namespaced.
 var clusterScopedKinds = map[string]bool{
-	"namespaces":            true,
-	"nodes":                 true,
-	"pvs":                   true,
-	"storage-classes":       true,
-	"crds":                  true,
-	"cluster-roles":         true,
-	"cluster-role-bindings": true,
-	"ingress-classes":       true,
+	"namespaces":              true,
+	"nodes":                   true,
+	"pvs":                     true,
+	"storage-classes":         true,
+	"crds":                    true,
+	"cluster-roles":           true,
+	"cluster-role-bindings":   true,
+	"ingress-classes":         true,
+	"validating-webhooks":     true,
+	"mutating-webhooks":       true,
+	"volume-snapshot-classes": true,
+	"priority-classes":        true,
+	"flow-schemas":            true,
+	"priority-levels":         true,
+	"cluster-policy-reports":  true,
+	"constraint-templates":    true,
 }
 
 // isClusterScoped returns true if the given kind is not namespace-scoped.
@@ -118,6 +185,68 @@ func isClusterScoped(kind string) bool {
 	return clusterScopedKinds[strings.ToLower(kind)]
 }
 
+// podSpecFromObject extracts the pod spec from a pod or from the pod template of a workload.
+func podSpecFromObject(obj *unstructured.Unstructured) (*corev1.PodSpec, error) {
+	var path []string
+	switch obj.GetKind() {
+	case "Pod":
+		path = []string{"spec"}
+	case "CronJob":
+		path = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		path = []string{"spec", "template", "spec"}
+	}
+
+	raw, found, err := unstructured.NestedMap(obj.Object, path...)
+	if err != nil || !found {
+		return nil, fmt.Errorf("%s %s has no pod template", obj.GetKind(), obj.GetName())
+	}
+
+	var spec corev1.PodSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &spec); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// eventTimestamp returns the most relevant timestamp of a core/v1 Event object.
+func eventTimestamp(obj map[string]interface{}) time.Time {
+	var t time.Time
+	if lastTimestamp, ok, _ := unstructured.NestedString(obj, "lastTimestamp"); ok && lastTimestamp != "" {
+		t, _ = time.Parse(time.RFC3339, lastTimestamp)
+	} else if eventTime, ok, _ := unstructured.NestedString(obj, "eventTime"); ok && eventTime != "" {
+		t, _ = time.Parse(time.RFC3339Nano, eventTime)
+	}
+	return t
+}
+
+// leaseStatus classifies a Lease as Held, Expired (holder stopped renewing) or Released.
+func leaseStatus(holder string, obj map[string]interface{}, durationSeconds int64) string {
+	if holder == "" {
+		return "Released"
+	}
+	renew, _, _ := unstructured.NestedString(obj, "spec", "renewTime")
+	renewTime, err := time.Parse(time.RFC3339Nano, renew)
+	if err != nil {
+		return "Unknown"
+	}
+	if durationSeconds == 0 {
+		durationSeconds = 15
+	}
+	if time.Since(renewTime) > time.Duration(durationSeconds)*time.Second {
+		return "Expired"
+	}
+	return "Held"
+}
+
+// timestamp formats t as RFC3339 in UTC, or "" when it is not set.
+func timestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
 func getAge(t time.Time) string {
 	if t.IsZero() {
 		return "Unknown"
@@ -134,11 +263,40 @@ func getAge(t time.Time) string {
 }
 
 type ResourceItem struct {
-	Name      string            `json:"name"`
-	Namespace string            `json:"namespace,omitempty"`
-	Age       string            `json:"age"`
-	Status    string            `json:"status,omitempty"`
-	Extra     map[string]string `json:"extra,omitempty"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Age       string `json:"age"`
+	// CreationTimestamp is the RFC3339 time Age is counted from, for sorting and local-time display
+	CreationTimestamp string            `json:"creationTimestamp,omitempty"`
+	Status            string            `json:"status,omitempty"`
+	Extra             map[string]string `json:"extra,omitempty"`
+	Owner             *OwnerRef         `json:"owner,omitempty"` // Pods and replicasets only
+	// Labels are only used to group lists (?groupBy=label:<key>); they are not sent
+	Labels map[string]string `json:"-"`
+}
+
+// OwnerRef is the controller of a resource, in the same namespace.
+type OwnerRef struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// controllerOf returns the controller of a pod or replicaset. A pod created by a Deployment's
+// ReplicaSet is attributed to the Deployment: the ReplicaSet is named after the Deployment
+// followed by the pod-template-hash label, so no further lookup is needed.
+func controllerOf(item unstructured.Unstructured) *OwnerRef {
+	for _, ref := range item.GetOwnerReferences() {
+		if ref.Controller == nil || !*ref.Controller {
+			continue
+		}
+		owner := &OwnerRef{Kind: ref.Kind, Name: ref.Name}
+		hash := item.GetLabels()["pod-template-hash"]
+		if ref.Kind == "ReplicaSet" && item.GetKind() == "Pod" && hash != "" && strings.HasSuffix(ref.Name, "-"+hash) {
+			owner = &OwnerRef{Kind: "Deployment", Name: strings.TrimSuffix(ref.Name, "-"+hash)}
+		}
+		return owner
+	}
+	return nil
 }
 
 type MetricHistory struct {
@@ -147,19 +305,24 @@ type MetricHistory struct {
 }
 
 type ClusterStats struct {
-	K8sVersion     string          `json:"k8sVersion"`
-	NodeCount      int             `json:"nodeCount"`
-	PodCount       int             `json:"podCount"`
-	PodCountFailed int             `json:"podCountFailed"`
-	CPUUsage       float64         `json:"cpuUsage"` // Percentage
-	CPUTotal       string          `json:"cpuTotal"` // e.g., "32 Cores"
-	RAMUsage       float64         `json:"ramUsage"` // Percentage
-	RAMTotal       string          `json:"ramTotal"` // e.g., "128 GiB"
-	ClusterName    string          `json:"clusterName"`
-	ETCDHealth     string          `json:"etcdHealth"`
-	MetricsServer  bool            `json:"metricsServer"`
-	CPUHistory     []MetricHistory `json:"cpuHistory"`
-	RAMHistory     []MetricHistory `json:"ramHistory"`
+	K8sVersion     string              `json:"k8sVersion"`
+	NodeCount      int                 `json:"nodeCount"`
+	PodCount       int                 `json:"podCount"`
+	PodCountFailed int                 `json:"podCountFailed"`
+	CPUUsage       float64             `json:"cpuUsage"` // Percentage
+	CPUTotal       string              `json:"cpuTotal"` // e.g., "32 Cores"
+	RAMUsage       float64             `json:"ramUsage"` // Percentage
+	RAMTotal       string              `json:"ramTotal"` // e.g., "128 GiB"
+	ClusterName    string              `json:"clusterName"`
+	ETCDHealth     string              `json:"etcdHealth"`
+	MetricsServer  bool                `json:"metricsServer"`
+	// UsageSource says what CPUUsage and RAMUsage measure: "metrics-server" (actual usage of
+	// capacity), "requests" (pod requests of allocatable, when metrics-server is missing) or
+	// "none"
+	UsageSource    string              `json:"usageSource"`
+	CPUHistory     []MetricHistory     `json:"cpuHistory"`
+	RAMHistory     []MetricHistory     `json:"ramHistory"`
+	Maintenance    []MaintenanceWindow `json:"maintenance"` // Windows in effect now
 }
 
 func (h *ResourceHandler) GetStats(c *gin.Context) {
@@ -177,6 +340,7 @@ func (h *ResourceHandler) GetStats(c *gin.Context) {
 			ClusterName:    "development-mock",
 			ETCDHealth:     "Healthy",
 			MetricsServer:  true,
+			UsageSource:    "metrics-server",
 			CPUHistory: []MetricHistory{
 				{Timestamp: "08:00", Value: 35.0},
 				{Timestamp: "09:00", Value: 42.0},
@@ -185,6 +349,7 @@ func (h *ResourceHandler) GetStats(c *gin.Context) {
 				{Timestamp: "08:00", Value: 60.0},
 				{Timestamp: "09:00", Value: 62.0},
 			},
+			Maintenance: h.maintenance.Active(rbacNamespace(c)),
 		}
 		c.JSON(http.StatusOK, stats)
 		return
@@ -194,7 +359,7 @@ func (h *ResourceHandler) GetStats(c *gin.Context) {
 	ctx := c.Request.Context()
 	nodes, err := h.k8sClient.ListNodes(ctx)
 	if err != nil {
-		c.JSON(http.StatusOK, ClusterStats{ClusterName: "k-cluster (limited access)"}) // fail gracefully for viewers
+		c.JSON(http.StatusOK, ClusterStats{ClusterName: "k-cluster (limited access)", Maintenance: h.maintenance.Active(rbacNamespace(c))}) // fail gracefully for viewers
 		return
 	}
 
@@ -255,8 +420,24 @@ func (h *ResourceHandler) GetStats(c *gin.Context) {
 		RAMUsage:       ramUsage,
 		RAMTotal:       fmt.Sprintf("%d GiB", ramTotalInt),
 		ClusterName:    "Kubernetes",
-		ETCDHealth:     "Healthy", // Assume healthy if we can list nodes
+		ETCDHealth:     "Unknown",
 		MetricsServer:  hasMetrics,
+		UsageSource:    "metrics-server",
+		Maintenance:    h.maintenance.Active(rbacNamespace(c)),
+	}
+	if !hasMetrics {
+		// Without metrics-server, show how much of the schedulable capacity pods reserve
+		// rather than zeros; the dashboard labels it as requested, not used
+		stats.UsageSource = "none"
+		if cpu, ram, ok := requestedShare(nodes, pods); ok {
+			stats.CPUUsage, stats.RAMUsage, stats.UsageSource = cpu, ram, "requests"
+		}
+	}
+
+	for _, component := range h.checkComponents(ctx) {
+		if component.Name == "etcd" {
+			stats.ETCDHealth = component.Status
+		}
 	}
 
 	if len(nodes) > 0 {
@@ -288,6 +469,29 @@ func (h *ResourceHandler) GetStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// requestedShare returns the CPU and memory requested by running pods as a percentage of the
+// nodes' allocatable resources. ok is false if the nodes report nothing allocatable.
+func requestedShare(nodes []corev1.Node, pods []corev1.Pod) (cpu, ram float64, ok bool) {
+	var allocCPU, allocRAM, reqCPU, reqRAM int64
+	for _, n := range nodes {
+		allocCPU += n.Status.Allocatable.Cpu().MilliValue()
+		allocRAM += n.Status.Allocatable.Memory().Value()
+	}
+	if allocCPU == 0 || allocRAM == 0 {
+		return 0, 0, false
+	}
+	for _, p := range pods {
+		if p.Spec.NodeName == "" || p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, ctr := range p.Spec.Containers {
+			reqCPU += ctr.Resources.Requests.Cpu().MilliValue()
+			reqRAM += ctr.Resources.Requests.Memory().Value()
+		}
+	}
+	return float64(reqCPU) / float64(allocCPU) * 100.0, float64(reqRAM) / float64(allocRAM) * 100.0, true
+}
+
 func (h *ResourceHandler) List(c *gin.Context) {
 	kind := strings.ToLower(c.Param("kind"))
 	ns := c.Query("namespace")
@@ -300,32 +504,39 @@ func (h *ResourceHandler) List(c *gin.Context) {
 		ns = rbacNs.(string)
 	}
 
+	items, err := h.listItems(c.Request.Context(), kind, ns)
+	if err != nil {
+		apierror.Fail(c, "Failed to list resources", err)
+		return
+	}
+	respondList(c, items)
+}
+
+// listItems lists a kind in ns, or in all namespaces when ns is empty, as table rows.
+func (h *ResourceHandler) listItems(ctx context.Context, kind, ns string) ([]ResourceItem, error) {
 	// Serve mock data if running in developer mode
 	if h.devMode {
-		items := mockResourceList(kind, ns)
-		c.JSON(http.StatusOK, items)
-		return
+		return mockResourceList(kind, ns), nil
 	}
 
-	dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get dynamic client: " + err.Error()})
-		return
+		return nil, err
 	}
 
-	gvr := getGVR(kind)
+	gvr, clusterScoped := h.columns.gvr(kind)
+	columns := h.columns.forKind(kind)
 	
 	var listInterface dynamic.ResourceInterface
-	if ns != "" && !isClusterScoped(kind) {
+	if ns != "" && !clusterScoped {
 		listInterface = dynClient.Resource(gvr).Namespace(ns)
 	} else {
 		listInterface = dynClient.Resource(gvr)
 	}
 
-	unstructuredList, err := listInterface.List(c.Request.Context(), metav1.ListOptions{})
+	unstructuredList, err := listInterface.List(ctx, metav1.ListOptions{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list resources: " + err.Error()})
-		return
+		return nil, err
 	}
 
 	var items []ResourceItem
@@ -333,6 +544,7 @@ func (h *ResourceHandler) List(c *gin.Context) {
 		name := item.GetName()
 		namespace := item.GetNamespace()
 		age := getAge(item.GetCreationTimestamp().Time)
+		created := timestamp(item.GetCreationTimestamp().Time)
 		
 		status := "Active"
 		if statusMap, ok := item.Object["status"].(map[string]interface{}); ok {
@@ -348,6 +560,7 @@ func (h *ResourceHandler) List(c *gin.Context) {
 		}
 
 		extra := map[string]string{"kind": item.GetKind()}
+		var owner *OwnerRef
 		
 		switch kind {
 		case "configmaps":
@@ -429,6 +642,9 @@ func (h *ResourceHandler) List(c *gin.Context) {
 			// Just generic values if unavailable
 			extra["ready"] = "1/1"
 			extra["restarts"] = "0"
+			owner = controllerOf(item)
+		case "replicasets":
+			owner = controllerOf(item)
 		case "deployments":
 			replicas, _, _ := unstructured.NestedInt64(item.Object, "status", "replicas")
 			ready, _, _ := unstructured.NestedInt64(item.Object, "status", "readyReplicas")
@@ -476,6 +692,141 @@ func (h *ResourceHandler) List(c *gin.Context) {
 			if sc, ok, _ := unstructured.NestedString(item.Object, "spec", "storageClassName"); ok {
 				extra["storage-class"] = sc
 			}
+		case "validating-webhooks", "mutating-webhooks":
+			webhooks, _, _ := unstructured.NestedSlice(item.Object, "webhooks")
+			extra["webhooks"] = fmt.Sprintf("%d", len(webhooks))
+			policies := map[string]bool{}
+			for _, w := range webhooks {
+				if wm, ok := w.(map[string]interface{}); ok {
+					fp, _ := wm["failurePolicy"].(string)
+					if fp == "" {
+						fp = "Fail"
+					}
+					policies[fp] = true
+				}
+			}
+			var fps []string
+			for fp := range policies {
+				fps = append(fps, fp)
+			}
+			sort.Strings(fps)
+			extra["failure-policy"] = strings.Join(fps, ", ")
+		case "priority-classes":
+			if value, ok, _ := unstructured.NestedInt64(item.Object, "value"); ok {
+				extra["value"] = fmt.Sprintf("%d", value)
+			}
+			preemption, ok, _ := unstructured.NestedString(item.Object, "preemptionPolicy")
+			if !ok {
+				preemption = "PreemptLowerPriority"
+			}
+			extra["preemption-policy"] = preemption
+			if def, _, _ := unstructured.NestedBool(item.Object, "globalDefault"); def {
+				status = "Default"
+			}
+		case "volume-snapshots":
+			if pvc, ok, _ := unstructured.NestedString(item.Object, "spec", "source", "persistentVolumeClaimName"); ok {
+				extra["source-pvc"] = pvc
+			}
+			if class, ok, _ := unstructured.NestedString(item.Object, "spec", "volumeSnapshotClassName"); ok {
+				extra["snapshot-class"] = class
+			}
+			if size, ok, _ := unstructured.NestedString(item.Object, "status", "restoreSize"); ok {
+				extra["restore-size"] = size
+			}
+			status = "Pending"
+			if ready, _, _ := unstructured.NestedBool(item.Object, "status", "readyToUse"); ready {
+				status = "Ready"
+			} else if msg, ok, _ := unstructured.NestedString(item.Object, "status", "error", "message"); ok {
+				status = "Failed"
+				extra["error"] = msg
+			}
+		case "volume-snapshot-classes":
+			if driver, ok, _ := unstructured.NestedString(item.Object, "driver"); ok {
+				extra["driver"] = driver
+			}
+			if policy, ok, _ := unstructured.NestedString(item.Object, "deletionPolicy"); ok {
+				extra["deletion-policy"] = policy
+			}
+			if isDef, ok, _ := unstructured.NestedString(item.Object, "metadata", "annotations", "snapshot.storage.kubernetes.io/is-default-class"); ok && isDef == "true" {
+				status = "Default"
+			}
+		case "leases":
+			holder, _, _ := unstructured.NestedString(item.Object, "spec", "holderIdentity")
+			duration, _, _ := unstructured.NestedInt64(item.Object, "spec", "leaseDurationSeconds")
+			transitions, _, _ := unstructured.NestedInt64(item.Object, "spec", "leaseTransitions")
+			extra["holder"] = holder
+			extra["duration"] = fmt.Sprintf("%ds", duration)
+			extra["transitions"] = fmt.Sprintf("%d", transitions)
+			status = leaseStatus(holder, item.Object, duration)
+			if renew, ok, _ := unstructured.NestedString(item.Object, "spec", "renewTime"); ok {
+				if t, err := time.Parse(time.RFC3339Nano, renew); err == nil {
+					extra["renewed"] = getAge(t) + " ago"
+				}
+			}
+		case "virtual-services":
+			if hosts, ok, _ := unstructured.NestedStringSlice(item.Object, "spec", "hosts"); ok {
+				extra["hosts"] = strings.Join(hosts, ", ")
+			}
+			if gateways, ok, _ := unstructured.NestedStringSlice(item.Object, "spec", "gateways"); ok {
+				extra["gateways"] = strings.Join(gateways, ", ")
+			} else {
+				extra["gateways"] = "mesh"
+			}
+		case "destination-rules":
+			if host, ok, _ := unstructured.NestedString(item.Object, "spec", "host"); ok {
+				extra["host"] = host
+			}
+			subsets, _, _ := unstructured.NestedSlice(item.Object, "spec", "subsets")
+			extra["subsets"] = fmt.Sprintf("%d", len(subsets))
+			if mode, ok, _ := unstructured.NestedString(item.Object, "spec", "trafficPolicy", "tls", "mode"); ok {
+				extra["tls-mode"] = mode
+			}
+		case "istio-gateways":
+			if sel, ok, _ := unstructured.NestedStringMap(item.Object, "spec", "selector"); ok {
+				var parts []string
+				for k, v := range sel {
+					parts = append(parts, k+"="+v)
+				}
+				sort.Strings(parts)
+				extra["selector"] = strings.Join(parts, ",")
+			}
+			servers, _, _ := unstructured.NestedSlice(item.Object, "spec", "servers")
+			var hosts, ports []string
+			for _, raw := range servers {
+				server, _ := raw.(map[string]interface{})
+				h, _, _ := unstructured.NestedStringSlice(server, "hosts")
+				hosts = append(hosts, h...)
+				num, _, _ := unstructured.NestedInt64(server, "port", "number")
+				proto, _, _ := unstructured.NestedString(server, "port", "protocol")
+				ports = append(ports, fmt.Sprintf("%d/%s", num, proto))
+			}
+			extra["hosts"] = strings.Join(hosts, ", ")
+			extra["ports"] = strings.Join(ports, ", ")
+		case "peer-authentications":
+			mode, ok, _ := unstructured.NestedString(item.Object, "spec", "mtls", "mode")
+			if !ok {
+				mode = "UNSET"
+			}
+			status = mode
+			if sel, ok, _ := unstructured.NestedStringMap(item.Object, "spec", "selector", "matchLabels"); ok && len(sel) > 0 {
+				extra["selector"] = fmt.Sprintf("%v", sel)
+			} else {
+				extra["selector"] = "<namespace>"
+			}
+		case "flow-schemas":
+			if pl, ok, _ := unstructured.NestedString(item.Object, "spec", "priorityLevelConfiguration", "name"); ok {
+				extra["priority-level"] = pl
+			}
+			if prec, ok, _ := unstructured.NestedInt64(item.Object, "spec", "matchingPrecedence"); ok {
+				extra["precedence"] = fmt.Sprintf("%d", prec)
+			}
+		case "priority-levels":
+			if plType, ok, _ := unstructured.NestedString(item.Object, "spec", "type"); ok {
+				status = plType
+			}
+			if shares, ok, _ := unstructured.NestedInt64(item.Object, "spec", "limited", "nominalConcurrencyShares"); ok {
+				extra["shares"] = fmt.Sprintf("%d", shares)
+			}
 		case "persistentvolumes", "pvs":
 			if phase, ok, _ := unstructured.NestedString(item.Object, "status", "phase"); ok {
 				status = phase
@@ -494,17 +845,21 @@ func (h *ResourceHandler) List(c *gin.Context) {
 				extra["claim"] = fmt.Sprintf("%s/%s", claimNs, claimRef)
 			}
 		}
+		columns.apply(item.Object, extra)
 
 		items = append(items, ResourceItem{
-			Name:      name,
-			Namespace: namespace,
-			Age:       age,
-			Status:    status,
-			Extra:     extra,
+			Name:              name,
+			Namespace:         namespace,
+			Age:               age,
+			CreationTimestamp: created,
+			Status:            status,
+			Extra:             extra,
+			Owner:             owner,
+			Labels:            item.GetLabels(),
 		})
 	}
 
-	c.JSON(http.StatusOK, items)
+	return items, nil
 }
 
 func (h *ResourceHandler) GetDetails(c *gin.Context) {
@@ -519,7 +874,7 @@ func (h *ResourceHandler) GetDetails(c *gin.Context) {
 	if !isClusterScoped(kind) {
 		if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
 			if ns != rbacNs.(string) {
-				c.JSON(http.StatusForbidden, gin.H{"error": "access denied to namespace " + ns})
+				apierror.Write(c, http.StatusForbidden, "access denied to namespace "+ns)
 				return
 			}
 		}
@@ -536,7 +891,7 @@ func (h *ResourceHandler) GetDetails(c *gin.Context) {
 		}
 
 		if found == nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "resource not found"})
+			apierror.Write(c, http.StatusNotFound, "resource not found")
 			return
 		}
 
@@ -614,17 +969,25 @@ func (h *ResourceHandler) GetDetails(c *gin.Context) {
 			},
 		}
 
+		if kind == "pods" || kind == "pod" {
+			details["initContainers"] = []InitContainerStatus{
+				{Order: 1, Name: "wait-for-db", Image: "busybox:1.36", State: "Terminated", Reason: "Completed", ExitCode: new(int32)},
+				{Order: 2, Name: "log-shipper", Image: "fluent/fluent-bit:2.2", Sidecar: true, State: "Running", Ready: true, Started: true},
+			}
+			details["initProgress"] = "2/2"
+		}
+
 		c.JSON(http.StatusOK, details)
 		return
 	}
 
 	dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get dynamic client: " + err.Error()})
+		apierror.Fail(c, "Failed to get dynamic client", err)
 		return
 	}
 
-	gvr := getGVR(kind)
+	gvr, _ := h.columns.gvr(kind)
 	var resInterface dynamic.ResourceInterface
 	if ns != "" {
 		resInterface = dynClient.Resource(gvr).Namespace(ns)
@@ -634,7 +997,7 @@ func (h *ResourceHandler) GetDetails(c *gin.Context) {
 
 	item, err := resInterface.Get(c.Request.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "resource not found: " + err.Error()})
+		apierror.Fail(c, "Failed to get resource", err)
 		return
 	}
 
@@ -656,6 +1019,15 @@ func (h *ResourceHandler) GetDetails(c *gin.Context) {
 		if metrics != nil {
 			wrapped["metrics"] = metrics
 		}
+
+		// Surface init containers and sidecars in startup order alongside the raw status
+		var pod corev1.Pod
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &pod); err == nil {
+			initStatus, initProgress := podInitStatus(pod)
+			wrapped["initContainers"] = summarizeInitContainers(pod)
+			wrapped["initProgress"] = initProgress
+			wrapped["initStatus"] = initStatus
+		}
 	}
 
 	c.JSON(http.StatusOK, wrapped)
@@ -673,7 +1045,7 @@ func (h *ResourceHandler) GetYAML(c *gin.Context) {
 	if !isClusterScoped(kind) {
 		if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
 			if ns != rbacNs.(string) {
-				c.JSON(http.StatusForbidden, gin.H{"error": "access denied to namespace " + ns})
+				apierror.Write(c, http.StatusForbidden, "access denied to namespace "+ns)
 				return
 			}
 		}
@@ -731,7 +1103,7 @@ func (h *ResourceHandler) GetYAML(c *gin.Context) {
 		}
 
 		if marshalErr != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal mock resource"})
+			apierror.Write(c, http.StatusInternalServerError, "Failed to marshal mock resource")
 			return
 		}
 
@@ -741,11 +1113,11 @@ func (h *ResourceHandler) GetYAML(c *gin.Context) {
 
 	dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get dynamic client: " + err.Error()})
+		apierror.Fail(c, "Failed to get dynamic client", err)
 		return
 	}
 
-	gvr := getGVR(kind)
+	gvr, _ := h.columns.gvr(kind)
 	var resInterface dynamic.ResourceInterface
 	if ns != "" {
 		resInterface = dynClient.Resource(gvr).Namespace(ns)
@@ -755,7 +1127,7 @@ func (h *ResourceHandler) GetYAML(c *gin.Context) {
 
 	item, err := resInterface.Get(c.Request.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "resource not found: " + err.Error()})
+		apierror.Fail(c, "Failed to get resource", err)
 		return
 	}
 
@@ -775,7 +1147,7 @@ func (h *ResourceHandler) GetYAML(c *gin.Context) {
 	}
 
 	if marshalErr != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal resource"})
+		apierror.Write(c, http.StatusInternalServerError, "Failed to marshal resource")
 		return
 	}
 
@@ -794,7 +1166,7 @@ func (h *ResourceHandler) UpdateYAML(c *gin.Context) {
 	if !isClusterScoped(kind) {
 		if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
 			if ns != rbacNs.(string) {
-				c.JSON(http.StatusForbidden, gin.H{"error": "access denied to namespace " + ns})
+				apierror.Write(c, http.StatusForbidden, "access denied to namespace "+ns)
 				return
 			}
 		}
@@ -803,40 +1175,53 @@ func (h *ResourceHandler) UpdateYAML(c *gin.Context) {
 	// Verify Edit Permissions
 	role, exists := c.Get("role")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		apierror.Write(c, http.StatusUnauthorized, "Not authenticated")
 		return
 	}
 	roleStr := role.(string)
 	if roleStr != "kview-cluster-admin" && roleStr != "admin" && roleStr != "edit" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Editing permissions required (admin or edit role)"})
+		apierror.Write(c, http.StatusForbidden, "Editing permissions required (admin or edit role)")
+		return
+	}
+	if h.rejectProtected(c, kind, ns, name) {
 		return
 	}
 
 	body, err := c.GetRawData()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		apierror.Write(c, http.StatusBadRequest, "Failed to read request body")
 		return
 	}
 
-	if h.devMode {
-		fmt.Printf("[DEV MODE] Would update %s/%s/%s with YAML:\n%s\n", kind, ns, name, string(body))
-		c.JSON(http.StatusOK, gin.H{"message": "Resource updated (mocked)"})
+	var obj unstructured.Unstructured
+	if err := yaml.Unmarshal(body, &obj); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "Invalid YAML: "+err.Error())
 		return
 	}
 
-	var obj unstructured.Unstructured
-	if err := yaml.Unmarshal(body, &obj); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid YAML: " + err.Error()})
+	// Run admission-style policy checks on anything that carries a pod template
+	violations := []policy.Violation{}
+	if spec, err := podSpecFromObject(&obj); err == nil && h.policies != nil {
+		violations = h.policies.Evaluate(ns, spec)
+	}
+	if policy.Blocking(violations) {
+		c.JSON(http.StatusUnprocessableEntity, apierror.New(http.StatusUnprocessableEntity, "Rejected by policy").With(gin.H{"violations": violations}))
+		return
+	}
+
+	if h.devMode {
+		fmt.Printf("[DEV MODE] Would update %s/%s/%s with YAML:\n%s\n", kind, ns, name, string(body))
+		c.JSON(http.StatusOK, gin.H{"message": "Resource updated (mocked)", "warnings": violations})
 		return
 	}
 
 	dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get dynamic client: " + err.Error()})
+		apierror.Fail(c, "Failed to get dynamic client", err)
 		return
 	}
 
-	gvr := getGVR(kind)
+	gvr, _ := h.columns.gvr(kind)
 	var resInterface dynamic.ResourceInterface
 	if ns != "" {
 		resInterface = dynClient.Resource(gvr).Namespace(ns)
@@ -847,11 +1232,20 @@ func (h *ResourceHandler) UpdateYAML(c *gin.Context) {
 	// Use Update instead of Apply for simplicity and broad compatibility with unstructured objects
 	_, err = resInterface.Update(c.Request.Context(), &obj, metav1.UpdateOptions{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update resource: " + err.Error()})
+		apierror.Fail(c, "Failed to update resource", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Resource updated successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Resource updated successfully", "warnings": violations})
+}
+
+// ListPolicies returns the active policy rules so the editor can show them up front.
+func (h *ResourceHandler) ListPolicies(c *gin.Context) {
+	rules := []policy.Rule{}
+	if h.policies != nil {
+		rules = h.policies.Rules
+	}
+	c.JSON(http.StatusOK, rules)
 }
 
 func (h *ResourceHandler) Delete(c *gin.Context) {
@@ -866,7 +1260,7 @@ func (h *ResourceHandler) Delete(c *gin.Context) {
 	if !isClusterScoped(kind) {
 		if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
 			if ns != rbacNs.(string) {
-				c.JSON(http.StatusForbidden, gin.H{"error": "access denied to namespace " + ns})
+				apierror.Write(c, http.StatusForbidden, "access denied to namespace "+ns)
 				return
 			}
 		}
@@ -875,28 +1269,60 @@ func (h *ResourceHandler) Delete(c *gin.Context) {
 	// Verify Delete Permissions
 	role, exists := c.Get("role")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		apierror.Write(c, http.StatusUnauthorized, "Not authenticated")
 		return
 	}
 	roleStr := role.(string)
 	if roleStr != "kview-cluster-admin" && roleStr != "admin" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admin permissions required to delete resources"})
+		apierror.Write(c, http.StatusForbidden, "Admin permissions required to delete resources")
+		return
+	}
+	if h.rejectProtected(c, kind, ns, name) {
 		return
 	}
 
 	force := c.Query("force") == "true"
+
+	// Protected targets go through the two-person approval queue instead of being deleted now
+	if h.policies.RequiresApproval(kind, policyNamespace(kind, ns, name), apiResource) {
+		email, _ := c.Get("email")
+		action, created, err := h.approvals.submit(PendingAction{Action: "delete", Kind: kind, Namespace: ns, Name: name, Force: force, RequestedBy: email.(string)})
+		if err != nil {
+			apierror.Fail(c, "Failed to queue delete for approval", err)
+			return
+		}
+		msg := "Delete requires approval by a second admin"
+		if !created {
+			msg = "A delete of this resource is already awaiting approval"
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": msg, "approval": action})
+		return
+	}
+
+	if err := h.deleteResource(c.Request.Context(), kind, ns, name, force); err != nil {
+		apierror.Fail(c, "Failed to delete resource", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Resource deleted"})
+}
+
+// deleteResource deletes one object, immediately if force is set or with a 30s grace period.
+func (h *ResourceHandler) deleteResource(ctx context.Context, kind, ns, name string, force bool) error {
+	if h.devMode {
+		return nil
+	}
 	gracePeriod := int64(30)
 	if force {
 		gracePeriod = 0
 	}
 
-	dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get dynamic client: " + err.Error()})
-		return
+		return err
 	}
 
-	gvr := getGVR(kind)
+	gvr, _ := h.columns.gvr(kind)
 	var dc dynamic.ResourceInterface
 	if ns != "" {
 		dc = dynClient.Resource(gvr).Namespace(ns)
@@ -904,15 +1330,9 @@ func (h *ResourceHandler) Delete(c *gin.Context) {
 		dc = dynClient.Resource(gvr)
 	}
 
-	err = dc.Delete(c.Request.Context(), name, metav1.DeleteOptions{
+	return dc.Delete(ctx, name, metav1.DeleteOptions{
 		GracePeriodSeconds: &gracePeriod,
 	})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete resource: " + err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Resource deleted"})
 }
 
 func (h *ResourceHandler) Restart(c *gin.Context) {
@@ -926,7 +1346,10 @@ func (h *ResourceHandler) Restart(c *gin.Context) {
 	// Verify Edit Permissions
 	role, _ := c.Get("role")
 	if role.(string) != "kview-cluster-admin" && role.(string) != "admin" && role.(string) != "edit" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admin/Edit permissions required"})
+		apierror.Write(c, http.StatusForbidden, "Admin/Edit permissions required")
+		return
+	}
+	if h.rejectProtected(c, kind, ns, name) {
 		return
 	}
 
@@ -937,7 +1360,7 @@ func (h *ResourceHandler) Restart(c *gin.Context) {
 
 	dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Client failed"})
+		apierror.Write(c, http.StatusInternalServerError, "Client failed")
 		return
 	}
 
@@ -953,7 +1376,7 @@ func (h *ResourceHandler) Restart(c *gin.Context) {
 	if kind == "pods" || kind == "pod" {
 		err = dc.Delete(c.Request.Context(), name, metav1.DeleteOptions{})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			apierror.Fail(c, "", err)
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{"message": "Pod deletion triggered (restart)"})
@@ -963,7 +1386,7 @@ func (h *ResourceHandler) Restart(c *gin.Context) {
 	// For Deployments, StatefulSets, DaemonSets - update annotation
 	obj, err := dc.Get(c.Request.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Fetch failed: " + err.Error()})
+		apierror.Fail(c, "Fetch failed", err)
 		return
 	}
 
@@ -973,7 +1396,7 @@ func (h *ResourceHandler) Restart(c *gin.Context) {
 
 	_, err = dc.Update(c.Request.Context(), obj, metav1.UpdateOptions{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Restart failed: " + err.Error()})
+		apierror.Fail(c, "Restart failed", err)
 		return
 	}
 
@@ -992,14 +1415,17 @@ func (h *ResourceHandler) Scale(c *gin.Context) {
 		Replicas int64 `json:"replicas"`
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		apierror.Write(c, http.StatusBadRequest, "Invalid input")
 		return
 	}
 
 	// Verify Edit Permissions
 	role, _ := c.Get("role")
 	if role.(string) != "kview-cluster-admin" && role.(string) != "admin" && role.(string) != "edit" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Admin/Edit permissions required"})
+		apierror.Write(c, http.StatusForbidden, "Admin/Edit permissions required")
+		return
+	}
+	if h.rejectProtected(c, kind, ns, name) {
 		return
 	}
 
@@ -1010,7 +1436,7 @@ func (h *ResourceHandler) Scale(c *gin.Context) {
 
 	dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Client failed"})
+		apierror.Write(c, http.StatusInternalServerError, "Client failed")
 		return
 	}
 
@@ -1024,7 +1450,7 @@ func (h *ResourceHandler) Scale(c *gin.Context) {
 
 	obj, err := dc.Get(c.Request.Context(), name, metav1.GetOptions{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Fetch failed"})
+		apierror.Write(c, http.StatusInternalServerError, "Fetch failed")
 		return
 	}
 
@@ -1032,7 +1458,7 @@ func (h *ResourceHandler) Scale(c *gin.Context) {
 
 	_, err = dc.Update(c.Request.Context(), obj, metav1.UpdateOptions{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Scale failed: " + err.Error()})
+		apierror.Fail(c, "Scale failed", err)
 		return
 	}
 
@@ -1050,7 +1476,7 @@ func (h *ResourceHandler) GetEvents(c *gin.Context) {
 	// Apply RBAC namespace restriction
 	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
 		if ns != rbacNs.(string) {
-			c.JSON(http.StatusForbidden, gin.H{"error": "access denied to namespace " + ns})
+			apierror.Write(c, http.StatusForbidden, "access denied to namespace "+ns)
 			return
 		}
 	}
@@ -1065,7 +1491,7 @@ func (h *ResourceHandler) GetEvents(c *gin.Context) {
 
 	dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get dynamic client: " + err.Error()})
+		apierror.Fail(c, "Failed to get dynamic client", err)
 		return
 	}
 
@@ -1086,13 +1512,7 @@ func (h *ResourceHandler) GetEvents(c *gin.Context) {
 		eType, _, _ := unstructured.NestedString(e.Object, "type")
 		reason, _, _ := unstructured.NestedString(e.Object, "reason")
 		message, _, _ := unstructured.NestedString(e.Object, "message")
-		
-		var t time.Time
-		if lastTimestamp, ok, _ := unstructured.NestedString(e.Object, "lastTimestamp"); ok && lastTimestamp != "" {
-			t, _ = time.Parse(time.RFC3339, lastTimestamp)
-		} else if eventTime, ok, _ := unstructured.NestedString(e.Object, "eventTime"); ok && eventTime != "" {
-			t, _ = time.Parse(time.RFC3339Nano, eventTime)
-		}
+		t := eventTimestamp(e.Object)
 
 		events = append(events, gin.H{
 			"type":    eType,
@@ -1135,17 +1555,17 @@ func mockResourceList(kind, ns string) []ResourceItem {
 	switch kind {
 	case "pods":
 		items = []ResourceItem{
-			{Name: "frontend-web-5d8f7b", Namespace: "default", Age: "19h", Status: "Running", Extra: ex("ready", "1/1", "restarts", "0")},
-			{Name: "backend-api-6c9f8c", Namespace: "default", Age: "4h", Status: "Running", Extra: ex("ready", "1/1", "restarts", "0")},
-			{Name: "worker-job-abc12", Namespace: "default", Age: "2h", Status: "CrashLoopBackOff", Extra: ex("ready", "0/1", "restarts", "8")},
-			{Name: "cache-redis-001", Namespace: "default", Age: "3h", Status: "Running", Extra: ex("ready", "1/1", "restarts", "0")},
-			{Name: "auth-service-xyz", Namespace: "auth", Age: "1h", Status: "Running", Extra: ex("ready", "1/1", "restarts", "0")},
+			{Name: "frontend-web-5d8f7b", Namespace: "default", Age: "19h", Status: "Running", Extra: ex("ready", "1/1", "restarts", "0"), Owner: &OwnerRef{Kind: "Deployment", Name: "frontend-web"}},
+			{Name: "backend-api-6c9f8c", Namespace: "default", Age: "4h", Status: "Running", Extra: ex("ready", "1/1", "restarts", "0"), Owner: &OwnerRef{Kind: "Deployment", Name: "backend-api"}},
+			{Name: "worker-job-abc12", Namespace: "default", Age: "2h", Status: "CrashLoopBackOff", Extra: ex("ready", "0/1", "restarts", "8"), Owner: &OwnerRef{Kind: "Job", Name: "worker-job"}},
+			{Name: "cache-redis-001", Namespace: "default", Age: "3h", Status: "Running", Extra: ex("ready", "1/1", "restarts", "0"), Owner: &OwnerRef{Kind: "Deployment", Name: "cache-redis"}},
+			{Name: "auth-service-xyz", Namespace: "auth", Age: "1h", Status: "Running", Extra: ex("ready", "1/1", "restarts", "0"), Owner: &OwnerRef{Kind: "Deployment", Name: "auth-service"}},
 			{Name: "oauth-proxy-001", Namespace: "auth", Age: "30m", Status: "Running", Extra: ex("ready", "1/1", "restarts", "0")},
-			{Name: "postgres-primary-0", Namespace: "database", Age: "2d", Status: "Running", Extra: ex("ready", "1/1", "restarts", "0")},
-			{Name: "kafka-broker-0", Namespace: "messaging", Age: "3d", Status: "Running", Extra: ex("ready", "1/1", "restarts", "0")},
+			{Name: "postgres-primary-0", Namespace: "database", Age: "2d", Status: "Running", Extra: ex("ready", "1/1", "restarts", "0"), Owner: &OwnerRef{Kind: "StatefulSet", Name: "postgres-primary"}},
+			{Name: "kafka-broker-0", Namespace: "messaging", Age: "3d", Status: "Running", Extra: ex("ready", "1/1", "restarts", "0"), Owner: &OwnerRef{Kind: "StatefulSet", Name: "kafka-broker"}},
 			{Name: "prometheus-0", Namespace: "monitoring", Age: "1d", Status: "Running", Extra: ex("ready", "1/1", "restarts", "0")},
-			{Name: "alertmanager-0", Namespace: "monitoring", Age: "1h", Status: "CrashLoopBackOff", Extra: ex("ready", "0/1", "restarts", "3")},
-			{Name: "coredns-5d78c9b4", Namespace: "kube-system", Age: "7d", Status: "Running", Extra: ex("ready", "1/1", "restarts", "0")},
+			{Name: "alertmanager-0", Namespace: "monitoring", Age: "1h", Status: "CrashLoopBackOff", Extra: ex("ready", "0/1", "restarts", "3"), Owner: &OwnerRef{Kind: "StatefulSet", Name: "alertmanager"}},
+			{Name: "coredns-5d78c9b4", Namespace: "kube-system", Age: "7d", Status: "Running", Extra: ex("ready", "1/1", "restarts", "0"), Owner: &OwnerRef{Kind: "Deployment", Name: "coredns"}},
 		}
 
 	case "deployments":
@@ -1358,7 +1778,111 @@ func mockResourceList(kind, ns string) []ResourceItem {
 			{Name: "default-limits", Namespace: "default", Age: "30d", Extra: ex("limits", "Container: cpu 100m-1, mem 128Mi-1Gi")},
 			{Name: "db-limits", Namespace: "database", Age: "25d", Extra: ex("limits", "Container: cpu 500m-2, mem 512Mi-4Gi")},
 		}
+
+	case "validating-webhooks":
+		items = []ResourceItem{
+			{Name: "cert-manager-webhook", Age: "30d", Extra: ex("webhooks", "1", "failure-policy", "Fail")},
+			{Name: "ingress-nginx-admission", Age: "30d", Extra: ex("webhooks", "1", "failure-policy", "Fail")},
+			{Name: "kyverno-resource-validating-webhook-cfg", Age: "12d", Extra: ex("webhooks", "2", "failure-policy", "Fail, Ignore")},
+		}
+
+	case "mutating-webhooks":
+		items = []ResourceItem{
+			{Name: "cert-manager-webhook", Age: "30d", Extra: ex("webhooks", "1", "failure-policy", "Fail")},
+			{Name: "istio-sidecar-injector", Age: "15d", Extra: ex("webhooks", "1", "failure-policy", "Ignore")},
+		}
+
+	case "priority-classes":
+		items = []ResourceItem{
+			{Name: "system-node-critical", Age: "30d", Status: "Active", Extra: ex("value", "2000001000", "preemption-policy", "PreemptLowerPriority")},
+			{Name: "system-cluster-critical", Age: "30d", Status: "Active", Extra: ex("value", "2000000000", "preemption-policy", "PreemptLowerPriority")},
+			{Name: "high-priority", Age: "25d", Status: "Active", Extra: ex("value", "100000", "preemption-policy", "PreemptLowerPriority")},
+			{Name: "batch-low", Age: "15d", Status: "Active", Extra: ex("value", "-10", "preemption-policy", "Never")},
+		}
+
+	case "volume-snapshots":
+		items = []ResourceItem{
+			{Name: "postgres-data-pvc-20260214-020000", Namespace: "database", Age: "2d", Status: "Ready", Extra: ex("source-pvc", "postgres-data-pvc", "snapshot-class", "csi-gce-pd", "restore-size", "50Gi")},
+			{Name: "postgres-data-pvc-20260216-020000", Namespace: "database", Age: "4h", Status: "Ready", Extra: ex("source-pvc", "postgres-data-pvc", "snapshot-class", "csi-gce-pd", "restore-size", "50Gi")},
+			{Name: "kafka-data-pvc-0-pre-upgrade", Namespace: "messaging", Age: "5m", Status: "Pending", Extra: ex("source-pvc", "kafka-data-pvc-0", "snapshot-class", "csi-gce-pd")},
+		}
+
+	case "volume-snapshot-classes":
+		items = []ResourceItem{
+			{Name: "csi-gce-pd", Age: "30d", Status: "Default", Extra: ex("driver", "pd.csi.storage.gke.io", "deletion-policy", "Delete")},
+			{Name: "csi-gce-pd-retain", Age: "30d", Extra: ex("driver", "pd.csi.storage.gke.io", "deletion-policy", "Retain")},
+		}
+
+	case "leases":
+		items = []ResourceItem{
+			{Name: "kube-scheduler", Namespace: "kube-system", Age: "30d", Status: "Held", Extra: ex("holder", "master-01_8e3c1f0a-2b7d-4c59-a1e2-3f4b5c6d7e8f", "renewed", "2s ago", "duration", "15s", "transitions", "3")},
+			{Name: "kube-controller-manager", Namespace: "kube-system", Age: "30d", Status: "Held", Extra: ex("holder", "master-01_1a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d", "renewed", "1s ago", "duration", "15s", "transitions", "4")},
+			{Name: "cert-manager-controller", Namespace: "cert-manager", Age: "30d", Status: "Expired", Extra: ex("holder", "cert-manager-7d9f8b6c5-x2k4p-external-cert-manager-controller", "renewed", "14m ago", "duration", "60s", "transitions", "7")},
+			{Name: "ingress-nginx-leader", Namespace: "ingress-nginx", Age: "30d", Status: "Held", Extra: ex("holder", "ingress-nginx-controller-6b8f9c7d5-abcde", "renewed", "9s ago", "duration", "30s", "transitions", "2")},
+			{Name: "worker-01", Namespace: "kube-node-lease", Age: "30d", Status: "Held", Extra: ex("holder", "worker-01", "renewed", "5s ago", "duration", "40s", "transitions", "0")},
+			{Name: "worker-02", Namespace: "kube-node-lease", Age: "30d", Status: "Held", Extra: ex("holder", "worker-02", "renewed", "7s ago", "duration", "40s", "transitions", "0")},
+		}
+
+	case "virtual-services":
+		items = []ResourceItem{
+			{Name: "frontend", Namespace: "default", Age: "15d", Status: "Active", Extra: ex("hosts", "app.example.com, frontend-svc", "gateways", "istio-system/public-gateway, mesh")},
+			{Name: "backend-canary", Namespace: "default", Age: "2d", Status: "Active", Extra: ex("hosts", "backend-svc", "gateways", "mesh")},
+		}
+
+	case "destination-rules":
+		items = []ResourceItem{
+			{Name: "backend-versions", Namespace: "default", Age: "2d", Status: "Active", Extra: ex("host", "backend-svc", "subsets", "2", "tls-mode", "ISTIO_MUTUAL")},
+			{Name: "postgres", Namespace: "database", Age: "25d", Status: "Active", Extra: ex("host", "postgres-primary.database.svc.cluster.local", "subsets", "0", "tls-mode", "DISABLE")},
+		}
+
+	case "istio-gateways":
+		items = []ResourceItem{
+			{Name: "public-gateway", Namespace: "istio-system", Age: "15d", Status: "Active", Extra: ex("selector", "istio=ingressgateway", "hosts", "app.example.com, api.example.com", "ports", "443/HTTPS, 80/HTTP")},
+		}
+
+	case "peer-authentications":
+		items = []ResourceItem{
+			{Name: "default", Namespace: "istio-system", Age: "15d", Status: "STRICT", Extra: ex("selector", "<namespace>")},
+			{Name: "postgres-permissive", Namespace: "database", Age: "25d", Status: "PERMISSIVE", Extra: ex("selector", "map[app:postgres]")},
+		}
+
+	case "flow-schemas":
+		items = []ResourceItem{
+			{Name: "exempt", Age: "30d", Status: "Active", Extra: ex("priority-level", "exempt", "precedence", "1")},
+			{Name: "system-leader-election", Age: "30d", Status: "Active", Extra: ex("priority-level", "leader-election", "precedence", "100")},
+			{Name: "kube-controller-manager", Age: "30d", Status: "Active", Extra: ex("priority-level", "workload-high", "precedence", "800")},
+			{Name: "service-accounts", Age: "30d", Status: "Active", Extra: ex("priority-level", "workload-low", "precedence", "9000")},
+			{Name: "global-default", Age: "30d", Status: "Active", Extra: ex("priority-level", "global-default", "precedence", "9900")},
+		}
+
+	case "priority-levels":
+		items = []ResourceItem{
+			{Name: "exempt", Age: "30d", Status: "Exempt"},
+			{Name: "leader-election", Age: "30d", Status: "Limited", Extra: ex("shares", "10")},
+			{Name: "workload-high", Age: "30d", Status: "Limited", Extra: ex("shares", "40")},
+			{Name: "workload-low", Age: "30d", Status: "Limited", Extra: ex("shares", "100")},
+			{Name: "global-default", Age: "30d", Status: "Limited", Extra: ex("shares", "20")},
+		}
 	}
 
-	return filter(items, ns)
+	items = filter(items, ns)
+	// Mock items only have an age; derive their creation time from it, and give them the
+	// recommended app labels so lists can be grouped
+	now := time.Now()
+	for i := range items {
+		app := items[i].Name
+		if items[i].Owner != nil {
+			app = items[i].Owner.Name
+		}
+		items[i].Labels = map[string]string{"app.kubernetes.io/name": app}
+		var n int
+		var unit string
+		if _, err := fmt.Sscanf(items[i].Age, "%d%s", &n, &unit); err != nil {
+			continue
+		}
+		if d, ok := map[string]time.Duration{"d": 24 * time.Hour, "h": time.Hour, "m": time.Minute, "s": time.Second}[unit]; ok {
+			items[i].CreationTimestamp = timestamp(now.Add(-time.Duration(n) * d))
+		}
+	}
+	return items
 }
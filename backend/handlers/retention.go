@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"k-view/store"
+)
+
+// RetentionPolicy says how long each kind of history in the data store is kept.
+type RetentionPolicy struct {
+	AuditLog time.Duration // Audit entries kept for export
+	SlowLog  time.Duration // Slow requests, with their Kubernetes API call metrics
+	Events   time.Duration // The event archive
+	// Sessions bounds the recorded sign-outs. They are kept for at least the session lifetime
+	// (24h) whatever the setting, or revoked ID tokens would be accepted again.
+	Sessions time.Duration
+}
+
+// PruneResult is what one pruning pass did to one collection.
+type PruneResult struct {
+	Collection string `json:"collection"`
+	Retention  string `json:"retention"`
+	Removed    int    `json:"removed"`         // Day files for journals, records for documents
+	Days       int    `json:"days,omitempty"`  // Day files left, for journals
+	Bytes      int64  `json:"bytes,omitempty"` // Size left, for journals
+	Error      string `json:"error,omitempty"`
+}
+
+// PruneRun is one pass of the pruner.
+type PruneRun struct {
+	StartedAt  time.Time     `json:"startedAt"`
+	DurationMs int64         `json:"durationMs"`
+	Results    []PruneResult `json:"results"`
+}
+
+// Pruner enforces the retention policy on the data store. Journals are pruned by whole UTC
+// days, so up to a day more than the retention may be kept. There is no database to vacuum:
+// documents are rewritten in full on every update and journal days are deleted as files, so
+// pruning returns the space to the volume right away. The in-memory usage and
+// kube-state-metrics histories are bounded by their own windows and are not covered here.
+// Replicas sharing a volume may each prune; a pass is idempotent.
+type Pruner struct {
+	store       *store.Store
+	policy      RetentionPolicy
+	revocations *SessionRevocations
+
+	mu   sync.Mutex // Serializes passes; guards last
+	last *PruneRun
+}
+
+func NewPruner(st *store.Store, policy RetentionPolicy, revocations *SessionRevocations) *Pruner {
+	return &Pruner{store: st, policy: policy, revocations: revocations}
+}
+
+// Run prunes at startup and then every interval.
+func (p *Pruner) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		p.prune()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// prune runs one pass over every collection.
+func (p *Pruner) prune() PruneRun {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	run := PruneRun{StartedAt: time.Now().UTC()}
+	journals := []struct {
+		name      string
+		retention time.Duration
+	}{
+		{auditJournal, p.policy.AuditLog},
+		{slowLogJournal, p.policy.SlowLog},
+		{eventJournal, p.policy.Events},
+	}
+	for _, j := range journals {
+		result := PruneResult{Collection: j.name, Retention: j.retention.String()}
+		removed, err := p.store.Prune(j.name, run.StartedAt.Add(-j.retention))
+		if err == nil {
+			result.Removed = removed
+			result.Days, result.Bytes, err = p.store.JournalUsage(j.name)
+		}
+		if err != nil {
+			result.Error = err.Error()
+			log.Printf("Retention: %s: %v", j.name, err)
+		} else if removed > 0 {
+			log.Printf("Retention: pruned %d day(s) of %s older than %s", removed, j.name, j.retention)
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	result := PruneResult{Collection: sessionLogoutsDoc, Retention: max(p.policy.Sessions, sessionLifetime).String()}
+	removed, err := p.revocations.prune(run.StartedAt.Add(-p.policy.Sessions))
+	if err != nil {
+		result.Error = err.Error()
+		log.Printf("Retention: %s: %v", sessionLogoutsDoc, err)
+	}
+	result.Removed = removed
+	run.Results = append(run.Results, result)
+
+	run.DurationMs = time.Since(run.StartedAt).Milliseconds()
+	p.last = &run
+	return run
+}
+
+// Status returns the retention policy with the outcome of the last pass (admin only).
+func (p *Pruner) Status(c *gin.Context) {
+	p.mu.Lock()
+	last := p.last
+	p.mu.Unlock()
+	c.JSON(http.StatusOK, gin.H{
+		"policy": gin.H{
+			"auditLog": p.policy.AuditLog.String(),
+			"slowLog":  p.policy.SlowLog.String(),
+			"events":   p.policy.Events.String(),
+			"sessions": max(p.policy.Sessions, sessionLifetime).String(),
+		},
+		"lastRun": last,
+	})
+}
+
+// PruneNow runs a pass right away and returns its outcome (admin only).
+func (p *Pruner) PruneNow(c *gin.Context) {
+	email, _ := c.Get("email")
+	auditf("data store pruning run by %v", email)
+	c.JSON(http.StatusOK, p.prune())
+}
@@ -0,0 +1,522 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"k-view/apierror"
+)
+
+// rolloutHashLabel is the label Argo Rollouts puts on the pods of each revision.
+const rolloutHashLabel = "rollouts-pod-template-hash"
+
+// rolloutActionDone is the past tense of the rollout actions, for messages.
+var rolloutActionDone = map[string]string{"promote": "promoted", "abort": "aborted"}
+
+// RevisionHealth is the state of the pods of one revision of a rollout.
+type RevisionHealth struct {
+	Name      string   `json:"name"` // Pod template hash, or Deployment name for pairs
+	Images    []string `json:"images"`
+	Replicas  int      `json:"replicas"`
+	Ready     int      `json:"ready"`
+	Restarts  int32    `json:"restarts"`
+	Unhealthy []string `json:"unhealthy,omitempty"` // "pod: reason" of pods that are not ready
+	Healthy   bool     `json:"healthy"`
+}
+
+// TrafficSplit is how a Service's traffic is divided between the stable and canary revisions.
+type TrafficSplit struct {
+	Service      string `json:"service"`
+	StableWeight int    `json:"stableWeight"`
+	CanaryWeight int    `json:"canaryWeight"`
+	Source       string `json:"source"` // trafficRouting (set by Argo), activeService, or readyPods
+}
+
+// RolloutStatus is the progressive delivery state of an Argo Rollout or a Deployment pair.
+type RolloutStatus struct {
+	Kind      string          `json:"kind"` // Rollout or DeploymentPair
+	Name      string          `json:"name"`
+	Namespace string          `json:"namespace"`
+	Strategy  string          `json:"strategy"` // canary or blueGreen
+	Phase     string          `json:"phase"`
+	Message   string          `json:"message,omitempty"`
+	Paused    bool            `json:"paused"`
+	Aborted   bool            `json:"aborted"`
+	Step      int64           `json:"step,omitempty"`  // Current canary step index
+	Steps     int             `json:"steps,omitempty"` // Number of canary steps
+	Stable    *RevisionHealth `json:"stable,omitempty"`
+	Canary    *RevisionHealth `json:"canary,omitempty"` // Unset when no update is in progress
+	Traffic   []TrafficSplit  `json:"traffic"`
+}
+
+// ListRollouts lists the Argo Rollouts of a namespace with the health of their stable and
+// canary revisions and the traffic split, or reports installed=false on clusters without
+// Argo Rollouts.
+func (h *ResourceHandler) ListRollouts(c *gin.Context) {
+	ns := c.Param("namespace")
+	if rbacNs := c.GetString("namespace"); rbacNs != "" && ns != rbacNs {
+		apierror.Write(c, http.StatusForbidden, "access denied to namespace "+ns)
+		return
+	}
+	if h.devMode {
+		c.JSON(http.StatusOK, gin.H{"installed": true, "rollouts": mockRollouts(ns)})
+		return
+	}
+
+	ctx := c.Request.Context()
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+	list, err := dynClient.Resource(getGVR("rollouts")).Namespace(ns).List(ctx, metav1.ListOptions{})
+	if k8serrors.IsNotFound(err) {
+		c.JSON(http.StatusOK, gin.H{"installed": false, "rollouts": []RolloutStatus{}})
+		return
+	}
+	if err != nil {
+		apierror.Fail(c, "Failed to list rollouts", err)
+		return
+	}
+	pods, err := h.namespacePods(ctx, ns)
+	if err != nil {
+		apierror.Fail(c, "Failed to list pods", err)
+		return
+	}
+
+	result := []RolloutStatus{}
+	for _, item := range list.Items {
+		result = append(result, argoRolloutStatus(item, pods))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	c.JSON(http.StatusOK, gin.H{"installed": true, "rollouts": result})
+}
+
+// argoRolloutStatus reads the state of an Argo Rollout and the health of its revisions from
+// the pods of the namespace.
+func argoRolloutStatus(item unstructured.Unstructured, pods []corev1.Pod) RolloutStatus {
+	str := func(path ...string) string {
+		v, _, _ := unstructured.NestedString(item.Object, path...)
+		return v
+	}
+	st := RolloutStatus{Kind: "Rollout", Name: item.GetName(), Namespace: item.GetNamespace(), Strategy: "canary", Phase: str("status", "phase"), Message: str("status", "message"), Traffic: []TrafficSplit{}}
+	st.Paused, _, _ = unstructured.NestedBool(item.Object, "spec", "paused")
+	if conditions, _, _ := unstructured.NestedSlice(item.Object, "status", "pauseConditions"); len(conditions) > 0 {
+		st.Paused = true
+	}
+	st.Aborted, _, _ = unstructured.NestedBool(item.Object, "status", "abort")
+	if _, found, _ := unstructured.NestedMap(item.Object, "spec", "strategy", "blueGreen"); found {
+		st.Strategy = "blueGreen"
+	}
+
+	stableHash, currentHash := str("status", "stableRS"), str("status", "currentPodHash")
+	canaryHash := ""
+	if st.Strategy == "blueGreen" {
+		stableHash = str("status", "blueGreen", "activeSelector")
+		canaryHash = str("status", "blueGreen", "previewSelector")
+	} else if currentHash != stableHash {
+		canaryHash = currentHash
+	}
+	if stableHash != "" {
+		st.Stable = revisionHealth(stableHash, podsWithLabel(pods, rolloutHashLabel, stableHash))
+	}
+	if canaryHash != "" && canaryHash != stableHash {
+		st.Canary = revisionHealth(canaryHash, podsWithLabel(pods, rolloutHashLabel, canaryHash))
+	}
+
+	if st.Strategy == "blueGreen" {
+		// The active Service gets all traffic; the preview Service only serves testers
+		if svc := str("spec", "strategy", "blueGreen", "activeService"); svc != "" {
+			st.Traffic = append(st.Traffic, TrafficSplit{Service: svc, StableWeight: 100, Source: "activeService"})
+		}
+		if svc := str("spec", "strategy", "blueGreen", "previewService"); svc != "" && st.Canary != nil {
+			st.Traffic = append(st.Traffic, TrafficSplit{Service: svc, CanaryWeight: 100, Source: "activeService"})
+		}
+		return st
+	}
+
+	steps, _, _ := unstructured.NestedSlice(item.Object, "spec", "strategy", "canary", "steps")
+	st.Steps = len(steps)
+	st.Step, _, _ = unstructured.NestedInt64(item.Object, "status", "currentStepIndex")
+	svc := str("spec", "strategy", "canary", "stableService")
+	if canary, found, _ := unstructured.NestedInt64(item.Object, "status", "canary", "weights", "canary", "weight"); found {
+		stable, _, _ := unstructured.NestedInt64(item.Object, "status", "canary", "weights", "stable", "weight")
+		st.Traffic = append(st.Traffic, TrafficSplit{Service: svc, StableWeight: int(stable), CanaryWeight: int(canary), Source: "trafficRouting"})
+	} else if st.Stable != nil {
+		// Without traffic routing the split follows the ready pods behind the shared Service
+		split := readyPodSplit(svc, st.Stable, st.Canary)
+		st.Traffic = append(st.Traffic, split)
+	}
+	return st
+}
+
+// PromoteRollout moves an Argo Rollout forward, like `kubectl argo rollouts promote`: it resumes
+// a paused rollout, or skips to the next canary step when nothing is paused. ?full=true skips
+// all remaining steps and analysis.
+func (h *ResourceHandler) PromoteRollout(c *gin.Context) {
+	h.decideRollout(c, "promote")
+}
+
+// AbortRollout aborts an Argo Rollout update, scaling the canary down and sending all traffic
+// back to the stable revision.
+func (h *ResourceHandler) AbortRollout(c *gin.Context) {
+	h.decideRollout(c, "abort")
+}
+
+func (h *ResourceHandler) decideRollout(c *gin.Context, action string) {
+	ns, name := c.Param("namespace"), c.Param("name")
+	full := action == "promote" && c.Query("full") == "true"
+	if !requireEditAccess(c, ns) || h.rejectProtected(c, "rollouts", ns, name) {
+		return
+	}
+	email := c.GetString("email")
+	if h.devMode {
+		auditf("%s %s rollout %s/%s (full=%v, mocked)", email, rolloutActionDone[action], ns, name, full)
+		c.JSON(http.StatusOK, gin.H{"message": "Rollout " + name + " " + rolloutActionDone[action] + " (mocked)"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+	rollouts := dynClient.Resource(getGVR("rollouts")).Namespace(ns)
+	item, err := rollouts.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		apierror.Fail(c, "Failed to get resource", err)
+		return
+	}
+	st := argoRolloutStatus(*item, nil)
+	if st.Canary == nil {
+		apierror.Write(c, http.StatusConflict, "Rollout "+name+" has no update in progress")
+		return
+	}
+
+	var specPatch, statusPatch map[string]interface{}
+	switch {
+	case action == "abort":
+		if st.Aborted {
+			apierror.Write(c, http.StatusConflict, "Rollout "+name+" is already aborted")
+			return
+		}
+		statusPatch = map[string]interface{}{"abort": true}
+	case st.Aborted:
+		apierror.Write(c, http.StatusConflict, "Rollout "+name+" is aborted; retry it before promoting")
+		return
+	case full:
+		statusPatch = map[string]interface{}{"promoteFull": true}
+	case st.Paused:
+		statusPatch = map[string]interface{}{"pauseConditions": nil}
+	case st.Strategy == "canary" && st.Step < int64(st.Steps):
+		statusPatch = map[string]interface{}{"currentStepIndex": st.Step + 1}
+	default:
+		apierror.Write(c, http.StatusConflict, "Rollout "+name+" is neither paused nor at a canary step to skip")
+		return
+	}
+	if paused, _, _ := unstructured.NestedBool(item.Object, "spec", "paused"); paused && action == "promote" {
+		specPatch = map[string]interface{}{"paused": false}
+	}
+
+	if specPatch != nil {
+		patch, _ := json.Marshal(map[string]interface{}{"spec": specPatch})
+		if _, err := rollouts.Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			apierror.Fail(c, "Failed to "+action+" rollout", err)
+			return
+		}
+	}
+	patch, _ := json.Marshal(map[string]interface{}{"status": statusPatch})
+	if _, err := rollouts.Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}, "status"); err != nil {
+		apierror.Fail(c, "Failed to "+action+" rollout", err)
+		return
+	}
+	auditf("%s %s rollout %s/%s (full=%v, step %d/%d)", email, rolloutActionDone[action], ns, name, full, st.Step, st.Steps)
+	c.JSON(http.StatusOK, gin.H{"message": "Rollout " + name + " " + rolloutActionDone[action]})
+}
+
+// GetDeploymentPair reports a canary done with two plain Deployments behind a shared Service:
+// the health and images of each, and the traffic split of the Services selecting both.
+func (h *ResourceHandler) GetDeploymentPair(c *gin.Context) {
+	ns, stable, canary := c.Param("namespace"), c.Param("stable"), c.Param("canary")
+	if rbacNs := c.GetString("namespace"); rbacNs != "" && ns != rbacNs {
+		apierror.Write(c, http.StatusForbidden, "access denied to namespace "+ns)
+		return
+	}
+	if stable == canary {
+		apierror.Write(c, http.StatusBadRequest, "the stable and canary Deployments must differ")
+		return
+	}
+	if h.devMode {
+		c.JSON(http.StatusOK, mockDeploymentPair(ns, stable, canary))
+		return
+	}
+
+	ctx := c.Request.Context()
+	deployments, err := h.deploymentPair(ctx, ns, stable, canary)
+	if err != nil {
+		apierror.Fail(c, "Failed to get resource", err)
+		return
+	}
+	pods, err := h.namespacePods(ctx, ns)
+	if err != nil {
+		apierror.Fail(c, "Failed to list pods", err)
+		return
+	}
+	services, err := h.listNamespaced(ctx, "services", ns)
+	if err != nil {
+		apierror.Fail(c, "Failed to list services", err)
+		return
+	}
+
+	st := RolloutStatus{Kind: "DeploymentPair", Name: stable + "/" + canary, Namespace: ns, Strategy: "canary", Traffic: []TrafficSplit{}}
+	revisions := make([]*RevisionHealth, 2)
+	for i, d := range deployments {
+		selector, err := metav1.LabelSelectorAsSelector(d.Spec.Selector)
+		if err != nil {
+			apierror.Fail(c, "Invalid selector of deployment "+d.Name, err)
+			return
+		}
+		var selected []corev1.Pod
+		for _, p := range pods {
+			if selector.Matches(labels.Set(p.Labels)) {
+				selected = append(selected, p)
+			}
+		}
+		revisions[i] = revisionHealth(d.Name, selected)
+		revisions[i].Images = nil
+		for _, ctr := range d.Spec.Template.Spec.Containers {
+			revisions[i].Images = append(revisions[i].Images, ctr.Image)
+		}
+	}
+	st.Stable, st.Canary = revisions[0], revisions[1]
+	st.Paused = deployments[1].Spec.Paused
+
+	for _, svc := range services {
+		sel, _, _ := unstructured.NestedStringMap(svc.Object, "spec", "selector")
+		if len(sel) == 0 {
+			continue
+		}
+		selector := labels.SelectorFromSet(sel)
+		if selector.Matches(labels.Set(deployments[0].Spec.Template.Labels)) && selector.Matches(labels.Set(deployments[1].Spec.Template.Labels)) {
+			st.Traffic = append(st.Traffic, readyPodSplit(svc.GetName(), st.Stable, st.Canary))
+		}
+	}
+	switch {
+	case st.Canary.Replicas == 0:
+		st.Phase = "Idle"
+	case !st.Canary.Healthy:
+		st.Phase = "Degraded"
+	case len(st.Traffic) == 0:
+		st.Phase = "Isolated"
+		st.Message = "no Service selects the pods of both Deployments, so the canary gets no shared traffic"
+	default:
+		st.Phase = "Progressing"
+	}
+	c.JSON(http.StatusOK, st)
+}
+
+// PromoteDeploymentPair rolls the canary's images out to the stable Deployment, by container
+// name, and scales the canary down to zero.
+func (h *ResourceHandler) PromoteDeploymentPair(c *gin.Context) {
+	h.decideDeploymentPair(c, "promote")
+}
+
+// AbortDeploymentPair scales the canary Deployment down to zero, leaving stable untouched.
+func (h *ResourceHandler) AbortDeploymentPair(c *gin.Context) {
+	h.decideDeploymentPair(c, "abort")
+}
+
+func (h *ResourceHandler) decideDeploymentPair(c *gin.Context, action string) {
+	ns, stable, canary := c.Param("namespace"), c.Param("stable"), c.Param("canary")
+	if stable == canary {
+		apierror.Write(c, http.StatusBadRequest, "the stable and canary Deployments must differ")
+		return
+	}
+	if !requireEditAccess(c, ns) || h.rejectProtected(c, "deployments", ns, stable) || h.rejectProtected(c, "deployments", ns, canary) {
+		return
+	}
+	email := c.GetString("email")
+	if h.devMode {
+		auditf("%s %s canary %s/%s of %s (mocked)", email, rolloutActionDone[action], ns, canary, stable)
+		c.JSON(http.StatusOK, gin.H{"message": "Canary " + canary + " " + rolloutActionDone[action] + " (mocked)"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	deployments, err := h.deploymentPair(ctx, ns, stable, canary)
+	if err != nil {
+		apierror.Fail(c, "Failed to get resource", err)
+		return
+	}
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+	client := dynClient.Resource(getGVR("deployments")).Namespace(ns)
+
+	var images []string
+	if action == "promote" {
+		stableContainers := map[string]bool{}
+		for _, ctr := range deployments[0].Spec.Template.Spec.Containers {
+			stableContainers[ctr.Name] = true
+		}
+		var containers []map[string]interface{}
+		for _, ctr := range deployments[1].Spec.Template.Spec.Containers {
+			if stableContainers[ctr.Name] {
+				containers = append(containers, map[string]interface{}{"name": ctr.Name, "image": ctr.Image})
+				images = append(images, ctr.Name+"="+ctr.Image)
+			}
+		}
+		if len(containers) == 0 {
+			apierror.Write(c, http.StatusConflict, "Deployments "+stable+" and "+canary+" have no container names in common")
+			return
+		}
+		patch, _ := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{"containers": containers}}}})
+		if _, err := client.Patch(ctx, stable, types.StrategicMergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			apierror.Fail(c, "Failed to update deployment "+stable, err)
+			return
+		}
+	}
+	if _, err := client.Patch(ctx, canary, types.MergePatchType, []byte(`{"spec":{"replicas":0}}`), metav1.PatchOptions{}); err != nil {
+		apierror.Fail(c, "Failed to scale down deployment "+canary, err)
+		return
+	}
+	auditf("%s %s canary %s/%s of %s %v", email, rolloutActionDone[action], ns, canary, stable, images)
+	c.JSON(http.StatusOK, gin.H{"message": "Canary " + canary + " " + rolloutActionDone[action]})
+}
+
+// deploymentPair fetches the stable and canary Deployments, in that order.
+func (h *ResourceHandler) deploymentPair(ctx context.Context, ns, stable, canary string) ([2]appsv1.Deployment, error) {
+	var pair [2]appsv1.Deployment
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		return pair, err
+	}
+	for i, name := range []string{stable, canary} {
+		item, err := dynClient.Resource(getGVR("deployments")).Namespace(ns).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return pair, err
+		}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &pair[i]); err != nil {
+			return pair, fmt.Errorf("deployment %s: %v", name, err)
+		}
+	}
+	return pair, nil
+}
+
+// namespacePods lists the pods of a namespace as typed objects.
+func (h *ResourceHandler) namespacePods(ctx context.Context, ns string) ([]corev1.Pod, error) {
+	items, err := h.listNamespaced(ctx, "pods", ns)
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]corev1.Pod, 0, len(items))
+	for _, item := range items {
+		var pod corev1.Pod
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &pod); err == nil {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+func podsWithLabel(pods []corev1.Pod, key, value string) []corev1.Pod {
+	var selected []corev1.Pod
+	for _, p := range pods {
+		if p.Labels[key] == value {
+			selected = append(selected, p)
+		}
+	}
+	return selected
+}
+
+// revisionHealth summarizes the pods of a revision. Terminating pods are left out.
+func revisionHealth(name string, pods []corev1.Pod) *RevisionHealth {
+	rh := &RevisionHealth{Name: name, Images: []string{}}
+	images := map[string]bool{}
+	for _, p := range pods {
+		if p.DeletionTimestamp != nil {
+			continue
+		}
+		rh.Replicas++
+		wp := workloadPod(p)
+		rh.Restarts += wp.Restarts
+		if podReady(p) {
+			rh.Ready++
+		} else {
+			rh.Unhealthy = append(rh.Unhealthy, p.Name+": "+wp.Status)
+		}
+		for _, ctr := range p.Spec.Containers {
+			if !images[ctr.Image] {
+				images[ctr.Image] = true
+				rh.Images = append(rh.Images, ctr.Image)
+			}
+		}
+	}
+	sort.Strings(rh.Images)
+	sort.Strings(rh.Unhealthy)
+	rh.Healthy = rh.Replicas > 0 && rh.Ready == rh.Replicas
+	return rh
+}
+
+func podReady(p corev1.Pod) bool {
+	for _, cond := range p.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// readyPodSplit estimates the traffic split of a Service selecting both revisions from their
+// ready pods, as kube-proxy balances evenly between endpoints.
+func readyPodSplit(service string, stable, canary *RevisionHealth) TrafficSplit {
+	split := TrafficSplit{Service: service, StableWeight: 100, Source: "readyPods"}
+	if canary == nil {
+		return split
+	}
+	total := stable.Ready + canary.Ready
+	if total == 0 {
+		split.StableWeight = 0
+		return split
+	}
+	split.CanaryWeight = canary.Ready * 100 / total
+	split.StableWeight = 100 - split.CanaryWeight
+	return split
+}
+
+// mockRollouts returns Argo Rollouts for DEV_MODE: a canary paused at a step and a settled
+// blue/green rollout.
+func mockRollouts(ns string) []RolloutStatus {
+	return []RolloutStatus{
+		{Kind: "Rollout", Name: "checkout", Namespace: ns, Strategy: "canary", Phase: "Paused", Message: "CanaryPauseStep", Paused: true, Step: 1, Steps: 4,
+			Stable:  &RevisionHealth{Name: "6d8f7b9c5", Images: []string{"registry.example.com/checkout:3.2.0"}, Replicas: 4, Ready: 4, Healthy: true},
+			Canary:  &RevisionHealth{Name: "5c7d9f8b4", Images: []string{"registry.example.com/checkout:3.3.0"}, Replicas: 1, Ready: 1, Restarts: 1, Healthy: true},
+			Traffic: []TrafficSplit{{Service: "checkout", StableWeight: 80, CanaryWeight: 20, Source: "trafficRouting"}}},
+		{Kind: "Rollout", Name: "search", Namespace: ns, Strategy: "blueGreen", Phase: "Healthy",
+			Stable:  &RevisionHealth{Name: "7f9c6d5b8", Images: []string{"registry.example.com/search:1.8.2"}, Replicas: 3, Ready: 3, Healthy: true},
+			Traffic: []TrafficSplit{{Service: "search-active", StableWeight: 100, Source: "activeService"}}},
+	}
+}
+
+func mockDeploymentPair(ns, stable, canary string) RolloutStatus {
+	return RolloutStatus{Kind: "DeploymentPair", Name: stable + "/" + canary, Namespace: ns, Strategy: "canary", Phase: "Degraded",
+		Stable:  &RevisionHealth{Name: stable, Images: []string{"registry.example.com/backend:1.18.0"}, Replicas: 4, Ready: 4, Healthy: true},
+		Canary:  &RevisionHealth{Name: canary, Images: []string{"registry.example.com/backend:1.19.0-rc.1"}, Replicas: 1, Restarts: 5, Unhealthy: []string{canary + "-7b9f-x2kq: CrashLoopBackOff"}},
+		Traffic: []TrafficSplit{{Service: "backend", StableWeight: 100, Source: "readyPods"}}}
+}
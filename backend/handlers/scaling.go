@@ -0,0 +1,401 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"k-view/apierror"
+	"k-view/k8s"
+	"k-view/store"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// scaledFromAnnotation records the replica count a workload had before a scaling rule changed it,
+// so a later rule with restore: true can bring it back.
+const scaledFromAnnotation = "k-view.io/scaled-from"
+
+const scalingRulesDoc = "scaling-rules"
+
+// ScalingRule scales the matching workloads of a namespace on a cron schedule.
+type ScalingRule struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name" binding:"required"`
+	Namespace string   `json:"namespace" binding:"required"`
+	Kinds     []string `json:"kinds"`                       // deployments and/or statefulsets; defaults to deployments
+	Selector  string   `json:"selector"`                    // Optional label selector
+	Workloads []string `json:"workloads"`                   // Optional workload names; empty means all matching
+	Schedule  string   `json:"schedule" binding:"required"` // Cron expression, e.g. "0 20 * * 1-5"
+	Timezone  string   `json:"timezone"`                    // IANA name; defaults to the server's TZ
+	Replicas  int64    `json:"replicas"`
+	// Restore scales back to the replica count recorded before the last scale-down instead of Replicas
+	Restore    bool       `json:"restore"`
+	Enabled    bool       `json:"enabled"`
+	CreatedBy  string     `json:"createdBy"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastRun    *time.Time `json:"lastRun,omitempty"`
+	LastResult string     `json:"lastResult,omitempty"`
+	NextRun    *time.Time `json:"nextRun,omitempty"`
+}
+
+// ScalingHandler manages scheduled scaling rules and runs them from a background worker.
+type ScalingHandler struct {
+	devMode   bool
+	k8sClient k8s.KubernetesProvider
+	store     *store.Store
+	readOnly  *ReadOnlyMode // Rules do not run while read-only mode is on
+}
+
+func NewScalingHandler(devMode bool, client k8s.KubernetesProvider, st *store.Store, readOnly *ReadOnlyMode) *ScalingHandler {
+	return &ScalingHandler{devMode: devMode, k8sClient: client, store: st, readOnly: readOnly}
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validate normalises a rule and checks its schedule, timezone and targets.
+func (r *ScalingRule) validate() (*cronSchedule, *time.Location, error) {
+	if len(r.Kinds) == 0 {
+		r.Kinds = []string{"deployments"}
+	}
+	for i, k := range r.Kinds {
+		r.Kinds[i] = strings.ToLower(k)
+		if r.Kinds[i] != "deployments" && r.Kinds[i] != "statefulsets" {
+			return nil, nil, fmt.Errorf("kind %q cannot be scaled; use deployments or statefulsets", k)
+		}
+	}
+	if r.Replicas < 0 {
+		return nil, nil, fmt.Errorf("replicas must not be negative")
+	}
+	if r.Selector != "" {
+		if _, err := metav1.ParseToLabelSelector(r.Selector); err != nil {
+			return nil, nil, fmt.Errorf("invalid selector: %v", err)
+		}
+	}
+	schedule, err := parseCron(r.Schedule)
+	if err != nil {
+		return nil, nil, err
+	}
+	loc := time.Local
+	if r.Timezone != "" {
+		if loc, err = time.LoadLocation(r.Timezone); err != nil {
+			return nil, nil, fmt.Errorf("unknown timezone %q", r.Timezone)
+		}
+	}
+	return schedule, loc, nil
+}
+
+// withNextRun fills in the next execution time for API responses.
+func (r ScalingRule) withNextRun(now time.Time) ScalingRule {
+	schedule, loc, err := r.validate()
+	if err != nil || !r.Enabled {
+		return r
+	}
+	if next, ok := schedule.Next(now.In(loc)); ok {
+		r.NextRun = &next
+	}
+	return r
+}
+
+// ListRules returns all scaling rules with their next scheduled run.
+func (h *ScalingHandler) ListRules(c *gin.Context) {
+	var rules []ScalingRule
+	if err := h.store.Load(scalingRulesDoc, &rules); err != nil {
+		apierror.Fail(c, "Failed to load scaling rules", err)
+		return
+	}
+	now := time.Now()
+	result := make([]ScalingRule, 0, len(rules))
+	for _, r := range rules {
+		result = append(result, r.withNextRun(now))
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// CreateRule adds a scaling rule. New rules are enabled unless "enabled": false is sent.
+func (h *ScalingHandler) CreateRule(c *gin.Context) {
+	rule := ScalingRule{Enabled: true}
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "name, namespace and schedule are required")
+		return
+	}
+	if _, _, err := rule.validate(); err != nil {
+		apierror.Write(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	email, _ := c.Get("email")
+	rule.ID = newID()
+	rule.CreatedBy, _ = email.(string)
+	rule.CreatedAt = time.Now().UTC()
+	rule.LastRun, rule.LastResult, rule.NextRun = nil, "", nil
+
+	var rules []ScalingRule
+	err := h.store.Update(scalingRulesDoc, &rules, func() error {
+		rules = append(rules, rule)
+		return nil
+	})
+	if err != nil {
+		apierror.Fail(c, "Failed to save scaling rule", err)
+		return
+	}
+	c.JSON(http.StatusCreated, rule.withNextRun(time.Now()))
+}
+
+// UpdateRule replaces a rule's definition, keeping its ID, author and run history. The rule
+// stays enabled or disabled unless "enabled" is sent.
+func (h *ScalingHandler) UpdateRule(c *gin.Context) {
+	id := c.Param("id")
+	var req struct {
+		ScalingRule
+		Enabled *bool `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "name, namespace and schedule are required")
+		return
+	}
+	input := req.ScalingRule
+	if _, _, err := input.validate(); err != nil {
+		apierror.Write(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var rules []ScalingRule
+	var updated ScalingRule
+	err := h.store.Update(scalingRulesDoc, &rules, func() error {
+		for i := range rules {
+			if rules[i].ID != id {
+				continue
+			}
+			input.ID, input.CreatedBy, input.CreatedAt = rules[i].ID, rules[i].CreatedBy, rules[i].CreatedAt
+			input.LastRun, input.LastResult, input.NextRun = rules[i].LastRun, rules[i].LastResult, nil
+			input.Enabled = rules[i].Enabled
+			if req.Enabled != nil {
+				input.Enabled = *req.Enabled
+			}
+			rules[i] = input
+			updated = input
+			return nil
+		}
+		return errRuleNotFound
+	})
+	if err == errRuleNotFound {
+		apierror.Write(c, http.StatusNotFound, "scaling rule "+id+" not found")
+		return
+	}
+	if err != nil {
+		apierror.Fail(c, "Failed to save scaling rule", err)
+		return
+	}
+	c.JSON(http.StatusOK, updated.withNextRun(time.Now()))
+}
+
+var errRuleNotFound = fmt.Errorf("rule not found")
+
+// DeleteRule removes a scaling rule.
+func (h *ScalingHandler) DeleteRule(c *gin.Context) {
+	id := c.Param("id")
+	var rules []ScalingRule
+	err := h.store.Update(scalingRulesDoc, &rules, func() error {
+		for i := range rules {
+			if rules[i].ID == id {
+				rules = append(rules[:i], rules[i+1:]...)
+				return nil
+			}
+		}
+		return errRuleNotFound
+	})
+	if err == errRuleNotFound {
+		apierror.Write(c, http.StatusNotFound, "scaling rule "+id+" not found")
+		return
+	}
+	if err != nil {
+		apierror.Fail(c, "Failed to save scaling rule", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Scaling rule deleted"})
+}
+
+// RunRule executes a rule immediately, regardless of its schedule.
+func (h *ScalingHandler) RunRule(c *gin.Context) {
+	id := c.Param("id")
+	var rules []ScalingRule
+	if err := h.store.Load(scalingRulesDoc, &rules); err != nil {
+		apierror.Fail(c, "Failed to load scaling rules", err)
+		return
+	}
+	for _, r := range rules {
+		if r.ID == id {
+			result := h.execute(context.Background(), r)
+			h.recordRun(id, result)
+			c.JSON(http.StatusOK, gin.H{"message": result})
+			return
+		}
+	}
+	apierror.Write(c, http.StatusNotFound, "scaling rule "+id+" not found")
+}
+
+// RunScheduler is the background worker: once a minute it runs every enabled rule whose
+// schedule matches. Scaling is idempotent, so several K-View replicas running the same rule is harmless.
+func (h *ScalingHandler) RunScheduler(ctx context.Context) {
+	// Align to the start of the next minute so each schedule fires once
+	time.Sleep(time.Until(time.Now().Truncate(time.Minute).Add(time.Minute)))
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		h.runDue(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *ScalingHandler) runDue(ctx context.Context, now time.Time) {
+	var rules []ScalingRule
+	if err := h.store.Load(scalingRulesDoc, &rules); err != nil {
+		log.Printf("Scaling scheduler: %v", err)
+		return
+	}
+	for _, r := range rules {
+		if !r.Enabled {
+			continue
+		}
+		schedule, loc, err := r.validate()
+		if err != nil || !schedule.Matches(now.In(loc)) {
+			continue
+		}
+		result := h.execute(ctx, r)
+		log.Printf("Scaling rule %q (%s): %s", r.Name, r.Namespace, result)
+		h.recordRun(r.ID, result)
+	}
+}
+
+func (h *ScalingHandler) recordRun(id, result string) {
+	var rules []ScalingRule
+	now := time.Now().UTC()
+	err := h.store.Update(scalingRulesDoc, &rules, func() error {
+		for i := range rules {
+			if rules[i].ID == id {
+				rules[i].LastRun = &now
+				rules[i].LastResult = result
+				return nil
+			}
+		}
+		return errRuleNotFound // Deleted while running
+	})
+	if err != nil && err != errRuleNotFound {
+		log.Printf("Scaling scheduler: failed to record run of %s: %v", id, err)
+	}
+}
+
+// execute scales the rule's targets with the ServiceAccount's permissions and returns a summary.
+// Nothing is changed while read-only mode is on, as for every other mutation.
+func (h *ScalingHandler) execute(ctx context.Context, rule ScalingRule) string {
+	if h.readOnly != nil && h.readOnly.Enabled() {
+		return "Skipped: read-only mode"
+	}
+	if _, _, err := rule.validate(); err != nil {
+		return "Failed: " + err.Error()
+	}
+	if h.devMode {
+		if rule.Restore {
+			return "Restored 3 workload(s) to their previous replicas (mocked)"
+		}
+		return fmt.Sprintf("Scaled 3 workload(s) to %d (mocked)", rule.Replicas)
+	}
+
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		return "Failed: " + err.Error()
+	}
+	names := map[string]bool{}
+	for _, n := range rule.Workloads {
+		names[n] = true
+	}
+
+	scaled, skipped := 0, 0
+	var failures []string
+	for _, kind := range rule.Kinds {
+		dc := dynClient.Resource(getGVR(kind)).Namespace(rule.Namespace)
+		list, err := dc.List(ctx, metav1.ListOptions{LabelSelector: rule.Selector})
+		if err != nil {
+			failures = append(failures, kind+": "+err.Error())
+			continue
+		}
+		for _, item := range list.Items {
+			if len(names) > 0 && !names[item.GetName()] {
+				continue
+			}
+			current, found, _ := unstructured.NestedInt64(item.Object, "spec", "replicas")
+			if !found {
+				current = 1
+			}
+
+			target := rule.Replicas
+			annotation := interface{}(nil) // Remove the marker once restored
+			if rule.Restore {
+				prev, ok := item.GetAnnotations()[scaledFromAnnotation]
+				if !ok {
+					skipped++
+					continue
+				}
+				if target, err = strconv.ParseInt(prev, 10, 64); err != nil {
+					skipped++
+					continue
+				}
+			} else if target < current {
+				annotation = strconv.FormatInt(current, 10)
+				// Keep the original count across repeated scale-downs
+				if prev, ok := item.GetAnnotations()[scaledFromAnnotation]; ok {
+					annotation = prev
+				}
+			}
+			if target == current && annotation == nil && !rule.Restore {
+				continue
+			}
+
+			patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%s}},"spec":{"replicas":%d}}`, scaledFromAnnotation, jsonValue(annotation), target)
+			if _, err := dc.Patch(ctx, item.GetName(), types.MergePatchType, []byte(patch), metav1.PatchOptions{}); err != nil {
+				failures = append(failures, item.GetName()+": "+err.Error())
+				continue
+			}
+			scaled++
+		}
+	}
+
+	result := fmt.Sprintf("Scaled %d workload(s)", scaled)
+	if rule.Restore {
+		result = fmt.Sprintf("Restored %d workload(s)", scaled)
+		if skipped > 0 {
+			result += fmt.Sprintf(", %d without a recorded replica count", skipped)
+		}
+	} else {
+		result += fmt.Sprintf(" to %d", rule.Replicas)
+	}
+	if len(failures) > 0 {
+		result += "; failed: " + strings.Join(failures, "; ")
+	}
+	return result
+}
+
+// jsonValue renders a merge-patch value: null deletes the key.
+func jsonValue(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	return strconv.Quote(v.(string))
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+
+	"k-view/apierror"
+)
+
+// SecurityFinding is a failed security check on a pod or one of its containers.
+type SecurityFinding struct {
+	Pod       string `json:"pod"`
+	Container string `json:"container,omitempty"`
+	Check     string `json:"check"`
+	Severity  string `json:"severity"` // critical, high, medium, low
+	Message   string `json:"message"`
+}
+
+// NamespaceSecurity is the security score of one namespace (100 = every check passed).
+type NamespaceSecurity struct {
+	Namespace string            `json:"namespace"`
+	Score     int               `json:"score"`
+	Pods      int               `json:"pods"`
+	Findings  []SecurityFinding `json:"findings"`
+}
+
+var severityWeights = map[string]int{"critical": 10, "high": 5, "medium": 3, "low": 1}
+
+// dangerousCapabilities grant near-root access to the node when added to a container.
+var dangerousCapabilities = map[corev1.Capability]bool{
+	"ALL": true, "SYS_ADMIN": true, "NET_ADMIN": true, "SYS_PTRACE": true, "SYS_MODULE": true, "DAC_READ_SEARCH": true, "NET_RAW": true,
+}
+
+// securityCheck accumulates weighted check results for one namespace.
+type securityCheck struct {
+	findings []SecurityFinding
+	total    int
+	failed   int
+}
+
+func (s *securityCheck) record(pass bool, f SecurityFinding) {
+	w := severityWeights[f.Severity]
+	s.total += w
+	if !pass {
+		s.failed += w
+		s.findings = append(s.findings, f)
+	}
+}
+
+// auditPodSecurity evaluates the pod-level and container-level security settings of a pod.
+func auditPodSecurity(pod corev1.Pod, s *securityCheck) {
+	spec := pod.Spec
+	podCtx := spec.SecurityContext
+	if podCtx == nil {
+		podCtx = &corev1.PodSecurityContext{}
+	}
+
+	s.record(!spec.HostNetwork, SecurityFinding{Pod: pod.Name, Check: "hostNetwork", Severity: "high", Message: "pod shares the node's network namespace"})
+	s.record(!spec.HostPID && !spec.HostIPC, SecurityFinding{Pod: pod.Name, Check: "hostNamespaces", Severity: "high", Message: "pod shares the node's PID or IPC namespace"})
+
+	var hostPaths []string
+	for _, v := range spec.Volumes {
+		if v.HostPath != nil {
+			hostPaths = append(hostPaths, v.HostPath.Path)
+		}
+	}
+	hostPathMsg := "pod mounts host paths"
+	if len(hostPaths) > 0 {
+		hostPathMsg += ": " + strings.Join(hostPaths, ", ")
+	}
+	s.record(len(hostPaths) == 0, SecurityFinding{Pod: pod.Name, Check: "hostPath", Severity: "high", Message: hostPathMsg})
+
+	for _, ctr := range podImages(pod) {
+		ctx := ctr.SecurityContext
+		if ctx == nil {
+			ctx = &corev1.SecurityContext{}
+		}
+
+		privileged := ctx.Privileged != nil && *ctx.Privileged
+		s.record(!privileged, SecurityFinding{Pod: pod.Name, Container: ctr.Name, Check: "privileged", Severity: "critical", Message: "container runs privileged"})
+
+		nonRoot := (ctx.RunAsNonRoot != nil && *ctx.RunAsNonRoot) || (ctx.RunAsNonRoot == nil && podCtx.RunAsNonRoot != nil && *podCtx.RunAsNonRoot)
+		if uid := ctx.RunAsUser; uid != nil {
+			nonRoot = nonRoot || *uid != 0
+		} else if podCtx.RunAsUser != nil {
+			nonRoot = nonRoot || *podCtx.RunAsUser != 0
+		}
+		s.record(nonRoot, SecurityFinding{Pod: pod.Name, Container: ctr.Name, Check: "runAsNonRoot", Severity: "high", Message: "container may run as root (runAsNonRoot not set)"})
+
+		// allowPrivilegeEscalation defaults to true unless explicitly disabled
+		noEscalation := ctx.AllowPrivilegeEscalation != nil && !*ctx.AllowPrivilegeEscalation
+		s.record(noEscalation && !privileged, SecurityFinding{Pod: pod.Name, Container: ctr.Name, Check: "allowPrivilegeEscalation", Severity: "medium", Message: "privilege escalation is not disabled"})
+
+		var added []string
+		dropsAll := false
+		if ctx.Capabilities != nil {
+			for _, capability := range ctx.Capabilities.Add {
+				if dangerousCapabilities[capability] {
+					added = append(added, string(capability))
+				}
+			}
+			for _, capability := range ctx.Capabilities.Drop {
+				if capability == "ALL" {
+					dropsAll = true
+				}
+			}
+		}
+		capMsg := "container adds dangerous capabilities"
+		if len(added) > 0 {
+			capMsg += ": " + strings.Join(added, ", ")
+		}
+		s.record(len(added) == 0, SecurityFinding{Pod: pod.Name, Container: ctr.Name, Check: "capabilities", Severity: "high", Message: capMsg})
+		s.record(dropsAll, SecurityFinding{Pod: pod.Name, Container: ctr.Name, Check: "dropCapabilities", Severity: "low", Message: "container does not drop ALL capabilities"})
+
+		readOnlyRoot := ctx.ReadOnlyRootFilesystem != nil && *ctx.ReadOnlyRootFilesystem
+		s.record(readOnlyRoot, SecurityFinding{Pod: pod.Name, Container: ctr.Name, Check: "readOnlyRootFilesystem", Severity: "low", Message: "root filesystem is writable"})
+	}
+}
+
+// GetSecurityPosture scores the pod security settings of every namespace the user can see.
+func (h *DiagnosticsHandler) GetSecurityPosture(c *gin.Context) {
+	ns := c.Query("namespace")
+	if ns == "-" {
+		ns = ""
+	}
+
+	// Apply RBAC namespace restriction
+	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
+		ns = rbacNs.(string)
+	}
+
+	pods, err := h.k8sClient.ListPods(c.Request.Context(), ns)
+	if err != nil {
+		apierror.Fail(c, "Failed to list pods", err)
+		return
+	}
+	c.JSON(http.StatusOK, securityPosture(pods))
+}
+
+// securityPosture scores the running pods by namespace, lowest score first.
+func securityPosture(pods []corev1.Pod) []NamespaceSecurity {
+	checks := make(map[string]*securityCheck)
+	podCounts := make(map[string]int)
+	for _, p := range pods {
+		if p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		s, ok := checks[p.Namespace]
+		if !ok {
+			s = &securityCheck{}
+			checks[p.Namespace] = s
+		}
+		auditPodSecurity(p, s)
+		podCounts[p.Namespace]++
+	}
+
+	result := make([]NamespaceSecurity, 0, len(checks))
+	for namespace, s := range checks {
+		score := 100
+		if s.total > 0 {
+			score = int(math.Round(100 * float64(s.total-s.failed) / float64(s.total)))
+		}
+		findings := s.findings
+		if findings == nil {
+			findings = []SecurityFinding{}
+		}
+		sort.SliceStable(findings, func(i, j int) bool {
+			return severityWeights[findings[i].Severity] > severityWeights[findings[j].Severity]
+		})
+		result = append(result, NamespaceSecurity{Namespace: namespace, Score: score, Pods: podCounts[namespace], Findings: findings})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score < result[j].Score
+		}
+		return result[i].Namespace < result[j].Namespace
+	})
+	return result
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"k-view/apierror"
+)
+
+// staleTokenAge is the age after which a long-lived ServiceAccount token secret should be rotated.
+const staleTokenAge = 90 * 24 * time.Hour
+
+// SATokenUsage describes whether a pod running as the ServiceAccount gets an API token mounted.
+type SATokenUsage struct {
+	Pod       string `json:"pod"`
+	Phase     string `json:"phase"`
+	Automount bool   `json:"automount"`
+}
+
+// SATokenSecret is a legacy, non-expiring token stored in a Secret.
+type SATokenSecret struct {
+	Name  string `json:"name"`
+	Age   string `json:"age"`
+	Stale bool   `json:"stale"`
+}
+
+type ServiceAccountReport struct {
+	Namespace    string          `json:"namespace"`
+	Name         string          `json:"name"`
+	Automount    *bool           `json:"automountServiceAccountToken"`
+	Grants       []Grant         `json:"grants"`
+	Pods         []SATokenUsage  `json:"pods"`
+	TokenSecrets []SATokenSecret `json:"tokenSecrets"`
+	Warnings     []string        `json:"warnings"`
+}
+
+// InspectServiceAccount reports the bindings of a ServiceAccount, which pods mount its token
+// and which long-lived token secrets exist for it.
+func (h *RBACHandler) InspectServiceAccount(c *gin.Context) {
+	ns := c.Param("namespace")
+	name := c.Param("name")
+
+	report := ServiceAccountReport{Namespace: ns, Name: name, Grants: []Grant{}, Pods: []SATokenUsage{}, TokenSecrets: []SATokenSecret{}, Warnings: []string{}}
+
+	model, err := h.loadRBACModel(c.Request.Context())
+	if err != nil {
+		apierror.Fail(c, "", err)
+		return
+	}
+	for _, g := range model.grants() {
+		if g.Subject.Kind == rbacv1.ServiceAccountKind && g.Subject.Namespace == ns && g.Subject.Name == name {
+			report.Grants = append(report.Grants, g)
+			if g.RoleRef == "ClusterRole/cluster-admin" {
+				report.Warnings = append(report.Warnings, "bound to cluster-admin via "+g.Binding)
+			}
+		}
+	}
+
+	var secrets []unstructured.Unstructured
+	if h.devMode {
+		created := metav1.NewTime(time.Now().Add(-400 * 24 * time.Hour))
+		legacy := unstructured.Unstructured{}
+		legacy.SetName(name + "-token-x7k2p")
+		legacy.SetCreationTimestamp(created)
+		secrets = append(secrets, legacy)
+	} else {
+		dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
+		if err != nil {
+			apierror.Fail(c, "Failed to get dynamic client", err)
+			return
+		}
+
+		sa, err := dynClient.Resource(getGVR("serviceaccounts")).Namespace(ns).Get(c.Request.Context(), name, metav1.GetOptions{})
+		if err != nil {
+			apierror.Fail(c, "Failed to get resource", err)
+			return
+		}
+		if automount, found, _ := unstructured.NestedBool(sa.Object, "automountServiceAccountToken"); found {
+			report.Automount = &automount
+		}
+
+		list, err := dynClient.Resource(getGVR("secrets")).Namespace(ns).List(c.Request.Context(), metav1.ListOptions{
+			FieldSelector: "type=" + string(corev1.SecretTypeServiceAccountToken),
+		})
+		if err == nil {
+			for _, s := range list.Items {
+				if s.GetAnnotations()[corev1.ServiceAccountNameKey] == name {
+					secrets = append(secrets, s)
+				}
+			}
+		}
+	}
+
+	for _, s := range secrets {
+		created := s.GetCreationTimestamp().Time
+		stale := time.Since(created) > staleTokenAge
+		report.TokenSecrets = append(report.TokenSecrets, SATokenSecret{Name: s.GetName(), Age: getAge(created), Stale: stale})
+		if stale {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("long-lived token secret %s is %s old; rotate it or switch to projected tokens", s.GetName(), getAge(created)))
+		}
+	}
+
+	pods, err := h.k8sClient.ListPods(c.Request.Context(), ns)
+	if err != nil {
+		apierror.Fail(c, "Failed to list pods", err)
+		return
+	}
+	mounting := 0
+	for _, p := range pods {
+		podSA := p.Spec.ServiceAccountName
+		if podSA == "" {
+			podSA = "default"
+		}
+		if podSA != name {
+			continue
+		}
+		// The pod-level setting overrides the ServiceAccount, which defaults to true
+		automount := true
+		if p.Spec.AutomountServiceAccountToken != nil {
+			automount = *p.Spec.AutomountServiceAccountToken
+		} else if report.Automount != nil {
+			automount = *report.Automount
+		}
+		if automount {
+			mounting++
+		}
+		report.Pods = append(report.Pods, SATokenUsage{Pod: p.Name, Phase: string(p.Status.Phase), Automount: automount})
+	}
+	if name == "default" && len(report.Pods) > 0 {
+		report.Warnings = append(report.Warnings, "pods run as the namespace default ServiceAccount; create a dedicated one per workload")
+	}
+	if mounting > 0 && len(report.Grants) == 0 {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("token is mounted into %d pod(s) but the ServiceAccount has no bindings; set automountServiceAccountToken: false", mounting))
+	}
+
+	c.JSON(http.StatusOK, report)
+}
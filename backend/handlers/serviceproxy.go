@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"k-view/apierror"
+	"k-view/k8s"
+)
+
+// ServiceProxyHandler forwards HTTP requests to in-cluster Services, like kubectl proxy, so
+// internal UIs such as Prometheus or Grafana can be reached through K-View.
+type ServiceProxyHandler struct {
+	k8sClient k8s.KubernetesProvider
+	roles     []string // Roles allowed to use the proxy
+	basePath  string
+}
+
+// NewServiceProxyHandler creates a ServiceProxyHandler for the roles in the comma-separated
+// list, or the admin roles when it is empty. basePath is the subpath K-View is served under.
+func NewServiceProxyHandler(client k8s.KubernetesProvider, roles, basePath string) *ServiceProxyHandler {
+	allowed := splitList(roles)
+	if len(allowed) == 0 {
+		allowed = []string{"kview-cluster-admin", "admin"}
+	}
+	return &ServiceProxyHandler{k8sClient: client, roles: allowed, basePath: basePath}
+}
+
+// Proxy forwards a request to port :port (a name or number) of a Service, with the rest of the
+// URL path and the query. It goes through the API server's service proxy as the user, so
+// besides the K-View role, non-admins need the Kubernetes permission to proxy to services.
+// Changes (anything but GET, HEAD and OPTIONS) are audited. Responses are served with a CSP
+// sandbox and without Set-Cookie, as they come from K-View's origin.
+func (h *ServiceProxyHandler) Proxy(c *gin.Context) {
+	role := c.GetString("role")
+	if !contains(h.roles, role) {
+		apierror.Write(c, http.StatusForbidden, "the service proxy is not available to the "+role+" role")
+		return
+	}
+	namespace, name, port := c.Param("namespace"), c.Param("name"), c.Param("port")
+	if rbacNs := c.GetString("namespace"); rbacNs != "" && namespace != rbacNs {
+		apierror.Write(c, http.StatusForbidden, "access denied to namespace "+namespace)
+		return
+	}
+	if n, err := strconv.Atoi(port); err == nil && (n < 1 || n > 65535) || !dnsLabel.MatchString(namespace) || !dnsLabel.MatchString(name) || !dnsLabel.MatchString(port) {
+		apierror.Write(c, http.StatusBadRequest, "invalid namespace, service or port")
+		return
+	}
+	proxier, ok := h.k8sClient.(k8s.ServiceProxier)
+	if !ok {
+		apierror.Write(c, http.StatusNotImplemented, "the service proxy is not supported by this Kubernetes provider")
+		return
+	}
+
+	prefix := h.basePath + "/api/proxy/services/" + namespace + "/" + name + "/" + port
+	handler, err := proxier.ServiceProxy(c.Request.Context(), namespace, name, port, prefix)
+	if err != nil {
+		apierror.Write(c, http.StatusBadGateway, "Failed to proxy to service: "+err.Error())
+		return
+	}
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		auditf("%s %s proxied to service %s/%s:%s by %s", c.Request.Method, c.Param("path"), namespace, name, port, c.GetString("email"))
+	}
+
+	req := c.Request.Clone(c.Request.Context())
+	req.URL.Path = c.Param("path")
+	req.URL.RawPath = ""
+	handler.ServeHTTP(&sandboxedWriter{ResponseWriter: c.Writer}, req)
+}
+
+// sandboxedWriter isolates proxied responses from K-View's origin: pages are sandboxed into a
+// unique origin, so their scripts cannot call /api with the user's auth cookie, and services
+// cannot set cookies on K-View's domain.
+type sandboxedWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *sandboxedWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		h := w.Header()
+		h.Del("Set-Cookie")
+		h.Set("Content-Security-Policy", "sandbox allow-scripts allow-forms allow-popups")
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *sandboxedWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets the reverse proxy reach the underlying writer to flush streamed responses.
+func (w *sandboxedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"k-view/apierror"
+)
+
+var errTooManySessions = errors.New("too many open terminals")
+
+// TerminalSession is an open exec WebSocket.
+type TerminalSession struct {
+	ID           string    `json:"id"`
+	User         string    `json:"user"`
+	Namespace    string    `json:"namespace"`
+	Pod          string    `json:"pod"`
+	Container    string    `json:"container"`
+	StartedAt    time.Time `json:"startedAt"`
+	LastActivity time.Time `json:"lastActivity"`
+
+	pty    *wsPtyHandler
+	cancel context.CancelFunc
+}
+
+// sessionRegistry tracks open terminals, enforces the per-user limit and closes idle ones.
+type sessionRegistry struct {
+	mu          sync.Mutex
+	sessions    map[string]*TerminalSession
+	idleTimeout time.Duration // 0 disables the idle timeout
+	maxPerUser  int           // 0 means unlimited
+}
+
+func newSessionRegistry(idleTimeout time.Duration, maxPerUser int) *sessionRegistry {
+	return &sessionRegistry{sessions: map[string]*TerminalSession{}, idleTimeout: idleTimeout, maxPerUser: maxPerUser}
+}
+
+// open registers a session unless the user already has maxPerUser terminals open.
+func (r *sessionRegistry) open(s *TerminalSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.maxPerUser > 0 {
+		n := 0
+		for _, existing := range r.sessions {
+			if existing.User == s.User {
+				n++
+			}
+		}
+		if n >= r.maxPerUser {
+			return errTooManySessions
+		}
+	}
+	s.ID = newID()
+	s.StartedAt = time.Now().UTC()
+	s.LastActivity = s.StartedAt
+	r.sessions[s.ID] = s
+	return nil
+}
+
+// attach hooks up the terminal once the WebSocket is open.
+func (r *sessionRegistry) attach(id string, pty *wsPtyHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[id]; ok {
+		s.pty = pty
+	}
+}
+
+func (r *sessionRegistry) close(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, id)
+}
+
+// count returns the number of open terminals.
+func (r *sessionRegistry) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.sessions)
+}
+
+// touch records keyboard input on a session.
+func (r *sessionRegistry) touch(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[id]; ok {
+		s.LastActivity = time.Now().UTC()
+	}
+}
+
+// kill ends a session, telling the user why. It returns false if the session is gone.
+func (r *sessionRegistry) kill(id, reason string) bool {
+	r.mu.Lock()
+	s, ok := r.sessions[id]
+	var pty *wsPtyHandler
+	if ok {
+		pty = s.pty
+	}
+	r.mu.Unlock()
+	if !ok {
+		return false
+	}
+	s.cancel()
+	if pty != nil {
+		pty.notice("\r\n\033[33m" + reason + "\033[0m\r\n")
+		_ = pty.conn.Close()
+	}
+	return true
+}
+
+// watchIdle closes the session once it has seen no input for idleTimeout.
+func (r *sessionRegistry) watchIdle(ctx context.Context, id string) {
+	if r.idleTimeout <= 0 {
+		return
+	}
+	// Check ten times per timeout, but not more than once a second
+	ticker := time.NewTicker(max(r.idleTimeout/10, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			s, ok := r.sessions[id]
+			idle := ok && time.Since(s.LastActivity) >= r.idleTimeout
+			r.mu.Unlock()
+			if !ok {
+				return
+			}
+			if idle {
+				auditf("terminal %s/%s/%s of %s closed after %s idle", s.Namespace, s.Pod, s.Container, s.User, r.idleTimeout)
+				r.kill(id, fmt.Sprintf("Session closed after %s without input", r.idleTimeout))
+				return
+			}
+		}
+	}
+}
+
+// ListSessions returns the open terminals, oldest first (admin only).
+func (h *ExecHandler) ListSessions(c *gin.Context) {
+	h.sessions.mu.Lock()
+	result := []TerminalSession{}
+	for _, s := range h.sessions.sessions {
+		result = append(result, *s)
+	}
+	h.sessions.mu.Unlock()
+	sort.Slice(result, func(i, j int) bool { return result[i].StartedAt.Before(result[j].StartedAt) })
+	c.JSON(http.StatusOK, gin.H{
+		"sessions":    result,
+		"idleTimeout": h.sessions.idleTimeout.String(),
+		"maxPerUser":  h.sessions.maxPerUser,
+	})
+}
+
+// KillSession terminates an open terminal (admin only).
+func (h *ExecHandler) KillSession(c *gin.Context) {
+	id := c.Param("id")
+	email, _ := c.Get("email")
+	if !h.sessions.kill(id, fmt.Sprintf("Session terminated by administrator %v", email)) {
+		apierror.Write(c, http.StatusNotFound, "terminal session "+id+" not found")
+		return
+	}
+	auditf("terminal session %s terminated by %v", id, email)
+	c.JSON(http.StatusOK, gin.H{"message": "Terminal session terminated"})
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"k-view/apierror"
+)
+
+// slackMaxOutput keeps replies under Slack's message size limit.
+const slackMaxOutput = 3500
+
+// SlackHandler implements the `/kview` slash command: the text is run as a kubectl command
+// through the console pipeline, as the K-View user the Slack user is mapped to.
+type SlackHandler struct {
+	console       *ConsoleHandler
+	auth          *AuthHandler
+	readOnly      *ReadOnlyMode
+	signingSecret string
+	client        *http.Client
+}
+
+func NewSlackHandler(console *ConsoleHandler, auth *AuthHandler, readOnly *ReadOnlyMode, signingSecret string) *SlackHandler {
+	return &SlackHandler{
+		console:       console,
+		auth:          auth,
+		readOnly:      readOnly,
+		signingSecret: signingSecret,
+		client:        &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// slackMessage is a slash command response; ephemeral replies are only shown to the caller.
+type slackMessage struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+func ephemeral(text string) slackMessage {
+	return slackMessage{ResponseType: "ephemeral", Text: text}
+}
+
+// verify checks the X-Slack-Signature of a request and rejects timestamps older than 5 minutes.
+func (h *SlackHandler) verify(c *gin.Context, body []byte) bool {
+	ts := c.GetHeader("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || time.Since(time.Unix(sec, 0)).Abs() > 5*time.Minute {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(h.signingSecret))
+	mac.Write([]byte("v0:" + ts + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(c.GetHeader("X-Slack-Signature")))
+}
+
+// formatSlackOutput wraps command output in a code block, keeping its tail when it is too long.
+func formatSlackOutput(cmd, output string, exitCode int) string {
+	output = strings.TrimRight(output, "\n")
+	if len(output) > slackMaxOutput {
+		output = "…" + output[len(output)-slackMaxOutput:]
+	}
+	if output == "" {
+		output = "(no output)"
+	}
+	text := "`" + cmd + "`\n```\n" + output + "\n```"
+	if exitCode != 0 {
+		text += "\nexit code " + strconv.Itoa(exitCode)
+	}
+	return text
+}
+
+// HandleCommand answers a Slack slash command (POST /api/integrations/slack). The request is
+// authenticated by Slack's signature rather than a K-View session. Slack allows 3 seconds for the
+// reply, so the command is acknowledged at once and its output is posted to the response_url.
+func (h *SlackHandler) HandleCommand(c *gin.Context) {
+	if h.signingSecret == "" {
+		apierror.Write(c, http.StatusNotImplemented, "the Slack integration is disabled (set KVIEW_SLACK_SIGNING_SECRET)")
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 64*1024))
+	if err != nil || !h.verify(c, body) {
+		apierror.Write(c, http.StatusUnauthorized, "invalid Slack signature")
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		apierror.Write(c, http.StatusBadRequest, "invalid slash command payload")
+		return
+	}
+
+	slackID, slackName := form.Get("user_id"), form.Get("user_name")
+	email := h.auth.SlackUser(slackID)
+	if email == "" {
+		auditf("Slack user %s (%s) denied: not mapped to a K-View user", slackID, slackName)
+		c.JSON(http.StatusOK, ephemeral("Your Slack account ("+slackID+") is not linked to a K-View user. Ask an administrator to add it to slackUsers in the RBAC configuration."))
+		return
+	}
+	// Slack carries no IdP groups, so a role that only comes from group membership cannot be
+	// resolved here; falling back to the default role could widen a namespaced group role.
+	if !h.auth.HasDirectRole(email) {
+		auditf("Slack user %s (%s) denied: %s has no role assigned by email", slackID, slackName, email)
+		c.JSON(http.StatusOK, ephemeral("Your K-View role for "+email+" comes from identity provider groups, which Slack cannot see. Ask an administrator to assign a role to "+email+" directly or through a team."))
+		return
+	}
+	user, _ := h.auth.ResolveUser(email, nil)
+
+	text := strings.TrimSpace(form.Get("text"))
+	if text == "" || text == "help" {
+		c.JSON(http.StatusOK, ephemeral("Usage: `"+form.Get("command")+" get pods -n prod` runs `kubectl get pods -n prod` as "+email+" ("+user.Role+")."))
+		return
+	}
+	cmd := normalizeKubectl(text)
+	if !strings.HasPrefix(cmd, "kubectl") {
+		cmd = "kubectl " + cmd
+	}
+	if h.readOnly.Enabled() && kubectlMutation(cmd) {
+		c.JSON(http.StatusOK, ephemeral("K-View is in read-only mode; only read commands are allowed."))
+		return
+	}
+
+	auditf("Slack user %s (%s) as %s (%s) ran: %s", slackID, slackName, email, user.Role, cmd)
+	responseURL := form.Get("response_url")
+	if responseURL == "" {
+		output, exitCode, _ := h.console.Run(cmd, user)
+		c.JSON(http.StatusOK, ephemeral(formatSlackOutput(cmd, output, exitCode)))
+		return
+	}
+	c.JSON(http.StatusOK, ephemeral("Running `"+cmd+"`…"))
+	go func() {
+		output, exitCode, _ := h.console.Run(cmd, user)
+		h.reply(responseURL, ephemeral(formatSlackOutput(cmd, output, exitCode)))
+	}()
+}
+
+// isSlackHost reports whether host is slack.com or one of its subdomains.
+func isSlackHost(host string) bool {
+	return host == "slack.com" || strings.HasSuffix(host, ".slack.com")
+}
+
+// reply posts a delayed response to a slash command's response_url.
+func (h *SlackHandler) reply(responseURL string, msg slackMessage) {
+	u, err := url.Parse(responseURL)
+	if err != nil || u.Scheme != "https" || !isSlackHost(u.Hostname()) {
+		log.Printf("Slack: refusing to post to response_url %q", responseURL)
+		return
+	}
+	payload, _ := json.Marshal(msg)
+	resp, err := h.client.Post(responseURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		log.Printf("Slack: failed to post command output: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("Slack: failed to post command output: HTTP %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"k-view/apierror"
+	"k-view/store"
+	"k-view/tracing"
+)
+
+const slowLogJournal = "slowlog"
+
+// SlowRequest is a request that took longer than its route's latency budget.
+type SlowRequest struct {
+	Time       time.Time         `json:"time"`
+	Method     string            `json:"method"`
+	Route      string            `json:"route"`
+	Path       string            `json:"path"`
+	Status     int               `json:"status"`
+	User       string            `json:"user,omitempty"`
+	DurationMs int64             `json:"durationMs"`
+	BudgetMs   int64             `json:"budgetMs"`
+	K8sCalls   []tracing.APICall `json:"k8sCalls"`
+	K8sOmitted int               `json:"k8sOmitted,omitempty"` // Calls beyond the recorded ones
+	K8sVerbs   map[string]int    `json:"k8sVerbs"`             // Calls per verb
+}
+
+// SlowRoute aggregates the slow requests of one route.
+type SlowRoute struct {
+	Method   string `json:"method"`
+	Route    string `json:"route"`
+	Count    int    `json:"count"`
+	AvgMs    int64  `json:"avgMs"`
+	MaxMs    int64  `json:"maxMs"`
+	BudgetMs int64  `json:"budgetMs"`
+}
+
+// SlowLog records requests that exceed a latency budget, with the Kubernetes API calls they
+// made, for performance triage. Budgets are per route ("GET /api/pods" or "/api/pods") with a
+// default for the rest.
+type SlowLog struct {
+	store         *store.Store
+	defaultBudget time.Duration
+	budgets       map[string]time.Duration
+}
+
+func NewSlowLog(st *store.Store, defaultBudget time.Duration, budgets map[string]time.Duration) *SlowLog {
+	return &SlowLog{store: st, defaultBudget: defaultBudget, budgets: budgets}
+}
+
+// ParseRouteBudgets reads "GET /api/pods=1s,/api/cluster/stats=5s" into per-route budgets.
+func ParseRouteBudgets(spec string) (map[string]time.Duration, error) {
+	budgets := map[string]time.Duration{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, value, ok := strings.Cut(entry, "=")
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if !ok || err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid route budget %q (expected route=duration)", entry)
+		}
+		budgets[strings.TrimSpace(route)] = d
+	}
+	return budgets, nil
+}
+
+// budget returns the latency budget of a route: a method-specific entry, then a route entry,
+// then the default.
+func (l *SlowLog) budget(method, route string) time.Duration {
+	if d, ok := l.budgets[method+" "+route]; ok {
+		return d
+	}
+	if d, ok := l.budgets[route]; ok {
+		return d
+	}
+	return l.defaultBudget
+}
+
+// Middleware times every API request and records the ones over budget. WebSocket upgrades
+// (terminals, attach) and server-sent event streams (live usage) are long-lived by design and
+// are not timed.
+func (l *SlowLog) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l.defaultBudget <= 0 || c.GetHeader("Upgrade") != "" || strings.HasSuffix(c.Request.URL.Path, "/stream") || !strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			c.Next()
+			return
+		}
+		ctx, calls := tracing.TrackCalls(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+		start := time.Now()
+
+		c.Next()
+
+		elapsed := time.Since(start)
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		budget := l.budget(c.Request.Method, route)
+		if elapsed <= budget {
+			return
+		}
+		entry := SlowRequest{
+			Time:       start.UTC(),
+			Method:     c.Request.Method,
+			Route:      route,
+			Path:       c.Request.URL.Path,
+			Status:     c.Writer.Status(),
+			User:       c.GetString("email"),
+			DurationMs: elapsed.Milliseconds(),
+			BudgetMs:   budget.Milliseconds(),
+			K8sVerbs:   map[string]int{},
+		}
+		entry.K8sCalls, entry.K8sOmitted = calls.Calls()
+		for _, call := range entry.K8sCalls {
+			entry.K8sVerbs[call.Verb]++
+		}
+		if entry.K8sCalls == nil {
+			entry.K8sCalls = []tracing.APICall{}
+		}
+		l.record(entry)
+	}
+}
+
+func (l *SlowLog) record(entry SlowRequest) {
+	if err := l.store.Append(slowLogJournal, entry.Time, entry); err != nil {
+		log.Printf("Slow log: %v", err)
+	}
+}
+
+// List returns slow requests since ?since= (default 24h), newest first, with per-route totals
+// (admin only). ?route=, ?user= and ?min= (a duration) narrow the result; ?limit= caps the
+// entries (default 200) but not the totals.
+func (l *SlowLog) List(c *gin.Context) {
+	window, err := time.ParseDuration(c.DefaultQuery("since", "24h"))
+	if err != nil || window <= 0 {
+		apierror.Write(c, http.StatusBadRequest, "since must be a duration such as 24h")
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "200"))
+	if err != nil || limit < 1 {
+		apierror.Write(c, http.StatusBadRequest, "limit must be a positive number")
+		return
+	}
+	var minDuration time.Duration
+	if v := c.Query("min"); v != "" {
+		if minDuration, err = time.ParseDuration(v); err != nil {
+			apierror.Write(c, http.StatusBadRequest, "min must be a duration such as 5s")
+			return
+		}
+	}
+
+	since := time.Now().Add(-window)
+	entries := []SlowRequest{}
+	err = l.store.Scan(slowLogJournal, since, func(raw json.RawMessage) error {
+		var e SlowRequest
+		if json.Unmarshal(raw, &e) != nil || e.Time.Before(since) {
+			return nil
+		}
+		if (c.Query("route") != "" && e.Route != c.Query("route")) ||
+			(c.Query("user") != "" && e.User != c.Query("user")) ||
+			e.DurationMs < minDuration.Milliseconds() {
+			return nil
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		apierror.Fail(c, "Failed to read slow log", err)
+		return
+	}
+
+	routes := map[string]*SlowRoute{}
+	var totals []*SlowRoute
+	for _, e := range entries {
+		key := e.Method + " " + e.Route
+		r, ok := routes[key]
+		if !ok {
+			r = &SlowRoute{Method: e.Method, Route: e.Route, BudgetMs: e.BudgetMs}
+			routes[key] = r
+			totals = append(totals, r)
+		}
+		r.Count++
+		r.AvgMs += e.DurationMs // Summed here, divided below
+		if e.DurationMs > r.MaxMs {
+			r.MaxMs = e.DurationMs
+		}
+	}
+	summary := make([]SlowRoute, 0, len(totals))
+	for _, r := range totals {
+		r.AvgMs /= int64(r.Count)
+		summary = append(summary, *r)
+	}
+	sort.Slice(summary, func(i, j int) bool { return summary[i].Count > summary[j].Count })
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Time.After(entries[j].Time) })
+	truncated := len(entries) > limit
+	if truncated {
+		entries = entries[:limit]
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"since":         since.UTC(),
+		"defaultBudget": l.defaultBudget.String(),
+		"routes":        summary,
+		"requests":      entries,
+		"truncated":     truncated,
+	})
+}
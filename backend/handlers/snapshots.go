@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"k-view/apierror"
+)
+
+// SnapshotRequest is the body of a POST /api/snapshots/:namespace request.
+type SnapshotRequest struct {
+	PVC           string `json:"pvc" binding:"required"`
+	Name          string `json:"name"`          // Defaults to <pvc>-<timestamp>
+	SnapshotClass string `json:"snapshotClass"` // Defaults to the cluster's default VolumeSnapshotClass
+}
+
+// RestoreRequest is the body of a POST /api/snapshots/:namespace/:name/restore request.
+type RestoreRequest struct {
+	PVC          string `json:"pvc" binding:"required"`
+	StorageClass string `json:"storageClass"` // Defaults to the storage class of the source PVC
+	Size         string `json:"size"`         // Defaults to the snapshot's restoreSize
+}
+
+// requireEditAccess applies the namespace restriction and edit permission checks for write
+// endpoints scoped to one namespace. It writes the error response and returns false if access is denied.
+func requireEditAccess(c *gin.Context, ns string) bool {
+	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" && ns != rbacNs.(string) {
+		apierror.Write(c, http.StatusForbidden, "access denied to namespace "+ns)
+		return false
+	}
+	role, _ := c.Get("role")
+	if role.(string) != "kview-cluster-admin" && role.(string) != "admin" && role.(string) != "edit" {
+		apierror.Write(c, http.StatusForbidden, "Admin/Edit permissions required")
+		return false
+	}
+	return true
+}
+
+// CreateSnapshot takes a point-in-time VolumeSnapshot of a PVC.
+func (h *ResourceHandler) CreateSnapshot(c *gin.Context) {
+	ns := c.Param("namespace")
+	var req SnapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "pvc is required")
+		return
+	}
+	if req.Name == "" {
+		req.Name = fmt.Sprintf("%s-%s", req.PVC, time.Now().UTC().Format("20060102-150405"))
+	}
+	if !requireEditAccess(c, ns) {
+		return
+	}
+	if h.rejectProtected(c, "volume-snapshots", ns, req.Name) {
+		return
+	}
+
+	if h.devMode {
+		c.JSON(http.StatusCreated, gin.H{"message": "Snapshot " + req.Name + " created (mocked)", "name": req.Name})
+		return
+	}
+
+	ctx := c.Request.Context()
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+	if _, err := dynClient.Resource(getGVR("pvcs")).Namespace(ns).Get(ctx, req.PVC, metav1.GetOptions{}); err != nil {
+		apierror.Fail(c, "Failed to get resource", err)
+		return
+	}
+
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{"persistentVolumeClaimName": req.PVC},
+	}
+	if req.SnapshotClass != "" {
+		spec["volumeSnapshotClassName"] = req.SnapshotClass
+	}
+	snapshot := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "snapshot.storage.k8s.io/v1",
+		"kind":       "VolumeSnapshot",
+		"metadata": map[string]interface{}{
+			"name":      req.Name,
+			"namespace": ns,
+			"labels":    map[string]interface{}{"app.kubernetes.io/managed-by": "k-view"},
+		},
+		"spec": spec,
+	}}
+	if _, err := dynClient.Resource(getGVR("volume-snapshots")).Namespace(ns).Create(ctx, snapshot, metav1.CreateOptions{}); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			apierror.Write(c, http.StatusConflict, "snapshot "+req.Name+" already exists")
+			return
+		}
+		apierror.Fail(c, "Failed to create snapshot", err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Snapshot " + req.Name + " created", "name": req.Name})
+}
+
+// RestoreSnapshot creates a new PVC populated from a ready VolumeSnapshot.
+func (h *ResourceHandler) RestoreSnapshot(c *gin.Context) {
+	ns := c.Param("namespace")
+	name := c.Param("name")
+	var req RestoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "pvc is required")
+		return
+	}
+	if !requireEditAccess(c, ns) {
+		return
+	}
+	if h.rejectProtected(c, "pvcs", ns, req.PVC) {
+		return
+	}
+
+	if h.devMode {
+		c.JSON(http.StatusCreated, gin.H{"message": "PVC " + req.PVC + " restored from " + name + " (mocked)"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+	snapshot, err := dynClient.Resource(getGVR("volume-snapshots")).Namespace(ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		apierror.Fail(c, "Failed to get resource", err)
+		return
+	}
+	if ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse"); !ready {
+		apierror.Write(c, http.StatusConflict, "snapshot "+name+" is not ready to use yet")
+		return
+	}
+
+	// Inherit size, storage class and access modes from the snapshot and its source claim
+	accessModes := []interface{}{"ReadWriteOnce"}
+	if req.Size == "" {
+		req.Size, _, _ = unstructured.NestedString(snapshot.Object, "status", "restoreSize")
+	}
+	if sourcePVC, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName"); sourcePVC != "" {
+		if source, err := dynClient.Resource(getGVR("pvcs")).Namespace(ns).Get(ctx, sourcePVC, metav1.GetOptions{}); err == nil {
+			if req.StorageClass == "" {
+				req.StorageClass, _, _ = unstructured.NestedString(source.Object, "spec", "storageClassName")
+			}
+			if req.Size == "" {
+				req.Size, _, _ = unstructured.NestedString(source.Object, "spec", "resources", "requests", "storage")
+			}
+			if modes, found, _ := unstructured.NestedSlice(source.Object, "spec", "accessModes"); found {
+				accessModes = modes
+			}
+		}
+	}
+	if req.Size == "" {
+		apierror.Write(c, http.StatusBadRequest, "size is required: the snapshot reports no restoreSize")
+		return
+	}
+
+	spec := map[string]interface{}{
+		"accessModes": accessModes,
+		"resources":   map[string]interface{}{"requests": map[string]interface{}{"storage": req.Size}},
+		"dataSource": map[string]interface{}{
+			"apiGroup": "snapshot.storage.k8s.io",
+			"kind":     "VolumeSnapshot",
+			"name":     name,
+		},
+	}
+	if req.StorageClass != "" {
+		spec["storageClassName"] = req.StorageClass
+	}
+	pvc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "PersistentVolumeClaim",
+		"metadata":   map[string]interface{}{"name": req.PVC, "namespace": ns},
+		"spec":       spec,
+	}}
+	if _, err := dynClient.Resource(getGVR("pvcs")).Namespace(ns).Create(ctx, pvc, metav1.CreateOptions{}); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			apierror.Write(c, http.StatusConflict, "PVC "+req.PVC+" already exists")
+			return
+		}
+		apierror.Fail(c, "Failed to restore snapshot", err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "PVC " + req.PVC + " restored from " + name})
+}
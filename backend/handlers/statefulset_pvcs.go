@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"k-view/apierror"
+)
+
+// StatefulSetPVC is a claim created from one of a StatefulSet's volumeClaimTemplates.
+type StatefulSetPVC struct {
+	Name         string `json:"name"`
+	Template     string `json:"template"`
+	Ordinal      int    `json:"ordinal"`
+	Capacity     string `json:"capacity,omitempty"`
+	StorageClass string `json:"storageClass,omitempty"`
+	Status       string `json:"status"`
+	Orphaned     bool   `json:"orphaned"` // Ordinal >= replicas: left behind after a scale-down
+}
+
+// PVCCleanupResult reports what happened to one claim during an orphan cleanup.
+type PVCCleanupResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // Deleted, DryRun, Skipped, Failed
+	Error  string `json:"error,omitempty"`
+}
+
+type StatefulSetPVCReport struct {
+	StatefulSet string           `json:"statefulSet"`
+	Namespace   string           `json:"namespace"`
+	Replicas    int64            `json:"replicas"`
+	PVCs        []StatefulSetPVC `json:"pvcs"`
+}
+
+// statefulSetClaimOrdinal matches "<template>-<statefulset>-<ordinal>" claim names.
+func statefulSetClaimOrdinal(claim, template, statefulSet string) (int, bool) {
+	prefix := template + "-" + statefulSet + "-"
+	if !strings.HasPrefix(claim, prefix) {
+		return 0, false
+	}
+	ordinal, err := strconv.Atoi(strings.TrimPrefix(claim, prefix))
+	if err != nil || ordinal < 0 {
+		return 0, false
+	}
+	return ordinal, true
+}
+
+// statefulSetPVCs lists the claims that belong to a StatefulSet's volumeClaimTemplates.
+func (h *ResourceHandler) statefulSetPVCs(c *gin.Context, ns, name string) (*StatefulSetPVCReport, bool) {
+	if h.devMode {
+		report := &StatefulSetPVCReport{StatefulSet: name, Namespace: ns, Replicas: 2, PVCs: []StatefulSetPVC{}}
+		for i := 0; i < 4; i++ {
+			pvc := StatefulSetPVC{Name: fmt.Sprintf("data-%s-%d", name, i), Template: "data", Ordinal: i, Capacity: "20Gi", StorageClass: "standard", Status: "Bound", Orphaned: i >= 2}
+			report.PVCs = append(report.PVCs, pvc)
+		}
+		return report, true
+	}
+
+	ctx := c.Request.Context()
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return nil, false
+	}
+	sts, err := dynClient.Resource(getGVR("statefulsets")).Namespace(ns).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		apierror.Fail(c, "Failed to get resource", err)
+		return nil, false
+	}
+	report := &StatefulSetPVCReport{StatefulSet: name, Namespace: ns, Replicas: 1, PVCs: []StatefulSetPVC{}}
+	if replicas, found, _ := unstructured.NestedInt64(sts.Object, "spec", "replicas"); found {
+		report.Replicas = replicas
+	}
+
+	var templates []string
+	rawTemplates, _, _ := unstructured.NestedSlice(sts.Object, "spec", "volumeClaimTemplates")
+	for _, raw := range rawTemplates {
+		if t, ok := raw.(map[string]interface{}); ok {
+			if tName, _, _ := unstructured.NestedString(t, "metadata", "name"); tName != "" {
+				templates = append(templates, tName)
+			}
+		}
+	}
+	if len(templates) == 0 {
+		return report, true
+	}
+
+	claims, err := dynClient.Resource(getGVR("pvcs")).Namespace(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		apierror.Fail(c, "Failed to list persistent volume claims", err)
+		return nil, false
+	}
+	for _, claim := range claims.Items {
+		for _, tName := range templates {
+			ordinal, ok := statefulSetClaimOrdinal(claim.GetName(), tName, name)
+			if !ok {
+				continue
+			}
+			pvc := StatefulSetPVC{Name: claim.GetName(), Template: tName, Ordinal: ordinal, Orphaned: int64(ordinal) >= report.Replicas}
+			pvc.Status, _, _ = unstructured.NestedString(claim.Object, "status", "phase")
+			pvc.Capacity, _, _ = unstructured.NestedString(claim.Object, "status", "capacity", "storage")
+			pvc.StorageClass, _, _ = unstructured.NestedString(claim.Object, "spec", "storageClassName")
+			report.PVCs = append(report.PVCs, pvc)
+			break
+		}
+	}
+	sort.Slice(report.PVCs, func(i, j int) bool {
+		if report.PVCs[i].Ordinal != report.PVCs[j].Ordinal {
+			return report.PVCs[i].Ordinal < report.PVCs[j].Ordinal
+		}
+		return report.PVCs[i].Template < report.PVCs[j].Template
+	})
+	return report, true
+}
+
+// ListStatefulSetPVCs lists a StatefulSet's per-replica PVCs with size and binding status,
+// flagging claims left behind by a scale-down.
+func (h *ResourceHandler) ListStatefulSetPVCs(c *gin.Context) {
+	ns := c.Param("namespace")
+	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" && ns != rbacNs.(string) {
+		apierror.Write(c, http.StatusForbidden, "access denied to namespace "+ns)
+		return
+	}
+	report, ok := h.statefulSetPVCs(c, ns, c.Param("name"))
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// CleanupStatefulSetPVCs deletes orphaned PVCs (ordinal >= replicas). An optional
+// {"pvcs": [...]} body limits the cleanup to the listed claims; non-orphaned claims are never deleted.
+func (h *ResourceHandler) CleanupStatefulSetPVCs(c *gin.Context) {
+	ns := c.Param("namespace")
+	name := c.Param("name")
+	var req struct {
+		PVCs   []string `json:"pvcs"`
+		DryRun bool     `json:"dryRun"`
+	}
+	// An empty body means "all orphans"
+	_ = c.ShouldBindJSON(&req)
+
+	if !requireEditAccess(c, ns) {
+		return
+	}
+	if h.rejectProtected(c, "pvcs", ns, name) {
+		return
+	}
+	report, ok := h.statefulSetPVCs(c, ns, name)
+	if !ok {
+		return
+	}
+
+	selected := map[string]bool{}
+	for _, p := range req.PVCs {
+		selected[p] = true
+	}
+	results := []PVCCleanupResult{}
+	for _, pvc := range report.PVCs {
+		if len(selected) > 0 && !selected[pvc.Name] {
+			continue
+		}
+		if !pvc.Orphaned {
+			if selected[pvc.Name] {
+				results = append(results, PVCCleanupResult{Name: pvc.Name, Status: "Skipped", Error: "claim is still used by a replica"})
+			}
+			continue
+		}
+		result := PVCCleanupResult{Name: pvc.Name, Status: "Deleted"}
+		switch {
+		case req.DryRun:
+			result.Status = "DryRun"
+		case h.devMode:
+		default:
+			dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
+			if err == nil {
+				err = dynClient.Resource(getGVR("pvcs")).Namespace(ns).Delete(c.Request.Context(), pvc.Name, metav1.DeleteOptions{})
+			}
+			if err != nil {
+				result.Status = "Failed"
+				result.Error = err.Error()
+			}
+		}
+		results = append(results, result)
+	}
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"k-view/apierror"
+)
+
+// statsWindow is how far back the admin statistics reach, in hourly buckets.
+const statsWindow = 24
+
+// activeUserWindow is how recently a user must have made a request to count as active.
+// Sessions are stateless tokens, so recent activity is the closest thing to a live session.
+const activeUserWindow = 15 * time.Minute
+
+// terminalRoutes are the WebSocket routes that open a terminal.
+var terminalRoutes = map[string]bool{
+	"/api/exec/:namespace/:name":              true,
+	"/api/exec/:namespace/:name/:container":   true,
+	"/api/attach/:namespace/:name":            true,
+	"/api/attach/:namespace/:name/:container": true,
+	"/api/nodes/:name/shell":                  true,
+}
+
+type routeCounts struct {
+	requests     int
+	clientErrors int // 4xx
+	errors       int // 5xx
+}
+
+type statsBucket struct {
+	hour         time.Time
+	routes       map[string]*routeCounts // "GET /api/pods"
+	users        map[string]*routeCounts
+	failedLogins map[string]int
+	terminals    int
+}
+
+// UsageStats counts API traffic per user and route for the admin dashboard. Counts are kept in
+// memory, in hourly buckets for the last 24 hours, and start over when K-View restarts.
+type UsageStats struct {
+	mu       sync.Mutex
+	buckets  [statsWindow]*statsBucket
+	lastSeen map[string]time.Time
+	exec     *ExecHandler
+}
+
+func NewUsageStats(exec *ExecHandler) *UsageStats {
+	return &UsageStats{lastSeen: map[string]time.Time{}, exec: exec}
+}
+
+// bucket returns the bucket of the current hour, recycling the one from 24 hours ago.
+// Callers hold s.mu.
+func (s *UsageStats) bucket(now time.Time) *statsBucket {
+	hour := now.Truncate(time.Hour)
+	i := int(hour.Unix()/3600) % statsWindow
+	b := s.buckets[i]
+	if b == nil || !b.hour.Equal(hour) {
+		b = &statsBucket{hour: hour, routes: map[string]*routeCounts{}, users: map[string]*routeCounts{}, failedLogins: map[string]int{}}
+		s.buckets[i] = b
+	}
+	return b
+}
+
+func (r *routeCounts) add(status int) {
+	r.requests++
+	switch {
+	case status >= 500:
+		r.errors++
+	case status >= 400:
+		r.clientErrors++
+	}
+}
+
+// Middleware counts every API request once it has been handled.
+func (s *UsageStats) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if !strings.HasPrefix(c.Request.URL.Path, "/api/") {
+			return
+		}
+		route := c.FullPath()
+		if route == "" {
+			route = "(unmatched)" // Raw paths of 404s would grow the table without bound
+		}
+		key := c.Request.Method + " " + route
+		status := c.Writer.Status()
+		user := c.GetString("email")
+		now := time.Now().UTC()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		b := s.bucket(now)
+		if b.routes[key] == nil {
+			b.routes[key] = &routeCounts{}
+		}
+		b.routes[key].add(status)
+		if user != "" {
+			if b.users[user] == nil {
+				b.users[user] = &routeCounts{}
+			}
+			b.users[user].add(status)
+			s.lastSeen[user] = now
+		}
+		if terminalRoutes[route] && c.GetHeader("Upgrade") != "" {
+			b.terminals++
+		}
+	}
+}
+
+// failedLogin records a rejected sign-in. It is safe to call on a nil UsageStats.
+func (s *UsageStats) failedLogin(user string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bucket(time.Now().UTC()).failedLogins[user]++
+}
+
+// UserStats is one user's traffic.
+type UserStats struct {
+	User         string `json:"user"`
+	Requests     int    `json:"requests"`
+	ClientErrors int    `json:"clientErrors"`
+	Errors       int    `json:"errors"`
+}
+
+// EndpointStats is one route's traffic. ErrorRate is the share of 5xx responses.
+type EndpointStats struct {
+	Method       string  `json:"method"`
+	Route        string  `json:"route"`
+	Requests     int     `json:"requests"`
+	ClientErrors int     `json:"clientErrors"`
+	Errors       int     `json:"errors"`
+	ErrorRate    float64 `json:"errorRate"`
+}
+
+// HourStats is the traffic of one hour, for charts.
+type HourStats struct {
+	Hour         time.Time `json:"hour"`
+	Requests     int       `json:"requests"`
+	Errors       int       `json:"errors"`
+	FailedLogins int       `json:"failedLogins"`
+}
+
+func errorRate(errors, requests int) float64 {
+	if requests == 0 {
+		return 0
+	}
+	return float64(errors) / float64(requests)
+}
+
+// Stats summarizes the last 24 hours (admin only): active users, requests per user, terminals,
+// failed logins, the most used endpoints and error rates. ?limit= caps the user and endpoint
+// lists (default 20).
+func (s *UsageStats) Stats(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		apierror.Write(c, http.StatusBadRequest, "limit must be a positive number")
+		return
+	}
+	now := time.Now().UTC()
+	since := now.Truncate(time.Hour).Add(-(statsWindow - 1) * time.Hour)
+
+	users := map[string]*UserStats{}
+	routes := map[string]*EndpointStats{}
+	failed := map[string]int{}
+	hourly := []HourStats{}
+	var total routeCounts
+	totalFailed, terminalsOpened := 0, 0
+	active := []gin.H{}
+
+	s.mu.Lock()
+	for _, b := range s.buckets {
+		if b == nil || b.hour.Before(since) {
+			continue
+		}
+		hour := HourStats{Hour: b.hour}
+		for key, r := range b.routes {
+			e, ok := routes[key]
+			if !ok {
+				method, route, _ := strings.Cut(key, " ")
+				e = &EndpointStats{Method: method, Route: route}
+				routes[key] = e
+			}
+			e.Requests += r.requests
+			e.ClientErrors += r.clientErrors
+			e.Errors += r.errors
+			hour.Requests += r.requests
+			hour.Errors += r.errors
+			total.requests += r.requests
+			total.clientErrors += r.clientErrors
+			total.errors += r.errors
+		}
+		for user, r := range b.users {
+			u, ok := users[user]
+			if !ok {
+				u = &UserStats{User: user}
+				users[user] = u
+			}
+			u.Requests += r.requests
+			u.ClientErrors += r.clientErrors
+			u.Errors += r.errors
+		}
+		for user, n := range b.failedLogins {
+			failed[user] += n
+			hour.FailedLogins += n
+			totalFailed += n
+		}
+		terminalsOpened += b.terminals
+		hourly = append(hourly, hour)
+	}
+	for user, seen := range s.lastSeen {
+		if now.Sub(seen) > statsWindow*time.Hour {
+			delete(s.lastSeen, user)
+			continue
+		}
+		if now.Sub(seen) <= activeUserWindow {
+			active = append(active, gin.H{"user": user, "lastSeen": seen})
+		}
+	}
+	s.mu.Unlock()
+
+	perUser := make([]UserStats, 0, len(users))
+	for _, u := range users {
+		perUser = append(perUser, *u)
+	}
+	sort.Slice(perUser, func(i, j int) bool { return perUser[i].Requests > perUser[j].Requests })
+	endpoints := make([]EndpointStats, 0, len(routes))
+	for _, e := range routes {
+		e.ErrorRate = errorRate(e.Errors, e.Requests)
+		endpoints = append(endpoints, *e)
+	}
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].Requests > endpoints[j].Requests })
+	// The routes failing most often, by share of 5xx responses
+	failing := []EndpointStats{}
+	for _, e := range endpoints {
+		if e.Errors > 0 {
+			failing = append(failing, e)
+		}
+	}
+	sort.SliceStable(failing, func(i, j int) bool { return failing[i].ErrorRate > failing[j].ErrorRate })
+	failedByUser := make([]gin.H, 0, len(failed))
+	for user, n := range failed {
+		failedByUser = append(failedByUser, gin.H{"user": user, "count": n})
+	}
+	sort.Slice(failedByUser, func(i, j int) bool { return failedByUser[i]["count"].(int) > failedByUser[j]["count"].(int) })
+	sort.Slice(hourly, func(i, j int) bool { return hourly[i].Hour.Before(hourly[j].Hour) })
+	sort.Slice(active, func(i, j int) bool { return active[i]["lastSeen"].(time.Time).After(active[j]["lastSeen"].(time.Time)) })
+
+	open := 0
+	if s.exec != nil {
+		open = s.exec.sessions.count()
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"since":        since,
+		"activeWindow": activeUserWindow.String(),
+		"activeUsers":  active,
+		"requests": gin.H{
+			"total":        total.requests,
+			"clientErrors": total.clientErrors,
+			"errors":       total.errors,
+			"errorRate":    errorRate(total.errors, total.requests),
+		},
+		"requestsPerUser":  perUser[:min(limit, len(perUser))],
+		"topEndpoints":     endpoints[:min(limit, len(endpoints))],
+		"failingEndpoints": failing[:min(limit, len(failing))],
+		"failedLogins":     gin.H{"total": totalFailed, "byUser": failedByUser},
+		"terminals":        gin.H{"open": open, "opened": terminalsOpened},
+		"hourly":           hourly,
+	})
+}
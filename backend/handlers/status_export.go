@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The status export follows the list format of the Kubernetes Dashboard API (objectMeta,
+// typeMeta, pods, containerImages), so tools that already read it can ingest this document.
+
+// ExportObjectMeta identifies a workload.
+type ExportObjectMeta struct {
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	CreationTimestamp string            `json:"creationTimestamp,omitempty"`
+}
+
+// ExportPodInfo counts the pods of a workload by phase.
+type ExportPodInfo struct {
+	Current   int `json:"current"`
+	Desired   int `json:"desired"`
+	Running   int `json:"running"`
+	Pending   int `json:"pending"`
+	Failed    int `json:"failed"`
+	Succeeded int `json:"succeeded"`
+}
+
+// ExportWorkload is the status of one deployment, statefulset or daemonset.
+type ExportWorkload struct {
+	ObjectMeta      ExportObjectMeta  `json:"objectMeta"`
+	TypeMeta        map[string]string `json:"typeMeta"` // {"kind": "deployment"}
+	Pods            ExportPodInfo     `json:"pods"`
+	ContainerImages []string          `json:"containerImages"`
+	// Version is the app.kubernetes.io/version label, when set
+	Version string `json:"version,omitempty"`
+	Health  string `json:"health"` // Healthy, Degraded or Unhealthy
+}
+
+// ExportNode is a node and the version of its kubelet.
+type ExportNode struct {
+	Name           string `json:"name"`
+	Role           string `json:"role"`
+	KubeletVersion string `json:"kubeletVersion"`
+	Ready          bool   `json:"ready"`
+}
+
+// StatusExport is the cluster status document.
+type StatusExport struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	Cluster     struct {
+		Version string       `json:"version"`
+		Nodes   []ExportNode `json:"nodes"`
+	} `json:"cluster"`
+	ListMeta struct {
+		TotalItems int `json:"totalItems"`
+	} `json:"listMeta"`
+	Workloads []ExportWorkload `json:"workloads"`
+	// Status counts the pods of all exported workloads by phase
+	Status ExportPodInfo     `json:"status"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// exportKinds maps the exported kinds to their typeMeta kind.
+var exportKinds = []struct{ resource, kind string }{
+	{"deployments", "deployment"},
+	{"statefulsets", "statefulset"},
+	{"daemonsets", "daemonset"},
+}
+
+// ExportStatus returns a machine-readable status document of the workloads the caller can see:
+// replicas and pods by phase, images, versions and health, plus the cluster and kubelet
+// versions. Reporting tools can fetch it on a schedule with a personal access token. Sections
+// that cannot be loaded are reported in errors.
+func (h *ResourceHandler) ExportStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.statusDocument(c.Request.Context(), rbacNamespace(c)))
+}
+
+// statusDocument builds the status export for ns, or for the whole cluster when ns is empty.
+func (h *ResourceHandler) statusDocument(ctx context.Context, ns string) StatusExport {
+	doc := StatusExport{GeneratedAt: time.Now().UTC(), Workloads: []ExportWorkload{}}
+	doc.Cluster.Nodes = []ExportNode{}
+	fail := func(section string, err error) {
+		if doc.Errors == nil {
+			doc.Errors = map[string]string{}
+		}
+		doc.Errors[section] = err.Error()
+	}
+
+	// Namespace-restricted users get no node details, as with the cluster stats
+	if ns == "" {
+		nodes, err := h.k8sClient.ListNodes(ctx)
+		if err != nil {
+			fail("nodes", err)
+		}
+		var versions []NodeVersion
+		for _, n := range nodes {
+			ready := false
+			for _, cond := range n.Status.Conditions {
+				if cond.Type == corev1.NodeReady {
+					ready = cond.Status == corev1.ConditionTrue
+				}
+			}
+			node := ExportNode{Name: n.Name, Role: nodeRole(n), KubeletVersion: n.Status.NodeInfo.KubeletVersion, Ready: ready}
+			doc.Cluster.Nodes = append(doc.Cluster.Nodes, node)
+			versions = append(versions, NodeVersion{Name: node.Name, Role: node.Role, KubeletVersion: node.KubeletVersion})
+		}
+		doc.Cluster.Version = controlPlaneVersion(ctx, h.k8sClient, versions)
+	}
+
+	pods, err := h.k8sClient.ListPods(ctx, ns)
+	if err != nil {
+		fail("pods", err)
+	}
+
+	index := map[string]int{} // "namespace/name" -> position in doc.Workloads
+	for _, k := range exportKinds {
+		items, err := h.listItems(ctx, k.resource, ns)
+		if err != nil {
+			fail(k.resource, err)
+			continue
+		}
+		for _, item := range items {
+			ready, desired, _ := workloadReadiness(k.resource, item)
+			w := ExportWorkload{
+				ObjectMeta:      ExportObjectMeta{Name: item.Name, Namespace: item.Namespace, Labels: item.Labels, CreationTimestamp: item.CreationTimestamp},
+				TypeMeta:        map[string]string{"kind": k.kind},
+				Pods:            ExportPodInfo{Desired: desired},
+				ContainerImages: []string{},
+				Version:         item.Labels["app.kubernetes.io/version"],
+				Health:          "Healthy",
+			}
+			switch {
+			case desired > 0 && ready == 0:
+				w.Health = "Unhealthy"
+			case ready < desired:
+				w.Health = "Degraded"
+			}
+			doc.Status.Desired += desired
+			index[item.Namespace+"/"+item.Name] = len(doc.Workloads)
+			doc.Workloads = append(doc.Workloads, w)
+		}
+	}
+
+	for _, p := range pods {
+		i, ok := index[podWorkload(p)]
+		if !ok && h.devMode {
+			// Mock pods have no owner references; match them by name
+			for _, w := range doc.Workloads {
+				if w.ObjectMeta.Namespace == p.Namespace && strings.HasPrefix(p.Name, w.ObjectMeta.Name+"-") {
+					i, ok = index[p.Namespace+"/"+w.ObjectMeta.Name], true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		w := &doc.Workloads[i]
+		for _, counts := range []*ExportPodInfo{&w.Pods, &doc.Status} {
+			counts.Current++
+			switch p.Status.Phase {
+			case corev1.PodRunning:
+				counts.Running++
+			case corev1.PodPending:
+				counts.Pending++
+			case corev1.PodFailed:
+				counts.Failed++
+			case corev1.PodSucceeded:
+				counts.Succeeded++
+			}
+		}
+		for _, ctr := range p.Spec.Containers {
+			if !contains(w.ContainerImages, ctr.Image) {
+				w.ContainerImages = append(w.ContainerImages, ctr.Image)
+			}
+		}
+	}
+
+	for i := range doc.Workloads {
+		sort.Strings(doc.Workloads[i].ContainerImages)
+	}
+	doc.ListMeta.TotalItems = len(doc.Workloads)
+	return doc
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"k-view/apierror"
+)
+
+var (
+	csiDriversGVR        = schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "csidrivers"}
+	volumeAttachmentsGVR = schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "volumeattachments"}
+)
+
+// VolumeTopology follows one volume from its claim down to the node it is attached to.
+type VolumeTopology struct {
+	Namespace    string   `json:"namespace,omitempty"`
+	PVC          string   `json:"pvc,omitempty"`
+	PVCStatus    string   `json:"pvcStatus,omitempty"`
+	Capacity     string   `json:"capacity,omitempty"`
+	PV           string   `json:"pv,omitempty"`
+	PVStatus     string   `json:"pvStatus,omitempty"`
+	StorageClass string   `json:"storageClass,omitempty"`
+	Provisioner  string   `json:"provisioner,omitempty"`
+	CSIDriver    string   `json:"csiDriver,omitempty"`
+	VolumeHandle string   `json:"volumeHandle,omitempty"`
+	Node         string   `json:"node,omitempty"`
+	Attached     bool     `json:"attached"`
+	AttachError  string   `json:"attachError,omitempty"`
+	Pods         []string `json:"pods"`
+}
+
+// CSIDriverInfo is an installed CSI driver and how many volumes it serves.
+type CSIDriverInfo struct {
+	Name           string `json:"name"`
+	AttachRequired bool   `json:"attachRequired"`
+	Volumes        int    `json:"volumes"`
+}
+
+type StorageTopology struct {
+	Volumes []VolumeTopology    `json:"volumes"`
+	Drivers []CSIDriverInfo     `json:"drivers"`
+	Nodes   map[string][]string `json:"nodes"` // node -> attached PVs
+}
+
+// GetStorageTopology maps PVCs -> PVs -> StorageClasses -> CSI drivers -> VolumeAttachments,
+// answering "which node holds this volume".
+func (h *ResourceHandler) GetStorageTopology(c *gin.Context) {
+	ns := c.Query("namespace")
+	if ns == "-" {
+		ns = ""
+	}
+	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
+		ns = rbacNs.(string)
+	}
+
+	if h.devMode {
+		topology := StorageTopology{
+			Volumes: []VolumeTopology{
+				{Namespace: "database", PVC: "postgres-data-pvc", PVCStatus: "Bound", Capacity: "50Gi", PV: "pv-postgres-primary", PVStatus: "Bound", StorageClass: "standard", Provisioner: "pd.csi.storage.gke.io", CSIDriver: "pd.csi.storage.gke.io", VolumeHandle: "projects/demo/zones/europe-west1-b/disks/pvc-4f1c", Node: "worker-02", Attached: true, Pods: []string{"postgres-primary-0"}},
+				{Namespace: "messaging", PVC: "kafka-data-pvc-0", PVCStatus: "Bound", Capacity: "20Gi", PV: "pv-kafka-0", PVStatus: "Bound", StorageClass: "standard", Provisioner: "pd.csi.storage.gke.io", CSIDriver: "pd.csi.storage.gke.io", VolumeHandle: "projects/demo/zones/europe-west1-b/disks/pvc-9a2e", Node: "worker-01", Attached: true, Pods: []string{"kafka-broker-0"}},
+				{Namespace: "messaging", PVC: "kafka-data-pvc-1", PVCStatus: "Bound", Capacity: "20Gi", PV: "pv-kafka-1", PVStatus: "Bound", StorageClass: "standard", Provisioner: "pd.csi.storage.gke.io", CSIDriver: "pd.csi.storage.gke.io", VolumeHandle: "projects/demo/zones/europe-west1-c/disks/pvc-b7d0", Node: "worker-03", Attached: false, AttachError: "rpc error: code = Internal desc = disk is attached to another node", Pods: []string{"kafka-broker-1"}},
+				{Namespace: "monitoring", PVC: "prometheus-data-pvc", PVCStatus: "Bound", Capacity: "10Gi", PV: "pv-prometheus", PVStatus: "Bound", StorageClass: "standard", Provisioner: "pd.csi.storage.gke.io", CSIDriver: "pd.csi.storage.gke.io", Node: "worker-01", Attached: true, Pods: []string{"prometheus-0"}},
+				{Namespace: "default", PVC: "orphan-pvc", PVCStatus: "Pending", Capacity: "5Gi", StorageClass: "standard", Provisioner: "pd.csi.storage.gke.io", Pods: []string{}},
+				{PV: "pv-released-old", PVStatus: "Released", Capacity: "5Gi", StorageClass: "standard", Provisioner: "pd.csi.storage.gke.io", CSIDriver: "pd.csi.storage.gke.io", Pods: []string{}},
+			},
+			Drivers: []CSIDriverInfo{{Name: "pd.csi.storage.gke.io", AttachRequired: true, Volumes: 5}},
+			Nodes:   map[string][]string{"worker-01": {"pv-kafka-0", "pv-prometheus"}, "worker-02": {"pv-postgres-primary"}},
+		}
+		if ns != "" {
+			filtered := []VolumeTopology{}
+			for _, v := range topology.Volumes {
+				if v.Namespace == ns {
+					filtered = append(filtered, v)
+				}
+			}
+			topology.Volumes = filtered
+		}
+		c.JSON(http.StatusOK, topology)
+		return
+	}
+
+	ctx := c.Request.Context()
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+
+	pvcs, err := dynClient.Resource(getGVR("pvcs")).Namespace(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		apierror.Fail(c, "Failed to list persistent volume claims", err)
+		return
+	}
+	// Namespace-scoped users may not be allowed to read PVs; their claims are still listed
+	pvs, err := dynClient.Resource(getGVR("pvs")).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		pvs = &unstructured.UnstructuredList{}
+	}
+
+	provisioners := map[string]string{}
+	if classes, err := dynClient.Resource(getGVR("storage-classes")).List(ctx, metav1.ListOptions{}); err == nil {
+		for _, sc := range classes.Items {
+			provisioners[sc.GetName()], _, _ = unstructured.NestedString(sc.Object, "provisioner")
+		}
+	}
+
+	type attachment struct {
+		node     string
+		attached bool
+		err      string
+	}
+	attachments := map[string]attachment{}
+	if list, err := dynClient.Resource(volumeAttachmentsGVR).List(ctx, metav1.ListOptions{}); err == nil {
+		for _, va := range list.Items {
+			pv, _, _ := unstructured.NestedString(va.Object, "spec", "source", "persistentVolumeName")
+			node, _, _ := unstructured.NestedString(va.Object, "spec", "nodeName")
+			attached, _, _ := unstructured.NestedBool(va.Object, "status", "attached")
+			msg, _, _ := unstructured.NestedString(va.Object, "status", "attachError", "message")
+			attachments[pv] = attachment{node: node, attached: attached, err: msg}
+		}
+	}
+
+	// Pods reference claims; the pod's node is the answer when the driver needs no attachment
+	podsByClaim := map[string][]string{}
+	podNodeByClaim := map[string]string{}
+	if pods, err := h.k8sClient.ListPods(ctx, ns); err == nil {
+		for _, pod := range pods {
+			for _, vol := range pod.Spec.Volumes {
+				if vol.PersistentVolumeClaim == nil {
+					continue
+				}
+				key := pod.Namespace + "/" + vol.PersistentVolumeClaim.ClaimName
+				podsByClaim[key] = append(podsByClaim[key], pod.Name)
+				if pod.Spec.NodeName != "" {
+					podNodeByClaim[key] = pod.Spec.NodeName
+				}
+			}
+		}
+	}
+
+	topology := StorageTopology{Volumes: []VolumeTopology{}, Drivers: []CSIDriverInfo{}, Nodes: map[string][]string{}}
+	driverVolumes := map[string]int{}
+	pvByName := map[string]unstructured.Unstructured{}
+	for _, pv := range pvs.Items {
+		pvByName[pv.GetName()] = pv
+	}
+
+	fillPV := func(v *VolumeTopology, pv unstructured.Unstructured) {
+		v.PV = pv.GetName()
+		v.PVStatus, _, _ = unstructured.NestedString(pv.Object, "status", "phase")
+		if v.Capacity == "" {
+			v.Capacity, _, _ = unstructured.NestedString(pv.Object, "spec", "capacity", "storage")
+		}
+		if v.StorageClass == "" {
+			v.StorageClass, _, _ = unstructured.NestedString(pv.Object, "spec", "storageClassName")
+		}
+		v.CSIDriver, _, _ = unstructured.NestedString(pv.Object, "spec", "csi", "driver")
+		v.VolumeHandle, _, _ = unstructured.NestedString(pv.Object, "spec", "csi", "volumeHandle")
+		if v.CSIDriver != "" {
+			driverVolumes[v.CSIDriver]++
+		}
+		if a, ok := attachments[v.PV]; ok {
+			v.Node, v.Attached, v.AttachError = a.node, a.attached, a.err
+			if a.attached {
+				topology.Nodes[a.node] = append(topology.Nodes[a.node], v.PV)
+			}
+			return
+		}
+		// Local volumes are pinned to a node through their node affinity
+		terms, _, _ := unstructured.NestedSlice(pv.Object, "spec", "nodeAffinity", "required", "nodeSelectorTerms")
+		for _, t := range terms {
+			term, _ := t.(map[string]interface{})
+			exprs, _, _ := unstructured.NestedSlice(term, "matchExpressions")
+			for _, e := range exprs {
+				em, _ := e.(map[string]interface{})
+				values, _, _ := unstructured.NestedStringSlice(em, "values")
+				if em["key"] == "kubernetes.io/hostname" && len(values) == 1 {
+					v.Node, v.Attached = values[0], true
+					topology.Nodes[v.Node] = append(topology.Nodes[v.Node], v.PV)
+				}
+			}
+		}
+	}
+
+	boundPVs := map[string]bool{}
+	for _, pvc := range pvcs.Items {
+		key := pvc.GetNamespace() + "/" + pvc.GetName()
+		v := VolumeTopology{Namespace: pvc.GetNamespace(), PVC: pvc.GetName(), Pods: podsByClaim[key]}
+		v.PVCStatus, _, _ = unstructured.NestedString(pvc.Object, "status", "phase")
+		v.Capacity, _, _ = unstructured.NestedString(pvc.Object, "status", "capacity", "storage")
+		v.StorageClass, _, _ = unstructured.NestedString(pvc.Object, "spec", "storageClassName")
+		volumeName, _, _ := unstructured.NestedString(pvc.Object, "spec", "volumeName")
+		if pv, ok := pvByName[volumeName]; ok {
+			boundPVs[volumeName] = true
+			fillPV(&v, pv)
+		}
+		if v.Node == "" {
+			v.Node = podNodeByClaim[key]
+		}
+		v.Provisioner = provisioners[v.StorageClass]
+		if v.Pods == nil {
+			v.Pods = []string{}
+		}
+		topology.Volumes = append(topology.Volumes, v)
+	}
+
+	// Unclaimed PVs are only of interest to users who can see the whole cluster
+	if ns == "" {
+		for _, pv := range pvs.Items {
+			if boundPVs[pv.GetName()] {
+				continue
+			}
+			v := VolumeTopology{Pods: []string{}}
+			fillPV(&v, pv)
+			v.Provisioner = provisioners[v.StorageClass]
+			topology.Volumes = append(topology.Volumes, v)
+		}
+	}
+
+	if drivers, err := dynClient.Resource(csiDriversGVR).List(ctx, metav1.ListOptions{}); err == nil {
+		for _, d := range drivers.Items {
+			attach, found, _ := unstructured.NestedBool(d.Object, "spec", "attachRequired")
+			topology.Drivers = append(topology.Drivers, CSIDriverInfo{Name: d.GetName(), AttachRequired: attach || !found, Volumes: driverVolumes[d.GetName()]})
+		}
+	}
+	sort.Slice(topology.Drivers, func(i, j int) bool { return topology.Drivers[i].Name < topology.Drivers[j].Name })
+	for node := range topology.Nodes {
+		sort.Strings(topology.Nodes[node])
+	}
+
+	c.JSON(http.StatusOK, topology)
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"k-view/apierror"
+	"k-view/k8s"
+	"k-view/rbac"
+	"k-view/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const teamsDoc = "teams"
+
+// teamNamePattern keeps team names usable in Kubernetes binding names.
+var teamNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,38}[a-z0-9])?$`)
+
+var errTeamNotFound = errors.New("team not found")
+
+// TeamHandler manages teams: everyone in a team, by name or through a directory group, gets
+// the team's role, so admins stop assigning roles user by user. Teams are kept in the store
+// and applied to the RBAC config; against a real cluster each team also gets bindings for its
+// role, a RoleBinding per namespace or a ClusterRoleBinding.
+type TeamHandler struct {
+	mu        sync.Mutex
+	teams     []rbac.Team
+	store     *store.Store
+	rbac      *rbac.RBACConfig
+	k8sClient k8s.KubernetesProvider
+}
+
+func NewTeamHandler(st *store.Store, config *rbac.RBACConfig, k8sClient k8s.KubernetesProvider) *TeamHandler {
+	h := &TeamHandler{store: st, rbac: config, k8sClient: k8sClient}
+	if err := st.Load(teamsDoc, &h.teams); err != nil {
+		log.Printf("Failed to load teams: %v", err)
+	}
+	config.SetTeams(h.teams)
+	return h
+}
+
+func (h *TeamHandler) Document() string { return teamsDoc }
+
+// Reload refreshes the cached teams from the store and re-applies them to the RBAC config.
+func (h *TeamHandler) Reload() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var teams []rbac.Team
+	if err := h.store.Load(teamsDoc, &teams); err != nil {
+		return err
+	}
+	h.teams = teams
+	h.rbac.SetTeams(teams)
+	return nil
+}
+
+// update applies fn to the stored teams, refreshes the cache and re-applies them.
+func (h *TeamHandler) update(fn func([]rbac.Team) ([]rbac.Team, error)) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var stored []rbac.Team
+	err := h.store.Update(teamsDoc, &stored, func() error {
+		var err error
+		stored, err = fn(stored)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	h.teams = stored
+	h.rbac.SetTeams(stored)
+	return nil
+}
+
+func (h *TeamHandler) find(name string) (rbac.Team, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, t := range h.teams {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return rbac.Team{}, false
+}
+
+func teamBindingName(team string) string {
+	return "kview-team-" + team
+}
+
+// bindingScopes returns where a team's bindings live: its namespaces, or "" for cluster-wide.
+func bindingScopes(t rbac.Team) []string {
+	if len(t.Namespaces) == 0 {
+		return []string{""}
+	}
+	return t.Namespaces
+}
+
+// syncBindings makes the cluster's bindings match a team, removing those of its previous
+// version. Either may be nil. It does nothing unless K-View runs against a real cluster.
+func (h *TeamHandler) syncBindings(c *gin.Context, old, team *rbac.Team) error {
+	binder, ok := h.k8sClient.(k8s.RoleBinder)
+	if !ok {
+		return nil
+	}
+	keep := map[string]bool{}
+	if team != nil {
+		for _, ns := range bindingScopes(*team) {
+			if err := binder.Bind(c.Request.Context(), ns, teamBindingName(team.Name), team.Role(), team.Members, team.Groups); err != nil {
+				return err
+			}
+			keep[ns] = true
+		}
+	}
+	if old != nil {
+		for _, ns := range bindingScopes(*old) {
+			if keep[ns] {
+				continue
+			}
+			if err := binder.Unbind(c.Request.Context(), ns, teamBindingName(old.Name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// bindTeam reads and validates a team from the request body.
+func bindTeam(c *gin.Context) (rbac.Team, bool) {
+	var t rbac.Team
+	if err := c.ShouldBindJSON(&t); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "Invalid team: "+err.Error())
+		return t, false
+	}
+	// Blank entries from form fields are dropped
+	t.Members = splitList(strings.Join(t.Members, ","))
+	t.Groups = splitList(strings.Join(t.Groups, ","))
+	t.Namespaces = splitList(strings.Join(t.Namespaces, ","))
+	if t.Level == "" {
+		t.Level = "viewer"
+	}
+	switch {
+	case !contains(rbac.TeamLevels, t.Level):
+		apierror.Write(c, http.StatusBadRequest, "level must be one of "+strings.Join(rbac.TeamLevels, ", "))
+		return t, false
+	case len(t.Members) == 0 && len(t.Groups) == 0:
+		apierror.Write(c, http.StatusBadRequest, "a team needs members or groups")
+		return t, false
+	}
+	return t, true
+}
+
+// List returns all teams.
+func (h *TeamHandler) List(c *gin.Context) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	result := append([]rbac.Team{}, h.teams...)
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	c.JSON(http.StatusOK, result)
+}
+
+// Create adds a team.
+func (h *TeamHandler) Create(c *gin.Context) {
+	t, ok := bindTeam(c)
+	if !ok {
+		return
+	}
+	if !teamNamePattern.MatchString(t.Name) {
+		apierror.Write(c, http.StatusBadRequest, "name must be lower-case letters, digits and dashes, at most 40 characters")
+		return
+	}
+	if _, exists := h.find(t.Name); exists {
+		apierror.Write(c, http.StatusConflict, "team "+t.Name+" already exists")
+		return
+	}
+	if err := h.syncBindings(c, nil, &t); err != nil {
+		apierror.Fail(c, "", err)
+		return
+	}
+	err := h.update(func(all []rbac.Team) ([]rbac.Team, error) {
+		for _, existing := range all {
+			if existing.Name == t.Name {
+				return nil, errors.New("team " + t.Name + " already exists")
+			}
+		}
+		return append(all, t), nil
+	})
+	if err != nil {
+		apierror.Write(c, http.StatusConflict, err.Error())
+		return
+	}
+	email, _ := c.Get("email")
+	auditf("team %s created by %v: role=%s namespaces=%v members=%v groups=%v", t.Name, email, t.Role(), t.Namespaces, t.Members, t.Groups)
+	c.JSON(http.StatusCreated, t)
+}
+
+// Update replaces a team's members, groups, level and namespaces.
+func (h *TeamHandler) Update(c *gin.Context) {
+	name := c.Param("name")
+	t, ok := bindTeam(c)
+	if !ok {
+		return
+	}
+	t.Name = name
+	if _, exists := h.find(name); !exists {
+		apierror.Write(c, http.StatusNotFound, "team "+name+" not found")
+		return
+	}
+	if err := h.put(c, t); err != nil {
+		apierror.Fail(c, "", err)
+		return
+	}
+	email, _ := c.Get("email")
+	auditf("team %s updated by %v: role=%s namespaces=%v members=%v groups=%v", t.Name, email, t.Role(), t.Namespaces, t.Members, t.Groups)
+	c.JSON(http.StatusOK, t)
+}
+
+// put creates or replaces a team, bindings first.
+func (h *TeamHandler) put(c *gin.Context, t rbac.Team) error {
+	var old *rbac.Team
+	if existing, exists := h.find(t.Name); exists {
+		old = &existing
+	}
+	if err := h.syncBindings(c, old, &t); err != nil {
+		return err
+	}
+	return h.update(func(all []rbac.Team) ([]rbac.Team, error) {
+		for i := range all {
+			if all[i].Name == t.Name {
+				all[i] = t
+				return all, nil
+			}
+		}
+		return append(all, t), nil
+	})
+}
+
+// Delete removes a team and its bindings.
+func (h *TeamHandler) Delete(c *gin.Context) {
+	name := c.Param("name")
+	old, exists := h.find(name)
+	if !exists {
+		apierror.Write(c, http.StatusNotFound, "team "+name+" not found")
+		return
+	}
+	if err := h.syncBindings(c, &old, nil); err != nil {
+		apierror.Fail(c, "", err)
+		return
+	}
+	err := h.update(func(all []rbac.Team) ([]rbac.Team, error) {
+		for i := range all {
+			if all[i].Name == name {
+				return append(all[:i], all[i+1:]...), nil
+			}
+		}
+		return nil, errTeamNotFound
+	})
+	if err != nil {
+		apierror.Write(c, http.StatusNotFound, err.Error())
+		return
+	}
+	email, _ := c.Get("email")
+	auditf("team %s deleted by %v", name, email)
+	c.JSON(http.StatusOK, gin.H{"message": "Team deleted"})
+}
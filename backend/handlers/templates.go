@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"k-view/apierror"
+	"k-view/k8s"
+	"k-view/policy"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// TemplateParam describes one form field of a resource template.
+type TemplateParam struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Type        string   `json:"type"` // string, int, name
+	Default     string   `json:"default,omitempty"`
+	Required    bool     `json:"required"`
+	Enum        []string `json:"enum,omitempty"`
+}
+
+// ResourceTemplate is a parameterized manifest skeleton.
+type ResourceTemplate struct {
+	Name        string          `json:"name"`
+	Kind        string          `json:"kind"`
+	Description string          `json:"description"`
+	Params      []TemplateParam `json:"params"`
+	objectKind  string
+	body        string
+}
+
+var (
+	dnsLabel     = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	dnsSubdomain = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+var resourceTemplates = []ResourceTemplate{
+	{
+		Name:        "deployment",
+		Kind:        "deployments",
+		objectKind:  "Deployment",
+		Description: "Stateless application with a configurable image, replica count and resource requests",
+		Params: []TemplateParam{
+			{Name: "name", Description: "Application name", Type: "string", Required: true},
+			{Name: "namespace", Description: "Target namespace", Type: "string", Required: true},
+			{Name: "image", Description: "Container image (prefer a pinned tag)", Type: "string", Required: true},
+			{Name: "replicas", Description: "Number of pods", Type: "int", Default: "2"},
+			{Name: "port", Description: "Container port", Type: "int", Default: "8080"},
+			{Name: "cpu", Description: "CPU request", Type: "string", Default: "100m"},
+			{Name: "memory", Description: "Memory request and limit", Type: "string", Default: "128Mi"},
+		},
+		body: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .name }}
+  namespace: {{ .namespace }}
+  labels:
+    app.kubernetes.io/name: {{ .name }}
+spec:
+  replicas: {{ .replicas }}
+  selector:
+    matchLabels:
+      app.kubernetes.io/name: {{ .name }}
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: {{ .name }}
+    spec:
+      containers:
+        - name: {{ .name }}
+          image: {{ quote .image }}
+          ports:
+            - containerPort: {{ .port }}
+          resources:
+            requests:
+              cpu: {{ quote .cpu }}
+              memory: {{ quote .memory }}
+            limits:
+              memory: {{ quote .memory }}
+`,
+	},
+	{
+		Name:        "service",
+		Kind:        "services",
+		objectKind:  "Service",
+		Description: "ClusterIP service exposing pods selected by app.kubernetes.io/name",
+		Params: []TemplateParam{
+			{Name: "name", Description: "Service name (also used as pod selector)", Type: "string", Required: true},
+			{Name: "namespace", Description: "Target namespace", Type: "string", Required: true},
+			{Name: "port", Description: "Service port", Type: "int", Default: "80"},
+			{Name: "targetPort", Description: "Container port", Type: "int", Default: "8080"},
+			{Name: "type", Description: "ClusterIP, NodePort or LoadBalancer", Type: "string", Default: "ClusterIP", Enum: []string{"ClusterIP", "NodePort", "LoadBalancer"}},
+		},
+		body: `apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .name }}
+  namespace: {{ .namespace }}
+spec:
+  type: {{ quote .type }}
+  selector:
+    app.kubernetes.io/name: {{ .name }}
+  ports:
+    - port: {{ .port }}
+      targetPort: {{ .targetPort }}
+      protocol: TCP
+`,
+	},
+	{
+		Name:        "ingress",
+		Kind:        "ingresses",
+		objectKind:  "Ingress",
+		Description: "HTTP(S) ingress routing a host to a service",
+		Params: []TemplateParam{
+			{Name: "name", Description: "Ingress name", Type: "string", Required: true},
+			{Name: "namespace", Description: "Target namespace", Type: "string", Required: true},
+			{Name: "host", Description: "Public hostname", Type: "string", Required: true},
+			{Name: "service", Description: "Backend service name", Type: "name", Required: true},
+			{Name: "servicePort", Description: "Backend service port", Type: "int", Default: "80"},
+			{Name: "ingressClass", Description: "Ingress class name", Type: "name", Default: "nginx"},
+			{Name: "tlsSecret", Description: "TLS secret name (empty for plain HTTP)", Type: "name"},
+		},
+		body: `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: {{ .name }}
+  namespace: {{ .namespace }}
+spec:
+  ingressClassName: {{ quote .ingressClass }}
+  {{- if .tlsSecret }}
+  tls:
+    - hosts:
+        - {{ quote .host }}
+      secretName: {{ quote .tlsSecret }}
+  {{- end }}
+  rules:
+    - host: {{ quote .host }}
+      http:
+        paths:
+          - path: /
+            pathType: Prefix
+            backend:
+              service:
+                name: {{ quote .service }}
+                port:
+                  number: {{ .servicePort }}
+`,
+	},
+	{
+		Name:        "cronjob",
+		Kind:        "cronjobs",
+		objectKind:  "CronJob",
+		Description: "Scheduled job running a command in a container",
+		Params: []TemplateParam{
+			{Name: "name", Description: "CronJob name", Type: "string", Required: true},
+			{Name: "namespace", Description: "Target namespace", Type: "string", Required: true},
+			{Name: "schedule", Description: "Cron schedule, e.g. \"0 2 * * *\"", Type: "string", Required: true},
+			{Name: "image", Description: "Container image", Type: "string", Required: true},
+			{Name: "command", Description: "Shell command to run", Type: "string", Required: true},
+			{Name: "concurrencyPolicy", Description: "Allow, Forbid or Replace", Type: "string", Default: "Forbid", Enum: []string{"Allow", "Forbid", "Replace"}},
+		},
+		body: `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: {{ .name }}
+  namespace: {{ .namespace }}
+spec:
+  schedule: {{ quote .schedule }}
+  concurrencyPolicy: {{ quote .concurrencyPolicy }}
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: OnFailure
+          containers:
+            - name: {{ .name }}
+              image: {{ quote .image }}
+              command: ["/bin/sh", "-c", {{ quote .command }}]
+`,
+	},
+	{
+		Name:        "pvc",
+		Kind:        "pvcs",
+		objectKind:  "PersistentVolumeClaim",
+		Description: "Persistent volume claim",
+		Params: []TemplateParam{
+			{Name: "name", Description: "Claim name", Type: "string", Required: true},
+			{Name: "namespace", Description: "Target namespace", Type: "string", Required: true},
+			{Name: "size", Description: "Requested storage, e.g. 10Gi", Type: "string", Default: "1Gi"},
+			{Name: "accessMode", Description: "ReadWriteOnce, ReadOnlyMany or ReadWriteMany", Type: "string", Default: "ReadWriteOnce", Enum: []string{"ReadWriteOnce", "ReadOnlyMany", "ReadWriteMany", "ReadWriteOncePod"}},
+			{Name: "storageClass", Description: "Storage class (empty for the cluster default)", Type: "name"},
+		},
+		body: `apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: {{ .name }}
+  namespace: {{ .namespace }}
+spec:
+  accessModes:
+    - {{ quote .accessMode }}
+  {{- if .storageClass }}
+  storageClassName: {{ quote .storageClass }}
+  {{- end }}
+  resources:
+    requests:
+      storage: {{ quote .size }}
+`,
+	},
+}
+
+var templateFuncs = template.FuncMap{"quote": strconv.Quote}
+
+// TemplateHandler serves the catalog of resource templates.
+type TemplateHandler struct {
+	devMode   bool
+	k8sClient k8s.KubernetesProvider
+	policies  *policy.PolicyConfig
+}
+
+func NewTemplateHandler(devMode bool, client k8s.KubernetesProvider, policies *policy.PolicyConfig) *TemplateHandler {
+	return &TemplateHandler{devMode: devMode, k8sClient: client, policies: policies}
+}
+
+func findTemplate(name string) *ResourceTemplate {
+	for i := range resourceTemplates {
+		if resourceTemplates[i].Name == name {
+			return &resourceTemplates[i]
+		}
+	}
+	return nil
+}
+
+// render validates the supplied values against the template parameters and renders the
+// manifest. The validated values are returned alongside so callers can check the parsed object.
+func (t *ResourceTemplate) render(values map[string]string) (string, map[string]string, error) {
+	data := make(map[string]string, len(t.Params))
+	var missing []string
+	for _, p := range t.Params {
+		v := strings.TrimSpace(values[p.Name])
+		if v == "" {
+			v = p.Default
+		}
+		if v == "" && p.Required {
+			missing = append(missing, p.Name)
+			continue
+		}
+		if v != "" && p.Type == "int" {
+			if _, err := strconv.Atoi(v); err != nil {
+				return "", nil, fmt.Errorf("parameter %q must be an integer", p.Name)
+			}
+		}
+		if (p.Name == "name" || p.Name == "namespace") && v != "" && !dnsLabel.MatchString(v) {
+			return "", nil, fmt.Errorf("parameter %q must be a valid DNS label", p.Name)
+		}
+		if p.Type == "name" && v != "" && (len(v) > 253 || !dnsSubdomain.MatchString(v)) {
+			return "", nil, fmt.Errorf("parameter %q must be a valid resource name", p.Name)
+		}
+		if len(p.Enum) > 0 && v != "" && !contains(p.Enum, v) {
+			return "", nil, fmt.Errorf("parameter %q must be one of %s", p.Name, strings.Join(p.Enum, ", "))
+		}
+		data[p.Name] = v
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return "", nil, fmt.Errorf("missing required parameters: %s", strings.Join(missing, ", "))
+	}
+
+	tmpl, err := template.New(t.Name).Funcs(templateFuncs).Option("missingkey=zero").Parse(t.body)
+	if err != nil {
+		return "", nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", nil, err
+	}
+	return buf.String(), data, nil
+}
+
+// List returns the available templates and their parameters.
+func (h *TemplateHandler) List(c *gin.Context) {
+	c.JSON(http.StatusOK, resourceTemplates)
+}
+
+// Render fills a template with the posted values. With "apply": true the result is also
+// created in the cluster, which requires the edit or admin role.
+func (h *TemplateHandler) Render(c *gin.Context) {
+	tmpl := findTemplate(c.Param("name"))
+	if tmpl == nil {
+		apierror.Write(c, http.StatusNotFound, "template not found")
+		return
+	}
+
+	var req struct {
+		Values map[string]string `json:"values"`
+		Apply  bool              `json:"apply"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	// Namespace-restricted users can only render into their own namespace
+	if rbacNs, exists := c.Get("namespace"); exists && rbacNs.(string) != "" {
+		if req.Values == nil {
+			req.Values = map[string]string{}
+		}
+		if req.Values["namespace"] != "" && req.Values["namespace"] != rbacNs.(string) {
+			apierror.Write(c, http.StatusForbidden, "access denied to namespace "+req.Values["namespace"])
+			return
+		}
+		req.Values["namespace"] = rbacNs.(string)
+	}
+
+	manifest, values, err := tmpl.render(req.Values)
+	if err != nil {
+		apierror.Write(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var obj unstructured.Unstructured
+	if err := yaml.Unmarshal([]byte(manifest), &obj.Object); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "Rendered manifest is not valid YAML: "+err.Error())
+		return
+	}
+	// The parsed object must be exactly the one the validated values describe
+	if obj.GetKind() != tmpl.objectKind || obj.GetName() != values["name"] || obj.GetNamespace() != values["namespace"] {
+		apierror.Write(c, http.StatusBadRequest, "Rendered manifest does not match the template parameters")
+		return
+	}
+
+	if !req.Apply {
+		c.JSON(http.StatusOK, gin.H{"yaml": manifest, "object": obj.Object})
+		return
+	}
+
+	// Verify Edit Permissions
+	role, _ := c.Get("role")
+	if role.(string) != "kview-cluster-admin" && role.(string) != "admin" && role.(string) != "edit" {
+		apierror.Write(c, http.StatusForbidden, "Admin/Edit permissions required")
+		return
+	}
+	if rejectProtected(c, h.policies, tmpl.Kind, values["namespace"], values["name"]) {
+		return
+	}
+
+	if h.devMode {
+		c.JSON(http.StatusCreated, gin.H{"message": "Resource created (mocked)", "yaml": manifest})
+		return
+	}
+
+	dynClient, err := h.k8sClient.GetDynamicClient(c.Request.Context())
+	if err != nil {
+		apierror.Fail(c, "Failed to get dynamic client", err)
+		return
+	}
+
+	_, err = dynClient.Resource(getGVR(tmpl.Kind)).Namespace(values["namespace"]).Create(c.Request.Context(), &obj, metav1.CreateOptions{})
+	if err != nil {
+		apierror.Fail(c, "Failed to create resource", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Resource created", "yaml": manifest})
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"k-view/k8s"
+)
+
+// ThrottlingMiddleware tracks the Kubernetes API calls of every request. When a request fails
+// after the API server or K-View's client-side rate limit throttled one of its calls, it
+// answers 429 Too Many Requests instead of the handler's server error, with an
+// X-Kview-Throttled header naming which one and the API server's Retry-After; when a call ran
+// into its timeout, it answers 504 Gateway Timeout. Clients can then tell a busy cluster from
+// a broken one and back off. WebSocket upgrades are passed through untouched.
+func ThrottlingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Upgrade") != "" {
+			c.Next()
+			return
+		}
+		ctx, throttling := k8s.TrackThrottling(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+		c.Writer = &throttlingWriter{ResponseWriter: c.Writer, throttling: throttling}
+		c.Next()
+	}
+}
+
+// throttlingWriter rewrites the status of server errors caused by throttled or timed out
+// Kubernetes API calls.
+type throttlingWriter struct {
+	gin.ResponseWriter
+	throttling *k8s.Throttling
+}
+
+func (w *throttlingWriter) WriteHeader(code int) {
+	if code >= http.StatusInternalServerError && !w.Written() {
+		if by, retryAfter := w.throttling.Throttled(); by != "" {
+			w.Header().Set("X-Kview-Throttled", by)
+			if retryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			}
+			code = http.StatusTooManyRequests
+		} else if w.throttling.TimedOut() {
+			code = http.StatusGatewayTimeout
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k-view/apierror"
+	"k-view/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const apiTokensDoc = "api-tokens"
+
+// tokenPrefix marks personal access tokens: kvpat_<id>_<secret>.
+const tokenPrefix = "kvpat_"
+
+const (
+	defaultTokenLifetime = 30 * 24 * time.Hour
+	maxTokenLifetime     = 365 * 24 * time.Hour
+	// tokenUsageInterval limits how often last-use times are written to the store.
+	tokenUsageInterval = 5 * time.Minute
+)
+
+// Token scopes. Each includes the ones before it; what a token can do is further limited by
+// its owner's current role.
+var tokenScopes = []string{"read", "write", "admin"}
+
+// APIToken is a personal access token. Only a hash of the secret is kept.
+type APIToken struct {
+	ID         string     `json:"id"`
+	User       string     `json:"user"`
+	Name       string     `json:"name"`
+	Scope      string     `json:"scope"`
+	Groups     []string   `json:"groups,omitempty"` // Directory groups of the owner when it was minted
+	Hash       string     `json:"hash,omitempty"`   // Never returned by the API
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// TokenHandler mints and verifies personal access tokens, which let scripts call the API
+// with "Authorization: Bearer kvpat_..." instead of a browser session.
+type TokenHandler struct {
+	mu     sync.RWMutex
+	tokens []APIToken // Cached copy of the store document; AuthMiddleware reads it on every request
+	saved  map[string]time.Time
+	store  *store.Store
+}
+
+func NewTokenHandler(st *store.Store) *TokenHandler {
+	h := &TokenHandler{store: st, saved: map[string]time.Time{}}
+	if err := st.Load(apiTokensDoc, &h.tokens); err != nil {
+		log.Printf("Failed to load API tokens: %v", err)
+	}
+	return h
+}
+
+func (h *TokenHandler) Document() string { return apiTokensDoc }
+
+// Reload refreshes the cached tokens from the store.
+func (h *TokenHandler) Reload() error {
+	var tokens []APIToken
+	if err := h.store.Load(apiTokensDoc, &tokens); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	h.tokens = tokens
+	h.mu.Unlock()
+	return nil
+}
+
+func hashTokenSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// update applies fn to the stored tokens and refreshes the cache.
+func (h *TokenHandler) update(fn func([]APIToken) ([]APIToken, error)) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var stored []APIToken
+	err := h.store.Update(apiTokensDoc, &stored, func() error {
+		var err error
+		stored, err = fn(stored)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	h.tokens = stored
+	return nil
+}
+
+// Authenticate returns a valid, unexpired token.
+func (h *TokenHandler) Authenticate(raw string) (APIToken, bool) {
+	if h == nil || !strings.HasPrefix(raw, tokenPrefix) {
+		return APIToken{}, false
+	}
+	id, secret, ok := strings.Cut(strings.TrimPrefix(raw, tokenPrefix), "_")
+	if !ok {
+		return APIToken{}, false
+	}
+	hash := hashTokenSecret(secret)
+	now := time.Now()
+
+	h.mu.RLock()
+	var token *APIToken
+	for i := range h.tokens {
+		if h.tokens[i].ID == id {
+			t := h.tokens[i]
+			token = &t
+			break
+		}
+	}
+	lastSaved := h.saved[id]
+	h.mu.RUnlock()
+	if token == nil || subtle.ConstantTimeCompare([]byte(token.Hash), []byte(hash)) != 1 || !now.Before(token.ExpiresAt) {
+		return APIToken{}, false
+	}
+
+	if now.Sub(lastSaved) > tokenUsageInterval {
+		h.mu.Lock()
+		h.saved[id] = now
+		h.mu.Unlock()
+		go h.touch(id, now.UTC())
+	}
+	return *token, true
+}
+
+// touch records when a token was last used.
+func (h *TokenHandler) touch(id string, at time.Time) {
+	err := h.update(func(all []APIToken) ([]APIToken, error) {
+		for i := range all {
+			if all[i].ID == id {
+				all[i].LastUsedAt = &at
+			}
+		}
+		return all, nil
+	})
+	if err != nil {
+		log.Printf("Failed to record API token use: %v", err)
+	}
+}
+
+// scopeAllows reports whether a token scope includes the required one.
+func scopeAllows(scope, required string) bool {
+	for _, s := range tokenScopes {
+		if s == required {
+			return true
+		}
+		if s == scope {
+			return false
+		}
+	}
+	return false
+}
+
+// checkTokenScope rejects requests a token's scope does not cover. Tokens never manage sign-in
+// settings or other tokens, so a leaked token cannot entrench itself.
+func checkTokenScope(c *gin.Context, scope string) bool {
+	path := c.FullPath()
+	readOnly := c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead
+	switch {
+	case (strings.HasPrefix(path, "/api/auth/") || strings.HasPrefix(path, "/api/tokens")) && !readOnly:
+		apierror.Abort(c, http.StatusForbidden, "API tokens cannot manage sign-in settings or tokens")
+		return false
+	case !scopeAllows(scope, "write") && isMutation(c):
+		apierror.Abort(c, http.StatusForbidden, "API token scope "+scope+" does not allow changes")
+		return false
+	}
+	return true
+}
+
+// List returns the caller's tokens; admins see everyone's with ?all=true.
+func (h *TokenHandler) List(c *gin.Context) {
+	email, _ := c.Get("email")
+	role, _ := c.Get("role")
+	all := c.Query("all") == "true" && isAdminRole(role.(string))
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	result := []APIToken{}
+	for _, t := range h.tokens {
+		if !all && t.User != email {
+			continue
+		}
+		t.Hash = ""
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.After(result[j].CreatedAt) })
+	c.JSON(http.StatusOK, result)
+}
+
+// Create mints a token for the caller. The token is returned once and cannot be shown again.
+func (h *TokenHandler) Create(c *gin.Context) {
+	var req struct {
+		Name      string `json:"name" binding:"required"`
+		Scope     string `json:"scope"`
+		ExpiresIn string `json:"expiresIn"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Write(c, http.StatusBadRequest, "name is required")
+		return
+	}
+	if req.Scope == "" {
+		req.Scope = "read"
+	}
+	if !contains(tokenScopes, req.Scope) {
+		apierror.Write(c, http.StatusBadRequest, "scope must be one of "+strings.Join(tokenScopes, ", "))
+		return
+	}
+	role, _ := c.Get("role")
+	if req.Scope == "admin" && !isAdminRole(role.(string)) {
+		apierror.Write(c, http.StatusForbidden, "only admins can create admin tokens")
+		return
+	}
+	lifetime := defaultTokenLifetime
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || d <= 0 || d > maxTokenLifetime {
+			apierror.Write(c, http.StatusBadRequest, "expiresIn must be a duration up to "+maxTokenLifetime.String())
+			return
+		}
+		lifetime = d
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		apierror.Write(c, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+	secret := base64.RawURLEncoding.EncodeToString(b)
+	email, _ := c.Get("email")
+	now := time.Now().UTC()
+	token := APIToken{ID: newID(), User: email.(string), Name: req.Name, Scope: req.Scope, Groups: c.GetStringSlice("groups"), Hash: hashTokenSecret(secret), CreatedAt: now, ExpiresAt: now.Add(lifetime)}
+	err := h.update(func(all []APIToken) ([]APIToken, error) {
+		// Expired tokens are dropped as new ones are minted
+		kept := all[:0]
+		for _, t := range all {
+			if now.Before(t.ExpiresAt) {
+				kept = append(kept, t)
+			}
+		}
+		return append(kept, token), nil
+	})
+	if err != nil {
+		apierror.Fail(c, "Failed to save token", err)
+		return
+	}
+	auditf("API token %q (%s, scope %s, expires %s) created by %s", token.Name, token.ID, token.Scope, token.ExpiresAt.Format(time.RFC3339), token.User)
+	token.Hash = ""
+	c.JSON(http.StatusCreated, gin.H{"token": tokenPrefix + token.ID + "_" + secret, "info": token})
+}
+
+// Revoke deletes one of the caller's tokens; admins can revoke anyone's.
+func (h *TokenHandler) Revoke(c *gin.Context) {
+	id := c.Param("id")
+	email, _ := c.Get("email")
+	role, _ := c.Get("role")
+	var revoked APIToken
+	err := h.update(func(all []APIToken) ([]APIToken, error) {
+		for i, t := range all {
+			if t.ID != id {
+				continue
+			}
+			if t.User != email && !isAdminRole(role.(string)) {
+				break
+			}
+			revoked = t
+			return append(all[:i], all[i+1:]...), nil
+		}
+		return nil, errors.New("token not found")
+	})
+	if err != nil {
+		apierror.Write(c, http.StatusNotFound, err.Error())
+		return
+	}
+	auditf("API token %q (%s) of %s revoked by %v", revoked.Name, revoked.ID, revoked.User, email)
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked"})
+}
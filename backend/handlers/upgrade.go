@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"k-view/apierror"
+)
+
+// ReadinessFinding is a single issue found by the upgrade readiness checker.
+type ReadinessFinding struct {
+	Check    string `json:"check"` // version-path, kubelet-skew, deprecated-api, pdb, single-replica
+	Resource string `json:"resource,omitempty"`
+	Message  string `json:"message"`
+}
+
+type UpgradeReadinessReport struct {
+	CurrentVersion string             `json:"currentVersion"`
+	TargetVersion  string             `json:"targetVersion"`
+	Ready          bool               `json:"ready"`
+	Blockers       []ReadinessFinding `json:"blockers"`
+	Warnings       []ReadinessFinding `json:"warnings"`
+	DeprecatedAPIs []DeprecatedUsage  `json:"deprecatedApis"`
+}
+
+// GetUpgradeReadiness checks whether the cluster can be upgraded to the ?target= version:
+// version path, kubelet skew, removed APIs in use, drain-blocking PDBs and single-replica workloads.
+func (h *DiagnosticsHandler) GetUpgradeReadiness(c *gin.Context) {
+	target := strings.TrimPrefix(c.Query("target"), "v")
+	targetMajor, targetMinor, ok := parseMinor(target)
+	if !ok {
+		apierror.Write(c, http.StatusBadRequest, "target must be a Kubernetes version such as 1.30")
+		return
+	}
+	ctx := c.Request.Context()
+
+	nodes, err := h.k8sClient.ListNodes(ctx)
+	if err != nil {
+		apierror.Fail(c, "Failed to list nodes", err)
+		return
+	}
+	var nodeVersions []NodeVersion
+	for _, n := range nodes {
+		nodeVersions = append(nodeVersions, NodeVersion{Name: n.Name, Role: nodeRole(n), KubeletVersion: n.Status.NodeInfo.KubeletVersion})
+	}
+
+	report := UpgradeReadinessReport{
+		CurrentVersion: controlPlaneVersion(ctx, h.k8sClient, nodeVersions),
+		TargetVersion:  target,
+		Blockers:       []ReadinessFinding{},
+		Warnings:       []ReadinessFinding{},
+		DeprecatedAPIs: []DeprecatedUsage{},
+	}
+	currentMajor, currentMinor, ok := parseMinor(report.CurrentVersion)
+	if !ok {
+		apierror.Write(c, http.StatusInternalServerError, "Unable to determine the current cluster version")
+		return
+	}
+	if targetMajor != currentMajor || targetMinor <= currentMinor {
+		apierror.Write(c, http.StatusBadRequest, fmt.Sprintf("target %s must be newer than the current version %s", target, report.CurrentVersion))
+		return
+	}
+
+	// The control plane can only move one minor version per upgrade
+	if targetMinor-currentMinor > 1 {
+		report.Blockers = append(report.Blockers, ReadinessFinding{
+			Check:   "version-path",
+			Message: fmt.Sprintf("upgrade one minor version at a time: %d.%d -> %d.%d first", currentMajor, currentMinor, currentMajor, currentMinor+1),
+		})
+	}
+
+	// Kubelets that would fall outside the supported skew once the control plane is upgraded
+	for _, n := range nodeVersions {
+		if _, minor, ok := parseMinor(n.KubeletVersion); ok && targetMinor-minor > maxKubeletSkew(targetMinor) {
+			report.Blockers = append(report.Blockers, ReadinessFinding{
+				Check:    "kubelet-skew",
+				Resource: "Node/" + n.Name,
+				Message:  fmt.Sprintf("kubelet %s would be more than %d minor versions behind %s; upgrade the node first", n.KubeletVersion, maxKubeletSkew(targetMinor), target),
+			})
+		}
+	}
+
+	if h.devMode {
+		if pendingRemoval("1.32", report.CurrentVersion, target) {
+			report.DeprecatedAPIs = append(report.DeprecatedAPIs, DeprecatedUsage{Kind: "FlowSchema", Name: "custom-tenant-limits", APIVersion: "flowcontrol.apiserver.k8s.io/v1beta3", RemovedIn: "1.32", Replacement: "flowcontrol.apiserver.k8s.io/v1", Source: "managedFields", Detail: "last written by helm"})
+		}
+		report.Warnings = append(report.Warnings,
+			ReadinessFinding{Check: "pdb", Resource: "PodDisruptionBudget/database/postgres", Message: "allows 0 disruptions; node drains will block until it is relaxed"},
+			ReadinessFinding{Check: "single-replica", Resource: "Deployment/default/cache-redis", Message: "runs a single replica and will be unavailable while its node is drained"},
+		)
+	} else {
+		dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+		if err != nil {
+			apierror.Fail(c, "Failed to get dynamic client", err)
+			return
+		}
+
+		current := fmt.Sprintf("%d.%d", currentMajor, currentMinor)
+		report.DeprecatedAPIs = append(report.DeprecatedAPIs, scanDeprecatedUsage(ctx, dynClient, "", current, target)...)
+		report.DeprecatedAPIs = append(report.DeprecatedAPIs, requestedDeprecatedAPIs(ctx, h.k8sClient, current, target)...)
+
+		if pdbs, err := dynClient.Resource(getGVR("pdbs")).List(ctx, metav1.ListOptions{}); err == nil {
+			for _, pdb := range pdbs.Items {
+				allowed, _, _ := unstructured.NestedInt64(pdb.Object, "status", "disruptionsAllowed")
+				expected, _, _ := unstructured.NestedInt64(pdb.Object, "status", "expectedPods")
+				if allowed == 0 && expected > 0 {
+					report.Warnings = append(report.Warnings, ReadinessFinding{
+						Check:    "pdb",
+						Resource: "PodDisruptionBudget/" + pdb.GetNamespace() + "/" + pdb.GetName(),
+						Message:  "allows 0 disruptions; node drains will block until it is relaxed",
+					})
+				}
+			}
+		}
+
+		for _, kind := range []string{"deployments", "statefulsets"} {
+			list, err := dynClient.Resource(getGVR(kind)).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				continue
+			}
+			for _, item := range list.Items {
+				replicas, found, _ := unstructured.NestedInt64(item.Object, "spec", "replicas")
+				if !found {
+					replicas = 1
+				}
+				if replicas == 1 {
+					report.Warnings = append(report.Warnings, ReadinessFinding{
+						Check:    "single-replica",
+						Resource: item.GetKind() + "/" + item.GetNamespace() + "/" + item.GetName(),
+						Message:  "runs a single replica and will be unavailable while its node is drained",
+					})
+				}
+			}
+		}
+	}
+
+	for _, d := range report.DeprecatedAPIs {
+		resource := d.Kind
+		if d.Name != "" {
+			resource += "/" + strings.TrimPrefix(d.Namespace+"/"+d.Name, "/")
+		}
+		report.Blockers = append(report.Blockers, ReadinessFinding{
+			Check:    "deprecated-api",
+			Resource: resource,
+			Message:  fmt.Sprintf("%s is removed in %s; migrate to %s", d.APIVersion, d.RemovedIn, d.Replacement),
+		})
+	}
+
+	report.Ready = len(report.Blockers) == 0
+	c.JSON(http.StatusOK, report)
+}
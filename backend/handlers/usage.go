@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"k-view/apierror"
+)
+
+// ContainerUsage is the CPU and memory use of a container, with its limits when set.
+type ContainerUsage struct {
+	Name               string `json:"name"`
+	CPUMillicores      int64  `json:"cpuMillicores"`
+	MemoryBytes        int64  `json:"memoryBytes"`
+	CPULimitMillicores int64  `json:"cpuLimitMillicores,omitempty"`
+	MemoryLimitBytes   int64  `json:"memoryLimitBytes,omitempty"`
+}
+
+// UsageSample is one reading of a pod's usage from the metrics API. metrics-server scrapes
+// every 15s or so by default; the timestamp repeats until the next scrape.
+type UsageSample struct {
+	Timestamp  string           `json:"timestamp"`
+	Containers []ContainerUsage `json:"containers"`
+}
+
+// StreamUsage streams the per-container CPU and memory usage of a pod as server-sent events,
+// polling the metrics API every ?interval= seconds (default 5, 1 to 60). Each sample is a
+// "usage" event; "unavailable" is sent while the metrics API has nothing for the pod, e.g.
+// without metrics-server or right after the pod started. The stream ends when the client
+// disconnects.
+func (h *PodHandler) StreamUsage(c *gin.Context) {
+	namespace, name := c.Param("namespace"), c.Param("name")
+	if rbacNs := c.GetString("namespace"); rbacNs != "" && namespace != rbacNs {
+		apierror.Write(c, http.StatusForbidden, "access denied to namespace "+namespace)
+		return
+	}
+	interval, err := strconv.Atoi(c.DefaultQuery("interval", "5"))
+	if err != nil || interval < 1 || interval > 60 {
+		apierror.Write(c, http.StatusBadRequest, "interval must be between 1 and 60 seconds")
+		return
+	}
+
+	ctx := c.Request.Context()
+	pods, err := h.k8sClient.ListPods(ctx, namespace)
+	if err != nil {
+		apierror.Fail(c, "Failed to get pod", err)
+		return
+	}
+	var pod *corev1.Pod
+	for i := range pods {
+		if pods[i].Name == name {
+			pod = &pods[i]
+			break
+		}
+	}
+	if pod == nil {
+		apierror.Write(c, http.StatusNotFound, "pod "+name+" not found in namespace "+namespace)
+		return
+	}
+	limits := map[string]corev1.ResourceList{}
+	for _, ctr := range pod.Spec.Containers {
+		limits[ctr.Name] = ctr.Resources.Limits
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // Keep nginx ingresses from buffering the stream
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+	c.Stream(func(_ io.Writer) bool {
+		if sample, ok := h.usageSample(ctx, namespace, name, limits); ok {
+			c.SSEvent("usage", sample)
+		} else {
+			c.SSEvent("unavailable", gin.H{"error": "no metrics for pod " + name + " yet"})
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			return true
+		}
+	})
+}
+
+// usageSample reads the current usage of a pod; ok is false when the metrics API has none.
+func (h *PodHandler) usageSample(ctx context.Context, namespace, name string, limits map[string]corev1.ResourceList) (UsageSample, bool) {
+	metrics, err := h.k8sClient.GetPodMetrics(ctx, namespace, name)
+	if err != nil || metrics == nil {
+		return UsageSample{}, false
+	}
+	sample := UsageSample{Containers: []ContainerUsage{}}
+	sample.Timestamp, _, _ = unstructured.NestedString(metrics, "timestamp")
+	if sample.Timestamp == "" {
+		sample.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	}
+	containers, _, _ := unstructured.NestedSlice(metrics, "containers")
+	for _, raw := range containers {
+		ctr, _ := raw.(map[string]interface{})
+		usage := ContainerUsage{}
+		usage.Name, _ = ctr["name"].(string)
+		if cpu, _, _ := unstructured.NestedString(ctr, "usage", "cpu"); cpu != "" {
+			if q, err := resource.ParseQuantity(cpu); err == nil {
+				usage.CPUMillicores = q.MilliValue()
+			}
+		}
+		if memory, _, _ := unstructured.NestedString(ctr, "usage", "memory"); memory != "" {
+			if q, err := resource.ParseQuantity(memory); err == nil {
+				usage.MemoryBytes = q.Value()
+			}
+		}
+		if limit, ok := limits[usage.Name]; ok {
+			usage.CPULimitMillicores = limit.Cpu().MilliValue()
+			usage.MemoryLimitBytes = limit.Memory().Value()
+		}
+		sample.Containers = append(sample.Containers, usage)
+	}
+	return sample, true
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k-view/apierror"
+	"k-view/k8s"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NodeVersion compares one node's kubelet with the control plane.
+type NodeVersion struct {
+	Name           string `json:"name"`
+	Role           string `json:"role"`
+	KubeletVersion string `json:"kubeletVersion"`
+	MinorsBehind   int    `json:"minorsBehind"` // Negative if the kubelet is newer than the API server
+	Status         string `json:"status"`       // Current, Behind, Unsupported, Unknown
+	Message        string `json:"message,omitempty"`
+}
+
+// OSGroup is a set of nodes sharing the same OS image, kernel and runtime.
+type OSGroup struct {
+	OSImage          string   `json:"osImage"`
+	KernelVersion    string   `json:"kernelVersion"`
+	ContainerRuntime string   `json:"containerRuntime"`
+	Nodes            []string `json:"nodes"`
+}
+
+type VersionSkewReport struct {
+	ControlPlaneVersion string        `json:"controlPlaneVersion"`
+	MaxSkew             int           `json:"maxSkew"`
+	Nodes               []NodeVersion `json:"nodes"`
+	OSGroups            []OSGroup     `json:"osGroups"`
+}
+
+// parseMinor extracts major and minor from versions like "v1.29.3" or "v1.28.7-eks-1234".
+func parseMinor(version string) (int, int, bool) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err1 := strconv.Atoi(parts[0])
+	minor, err2 := strconv.Atoi(strings.TrimRightFunc(parts[1], func(r rune) bool { return r < '0' || r > '9' }))
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
+
+// maxKubeletSkew is the number of minor versions a kubelet may lag behind the API server.
+// It was widened from 2 to 3 in Kubernetes 1.28.
+func maxKubeletSkew(apiMinor int) int {
+	if apiMinor >= 28 {
+		return 3
+	}
+	return 2
+}
+
+// controlPlaneVersion asks the API server for its version, falling back to the newest
+// control-plane kubelet if the version endpoint is not reachable.
+func controlPlaneVersion(ctx context.Context, client k8s.KubernetesProvider, fallback []NodeVersion) string {
+	if info, ok := client.(k8s.ClusterInfoProvider); ok {
+		if v, err := info.ServerVersion(ctx); err == nil {
+			return v
+		}
+	}
+	best, bestMinor := "", -1
+	for _, n := range fallback {
+		if _, minor, ok := parseMinor(n.KubeletVersion); ok && n.Role == "control-plane" && minor > bestMinor {
+			best, bestMinor = n.KubeletVersion, minor
+		}
+	}
+	return best
+}
+
+// GetVersionSkew reports kubelet/control-plane version skew and groups nodes by OS image and kernel.
+func (h *NodeHandler) GetVersionSkew(c *gin.Context) {
+	nodes, err := h.k8sClient.ListNodes(c.Request.Context())
+	if err != nil {
+		apierror.Fail(c, "Failed to list nodes", err)
+		return
+	}
+
+	report := VersionSkewReport{Nodes: []NodeVersion{}, OSGroups: []OSGroup{}}
+	groups := make(map[string]*OSGroup)
+	for _, n := range nodes {
+		info := n.Status.NodeInfo
+		report.Nodes = append(report.Nodes, NodeVersion{Name: n.Name, Role: nodeRole(n), KubeletVersion: info.KubeletVersion})
+
+		key := info.OSImage + "|" + info.KernelVersion + "|" + info.ContainerRuntimeVersion
+		g, ok := groups[key]
+		if !ok {
+			g = &OSGroup{OSImage: info.OSImage, KernelVersion: info.KernelVersion, ContainerRuntime: info.ContainerRuntimeVersion}
+			groups[key] = g
+		}
+		g.Nodes = append(g.Nodes, n.Name)
+	}
+
+	report.ControlPlaneVersion = controlPlaneVersion(c.Request.Context(), h.k8sClient, report.Nodes)
+	apiMajor, apiMinor, apiOK := parseMinor(report.ControlPlaneVersion)
+	if apiOK {
+		report.MaxSkew = maxKubeletSkew(apiMinor)
+	}
+
+	for i := range report.Nodes {
+		n := &report.Nodes[i]
+		major, minor, ok := parseMinor(n.KubeletVersion)
+		if !apiOK || !ok || major != apiMajor {
+			n.Status = "Unknown"
+			continue
+		}
+		n.MinorsBehind = apiMinor - minor
+		switch {
+		case n.MinorsBehind < 0:
+			n.Status = "Unsupported"
+			n.Message = "kubelet is newer than the API server"
+		case n.MinorsBehind > report.MaxSkew:
+			n.Status = "Unsupported"
+			n.Message = fmt.Sprintf("kubelet is %d minor versions behind (max %d)", n.MinorsBehind, report.MaxSkew)
+		case n.MinorsBehind > 0:
+			n.Status = "Behind"
+			n.Message = fmt.Sprintf("kubelet is %d minor version(s) behind; upgrade before the next control-plane upgrade", n.MinorsBehind)
+		default:
+			n.Status = "Current"
+		}
+	}
+	sort.SliceStable(report.Nodes, func(i, j int) bool { return report.Nodes[i].MinorsBehind > report.Nodes[j].MinorsBehind })
+
+	for _, g := range groups {
+		sort.Strings(g.Nodes)
+		report.OSGroups = append(report.OSGroups, *g)
+	}
+	sort.Slice(report.OSGroups, func(i, j int) bool {
+		if len(report.OSGroups[i].Nodes) != len(report.OSGroups[j].Nodes) {
+			return len(report.OSGroups[i].Nodes) > len(report.OSGroups[j].Nodes)
+		}
+		return report.OSGroups[i].OSImage < report.OSGroups[j].OSImage
+	})
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+
+	"k-view/apierror"
+)
+
+// WebhookStatus is the availability analysis of a single admission webhook.
+type WebhookStatus struct {
+	Configuration string `json:"configuration"`
+	Type          string `json:"type"` // Validating, Mutating
+	Name          string `json:"name"`
+	FailurePolicy string `json:"failurePolicy"`
+	TimeoutSecs   int64  `json:"timeoutSeconds"`
+	Target        string `json:"target"` // namespace/service:port or URL
+	ClusterWide   bool   `json:"clusterWide"`
+	Available     bool   `json:"available"`
+	Risk          string `json:"risk"` // Critical, Warning, OK
+	Message       string `json:"message,omitempty"`
+}
+
+var webhookRiskOrder = map[string]int{"Critical": 0, "Warning": 1, "OK": 2}
+
+// serviceAvailability reports whether a webhook's backing Service exists and has ready endpoints.
+func serviceAvailability(ctx context.Context, dyn dynamic.Interface, namespace, name string) (bool, string) {
+	if _, err := dyn.Resource(getGVR("services")).Namespace(namespace).Get(ctx, name, metav1.GetOptions{}); err != nil {
+		return false, "service " + namespace + "/" + name + " not found"
+	}
+	ep, err := dyn.Resource(getGVR("endpoints")).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return false, "service " + namespace + "/" + name + " has no endpoints"
+	}
+	subsets, _, _ := unstructured.NestedSlice(ep.Object, "subsets")
+	for _, s := range subsets {
+		if sm, ok := s.(map[string]interface{}); ok {
+			if addrs, _ := sm["addresses"].([]interface{}); len(addrs) > 0 {
+				return true, ""
+			}
+		}
+	}
+	return false, "service " + namespace + "/" + name + " has no ready endpoints"
+}
+
+// analyzeWebhooks evaluates every webhook of a Validating/MutatingWebhookConfiguration.
+func analyzeWebhooks(ctx context.Context, dyn dynamic.Interface, config unstructured.Unstructured, webhookType string) []WebhookStatus {
+	var out []WebhookStatus
+	webhooks, _, _ := unstructured.NestedSlice(config.Object, "webhooks")
+	for _, raw := range webhooks {
+		w, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		status := WebhookStatus{Configuration: config.GetName(), Type: webhookType, FailurePolicy: "Fail", TimeoutSecs: 10, Available: true}
+		status.Name, _, _ = unstructured.NestedString(w, "name")
+		if fp, ok, _ := unstructured.NestedString(w, "failurePolicy"); ok {
+			status.FailurePolicy = fp
+		}
+		if t, ok, _ := unstructured.NestedInt64(w, "timeoutSeconds"); ok {
+			status.TimeoutSecs = t
+		}
+		nsSelector, _, _ := unstructured.NestedMap(w, "namespaceSelector")
+		objSelector, _, _ := unstructured.NestedMap(w, "objectSelector")
+		status.ClusterWide = len(nsSelector) == 0 && len(objSelector) == 0
+
+		if url, ok, _ := unstructured.NestedString(w, "clientConfig", "url"); ok {
+			// External URLs cannot be probed from here
+			status.Target = url
+		} else {
+			svcNs, _, _ := unstructured.NestedString(w, "clientConfig", "service", "namespace")
+			svcName, _, _ := unstructured.NestedString(w, "clientConfig", "service", "name")
+			port, found, _ := unstructured.NestedInt64(w, "clientConfig", "service", "port")
+			if !found {
+				port = 443
+			}
+			status.Target = fmt.Sprintf("%s/%s:%d", svcNs, svcName, port)
+			status.Available, status.Message = serviceAvailability(ctx, dyn, svcNs, svcName)
+		}
+		classifyWebhook(&status)
+		out = append(out, status)
+	}
+	return out
+}
+
+// classifyWebhook assigns a risk level: a failing webhook with failurePolicy Fail rejects
+// every matching API request, which is a common cause of cluster-wide apply failures.
+func classifyWebhook(w *WebhookStatus) {
+	switch {
+	case !w.Available && w.FailurePolicy == "Fail":
+		w.Risk = "Critical"
+		scope := "matching"
+		if w.ClusterWide {
+			scope = "all matching requests in every namespace"
+		}
+		w.Message += "; " + scope + " API requests will be rejected"
+	case !w.Available:
+		w.Risk = "Warning"
+		w.Message += "; requests are admitted without this webhook (failurePolicy Ignore)"
+	case w.FailurePolicy == "Fail" && w.ClusterWide && w.TimeoutSecs > 10:
+		w.Risk = "Warning"
+		w.Message = fmt.Sprintf("cluster-wide webhook with failurePolicy Fail and a %ds timeout can stall API requests", w.TimeoutSecs)
+	default:
+		w.Risk = "OK"
+	}
+}
+
+// GetWebhookHealth lists admission webhooks with their failure policy and backing service
+// availability, highlighting fail-closed webhooks whose service is down.
+func (h *DiagnosticsHandler) GetWebhookHealth(c *gin.Context) {
+	var statuses []WebhookStatus
+	if h.devMode {
+		statuses = []WebhookStatus{
+			{Configuration: "kyverno-resource-validating-webhook-cfg", Type: "Validating", Name: "validate.kyverno.svc-fail", FailurePolicy: "Fail", TimeoutSecs: 10, Target: "kyverno/kyverno-svc:443", ClusterWide: true, Available: false, Message: "service kyverno/kyverno-svc has no ready endpoints"},
+			{Configuration: "kyverno-resource-validating-webhook-cfg", Type: "Validating", Name: "validate.kyverno.svc-ignore", FailurePolicy: "Ignore", TimeoutSecs: 10, Target: "kyverno/kyverno-svc:443", ClusterWide: true, Available: false, Message: "service kyverno/kyverno-svc has no ready endpoints"},
+			{Configuration: "cert-manager-webhook", Type: "Validating", Name: "webhook.cert-manager.io", FailurePolicy: "Fail", TimeoutSecs: 30, Target: "cert-manager/cert-manager-webhook:443", ClusterWide: true, Available: true},
+			{Configuration: "ingress-nginx-admission", Type: "Validating", Name: "validate.nginx.ingress.kubernetes.io", FailurePolicy: "Fail", TimeoutSecs: 10, Target: "ingress-nginx/ingress-nginx-controller-admission:443", Available: true},
+			{Configuration: "cert-manager-webhook", Type: "Mutating", Name: "webhook.cert-manager.io", FailurePolicy: "Fail", TimeoutSecs: 10, Target: "cert-manager/cert-manager-webhook:443", Available: true},
+			{Configuration: "istio-sidecar-injector", Type: "Mutating", Name: "namespace.sidecar-injector.istio.io", FailurePolicy: "Ignore", TimeoutSecs: 10, Target: "istio-system/istiod:443", Available: true},
+		}
+		for i := range statuses {
+			classifyWebhook(&statuses[i])
+		}
+	} else {
+		ctx := c.Request.Context()
+		dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+		if err != nil {
+			apierror.Fail(c, "Failed to get dynamic client", err)
+			return
+		}
+		for _, kind := range []struct{ resource, label string }{{"validating-webhooks", "Validating"}, {"mutating-webhooks", "Mutating"}} {
+			list, err := dynClient.Resource(getGVR(kind.resource)).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				apierror.Fail(c, "Failed to list webhook configurations", err)
+				return
+			}
+			for _, item := range list.Items {
+				statuses = append(statuses, analyzeWebhooks(ctx, dynClient, item, kind.label)...)
+			}
+		}
+	}
+
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return webhookRiskOrder[statuses[i].Risk] < webhookRiskOrder[statuses[j].Risk]
+	})
+	if statuses == nil {
+		statuses = []WebhookStatus{}
+	}
+	c.JSON(http.StatusOK, statuses)
+}
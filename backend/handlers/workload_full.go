@@ -0,0 +1,530 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"k-view/apierror"
+)
+
+// workloadKinds maps the kinds the detail page covers to the Kind HPAs target them by.
+var workloadKinds = map[string]string{
+	"deployments":  "Deployment",
+	"statefulsets": "StatefulSet",
+	"daemonsets":   "DaemonSet",
+}
+
+// WorkloadContainer is a container of the pod template.
+type WorkloadContainer struct {
+	Name     string            `json:"name"`
+	Image    string            `json:"image"`
+	Requests map[string]string `json:"requests,omitempty"`
+	Limits   map[string]string `json:"limits,omitempty"`
+}
+
+// WorkloadSpec summarizes what a workload asks for.
+type WorkloadSpec struct {
+	Replicas       *int64              `json:"replicas,omitempty"` // Not set for daemonsets
+	Strategy       string              `json:"strategy,omitempty"`
+	Selector       string              `json:"selector"`
+	ServiceAccount string              `json:"serviceAccount,omitempty"`
+	Revision       string              `json:"revision,omitempty"`
+	Containers     []WorkloadContainer `json:"containers"`
+}
+
+// ReplicaStatus is how far a workload's pods are rolled out.
+type ReplicaStatus struct {
+	Desired   int64 `json:"desired"`
+	Current   int64 `json:"current"`
+	Ready     int64 `json:"ready"`
+	Updated   int64 `json:"updated"`
+	Available int64 `json:"available"`
+}
+
+// WorkloadPod is one pod of a workload.
+type WorkloadPod struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Ready    string `json:"ready"` // "1/2"
+	Restarts int32  `json:"restarts"`
+	Node     string `json:"node,omitempty"`
+	Age      string `json:"age"`
+}
+
+// HPASummary is a HorizontalPodAutoscaler targeting the workload.
+type HPASummary struct {
+	Name            string   `json:"name"`
+	MinReplicas     int64    `json:"minReplicas"`
+	MaxReplicas     int64    `json:"maxReplicas"`
+	CurrentReplicas int64    `json:"currentReplicas"`
+	DesiredReplicas int64    `json:"desiredReplicas"`
+	Metrics         []string `json:"metrics"` // e.g. "cpu 80%"
+}
+
+// PDBSummary is a PodDisruptionBudget covering the workload's pods.
+type PDBSummary struct {
+	Name               string `json:"name"`
+	MinAvailable       string `json:"minAvailable,omitempty"`
+	MaxUnavailable     string `json:"maxUnavailable,omitempty"`
+	DisruptionsAllowed int64  `json:"disruptionsAllowed"`
+	CurrentHealthy     int64  `json:"currentHealthy"`
+	DesiredHealthy     int64  `json:"desiredHealthy"`
+}
+
+// ServiceSummary is a Service selecting the workload's pods.
+type ServiceSummary struct {
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	ClusterIP string   `json:"clusterIP,omitempty"`
+	Ports     []string `json:"ports"` // e.g. "80→8080/TCP"
+}
+
+// IngressSummary is an Ingress routing to one of those services.
+type IngressSummary struct {
+	Name     string   `json:"name"`
+	Hosts    []string `json:"hosts"`
+	Services []string `json:"services"`
+}
+
+// WorkloadMetrics is the CPU and memory of the workload's pods.
+type WorkloadMetrics struct {
+	Source      string        `json:"source"` // metrics-server or requests
+	CPUMillis   int64         `json:"cpuMillis"`
+	MemoryBytes int64         `json:"memoryBytes"`
+	Pods        []PodConsumer `json:"pods"`
+}
+
+// WorkloadDetail is everything the workload detail page shows.
+type WorkloadDetail struct {
+	Kind       string            `json:"kind"`
+	Namespace  string            `json:"namespace"`
+	Name       string            `json:"name"`
+	Age        string            `json:"age"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Spec       WorkloadSpec      `json:"spec"`
+	Replicas   ReplicaStatus     `json:"replicas"`
+	Status     string            `json:"status"` // Healthy, Degraded or Scaled down
+	Conditions []gin.H           `json:"conditions"`
+	Pods       []WorkloadPod     `json:"pods"`
+	HPAs       []HPASummary      `json:"hpas"`
+	PDBs       []PDBSummary      `json:"pdbs"`
+	Services   []ServiceSummary  `json:"services"`
+	Ingresses  []IngressSummary  `json:"ingresses"`
+	Events     []EventRecord     `json:"events"`
+	Metrics    WorkloadMetrics   `json:"metrics"`
+	// Errors holds the sections that could not be loaded; the others are still returned
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// mockWorkload builds a workload object from the mock resource list for DEV_MODE.
+func mockWorkload(kind, ns, name string) (*unstructured.Unstructured, bool) {
+	for _, item := range mockResourceList(kind, ns) {
+		if item.Name != name {
+			continue
+		}
+		ready := item.Extra["ready"]
+		if kind == "daemonsets" {
+			ready = item.Extra["ready"] + "/" + item.Extra["desired"]
+		}
+		var r, d int64
+		fmt.Sscanf(ready, "%d/%d", &r, &d)
+		podLabels := map[string]interface{}{"app": name}
+		obj := map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":              name,
+				"namespace":         ns,
+				"labels":            map[string]interface{}{"app": name},
+				"annotations":       map[string]interface{}{"deployment.kubernetes.io/revision": "4"},
+				"creationTimestamp": time.Now().AddDate(0, 0, -30).UTC().Format(time.RFC3339),
+			},
+			"spec": map[string]interface{}{
+				"replicas": d,
+				"selector": map[string]interface{}{"matchLabels": podLabels},
+				"strategy": map[string]interface{}{"type": "RollingUpdate"},
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{"labels": podLabels},
+					"spec": map[string]interface{}{
+						"containers": []interface{}{map[string]interface{}{
+							"name":      "main",
+							"image":     "nginx:1.21",
+							"resources": map[string]interface{}{"requests": map[string]interface{}{"cpu": "100m", "memory": "128Mi"}},
+						}},
+					},
+				},
+			},
+			"status": map[string]interface{}{
+				"replicas": d, "readyReplicas": r, "updatedReplicas": d, "availableReplicas": r,
+				"desiredNumberScheduled": d, "currentNumberScheduled": d, "numberReady": r, "updatedNumberScheduled": d, "numberAvailable": r,
+			},
+		}
+		return &unstructured.Unstructured{Object: obj}, true
+	}
+	return nil, false
+}
+
+// GetWorkloadFull returns a deployment, statefulset or daemonset with its pods, autoscalers,
+// disruption budgets, services, ingresses, recent events and metrics in one response. Related
+// objects are loaded in parallel; a section that fails is reported in errors.
+func (h *ResourceHandler) GetWorkloadFull(c *gin.Context) {
+	kind := strings.ToLower(c.Param("kind"))
+	ns, name := c.Param("namespace"), c.Param("name")
+	targetKind, ok := workloadKinds[kind]
+	if !ok {
+		apierror.Write(c, http.StatusBadRequest, "kind must be deployments, statefulsets or daemonsets")
+		return
+	}
+	if rbacNs := rbacNamespace(c); rbacNs != "" && rbacNs != ns {
+		apierror.Write(c, http.StatusForbidden, "access denied to namespace "+ns)
+		return
+	}
+	ctx := c.Request.Context()
+
+	var item *unstructured.Unstructured
+	if h.devMode {
+		if item, ok = mockWorkload(kind, ns, name); !ok {
+			apierror.Write(c, http.StatusNotFound, "resource not found")
+			return
+		}
+	} else {
+		dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+		if err != nil {
+			apierror.Fail(c, "Failed to get dynamic client", err)
+			return
+		}
+		if item, err = dynClient.Resource(getGVR(kind)).Namespace(ns).Get(ctx, name, metav1.GetOptions{}); err != nil {
+			apierror.Fail(c, "Failed to get resource", err)
+			return
+		}
+	}
+
+	d := WorkloadDetail{
+		Kind: kind, Namespace: ns, Name: name,
+		Age:    getAge(item.GetCreationTimestamp().Time),
+		Labels: item.GetLabels(),
+		Spec:   workloadSpec(item),
+		Pods:   []WorkloadPod{}, HPAs: []HPASummary{}, PDBs: []PDBSummary{}, Services: []ServiceSummary{}, Ingresses: []IngressSummary{}, Events: []EventRecord{},
+	}
+	d.Replicas = replicaStatus(kind, item)
+	d.Status = workloadStatus(d.Replicas.Ready, d.Replicas.Desired)
+	d.Conditions = []gin.H{}
+	conditions, _, _ := unstructured.NestedSlice(item.Object, "status", "conditions")
+	for _, raw := range conditions {
+		if cond, ok := raw.(map[string]interface{}); ok {
+			d.Conditions = append(d.Conditions, gin.H{"type": cond["type"], "status": cond["status"], "reason": cond["reason"], "message": cond["message"]})
+		}
+	}
+
+	var selector metav1.LabelSelector
+	rawSelector, _, _ := unstructured.NestedMap(item.Object, "spec", "selector")
+	_ = runtime.DefaultUnstructuredConverter.FromUnstructured(rawSelector, &selector)
+	podSelector, err := metav1.LabelSelectorAsSelector(&selector)
+	if err != nil {
+		podSelector = labels.Nothing()
+	}
+	templateLabels, _, _ := unstructured.NestedStringMap(item.Object, "spec", "template", "metadata", "labels")
+
+	var mu sync.Mutex
+	fail := func(section string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if d.Errors == nil {
+			d.Errors = map[string]string{}
+		}
+		d.Errors[section] = err.Error()
+	}
+	var wg sync.WaitGroup
+	run := func(section string, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				fail(section, err)
+			}
+		}()
+	}
+
+	var pods []corev1.Pod
+	run("pods", func() error {
+		all, err := h.k8sClient.ListPods(ctx, ns)
+		for _, p := range all {
+			// Mock pods carry no labels; they are matched by name
+			if podSelector.Matches(labels.Set(p.Labels)) || (h.devMode && strings.HasPrefix(p.Name, name+"-")) {
+				pods = append(pods, p)
+			}
+		}
+		return err
+	})
+	if !h.devMode {
+		run("hpas", func() (err error) {
+			d.HPAs, err = h.workloadHPAs(ctx, ns, targetKind, name)
+			return err
+		})
+		run("pdbs", func() (err error) {
+			d.PDBs, err = h.workloadPDBs(ctx, ns, templateLabels)
+			return err
+		})
+		run("services", func() (err error) {
+			d.Services, d.Ingresses, err = h.workloadServices(ctx, ns, templateLabels)
+			return err
+		})
+	}
+	wg.Wait()
+
+	podNames := map[string]bool{}
+	for _, p := range pods {
+		podNames[p.Name] = true
+		d.Pods = append(d.Pods, workloadPod(p))
+	}
+	// Events of the workload, its pods and, for deployments, its replicasets
+	events, err := h.namespaceEvents(ctx, ns, func(e EventRecord) bool {
+		return e.Name == name || podNames[e.Name] || (e.Kind == "ReplicaSet" && strings.HasPrefix(e.Name, name+"-"))
+	})
+	if err != nil {
+		fail("events", err)
+	} else {
+		d.Events = events
+	}
+
+	usage, source := h.podUsage(ctx, ns, pods)
+	d.Metrics = WorkloadMetrics{Source: source, Pods: usage}
+	for _, u := range usage {
+		d.Metrics.CPUMillis += u.CPUMillis
+		d.Metrics.MemoryBytes += u.MemoryBytes
+	}
+	c.JSON(http.StatusOK, d)
+}
+
+func workloadSpec(item *unstructured.Unstructured) WorkloadSpec {
+	spec := WorkloadSpec{Containers: []WorkloadContainer{}, Revision: item.GetAnnotations()["deployment.kubernetes.io/revision"]}
+	if replicas, ok, _ := unstructured.NestedInt64(item.Object, "spec", "replicas"); ok && item.GetKind() != "DaemonSet" {
+		spec.Replicas = &replicas
+	}
+	spec.Strategy, _, _ = unstructured.NestedString(item.Object, "spec", "strategy", "type")
+	if spec.Strategy == "" {
+		spec.Strategy, _, _ = unstructured.NestedString(item.Object, "spec", "updateStrategy", "type")
+	}
+	spec.ServiceAccount, _, _ = unstructured.NestedString(item.Object, "spec", "template", "spec", "serviceAccountName")
+
+	var selector metav1.LabelSelector
+	rawSelector, _, _ := unstructured.NestedMap(item.Object, "spec", "selector")
+	if runtime.DefaultUnstructuredConverter.FromUnstructured(rawSelector, &selector) == nil {
+		spec.Selector = metav1.FormatLabelSelector(&selector)
+	}
+
+	containers, _, _ := unstructured.NestedSlice(item.Object, "spec", "template", "spec", "containers")
+	for _, raw := range containers {
+		ctr, _ := raw.(map[string]interface{})
+		wc := WorkloadContainer{}
+		wc.Name, _, _ = unstructured.NestedString(ctr, "name")
+		wc.Image, _, _ = unstructured.NestedString(ctr, "image")
+		wc.Requests, _, _ = unstructured.NestedStringMap(ctr, "resources", "requests")
+		wc.Limits, _, _ = unstructured.NestedStringMap(ctr, "resources", "limits")
+		spec.Containers = append(spec.Containers, wc)
+	}
+	return spec
+}
+
+func replicaStatus(kind string, item *unstructured.Unstructured) ReplicaStatus {
+	field := func(path ...string) int64 {
+		v, _, _ := unstructured.NestedInt64(item.Object, path...)
+		return v
+	}
+	if kind == "daemonsets" {
+		return ReplicaStatus{
+			Desired:   field("status", "desiredNumberScheduled"),
+			Current:   field("status", "currentNumberScheduled"),
+			Ready:     field("status", "numberReady"),
+			Updated:   field("status", "updatedNumberScheduled"),
+			Available: field("status", "numberAvailable"),
+		}
+	}
+	desired := int64(1)
+	if v, ok, _ := unstructured.NestedInt64(item.Object, "spec", "replicas"); ok {
+		desired = v
+	}
+	return ReplicaStatus{
+		Desired:   desired,
+		Current:   field("status", "replicas"),
+		Ready:     field("status", "readyReplicas"),
+		Updated:   field("status", "updatedReplicas"),
+		Available: field("status", "availableReplicas"),
+	}
+}
+
+func workloadPod(p corev1.Pod) WorkloadPod {
+	wp := WorkloadPod{Name: p.Name, Status: string(p.Status.Phase), Node: p.Spec.NodeName, Age: getAge(p.CreationTimestamp.Time)}
+	ready := 0
+	for _, cs := range p.Status.ContainerStatuses {
+		wp.Restarts += cs.RestartCount
+		if cs.Ready {
+			ready++
+		}
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+			wp.Status = cs.State.Waiting.Reason
+		}
+	}
+	wp.Ready = fmt.Sprintf("%d/%d", ready, len(p.Spec.Containers))
+	return wp
+}
+
+func (h *ResourceHandler) listNamespaced(ctx context.Context, kind, ns string) ([]unstructured.Unstructured, error) {
+	dynClient, err := h.k8sClient.GetDynamicClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list, err := dynClient.Resource(getGVR(kind)).Namespace(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (h *ResourceHandler) workloadHPAs(ctx context.Context, ns, targetKind, name string) ([]HPASummary, error) {
+	items, err := h.listNamespaced(ctx, "hpas", ns)
+	if err != nil {
+		return []HPASummary{}, err
+	}
+	hpas := []HPASummary{}
+	for _, item := range items {
+		kind, _, _ := unstructured.NestedString(item.Object, "spec", "scaleTargetRef", "kind")
+		target, _, _ := unstructured.NestedString(item.Object, "spec", "scaleTargetRef", "name")
+		if kind != targetKind || target != name {
+			continue
+		}
+		hpa := HPASummary{Name: item.GetName(), MinReplicas: 1, Metrics: []string{}}
+		if v, ok, _ := unstructured.NestedInt64(item.Object, "spec", "minReplicas"); ok {
+			hpa.MinReplicas = v
+		}
+		hpa.MaxReplicas, _, _ = unstructured.NestedInt64(item.Object, "spec", "maxReplicas")
+		hpa.CurrentReplicas, _, _ = unstructured.NestedInt64(item.Object, "status", "currentReplicas")
+		hpa.DesiredReplicas, _, _ = unstructured.NestedInt64(item.Object, "status", "desiredReplicas")
+		metrics, _, _ := unstructured.NestedSlice(item.Object, "spec", "metrics")
+		for _, raw := range metrics {
+			m, _ := raw.(map[string]interface{})
+			if res, ok, _ := unstructured.NestedString(m, "resource", "name"); ok {
+				if util, ok, _ := unstructured.NestedInt64(m, "resource", "target", "averageUtilization"); ok {
+					hpa.Metrics = append(hpa.Metrics, fmt.Sprintf("%s %d%%", res, util))
+					continue
+				}
+				hpa.Metrics = append(hpa.Metrics, res)
+				continue
+			}
+			if t, ok := m["type"].(string); ok {
+				hpa.Metrics = append(hpa.Metrics, t)
+			}
+		}
+		hpas = append(hpas, hpa)
+	}
+	return hpas, nil
+}
+
+func (h *ResourceHandler) workloadPDBs(ctx context.Context, ns string, podLabels map[string]string) ([]PDBSummary, error) {
+	items, err := h.listNamespaced(ctx, "pdbs", ns)
+	if err != nil {
+		return []PDBSummary{}, err
+	}
+	pdbs := []PDBSummary{}
+	for _, item := range items {
+		var selector metav1.LabelSelector
+		rawSelector, _, _ := unstructured.NestedMap(item.Object, "spec", "selector")
+		if runtime.DefaultUnstructuredConverter.FromUnstructured(rawSelector, &selector) != nil {
+			continue
+		}
+		s, err := metav1.LabelSelectorAsSelector(&selector)
+		if err != nil || s.Empty() || !s.Matches(labels.Set(podLabels)) {
+			continue
+		}
+		pdb := PDBSummary{Name: item.GetName()}
+		if v, ok, _ := unstructured.NestedFieldNoCopy(item.Object, "spec", "minAvailable"); ok {
+			pdb.MinAvailable = fmt.Sprint(v)
+		}
+		if v, ok, _ := unstructured.NestedFieldNoCopy(item.Object, "spec", "maxUnavailable"); ok {
+			pdb.MaxUnavailable = fmt.Sprint(v)
+		}
+		pdb.DisruptionsAllowed, _, _ = unstructured.NestedInt64(item.Object, "status", "disruptionsAllowed")
+		pdb.CurrentHealthy, _, _ = unstructured.NestedInt64(item.Object, "status", "currentHealthy")
+		pdb.DesiredHealthy, _, _ = unstructured.NestedInt64(item.Object, "status", "desiredHealthy")
+		pdbs = append(pdbs, pdb)
+	}
+	return pdbs, nil
+}
+
+// workloadServices returns the services selecting the pods and the ingresses routing to them.
+func (h *ResourceHandler) workloadServices(ctx context.Context, ns string, podLabels map[string]string) ([]ServiceSummary, []IngressSummary, error) {
+	services, ingresses := []ServiceSummary{}, []IngressSummary{}
+	items, err := h.listNamespaced(ctx, "services", ns)
+	if err != nil {
+		return services, ingresses, err
+	}
+	names := map[string]bool{}
+	for _, item := range items {
+		sel, _, _ := unstructured.NestedStringMap(item.Object, "spec", "selector")
+		if len(sel) == 0 || !labels.SelectorFromSet(sel).Matches(labels.Set(podLabels)) {
+			continue
+		}
+		svc := ServiceSummary{Name: item.GetName(), Ports: []string{}}
+		svc.Type, _, _ = unstructured.NestedString(item.Object, "spec", "type")
+		svc.ClusterIP, _, _ = unstructured.NestedString(item.Object, "spec", "clusterIP")
+		ports, _, _ := unstructured.NestedSlice(item.Object, "spec", "ports")
+		for _, raw := range ports {
+			p, _ := raw.(map[string]interface{})
+			port, _, _ := unstructured.NestedInt64(p, "port")
+			protocol, _, _ := unstructured.NestedString(p, "protocol")
+			target, _, _ := unstructured.NestedFieldNoCopy(p, "targetPort")
+			svc.Ports = append(svc.Ports, fmt.Sprintf("%d→%v/%s", port, target, protocol))
+		}
+		services = append(services, svc)
+		names[svc.Name] = true
+	}
+	if len(names) == 0 {
+		return services, ingresses, nil
+	}
+
+	items, err = h.listNamespaced(ctx, "ingresses", ns)
+	if err != nil {
+		return services, ingresses, err
+	}
+	for _, item := range items {
+		ing := IngressSummary{Name: item.GetName(), Hosts: []string{}, Services: []string{}}
+		seen := map[string]bool{}
+		addBackend := func(backend map[string]interface{}) {
+			svc, _, _ := unstructured.NestedString(backend, "service", "name")
+			if names[svc] && !seen[svc] {
+				seen[svc] = true
+				ing.Services = append(ing.Services, svc)
+			}
+		}
+		if backend, ok, _ := unstructured.NestedMap(item.Object, "spec", "defaultBackend"); ok {
+			addBackend(backend)
+		}
+		rules, _, _ := unstructured.NestedSlice(item.Object, "spec", "rules")
+		for _, raw := range rules {
+			rule, _ := raw.(map[string]interface{})
+			if host, ok := rule["host"].(string); ok && host != "" {
+				ing.Hosts = append(ing.Hosts, host)
+			}
+			paths, _, _ := unstructured.NestedSlice(rule, "http", "paths")
+			for _, rawPath := range paths {
+				path, _ := rawPath.(map[string]interface{})
+				if backend, ok, _ := unstructured.NestedMap(path, "backend"); ok {
+					addBackend(backend)
+				}
+			}
+		}
+		if len(ing.Services) > 0 {
+			ingresses = append(ingresses, ing)
+		}
+	}
+	return services, ingresses, nil
+}
@@ -0,0 +1,75 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// Attacher streams the output of a container's main process (pods/attach) without starting a
+// new process in it. No stdin is sent, so the process cannot be interfered with.
+type Attacher interface {
+	// Attach copies stdout and stderr until the process exits or ctx ends. stderr is nil for
+	// containers running with a TTY, where both streams arrive on stdout.
+	Attach(ctx context.Context, namespace, pod, container string, stdout, stderr io.Writer) error
+}
+
+func (c *Client) Attach(ctx context.Context, namespace, pod, container string, stdout, stderr io.Writer) error {
+	clientset, err := c.getClientset(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get clientset: %v", err)
+	}
+
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(pod).
+		Namespace(namespace).
+		SubResource("attach")
+	req.VersionedParams(&corev1.PodAttachOptions{
+		Container: container,
+		Stdout:    true,
+		Stderr:    stderr != nil,
+		TTY:       stderr == nil,
+	}, scheme.ParameterCodec)
+
+	exec, err := remotecommand.NewSPDYExecutor(c.GetConfig(ctx), "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to initialize spdy executor: %v", err)
+	}
+	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdout: stdout,
+		Stderr: stderr,
+		Tty:    stderr == nil,
+	})
+	if err != nil {
+		return fmt.Errorf("attach stream failed: %v", err)
+	}
+	return nil
+}
+
+// Attach mock implementation for DEV_MODE: prints a request log line every two seconds.
+func (m *MockClient) Attach(ctx context.Context, namespace, pod, container string, stdout, stderr io.Writer) error {
+	_, _ = fmt.Fprintf(stdout, "Attached to %s/%s:%s (output only)\r\n", namespace, pod, container)
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	paths := []string{"/health", "/api/v1/items", "/api/v1/orders", "/metrics"}
+	for i := 0; ; i++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		case t := <-ticker.C:
+			line := fmt.Sprintf("%s GET %s 200 %dms\r\n", t.UTC().Format(time.RFC3339), paths[i%len(paths)], 3+i%17)
+			if _, err := io.WriteString(stdout, line); err != nil {
+				return nil
+			}
+			if i%7 == 6 && stderr != nil {
+				_, _ = fmt.Fprintf(stderr, "%s WARN slow upstream response\r\n", t.UTC().Format(time.RFC3339))
+			}
+		}
+	}
+}
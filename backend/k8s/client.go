@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -13,6 +14,8 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"k-view/tracing"
 )
 
 // UserContext represents the impersonation context for a request.
@@ -21,33 +24,28 @@ type UserContext struct {
 	Role  string
 }
 
-// KubernetesProvider is the interface that wraps all Kubernetes operations.
-type KubernetesProvider interface {
-	ListPods(ctx context.Context, namespace string) ([]corev1.Pod, error)
-	ListNamespaces(ctx context.Context) ([]string, error)
-	ListNodes(ctx context.Context) ([]corev1.Node, error)
-	Exec(ctx context.Context, namespace, pod, container string, pty PtyHandler) error
-	GetPodLogs(ctx context.Context, namespace, pod, container string, tailLines int64) (string, error)
-	GetPodMetrics(ctx context.Context, namespace, pod string) (map[string]interface{}, error)
-	GetDynamicClient(ctx context.Context) (dynamic.Interface, error)
-}
-
 // ---- Real Client ----
 
 type Client struct {
 	baseConfig *rest.Config
+	limits     CallLimits
 }
 
-func NewClient() (*Client, error) {
+func NewClient(limits CallLimits) (*Client, error) {
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		return nil, err
 	}
-	return &Client{baseConfig: config}, nil
+	config.Wrap(tracing.Transport)
+	limits.apply(config)
+	return &Client{baseConfig: config, limits: limits}, nil
 }
 
 func (c *Client) GetConfig(ctx context.Context) *rest.Config {
 	config := rest.CopyConfig(c.baseConfig)
+	if config.QPS > 0 {
+		config.RateLimiter = c.limits.rateLimiter(config)
+	}
 	if user, ok := ctx.Value("user").(UserContext); ok && user.Email != "" {
 		// Admin roles bypass impersonation — they use the ServiceAccount's own permissions.
 		// For non-admin roles, we impersonate the user so K8s RBAC applies to their identity.
@@ -193,16 +191,24 @@ func (m *MockClient) ListNamespaces(_ context.Context) ([]string, error) {
 }
 
 func (m *MockClient) GetPodLogs(_ context.Context, _, _, container string, _ int64) (string, error) {
-	return fmt.Sprintf("2024-02-18 10:00:01 [info] Starting %s...\n2024-02-18 10:00:02 [info] Configuration loaded.\n2024-02-18 10:00:05 [info] Connected to database clusters.\n2024-02-18 10:00:06 [info] Listening on :8080\n2024-02-18 10:15:23 GET /health 200 OK\n", container), nil
+	return fmt.Sprintf("2024-02-18 10:00:01 [info] Starting %s...\n2024-02-18 10:00:02 [info] Configuration loaded.\n2024-02-18 10:00:05 [info] Connected to database clusters.\n2024-02-18 10:00:06 [info] Listening on :8080\n2024-02-18 10:15:23 GET /health 200 OK\n"+
+		`{"level":"warn","ts":"2024-02-18T10:16:40Z","msg":"slow query","duration_ms":1840,"table":"orders"}`+"\n"+
+		`time=2024-02-18T10:17:02Z level=error msg="upstream request failed" upstream=payments status=503`+"\n"+
+		"2024-02-18 10:17:03 [error] Unhandled exception in worker pool\n\tat worker.process(worker.go:88)\n\tat worker.run(worker.go:42)\n", container), nil
 }
+// GetPodMetrics mock implementation for DEV_MODE. Usage drifts with the clock, as metrics-server
+// readings would every 15 seconds, so that live charts move.
 func (m *MockClient) GetPodMetrics(_ context.Context, _, _ string) (map[string]interface{}, error) {
+	now := time.Now().UTC().Truncate(15 * time.Second)
+	step := now.Unix() / 15
 	return map[string]interface{}{
+		"timestamp": now.Format(time.RFC3339),
 		"containers": []interface{}{
 			map[string]interface{}{
 				"name": "main",
 				"usage": map[string]interface{}{
-					"cpu":    "125m",
-					"memory": "256Mi",
+					"cpu":    fmt.Sprintf("%dm", 100+step*37%60),
+					"memory": fmt.Sprintf("%dKi", (248+step*13%16)*1024),
 				},
 			},
 		},
@@ -233,6 +239,8 @@ var allMockPods = []corev1.Pod{
 	mockPod("backend-api-6c9f8c", "default", corev1.PodRunning, -25*time.Minute),
 	mockPod("worker-job-abc12", "default", corev1.PodFailed, -2*time.Hour),
 	mockPod("cache-redis-001", "default", corev1.PodRunning, -3*time.Hour),
+	mockPod("model-serve-0a", "default", corev1.PodRunning, -6*time.Hour),
+	mockPod("iis-site-7f9c", "default", corev1.PodRunning, -4*time.Hour),
 	mockPod("auth-service-xyz", "auth", corev1.PodRunning, -1*time.Hour),
 	mockPod("oauth-proxy-001", "auth", corev1.PodRunning, -30*time.Minute),
 	mockPod("pgbouncer-main", "database", corev1.PodRunning, -5*time.Hour),
@@ -284,12 +292,27 @@ func mockNode(name, role, arch string, cpuCores, memGiB int64, ready bool, age t
 		labels["node-role.kubernetes.io/worker"] = ""
 	}
 
-	return corev1.Node{
+	// Long-running workers have not been through the last upgrade campaign yet
+	kubelet, osImage, kernel := "v1.29.3", "Alpine Linux v3.19", "6.6.8-0-lts"
+	if role == "worker" && age < -400*time.Hour {
+		kubelet, osImage, kernel = "v1.28.7", "Alpine Linux v3.18", "6.1.62-0-lts"
+	}
+	// win-* workers run Windows and are tainted so that only Windows pods land there
+	operatingSystem := "linux"
+	var taints []corev1.Taint
+	if strings.HasPrefix(name, "win-") {
+		operatingSystem, osImage, kernel = "windows", "Windows Server 2022 Datacenter", "10.0.20348.2227"
+		labels["kubernetes.io/os"] = operatingSystem
+		taints = []corev1.Taint{{Key: "os", Value: "windows", Effect: corev1.TaintEffectNoSchedule}}
+	}
+
+	node := corev1.Node{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:              name,
 			Labels:            labels,
 			CreationTimestamp: metav1.NewTime(time.Now().Add(age)),
 		},
+		Spec: corev1.NodeSpec{Taints: taints},
 		Status: corev1.NodeStatus{
 			Conditions: conditions,
 			Capacity: corev1.ResourceList{
@@ -301,13 +324,74 @@ func mockNode(name, role, arch string, cpuCores, memGiB int64, ready bool, age t
 				corev1.ResourceMemory: *resource.NewQuantity((memGiB-1)*1024*1024*1024, resource.BinarySI),
 			},
 			NodeInfo: corev1.NodeSystemInfo{
-				KubeletVersion:          "v1.29.3",
+				KubeletVersion:          kubelet,
 				ContainerRuntimeVersion: "containerd://1.7.13",
-				OSImage:                 "Alpine Linux v3.19",
+				OSImage:                 osImage,
+				KernelVersion:           kernel,
+				OperatingSystem:         operatingSystem,
 				Architecture:            arch,
 			},
+			Images: mockNodeImages(role),
 		},
 	}
+	// worker-03 is the GPU node
+	if name == "worker-03" {
+		for _, list := range []corev1.ResourceList{node.Status.Capacity, node.Status.Allocatable} {
+			list["nvidia.com/gpu"] = *resource.NewQuantity(4, resource.DecimalSI)
+			list["hugepages-2Mi"] = *resource.NewQuantity(2*1024*1024*1024, resource.BinarySI)
+		}
+	}
+	return node
+}
+
+// mockImages maps mock pod name prefixes to the container image they run.
+var mockImages = map[string]string{
+	"frontend-web":   "nginx:1.25",
+	"backend-api":    "registry.example.com/backend-api:2.4.1",
+	"worker-job":     "registry.example.com/worker:latest",
+	"cache-redis":    "redis:7.2",
+	"model-serve":    "nvcr.io/nvidia/tritonserver:24.01-py3",
+	"iis-site":       "mcr.microsoft.com/windows/servercore/iis:windowsservercore-ltsc2022",
+	"auth-service":   "registry.example.com/auth-service:1.8.0",
+	"oauth-proxy":    "quay.io/oauth2-proxy/oauth2-proxy:v7.5.1",
+	"pgbouncer":      "bitnami/pgbouncer:1.21.0",
+	"postgres":       "postgres:16.1",
+	"kafka-broker":   "bitnami/kafka:3.6",
+	"zookeeper":      "bitnami/zookeeper:3.9",
+	"prometheus":     "quay.io/prometheus/prometheus:v2.48.0",
+	"grafana":        "grafana/grafana:10.2.2",
+	"alertmanager":   "quay.io/prometheus/alertmanager:v0.26.0",
+	"loki":           "grafana/loki:2.9.2",
+	"fluentbit":      "fluent/fluent-bit:2.2",
+	"ingress-nginx":  "registry.k8s.io/ingress-nginx/controller@sha256:5b161f051d017e55d358435f295f5e9a297e66158f136321d9b04520ec6c48a3",
+	"cert-manager":   "quay.io/jetstack/cert-manager-controller:v1.13.2",
+	"coredns":        "registry.k8s.io/coredns/coredns:v1.11.1",
+	"etcd":           "registry.k8s.io/etcd:3.5.10-0",
+	"kube-apiserver": "registry.k8s.io/kube-apiserver:v1.29.3",
+	"kube-proxy":     "registry.k8s.io/kube-proxy:v1.29.3",
+	"kube-scheduler": "registry.k8s.io/kube-scheduler:v1.29.3",
+}
+
+func mockImageFor(podName string) string {
+	for prefix, image := range mockImages {
+		if strings.HasPrefix(podName, prefix) {
+			return image
+		}
+	}
+	return "nginx:1.25"
+}
+
+// mockNodeImages simulates the kubelet image cache: control-plane nodes only hold system images.
+func mockNodeImages(role string) []corev1.ContainerImage {
+	var images []corev1.ContainerImage
+	for _, image := range mockImages {
+		isSystem := strings.HasPrefix(image, "registry.k8s.io/")
+		if role == "control-plane" && !isSystem {
+			continue
+		}
+		images = append(images, corev1.ContainerImage{Names: []string{image}, SizeBytes: 50 * 1024 * 1024})
+	}
+	return images
 }
 
 var allMockNodes = []corev1.Node{
@@ -318,6 +402,7 @@ var allMockNodes = []corev1.Node{
 	mockNode("worker-02", "worker", "arm64", 8, 32, true, -500*time.Hour),
 	mockNode("worker-03", "worker", "arm64", 16, 64, true, -250*time.Hour),
 	mockNode("worker-04", "worker", "amd64", 16, 64, false, -10*time.Hour), // NotReady
+	mockNode("win-worker-01", "worker", "amd64", 8, 32, true, -120*time.Hour),
 }
 
 func mockPod(name, namespace string, phase corev1.PodPhase, age time.Duration) corev1.Pod {
@@ -327,21 +412,48 @@ func mockPod(name, namespace string, phase corev1.PodPhase, age time.Duration) c
 			Namespace:         namespace,
 			CreationTimestamp: metav1.NewTime(time.Now().Add(age)),
 		},
+		Spec: corev1.PodSpec{
+			NodeName: fmt.Sprintf("worker-0%d", len(name)%3+1),
+			Containers: []corev1.Container{
+				{Name: "main", Image: mockImageFor(name), ImagePullPolicy: corev1.PullIfNotPresent},
+			},
+		},
 		Status: corev1.PodStatus{Phase: phase},
 	}
+	// model-serve pods run inference on the GPUs of worker-03
+	if strings.HasPrefix(name, "model-serve") {
+		gpus := corev1.ResourceList{"nvidia.com/gpu": *resource.NewQuantity(2, resource.DecimalSI)}
+		pod.Spec.Containers[0].Resources = corev1.ResourceRequirements{Requests: gpus, Limits: gpus}
+	}
+	// iis-site pods are Windows containers on win-worker-01
+	if strings.HasPrefix(name, "iis-site") {
+		pod.Spec.OS = &corev1.PodOS{Name: corev1.Windows}
+		pod.Spec.NodeName = "win-worker-01"
+		pod.Spec.NodeSelector = map[string]string{"kubernetes.io/os": "windows"}
+		pod.Spec.Tolerations = []corev1.Toleration{{Key: "os", Operator: corev1.TolerationOpEqual, Value: "windows", Effect: corev1.TaintEffectNoSchedule}}
+	}
+	// fluentbit tails the node's logs, which keeps logging at the privileged level
+	if strings.HasPrefix(name, "fluentbit") {
+		pod.Spec.Volumes = []corev1.Volume{{Name: "varlog", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/log"}}}}
+		pod.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{{Name: "varlog", MountPath: "/var/log", ReadOnly: true}}
+	}
+	// cert-manager runs in a namespace enforcing the restricted level
+	if strings.HasPrefix(name, "cert-manager") {
+		nonRoot, escalation := true, false
+		pod.Spec.SecurityContext = &corev1.PodSecurityContext{RunAsNonRoot: &nonRoot, SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}}
+		pod.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{AllowPrivilegeEscalation: &escalation, Capabilities: &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}}}
+	}
 	if phase == corev1.PodFailed {
 		pod.Status.ContainerStatuses = []corev1.ContainerStatus{
-			{State: corev1.ContainerState{
+			{Name: "main", RestartCount: 5, State: corev1.ContainerState{
 				Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+			}, LastTerminationState: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{ExitCode: 2, Reason: "Error", FinishedAt: metav1.NewTime(time.Now().Add(-2 * time.Minute))},
 			}},
 		}
 	}
 	return pod
 }
 
-// Ensure MockClient satisfies KubernetesProvider at compile time
-var _ KubernetesProvider = (*MockClient)(nil)
-var _ KubernetesProvider = (*Client)(nil)
-
 // Suppress unused import lint warning
 var _ = corev1.PodRunning
@@ -0,0 +1,48 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+)
+
+// ClusterInfoProvider exposes raw API server endpoints that are not modelled as resources.
+// It is implemented by both Client and MockClient but kept out of KubernetesProvider so
+// callers can degrade gracefully when it is unavailable.
+type ClusterInfoProvider interface {
+	GetRaw(ctx context.Context, path string) ([]byte, error)
+	ServerVersion(ctx context.Context) (string, error)
+}
+
+// GetRaw performs a GET on a non-resource API server path such as /livez?verbose.
+func (c *Client) GetRaw(ctx context.Context, path string) ([]byte, error) {
+	clientset, err := c.getClientset(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return clientset.Discovery().RESTClient().Get().AbsPath(path).DoRaw(ctx)
+}
+
+// ServerVersion returns the git version of the API server, e.g. "v1.29.3".
+func (c *Client) ServerVersion(ctx context.Context) (string, error) {
+	clientset, err := c.getClientset(ctx)
+	if err != nil {
+		return "", err
+	}
+	info, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return "", err
+	}
+	return info.GitVersion, nil
+}
+
+func (m *MockClient) GetRaw(_ context.Context, path string) ([]byte, error) {
+	switch path {
+	case "/livez?verbose", "/readyz?verbose":
+		return []byte("[+]ping ok\n[+]log ok\n[+]etcd ok\n[+]poststarthook/start-kube-apiserver-admission-initializer ok\n[+]poststarthook/rbac/bootstrap-roles ok\nlivez check passed\n"), nil
+	}
+	return nil, fmt.Errorf("mock: no raw endpoint %s", path)
+}
+
+func (m *MockClient) ServerVersion(_ context.Context) (string, error) {
+	return "v1.29.3", nil
+}
@@ -0,0 +1,75 @@
+package k8s
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/client-go/tools/clientcmd"
+
+	"k-view/tracing"
+)
+
+// Clusters are the Kubernetes clusters K-View can reach: its own, and remote ones read from a
+// kubeconfig file with one context per cluster, named after the context. Remote clusters are
+// used for comparisons; the rest of K-View works on its own cluster.
+type Clusters struct {
+	local     string
+	providers map[string]KubernetesProvider
+}
+
+// LoadClusters registers the local provider as localName and every context of the kubeconfig
+// at path as a remote cluster. An empty path registers the local cluster only. Users are
+// impersonated on remote clusters as on the local one, so the kubeconfig credentials need
+// the impersonate permission there. Remote calls are bound by the same limits as local ones.
+func LoadClusters(localName string, local KubernetesProvider, path string, limits CallLimits) (*Clusters, error) {
+	c := &Clusters{local: localName, providers: map[string]KubernetesProvider{localName: local}}
+	if path == "" {
+		return c, nil
+	}
+	kubeconfig, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	for name := range kubeconfig.Contexts {
+		if name == localName {
+			return nil, fmt.Errorf("context %q in %s has the name of the local cluster", name, path)
+		}
+		config, err := clientcmd.NewNonInteractiveClientConfig(*kubeconfig, name, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("context %q in %s: %v", name, path, err)
+		}
+		config.Wrap(tracing.Transport)
+		limits.apply(config)
+		c.providers[name] = &Client{baseConfig: config, limits: limits}
+	}
+	return c, nil
+}
+
+// NewMockClusters returns the local mock cluster and a mock "staging" cluster for DEV_MODE.
+func NewMockClusters(localName string) *Clusters {
+	return &Clusters{local: localName, providers: map[string]KubernetesProvider{localName: NewMockClient(), "staging": NewMockClient()}}
+}
+
+// Local returns the name of K-View's own cluster.
+func (c *Clusters) Local() string {
+	return c.local
+}
+
+// Names lists the clusters, the local one first.
+func (c *Clusters) Names() []string {
+	names := []string{c.local}
+	var remote []string
+	for name := range c.providers {
+		if name != c.local {
+			remote = append(remote, name)
+		}
+	}
+	sort.Strings(remote)
+	return append(names, remote...)
+}
+
+// Get returns the provider of a cluster.
+func (c *Clusters) Get(name string) (KubernetesProvider, bool) {
+	p, ok := c.providers[name]
+	return p, ok
+}
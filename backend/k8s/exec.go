@@ -2,6 +2,7 @@ package k8s
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -20,8 +21,67 @@ type PtyHandler interface {
 	Done()
 }
 
-// Exec opens a shell in a pod container and connects it to the pty
-func (c *Client) Exec(ctx context.Context, namespace, pod, container string, pty PtyHandler) error {
+// ErrNoShell is returned by Exec when the container image has no usable shell (e.g. distroless).
+var ErrNoShell = errors.New("no shell found in the container image")
+
+// Shells lists the shells a user may ask for; anything else falls back to auto-detection.
+var Shells = []string{"bash", "sh", "ash", "zsh"}
+
+// WindowsShells lists the shells of Windows containers, the default first. Nano Server images
+// only have cmd.
+var WindowsShells = []string{"powershell", "pwsh", "cmd"}
+
+// ShellsFor returns the shells of containers running on an operating system ("linux" or
+// "windows", as in the kubernetes.io/os label).
+func ShellsFor(os string) []string {
+	if os == "windows" {
+		return WindowsShells
+	}
+	return Shells
+}
+
+// shellCommand starts the preferred shell if the image has it, then bash, ash and finally sh.
+// On Windows it starts PowerShell, pwsh or cmd, falling back to cmd.
+func shellCommand(shell string) []string {
+	switch shell {
+	case "cmd":
+		return []string{"cmd.exe"}
+	case "powershell", "pwsh":
+		return []string{"cmd.exe", "/c", "where " + shell + ".exe >nul 2>&1 && " + shell + ".exe -NoLogo || cmd.exe"}
+	}
+	candidates := "/bin/bash /bin/ash /bin/sh"
+	for _, s := range Shells {
+		if s == shell {
+			candidates = "/bin/" + shell + " " + candidates
+		}
+	}
+	return []string{"/bin/sh", "-c", "TERM=xterm-256color; export TERM; for s in " + candidates + "; do [ -x $s ] && exec $s; done"}
+}
+
+// isMissingShell recognises the runtime errors reported when /bin/sh does not exist.
+func isMissingShell(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "no such file or directory") || strings.Contains(msg, "executable file not found")
+}
+
+// CommandExecutor runs an arbitrary command on a TTY, for terminals that are not a plain
+// shell in the container (e.g. the node shell entering the host namespaces).
+type CommandExecutor interface {
+	ExecCommand(ctx context.Context, namespace, pod, container string, command []string, pty PtyHandler) error
+}
+
+// Exec opens a shell in a pod container and connects it to the pty. shell is the preferred
+// shell ("" picks bash if available, otherwise sh).
+func (c *Client) Exec(ctx context.Context, namespace, pod, container, shell string, pty PtyHandler) error {
+	err := c.ExecCommand(ctx, namespace, pod, container, shellCommand(shell), pty)
+	if err != nil && isMissingShell(err) {
+		return fmt.Errorf("%w: %v", ErrNoShell, err)
+	}
+	return err
+}
+
+// ExecCommand runs command in a pod container and connects it to the pty.
+func (c *Client) ExecCommand(ctx context.Context, namespace, pod, container string, command []string, pty PtyHandler) error {
 	defer pty.Done()
 
 	clientset, err := c.getClientset(ctx)
@@ -37,7 +97,7 @@ func (c *Client) Exec(ctx context.Context, namespace, pod, container string, pty
 
 	req.VersionedParams(&corev1.PodExecOptions{
 		Container: container,
-		Command:   []string{"/bin/sh", "-c", "TERM=xterm-256color; export TERM; [ -x /bin/bash ] && /bin/bash || /bin/sh"},
+		Command:   command,
 		Stdin:     true,
 		Stdout:    true,
 		Stderr:    true,
@@ -65,7 +125,7 @@ func (c *Client) Exec(ctx context.Context, namespace, pod, container string, pty
 }
 
 // Exec mock implementation for DEV_MODE
-func (m *MockClient) Exec(ctx context.Context, namespace, pod, container string, pty PtyHandler) error {
+func (m *MockClient) Exec(ctx context.Context, namespace, pod, container, shell string, pty PtyHandler) error {
 	defer pty.Done()
 
 	user, _ := ctx.Value("user").(UserContext)
@@ -74,10 +134,38 @@ func (m *MockClient) Exec(ctx context.Context, namespace, pod, container string,
 		return nil
 	}
 
-	welcome := fmt.Sprintf("\r\n\033[1;36mK-View Mock Terminal\033[0m\r\nConnected to %s/%s:%s\r\n\r\n", namespace, pod, container)
+	// Control-plane images and cert-manager ship distroless, like the real ones
+	image := mockImageFor(pod)
+	for _, distroless := range []string{"coredns", "kube-apiserver", "kube-scheduler", "cert-manager"} {
+		if strings.Contains(image, distroless) {
+			return fmt.Errorf("%w: exec: \"/bin/sh\": stat /bin/sh: no such file or directory", ErrNoShell)
+		}
+	}
+	if shell == "" {
+		shell = "bash"
+	}
+
+	welcome := fmt.Sprintf("Connected to %s/%s:%s (/bin/%s)", namespace, pod, container, shell)
+	for _, windowsShell := range WindowsShells {
+		if shell == windowsShell {
+			welcome = fmt.Sprintf("Connected to %s/%s:%s (%s.exe)", namespace, pod, container, shell)
+		}
+	}
+	return mockTerminal(ctx, pod, welcome, pty)
+}
+
+// ExecCommand mock implementation for DEV_MODE: the command is shown but not interpreted.
+func (m *MockClient) ExecCommand(ctx context.Context, namespace, pod, container string, command []string, pty PtyHandler) error {
+	defer pty.Done()
+	return mockTerminal(ctx, pod, fmt.Sprintf("Running %q in %s/%s:%s", strings.Join(command, " "), namespace, pod, container), pty)
+}
+
+// mockTerminal echoes input and answers a few commands until the user exits.
+func mockTerminal(ctx context.Context, host, banner string, pty PtyHandler) error {
+	welcome := fmt.Sprintf("\r\n\033[1;36mK-View Mock Terminal\033[0m\r\n%s\r\n\r\n", banner)
 	_, _ = pty.Write([]byte(welcome))
 
-	prompt := fmt.Sprintf("\033[1;32mroot@%s\033[0m:/# ", pod)
+	prompt := fmt.Sprintf("\033[1;32mroot@%s\033[0m:/# ", host)
 	_, _ = pty.Write([]byte(prompt))
 
 	buf := make([]byte, 1024)
@@ -0,0 +1,169 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var virtualServicesGVR = schema.GroupVersionResource{Group: "networking.istio.io", Version: "v1beta1", Resource: "virtualservices"}
+
+// meshDestination is one weighted destination of a VirtualService HTTP route.
+type meshDestination struct {
+	Service   string
+	Namespace string
+	Subset    string
+	Port      int64
+	Weight    int64
+}
+
+// serviceFromHost resolves a mesh host ("reviews", "reviews.bookinfo", "reviews.bookinfo.svc.cluster.local")
+// to a Service name and namespace. External hosts are returned with ok=false.
+func serviceFromHost(host, defaultNamespace string) (string, string, bool) {
+	parts := strings.Split(host, ".")
+	switch {
+	case len(parts) == 1:
+		return parts[0], defaultNamespace, true
+	case len(parts) == 2, len(parts) >= 3 && parts[2] == "svc":
+		return parts[0], parts[1], true
+	}
+	return "", "", false
+}
+
+// routesService reports whether a VirtualService applies to traffic addressed to the Service.
+func routesService(vs map[string]interface{}, svc *corev1.Service) bool {
+	hosts, _, _ := unstructured.NestedStringSlice(vs, "spec", "hosts")
+	for _, h := range hosts {
+		if name, ns, ok := serviceFromHost(h, svc.Namespace); ok && name == svc.Name && ns == svc.Namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// virtualServiceDestinations flattens the destinations of all HTTP and TCP routes.
+func virtualServiceDestinations(vs map[string]interface{}, namespace string) []meshDestination {
+	var dests []meshDestination
+	for _, section := range []string{"http", "tcp", "tls"} {
+		routes, _, _ := unstructured.NestedSlice(vs, "spec", section)
+		for _, raw := range routes {
+			route, _ := raw.(map[string]interface{})
+			list, _, _ := unstructured.NestedSlice(route, "route")
+			for _, r := range list {
+				rm, _ := r.(map[string]interface{})
+				host, _, _ := unstructured.NestedString(rm, "destination", "host")
+				name, ns, ok := serviceFromHost(host, namespace)
+				if !ok {
+					name, ns = host, ""
+				}
+				d := meshDestination{Service: name, Namespace: ns}
+				d.Subset, _, _ = unstructured.NestedString(rm, "destination", "subset")
+				d.Port, _, _ = unstructured.NestedInt64(rm, "destination", "port", "number")
+				d.Weight, _, _ = unstructured.NestedInt64(rm, "weight")
+				if d.Weight == 0 && len(list) == 1 {
+					d.Weight = 100
+				}
+				dests = append(dests, d)
+			}
+		}
+	}
+	return dests
+}
+
+// traceVirtualService adds a VirtualService, the Gateways it is bound to, and its weighted
+// destinations (down to their pods) to the trace.
+func traceVirtualService(ctx context.Context, client tracer, vs *unstructured.Unstructured, res *TraceResponse) {
+	vsKey := "VirtualService:" + vs.GetName()
+	hosts, _, _ := unstructured.NestedStringSlice(vs.Object, "spec", "hosts")
+	res.Nodes = append(res.Nodes, TraceNode{Type: "VirtualService", Name: vs.GetName(), Healthy: true, Message: "Mesh Routing", Details: "Hosts: " + strings.Join(hosts, ", ")})
+
+	gateways, _, _ := unstructured.NestedStringSlice(vs.Object, "spec", "gateways")
+	for _, gw := range gateways {
+		if gw == "mesh" {
+			continue
+		}
+		res.Nodes = append(res.Nodes, TraceNode{Type: "Gateway", Name: gw, Healthy: true, Message: "Istio Gateway"})
+		res.Edges = append(res.Edges, TraceEdge{From: "Gateway:" + gw, To: vsKey, Healthy: true, Message: "Bound"})
+	}
+
+	for _, d := range virtualServiceDestinations(vs.Object, vs.GetNamespace()) {
+		msg := fmt.Sprintf("weight %d%%", d.Weight)
+		if d.Subset != "" {
+			msg += ", subset " + d.Subset
+		}
+		if d.Port != 0 {
+			msg += fmt.Sprintf(", port %d", d.Port)
+		}
+		if d.Namespace == "" {
+			res.Nodes = append(res.Nodes, TraceNode{Type: "External", Name: d.Service, Healthy: true, Message: "External Host"})
+			res.Edges = append(res.Edges, TraceEdge{From: vsKey, To: "External:" + d.Service, Healthy: true, Message: msg})
+			continue
+		}
+
+		dest, err := client.GetService(ctx, d.Namespace, d.Service)
+		if err != nil {
+			res.Nodes = append(res.Nodes, TraceNode{Type: "Service", Name: d.Service, Healthy: false, Message: "Service Not Found"})
+			res.Edges = append(res.Edges, TraceEdge{From: vsKey, To: "Service:" + d.Service, Healthy: false, Message: msg})
+			continue
+		}
+		res.Nodes = append(res.Nodes, TraceNode{Type: "Service", Name: dest.Name, Healthy: true, Message: "Found", Selectors: dest.Spec.Selector})
+		res.Edges = append(res.Edges, TraceEdge{From: vsKey, To: "Service:" + dest.Name, Healthy: d.Weight > 0, Message: msg})
+		traceServiceToPods(ctx, client, dest.Namespace, dest, res)
+	}
+}
+
+// traceServiceMesh follows VirtualServices that re-route traffic sent to the Service. It returns
+// false if no VirtualService applies, in which case plain selector-based tracing should be used.
+func traceServiceMesh(ctx context.Context, client tracer, namespace string, svc *corev1.Service, res *TraceResponse) bool {
+	dyn, err := client.GetDynamicClient(ctx)
+	if err != nil {
+		return false
+	}
+	list, err := dyn.Resource(virtualServicesGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		// Istio is not installed or not accessible
+		return false
+	}
+	routed := false
+	for i := range list.Items {
+		vs := &list.Items[i]
+		if !routesService(vs.Object, svc) {
+			continue
+		}
+		routed = true
+		res.Edges = append(res.Edges, TraceEdge{From: "Service:" + svc.Name, To: "VirtualService:" + vs.GetName(), Healthy: true, Message: "Host Match"})
+		traceVirtualService(ctx, client, vs, res)
+	}
+	return routed
+}
+
+// traceBackend traces a Service to its pods, following mesh routing first when enabled.
+func traceBackend(ctx context.Context, client tracer, namespace string, svc *corev1.Service, res *TraceResponse, mesh bool) {
+	if mesh && traceServiceMesh(ctx, client, namespace, svc, res) {
+		return
+	}
+	traceServiceToPods(ctx, client, namespace, svc, res)
+}
+
+var mockMeshTrace = TraceResponse{
+	Nodes: []TraceNode{
+		{Type: "Gateway", Name: "public-gateway", Healthy: true, Message: "Istio Gateway"},
+		{Type: "VirtualService", Name: "mock-service", Healthy: true, Message: "Mesh Routing", Details: "Hosts: app.example.com, mock-service"},
+		{Type: "Service", Name: "mock-service-v1", Healthy: true, Message: "Found"},
+		{Type: "Service", Name: "mock-service-v2", Healthy: true, Message: "Found"},
+		{Type: "Pod", Name: "mock-pod-v1", Healthy: true, Message: "Running"},
+		{Type: "Pod", Name: "mock-pod-v2", Healthy: true, Message: "Running"},
+	},
+	Edges: []TraceEdge{
+		{From: "Gateway:public-gateway", To: "VirtualService:mock-service", Healthy: true, Message: "Bound"},
+		{From: "VirtualService:mock-service", To: "Service:mock-service-v1", Healthy: true, Message: "weight 90%"},
+		{From: "VirtualService:mock-service", To: "Service:mock-service-v2", Healthy: true, Message: "weight 10%"},
+		{From: "Service:mock-service-v1", To: "Pod:mock-pod-v1", Healthy: true, Message: "80 -> 8080"},
+		{From: "Service:mock-service-v2", To: "Pod:mock-pod-v2", Healthy: true, Message: "80 -> 8080"},
+	},
+}
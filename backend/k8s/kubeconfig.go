@@ -0,0 +1,165 @@
+package k8s
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	authv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// ServiceAccountIssuer issues the credentials of generated kubeconfigs.
+type ServiceAccountIssuer interface {
+	ServerInfo() (string, []byte, error)
+	IssueServiceAccountToken(ctx context.Context, saNamespace, saName, clusterRole string, bindNamespaces []string, ttl time.Duration) (string, error)
+}
+
+// ServerInfo returns the API server URL and CA bundle K-View itself connects with.
+func (c *Client) ServerInfo() (string, []byte, error) {
+	caData := c.baseConfig.CAData
+	if len(caData) == 0 && c.baseConfig.CAFile != "" {
+		data, err := os.ReadFile(c.baseConfig.CAFile)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read CA file: %v", err)
+		}
+		caData = data
+	}
+	return c.baseConfig.Host, caData, nil
+}
+
+// CurrentNamespace returns the namespace K-View runs in (KVIEW_NAMESPACE overrides it).
+func CurrentNamespace() string {
+	if ns := os.Getenv("KVIEW_NAMESPACE"); ns != "" {
+		return ns
+	}
+	if data, err := os.ReadFile(serviceAccountNamespaceFile); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+	return "default"
+}
+
+// ServiceAccountNameFor derives a valid ServiceAccount name from a user identity (usually an
+// email).
+func ServiceAccountNameFor(user string) string {
+	return NameFor("kview-", user)
+}
+
+// NameFor derives a valid object name from prefix and a user identity. The readable part is
+// lossy ("a.b@x.io" and "a-b@x.io" both give "a-b-x-io"), so a short hash of the exact identity
+// keeps the names of different users apart; it survives truncation to the 63 character limit.
+func NameFor(prefix, user string) string {
+	sum := sha256.Sum256([]byte(user))
+	suffix := "-" + hex.EncodeToString(sum[:4])
+	name := prefix + strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(user), "-"), "-")
+	if len(name) > 63-len(suffix) {
+		name = strings.TrimRight(name[:63-len(suffix)], "-")
+	}
+	return name + suffix
+}
+
+// managedLabel marks the RBAC objects K-View creates and may change or delete.
+const managedLabel = "app.kubernetes.io/managed-by"
+
+// removeStaleBindings deletes the bindings of a ServiceAccount that K-View created for an
+// earlier scope: the ClusterRoleBinding when the role is now namespaced, and the RoleBindings
+// outside bindNamespaces. Otherwise a user narrowed to one namespace would keep their old rights.
+func (c *Client) removeStaleBindings(ctx context.Context, saName string, bindNamespaces []string) error {
+	clientset, err := c.getClientset(ctx)
+	if err != nil {
+		return err
+	}
+	if len(bindNamespaces) > 0 {
+		crbs := clientset.RbacV1().ClusterRoleBindings()
+		if existing, err := crbs.Get(ctx, saName, metav1.GetOptions{}); err == nil && existing.Labels[managedLabel] == "k-view" {
+			if err := crbs.Delete(ctx, saName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete stale cluster role binding: %v", err)
+			}
+		}
+	}
+	list, err := clientset.RbacV1().RoleBindings("").List(ctx, metav1.ListOptions{LabelSelector: managedLabel + "=k-view"})
+	if err != nil {
+		return fmt.Errorf("failed to list role bindings: %v", err)
+	}
+	for _, rb := range list.Items {
+		if rb.Name != saName || slices.Contains(bindNamespaces, rb.Namespace) {
+			continue
+		}
+		if err := clientset.RbacV1().RoleBindings(rb.Namespace).Delete(ctx, rb.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete stale role binding in %s: %v", rb.Namespace, err)
+		}
+	}
+	return nil
+}
+
+// IssueServiceAccountToken ensures a per-user ServiceAccount bound to clusterRole exists in
+// saNamespace and returns a short-lived token for it. The role is granted cluster-wide when
+// bindNamespaces is empty, otherwise with one RoleBinding in each of bindNamespaces; bindings
+// left from an earlier scope are removed first.
+func (c *Client) IssueServiceAccountToken(ctx context.Context, saNamespace, saName, clusterRole string, bindNamespaces []string, ttl time.Duration) (string, error) {
+	clientset, err := c.getClientset(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.removeStaleBindings(ctx, saName, bindNamespaces); err != nil {
+		return "", err
+	}
+
+	labels := map[string]string{managedLabel: "k-view"}
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: saName, Namespace: saNamespace, Labels: labels}}
+	if _, err := clientset.CoreV1().ServiceAccounts(saNamespace).Create(ctx, sa, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return "", fmt.Errorf("failed to create service account: %v", err)
+	}
+
+	subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: saName, Namespace: saNamespace}}
+	roleRef := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: clusterRole}
+	if len(bindNamespaces) == 0 {
+		binding := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: saName, Labels: labels}, Subjects: subjects, RoleRef: roleRef}
+		bindings := clientset.RbacV1().ClusterRoleBindings()
+		// roleRef is immutable, so a changed role requires recreating the binding
+		if existing, getErr := bindings.Get(ctx, saName, metav1.GetOptions{}); getErr == nil && existing.RoleRef != roleRef {
+			_ = bindings.Delete(ctx, saName, metav1.DeleteOptions{})
+		}
+		if _, err = bindings.Create(ctx, binding, metav1.CreateOptions{}); apierrors.IsAlreadyExists(err) {
+			_, err = bindings.Update(ctx, binding, metav1.UpdateOptions{})
+		}
+	}
+	for _, ns := range bindNamespaces {
+		binding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: saName, Namespace: ns, Labels: labels}, Subjects: subjects, RoleRef: roleRef}
+		bindings := clientset.RbacV1().RoleBindings(ns)
+		if existing, getErr := bindings.Get(ctx, saName, metav1.GetOptions{}); getErr == nil && existing.RoleRef != roleRef {
+			_ = bindings.Delete(ctx, saName, metav1.DeleteOptions{})
+		}
+		if _, err = bindings.Create(ctx, binding, metav1.CreateOptions{}); apierrors.IsAlreadyExists(err) {
+			_, err = bindings.Update(ctx, binding, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			break
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to bind role: %v", err)
+	}
+
+	seconds := int64(ttl.Seconds())
+	req := &authv1.TokenRequest{Spec: authv1.TokenRequestSpec{ExpirationSeconds: &seconds}}
+	token, err := clientset.CoreV1().ServiceAccounts(saNamespace).CreateToken(ctx, saName, req, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to request token: %v", err)
+	}
+	return token.Status.Token, nil
+}
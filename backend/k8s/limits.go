@@ -0,0 +1,228 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// throttleThreshold is how long a call may wait for the client-side rate limiter before it
+// counts as throttled; client-go logs waits from the same length on.
+const throttleThreshold = time.Second
+
+// CallLimits bound the Kubernetes API calls of a Client. Every call gets its own timeout, and
+// every client built for a request a token bucket of QPS calls per second with bursts of
+// Burst, as client-go does by default.
+type CallLimits struct {
+	// Timeout bounds each call, including its wait for the rate limiter and reading its
+	// response. Watches, followed logs, proxied requests and upgraded streams (exec, attach,
+	// port-forward) are long-lived by design and only end with their request. 0 disables it.
+	Timeout time.Duration
+	QPS     float32
+	Burst   int
+}
+
+// DefaultCallLimits are client-go's own rate limits, with a 30 second timeout.
+var DefaultCallLimits = CallLimits{Timeout: 30 * time.Second, QPS: rest.DefaultQPS, Burst: rest.DefaultBurst}
+
+// apply sets the limits on the base config of a Client.
+func (l CallLimits) apply(config *rest.Config) {
+	config.QPS, config.Burst = l.QPS, l.Burst
+	if l.Timeout > 0 {
+		config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return &timeoutTransport{next: rt, timeout: l.Timeout}
+		})
+	}
+}
+
+// rateLimiter returns the rate limiter for the clients of one config, which records waits
+// for it into the request's Throttling. It is set per config rather than on the base config,
+// where every client would share it.
+func (l CallLimits) rateLimiter(config *rest.Config) flowcontrol.RateLimiter {
+	return &throttlingRateLimiter{
+		RateLimiter: flowcontrol.NewTokenBucketRateLimiter(config.QPS, config.Burst),
+		timeout:     l.Timeout,
+	}
+}
+
+// Throttling records whether the Kubernetes API calls made for one request were throttled,
+// by the API server (429 Too Many Requests) or by K-View's own client-side rate limit, or
+// timed out.
+type Throttling struct {
+	mu         sync.Mutex
+	server     bool
+	client     bool
+	timedOut   bool
+	retryAfter int
+}
+
+type throttlingKey struct{}
+
+// TrackThrottling returns a context in which throttled and timed out Kubernetes API calls are
+// recorded into the returned Throttling.
+func TrackThrottling(ctx context.Context) (context.Context, *Throttling) {
+	t := &Throttling{}
+	return context.WithValue(ctx, throttlingKey{}, t), t
+}
+
+func throttlingFrom(ctx context.Context) *Throttling {
+	t, _ := ctx.Value(throttlingKey{}).(*Throttling)
+	return t
+}
+
+// Throttled returns who throttled the calls, "apiserver" or "client", or "" when nothing did,
+// and the seconds the API server asked to wait before retrying, 0 when it did not say.
+func (t *Throttling) Throttled() (string, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case t.server:
+		return "apiserver", t.retryAfter
+	case t.client:
+		return "client", 0
+	}
+	return "", 0
+}
+
+// TimedOut reports whether a call ran into its timeout.
+func (t *Throttling) TimedOut() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.timedOut
+}
+
+func (t *Throttling) record(f func(t *Throttling)) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f(t)
+}
+
+// IsThrottled reports whether err is a call given up while waiting for the client-side rate
+// limiter. Calls the API server throttled are StatusErrors with reason TooManyRequests.
+func IsThrottled(err error) bool {
+	var throttled *clientThrottledError
+	return errors.As(err, &throttled)
+}
+
+// clientThrottledError is a call given up while waiting for the client-side rate limiter.
+type clientThrottledError struct {
+	qps float32
+	err error
+}
+
+func (e *clientThrottledError) Error() string {
+	return fmt.Sprintf("client-side throttling at %g calls per second: %v", e.qps, e.err)
+}
+
+func (e *clientThrottledError) Unwrap() error {
+	return e.err
+}
+
+// throttlingRateLimiter is a rate limiter that bounds its waits by the call timeout and
+// records long ones into the request's Throttling.
+type throttlingRateLimiter struct {
+	flowcontrol.RateLimiter
+	timeout time.Duration
+}
+
+func (l *throttlingRateLimiter) Wait(ctx context.Context) error {
+	waitCtx := ctx
+	if l.timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, l.timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	err := l.RateLimiter.Wait(waitCtx)
+	if err != nil && ctx.Err() == nil {
+		throttlingFrom(ctx).record(func(t *Throttling) { t.client = true })
+		return &clientThrottledError{qps: l.QPS(), err: err}
+	}
+	if time.Since(start) >= throttleThreshold {
+		throttlingFrom(ctx).record(func(t *Throttling) { t.client = true })
+	}
+	return err
+}
+
+// timeoutTransport bounds every Kubernetes API call but long-lived ones by a timeout, which
+// also covers reading the response. Cancelling the request cancels the call either way.
+type timeoutTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if longLived(req) {
+		return t.next.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, t.timedOut(ctx, req, err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		throttlingFrom(req.Context()).record(func(t *Throttling) {
+			t.server = true
+			if retryAfter > t.retryAfter {
+				t.retryAfter = retryAfter
+			}
+		})
+	}
+	resp.Body = &timeoutBody{ReadCloser: resp.Body, transport: t, req: req, ctx: ctx, cancel: cancel}
+	return resp, nil
+}
+
+// timedOut records and describes err when it is the call's timeout rather than the request
+// going away.
+func (t *timeoutTransport) timedOut(ctx context.Context, req *http.Request, err error) error {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) || req.Context().Err() != nil {
+		return err
+	}
+	throttlingFrom(req.Context()).record(func(t *Throttling) { t.timedOut = true })
+	return fmt.Errorf("no answer from the Kubernetes API within %s: %w", t.timeout, err)
+}
+
+// longLived reports whether a call is a watch, followed logs, a proxied request or an
+// upgraded stream.
+func longLived(req *http.Request) bool {
+	q := req.URL.Query()
+	return q.Get("watch") == "true" || q.Get("watch") == "1" || q.Get("follow") == "true" ||
+		req.Header.Get("Upgrade") != "" || strings.Contains(req.URL.Path, "/proxy")
+}
+
+// timeoutBody releases the timeout of a call once its response is read.
+type timeoutBody struct {
+	io.ReadCloser
+	transport *timeoutTransport
+	req       *http.Request
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		err = b.transport.timedOut(b.ctx, b.req, err)
+	}
+	return n, err
+}
+
+func (b *timeoutBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	netv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -80,6 +81,25 @@ func (c *Client) ListIngresses(ctx context.Context, namespace string) ([]netv1.I
 	return res.Items, nil
 }
 
+// EndpointSliceProvider lists the EndpointSlices that back a Service.
+type EndpointSliceProvider interface {
+	ListEndpointSlices(ctx context.Context, namespace, service string) ([]discoveryv1.EndpointSlice, error)
+}
+
+func (c *Client) ListEndpointSlices(ctx context.Context, namespace, service string) ([]discoveryv1.EndpointSlice, error) {
+	clientset, err := c.getClientset(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := clientset.DiscoveryV1().EndpointSlices(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: discoveryv1.LabelServiceName + "=" + service,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Items, nil
+}
+
 // Add mock methods to MockClient
 func (m *MockClient) GetIngress(ctx context.Context, namespace, name string) (*netv1.Ingress, error) {
 	return nil, fmt.Errorf("ingress %s not found in mock", name)
@@ -96,16 +116,132 @@ func (m *MockClient) GetPod(ctx context.Context, namespace, name string) (*corev
 	return nil, fmt.Errorf("pod %s not found in mock", name)
 }
 func (m *MockClient) ListServices(ctx context.Context, namespace string) ([]corev1.Service, error) {
-	return []corev1.Service{}, nil // simplify for now
+	var filtered []corev1.Service
+	for _, svc := range mockServices {
+		if namespace == "" || svc.Namespace == namespace {
+			filtered = append(filtered, svc)
+		}
+	}
+	return filtered, nil
 }
 func (m *MockClient) ListIngresses(ctx context.Context, namespace string) ([]netv1.Ingress, error) {
-	return []netv1.Ingress{}, nil // simplify for now
+	var filtered []netv1.Ingress
+	for _, ing := range mockIngresses {
+		if namespace == "" || ing.Namespace == namespace {
+			filtered = append(filtered, ing)
+		}
+	}
+	return filtered, nil
+}
+
+// NetworkLister lists Services and Ingresses as typed objects.
+type NetworkLister interface {
+	ListServices(ctx context.Context, namespace string) ([]corev1.Service, error)
+	ListIngresses(ctx context.Context, namespace string) ([]netv1.Ingress, error)
 }
 
-// TraceFlow provides a unified entrypoint for tracing network connections
-func TraceFlow(ctx context.Context, provider interface{}, resType, namespace, name string) (*TraceResponse, error) {
-	// For simplicity, we cast exactly to *Client here, allowing expansion later.
-	client, ok := provider.(*Client)
+func mockService(name, namespace string, svcType corev1.ServiceType, port, nodePort int32) corev1.Service {
+	svc := corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: corev1.ServiceSpec{
+			Type:     svcType,
+			Selector: map[string]string{"app": name},
+			Ports:    []corev1.ServicePort{{Port: port, NodePort: nodePort, Protocol: corev1.ProtocolTCP}},
+		},
+	}
+	if svcType == corev1.ServiceTypeLoadBalancer {
+		svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "34.76.120.15"}}
+	}
+	return svc
+}
+
+func mockIngress(name, namespace, host, service string, tls bool) netv1.Ingress {
+	class := "nginx"
+	pathType := netv1.PathTypePrefix
+	ing := netv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: netv1.IngressSpec{
+			IngressClassName: &class,
+			Rules: []netv1.IngressRule{{
+				Host: host,
+				IngressRuleValue: netv1.IngressRuleValue{HTTP: &netv1.HTTPIngressRuleValue{Paths: []netv1.HTTPIngressPath{{
+					Path:     "/",
+					PathType: &pathType,
+					Backend:  netv1.IngressBackend{Service: &netv1.IngressServiceBackend{Name: service, Port: netv1.ServiceBackendPort{Number: 80}}},
+				}}}},
+			}},
+		},
+		Status: netv1.IngressStatus{LoadBalancer: netv1.IngressLoadBalancerStatus{Ingress: []netv1.IngressLoadBalancerIngress{{IP: "192.168.1.100"}}}},
+	}
+	if tls {
+		ing.Spec.TLS = []netv1.IngressTLS{{Hosts: []string{host}, SecretName: name + "-tls"}}
+	}
+	return ing
+}
+
+var mockServices = []corev1.Service{
+	mockService("frontend-svc", "default", corev1.ServiceTypeClusterIP, 80, 0),
+	mockService("backend-svc", "default", corev1.ServiceTypeClusterIP, 8080, 0),
+	mockService("postgres-primary", "database", corev1.ServiceTypeClusterIP, 5432, 0),
+	mockService("postgres-debug", "database", corev1.ServiceTypeNodePort, 5432, 30432),
+	mockService("kafka-broker", "messaging", corev1.ServiceTypeClusterIP, 9092, 0),
+	mockService("prometheus", "monitoring", corev1.ServiceTypeClusterIP, 9090, 0),
+	mockService("grafana", "monitoring", corev1.ServiceTypeLoadBalancer, 80, 31080),
+	mockService("ingress-nginx-controller", "ingress-nginx", corev1.ServiceTypeLoadBalancer, 443, 30443),
+}
+
+var mockIngresses = []netv1.Ingress{
+	mockIngress("frontend-ingress", "default", "app.example.com", "frontend-svc", true),
+	mockIngress("api-ingress", "default", "api.example.com", "backend-svc", true),
+	mockIngress("grafana-ingress", "monitoring", "grafana.example.com", "grafana", false),
+}
+
+func (m *MockClient) ListEndpointSlices(ctx context.Context, namespace, service string) ([]discoveryv1.EndpointSlice, error) {
+	ready, notReady := true, false
+	port, portName, proto := int32(8080), "http", corev1.ProtocolTCP
+	zoneA, zoneB := "europe-west1-a", "europe-west1-b"
+	endpoint := func(ip, pod, node string, zone *string, isReady, terminating bool) discoveryv1.Endpoint {
+		serving := isReady || terminating
+		return discoveryv1.Endpoint{
+			Addresses:  []string{ip},
+			Conditions: discoveryv1.EndpointConditions{Ready: &isReady, Serving: &serving, Terminating: &terminating},
+			NodeName:   &node,
+			Zone:       zone,
+			TargetRef:  &corev1.ObjectReference{Kind: "Pod", Namespace: namespace, Name: pod},
+			Hints:      &discoveryv1.EndpointHints{ForZones: []discoveryv1.ForZone{{Name: *zone}}},
+		}
+	}
+	return []discoveryv1.EndpointSlice{{
+		ObjectMeta:  metav1.ObjectMeta{Name: service + "-x7k2p", Namespace: namespace, Labels: map[string]string{discoveryv1.LabelServiceName: service}},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Ports:       []discoveryv1.EndpointPort{{Name: &portName, Port: &port, Protocol: &proto}},
+		Endpoints: []discoveryv1.Endpoint{
+			endpoint("10.244.1.12", service+"-5d8f7b-abcde", "worker-01", &zoneA, ready, false),
+			endpoint("10.244.2.31", service+"-5d8f7b-fghij", "worker-02", &zoneB, ready, false),
+			endpoint("10.244.3.7", service+"-5d8f7b-klmno", "worker-03", &zoneB, notReady, false),
+			endpoint("10.244.1.40", service+"-7c6d5e-pqrst", "worker-01", &zoneA, notReady, true),
+		},
+	}}, nil
+}
+
+// tracer is a provider network traces can follow.
+type tracer interface {
+	KubernetesProvider
+	NetworkProvider
+}
+
+// TraceFlow provides a unified entrypoint for tracing network connections.
+// With mesh enabled, Istio VirtualService routing is followed between Services and Pods.
+func TraceFlow(ctx context.Context, provider KubernetesProvider, resType, namespace, name string, mesh bool) (*TraceResponse, error) {
+	client, ok := provider.(tracer)
+	if _, mock := provider.(*MockClient); mock {
+		// The mock's objects do not link up into a trace, so it returns canned ones
+		ok = false
+	}
+	if !ok && mesh {
+		trace := mockMeshTrace
+		return &trace, nil
+	}
 	if !ok {
 		// If mock, return a standard fake trace so we don't break DEV_MODE
 		return &TraceResponse{
@@ -195,7 +331,7 @@ func TraceFlow(ctx context.Context, provider interface{}, resType, namespace, na
 
 				res.Edges = append(res.Edges, TraceEdge{From: "Ingress:" + ing.Name, To: "Service:" + svcName, Healthy: true, Message: portMsg})
 
-				traceServiceToPods(ctx, client, namespace, svc, res)
+				traceBackend(ctx, client, namespace, svc, res, mesh)
 			}
 		}
 
@@ -244,7 +380,18 @@ func TraceFlow(ctx context.Context, provider interface{}, resType, namespace, na
 			}
 		}
 
-		traceServiceToPods(ctx, client, namespace, svc, res)
+		traceBackend(ctx, client, namespace, svc, res, mesh)
+
+	case "virtualservice", "virtualservices", "virtual-services":
+		dyn, err := client.GetDynamicClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		vs, err := dyn.Resource(virtualServicesGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		traceVirtualService(ctx, client, vs, res)
 
 	case "pod", "pods":
 		pod, err := client.GetPod(ctx, namespace, name)
@@ -311,7 +458,7 @@ func TraceFlow(ctx context.Context, provider interface{}, resType, namespace, na
 	return deduplicateTrace(res), nil
 }
 
-func traceServiceToPods(ctx context.Context, client *Client, namespace string, svc *corev1.Service, res *TraceResponse) {
+func traceServiceToPods(ctx context.Context, client tracer, namespace string, svc *corev1.Service, res *TraceResponse) {
 	pods, _ := client.ListPods(ctx, namespace)
 	matched := 0
 	for _, pod := range pods {
@@ -0,0 +1,62 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// NodeLogReader reads logs from a node through the kubelet's /logs/ endpoint, proxied by the
+// API server (nodes/proxy). path is a file or directory under /var/log; query carries the
+// NodeLogQuery parameters (query, tailLines, sinceTime, pattern, boot).
+type NodeLogReader interface {
+	NodeLogs(ctx context.Context, node, path string, query url.Values) ([]byte, error)
+}
+
+func (c *Client) NodeLogs(ctx context.Context, node, path string, query url.Values) ([]byte, error) {
+	clientset, err := c.getClientset(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// A single AbsPath segment keeps the trailing slash the kubelet needs for /logs/
+	req := clientset.CoreV1().RESTClient().Get().
+		AbsPath("/api/v1/nodes/" + url.PathEscape(node) + "/proxy/logs/" + strings.TrimPrefix(path, "/"))
+	for key, values := range query {
+		for _, v := range values {
+			req = req.Param(key, v)
+		}
+	}
+	return req.DoRaw(ctx)
+}
+
+// NodeLogs mock implementation for DEV_MODE: a /var/log listing and a short kubelet journal.
+func (m *MockClient) NodeLogs(_ context.Context, node, path string, query url.Values) ([]byte, error) {
+	if path == "" && query.Get("query") == "" {
+		return []byte(`<pre>
+<a href="containers/">containers/</a>
+<a href="pods/">pods/</a>
+<a href="journal/">journal/</a>
+<a href="syslog">syslog</a>
+<a href="kern.log">kern.log</a>
+<a href="dpkg.log">dpkg.log</a>
+</pre>`), nil
+	}
+	source := query.Get("query")
+	if source == "" {
+		source = path
+	}
+	now := time.Now().UTC()
+	var b strings.Builder
+	for i := 10; i > 0; i-- {
+		t := now.Add(-time.Duration(i) * 37 * time.Second).Format(time.Stamp)
+		switch {
+		case i == 4:
+			fmt.Fprintf(&b, "%s %s %s[1234]: E0101 kubelet.go:2874] \"Container runtime network not ready\" networkReady=\"NetworkReady=false\"\n", t, node, source)
+		default:
+			fmt.Fprintf(&b, "%s %s %s[1234]: I0101 kubelet_node_status.go:%d] \"Node status updated\" node=%q\n", t, node, source, 480+i, node)
+		}
+	}
+	return []byte(b.String()), nil
+}
@@ -0,0 +1,48 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PreviousLogReader reads the logs of the previous instance of a container, which the kubelet
+// keeps until the container restarts again.
+type PreviousLogReader interface {
+	GetPreviousPodLogs(ctx context.Context, namespace, pod, container string, tailLines int64) (string, error)
+}
+
+func (c *Client) GetPreviousPodLogs(ctx context.Context, namespace, pod, container string, tailLines int64) (string, error) {
+	clientset, err := c.getClientset(ctx)
+	if err != nil {
+		return "", err
+	}
+	if tailLines == 0 {
+		tailLines = 1000
+	}
+	readCloser, err := clientset.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container: container,
+		TailLines: &tailLines,
+		Previous:  true,
+	}).Stream(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer readCloser.Close()
+
+	data, err := io.ReadAll(readCloser)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// GetPreviousPodLogs mock implementation for DEV_MODE: the run that ended in a crash.
+func (m *MockClient) GetPreviousPodLogs(_ context.Context, _, _, container string, _ int64) (string, error) {
+	return fmt.Sprintf("2024-02-18 09:58:10 [info] Starting %s...\n2024-02-18 09:58:11 [info] Configuration loaded.\n"+
+		"2024-02-18 09:58:14 [warn] Database connection attempt 1 failed, retrying\n"+
+		"2024-02-18 09:58:44 [error] Database unreachable after 3 attempts\n"+
+		"panic: dial tcp 10.96.14.2:5432: connect: connection refused\n\ngoroutine 1 [running]:\nmain.main()\n\t/src/main.go:57 +0x1a5\n", container), nil
+}
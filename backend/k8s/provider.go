@@ -0,0 +1,117 @@
+package k8s
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+// PodProvider lists pods, reads their logs and metrics and opens shells in them.
+type PodProvider interface {
+	ListPods(ctx context.Context, namespace string) ([]corev1.Pod, error)
+	GetPodLogs(ctx context.Context, namespace, pod, container string, tailLines int64) (string, error)
+	GetPodMetrics(ctx context.Context, namespace, pod string) (map[string]interface{}, error)
+	Exec(ctx context.Context, namespace, pod, container, shell string, pty PtyHandler) error
+}
+
+// NamespaceProvider lists the names of the namespaces.
+type NamespaceProvider interface {
+	ListNamespaces(ctx context.Context) ([]string, error)
+}
+
+// NodeProvider lists the nodes.
+type NodeProvider interface {
+	ListNodes(ctx context.Context) ([]corev1.Node, error)
+}
+
+// DynamicProvider returns a dynamic client acting as the user of ctx, for any resource.
+type DynamicProvider interface {
+	GetDynamicClient(ctx context.Context) (dynamic.Interface, error)
+}
+
+// NetworkProvider reads the typed objects network traces follow.
+type NetworkProvider interface {
+	NetworkLister
+	EndpointSliceProvider
+	GetIngress(ctx context.Context, namespace, name string) (*netv1.Ingress, error)
+	GetService(ctx context.Context, namespace, name string) (*corev1.Service, error)
+	GetPod(ctx context.Context, namespace, name string) (*corev1.Pod, error)
+}
+
+// KubernetesProvider is what every provider implements, real or mock, and what handlers are
+// given. Everything else is an optional capability: handlers type-assert the interface they
+// need and degrade when a provider lacks it, so a new capability is added as its own
+// interface and registered below rather than added here, where every provider would have to
+// implement it at once.
+type KubernetesProvider interface {
+	PodProvider
+	NamespaceProvider
+	NodeProvider
+	DynamicProvider
+}
+
+// capabilities are the optional provider interfaces, by name.
+var capabilities = map[string]func(KubernetesProvider) bool{}
+
+// RegisterCapability adds an optional provider interface to the capabilities Capabilities
+// reports.
+func RegisterCapability[T any](name string) {
+	capabilities[name] = func(p KubernetesProvider) bool {
+		_, ok := p.(T)
+		return ok
+	}
+}
+
+func init() {
+	RegisterCapability[NetworkProvider]("network")
+	RegisterCapability[ClusterInfoProvider]("cluster-info")
+	RegisterCapability[Attacher]("attach")
+	RegisterCapability[CommandExecutor]("exec-command")
+	RegisterCapability[PreviousLogReader]("previous-logs")
+	RegisterCapability[NodeLogReader]("node-logs")
+	RegisterCapability[ImagePlatformResolver]("image-platforms")
+	RegisterCapability[ServiceProxier]("service-proxy")
+	RegisterCapability[RoleBinder]("role-bindings")
+	RegisterCapability[ServiceAccountIssuer]("service-account-tokens")
+}
+
+// Capabilities returns the names of the optional interfaces a provider implements, sorted.
+func Capabilities(p KubernetesProvider) []string {
+	var names []string
+	for name, supported := range capabilities {
+		if supported(p) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// The capabilities of each provider, checked at compile time. The mock has no RBAC bindings
+// or service account tokens to manage.
+var (
+	_ KubernetesProvider    = (*Client)(nil)
+	_ NetworkProvider       = (*Client)(nil)
+	_ ClusterInfoProvider   = (*Client)(nil)
+	_ Attacher              = (*Client)(nil)
+	_ CommandExecutor       = (*Client)(nil)
+	_ PreviousLogReader     = (*Client)(nil)
+	_ NodeLogReader         = (*Client)(nil)
+	_ ImagePlatformResolver = (*Client)(nil)
+	_ ServiceProxier        = (*Client)(nil)
+	_ RoleBinder            = (*Client)(nil)
+	_ ServiceAccountIssuer  = (*Client)(nil)
+
+	_ KubernetesProvider    = (*MockClient)(nil)
+	_ NetworkProvider       = (*MockClient)(nil)
+	_ ClusterInfoProvider   = (*MockClient)(nil)
+	_ Attacher              = (*MockClient)(nil)
+	_ CommandExecutor       = (*MockClient)(nil)
+	_ PreviousLogReader     = (*MockClient)(nil)
+	_ NodeLogReader         = (*MockClient)(nil)
+	_ ImagePlatformResolver = (*MockClient)(nil)
+	_ ServiceProxier        = (*MockClient)(nil)
+)
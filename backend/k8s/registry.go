@@ -0,0 +1,204 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ImagePlatformResolver looks up the platforms (os/arch) an image is published for.
+// Only anonymous registry access is supported; private images return an error.
+type ImagePlatformResolver interface {
+	ImagePlatforms(ctx context.Context, registry, repository, reference string) ([]string, error)
+}
+
+const manifestAccept = "application/vnd.oci.image.index.v1+json, application/vnd.docker.distribution.manifest.list.v2+json, " +
+	"application/vnd.oci.image.manifest.v1+json, application/vnd.docker.distribution.manifest.v2+json"
+
+type platformCacheEntry struct {
+	platforms []string
+	err       error
+	fetched   time.Time
+}
+
+var (
+	platformCache    = map[string]platformCacheEntry{}
+	platformCacheMu  sync.Mutex
+	platformCacheTTL = time.Hour
+	registryClient   = &http.Client{Timeout: 10 * time.Second}
+	challengeParam   = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+func (c *Client) ImagePlatforms(ctx context.Context, registry, repository, reference string) ([]string, error) {
+	key := registry + "/" + repository + "@" + reference
+	platformCacheMu.Lock()
+	entry, ok := platformCache[key]
+	platformCacheMu.Unlock()
+	if ok && time.Since(entry.fetched) < platformCacheTTL {
+		return entry.platforms, entry.err
+	}
+
+	platforms, err := fetchImagePlatforms(ctx, registry, repository, reference)
+	platformCacheMu.Lock()
+	platformCache[key] = platformCacheEntry{platforms: platforms, err: err, fetched: time.Now()}
+	platformCacheMu.Unlock()
+	return platforms, err
+}
+
+// fetchImagePlatforms reads the image manifest. An index lists one entry per platform; a
+// single-platform manifest requires reading its config blob to learn the architecture.
+func fetchImagePlatforms(ctx context.Context, registry, repository, reference string) ([]string, error) {
+	host := registry
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	base := "https://" + host + "/v2/" + repository
+
+	var manifest struct {
+		MediaType string `json:"mediaType"`
+		Manifests []struct {
+			Platform struct {
+				OS           string `json:"os"`
+				Architecture string `json:"architecture"`
+				Variant      string `json:"variant"`
+			} `json:"platform"`
+		} `json:"manifests"`
+		Config struct {
+			Digest string `json:"digest"`
+		} `json:"config"`
+	}
+	token, err := registryGet(ctx, base+"/manifests/"+reference, manifestAccept, "", &manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	var platforms []string
+	if len(manifest.Manifests) > 0 {
+		for _, m := range manifest.Manifests {
+			// Attestation manifests are published as unknown/unknown
+			if m.Platform.OS == "" || m.Platform.OS == "unknown" {
+				continue
+			}
+			p := m.Platform.OS + "/" + m.Platform.Architecture
+			if m.Platform.Variant != "" {
+				p += "/" + m.Platform.Variant
+			}
+			platforms = append(platforms, p)
+		}
+		return platforms, nil
+	}
+	if manifest.Config.Digest == "" {
+		return nil, fmt.Errorf("manifest for %s has neither platforms nor a config", repository)
+	}
+	var config struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+	}
+	if _, err := registryGet(ctx, base+"/blobs/"+manifest.Config.Digest, "*/*", token, &config); err != nil {
+		return nil, err
+	}
+	return []string{config.OS + "/" + config.Architecture}, nil
+}
+
+// registryGet performs a GET against the registry, answering a Bearer challenge with an
+// anonymous token. It returns the token used so follow-up requests can reuse it.
+func registryGet(ctx context.Context, url, accept, token string, out interface{}) (string, error) {
+	do := func(token string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", accept)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return registryClient.Do(req)
+	}
+
+	resp, err := do(token)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized && token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if token, err = anonymousToken(ctx, challenge); err != nil {
+			return "", err
+		}
+		if resp, err = do(token); err != nil {
+			return "", err
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry returned %s for %s", resp.Status, url)
+	}
+	return token, json.NewDecoder(resp.Body).Decode(out)
+}
+
+// anonymousToken exchanges a `Bearer realm="...",service="...",scope="..."` challenge for a pull token.
+func anonymousToken(ctx context.Context, challenge string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("registry requires credentials")
+	}
+	params := map[string]string{}
+	for _, m := range challengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[m[1]] = m[2]
+	}
+	if params["realm"] == "" {
+		return "", fmt.Errorf("registry sent an invalid auth challenge")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params["realm"], nil)
+	if err != nil {
+		return "", err
+	}
+	q := req.URL.Query()
+	for _, k := range []string{"service", "scope"} {
+		if params[k] != "" {
+			q.Set(k, params[k])
+		}
+	}
+	req.URL.RawQuery = q.Encode()
+	resp, err := registryClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry requires credentials (token endpoint returned %s)", resp.Status)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// mockSingleArchImages are images the mock registry publishes for amd64 only.
+var mockSingleArchImages = map[string]bool{
+	"registry.example.com/worker":       true,
+	"bitnami/pgbouncer":                 true,
+	"registry.example.com/auth-service": true,
+}
+
+func (m *MockClient) ImagePlatforms(_ context.Context, registry, repository, _ string) ([]string, error) {
+	name := strings.TrimPrefix(registry+"/"+repository, "docker.io/")
+	if mockSingleArchImages[name] {
+		return []string{"linux/amd64"}, nil
+	}
+	if registry == "registry.example.com" && repository == "backend-api" {
+		return nil, fmt.Errorf("registry requires credentials")
+	}
+	return []string{"linux/amd64", "linux/arm64"}, nil
+}
@@ -0,0 +1,106 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RoleBinder keeps Kubernetes RBAC bindings in line with the roles granted in K-View.
+type RoleBinder interface {
+	Bind(ctx context.Context, namespace, name, clusterRole string, users, groups []string) error
+	Unbind(ctx context.Context, namespace, name string) error
+}
+
+// errUnmanagedBinding is returned for bindings of the requested name that K-View did not create.
+var errUnmanagedBinding = errors.New("exists and is not managed by K-View")
+
+// Bind binds clusterRole to users and groups with a binding K-View manages, so Kubernetes
+// RBAC matches a role granted in K-View: a RoleBinding inside namespace, or a
+// ClusterRoleBinding when namespace is empty. A binding of that name K-View does not manage is
+// left alone and reported as an error.
+func (c *Client) Bind(ctx context.Context, namespace, name, clusterRole string, users, groups []string) error {
+	clientset, err := c.getClientset(ctx)
+	if err != nil {
+		return err
+	}
+	labels := map[string]string{managedLabel: "k-view"}
+	var subjects []rbacv1.Subject
+	for _, u := range users {
+		subjects = append(subjects, rbacv1.Subject{APIGroup: rbacv1.GroupName, Kind: rbacv1.UserKind, Name: u})
+	}
+	for _, g := range groups {
+		subjects = append(subjects, rbacv1.Subject{APIGroup: rbacv1.GroupName, Kind: rbacv1.GroupKind, Name: g})
+	}
+	roleRef := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: clusterRole}
+
+	if namespace == "" {
+		binding := &rbacv1.ClusterRoleBinding{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}, Subjects: subjects, RoleRef: roleRef}
+		bindings := clientset.RbacV1().ClusterRoleBindings()
+		existing, getErr := bindings.Get(ctx, name, metav1.GetOptions{})
+		if getErr == nil && existing.Labels[managedLabel] != "k-view" {
+			return fmt.Errorf("cluster role binding %s %w", name, errUnmanagedBinding)
+		}
+		// roleRef is immutable, so a changed role requires recreating the binding
+		if getErr == nil && existing.RoleRef != roleRef {
+			_ = bindings.Delete(ctx, name, metav1.DeleteOptions{})
+		}
+		if _, err = bindings.Create(ctx, binding, metav1.CreateOptions{}); apierrors.IsAlreadyExists(err) {
+			_, err = bindings.Update(ctx, binding, metav1.UpdateOptions{})
+		}
+	} else {
+		binding := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels}, Subjects: subjects, RoleRef: roleRef}
+		bindings := clientset.RbacV1().RoleBindings(namespace)
+		existing, getErr := bindings.Get(ctx, name, metav1.GetOptions{})
+		if getErr == nil && existing.Labels[managedLabel] != "k-view" {
+			return fmt.Errorf("role binding %s/%s %w", namespace, name, errUnmanagedBinding)
+		}
+		if getErr == nil && existing.RoleRef != roleRef {
+			_ = bindings.Delete(ctx, name, metav1.DeleteOptions{})
+		}
+		if _, err = bindings.Create(ctx, binding, metav1.CreateOptions{}); apierrors.IsAlreadyExists(err) {
+			_, err = bindings.Update(ctx, binding, metav1.UpdateOptions{})
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to bind role: %v", err)
+	}
+	return nil
+}
+
+// Unbind deletes a binding created by Bind. A missing binding is not an error; one K-View does
+// not manage is left alone.
+func (c *Client) Unbind(ctx context.Context, namespace, name string) error {
+	clientset, err := c.getClientset(ctx)
+	if err != nil {
+		return err
+	}
+	var existing metav1.Object
+	if namespace == "" {
+		existing, err = clientset.RbacV1().ClusterRoleBindings().Get(ctx, name, metav1.GetOptions{})
+	} else {
+		existing, err = clientset.RbacV1().RoleBindings(namespace).Get(ctx, name, metav1.GetOptions{})
+	}
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read role binding: %v", err)
+	}
+	if existing.GetLabels()[managedLabel] != "k-view" {
+		return fmt.Errorf("role binding %s %w", name, errUnmanagedBinding)
+	}
+	if namespace == "" {
+		err = clientset.RbacV1().ClusterRoleBindings().Delete(ctx, name, metav1.DeleteOptions{})
+	} else {
+		err = clientset.RbacV1().RoleBindings(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	}
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete role binding: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"k8s.io/client-go/rest"
+)
+
+// ServiceProxier forwards HTTP requests to a Service through the API server's service proxy
+// (services/proxy), as kubectl proxy does, so the user's Kubernetes RBAC applies. port is a
+// port name or number. The returned handler forwards each request's URL path to the Service;
+// externalPrefix is the URL the handler is reachable under, used to rewrite the links and
+// redirects the API server points at itself.
+type ServiceProxier interface {
+	ServiceProxy(ctx context.Context, namespace, service, port, externalPrefix string) (http.Handler, error)
+}
+
+// kviewCookies are K-View's own cookies, never passed on to proxied services.
+var kviewCookies = map[string]bool{"auth_token": true, "oauthstate": true}
+
+func (c *Client) ServiceProxy(ctx context.Context, namespace, service, port, externalPrefix string) (http.Handler, error) {
+	config := c.GetConfig(ctx)
+	transport, err := rest.TransportFor(config)
+	if err != nil {
+		return nil, err
+	}
+	host, err := url.Parse(config.Host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API server URL: %v", err)
+	}
+	upstreamPrefix := strings.TrimSuffix(host.Path, "/") + "/api/v1/namespaces/" + url.PathEscape(namespace) +
+		"/services/" + url.PathEscape(service) + ":" + url.PathEscape(port) + "/proxy"
+
+	return &httputil.ReverseProxy{
+		Transport: transport,
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.URL.Scheme, pr.Out.URL.Host = host.Scheme, host.Host
+			pr.Out.URL.Path = upstreamPrefix + pr.In.URL.Path
+			pr.Out.URL.RawPath = ""
+			pr.Out.Host = ""
+			// The transport authenticates to the API server; K-View's own credentials stay here
+			pr.Out.Header.Del("Authorization")
+			stripCookies(pr.Out.Header)
+			query := pr.Out.URL.Query()
+			if query.Has("token") {
+				query.Del("token")
+				pr.Out.URL.RawQuery = query.Encode()
+			}
+			// Let the transport negotiate compression, so HTML arrives decoded for rewriting
+			pr.Out.Header.Del("Accept-Encoding")
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			return rewriteProxyResponse(resp, upstreamPrefix, externalPrefix)
+		},
+	}, nil
+}
+
+// stripCookies removes K-View's cookies from a request, keeping the proxied service's own.
+func stripCookies(h http.Header) {
+	cookies := (&http.Request{Header: h}).Cookies()
+	h.Del("Cookie")
+	var kept []string
+	for _, ck := range cookies {
+		if !kviewCookies[ck.Name] {
+			kept = append(kept, ck.Name+"="+ck.Value)
+		}
+	}
+	if len(kept) > 0 {
+		h.Set("Cookie", strings.Join(kept, "; "))
+	}
+}
+
+// rewriteProxyResponse points redirects and the links the API server rewrote in HTML pages at
+// K-View's proxy path instead of the API server's.
+func rewriteProxyResponse(resp *http.Response, upstreamPrefix, externalPrefix string) error {
+	if loc := resp.Header.Get("Location"); strings.HasPrefix(loc, upstreamPrefix) {
+		resp.Header.Set("Location", externalPrefix+strings.TrimPrefix(loc, upstreamPrefix))
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") || resp.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	body = bytes.ReplaceAll(body, []byte(upstreamPrefix), []byte(externalPrefix))
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
+
+// ServiceProxy mock implementation for DEV_MODE: a page describing the request it received.
+func (m *MockClient) ServiceProxy(_ context.Context, namespace, service, port, externalPrefix string) (http.Handler, error) {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<!doctype html><title>%s</title><h1>%s.%s:%s</h1><p>%s %s</p><p><a href=\"%s/\">Home</a></p>\n",
+			html.EscapeString(service), html.EscapeString(service), html.EscapeString(namespace), html.EscapeString(port),
+			html.EscapeString(r.Method), html.EscapeString(r.URL.RequestURI()), html.EscapeString(externalPrefix))
+	}), nil
+}
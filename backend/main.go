@@ -1,11 +1,21 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
+	"k-view/config"
 	"k-view/handlers"
 	"k-view/k8s"
+	"k-view/policy"
+	"k-view/store"
+	"k-view/tracing"
+	"k-view/web"
 
 	"github.com/gin-gonic/gin"
 	"bufio"
@@ -34,28 +44,90 @@ func loadEnv(path string) {
 	}
 }
 
+// durationEnv reads a positive duration from the environment, falling back to def when the
+// variable is unset or invalid.
+func durationEnv(name string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(name)); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
+
 func main() {
 	loadEnv(".env")
 
+	// Settings from config.yaml, for the environment variables that are not set
+	cfg, err := config.Load(os.Getenv("KVIEW_CONFIG_PATH"))
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if cfg.Path != "" {
+		log.Printf("Loaded configuration from %s", cfg.Path)
+	}
+
+	// Subpath K-View is hosted under behind a shared ingress, e.g. /k-view
+	basePath, err := web.NormalizeBasePath(os.Getenv("KVIEW_BASE_PATH"))
+	if err != nil {
+		log.Fatalf("Failed to read KVIEW_BASE_PATH: %v", err)
+	}
+
 	devMode := os.Getenv("DEV_MODE") == "true"
 	if devMode {
 		log.Println("⚠️  DEVELOPMENT MODE ENABLED — Do not use in production!")
 	}
 
+	// OpenTelemetry tracing, exported over OTLP/HTTP when OTEL_EXPORTER_OTLP_ENDPOINT is set
+	if endpoint, err := tracing.InitFromEnv(); err != nil {
+		log.Fatalf("Failed to initialize tracing: %v", err)
+	} else if endpoint != "" {
+		log.Printf("Exporting traces to %s", endpoint)
+	}
+
 	// Stateless execution natively requires no DB init.
 
+	// Timeout and client-side rate limit of every Kubernetes API call
+	callLimits := k8s.DefaultCallLimits
+	if v := os.Getenv("KVIEW_K8S_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			callLimits.Timeout = d
+		}
+	}
+	if v := os.Getenv("KVIEW_K8S_QPS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			callLimits.QPS = float32(n)
+		}
+	}
+	if v := os.Getenv("KVIEW_K8S_BURST"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			callLimits.Burst = n
+		}
+	}
+
 	// Initialize Kubernetes Provider (real or mock based on DEV_MODE)
 	var k8sProvider k8s.KubernetesProvider
 	if devMode {
 		log.Println("Using mock Kubernetes provider")
 		k8sProvider = k8s.NewMockClient()
 	} else {
-		realClient, err := k8s.NewClient()
+		realClient, err := k8s.NewClient(callLimits)
 		if err != nil {
 			log.Fatalf("Failed to initialize Kubernetes client: %v", err)
 		}
 		k8sProvider = realClient
 	}
+	log.Printf("Kubernetes provider capabilities: %s", strings.Join(k8s.Capabilities(k8sProvider), ", "))
+
+	// Remote clusters for comparisons, one kubeconfig context each
+	clusterName := os.Getenv("KVIEW_CLUSTER_NAME")
+	if clusterName == "" {
+		clusterName = "local"
+	}
+	var clusters *k8s.Clusters
+	if devMode {
+		clusters = k8s.NewMockClusters(clusterName)
+	} else if clusters, err = k8s.LoadClusters(clusterName, k8sProvider, os.Getenv("KVIEW_CLUSTERS_KUBECONFIG"), callLimits); err != nil {
+		log.Fatalf("Failed to load remote clusters: %v", err)
+	}
 
 	// Initialize Auth Handler (skips OIDC setup in DEV_MODE)
 	authHandler, err := handlers.NewAuthHandler()
@@ -63,27 +135,206 @@ func main() {
 		log.Fatalf("Failed to initialize Auth handler: %v", err)
 	}
 
+	// Policy checks applied to YAML edits (built-in defaults if no file is mounted)
+	policyPath := os.Getenv("KVIEW_POLICY_CONFIG_PATH")
+	if policyPath == "" {
+		policyPath = "/etc/kview/policy/policies.yaml"
+	}
+	policyConfig, err := policy.LoadConfig(policyPath)
+	if err != nil {
+		log.Fatalf("Failed to load policy config: %v", err)
+	}
+
+	// Extra JSONPath columns of resource lists, and custom resources to list with them
+	columnsPath := os.Getenv("KVIEW_COLUMNS_CONFIG_PATH")
+	if columnsPath == "" {
+		columnsPath = "/etc/kview/columns/columns.yaml"
+	}
+	columnConfig, err := handlers.LoadColumnConfig(columnsPath)
+	if err != nil {
+		log.Fatalf("Failed to load columns config: %v", err)
+	}
+
+	// K-View's own state lives in the data volume (/data in the Helm chart), or in Postgres or
+	// MySQL when a database URL is set, so replicas need no shared volume
+	dataDir := os.Getenv("KVIEW_DATA_DIR")
+	if dataDir == "" {
+		dataDir = "/data"
+		if devMode {
+			dataDir = filepath.Join(os.TempDir(), "k-view")
+		}
+	}
+	var dataStore *store.Store
+	if databaseURL := os.Getenv("KVIEW_DATABASE_URL"); databaseURL != "" {
+		dataStore, err = store.OpenDatabase(databaseURL)
+	} else {
+		dataStore, err = store.Open(dataDir)
+	}
+	if err != nil {
+		log.Fatalf("Failed to open data store: %v", err)
+	}
+	if err := dataStore.Migrate(store.Migrations); err != nil {
+		log.Fatalf("Failed to migrate data store: %v", err)
+	}
+
+	// How long the history kept in the data store is retained, enforced by a periodic pruner
+	retention := handlers.RetentionPolicy{
+		AuditLog: durationEnv("KVIEW_AUDIT_RETENTION", 90*24*time.Hour),
+		SlowLog:  durationEnv("KVIEW_SLOWLOG_RETENTION", 7*24*time.Hour),
+		Events:   durationEnv("KVIEW_EVENT_RETENTION", 7*24*time.Hour),
+		Sessions: durationEnv("KVIEW_SESSION_RETENTION", 24*time.Hour),
+	}
+
+	// Audit events are kept in the data store for export, and optionally sent to syslog
+	auditLog, err := handlers.NewAuditLog(dataStore, os.Getenv("KVIEW_AUDIT_SYSLOG"), os.Getenv("KVIEW_AUDIT_SYSLOG_FORMAT"))
+	if err != nil {
+		log.Fatalf("Invalid audit syslog settings: %v", err)
+	}
+	handlers.SetAuditLog(auditLog)
+
+	maintenanceHandler := handlers.NewMaintenanceHandler(dataStore)
+	elevationHandler := handlers.NewElevationHandler(dataStore)
+	authHandler.SetElevations(elevationHandler)
+	revocations := handlers.NewSessionRevocations(dataStore)
+	authHandler.SetRevocations(revocations)
+	pruner := handlers.NewPruner(dataStore, retention, revocations)
+	go pruner.Run(context.Background(), durationEnv("KVIEW_PRUNE_INTERVAL", time.Hour))
+	backupHandler := handlers.NewBackupHandler(dataStore)
+	configHandler := handlers.NewConfigHandler(cfg)
+	mfaHandler := handlers.NewMFAHandler(dataStore, authHandler)
+	authHandler.SetMFA(mfaHandler)
+	localUserHandler := handlers.NewLocalUserHandler(dataStore, authHandler)
+	tokenHandler := handlers.NewTokenHandler(dataStore)
+	authHandler.SetTokens(tokenHandler)
+	readOnlyMode := handlers.NewReadOnlyMode(dataStore, os.Getenv("KVIEW_READ_ONLY") == "true")
+	featureFlags, err := handlers.NewFeatureFlags(dataStore, os.Getenv("KVIEW_DISABLED_FEATURES"))
+	if err != nil {
+		log.Fatalf("Invalid KVIEW_DISABLED_FEATURES: %v", err)
+	}
+	authHandler.SetFeatures(featureFlags)
+	authHandler.SetBasePath(basePath)
 	podHandler := handlers.NewPodHandler(k8sProvider)
 	nodeHandler := handlers.NewNodeHandler(k8sProvider)
-	consoleHandler := handlers.NewConsoleHandler(devMode)
-	resourceHandler := handlers.NewResourceHandler(devMode, k8sProvider)
-	rbacHandler := handlers.NewRBACHandler(authHandler.GetRBACConfig())
+	compareHandler := handlers.NewCompareHandler(devMode, clusters)
+	serviceProxyHandler := handlers.NewServiceProxyHandler(k8sProvider, os.Getenv("KVIEW_SERVICE_PROXY_ROLES"), basePath)
+	consoleHandler := handlers.NewConsoleHandler(devMode, policyConfig)
+	slackHandler := handlers.NewSlackHandler(consoleHandler, authHandler, readOnlyMode, os.Getenv("KVIEW_SLACK_SIGNING_SECRET"))
+	resourceHandler := handlers.NewResourceHandler(devMode, k8sProvider, policyConfig, maintenanceHandler, dataStore)
+	resourceHandler.SetColumns(columnConfig)
+	rbacHandler := handlers.NewRBACHandler(authHandler.GetRBACConfig(), devMode, k8sProvider)
+	accessRequestHandler := handlers.NewAccessRequestHandler(dataStore, authHandler.GetRBACConfig(), k8sProvider)
+	teamHandler := handlers.NewTeamHandler(dataStore, authHandler.GetRBACConfig(), k8sProvider)
+	// Replicas sharing the data store pick up each other's changes to the per-request caches
+	cacheRefresher := handlers.NewCacheRefresher(dataStore, tokenHandler, elevationHandler, revocations, mfaHandler, localUserHandler, featureFlags, readOnlyMode, teamHandler, accessRequestHandler)
+	go cacheRefresher.Run(context.Background(), durationEnv("KVIEW_CACHE_REFRESH_INTERVAL", 5*time.Second))
+	rbacHandler.SetTeams(teamHandler)
+	rbacHandler.SetOIDC(authHandler.OIDCClient())
 	networkHandler := handlers.NewNetworkHandler(k8sProvider)
-	execHandler := handlers.NewExecHandler(k8sProvider)
+	// Terminal limits: idle sessions are closed and each user gets a handful of concurrent shells
+	terminalConfig := handlers.TerminalConfig{
+		IdleTimeout:        15 * time.Minute,
+		MaxPerUser:         5,
+		NodeShellNamespace: "kube-system",
+		NodeShellImage:     "busybox:1.36",
+	}
+	if v := os.Getenv("KVIEW_TERMINAL_IDLE_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			terminalConfig.IdleTimeout = d
+		}
+	}
+	if v := os.Getenv("KVIEW_TERMINAL_MAX_SESSIONS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			terminalConfig.MaxPerUser = n
+		}
+	}
+	if v := os.Getenv("KVIEW_NODE_SHELL_NAMESPACE"); v != "" {
+		terminalConfig.NodeShellNamespace = v
+	}
+	if v := os.Getenv("KVIEW_NODE_SHELL_IMAGE"); v != "" {
+		terminalConfig.NodeShellImage = v
+	}
+	execHandler := handlers.NewExecHandler(devMode, k8sProvider, terminalConfig)
+	imageHandler := handlers.NewImageHandler(k8sProvider)
+	diagnosticsHandler := handlers.NewDiagnosticsHandler(devMode, k8sProvider)
+	templateHandler := handlers.NewTemplateHandler(devMode, k8sProvider, policyConfig)
+	dashboardHandler := handlers.NewDashboardHandler(dataStore, authHandler.GetRBACConfig())
+	scalingHandler := handlers.NewScalingHandler(devMode, k8sProvider, dataStore, readOnlyMode)
+	go scalingHandler.RunScheduler(context.Background())
 
-	router := gin.Default()
+	// Scheduled cluster reports, mailed through an SMTP relay
+	mailer := handlers.NewMailer(os.Getenv("KVIEW_SMTP_ADDR"), os.Getenv("KVIEW_SMTP_USERNAME"), os.Getenv("KVIEW_SMTP_PASSWORD"), os.Getenv("KVIEW_SMTP_FROM"))
+	reportHandler := handlers.NewReportHandler(resourceHandler, k8sProvider, dataStore, mailer)
+	go reportHandler.RunScheduler(context.Background())
+
+	// Optional event archive: keeps events beyond the API server's ~1h TTL
+	eventArchive := handlers.NewEventArchive(devMode, os.Getenv("KVIEW_EVENT_ARCHIVE") == "true", k8sProvider, dataStore, retention.Events)
+	if os.Getenv("KVIEW_EVENT_ARCHIVE") == "true" && !devMode {
+		go eventArchive.RunRecorder(context.Background())
+	}
+
+	// Outbound webhooks for resource lifecycle changes
+	notifier := handlers.NewNotifier(devMode, k8sProvider, dataStore, maintenanceHandler)
+	if !devMode {
+		go notifier.RunWatcher(context.Background())
+	}
+
+	// Slow request log: requests over their latency budget, with the Kubernetes calls they made
+	slowBudget := 2 * time.Second
+	if v := os.Getenv("KVIEW_SLOW_REQUEST_THRESHOLD"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			slowBudget = d
+		}
+	}
+	routeBudgets, err := handlers.ParseRouteBudgets(os.Getenv("KVIEW_SLOW_ROUTE_THRESHOLDS"))
+	if err != nil {
+		log.Fatalf("Invalid KVIEW_SLOW_ROUTE_THRESHOLDS: %v", err)
+	}
+	slowLog := handlers.NewSlowLog(dataStore, slowBudget, routeBudgets)
 
-	// Serve static frontend assets (JS, CSS, images compiled by Vite)
-	router.Static("/assets", "./web/dist/assets")
+	// Request, login and terminal counts for the admin dashboard (in-memory, last 24h)
+	usageStats := handlers.NewUsageStats(execHandler)
+	authHandler.SetStats(usageStats)
 
-	// SPA catch-all: any path that is not an API route will serve index.html,
-	// allowing React Router to handle client-side routing (e.g. /admin, /login).
-	router.NoRoute(func(c *gin.Context) {
-		c.File("./web/dist/index.html")
-	})
+	// Usage history for idle workload detection (kubelet stats summary, in-memory)
+	if !devMode {
+		interval := 5 * time.Minute
+		if v := os.Getenv("KVIEW_USAGE_SAMPLE_INTERVAL"); v != "" {
+			if d, err := time.ParseDuration(v); err == nil && d > 0 {
+				interval = d
+			}
+		}
+		go diagnosticsHandler.RunUsageSampler(context.Background(), interval)
+	}
+
+	// Optional kube-state-metrics scraping for restart, scheduling and availability trends
+	var ksmCollector *handlers.KSMCollector
+	if target := os.Getenv("KVIEW_KSM_TARGET"); target != "" {
+		ksmCollector, err = handlers.NewKSMCollector(k8sProvider, target)
+		if err != nil {
+			log.Fatalf("Invalid KVIEW_KSM_TARGET: %v", err)
+		}
+		interval := time.Minute
+		if v := os.Getenv("KVIEW_KSM_INTERVAL"); v != "" {
+			if d, err := time.ParseDuration(v); err == nil && d > 0 {
+				interval = d
+			}
+		}
+		go ksmCollector.Run(context.Background(), interval)
+	}
+
+	router := gin.Default()
+	router.Use(tracing.Middleware())
+	router.Use(slowLog.Middleware())
+	router.Use(usageStats.Middleware())
+	router.Use(handlers.ThrottlingMiddleware())
+
+	// Frontend compiled by Vite and embedded in the binary, with an index.html catch-all
+	// so React Router can handle client-side routing (e.g. /admin, /login).
+	web.Register(router, basePath)
 
 	// API Routes
 	api := router.Group("/api")
+	api.Use(featureFlags.Middleware())
 	{
 		// Public Auth routes
 		api.GET("/auth/login", authHandler.Login)           // OIDC initiation
@@ -91,6 +342,12 @@ func main() {
 		api.GET("/auth/providers", authHandler.GetProviders) // Get available auth methods
 		api.GET("/auth/callback", authHandler.Callback)
 		api.POST("/auth/logout", authHandler.Logout)
+		// Sign-outs at the IdP (OpenID back-channel and front-channel logout)
+		api.POST("/auth/backchannel-logout", authHandler.BackChannelLogout)
+		api.GET("/auth/frontchannel-logout", authHandler.FrontChannelLogout)
+
+		// Slack slash command, authenticated by Slack's request signature
+		api.POST("/integrations/slack", slackHandler.HandleCommand)
 
 		// Dev-mode only: bypass SSO login
 		if devMode {
@@ -100,29 +357,229 @@ func main() {
 		// Protected routes — require a valid auth token
 		protected := api.Group("/")
 		protected.Use(authHandler.AuthMiddleware())
+		protected.Use(readOnlyMode.Middleware())
 		{
 			// /auth/me needs to be here so AuthMiddleware populates the email context
 			protected.GET("/auth/me", authHandler.Me)
+			protected.GET("/auth/mfa", mfaHandler.Status)
+			protected.POST("/auth/mfa/setup", mfaHandler.Setup)
+			protected.POST("/auth/mfa/confirm", mfaHandler.Confirm)
+			protected.DELETE("/auth/mfa", mfaHandler.Disable)
+			protected.DELETE("/admin/mfa/:username", authHandler.AdminMiddleware(), mfaHandler.Reset)
+			protected.POST("/auth/password", localUserHandler.ChangePassword)
+			protected.GET("/tokens", tokenHandler.List)
+			protected.POST("/tokens", tokenHandler.Create)
+			protected.DELETE("/tokens/:id", tokenHandler.Revoke)
+			users := protected.Group("/admin/users")
+			users.Use(authHandler.AdminMiddleware())
+			{
+				users.GET("", localUserHandler.List)
+				users.POST("", localUserHandler.Create)
+				users.POST("/:username/password", localUserHandler.ResetPassword)
+				users.POST("/:username/disable", localUserHandler.Disable)
+				users.POST("/:username/enable", localUserHandler.Enable)
+			}
+			protected.GET("/read-only", readOnlyMode.GetStatus)
+			protected.PUT("/read-only", authHandler.AdminMiddleware(), readOnlyMode.SetStatus)
+			protected.GET("/features", featureFlags.List)
+			protected.PUT("/admin/features/:name", authHandler.AdminMiddleware(), featureFlags.Set)
 			protected.GET("/pods", podHandler.ListPods)
 			protected.GET("/namespaces", podHandler.ListNamespaces)
+			protected.GET("/namespaces/:namespace/overview", resourceHandler.GetNamespaceOverview)
+			protected.GET("/workloads/:kind/:namespace/:name/full", resourceHandler.GetWorkloadFull)
+			protected.GET("/apps", resourceHandler.ListApps)
+			protected.GET("/export/status", resourceHandler.ExportStatus)
 			protected.GET("/nodes", nodeHandler.ListNodes)
+			protected.GET("/nodes/versions", nodeHandler.GetVersionSkew)
+			protected.GET("/nodes/gpus", nodeHandler.GetGPUSummary)
+			protected.GET("/nodes/:name/shell", authHandler.AdminMiddleware(), execHandler.HandleNodeShell)
+			protected.GET("/nodes/:name/logs", authHandler.AdminMiddleware(), nodeHandler.GetLogs)
+
+			// Clusters and cross-cluster comparisons
+			protected.GET("/clusters", compareHandler.ListClusters)
+			protected.GET("/compare/namespaces/:namespace", compareHandler.CompareNamespace)
+
+			// HTTP proxy to in-cluster services (role-gated, see KVIEW_SERVICE_PROXY_ROLES)
+			protected.Any("/proxy/services/:namespace/:name/:port/*path", serviceProxyHandler.Proxy)
 			protected.POST("/console/exec", consoleHandler.Exec)
 			protected.GET("/resources/:kind", resourceHandler.List)
+			protected.GET("/columns", resourceHandler.ListColumns)
+			protected.POST("/query", resourceHandler.Query)
 			protected.GET("/cluster/stats", resourceHandler.GetStats)
+			if ksmCollector != nil {
+				protected.GET("/cluster/stats/insights", ksmCollector.Insights)
+			}
+			protected.GET("/cluster/components", resourceHandler.GetComponents)
 			protected.GET("/resources/:kind/:namespace/:name", resourceHandler.GetDetails)
 			protected.GET("/resources/:kind/:namespace/:name/yaml", resourceHandler.GetYAML)
 			protected.PUT("/resources/:kind/:namespace/:name/yaml", resourceHandler.UpdateYAML)
 			protected.PUT("/resources/:kind/:namespace/:name/restart", resourceHandler.Restart)
 			protected.PUT("/resources/:kind/:namespace/:name/scale", resourceHandler.Scale)
+			protected.PUT("/resources/:kind/:namespace/:name/resize", resourceHandler.ResizePod)
+			protected.POST("/resources/:kind/:namespace/:name/clone", resourceHandler.Clone)
 			protected.DELETE("/resources/:kind/:namespace/:name", resourceHandler.Delete)
+			protected.GET("/resources/:kind/:namespace/:name/impact", resourceHandler.GetDeleteImpact)
+			protected.POST("/resources/:kind/:namespace/:name/finalizers/remove", authHandler.AdminMiddleware(), resourceHandler.RemoveFinalizer)
+			protected.GET("/insights/terminating", resourceHandler.ListTerminating)
+			protected.GET("/policies", resourceHandler.ListPolicies)
 			protected.GET("/pods/:namespace/:name/logs", podHandler.GetLogs)
+			protected.GET("/pods/:namespace/:name/usage/stream", podHandler.StreamUsage)
 			protected.GET("/resources/:kind/:namespace/:name/events", resourceHandler.GetEvents)
+			protected.GET("/events/stats", resourceHandler.GetEventStats)
+			protected.GET("/events/history", eventArchive.History)
+			protected.GET("/resources/:kind/:namespace/:name/probes", resourceHandler.GetProbes)
+			protected.GET("/resources/:kind/:namespace/:name/scheduling", resourceHandler.GetScheduling)
+			protected.GET("/insights/probes", resourceHandler.ListUnprobedWorkloads)
+			protected.GET("/images", imageHandler.ListImages)
+			protected.GET("/images/architectures", imageHandler.GetMultiArchReport)
+			protected.GET("/diagnostics/image-pull-secrets", diagnosticsHandler.CheckPullSecrets)
+			protected.GET("/diagnostics/namespaces/:name/terminating", diagnosticsHandler.DiagnoseTerminatingNamespace)
+			protected.GET("/insights/security", diagnosticsHandler.GetSecurityPosture)
+			protected.GET("/insights/pod-security", diagnosticsHandler.GetPodSecurityAdmission)
+			protected.GET("/insights/policies", diagnosticsHandler.GetPolicyViolations)
+			protected.GET("/insights/upgrade-readiness", diagnosticsHandler.GetUpgradeReadiness)
+			protected.GET("/insights/deprecated-apis", diagnosticsHandler.ListDeprecatedAPIs)
+			protected.GET("/insights/webhooks", diagnosticsHandler.GetWebhookHealth)
+			protected.GET("/insights/flow-control", diagnosticsHandler.GetFlowControl)
+			protected.GET("/insights/priority", diagnosticsHandler.GetPriorityInsights)
+			protected.GET("/insights/idle", diagnosticsHandler.GetIdleWorkloads)
+			protected.GET("/crds/:name/schema", resourceHandler.GetCRDSchema)
+			protected.GET("/templates", templateHandler.List)
+			protected.POST("/templates/:name/render", templateHandler.Render)
+
+			// User-defined dashboards, private or shared with a team
+			protected.GET("/dashboards", dashboardHandler.List)
+			protected.POST("/dashboards", dashboardHandler.Create)
+			protected.GET("/dashboards/:id", dashboardHandler.Get)
+			protected.PUT("/dashboards/:id", dashboardHandler.Update)
+			protected.DELETE("/dashboards/:id", dashboardHandler.Delete)
 			protected.GET("/network/trace/:type/:namespace/:name", networkHandler.Trace)
+			protected.GET("/network/services/:namespace/:name/endpoints", networkHandler.GetServiceEndpoints)
+			protected.GET("/network/exposure", networkHandler.GetExposure)
+			protected.GET("/network/mesh", resourceHandler.GetMeshStatus)
+			protected.GET("/crossplane", resourceHandler.GetCrossplaneStatus)
+			protected.GET("/crossplane/managed", resourceHandler.ListManagedResources)
+			protected.GET("/crossplane/compositions", resourceHandler.ListCompositions)
+			protected.GET("/olm", resourceHandler.GetOperators)
+			protected.GET("/olm/csvs", resourceHandler.ListCSVs)
+			protected.GET("/olm/installplans", resourceHandler.ListInstallPlans)
+			protected.POST("/olm/installplans/:namespace/:name/approve", authHandler.AdminMiddleware(), resourceHandler.ApproveInstallPlan)
+			protected.GET("/storage/topology", resourceHandler.GetStorageTopology)
+			protected.POST("/snapshots/:namespace", resourceHandler.CreateSnapshot)
+			protected.POST("/snapshots/:namespace/:name/restore", resourceHandler.RestoreSnapshot)
+
+			// Progressive delivery: Argo Rollouts and canaries made of two Deployments
+			protected.GET("/rollouts/:namespace", resourceHandler.ListRollouts)
+			protected.POST("/rollouts/:namespace/:name/promote", resourceHandler.PromoteRollout)
+			protected.POST("/rollouts/:namespace/:name/abort", resourceHandler.AbortRollout)
+			protected.GET("/rollout-pairs/:namespace/:stable/:canary", resourceHandler.GetDeploymentPair)
+			protected.POST("/rollout-pairs/:namespace/:stable/:canary/promote", resourceHandler.PromoteDeploymentPair)
+			protected.POST("/rollout-pairs/:namespace/:stable/:canary/abort", resourceHandler.AbortDeploymentPair)
+
+			// Certificate signing requests (kubelet serving certificates, user certificates)
+			protected.GET("/csrs", resourceHandler.ListCSRs)
+			protected.POST("/csrs/:name/approve", authHandler.AdminMiddleware(), resourceHandler.ApproveCSR)
+			protected.POST("/csrs/:name/deny", authHandler.AdminMiddleware(), resourceHandler.DenyCSR)
+			protected.GET("/statefulsets/:namespace/:name/pvcs", resourceHandler.ListStatefulSetPVCs)
+			protected.POST("/statefulsets/:namespace/:name/pvcs/cleanup", resourceHandler.CleanupStatefulSetPVCs)
+			protected.GET("/exec/:namespace/:name", execHandler.HandleExec)
 			protected.GET("/exec/:namespace/:name/:container", execHandler.HandleExec)
+			protected.GET("/pods/:namespace/:name/containers", execHandler.ListContainers)
+			protected.GET("/attach/:namespace/:name", execHandler.HandleAttach)
+			protected.GET("/attach/:namespace/:name/:container", execHandler.HandleAttach)
 			admin := protected.Group("/rbac")
 			admin.Use(authHandler.AdminMiddleware())
 			{
 				admin.GET("/status", rbacHandler.GetStatus)
+				admin.GET("/can", rbacHandler.Can)
+				admin.GET("/who-can", rbacHandler.WhoCan)
+				admin.GET("/serviceaccounts/:namespace/:name", rbacHandler.InspectServiceAccount)
+				admin.POST("/kubeconfig", rbacHandler.GenerateKubeconfig)
+				admin.GET("/suggestions", rbacHandler.SuggestRoles)
+				admin.POST("/suggestions/apply", rbacHandler.ApplySuggestion)
+			}
+			protected.GET("/elevations", elevationHandler.List)
+			protected.POST("/elevations", elevationHandler.Request)
+			elevations := protected.Group("/elevations")
+			elevations.Use(authHandler.AdminMiddleware())
+			{
+				elevations.POST("/:id/approve", elevationHandler.Approve)
+				elevations.POST("/:id/deny", elevationHandler.Deny)
+				elevations.POST("/:id/revoke", elevationHandler.Revoke)
+			}
+			protected.GET("/access-requests", accessRequestHandler.List)
+			protected.POST("/access-requests", accessRequestHandler.Request)
+			accessRequests := protected.Group("/admin/access-requests")
+			accessRequests.Use(authHandler.AdminMiddleware())
+			{
+				accessRequests.GET("", accessRequestHandler.AdminList)
+				accessRequests.POST("/:id/approve", accessRequestHandler.Approve)
+				accessRequests.POST("/:id/deny", accessRequestHandler.Deny)
+				accessRequests.POST("/:id/revoke", accessRequestHandler.Revoke)
+			}
+			teams := protected.Group("/admin/teams")
+			teams.Use(authHandler.AdminMiddleware())
+			{
+				teams.GET("", teamHandler.List)
+				teams.POST("", teamHandler.Create)
+				teams.PUT("/:name", teamHandler.Update)
+				teams.DELETE("/:name", teamHandler.Delete)
+			}
+			protected.GET("/maintenance", maintenanceHandler.List)
+			maintenance := protected.Group("/maintenance")
+			maintenance.Use(authHandler.AdminMiddleware())
+			{
+				maintenance.POST("", maintenanceHandler.Create)
+				maintenance.DELETE("/:id", maintenanceHandler.Delete)
+			}
+			approvals := protected.Group("/admin/approvals")
+			approvals.Use(authHandler.AdminMiddleware())
+			{
+				approvals.GET("", resourceHandler.ListApprovals)
+				approvals.POST("/:id/approve", resourceHandler.ApproveAction)
+				approvals.POST("/:id/reject", resourceHandler.RejectAction)
+			}
+			terminals := protected.Group("/admin/terminal-sessions")
+			terminals.Use(authHandler.AdminMiddleware())
+			{
+				terminals.GET("", execHandler.ListSessions)
+				terminals.DELETE("/:id", execHandler.KillSession)
+			}
+			protected.GET("/admin/slowlog", authHandler.AdminMiddleware(), slowLog.List)
+			protected.GET("/admin/stats", authHandler.AdminMiddleware(), usageStats.Stats)
+			protected.GET("/admin/audit/export", authHandler.AdminMiddleware(), auditLog.Export)
+			protected.GET("/admin/config", authHandler.AdminMiddleware(), configHandler.Get)
+			protected.GET("/admin/retention", authHandler.AdminMiddleware(), pruner.Status)
+			protected.POST("/admin/retention/prune", authHandler.AdminMiddleware(), pruner.PruneNow)
+			protected.GET("/admin/backup", authHandler.AdminMiddleware(), backupHandler.Download)
+			protected.POST("/admin/backup/restore", authHandler.AdminMiddleware(), backupHandler.Restore)
+			webhooks := protected.Group("/admin/webhooks")
+			webhooks.Use(authHandler.AdminMiddleware())
+			{
+				webhooks.GET("", notifier.List)
+				webhooks.POST("", notifier.Create)
+				webhooks.PUT("/:id", notifier.Update)
+				webhooks.DELETE("/:id", notifier.Delete)
+				webhooks.POST("/:id/test", notifier.Test)
+			}
+			scaling := protected.Group("/scaling-rules")
+			scaling.Use(authHandler.AdminMiddleware())
+			{
+				scaling.GET("", scalingHandler.ListRules)
+				scaling.POST("", scalingHandler.CreateRule)
+				scaling.PUT("/:id", scalingHandler.UpdateRule)
+				scaling.DELETE("/:id", scalingHandler.DeleteRule)
+				scaling.POST("/:id/run", scalingHandler.RunRule)
+			}
+			reports := protected.Group("/admin/reports")
+			reports.Use(authHandler.AdminMiddleware())
+			{
+				reports.GET("", reportHandler.List)
+				reports.GET("/preview", reportHandler.Preview)
+				reports.POST("", reportHandler.Create)
+				reports.PUT("/:id", reportHandler.Update)
+				reports.DELETE("/:id", reportHandler.Delete)
+				reports.POST("/:id/send", reportHandler.Send)
 			}
 		}
 	}
@@ -131,6 +588,13 @@ func main() {
 	if port == "" {
 		port = "8080"
 	}
-	log.Printf("Starting K-View on port %s", port)
-	router.Run(":" + port)
+	if basePath != "" {
+		log.Printf("Starting K-View on port %s under %s/", port, basePath)
+	} else {
+		log.Printf("Starting K-View on port %s", port)
+	}
+	// Routes are registered without the base path; it is stripped before they are matched
+	if err := http.ListenAndServe(":"+port, web.StripBasePath(basePath, router)); err != nil {
+		log.Fatalf("Server stopped: %v", err)
+	}
 }
@@ -0,0 +1,56 @@
+package policy
+
+import (
+	"path"
+	"strings"
+)
+
+// ApprovalConfig lists the deletes that need a second admin's approval before they run.
+// Namespace entries may be shell globs such as "prod-*"; kinds use the API URL names
+// (deployments, persistentvolumes, ...). Both lists empty disables the approval queue.
+type ApprovalConfig struct {
+	Namespaces []string `yaml:"namespaces,omitempty" json:"namespaces,omitempty"`
+	Kinds      []string `yaml:"kinds,omitempty" json:"kinds,omitempty"`
+}
+
+// matchesAny reports whether value matches one of the glob patterns.
+func matchesAny(patterns []string, value string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiresApproval reports whether deleting a resource of kind in namespace must go through
+// the approval queue. For cluster-scoped resources namespace is empty. resource maps a kind to
+// its API resource name, so aliases of one kind ("priority-classes", "priorityclasses") match
+// the same entries; configured kinds without glob characters are mapped the same way.
+func (c *PolicyConfig) RequiresApproval(kind, namespace string, resource func(string) string) bool {
+	if c == nil {
+		return false
+	}
+	kind = resource(strings.ToLower(kind))
+	for _, p := range c.Approvals.Kinds {
+		p = strings.ToLower(p)
+		if !strings.ContainsAny(p, "*?[") {
+			p = resource(p)
+		}
+		if ok, _ := path.Match(p, kind); ok {
+			return true
+		}
+	}
+	return namespace != "" && matchesAny(c.Approvals.Namespaces, namespace)
+}
+
+// ApprovesNamespaces reports whether deletes in some namespaces need approval, so a delete
+// spanning all namespaces would include one of them.
+func (c *PolicyConfig) ApprovesNamespaces() bool {
+	return c != nil && len(c.Approvals.Namespaces) > 0
+}
+
+// ApprovalsEnabled reports whether any delete needs approval.
+func (c *PolicyConfig) ApprovalsEnabled() bool {
+	return c != nil && (len(c.Approvals.Kinds) > 0 || len(c.Approvals.Namespaces) > 0)
+}
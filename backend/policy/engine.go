@@ -0,0 +1,162 @@
+package policy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Actions a rule can take when it is violated.
+const (
+	ActionBlock = "block"
+	ActionWarn  = "warn"
+	ActionOff   = "off"
+)
+
+// Built-in rule names.
+const (
+	RuleDisallowLatestTag     = "disallow-latest-tag"
+	RuleRequireResourceLimits = "require-resource-limits"
+	RuleForbidPrivileged      = "forbid-privileged"
+)
+
+// Rule enables a built-in check with block or warn semantics.
+// An empty Namespaces list applies the rule to every namespace.
+type Rule struct {
+	Name       string   `yaml:"name" json:"name"`
+	Action     string   `yaml:"action" json:"action"`
+	Namespaces []string `yaml:"namespaces,omitempty" json:"namespaces,omitempty"`
+}
+
+type PolicyConfig struct {
+	Rules     []Rule          `yaml:"rules" json:"rules"`
+	Approvals ApprovalConfig  `yaml:"approvals,omitempty" json:"approvals"`
+	Protected ProtectedConfig `yaml:"protected,omitempty" json:"protected"`
+}
+
+// Violation is a single failed check on a container.
+type Violation struct {
+	Rule      string `json:"rule"`
+	Action    string `json:"action"`
+	Container string `json:"container"`
+	Message   string `json:"message"`
+}
+
+// DefaultConfig is used when no policy file is mounted.
+func DefaultConfig() *PolicyConfig {
+	return &PolicyConfig{Rules: []Rule{
+		{Name: RuleDisallowLatestTag, Action: ActionWarn},
+		{Name: RuleRequireResourceLimits, Action: ActionWarn},
+		{Name: RuleForbidPrivileged, Action: ActionBlock},
+	}}
+}
+
+// LoadConfig loads the policy configuration from a YAML file.
+func LoadConfig(path string) (*PolicyConfig, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return DefaultConfig(), nil // Fall back to the built-in defaults
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy config: %v", err)
+	}
+
+	var config PolicyConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal policy config: %v", err)
+	}
+
+	for _, r := range config.Rules {
+		if _, ok := checks[r.Name]; !ok {
+			return nil, fmt.Errorf("unknown policy rule %q", r.Name)
+		}
+		if r.Action != ActionBlock && r.Action != ActionWarn && r.Action != ActionOff {
+			return nil, fmt.Errorf("invalid action %q for policy rule %q", r.Action, r.Name)
+		}
+	}
+
+	return &config, nil
+}
+
+// check returns a message for every container that violates the rule.
+type check func(c corev1.Container) string
+
+var checks = map[string]check{
+	RuleDisallowLatestTag: func(c corev1.Container) string {
+		image := c.Image
+		if i := strings.Index(image, "@"); i >= 0 {
+			return "" // Digest-pinned images are immutable
+		}
+		tag := ""
+		if i := strings.LastIndex(image, ":"); i >= 0 && !strings.Contains(image[i:], "/") {
+			tag = image[i+1:]
+		}
+		if tag == "" || tag == "latest" {
+			return fmt.Sprintf("image %q uses the mutable latest tag", image)
+		}
+		return ""
+	},
+	RuleRequireResourceLimits: func(c corev1.Container) string {
+		var missing []string
+		if _, ok := c.Resources.Limits[corev1.ResourceCPU]; !ok {
+			missing = append(missing, "cpu")
+		}
+		if _, ok := c.Resources.Limits[corev1.ResourceMemory]; !ok {
+			missing = append(missing, "memory")
+		}
+		if len(missing) > 0 {
+			return "missing resource limits: " + strings.Join(missing, ", ")
+		}
+		return ""
+	},
+	RuleForbidPrivileged: func(c corev1.Container) string {
+		if c.SecurityContext != nil && c.SecurityContext.Privileged != nil && *c.SecurityContext.Privileged {
+			return "container runs privileged"
+		}
+		return ""
+	},
+}
+
+func (r Rule) appliesTo(namespace string) bool {
+	if len(r.Namespaces) == 0 {
+		return true
+	}
+	for _, ns := range r.Namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate runs every enabled rule against the containers of a pod spec.
+func (c *PolicyConfig) Evaluate(namespace string, spec *corev1.PodSpec) []Violation {
+	violations := []Violation{}
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, r := range c.Rules {
+		if r.Action == ActionOff || !r.appliesTo(namespace) {
+			continue
+		}
+		for _, ctr := range containers {
+			if msg := checks[r.Name](ctr); msg != "" {
+				violations = append(violations, Violation{Rule: r.Name, Action: r.Action, Container: ctr.Name, Message: msg})
+			}
+		}
+	}
+	return violations
+}
+
+// Blocking reports whether any violation must reject the change.
+func Blocking(violations []Violation) bool {
+	for _, v := range violations {
+		if v.Action == ActionBlock {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package policy
+
+import "strings"
+
+// ProtectedConfig lists namespaces (globs allowed, e.g. "prod-*") and kinds that cannot be
+// edited or deleted through K-View at all, whatever the user's role.
+type ProtectedConfig struct {
+	Namespaces []string `yaml:"namespaces,omitempty" json:"namespaces,omitempty"`
+	Kinds      []string `yaml:"kinds,omitempty" json:"kinds,omitempty"`
+}
+
+// IsProtected reports whether changes to a resource of kind in namespace are forbidden.
+// For cluster-scoped resources namespace is empty; a Namespace object is judged by its own name.
+func (c *PolicyConfig) IsProtected(kind, namespace string) bool {
+	if c == nil {
+		return false
+	}
+	if matchesAny(c.Protected.Kinds, strings.ToLower(kind)) {
+		return true
+	}
+	return namespace != "" && matchesAny(c.Protected.Namespaces, namespace)
+}
+
+// ProtectsNamespaces reports whether any namespace is protected, so a command spanning all
+// namespaces would touch one of them.
+func (c *PolicyConfig) ProtectsNamespaces() bool {
+	return c != nil && len(c.Protected.Namespaces) > 0
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sync"
 
 	"gopkg.in/yaml.v2"
 )
@@ -17,6 +18,14 @@ type Assignment struct {
 
 type RBACConfig struct {
 	Assignments []Assignment `yaml:"assignments"`
+	// SlackUsers maps Slack user IDs (U0123ABC) to K-View users for the Slack slash command
+	SlackUsers map[string]string `yaml:"slackUsers,omitempty"`
+
+	// granted holds user assignments approved at runtime (namespace access requests). They
+	// apply to users without a static user assignment.
+	mu      sync.RWMutex
+	granted []Assignment
+	teams   []Team
 }
 
 // LoadStaticConfig loads the RBAC configuration from a YAML file.
@@ -38,23 +47,104 @@ func LoadStaticConfig(path string) (*RBACConfig, error) {
 	return &config, nil
 }
 
-// GetRoleForUser returns the role and namespace for a given user email and groups.
-func (c *RBACConfig) GetRoleForUser(email string, groups []string) (string, string) {
+// HasAssignment reports whether a user or one of their groups is assigned a role explicitly,
+// rather than falling back to the default viewer role.
+func (c *RBACConfig) HasAssignment(email string, groups []string) bool {
+	if _, ok := c.grantFor(email); ok {
+		return true
+	}
+	if _, _, ok := c.teamRole(email, groups); ok {
+		return true
+	}
+	for _, a := range c.Assignments {
+		if a.User != "" && a.User == email {
+			return true
+		}
+		for _, group := range groups {
+			if a.Group != "" && a.Group == group {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Resolve returns the effective role of a user and the namespaces it is limited to (none
+// means cluster-wide). Static user assignments come first, then runtime grants, then team
+// membership, then static group assignments.
+func (c *RBACConfig) Resolve(email string, groups []string) (string, []string) {
 	// Check static assignments for specific user
 	for _, a := range c.Assignments {
 		if a.User != "" && a.User == email {
-			return a.Role, a.Namespace
+			return a.Role, namespaceList(a.Namespace)
 		}
 	}
 
+	// Then runtime grants
+	if a, ok := c.grantFor(email); ok {
+		return a.Role, namespaceList(a.Namespace)
+	}
+
+	// Then teams
+	if role, namespaces, ok := c.teamRole(email, groups); ok {
+		return role, namespaces
+	}
+
 	// Check static assignments for groups
 	for _, group := range groups {
 		for _, a := range c.Assignments {
 			if a.Group != "" && a.Group == group {
-				return a.Role, a.Namespace
+				return a.Role, namespaceList(a.Namespace)
 			}
 		}
 	}
 
-	return "viewer", "" // Default fallback
+	return "viewer", nil // Default fallback
+}
+
+func namespaceList(namespace string) []string {
+	if namespace == "" {
+		return nil
+	}
+	return []string{namespace}
+}
+
+// UserForSlack returns the K-View user a Slack user ID is mapped to, or "" if it is not mapped.
+func (c *RBACConfig) UserForSlack(slackID string) string {
+	return c.SlackUsers[slackID]
+}
+
+// HasUserAssignment reports whether a user is assigned a role by name in the static config.
+func (c *RBACConfig) HasUserAssignment(email string) bool {
+	for _, a := range c.Assignments {
+		if a.User != "" && a.User == email {
+			return true
+		}
+	}
+	return false
+}
+
+// SetGranted replaces the runtime grants.
+func (c *RBACConfig) SetGranted(granted []Assignment) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.granted = granted
+}
+
+// Granted returns the runtime grants.
+func (c *RBACConfig) Granted() []Assignment {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]Assignment(nil), c.granted...)
+}
+
+func (c *RBACConfig) grantFor(email string) (Assignment, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, a := range c.granted {
+		if a.User == email {
+			return a, true
+		}
+	}
+	return Assignment{}, false
 }
@@ -0,0 +1,115 @@
+package rbac
+
+import "sort"
+
+// TeamLevels are the access levels a team can have, from least to most privileged.
+var TeamLevels = []string{"viewer", "developer", "admin"}
+
+// Team gives everyone in it the same role, so roles need not be assigned user by user.
+// Members are listed by name or come from directory groups.
+type Team struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Members     []string `json:"members"`
+	Groups      []string `json:"groups,omitempty"`
+	Level       string   `json:"level"`                // viewer, developer or admin
+	Namespaces  []string `json:"namespaces,omitempty"` // Empty: the role is cluster-wide
+}
+
+// Role returns the K-View role of the team: kview-cluster-<level>, or kview-namespace-<level>
+// when it is limited to namespaces.
+func (t Team) Role() string {
+	if len(t.Namespaces) > 0 {
+		return "kview-namespace-" + t.Level
+	}
+	return "kview-cluster-" + t.Level
+}
+
+// Includes reports whether a user belongs to the team, by name or through a group.
+func (t Team) Includes(email string, groups []string) bool {
+	for _, m := range t.Members {
+		if m == email {
+			return true
+		}
+	}
+	for _, g := range t.Groups {
+		for _, group := range groups {
+			if g == group {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rank orders team roles by privilege: a higher level always wins, and at the same level a
+// cluster-wide role beats a namespaced one.
+func (t Team) rank() int {
+	rank := 0
+	for i, l := range TeamLevels {
+		if l == t.Level {
+			rank = 2 * i
+		}
+	}
+	if len(t.Namespaces) == 0 {
+		rank++
+	}
+	return rank
+}
+
+// SetTeams replaces the teams.
+func (c *RBACConfig) SetTeams(teams []Team) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.teams = teams
+}
+
+// Teams returns the teams.
+func (c *RBACConfig) Teams() []Team {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]Team(nil), c.teams...)
+}
+
+// TeamsOf returns the names of the teams a user belongs to.
+func (c *RBACConfig) TeamsOf(email string, groups []string) []string {
+	var names []string
+	for _, t := range c.Teams() {
+		if t.Includes(email, groups) {
+			names = append(names, t.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// teamRole resolves the role a user gets from their teams. The most privileged team wins;
+// namespaces of teams with that same role are combined.
+func (c *RBACConfig) teamRole(email string, groups []string) (string, []string, bool) {
+	var best []Team
+	for _, t := range c.Teams() {
+		if !t.Includes(email, groups) {
+			continue
+		}
+		switch {
+		case len(best) == 0 || t.rank() > best[0].rank():
+			best = []Team{t}
+		case t.rank() == best[0].rank():
+			best = append(best, t)
+		}
+	}
+	if len(best) == 0 {
+		return "", nil, false
+	}
+	var namespaces []string
+	seen := map[string]bool{}
+	for _, t := range best {
+		for _, ns := range t.Namespaces {
+			if !seen[ns] {
+				seen[ns] = true
+				namespaces = append(namespaces, ns)
+			}
+		}
+	}
+	return best[0].Role(), namespaces, true
+}
@@ -0,0 +1,218 @@
+package store
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// manifestName is the first entry of a backup archive. It is not restored as a document.
+const manifestName = "backup-manifest.json"
+
+// backupFormat is the version of the archive layout, bumped if it ever changes.
+const backupFormat = 1
+
+// ErrIncompatibleBackup is returned for archives this version of K-View cannot restore.
+var ErrIncompatibleBackup = errors.New("incompatible backup")
+
+// Manifest describes a backup archive.
+type Manifest struct {
+	Format        int       `json:"format"`
+	SchemaVersion int       `json:"schemaVersion"` // Latest migration applied when the backup was taken
+	CreatedAt     time.Time `json:"createdAt"`
+	Documents     []string  `json:"documents"`
+	Journals      []string  `json:"journals"`
+}
+
+// Backup writes a gzipped tar archive of every document and journal, holding the store lock
+// so the snapshot is consistent. Journals are left out unless withJournals.
+func (s *Store) Backup(w io.Writer, withJournals bool) (Manifest, error) {
+	var m Manifest
+	err := s.backend.locked(func(st storage) error {
+		docs, err := st.documents()
+		if err != nil {
+			return fmt.Errorf("failed to list documents: %v", err)
+		}
+		journals, err := st.journals()
+		if err != nil {
+			return fmt.Errorf("failed to list journals: %v", err)
+		}
+		var applied []AppliedMigration
+		if err := load(st, migrationsDoc, &applied); err != nil {
+			return err
+		}
+		for _, a := range applied {
+			m.SchemaVersion = max(m.SchemaVersion, a.Version)
+		}
+		m.Format, m.CreatedAt, m.Documents, m.Journals = backupFormat, time.Now().UTC(), docs, []string{}
+		if withJournals {
+			m.Journals = journals
+		}
+
+		gz := gzip.NewWriter(w)
+		tw := tar.NewWriter(gz)
+		manifest, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeEntry(tw, manifestName, manifest, m.CreatedAt); err != nil {
+			return err
+		}
+		for _, d := range m.Documents {
+			data, err := st.read(d)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %v", d, err)
+			}
+			if err := writeEntry(tw, d+".json", data, m.CreatedAt); err != nil {
+				return err
+			}
+		}
+		for _, j := range m.Journals {
+			days, err := days(st, j)
+			if err != nil {
+				return err
+			}
+			for _, day := range days {
+				data, err := st.readDay(j, day.Format(journalDay))
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %v", j, err)
+				}
+				if err := writeEntry(tw, j+"/"+day.Format(journalDay)+".jsonl", data, m.CreatedAt); err != nil {
+					return err
+				}
+			}
+		}
+		if err := tw.Close(); err != nil {
+			return err
+		}
+		return gz.Close()
+	})
+	return m, err
+}
+
+func writeEntry(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: modTime, Typeflag: tar.TypeReg}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// validEntry reports whether an archive path is a document or a journal day file, so a crafted
+// archive cannot write outside the staging directory or over the lock file.
+func validEntry(name string) bool {
+	if name != path.Clean(name) || strings.HasPrefix(name, ".") || strings.Contains(name, "\\") {
+		return false
+	}
+	dir, file := path.Split(name)
+	if dir == "" {
+		return strings.HasSuffix(file, ".json") && file != manifestName
+	}
+	if strings.Count(name, "/") != 1 {
+		return false
+	}
+	_, err := time.Parse(journalDay, strings.TrimSuffix(file, ".jsonl"))
+	return err == nil && strings.HasSuffix(file, ".jsonl")
+}
+
+// validJournal reports whether a manifest journal name is one of known and a single plain path
+// segment, so a crafted manifest cannot name the data directory itself or a path outside it.
+func validJournal(name string, known []string) bool {
+	return name != "" && !strings.HasPrefix(name, ".") && !strings.ContainsAny(name, "/\\") && slices.Contains(known, name)
+}
+
+// Restore replaces the contents of the store with a backup archive and then applies
+// the migrations newer than the backup. Backups taken by a newer K-View, with migrations this
+// one does not know, are refused with ErrIncompatibleBackup, as are archives naming journals
+// other than known. The archive is unpacked and checked before anything is replaced, and the
+// swap is undone if it fails half-way. Journals missing from the archive are kept, so a backup
+// taken without them restores the documents only.
+func (s *Store) Restore(r io.Reader, migrations []Migration, known []string) (Manifest, error) {
+	var m Manifest
+	latest := 0
+	for _, mig := range migrations {
+		latest = max(latest, mig.Version)
+	}
+
+	err := s.backend.locked(func(st storage) error {
+		staging, err := st.stage()
+		if err != nil {
+			return fmt.Errorf("failed to stage restore: %v", err)
+		}
+		defer os.RemoveAll(staging)
+
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("%w: not a gzipped archive", ErrIncompatibleBackup)
+		}
+		tr := tar.NewReader(gz)
+		first := true
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrIncompatibleBackup, err)
+			}
+			if first {
+				first = false
+				if hdr.Name != manifestName {
+					return fmt.Errorf("%w: the archive has no manifest", ErrIncompatibleBackup)
+				}
+				if err := json.NewDecoder(tr).Decode(&m); err != nil {
+					return fmt.Errorf("%w: unreadable manifest: %v", ErrIncompatibleBackup, err)
+				}
+				if m.Format != backupFormat {
+					return fmt.Errorf("%w: archive format %d, expected %d", ErrIncompatibleBackup, m.Format, backupFormat)
+				}
+				if m.SchemaVersion > latest {
+					return fmt.Errorf("%w: the backup has schema version %d, this K-View knows up to %d; upgrade K-View first", ErrIncompatibleBackup, m.SchemaVersion, latest)
+				}
+				for _, j := range m.Journals {
+					if !validJournal(j, known) {
+						return fmt.Errorf("%w: unknown journal %q", ErrIncompatibleBackup, j)
+					}
+				}
+				continue
+			}
+			journal, _, inJournal := strings.Cut(hdr.Name, "/")
+			if hdr.Typeflag != tar.TypeReg || !validEntry(hdr.Name) || (inJournal && !slices.Contains(m.Journals, journal)) {
+				return fmt.Errorf("%w: unexpected entry %q", ErrIncompatibleBackup, hdr.Name)
+			}
+			dest := filepath.Join(staging, filepath.FromSlash(hdr.Name))
+			if err := os.MkdirAll(filepath.Dir(dest), 0o700); err != nil {
+				return err
+			}
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrIncompatibleBackup, err)
+			}
+			if strings.HasSuffix(hdr.Name, ".json") && !json.Valid(data) {
+				return fmt.Errorf("%w: %s is not valid JSON", ErrIncompatibleBackup, hdr.Name)
+			}
+			if err := os.WriteFile(dest, data, 0o600); err != nil {
+				return fmt.Errorf("failed to stage %s: %v", hdr.Name, err)
+			}
+		}
+		if first {
+			return fmt.Errorf("%w: the archive is empty", ErrIncompatibleBackup)
+		}
+
+		// Swap in the staged documents, and the journals the backup has
+		return st.replace(staging, m.Journals)
+	})
+	if err != nil {
+		return m, err
+	}
+	return m, s.Migrate(migrations)
+}
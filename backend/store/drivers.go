@@ -0,0 +1,7 @@
+package store
+
+// SQL drivers for OpenDatabase
+import (
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
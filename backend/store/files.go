@@ -0,0 +1,263 @@
+package store
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// files keeps documents as <name>.json in the data directory and journals as directories of
+// <day>.jsonl files. Writes hold the mutex and an advisory lock on the data directory, so
+// replicas sharing a volume (ReadWriteMany) take turns. Documents are replaced by renaming,
+// so reads need no lock.
+type files struct {
+	dir  string
+	mu   sync.Mutex
+	lock *os.File
+}
+
+func openFiles(dir string) (*files, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %v", err)
+	}
+	lock, err := os.OpenFile(filepath.Join(dir, ".lock"), os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open data directory lock: %v", err)
+	}
+	return &files{dir: dir, lock: lock}, nil
+}
+
+func (f *files) locked(fn func(st storage) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := lockFile(f.lock); err != nil {
+		return fmt.Errorf("failed to lock data directory: %v", err)
+	}
+	defer unlockFile(f.lock)
+	return fn(f)
+}
+
+func (f *files) view(fn func(st storage) error) error {
+	return fn(f)
+}
+
+func (f *files) stamp(name string) Stamp {
+	info, err := os.Stat(f.path(name))
+	if err != nil {
+		return Stamp{}
+	}
+	return Stamp{ModTime: info.ModTime(), Size: info.Size()}
+}
+
+func (f *files) path(name string) string {
+	return filepath.Join(f.dir, name+".json")
+}
+
+func (f *files) dayPath(journal, day string) string {
+	return filepath.Join(f.dir, journal, day+".jsonl")
+}
+
+func (f *files) read(name string) ([]byte, error) {
+	data, err := os.ReadFile(f.path(name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// write writes to a temporary file and renames it so a crash never leaves a truncated document.
+func (f *files) write(name string, data []byte) error {
+	tmp := f.path(name) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path(name))
+}
+
+// contents lists the documents and journals of the data directory: *.json files at the top
+// level and directories of day files. Lock and temporary files are left out.
+func (f *files) contents() ([]string, []string, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read data directory: %v", err)
+	}
+	docs, journals := []string{}, []string{}
+	for _, e := range entries {
+		switch {
+		case e.IsDir() && !strings.HasPrefix(e.Name(), "."):
+			journals = append(journals, e.Name())
+		case e.Type().IsRegular() && strings.HasSuffix(e.Name(), ".json"):
+			docs = append(docs, strings.TrimSuffix(e.Name(), ".json"))
+		}
+	}
+	return docs, journals, nil
+}
+
+func (f *files) documents() ([]string, error) {
+	docs, _, err := f.contents()
+	return docs, err
+}
+
+func (f *files) journals() ([]string, error) {
+	_, journals, err := f.contents()
+	return journals, err
+}
+
+func (f *files) appendLines(journal, day string, lines []byte) error {
+	if err := os.MkdirAll(filepath.Join(f.dir, journal), 0o700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.dayPath(journal, day), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(lines); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (f *files) days(journal string) ([]time.Time, error) {
+	entries, err := os.ReadDir(filepath.Join(f.dir, journal))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var days []time.Time
+	for _, e := range entries {
+		day, err := time.Parse(journalDay, strings.TrimSuffix(e.Name(), ".jsonl"))
+		if err == nil && strings.HasSuffix(e.Name(), ".jsonl") {
+			days = append(days, day)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days, nil
+}
+
+func (f *files) readDay(journal, day string) ([]byte, error) {
+	return os.ReadFile(f.dayPath(journal, day))
+}
+
+func (f *files) scanDay(journal, day string, fn func(line []byte) error) error {
+	file, err := os.Open(f.dayPath(journal, day))
+	if os.IsNotExist(err) {
+		return nil // Pruned in the meantime
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if err := fn(scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (f *files) removeDay(journal, day string) error {
+	if err := os.Remove(f.dayPath(journal, day)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (f *files) daySize(journal, day string) (int64, error) {
+	info, err := os.Stat(f.dayPath(journal, day))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (f *files) stage() (string, error) {
+	return os.MkdirTemp(f.dir, ".restore-")
+}
+
+func (f *files) replace(staging string, journals []string) error {
+	docs, _, err := f.contents()
+	if err != nil {
+		return err
+	}
+	var replaced []string
+	for _, d := range docs {
+		replaced = append(replaced, d+".json")
+	}
+	replaced = append(replaced, journals...)
+	staged, err := os.ReadDir(staging)
+	if err != nil {
+		return err
+	}
+	var restored []string
+	for _, e := range staged {
+		restored = append(restored, e.Name())
+	}
+	return f.swap(staging, replaced, restored)
+}
+
+// swap moves the replaced entries of the data directory aside, then moves the restored entries
+// in from staging. If any rename fails, every move made so far is undone, so the data directory
+// ends up either fully restored or as it was.
+func (f *files) swap(staging string, replaced, restored []string) error {
+	trash, err := os.MkdirTemp(f.dir, ".replaced-")
+	if err != nil {
+		return fmt.Errorf("failed to stage restore: %v", err)
+	}
+	defer os.RemoveAll(trash)
+
+	type move struct{ from, to string }
+	var done []move
+	rename := func(from, to string) error {
+		if err := os.Rename(from, to); err != nil {
+			return err
+		}
+		done = append(done, move{from, to})
+		return nil
+	}
+	undo := func() {
+		for i := len(done) - 1; i >= 0; i-- {
+			os.Rename(done[i].to, done[i].from)
+		}
+	}
+
+	for _, name := range replaced {
+		err := rename(filepath.Join(f.dir, name), filepath.Join(trash, name))
+		if err != nil && !os.IsNotExist(err) {
+			undo()
+			return fmt.Errorf("failed to replace %s: %v", name, err)
+		}
+	}
+	for _, name := range restored {
+		if err := rename(filepath.Join(staging, name), filepath.Join(f.dir, name)); err != nil {
+			undo()
+			return fmt.Errorf("failed to restore %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func (f *files) removeTemp() error {
+	matches, err := filepath.Glob(filepath.Join(f.dir, "*.json.tmp"))
+	if err != nil {
+		return err
+	}
+	for _, m := range matches {
+		if err := os.Remove(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Journals are append-only collections that grow too large for a single document, such as the
+// event archive. Records are stored as JSON lines grouped by UTC day (one file per day in the
+// data directory), so retention is enforced by deleting whole days.
+
+const journalDay = "2006-01-02"
+
+// Append adds records to the named journal, in the day t falls on.
+func (s *Store) Append(name string, t time.Time, records ...interface{}) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("failed to encode %s record: %v", name, err)
+		}
+	}
+	return s.backend.locked(func(st storage) error {
+		if err := st.appendLines(name, t.UTC().Format(journalDay), buf.Bytes()); err != nil {
+			return fmt.Errorf("failed to write %s: %v", name, err)
+		}
+		return nil
+	})
+}
+
+// days lists the days of a journal, oldest first.
+func days(st storage, name string) ([]time.Time, error) {
+	days, err := st.days(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", name, err)
+	}
+	return days, nil
+}
+
+// Scan calls fn with every raw record from the day of since onwards, oldest day first. Records
+// from earlier in that first day are included; callers filter by their own timestamps.
+// Undecodable lines (e.g. a torn final write) are skipped.
+func (s *Store) Scan(name string, since time.Time, fn func(record json.RawMessage) error) error {
+	return s.backend.view(func(st storage) error {
+		days, err := days(st, name)
+		if err != nil {
+			return err
+		}
+		first := since.UTC().Truncate(24 * time.Hour)
+		for _, day := range days {
+			if day.Before(first) {
+				continue
+			}
+			err := st.scanDay(name, day.Format(journalDay), func(line []byte) error {
+				if !json.Valid(line) {
+					return nil
+				}
+				return fn(append(json.RawMessage(nil), line...))
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Prune deletes the days that end before the cutoff and returns how many were removed.
+func (s *Store) Prune(name string, before time.Time) (int, error) {
+	removed := 0
+	err := s.backend.locked(func(st storage) error {
+		days, err := days(st, name)
+		if err != nil {
+			return err
+		}
+		for _, day := range days {
+			if day.Add(24 * time.Hour).After(before) {
+				break
+			}
+			if err := st.removeDay(name, day.Format(journalDay)); err != nil {
+				return fmt.Errorf("failed to prune %s: %v", name, err)
+			}
+			removed++
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// JournalUsage returns the number of days of a journal and their total size in bytes.
+func (s *Store) JournalUsage(name string) (int, int64, error) {
+	var count int
+	var size int64
+	err := s.backend.view(func(st storage) error {
+		days, err := days(st, name)
+		if err != nil {
+			return err
+		}
+		count = len(days)
+		for _, day := range days {
+			if n, err := st.daySize(name, day.Format(journalDay)); err == nil {
+				size += n
+			}
+		}
+		return nil
+	})
+	return count, size, err
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package store
+
+import "os"
+
+// Without flock, writes are only serialized within the process.
+func lockFile(f *os.File) error { return nil }
+
+func unlockFile(f *os.File) error { return nil }
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+const migrationsDoc = "schema-migrations"
+
+// Migration is a one-time change to the stored documents, such as renaming a collection or
+// reshaping its records. Versions are applied in order and never reused.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *Tx) error
+}
+
+// Tx gives a migration access to the documents while the store is locked.
+type Tx struct {
+	st storage
+}
+
+// Load decodes the named document into v. A missing document is not an error.
+func (tx *Tx) Load(name string, v interface{}) error {
+	return load(tx.st, name, v)
+}
+
+// Save replaces the named document with v.
+func (tx *Tx) Save(name string, v interface{}) error {
+	return save(tx.st, name, v)
+}
+
+// AppliedMigration records a migration that has run against the store.
+type AppliedMigration struct {
+	Version   int       `json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"appliedAt"`
+}
+
+// Migrations are the changes to the stored documents, oldest first. Append new ones; never edit
+// or remove a migration that has shipped.
+var Migrations = []Migration{
+	{Version: 1, Name: "remove temporary files of interrupted writes", Up: removeTempFiles},
+}
+
+// Migrate applies the migrations newer than the store's version. It holds the store lock
+// throughout, so replicas never run the same migration twice, and records each migration as
+// soon as it succeeds. The tables of a database backend are created when it is opened; these
+// migrations change the documents kept in them.
+func (s *Store) Migrate(migrations []Migration) error {
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	return s.backend.locked(func(st storage) error {
+		var applied []AppliedMigration
+		if err := load(st, migrationsDoc, &applied); err != nil {
+			return err
+		}
+		current := 0
+		for _, a := range applied {
+			current = max(current, a.Version)
+		}
+		for _, m := range sorted {
+			if m.Version <= current {
+				continue
+			}
+			if err := m.Up(&Tx{st: st}); err != nil {
+				return fmt.Errorf("migration %d (%s) failed: %v", m.Version, m.Name, err)
+			}
+			applied = append(applied, AppliedMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now().UTC()})
+			if err := save(st, migrationsDoc, applied); err != nil {
+				return err
+			}
+			log.Printf("Data store migrated to version %d: %s", m.Version, m.Name)
+		}
+		return nil
+	})
+}
+
+// Version returns the latest migration applied to the store, 0 if none.
+func (s *Store) Version() (int, error) {
+	var applied []AppliedMigration
+	if err := s.Load(migrationsDoc, &applied); err != nil {
+		return 0, err
+	}
+	version := 0
+	for _, a := range applied {
+		version = max(version, a.Version)
+	}
+	return version, nil
+}
+
+func removeTempFiles(tx *Tx) error {
+	return tx.st.removeTemp()
+}
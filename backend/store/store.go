@@ -0,0 +1,122 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Store persists K-View's own state (scaling rules, approvals, ...) as one JSON document per
+// collection. By default documents are files in the data directory, which the Helm chart
+// mounts at /data, backed by a PVC when persistence is enabled. With a database URL they are
+// rows in Postgres or MySQL instead, so replicas do not need a shared volume. Documents are
+// small, so every update rewrites the whole document.
+//
+// Writes hold a lock shared by all replicas (an advisory lock on the data directory, or a row
+// lock in the database), so replicas do not interleave read-modify-write cycles. Handlers that
+// cache a document compare its Stamp periodically and reload it when another replica has
+// written it.
+type Store struct {
+	backend backend
+}
+
+// backend keeps the documents and journals of a Store: files in a data directory or tables in
+// a database.
+type backend interface {
+	// locked runs fn holding the lock shared by all replicas. Database backends run fn in one
+	// transaction, so nothing fn wrote is kept if it fails.
+	locked(fn func(st storage) error) error
+	// view runs fn against the current contents without the lock.
+	view(fn func(st storage) error) error
+	stamp(name string) Stamp
+}
+
+// storage reads and writes the contents of a backend. Journal days are formatted as journalDay.
+type storage interface {
+	read(name string) ([]byte, error) // nil when the document does not exist
+	write(name string, data []byte) error
+	documents() ([]string, error)
+	journals() ([]string, error)
+	appendLines(journal, day string, lines []byte) error
+	days(journal string) ([]time.Time, error) // oldest first
+	readDay(journal, day string) ([]byte, error)
+	scanDay(journal, day string, fn func(line []byte) error) error
+	removeDay(journal, day string) error
+	daySize(journal, day string) (int64, error)
+	// stage returns a new directory to unpack a backup archive into; the caller removes it.
+	stage() (string, error)
+	// replace swaps every document, and the listed journals, for the contents of a staging
+	// directory, entirely or not at all.
+	replace(staging string, journals []string) error
+	// removeTemp deletes what interrupted writes left behind.
+	removeTemp() error
+}
+
+// Open prepares the data directory, creating it if needed.
+func Open(dir string) (*Store, error) {
+	b, err := openFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{backend: b}, nil
+}
+
+func load(st storage, name string, v interface{}) error {
+	data, err := st.read(name)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", name, err)
+	}
+	if data == nil {
+		return nil // Nothing stored yet: leave v at its zero value
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", name, err)
+	}
+	return nil
+}
+
+func save(st storage, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %v", name, err)
+	}
+	if err := st.write(name, data); err != nil {
+		return fmt.Errorf("failed to write %s: %v", name, err)
+	}
+	return nil
+}
+
+// Load decodes the named document into v. A missing document is not an error.
+func (s *Store) Load(name string, v interface{}) error {
+	return s.backend.view(func(st storage) error {
+		return load(st, name, v)
+	})
+}
+
+// Stamp identifies the version of a document; it changes whenever the document is saved, by
+// this process or another replica. Files are told apart by ModTime and Size, database rows by
+// Version.
+type Stamp struct {
+	ModTime time.Time
+	Size    int64
+	Version int64
+}
+
+// Stamp returns the current stamp of the named document, the zero Stamp when it does not exist.
+func (s *Store) Stamp(name string) Stamp {
+	return s.backend.stamp(name)
+}
+
+// Update loads the named document into v, applies fn and saves the result. The document is
+// not written if fn returns an error.
+func (s *Store) Update(name string, v interface{}, fn func() error) error {
+	return s.backend.locked(func(st storage) error {
+		if err := load(st, name, v); err != nil {
+			return err
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+		return save(st, name, v)
+	})
+}
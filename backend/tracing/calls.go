@@ -0,0 +1,92 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// maxCalls bounds the calls remembered per request; list-heavy pages can make hundreds.
+const maxCalls = 100
+
+// APICall is one Kubernetes API call made while serving a request.
+type APICall struct {
+	Verb       string `json:"verb"`     // get, list, watch, create, update, patch, delete, ...
+	Resource   string `json:"resource"` // API path with namespace and name placeholders
+	Status     int    `json:"status"`   // HTTP status, 0 when the call failed without a response
+	DurationMs int64  `json:"durationMs"`
+}
+
+// CallLog collects the Kubernetes API calls made on behalf of one request. Unlike spans it is
+// recorded whether or not tracing is enabled.
+type CallLog struct {
+	mu      sync.Mutex
+	calls   []APICall
+	omitted int
+}
+
+func (l *CallLog) add(call APICall) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.calls) >= maxCalls {
+		l.omitted++
+		return
+	}
+	l.calls = append(l.calls, call)
+}
+
+// Calls returns the recorded calls and how many more were made beyond the recorded ones.
+func (l *CallLog) Calls() ([]APICall, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]APICall(nil), l.calls...), l.omitted
+}
+
+type callLogKey struct{}
+
+// TrackCalls returns a context in which Kubernetes API calls are recorded into the returned log.
+func TrackCalls(ctx context.Context) (context.Context, *CallLog) {
+	l := &CallLog{}
+	return context.WithValue(ctx, callLogKey{}, l), l
+}
+
+func callLogFrom(ctx context.Context) *CallLog {
+	l, _ := ctx.Value(callLogKey{}).(*CallLog)
+	return l
+}
+
+// apiVerb derives the Kubernetes verb of an API request, as the API server's audit log does.
+func apiVerb(req *http.Request, named bool) string {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		if req.URL.Query().Get("watch") == "true" || req.URL.Query().Get("watch") == "1" {
+			return "watch"
+		}
+		if named {
+			return "get"
+		}
+		return "list"
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut:
+		return "update"
+	case http.MethodPatch:
+		return "patch"
+	case http.MethodDelete:
+		if named {
+			return "delete"
+		}
+		return "deletecollection"
+	}
+	return req.Method
+}
+
+func recordCall(l *CallLog, req *http.Request, resp *http.Response, start time.Time) {
+	route, named := apiRoute(req.URL.Path)
+	call := APICall{Verb: apiVerb(req, named), Resource: route, DurationMs: time.Since(start).Milliseconds()}
+	if resp != nil {
+		call.Status = resp.StatusCode
+	}
+	l.add(call)
+}
@@ -0,0 +1,123 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware starts a server span for every request, continuing the caller's trace when a
+// traceparent header is present, and names it after the matched route.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !Enabled() {
+			c.Next()
+			return
+		}
+		ctx := c.Request.Context()
+		if sc, ok := parseTraceparent(c.GetHeader("traceparent")); ok {
+			ctx = withRemote(ctx, sc)
+		}
+		ctx, span := Start(ctx, c.Request.Method+" "+c.Request.URL.Path, KindServer)
+		c.Request = c.Request.WithContext(ctx)
+		// Lets a client or proxy log the trace ID for a slow page
+		c.Header("traceresponse", traceparent(span.sc))
+
+		c.Next()
+
+		if route := c.FullPath(); route != "" {
+			span.SetName(c.Request.Method + " " + route)
+			span.SetAttr("http.route", route)
+		}
+		status := c.Writer.Status()
+		span.SetAttr("http.request.method", c.Request.Method)
+		span.SetAttr("url.path", c.Request.URL.Path)
+		span.SetAttr("http.response.status_code", status)
+		if email := c.GetString("email"); email != "" {
+			span.SetAttr("enduser.id", email)
+		}
+		if status >= 500 {
+			span.SetError(http.StatusText(status))
+		}
+		span.End()
+	}
+}
+
+// apiRoute returns a Kubernetes API path with namespaces and object names replaced by
+// placeholders, so that calls group by resource, and whether it addresses a single object:
+// /api/v1/namespaces/prod/pods/web-1/log becomes /api/v1/namespaces/{namespace}/pods/{name}/log.
+func apiRoute(path string) (string, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	prefix := 0
+	switch {
+	case len(parts) >= 2 && parts[0] == "api":
+		prefix = 2
+	case len(parts) >= 3 && parts[0] == "apis":
+		prefix = 3
+	default:
+		return path, false // /version, /livez, ...
+	}
+	rest := parts[prefix:]
+	if len(rest) >= 3 && rest[0] == "namespaces" {
+		rest[1] = "{namespace}"
+		rest = rest[2:]
+	}
+	if len(rest) >= 2 {
+		rest[1] = "{name}"
+	}
+	return "/" + strings.Join(parts, "/"), len(rest) >= 2
+}
+
+type transport struct {
+	next http.RoundTripper
+}
+
+// Transport wraps a client-go round tripper so every Kubernetes API call becomes a client span
+// and carries the trace context to the API server (which records its own spans when the
+// APIServerTracing feature is on). Calls are also added to the request's CallLog, if any.
+// It costs nothing for calls made outside of a traced or tracked request.
+func Transport(rt http.RoundTripper) http.RoundTripper {
+	return &transport{next: rt}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	calls := callLogFrom(req.Context())
+	traced := Enabled() && FromContext(req.Context()) != nil
+	if !traced && calls == nil {
+		return t.next.RoundTrip(req)
+	}
+	start := time.Now()
+	var span *Span
+	if traced {
+		route, _ := apiRoute(req.URL.Path)
+		var ctx context.Context
+		ctx, span = Start(req.Context(), req.Method+" "+route, KindClient)
+		req = req.Clone(ctx)
+		req.Header.Set("traceparent", traceparent(span.sc))
+		span.SetAttr("http.request.method", req.Method)
+		span.SetAttr("url.full", req.URL.String())
+		span.SetAttr("server.address", req.URL.Hostname())
+		if user := req.Header.Get("Impersonate-User"); user != "" {
+			span.SetAttr("k8s.impersonate.user", user)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if calls != nil {
+		recordCall(calls, req, resp, start)
+	}
+	if err != nil {
+		span.SetError(err.Error())
+	} else {
+		span.SetAttr("http.response.status_code", resp.StatusCode)
+		if resp.StatusCode >= 400 {
+			span.SetError(strconv.Itoa(resp.StatusCode) + " " + http.StatusText(resp.StatusCode))
+		}
+	}
+	span.End()
+	return resp, err
+}
@@ -0,0 +1,231 @@
+// Package tracing records OpenTelemetry-compatible spans for API requests and the Kubernetes API
+// calls they make, and exports them over OTLP/HTTP (JSON encoding) to a collector.
+//
+// It is configured with the standard OpenTelemetry environment variables and is off unless an
+// OTLP endpoint is set:
+//
+//	OTEL_EXPORTER_OTLP_ENDPOINT          collector base URL, e.g. http://otel-collector:4318
+//	OTEL_EXPORTER_OTLP_TRACES_ENDPOINT   full traces URL (overrides the above)
+//	OTEL_EXPORTER_OTLP_HEADERS           extra headers, "key=value,key2=value2"
+//	OTEL_SERVICE_NAME                    service.name resource attribute (default "k-view")
+//	OTEL_TRACES_SAMPLER_ARG              ratio of new traces to sample, 0..1 (default 1)
+//
+// Trace context is propagated with the W3C traceparent header in both directions.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Span kinds, as numbered by OTLP.
+const (
+	KindInternal = 1
+	KindServer   = 2
+	KindClient   = 3
+)
+
+// statusError is the OTLP status code of a failed span.
+const statusError = 2
+
+type spanContext struct {
+	traceID [16]byte
+	spanID  [8]byte
+	sampled bool
+}
+
+func (sc spanContext) valid() bool {
+	return sc.traceID != [16]byte{} && sc.spanID != [8]byte{}
+}
+
+// Span is a timed operation within a trace. A nil *Span is valid and records nothing, so callers
+// never need to check whether tracing is enabled.
+type Span struct {
+	sc        spanContext
+	parentID  [8]byte
+	name      string
+	kind      int
+	start     time.Time
+	end       time.Time
+	mu        sync.Mutex
+	attrs     map[string]interface{}
+	status    int
+	statusMsg string
+}
+
+// SetAttr records an attribute (string, bool, int, int64 or float64) on the span.
+func (s *Span) SetAttr(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.attrs[key] = value
+	s.mu.Unlock()
+}
+
+// SetName renames the span, e.g. once the matched route is known.
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.name = name
+	s.mu.Unlock()
+}
+
+// SetError marks the span as failed.
+func (s *Span) SetError(msg string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.status, s.statusMsg = statusError, msg
+	s.mu.Unlock()
+}
+
+// End finishes the span and queues it for export.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.end = time.Now()
+	if t := current(); t != nil && s.sc.sampled {
+		t.exporter.enqueue(s)
+	}
+}
+
+// tracer holds the active configuration; nil means tracing is disabled.
+type tracer struct {
+	service  string
+	ratio    float64
+	exporter *exporter
+}
+
+var (
+	mu     sync.RWMutex
+	active *tracer
+)
+
+func current() *tracer {
+	mu.RLock()
+	defer mu.RUnlock()
+	return active
+}
+
+// Enabled reports whether spans are being recorded.
+func Enabled() bool {
+	return current() != nil
+}
+
+// InitFromEnv enables tracing when an OTLP endpoint is configured and returns that endpoint, or
+// "" when tracing stays disabled.
+func InitFromEnv() (string, error) {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if endpoint == "" {
+		if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+			endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+		}
+	}
+	if endpoint == "" {
+		return "", nil
+	}
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = "k-view"
+	}
+	ratio := 1.0
+	if v := os.Getenv("OTEL_TRACES_SAMPLER_ARG"); v != "" {
+		r, err := strconv.ParseFloat(v, 64)
+		if err != nil || r < 0 || r > 1 {
+			return "", fmt.Errorf("OTEL_TRACES_SAMPLER_ARG must be a ratio between 0 and 1, got %q", v)
+		}
+		ratio = r
+	}
+	headers := http.Header{}
+	for _, pair := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		if k, v, ok := strings.Cut(pair, "="); ok {
+			headers.Set(strings.TrimSpace(k), strings.TrimSpace(v))
+		}
+	}
+
+	t := &tracer{service: service, ratio: ratio, exporter: newExporter(endpoint, service, headers)}
+	mu.Lock()
+	active = t
+	mu.Unlock()
+	return endpoint, nil
+}
+
+type ctxKey struct{}
+
+// FromContext returns the span stored in ctx, or nil.
+func FromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(ctxKey{}).(*Span)
+	return s
+}
+
+type remoteKey struct{}
+
+// withRemote stores a span context received from a caller so the next span continues its trace.
+func withRemote(ctx context.Context, sc spanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Start begins a span as a child of the span (or remote caller) in ctx. It returns a nil span when
+// tracing is disabled.
+func Start(ctx context.Context, name string, kind int) (context.Context, *Span) {
+	t := current()
+	if t == nil {
+		return ctx, nil
+	}
+	s := &Span{name: name, kind: kind, start: time.Now(), attrs: map[string]interface{}{}}
+	if parent := FromContext(ctx); parent != nil {
+		s.sc.traceID, s.parentID, s.sc.sampled = parent.sc.traceID, parent.sc.spanID, parent.sc.sampled
+	} else if remote, ok := ctx.Value(remoteKey{}).(spanContext); ok && remote.valid() {
+		s.sc.traceID, s.parentID, s.sc.sampled = remote.traceID, remote.spanID, remote.sampled
+	} else {
+		_, _ = rand.Read(s.sc.traceID[:])
+		// Sample on the trace ID so every service makes the same decision for a trace
+		s.sc.sampled = float64(binary.BigEndian.Uint64(s.sc.traceID[8:])>>11)/float64(1<<53) < t.ratio
+	}
+	_, _ = rand.Read(s.sc.spanID[:])
+	return context.WithValue(ctx, ctxKey{}, s), s
+}
+
+// traceparent formats a span context as a W3C traceparent header value.
+func traceparent(sc spanContext) string {
+	flags := "00"
+	if sc.sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(sc.traceID[:]) + "-" + hex.EncodeToString(sc.spanID[:]) + "-" + flags
+}
+
+// parseTraceparent reads a W3C traceparent header value.
+func parseTraceparent(v string) (spanContext, bool) {
+	var sc spanContext
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.traceID[:], []byte(parts[1])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.spanID[:], []byte(parts[2])); err != nil {
+		return sc, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return sc, false
+	}
+	sc.sampled = flags[0]&1 == 1
+	return sc, sc.valid()
+}
@@ -0,0 +1,114 @@
+// Package web serves the compiled frontend. The Vite build is copied into web/dist before
+// go build and embedded in the binary; a binary built without it serves ./web/dist from the
+// working directory instead, as K-View did before the assets were embedded.
+package web
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"k-view/apierror"
+)
+
+//go:embed all:dist
+var embedded embed.FS
+
+// basePathPattern accepts URL paths of plain segments, so the path can be written into
+// index.html as is.
+var basePathPattern = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+
+// NormalizeBasePath checks a KVIEW_BASE_PATH value and returns it with a leading slash and
+// without a trailing one; "" and "/" mean the root and return "".
+func NormalizeBasePath(p string) (string, error) {
+	p = strings.TrimSuffix(strings.TrimSpace(p), "/")
+	if p == "" {
+		return "", nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	if !basePathPattern.MatchString(p) {
+		return "", fmt.Errorf("invalid base path %q: use path segments of letters, digits and . _ ~ -", p)
+	}
+	return p, nil
+}
+
+// files returns the embedded build when there is one, and ./web/dist otherwise.
+func files() fs.FS {
+	dist, err := fs.Sub(embedded, "dist")
+	if err == nil {
+		if _, err := fs.Stat(dist, "index.html"); err == nil {
+			return dist
+		}
+	}
+	return os.DirFS("web/dist")
+}
+
+// Register serves the frontend on router: the static files of the build, and index.html for
+// every other path outside /api so React Router can handle client-side routes (e.g. /admin,
+// /login). basePath is the subpath K-View is hosted under ("" for the root); index.html gets a
+// <base> element for it, so the relative asset URLs of the build resolve, and hands it to the
+// frontend as window.__KVIEW_BASE_PATH__.
+func Register(router *gin.Engine, basePath string) {
+	dist := files()
+	head := fmt.Sprintf("<head>\n  <base href=\"%s/\" />\n  <script>window.__KVIEW_BASE_PATH__ = %q;</script>", basePath, basePath)
+	fileServer := http.FileServer(http.FS(dist))
+
+	router.NoRoute(func(c *gin.Context) {
+		path := c.Request.URL.Path
+		if path == "/api" || strings.HasPrefix(path, "/api/") {
+			apierror.Write(c, http.StatusNotFound, "Not found")
+			return
+		}
+		if name := strings.TrimPrefix(path, "/"); name != "" && name != "index.html" {
+			if info, err := fs.Stat(dist, name); err == nil && !info.IsDir() {
+				if strings.HasPrefix(name, "assets/") {
+					// Vite fingerprints the asset file names
+					c.Header("Cache-Control", "public, max-age=31536000, immutable")
+				}
+				fileServer.ServeHTTP(c.Writer, c.Request)
+				return
+			}
+		}
+		index, err := fs.ReadFile(dist, "index.html")
+		if err != nil {
+			c.String(http.StatusNotFound, "The K-View frontend is not built")
+			return
+		}
+		c.Header("Cache-Control", "no-cache")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", bytes.Replace(index, []byte("<head>"), []byte(head), 1))
+	})
+}
+
+// StripBasePath serves h under basePath: requests below it reach h with the prefix removed,
+// the base path itself redirects to its trailing-slash form, and anything else is 404. With
+// an empty basePath, h is returned unchanged.
+func StripBasePath(basePath string, h http.Handler) http.Handler {
+	if basePath == "" {
+		return h
+	}
+	stripped := http.StripPrefix(basePath, h)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == basePath {
+			target := basePath + "/"
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, basePath+"/") {
+			http.NotFound(w, r)
+			return
+		}
+		stripped.ServeHTTP(w, r)
+	})
+}
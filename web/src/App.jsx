@@ -1,5 +1,5 @@
 import React, { useEffect, useState } from 'react';
-import { BrowserRouter as Router, Routes, Route, Navigate, useLocation } from 'react-router-dom';
+import { BrowserRouter as Router, Routes, Route, Navigate, useLocation, useParams } from 'react-router-dom';
 import Login from './components/Login';
 import Dashboard from './components/Dashboard';
 import Nodes from './components/Nodes';
@@ -7,6 +7,13 @@ import Console from './components/Console';
 import AdminPanel from './components/AdminPanel';
 import ResourceList from './components/ResourceList';
 import ResourceDetails from './components/ResourceDetails';
+import CertificateRequests from './components/CertificateRequests';
+import Rollouts from './components/Rollouts';
+import Policies from './components/Policies';
+import Terminating from './components/Terminating';
+import { setFeatures, featureEnabled } from './features';
+import { basePath, withBase } from './basePath';
+import { loadColumns } from './columns';
 
 import logo from './assets/k-view-logo.png';
 import background from './assets/background.png';
@@ -16,7 +23,7 @@ import {
     Boxes, Package, GitBranch, RefreshCw, Clock, Network, Globe,
     FileText, Lock, Database, Puzzle, ChevronDown, ChevronRight,
     Shield, Key, Users, Link, AlertTriangle, Globe2, Activity,
-    Settings, Moon, Sun, Palette
+    Settings, Moon, Sun, Palette, FileKey, Rocket, ShieldCheck, Hourglass
 } from 'lucide-react';
 
 // ── Collapsible section ────────────────────────────────────────────────────
@@ -53,11 +60,17 @@ function Section({ label, children, defaultOpen = true }) {
     );
 }
 
+// Lists a custom resource configured with custom columns
+function CustomResourceList() {
+    const { kind } = useParams();
+    return <ResourceList key={kind} kind={kind} />;
+}
+
 // ── Nav item ───────────────────────────────────────────────────────────────
 function NavItem({ href, icon: Icon, label, active }) {
     return (
         <a
-            href={href}
+            href={withBase(href)}
             className={`flex items-center gap-3 px-3 py-1.5 rounded-xl text-[13px] font-medium transition-all duration-200
         ${active
                     ? 'bg-[var(--accent)] text-white shadow-lg shadow-indigo-500/20'
@@ -73,6 +86,11 @@ function NavItem({ href, icon: Icon, label, active }) {
 // ── Sidebar ────────────────────────────────────────────────────────────────
 function Sidebar({ user, onLogout, theme, setTheme }) {
     const { pathname: p } = useLocation();
+    const [customKinds, setCustomKinds] = useState([]);
+
+    useEffect(() => {
+        loadColumns().then(kinds => setCustomKinds(kinds.filter(k => k.group)));
+    }, []);
 
     return (
         <aside className="w-64 bg-[var(--bg-sidebar)] border-r border-[var(--border-color)] flex-col hidden md:flex h-full shrink-0 transition-colors duration-200 shadow-2xl z-20">
@@ -96,6 +114,7 @@ function Sidebar({ user, onLogout, theme, setTheme }) {
                     <NavItem href="/workloads/daemonsets" icon={RefreshCw} label="DaemonSets" active={p === '/workloads/daemonsets'} />
                     <NavItem href="/workloads/jobs" icon={Database} label="Jobs" active={p === '/workloads/jobs'} />
                     <NavItem href="/workloads/cronjobs" icon={Clock} label="CronJobs" active={p === '/workloads/cronjobs'} />
+                    <NavItem href="/workloads/rollouts" icon={Rocket} label="Rollouts" active={p === '/workloads/rollouts'} />
                 </Section>
 
                 <Section label="Services" defaultOpen={false}>
@@ -115,6 +134,9 @@ function Sidebar({ user, onLogout, theme, setTheme }) {
                     <NavItem href="/cluster/ingress-classes" icon={Globe} label="Ingress Classes" active={p === '/cluster/ingress-classes'} />
                     <NavItem href="/config/storage-classes" icon={Database} label="Storage Classes" active={p === '/config/storage-classes'} />
                     <NavItem href="/crd" icon={Puzzle} label="Custom Resources" active={p === '/crd'} />
+                    {customKinds.map(k => (
+                        <NavItem key={k.kind} href={`/custom/${k.kind}`} icon={Puzzle} label={k.kind} active={p === `/custom/${k.kind}`} />
+                    ))}
                     <NavItem href="/cluster/cluster-role-bindings" icon={Link} label="Cluster Role Bindings" active={p === '/cluster/cluster-role-bindings'} />
                     <NavItem href="/cluster/cluster-roles" icon={Shield} label="Cluster Roles" active={p === '/cluster/cluster-roles'} />
                     <NavItem href="/cluster/network-policies" icon={AlertTriangle} label="Network Policies" active={p === '/cluster/network-policies'} />
@@ -122,11 +144,16 @@ function Sidebar({ user, onLogout, theme, setTheme }) {
                     <NavItem href="/cluster/role-bindings" icon={Key} label="Role Bindings" active={p === '/cluster/role-bindings'} />
                     <NavItem href="/cluster/roles" icon={Key} label="Roles" active={p === '/cluster/roles'} />
                     <NavItem href="/cluster/service-accounts" icon={Users} label="Service Accounts" active={p === '/cluster/service-accounts'} />
+                    <NavItem href="/cluster/csrs" icon={FileKey} label="Certificate Requests" active={p === '/cluster/csrs'} />
+                    <NavItem href="/cluster/policies" icon={ShieldCheck} label="Policy Violations" active={p === '/cluster/policies'} />
+                    <NavItem href="/cluster/terminating" icon={Hourglass} label="Stuck Deletions" active={p === '/cluster/terminating'} />
                 </Section>
 
-                <Section label="Tools" defaultOpen={false}>
-                    <NavItem href="/console" icon={Terminal} label="Console" active={p === '/console'} />
-                </Section>
+                {featureEnabled('console') && (
+                    <Section label="Tools" defaultOpen={false}>
+                        <NavItem href="/console" icon={Terminal} label="Console" active={p === '/console'} />
+                    </Section>
+                )}
 
                 {/* Settings Section at the bottom of the nav list */}
                 <div className="mt-auto pt-4">
@@ -168,7 +195,7 @@ function Sidebar({ user, onLogout, theme, setTheme }) {
             <div className="px-4 py-6 border-t border-[var(--border-color)] space-y-4">
                 {(user.role === 'kview-cluster-admin' || user.role === 'admin') && (
                     <a
-                        href="/access"
+                        href={withBase('/access')}
                         className={`flex items-center gap-3 px-3 py-2 rounded-xl text-[13px] font-bold transition-all w-full
                 ${p === '/access'
                                 ? 'bg-red-500/10 text-red-400 border border-red-500/20'
@@ -190,13 +217,23 @@ function Sidebar({ user, onLogout, theme, setTheme }) {
                             PRODUCTION
                         </div>
                     )}
-                    <button
-                        onClick={onLogout}
-                        className="p-2 rounded-xl bg-red-500/10 text-red-500 border border-red-500/20 hover:bg-red-500/20 hover:text-red-400 transition-all active:scale-90 flex items-center justify-center group shadow-sm"
-                        title="Logout"
-                    >
-                        <LogOut size={20} className="group-hover:translate-x-0.5 transition-transform" />
-                    </button>
+                    {user.anonymous ? (
+                        <a
+                            href={withBase('/login')}
+                            className="px-3 py-2 rounded-xl bg-blue-500/10 text-blue-400 border border-blue-500/20 hover:bg-blue-500/20 transition-all text-[12px] font-bold"
+                            title="Browsing anonymously"
+                        >
+                            Sign in
+                        </a>
+                    ) : (
+                        <button
+                            onClick={onLogout}
+                            className="p-2 rounded-xl bg-red-500/10 text-red-500 border border-red-500/20 hover:bg-red-500/20 hover:text-red-400 transition-all active:scale-90 flex items-center justify-center group shadow-sm"
+                            title="Logout"
+                        >
+                            <LogOut size={20} className="group-hover:translate-x-0.5 transition-transform" />
+                        </button>
+                    )}
                 </div>
             </div>
         </aside>
@@ -218,12 +255,14 @@ window.fetch = async (...args) => {
         };
     }
 
-    const response = await originalFetch(resource, config);
+    // API paths are relative to the base path K-View is served under
+    const url = typeof resource === 'string' && resource.startsWith('/api/') ? withBase(resource) : resource;
+    const response = await originalFetch(url, config);
     // If we're unauthorized, force clear token and prompt login
     if (response.status === 401 && resource !== '/api/auth/me') {
         localStorage.removeItem('token');
-        if (window.location.pathname !== '/login') {
-            window.location.href = '/login';
+        if (window.location.pathname !== withBase('/login')) {
+            window.location.href = withBase('/login');
         }
     }
     return response;
@@ -246,16 +285,18 @@ function App() {
     useEffect(() => {
         fetch('/api/auth/me')
             .then(r => r.ok ? r.json() : Promise.reject())
-            .then(d => setUser(d))
+            .then(d => { setFeatures(d.features); setUser(d); })
             .catch(() => setUser(null))
             .finally(() => setLoading(false));
     }, []);
 
     const handleLogout = async () => {
-        await fetch('/api/auth/logout', { method: 'POST' });
+        const res = await fetch('/api/auth/logout', { method: 'POST' });
+        const data = res.ok ? await res.json().catch(() => ({})) : {};
         localStorage.removeItem('token');
         setUser(null);
-        window.location.href = '/login';
+        // Single sign-out: the IdP ends its own session, then returns to K-View
+        window.location.href = data.redirect || withBase('/login');
     };
 
     if (loading) {
@@ -265,7 +306,7 @@ function App() {
     const protect = (el) => user ? el : <Navigate to="/login" />;
 
     return (
-        <Router>
+        <Router basename={basePath || '/'}>
             <div className={`flex h-screen bg-[var(--bg-main)] text-[var(--text-primary)] relative overflow-hidden transition-colors duration-200`}>
                 <div
                     className="absolute inset-0 pointer-events-none z-0 transition-all duration-500"
@@ -278,18 +319,18 @@ function App() {
                         filter: `grayscale(100%) brightness(var(--wallpaper-brightness))`,
                     }}
                 />
-                {user && (
+                {user && !(user.anonymous && window.location.pathname === withBase('/login')) && (
                     <Sidebar user={user} onLogout={handleLogout} theme={theme} setTheme={setTheme} />
                 )}
                 <main className="flex-1 overflow-auto flex flex-col">
                     <Routes>
                         {/* Auth */}
-                        <Route path="/login" element={!user ? <Login /> : <Navigate to="/" />} />
+                        <Route path="/login" element={!user || user.anonymous ? <Login /> : <Navigate to="/" />} />
 
                         {/* Top-level */}
                         <Route path="/" element={protect(<Dashboard />)} />
                         <Route path="/nodes" element={protect(<Nodes />)} />
-                        <Route path="/console" element={protect(<Console />)} />
+                        <Route path="/console" element={featureEnabled('console') ? protect(<Console />) : <Navigate to="/" />} />
 
                         {/* Workloads */}
                         <Route path="/workloads/pods" element={protect(<ResourceList kind="pods" />)} />
@@ -298,6 +339,7 @@ function App() {
                         <Route path="/workloads/daemonsets" element={protect(<ResourceList kind="daemonsets" />)} />
                         <Route path="/workloads/jobs" element={protect(<ResourceList kind="jobs" />)} />
                         <Route path="/workloads/cronjobs" element={protect(<ResourceList kind="cronjobs" />)} />
+                        <Route path="/workloads/rollouts" element={protect(<Rollouts user={user} />)} />
 
                         {/* Services / Networking */}
                         <Route path="/network/services" element={protect(<ResourceList kind="services" />)} />
@@ -312,6 +354,7 @@ function App() {
 
                         {/* CRD */}
                         <Route path="/crd" element={protect(<ResourceList kind="crds" />)} />
+                        <Route path="/custom/:kind" element={protect(<CustomResourceList />)} />
 
                         {/* Cluster */}
                         <Route path="/cluster/cluster-role-bindings" element={protect(<ResourceList kind="cluster-role-bindings" />)} />
@@ -322,6 +365,9 @@ function App() {
                         <Route path="/cluster/role-bindings" element={protect(<ResourceList kind="role-bindings" />)} />
                         <Route path="/cluster/roles" element={protect(<ResourceList kind="roles" />)} />
                         <Route path="/cluster/service-accounts" element={protect(<ResourceList kind="service-accounts" />)} />
+                        <Route path="/cluster/csrs" element={protect(<CertificateRequests user={user} />)} />
+                        <Route path="/cluster/policies" element={protect(<Policies />)} />
+                        <Route path="/cluster/terminating" element={protect(<Terminating user={user} />)} />
 
                         <Route path="/:kind/:namespace/:name" element={protect(<ResourceDetails user={user} />)} />
                         <Route path="/access" element={user && (user.role === 'kview-cluster-admin' || user.role === 'admin') ? protect(<AdminPanel />) : <Navigate to="/" />} />
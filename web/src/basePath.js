@@ -0,0 +1,8 @@
+// Subpath K-View is served under (e.g. "/k-view"), written into index.html by the backend from
+// KVIEW_BASE_PATH. Empty when served at the root and under the Vite dev server.
+export const basePath = window.__KVIEW_BASE_PATH__ || '';
+
+// withBase prefixes an absolute app path such as "/login" or "/api/auth/me" with the base path.
+export function withBase(path) {
+    return basePath + path;
+}
@@ -0,0 +1,15 @@
+// Custom list columns configured on the server (/api/columns), fetched once and shared by the
+// resource lists and the sidebar. Kinds with a group are custom resources K-View lists.
+let pending = null;
+
+export function loadColumns() {
+    if (!pending) {
+        pending = fetch('/api/columns')
+            .then(r => r.ok ? r.json() : Promise.reject())
+            .catch(() => {
+                pending = null; // Try again next time, e.g. after logging in
+                return [];
+            });
+    }
+    return pending;
+}
@@ -0,0 +1,167 @@
+import React, { useState, useEffect, useCallback } from 'react';
+import { FileKey, CheckCircle, XCircle, Clock, AlertTriangle, RefreshCw } from 'lucide-react';
+
+const statusStyles = {
+    Pending: 'text-amber-400 bg-amber-500/10',
+    Approved: 'text-blue-400 bg-blue-500/10',
+    Issued: 'text-green-400 bg-green-500/10',
+    Denied: 'text-red-400 bg-red-500/10',
+    Failed: 'text-red-400 bg-red-500/10',
+};
+
+function StatusBadge({ status }) {
+    return (
+        <span className={`text-xs font-semibold px-2 py-0.5 rounded-full ${statusStyles[status] || 'text-[var(--text-muted)]'}`}>
+            {status}
+        </span>
+    );
+}
+
+export default function CertificateRequests({ user }) {
+    const [csrs, setCsrs] = useState([]);
+    const [loading, setLoading] = useState(true);
+    const [error, setError] = useState(null);
+    const [busy, setBusy] = useState(null);
+    const isAdmin = user && (user.role === 'kview-cluster-admin' || user.role === 'admin');
+
+    const load = useCallback(() => {
+        setLoading(true);
+        fetch('/api/csrs')
+            .then(r => r.ok ? r.json() : r.json().then(d => Promise.reject(new Error(d.error || 'Failed to fetch certificate signing requests'))))
+            .then(data => { setCsrs(data || []); setError(null); })
+            .catch(e => setError(e.message))
+            .finally(() => setLoading(false));
+    }, []);
+
+    useEffect(() => {
+        load();
+    }, [load]);
+
+    const decide = async (csr, action) => {
+        let message = '';
+        if (action === 'deny') {
+            message = window.prompt(`Reason for denying ${csr.name}:`, '');
+            if (message === null) return;
+        } else if (csr.warnings?.length && !window.confirm(`Approve ${csr.name} despite:\n\n${csr.warnings.join('\n')}`)) {
+            return;
+        }
+        setBusy(csr.name);
+        const res = await fetch(`/api/csrs/${csr.name}/${action}`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ message }),
+        });
+        const data = await res.json().catch(() => ({}));
+        setBusy(null);
+        if (!res.ok) {
+            setError(data.error || `Failed to ${action} ${csr.name}`);
+            return;
+        }
+        load();
+    };
+
+    const pending = csrs.filter(c => c.status === 'Pending').length;
+
+    return (
+        <div className="p-8">
+            <div className="mb-8 flex items-start justify-between">
+                <div>
+                    <h2 className="text-2xl font-bold text-[var(--text-white)] mb-1">Certificate Requests</h2>
+                    <p className="text-[var(--text-secondary)] text-sm">
+                        {loading ? 'Loading...' : `${pending} pending of ${csrs.length} certificate signing request${csrs.length !== 1 ? 's' : ''}`}
+                    </p>
+                </div>
+                <button
+                    onClick={load}
+                    className="p-2 rounded-xl bg-[var(--bg-card)] border border-[var(--border-color)] text-[var(--text-muted)] hover:text-[var(--text-white)] transition-all"
+                    title="Refresh"
+                >
+                    <RefreshCw size={16} />
+                </button>
+            </div>
+
+            {error && (
+                <div className="mb-6 p-4 bg-red-900/30 border border-red-800 text-red-400 rounded-lg text-sm">{error}</div>
+            )}
+
+            <div className="bg-[var(--bg-glass)] glass rounded-2xl border border-[var(--border-color)] overflow-hidden shadow-xl">
+                <div className="overflow-x-auto">
+                    <table className="w-full text-sm text-left text-[var(--text-primary)]">
+                        <thead className="text-xs text-[var(--text-muted)] bg-[var(--bg-muted)]/60 uppercase tracking-wider border-b border-[var(--border-color)]">
+                            <tr>
+                                <th className="px-4 py-3">Request</th>
+                                <th className="px-4 py-3">Signer</th>
+                                <th className="px-4 py-3">Requestor</th>
+                                <th className="px-4 py-3">Subject</th>
+                                <th className="px-4 py-3">Status</th>
+                                <th className="px-4 py-3">Age</th>
+                                {isAdmin && <th className="px-4 py-3 text-right">Actions</th>}
+                            </tr>
+                        </thead>
+                        <tbody>
+                            {loading ? (
+                                <tr><td colSpan="7" className="px-4 py-8 text-center text-[var(--text-muted)] italic">Loading certificate signing requests...</td></tr>
+                            ) : csrs.length === 0 ? (
+                                <tr><td colSpan="7" className="px-4 py-8 text-center text-[var(--text-muted)]">No certificate signing requests.</td></tr>
+                            ) : (
+                                csrs.map(csr => (
+                                    <tr key={csr.name} className="border-b border-[var(--border-color)] hover:bg-[var(--sidebar-hover)]/30 transition-colors align-top">
+                                        <td className="px-4 py-3">
+                                            <div className="flex items-center gap-2 font-mono font-medium text-[var(--text-white)]">
+                                                <FileKey size={14} className="text-[var(--text-muted)] shrink-0" />
+                                                {csr.name}
+                                            </div>
+                                            {csr.warnings?.map((w, i) => (
+                                                <div key={i} className="flex items-center gap-1 text-xs text-amber-400 mt-1 ml-5">
+                                                    <AlertTriangle size={11} className="shrink-0" /> {w}
+                                                </div>
+                                            ))}
+                                        </td>
+                                        <td className="px-4 py-3 text-[var(--text-muted)] font-mono text-xs">{csr.signerName}</td>
+                                        <td className="px-4 py-3 text-xs">{csr.requestor}</td>
+                                        <td className="px-4 py-3 text-xs">
+                                            <div className="font-mono">{csr.commonName}</div>
+                                            {csr.organizations?.length > 0 && <div className="text-[var(--text-muted)]">O={csr.organizations.join(', ')}</div>}
+                                            {[...(csr.dnsNames || []), ...(csr.ipAddresses || [])].length > 0 && (
+                                                <div className="text-[var(--text-muted)]">SAN: {[...(csr.dnsNames || []), ...(csr.ipAddresses || [])].join(', ')}</div>
+                                            )}
+                                        </td>
+                                        <td className="px-4 py-3">
+                                            <StatusBadge status={csr.status} />
+                                            {csr.message && <div className="text-xs text-[var(--text-muted)] mt-1">{csr.message}</div>}
+                                        </td>
+                                        <td className="px-4 py-3 text-[var(--text-muted)] text-xs">
+                                            <div className="flex items-center gap-1"><Clock size={11} /> {new Date(csr.createdAt).toLocaleString()}</div>
+                                        </td>
+                                        {isAdmin && (
+                                            <td className="px-4 py-3 text-right whitespace-nowrap">
+                                                {csr.status === 'Pending' && (
+                                                    <>
+                                                        <button
+                                                            disabled={busy === csr.name}
+                                                            onClick={() => decide(csr, 'approve')}
+                                                            className="inline-flex items-center gap-1 px-3 py-1.5 rounded-lg bg-green-500/10 text-green-400 border border-green-500/20 hover:bg-green-500/20 text-xs font-bold transition-all disabled:opacity-50"
+                                                        >
+                                                            <CheckCircle size={12} /> Approve
+                                                        </button>
+                                                        <button
+                                                            disabled={busy === csr.name}
+                                                            onClick={() => decide(csr, 'deny')}
+                                                            className="inline-flex items-center gap-1 px-3 py-1.5 rounded-lg bg-red-500/10 text-red-400 border border-red-500/20 hover:bg-red-500/20 text-xs font-bold transition-all disabled:opacity-50 ml-2"
+                                                        >
+                                                            <XCircle size={12} /> Deny
+                                                        </button>
+                                                    </>
+                                                )}
+                                            </td>
+                                        )}
+                                    </tr>
+                                ))
+                            )}
+                        </tbody>
+                    </table>
+                </div>
+            </div>
+        </div>
+    );
+}
@@ -12,7 +12,10 @@ const NAMESPACES = ['default', 'auth', 'database', 'messaging', 'monitoring', 'l
 
 const PROMPT = '❯';
 
-const VERBS = ['get', 'describe', 'logs', 'top', 'delete', 'apply', 'edit', 'version', 'cluster-info'];
+// Commands reading their manifest from stdin get the one pasted below the prompt
+const readsStdin = (cmd) => /\s(-f|--filename)(\s+|=)-(\s|$)/.test(cmd);
+
+const VERBS = ['get', 'describe', 'logs', 'top', 'delete', 'apply', 'rollout', 'scale', 'edit', 'version', 'cluster-info'];
 const RESOURCES = ['pods', 'nodes', 'svc', 'deploy', 'ns', 'all', 'pv', 'pvc', 'cm', 'secret', 'ing', 'events'];
 const FLAGS = ['-A', '-o wide', '-n default', '-w', '--all-namespaces', '-o yaml'];
 
@@ -26,6 +29,7 @@ export default function Console() {
     const [cmdHistory, setCmdHistory] = useState([]);
     const [histIdx, setHistIdx] = useState(-1);
     const [loading, setLoading] = useState(false);
+    const [manifest, setManifest] = useState(''); // Sent as stdin to "-f -"
 
     const bottomRef = useRef(null);
     const inputRef = useRef(null);
@@ -189,7 +193,7 @@ export default function Console() {
             const res = await fetch('/api/console/exec', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
-                body: JSON.stringify({ command: cmd }),
+                body: JSON.stringify({ command: cmd, stdin: readsStdin(cmd) ? manifest : '' }),
             });
             const data = await res.json();
             const text = data.output ?? data.error ?? 'No output.';
@@ -201,7 +205,7 @@ export default function Console() {
             setLoading(false);
             setTimeout(focusAndEnd, 50);
         }
-    }, [bannerVisible, focusAndEnd]);
+    }, [bannerVisible, focusAndEnd, manifest]);
 
     const handleKeyDown = (e) => {
         if (e.key === 'Enter') {
@@ -329,6 +333,17 @@ export default function Console() {
                     />
                 </div>
 
+                {readsStdin(input) && (
+                    <textarea
+                        value={manifest}
+                        onChange={(e) => setManifest(e.target.value)}
+                        placeholder="Paste the manifest for -f - here"
+                        rows={8}
+                        spellCheck={false}
+                        className="mx-4 mb-3 p-2 bg-[var(--bg-muted)] border border-[var(--border-color)] rounded text-xs font-mono text-[var(--text-primary)] outline-none resize-y"
+                    />
+                )}
+
                 {/* Hint / Toolbar */}
                 <div className="px-4 py-1.5 flex gap-4 text-[10px] text-[var(--text-muted)] border-t border-[var(--border-color)] uppercase tracking-widest">
                     <span><kbd className="bg-[var(--bg-muted)] px-1 rounded text-[var(--text-muted)]">Enter</kbd> execute</span>
@@ -100,6 +100,9 @@ export default function Dashboard() {
         fetchStats();
     }, [fetchStats]);
 
+    // Without metrics-server the backend reports pod requests instead of usage
+    const requestsOnly = stats?.usageSource === 'requests';
+
     if (loading && !stats) {
         return (
             <div className="flex flex-col items-center justify-center h-full gap-4 text-[var(--text-secondary)]">
@@ -137,6 +140,7 @@ export default function Dashboard() {
                         <p className="font-bold text-sm">Metrics Server Missing</p>
                         <p className="text-xs opacity-80 mt-1">
                             Real-time CPU and RAM metrics are unavailable because Metrics Server is not installed in the cluster.
+                            {stats.usageSource === 'requests' && ' CPU and RAM below show resources requested by pods as a share of allocatable capacity, not actual usage.'}
                         </p>
                         <a
                             href="https://github.com/kubernetes-sigs/metrics-server"
@@ -212,10 +216,10 @@ export default function Dashboard() {
                 <div className="md:col-span-2 bg-[var(--bg-glass)] glass p-4 rounded-2xl border border-[var(--border-color)] shadow-lg hover:border-[var(--accent)]/30 transition-all duration-300 group">
                     <div className="flex items-center justify-between mb-5">
                         <div>
-                            <p className="text-[10px] font-bold text-[var(--text-muted)] uppercase tracking-[0.15em] mb-1.5">Compute Load (CPU)</p>
+                            <p className="text-[10px] font-bold text-[var(--text-muted)] uppercase tracking-[0.15em] mb-1.5">{requestsOnly ? 'Requested CPU' : 'Compute Load (CPU)'}</p>
                             <h3 className="text-3xl font-bold text-[var(--text-white)] flex items-baseline gap-2.5">
                                 {stats?.cpuUsage?.toFixed(2) || "0.00"}%
-                                <span className="text-xs text-[var(--text-secondary)] font-medium opacity-60">of {stats?.cpuTotal || '—'} cores</span>
+                                <span className="text-xs text-[var(--text-secondary)] font-medium opacity-60">of {requestsOnly ? 'allocatable' : `${stats?.cpuTotal || '—'} cores`}</span>
                             </h3>
                         </div>
                         <div className="p-2.5 rounded-xl text-indigo-400 bg-indigo-500/10 border border-indigo-500/20 group-hover:scale-110 transition-transform duration-300">
@@ -229,10 +233,10 @@ export default function Dashboard() {
                 <div className="md:col-span-2 bg-[var(--bg-glass)] glass p-4 rounded-2xl border border-[var(--border-color)] shadow-lg hover:border-[var(--accent)]/30 transition-all duration-300 group">
                     <div className="flex items-center justify-between mb-5">
                         <div>
-                            <p className="text-[10px] font-bold text-[var(--text-muted)] uppercase tracking-[0.15em] mb-1.5">Memory Pressure (RAM)</p>
+                            <p className="text-[10px] font-bold text-[var(--text-muted)] uppercase tracking-[0.15em] mb-1.5">{requestsOnly ? 'Requested Memory' : 'Memory Pressure (RAM)'}</p>
                             <h3 className="text-3xl font-bold text-[var(--text-white)] flex items-baseline gap-2.5">
                                 {stats?.ramUsage?.toFixed(2) || "0.00"}%
-                                <span className="text-xs text-[var(--text-secondary)] font-medium opacity-60">of {stats?.ramTotal || '—'}</span>
+                                <span className="text-xs text-[var(--text-secondary)] font-medium opacity-60">of {requestsOnly ? 'allocatable' : stats?.ramTotal || '—'}</span>
                             </h3>
                         </div>
                         <div className="p-2.5 rounded-xl text-violet-400 bg-violet-500/10 border border-violet-500/20 group-hover:scale-110 transition-transform duration-300">
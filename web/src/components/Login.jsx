@@ -1,14 +1,17 @@
 import React, { useState, useEffect } from 'react';
+import { withBase } from '../basePath';
 
 export default function Login() {
     const [devError, setDevError] = useState(null);
     const [loginError, setLoginError] = useState(null);
-    const [providers, setProviders] = useState({ oidc: false, local: false, dev: false });
+    const [providers, setProviders] = useState({ oidc: false, local: false, ldap: false, proxy: false, anonymous: false, dev: false });
     const [loading, setLoading] = useState(true);
     const [showLocalLogin, setShowLocalLogin] = useState(false);
 
     const [username, setUsername] = useState('');
     const [password, setPassword] = useState('');
+    const [code, setCode] = useState('');
+    const [mfaRequired, setMfaRequired] = useState(false);
     const [submitting, setSubmitting] = useState(false);
 
     useEffect(() => {
@@ -27,7 +30,7 @@ export default function Login() {
                 console.log('Auth providers received:', data);
                 setProviders(data);
                 // If only local is available, show it immediately
-                if (data.local && !data.oidc) {
+                if ((data.local || data.ldap) && !data.oidc) {
                     console.log('Force showing local login because OIDC is missing');
                     setShowLocalLogin(true);
                 }
@@ -41,7 +44,7 @@ export default function Login() {
     }, []);
 
     const handleGoogleLogin = () => {
-        window.location.href = '/api/auth/login';
+        window.location.href = withBase('/api/auth/login');
     };
 
     const handleLocalSubmit = async (e) => {
@@ -53,12 +56,16 @@ export default function Login() {
             const res = await fetch('/api/auth/login', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
-                body: JSON.stringify({ username, password })
+                body: JSON.stringify({ username, password, code })
             });
 
             if (!res.ok) {
                 const body = await res.json();
-                setLoginError(body.error || 'Authentication failed');
+                if (body.mfaRequired) {
+                    setMfaRequired(true);
+                }
+                // The first prompt for a code is not an error
+                setLoginError(body.mfaRequired && !code ? null : (body.error || 'Authentication failed'));
                 setSubmitting(false);
                 return;
             }
@@ -68,7 +75,7 @@ export default function Login() {
                 // Store token in localStorage
                 localStorage.setItem('token', data.token);
                 // Redirect will be handled organically by App.jsx mounting or refreshing
-                window.location.href = '/';
+                window.location.href = withBase('/');
             }
         } catch (err) {
             setLoginError('Network error during login');
@@ -85,7 +92,7 @@ export default function Login() {
                 setDevError(body.error || 'Dev login failed');
                 return;
             }
-            window.location.href = '/';
+            window.location.href = withBase('/');
         } catch (e) {
             setDevError('Dev login failed');
         }
@@ -110,6 +117,18 @@ export default function Login() {
                     </div>
                 )}
 
+                {providers.proxy && (
+                    <div className="mb-4 p-4 bg-blue-900/20 border border-blue-500/40 rounded-lg text-sm text-[var(--text-secondary)] text-center">
+                        <p className="mb-3">Sign-in is handled by your organization's authenticating proxy. If you see this page, your request did not come through it or the proxy did not pass your identity.</p>
+                        <button
+                            onClick={() => window.location.reload()}
+                            className="text-xs font-medium text-blue-400 hover:text-blue-300 transition-colors"
+                        >
+                            Try again
+                        </button>
+                    </div>
+                )}
+
                 {providers.oidc && (
                     <button
                         onClick={handleGoogleLogin}
@@ -119,7 +138,7 @@ export default function Login() {
                     </button>
                 )}
 
-                {providers.local && (
+                {(providers.local || providers.ldap) && (
                     <div className="mt-4 pt-4 border-t border-[var(--border-color)] text-center">
                         {!showLocalLogin ? (
                             <button
@@ -127,7 +146,7 @@ export default function Login() {
                                 className="text-xs text-[var(--text-secondary)] hover:text-blue-400 transition-colors flex items-center justify-center gap-1 mx-auto"
                             >
                                 <svg xmlns="http://www.w3.org/2000/svg" width="12" height="12" viewBox="0 0 24 24" fill="none" stroke="currentColor" strokeWidth="2" strokeLinecap="round" strokeLinejoin="round" className="lucide lucide-chevron-down"><path d="m6 9 6 6 6-6" /></svg>
-                                Local user login
+                                {providers.ldap ? 'Directory (LDAP) login' : 'Local user login'}
                             </button>
                         ) : providers.oidc && (
                             <div className="relative my-4">
@@ -142,7 +161,7 @@ export default function Login() {
                     </div>
                 )}
 
-                {providers.local && showLocalLogin && (
+                {(providers.local || providers.ldap) && showLocalLogin && (
                     <form onSubmit={handleLocalSubmit} className="space-y-4 mb-4">
                         <div>
                             <label className="block text-xs font-medium text-[var(--text-secondary)] mb-1">Username</label>
@@ -164,6 +183,21 @@ export default function Login() {
                                 className="w-full px-3 py-2 bg-[var(--bg-main)] border border-[var(--border-color)] rounded-md text-sm text-[var(--text-primary)] focus:outline-none focus:border-blue-500 transition-colors"
                             />
                         </div>
+                        {mfaRequired && (
+                            <div>
+                                <label className="block text-xs font-medium text-[var(--text-secondary)] mb-1">Authenticator code</label>
+                                <input
+                                    type="text"
+                                    inputMode="numeric"
+                                    autoComplete="one-time-code"
+                                    required
+                                    autoFocus
+                                    value={code}
+                                    onChange={e => setCode(e.target.value)}
+                                    className="w-full px-3 py-2 bg-[var(--bg-main)] border border-[var(--border-color)] rounded-md text-sm text-[var(--text-primary)] focus:outline-none focus:border-blue-500 transition-colors"
+                                />
+                            </div>
+                        )}
                         <button
                             type="submit"
                             disabled={submitting}
@@ -174,12 +208,20 @@ export default function Login() {
                     </form>
                 )}
 
-                {!providers.oidc && !providers.local && (
+                {!providers.oidc && !providers.local && !providers.ldap && !providers.proxy && !providers.anonymous && (
                     <div className="text-center p-4 bg-red-900/20 border border-red-500/50 rounded-lg text-red-400 text-sm mb-4">
                         No authentication providers are configured on the server.
                     </div>
                 )}
 
+                {providers.anonymous && (
+                    <div className="mt-4 text-center">
+                        <a href={withBase('/')} className="text-xs text-[var(--text-secondary)] hover:text-blue-400 transition-colors">
+                            Continue without signing in
+                        </a>
+                    </div>
+                )}
+
                 {providers.dev && (
                     <div className="border-t border-[var(--border-color)] mt-6 pt-4 relative z-10">
                         <p className="text-[10px] text-[var(--text-muted)] text-center mb-3 uppercase font-bold tracking-wider">Development</p>
@@ -1,5 +1,5 @@
 import React, { useState, useEffect, useCallback } from 'react';
-import { Server, Cpu, MemoryStick, CheckCircle, XCircle, Shield, Layers, MoreVertical } from 'lucide-react';
+import { Server, Cpu, MemoryStick, CheckCircle, XCircle, Shield, Layers, MoreVertical, CircuitBoard } from 'lucide-react';
 import { Link } from 'react-router-dom';
 import ResourceActionMenu from './ResourceActionMenu';
 
@@ -52,6 +52,7 @@ export default function Nodes() {
     const [nodes, setNodes] = useState([]);
     const [loading, setLoading] = useState(true);
     const [error, setError] = useState(null);
+    const [gpus, setGpus] = useState(null);
 
     const loadNodes = useCallback(() => {
         setLoading(true);
@@ -60,6 +61,10 @@ export default function Nodes() {
             .then(data => setNodes(data || []))
             .catch(e => setError(e.message))
             .finally(() => setLoading(false));
+        fetch('/api/nodes/gpus')
+            .then(r => r.ok ? r.json() : Promise.reject())
+            .then(setGpus)
+            .catch(() => setGpus(null));
     }, []);
 
     useEffect(() => {
@@ -91,6 +96,15 @@ export default function Nodes() {
                     <StatCard label="Ready" value={ready} sub={`${notReady} Not Ready`} icon={CheckCircle} color="bg-green-500/10 text-green-500" />
                     <StatCard label="Control Plane" value={controlPlane} icon={Shield} color="bg-purple-500/10 text-purple-400" />
                     <StatCard label="Workers" value={workers} icon={Layers} color="bg-cyan-500/10 text-cyan-400" />
+                    {gpus?.nodes?.length > 0 && (
+                        <StatCard
+                            label="GPUs allocated"
+                            value={`${gpus.allocationPercent}%`}
+                            sub={Object.entries(gpus.resources).map(([name, r]) => `${name}: ${r.requested}/${r.allocatable}`).join(', ') + (gpus.pending ? ` · ${gpus.pending} pod(s) pending` : '')}
+                            icon={CircuitBoard}
+                            color="bg-lime-500/10 text-lime-400"
+                        />
+                    )}
                 </div>
             )}
 
@@ -133,7 +147,12 @@ export default function Nodes() {
                                                     {node.name}
                                                 </Link>
                                             </div>
-                                            <div className="text-xs text-[var(--text-muted)] ml-5">{node.os}</div>
+                                            <div className="text-xs text-[var(--text-muted)] ml-5 flex items-center gap-1.5">
+                                                {node.operatingSystem === 'windows' && (
+                                                    <span className="text-[10px] font-semibold text-sky-400 bg-sky-500/10 px-1.5 rounded">windows</span>
+                                                )}
+                                                {node.os}
+                                            </div>
                                         </td>
                                         <td className="px-4 py-3"><RoleBadge role={node.role} /></td>
                                         <td className="px-4 py-3">
@@ -157,6 +176,11 @@ export default function Nodes() {
                                                 <span>{bytesToGiB(node.memoryCapacity)}</span>
                                                 <span className="text-[var(--text-muted)] text-xs">/ {bytesToGiB(node.memoryAllocatable)} alloc</span>
                                             </div>
+                                            {Object.entries(node.extendedAllocatable || {}).map(([name, value]) => (
+                                                <div key={name} className="text-xs text-lime-400 font-mono mt-0.5" title={`Capacity ${node.extendedCapacity?.[name]}`}>
+                                                    {name}: {value}
+                                                </div>
+                                            ))}
                                         </td>
                                         <td className="px-4 py-3 text-[var(--text-muted)] font-mono text-xs">{node.architecture}</td>
                                         <td className="px-4 py-3 text-[var(--text-muted)] font-mono text-xs">{node.kubeletVersion}</td>
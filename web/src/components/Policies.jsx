@@ -0,0 +1,111 @@
+import React, { useState, useEffect, useCallback } from 'react';
+import { ShieldCheck, RefreshCw } from 'lucide-react';
+import NamespaceSelect from './NamespaceSelect';
+
+const resultStyles = {
+    fail: 'text-red-400 bg-red-500/10',
+    deny: 'text-red-400 bg-red-500/10',
+    error: 'text-red-400 bg-red-500/10',
+    warn: 'text-amber-400 bg-amber-500/10',
+    dryrun: 'text-blue-400 bg-blue-500/10',
+};
+
+function WorkloadViolations({ workload }) {
+    return (
+        <div className="py-3 border-t border-[var(--border-color)] first:border-t-0">
+            <div className="text-sm font-mono text-[var(--text-white)] mb-2">
+                <span className="text-[var(--text-muted)]">{workload.kind}/</span>{workload.name}
+            </div>
+            <div className="space-y-2">
+                {workload.violations.map((v, i) => (
+                    <div key={i} className="text-xs flex items-start gap-2">
+                        <span className={`font-semibold px-2 py-0.5 rounded-full shrink-0 ${resultStyles[v.result] || 'text-[var(--text-muted)]'}`}>{v.result}</span>
+                        <div>
+                            <div className="text-[var(--text-primary)]">
+                                <span className="font-mono">{v.policy}</span>
+                                {v.rule && <span className="text-[var(--text-muted)]"> · {v.rule}</span>}
+                                <span className="text-[var(--text-muted)]"> · {v.engine}</span>
+                                {v.severity && <span className="text-[var(--text-muted)]"> · {v.severity}</span>}
+                            </div>
+                            <div className="text-[var(--text-muted)]">{v.message}</div>
+                        </div>
+                    </div>
+                ))}
+            </div>
+        </div>
+    );
+}
+
+export default function Policies() {
+    const [namespaces, setNamespaces] = useState([]);
+    const [namespace, setNamespace] = useState('');
+    const [data, setData] = useState({ kyverno: false, gatekeeper: false, namespaces: [] });
+    const [loading, setLoading] = useState(true);
+    const [error, setError] = useState(null);
+
+    useEffect(() => {
+        fetch('/api/namespaces')
+            .then(r => r.ok ? r.json() : Promise.reject())
+            .then(d => setNamespaces(d || []))
+            .catch(() => { });
+    }, []);
+
+    const load = useCallback(() => {
+        setLoading(true);
+        fetch(`/api/insights/policies${namespace ? `?namespace=${encodeURIComponent(namespace)}` : ''}`)
+            .then(r => r.ok ? r.json() : r.json().then(d => Promise.reject(new Error(d.error || 'Failed to fetch policy violations'))))
+            .then(d => { setData(d); setError(null); })
+            .catch(e => setError(e.message))
+            .finally(() => setLoading(false));
+    }, [namespace]);
+
+    useEffect(() => { load(); }, [load]);
+
+    const engines = [data.kyverno && 'Kyverno', data.gatekeeper && 'Gatekeeper'].filter(Boolean);
+    const total = data.namespaces.reduce((sum, ns) => sum + ns.violations, 0);
+
+    return (
+        <div className="p-8">
+            <div className="mb-8 flex items-start justify-between">
+                <div>
+                    <h2 className="text-2xl font-bold text-[var(--text-white)] mb-1">Policy Violations</h2>
+                    <p className="text-[var(--text-secondary)] text-sm">
+                        {loading ? 'Loading...' : engines.length === 0
+                            ? 'Neither Kyverno nor Gatekeeper is installed'
+                            : `${total} violation${total !== 1 ? 's' : ''} reported by ${engines.join(' and ')}`}
+                        {data.unlisted > 0 && ` (+${data.unlisted} beyond Gatekeeper's audit limit)`}
+                    </p>
+                </div>
+                <div className="flex items-center gap-2">
+                    <NamespaceSelect namespaces={namespaces} selected={namespace} onChange={ns => setNamespace(ns || '')} />
+                    <button
+                        onClick={load}
+                        className="p-2 rounded-xl bg-[var(--bg-card)] border border-[var(--border-color)] text-[var(--text-muted)] hover:text-[var(--text-white)] transition-all"
+                        title="Refresh"
+                    >
+                        <RefreshCw size={16} />
+                    </button>
+                </div>
+            </div>
+
+            {error && (
+                <div className="mb-6 p-4 bg-red-900/30 border border-red-800 text-red-400 rounded-lg text-sm">{error}</div>
+            )}
+
+            <div className="space-y-4">
+                {data.namespaces.map(ns => (
+                    <div key={ns.namespace || '-'} className="bg-[var(--bg-glass)] glass rounded-2xl border border-[var(--border-color)] p-5 shadow-xl">
+                        <div className="flex items-center justify-between mb-2">
+                            <div className="flex items-center gap-2 font-medium text-[var(--text-white)]">
+                                <ShieldCheck size={14} className="text-[var(--text-muted)]" />
+                                {ns.namespace || <span className="italic">cluster-scoped</span>}
+                            </div>
+                            <span className="text-xs text-[var(--text-muted)]">{ns.violations} violation{ns.violations !== 1 ? 's' : ''}</span>
+                        </div>
+                        {ns.workloads.map(w => <WorkloadViolations key={`${w.kind}/${w.name}`} workload={w} />)}
+                    </div>
+                ))}
+            </div>
+        </div>
+    );
+}
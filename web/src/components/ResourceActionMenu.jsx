@@ -5,6 +5,7 @@ import {
     ChevronUp, ChevronDown, Zap
 } from 'lucide-react';
 import { useNavigate } from 'react-router-dom';
+import { featureEnabled } from '../features';
 
 export default function ResourceActionMenu({ kind, namespace, name, onRefresh }) {
     const [isOpen, setIsOpen] = useState(false);
@@ -12,6 +13,7 @@ export default function ResourceActionMenu({ kind, namespace, name, onRefresh })
     const [confirmAction, setConfirmAction] = useState(null); // 'delete', 'restart', 'scale'
     const [forceDelete, setForceDelete] = useState(false);
     const [scaleValue, setScaleValue] = useState(1);
+    const [impact, setImpact] = useState(null);
     const menuRef = useRef(null);
     const navigate = useNavigate();
 
@@ -31,6 +33,18 @@ export default function ResourceActionMenu({ kind, namespace, name, onRefresh })
         return () => document.removeEventListener("mousedown", handleClickOutside);
     }, []);
 
+    // Preview what the delete takes with it before it is confirmed
+    useEffect(() => {
+        if (confirmAction !== 'delete') {
+            setImpact(null);
+            return;
+        }
+        fetch(`/api/resources/${kind}/${nsPath || '-'}/${name}/impact`)
+            .then(r => r.ok ? r.json() : Promise.reject())
+            .then(setImpact)
+            .catch(() => setImpact({ dependents: [], summary: {}, unavailable: true }));
+    }, [confirmAction, kind, nsPath, name]);
+
     const handleActionTrigger = (e, action) => {
         e.stopPropagation();
         if (action === 'delete' || action === 'restart' || action === 'scale') {
@@ -181,9 +195,11 @@ export default function ResourceActionMenu({ kind, namespace, name, onRefresh })
                             <button onClick={(e) => handleActionTrigger(e, 'describe')} className="w-full flex items-center gap-3 px-4 py-2 text-xs font-bold text-[var(--text-secondary)] hover:text-[var(--text-white)] hover:bg-[var(--accent)]/10 transition-colors">
                                 <ExternalLink size={14} /> View Details
                             </button>
-                            <button onClick={(e) => handleActionTrigger(e, 'edit')} className="w-full flex items-center gap-3 px-4 py-2 text-xs font-bold text-[var(--text-secondary)] hover:text-[var(--text-white)] hover:bg-[var(--accent)]/10 transition-colors">
-                                <Edit3 size={14} /> Edit YAML
-                            </button>
+                            {featureEnabled('edit') && (
+                                <button onClick={(e) => handleActionTrigger(e, 'edit')} className="w-full flex items-center gap-3 px-4 py-2 text-xs font-bold text-[var(--text-secondary)] hover:text-[var(--text-white)] hover:bg-[var(--accent)]/10 transition-colors">
+                                    <Edit3 size={14} /> Edit YAML
+                                </button>
+                            )}
                             {isScalable && (
                                 <button onClick={(e) => handleActionTrigger(e, 'scale')} className="w-full flex items-center gap-3 px-4 py-2 text-xs font-bold text-[var(--text-secondary)] hover:text-[var(--text-white)] hover:bg-[var(--accent)]/10 transition-colors">
                                     <Activity size={14} /> Scale Replicas
@@ -194,9 +210,11 @@ export default function ResourceActionMenu({ kind, namespace, name, onRefresh })
                                     <button onClick={(e) => handleActionTrigger(e, 'logs')} className="w-full flex items-center gap-3 px-4 py-2 text-xs font-bold text-[var(--text-secondary)] hover:text-[var(--text-white)] hover:bg-[var(--accent)]/10 transition-colors">
                                         <FileText size={14} /> View Logs
                                     </button>
-                                    <button onClick={(e) => handleActionTrigger(e, 'exec')} className="w-full flex items-center gap-3 px-4 py-2 text-xs font-bold text-[var(--text-secondary)] hover:text-[var(--text-white)] hover:bg-[var(--accent)]/10 transition-colors">
-                                        <Terminal size={14} /> Exec Shell
-                                    </button>
+                                    {featureEnabled('exec') && (
+                                        <button onClick={(e) => handleActionTrigger(e, 'exec')} className="w-full flex items-center gap-3 px-4 py-2 text-xs font-bold text-[var(--text-secondary)] hover:text-[var(--text-white)] hover:bg-[var(--accent)]/10 transition-colors">
+                                            <Terminal size={14} /> Exec Shell
+                                        </button>
+                                    )}
                                 </>
                             )}
                             <button onClick={(e) => handleActionTrigger(e, 'export')} className="w-full flex items-center gap-3 px-4 py-2 text-xs font-bold text-[var(--text-secondary)] hover:text-[var(--text-white)] hover:bg-[var(--accent)]/10 transition-colors">
@@ -215,6 +233,22 @@ export default function ResourceActionMenu({ kind, namespace, name, onRefresh })
                                         <AlertTriangle size={16} />
                                         <span className="text-[10px] font-black uppercase tracking-wider">Confirm Delete?</span>
                                     </div>
+                                    <div className="mb-3 px-1 text-[9px] text-[var(--text-muted)]">
+                                        {!impact ? 'Checking dependents...' : impact.unavailable ? 'Dependents could not be checked' : impact.dependents.length === 0 ? 'No dependent objects found' : (
+                                            <>
+                                                <div className="font-bold text-[var(--text-secondary)] mb-1">
+                                                    {['deleted', 'broken', 'kept'].filter(e => impact.summary[e]).map(e => `${impact.summary[e]} ${e}`).join(' · ')}
+                                                </div>
+                                                <div className="max-h-32 overflow-y-auto space-y-0.5">
+                                                    {impact.dependents.map(d => (
+                                                        <div key={`${d.kind}/${d.namespace}/${d.name}`} title={d.reason} className={`truncate font-mono ${d.effect === 'deleted' ? 'text-rose-400' : d.effect === 'broken' ? 'text-amber-400' : ''}`}>
+                                                            {d.kind}/{d.name}
+                                                        </div>
+                                                    ))}
+                                                </div>
+                                            </>
+                                        )}
+                                    </div>
                                     <label className="flex items-center gap-2 mb-4 px-1 cursor-pointer group">
                                         <input
                                             type="checkbox"
@@ -3,10 +3,13 @@ import { useParams, useNavigate, useSearchParams } from 'react-router-dom';
 import {
     ChevronLeft, FileText, List, Terminal, Search, RefreshCw, ChevronRight,
     Info, Clipboard, CheckCircle2, AlertCircle, Clock, Activity, SquareTerminal,
-    ChevronRight as ChevronRightIcon
+    ChevronRight as ChevronRightIcon, ExternalLink
 } from 'lucide-react';
 import NetworkTraceModal from './NetworkTraceModal';
 import TerminalModal from './TerminalModal';
+import UsageSparklines from './UsageSparklines';
+import { featureEnabled } from '../features';
+import { withBase } from '../basePath';
 
 export default function ResourceDetails({ user }) {
     const { kind, namespace, name } = useParams();
@@ -49,14 +52,16 @@ export default function ResourceDetails({ user }) {
     const [logPage, setLogPage] = useState(1);
     const [logLinesPerPage] = useState(100);
     const [logContainer, setLogContainer] = useState('');
+    const [logAcrossRestarts, setLogAcrossRestarts] = useState(false);
 
-    const canEdit = user && (user.role === 'kview-cluster-admin' || user.role === 'admin' || user.role === 'edit');
+    const canEdit = user && (user.role === 'kview-cluster-admin' || user.role === 'admin' || user.role === 'edit') && featureEnabled('edit');
 
     const fetchLogs = async () => {
         if (!kind.toLowerCase().startsWith('pod')) return;
         try {
             const containerQuery = logContainer ? `&container=${logContainer}` : '';
-            const logsRes = await fetch(`/api/pods/${namespace}/${name}/logs?tail=1000${containerQuery}`);
+            const previousQuery = logAcrossRestarts ? '&previous=merge' : '';
+            const logsRes = await fetch(`/api/pods/${namespace}/${name}/logs?tail=1000${containerQuery}${previousQuery}`);
             if (logsRes.ok) {
                 const logsData = await logsRes.text();
                 setLogs(logsData);
@@ -121,7 +126,7 @@ export default function ResourceDetails({ user }) {
         if (activeTab === 'logs') {
             fetchLogs();
         }
-    }, [activeTab, logContainer, namespace, name]);
+    }, [activeTab, logContainer, logAcrossRestarts, namespace, name]);
 
     useEffect(() => {
         if (activeTab === 'logs' && logRefreshInterval > 0) {
@@ -135,7 +140,7 @@ export default function ResourceDetails({ user }) {
             if (searchParams.get('edit') === 'true' && canEdit) {
                 setIsEditing(true);
             }
-            if (searchParams.get('exec') === 'true' && kind.toLowerCase().startsWith('pod')) {
+            if (searchParams.get('exec') === 'true' && kind.toLowerCase().startsWith('pod') && featureEnabled('exec')) {
                 setTerminalModalOpen(true);
             }
         }
@@ -228,7 +233,7 @@ export default function ResourceDetails({ user }) {
                         Visual Trace
                     </button>
                 )}
-                {kind === 'pods' && (
+                {kind === 'pods' && featureEnabled('exec') && (
                     <button
                         onClick={() => setTerminalModalOpen(true)}
                         className="flex items-center gap-2 px-5 py-2.5 bg-emerald-600 text-white rounded-xl text-xs font-bold uppercase tracking-wider hover:bg-emerald-500 shadow-lg shadow-emerald-500/20 transition-all active:scale-95 ml-2"
@@ -237,6 +242,21 @@ export default function ResourceDetails({ user }) {
                         Exec Terminal
                     </button>
                 )}
+                {kind === 'services' && featureEnabled('proxy') && namespace !== '-' && (spec?.ports || [])
+                    .filter(p => (p.protocol || 'TCP') === 'TCP')
+                    .map(p => (
+                        <a
+                            key={p.port}
+                            href={withBase(`/api/proxy/services/${namespace}/${name}/${p.name || p.port}/`)}
+                            target="_blank"
+                            rel="noreferrer"
+                            title="Open through the K-View service proxy"
+                            className="flex items-center gap-2 px-5 py-2.5 bg-sky-600 text-white rounded-xl text-xs font-bold uppercase tracking-wider hover:bg-sky-500 shadow-lg shadow-sky-500/20 transition-all active:scale-95 ml-2"
+                        >
+                            <ExternalLink size={16} />
+                            Open :{p.port}
+                        </a>
+                    ))}
             </div>
 
             <NetworkTraceModal
@@ -360,6 +380,14 @@ export default function ResourceDetails({ user }) {
                             </div>
                         </div>
 
+                        {isPod && status.phase === 'Running' && (
+                            <DetailSection title="Live Usage">
+                                <div className="px-6 py-4">
+                                    <UsageSparklines namespace={namespace} name={name} />
+                                </div>
+                            </DetailSection>
+                        )}
+
                         {/* Section: Metadata */}
                         <DetailSection title="Metadata">
                             <table className="w-full text-sm text-left border-collapse">
@@ -770,6 +798,16 @@ export default function ResourceDetails({ user }) {
                                 </div>
 
                                 <div className="flex items-center gap-4">
+                                    <label className="flex items-center gap-2 cursor-pointer group" title="Merge the logs of the previous container instance, marking where it restarted">
+                                        <div
+                                            className={`w-8 h-4 rounded-full relative transition-colors ${logAcrossRestarts ? 'bg-blue-600' : 'bg-[var(--border-color)]'}`}
+                                            onClick={() => { setLogAcrossRestarts(!logAcrossRestarts); setLogPage(1); }}
+                                        >
+                                            <div className={`absolute top-0.5 left-0.5 w-3 h-3 bg-white rounded-full transition-transform ${logAcrossRestarts ? 'translate-x-4' : ''}`} />
+                                        </div>
+                                        <span className="text-[10px] uppercase font-bold text-[var(--text-muted)] group-hover:text-[var(--text-white)] transition-colors">Across restarts</span>
+                                    </label>
+
                                     <label className="flex items-center gap-2 cursor-pointer group">
                                         <div
                                             className={`w-8 h-4 rounded-full relative transition-colors ${logPaginationEnabled ? 'bg-blue-600' : 'bg-[var(--border-color)]'}`}
@@ -4,6 +4,7 @@ import { Link } from 'react-router-dom';
 import ResourceActionMenu from './ResourceActionMenu';
 import NamespaceSelect from './NamespaceSelect';
 import NetworkTraceModal from './NetworkTraceModal';
+import { loadColumns } from '../columns';
 
 // Column schema per resource kind
 const SCHEMAS = {
@@ -15,6 +16,7 @@ const SCHEMAS = {
             { key: 'status', label: 'Status', badge: true },
             { key: 'extra.ready', label: 'Ready' },
             { key: 'extra.restarts', label: 'Restarts' },
+            { key: 'owner', label: 'Controlled By' },
             { key: 'age', label: 'Age' },
         ],
     },
@@ -238,11 +240,23 @@ const SCHEMAS = {
     },
 };
 
+// Route segment of the kinds a pod or replicaset can be controlled by
+const OWNER_ROUTES = {
+    Deployment: 'deployments',
+    StatefulSet: 'statefulsets',
+    DaemonSet: 'daemonsets',
+    ReplicaSet: 'replicasets',
+    Job: 'jobs',
+};
+
 // Get a possibly-nested value like "extra.ready"
 function getVal(item, key) {
     if (key.startsWith('extra.')) {
         return item.extra?.[key.slice(6)] ?? '—';
     }
+    if (key === 'owner') {
+        return item.owner ? `${item.owner.kind}/${item.owner.name}` : '—';
+    }
     return item[key] ?? '—';
 }
 
@@ -273,8 +287,30 @@ function StatusBadge({ value }) {
     );
 }
 
+// withCustomColumns adds the configured columns of a kind before its age column. Custom
+// resources without a built-in schema get name, namespace and status columns too.
+function withCustomColumns(kind, schema, custom) {
+    if (!custom) return schema;
+    let cols = schema.cols;
+    if (!SCHEMAS[kind]) {
+        cols = [
+            { key: 'name', label: 'Name' },
+            ...(custom.clusterScoped ? [] : [{ key: 'namespace', label: 'Namespace' }]),
+            { key: 'status', label: 'Status', badge: true },
+            { key: 'age', label: 'Age' },
+        ];
+    }
+    const extra = custom.columns.map(c => ({ key: `extra.${c.name}`, label: c.name }));
+    const age = cols.findIndex(c => c.key === 'age');
+    return {
+        ...schema,
+        cols: age < 0 ? [...cols, ...extra] : [...cols.slice(0, age), ...extra, ...cols.slice(age)],
+    };
+}
+
 export default function ResourceList({ kind }) {
-    const schema = SCHEMAS[kind] || { title: kind, cols: [{ key: 'name', label: 'Name' }, { key: 'age', label: 'Age' }] };
+    const [customColumns, setCustomColumns] = useState(null);
+    const schema = withCustomColumns(kind, SCHEMAS[kind] || { title: kind, cols: [{ key: 'name', label: 'Name' }, { key: 'age', label: 'Age' }] }, customColumns);
     const [items, setItems] = useState([]);
     const [namespaces, setNamespaces] = useState([]);
     const [namespace, setNamespace] = useState(localStorage.getItem('kview-selected-namespace') || '');
@@ -290,6 +326,10 @@ export default function ResourceList({ kind }) {
     // Sorting state
     const [sortConfig, setSortConfig] = useState({ key: 'name', direction: 'asc' });
 
+    useEffect(() => {
+        loadColumns().then(kinds => setCustomColumns(kinds.find(k => k.kind === kind) || null));
+    }, [kind]);
+
     // Fetch namespaces on mount
     useEffect(() => {
         fetch('/api/namespaces')
@@ -317,6 +357,12 @@ export default function ResourceList({ kind }) {
         if (!sortConfig.key) return result;
 
         result.sort((a, b) => {
+            // Age sorts by creation time: the newest item is the youngest
+            if (sortConfig.key === 'age' && a.creationTimestamp && b.creationTimestamp) {
+                const diff = Date.parse(b.creationTimestamp) - Date.parse(a.creationTimestamp);
+                return sortConfig.direction === 'asc' ? diff : -diff;
+            }
+
             let aVal = getVal(a, sortConfig.key);
             let bVal = getVal(b, sortConfig.key);
 
@@ -418,7 +464,11 @@ export default function ResourceList({ kind }) {
                                     {schema.cols.map(col => {
                                         const val = getVal(item, col.key);
                                         return (
-                                            <td key={col.key} className="px-4 py-2 whitespace-nowrap">
+                                            <td
+                                                key={col.key}
+                                                className="px-4 py-2 whitespace-nowrap"
+                                                title={col.key === 'age' && item.creationTimestamp ? new Date(item.creationTimestamp).toLocaleString() : undefined}
+                                            >
                                                 {col.badge
                                                     ? <StatusBadge value={val} />
                                                     : col.key === 'name'
@@ -430,7 +480,16 @@ export default function ResourceList({ kind }) {
                                                                 {val}
                                                             </Link>
                                                         )
-                                                        : <span className="text-[var(--text-secondary)] font-medium">{val}</span>
+                                                        : col.key === 'owner' && OWNER_ROUTES[item.owner?.kind]
+                                                            ? (
+                                                                <Link
+                                                                    to={`/${OWNER_ROUTES[item.owner.kind]}/${item.namespace}/${item.owner.name}`}
+                                                                    className="text-[var(--text-secondary)] hover:text-[var(--accent)] font-medium transition-colors"
+                                                                >
+                                                                    {val}
+                                                                </Link>
+                                                            )
+                                                            : <span className="text-[var(--text-secondary)] font-medium">{val}</span>
                                                 }
                                             </td>
                                         );
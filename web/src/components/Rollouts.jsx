@@ -0,0 +1,210 @@
+import React, { useState, useEffect, useCallback } from 'react';
+import { Rocket, CheckCircle, XCircle, AlertTriangle, RefreshCw, PauseCircle } from 'lucide-react';
+import NamespaceSelect from './NamespaceSelect';
+
+const phaseStyles = {
+    Healthy: 'text-green-400 bg-green-500/10',
+    Progressing: 'text-blue-400 bg-blue-500/10',
+    Paused: 'text-amber-400 bg-amber-500/10',
+    Degraded: 'text-red-400 bg-red-500/10',
+};
+
+function Revision({ label, rev }) {
+    if (!rev) return <div className="text-xs text-[var(--text-muted)]">{label}: none</div>;
+    return (
+        <div className="text-xs">
+            <div className="flex items-center gap-1">
+                {rev.healthy ? <CheckCircle size={11} className="text-green-400" /> : <AlertTriangle size={11} className="text-amber-400" />}
+                <span className="font-semibold text-[var(--text-white)]">{label}</span>
+                <span className="font-mono text-[var(--text-muted)]">{rev.name}</span>
+                <span>{rev.ready}/{rev.replicas} ready</span>
+                {rev.restarts > 0 && <span className="text-amber-400">{rev.restarts} restarts</span>}
+            </div>
+            {rev.images?.map(img => <div key={img} className="font-mono text-[var(--text-muted)] ml-4">{img}</div>)}
+            {rev.unhealthy?.map(u => <div key={u} className="text-red-400 ml-4">{u}</div>)}
+        </div>
+    );
+}
+
+function TrafficBar({ split }) {
+    return (
+        <div className="text-xs mb-1">
+            <div className="flex justify-between text-[var(--text-muted)]">
+                <span className="font-mono">{split.service || 'pods'}</span>
+                <span>{split.stableWeight}% / {split.canaryWeight}% ({split.source})</span>
+            </div>
+            <div className="flex h-1.5 rounded-full overflow-hidden bg-[var(--bg-muted)] mt-1">
+                <div className="bg-green-500" style={{ width: `${split.stableWeight}%` }} />
+                <div className="bg-blue-500" style={{ width: `${split.canaryWeight}%` }} />
+            </div>
+        </div>
+    );
+}
+
+function RolloutCard({ rollout, canEdit, busy, onAction }) {
+    const updating = !!rollout.canary;
+    return (
+        <div className="bg-[var(--bg-glass)] glass rounded-2xl border border-[var(--border-color)] p-5 shadow-xl">
+            <div className="flex items-start justify-between mb-3">
+                <div>
+                    <div className="flex items-center gap-2 font-mono font-medium text-[var(--text-white)]">
+                        <Rocket size={14} className="text-[var(--text-muted)]" /> {rollout.name}
+                    </div>
+                    <div className="text-xs text-[var(--text-muted)] mt-1">
+                        {rollout.kind === 'DeploymentPair' ? 'Deployment pair' : 'Argo Rollout'} · {rollout.strategy}
+                        {rollout.steps > 0 && ` · step ${rollout.step}/${rollout.steps}`}
+                    </div>
+                </div>
+                <div className="flex items-center gap-2">
+                    {rollout.paused && <PauseCircle size={14} className="text-amber-400" title="Paused" />}
+                    <span className={`text-xs font-semibold px-2 py-0.5 rounded-full ${phaseStyles[rollout.phase] || 'text-[var(--text-muted)]'}`}>
+                        {rollout.aborted ? 'Aborted' : rollout.phase}
+                    </span>
+                </div>
+            </div>
+            {rollout.message && <div className="text-xs text-[var(--text-muted)] mb-3">{rollout.message}</div>}
+            <div className="space-y-2 mb-3">
+                <Revision label="Stable" rev={rollout.stable} />
+                {updating && <Revision label="Canary" rev={rollout.canary} />}
+            </div>
+            {rollout.traffic?.map((t, i) => <TrafficBar key={i} split={t} />)}
+            {canEdit && updating && (
+                <div className="flex gap-2 mt-4">
+                    <button
+                        disabled={busy}
+                        onClick={() => onAction(rollout, 'promote')}
+                        className="inline-flex items-center gap-1 px-3 py-1.5 rounded-lg bg-green-500/10 text-green-400 border border-green-500/20 hover:bg-green-500/20 text-xs font-bold transition-all disabled:opacity-50"
+                    >
+                        <CheckCircle size={12} /> Promote
+                    </button>
+                    <button
+                        disabled={busy}
+                        onClick={() => onAction(rollout, 'abort')}
+                        className="inline-flex items-center gap-1 px-3 py-1.5 rounded-lg bg-red-500/10 text-red-400 border border-red-500/20 hover:bg-red-500/20 text-xs font-bold transition-all disabled:opacity-50"
+                    >
+                        <XCircle size={12} /> Abort
+                    </button>
+                </div>
+            )}
+        </div>
+    );
+}
+
+export default function Rollouts({ user }) {
+    const [namespaces, setNamespaces] = useState([]);
+    const [namespace, setNamespace] = useState(localStorage.getItem('kview-selected-namespace') || 'default');
+    const [data, setData] = useState({ installed: true, rollouts: [] });
+    const [pair, setPair] = useState({ stable: '', canary: '' });
+    const [pairStatus, setPairStatus] = useState(null);
+    const [loading, setLoading] = useState(true);
+    const [error, setError] = useState(null);
+    const [busy, setBusy] = useState(null);
+    const canEdit = user && ['kview-cluster-admin', 'admin', 'edit'].includes(user.role);
+
+    useEffect(() => {
+        fetch('/api/namespaces')
+            .then(r => r.ok ? r.json() : Promise.reject())
+            .then(d => setNamespaces(d || []))
+            .catch(() => { });
+    }, []);
+
+    const load = useCallback(() => {
+        if (!namespace) return;
+        setLoading(true);
+        fetch(`/api/rollouts/${encodeURIComponent(namespace)}`)
+            .then(r => r.ok ? r.json() : r.json().then(d => Promise.reject(new Error(d.error || 'Failed to fetch rollouts'))))
+            .then(d => { setData(d); setError(null); })
+            .catch(e => setError(e.message))
+            .finally(() => setLoading(false));
+    }, [namespace]);
+
+    useEffect(() => {
+        load();
+        setPairStatus(null);
+    }, [load]);
+
+    const loadPair = () => {
+        if (!pair.stable || !pair.canary) return;
+        fetch(`/api/rollout-pairs/${encodeURIComponent(namespace)}/${encodeURIComponent(pair.stable)}/${encodeURIComponent(pair.canary)}`)
+            .then(r => r.ok ? r.json() : r.json().then(d => Promise.reject(new Error(d.error || 'Failed to fetch deployment pair'))))
+            .then(d => { setPairStatus(d); setError(null); })
+            .catch(e => setError(e.message));
+    };
+
+    const act = async (rollout, action) => {
+        let url = `/api/rollouts/${encodeURIComponent(namespace)}/${rollout.name}/${action}`;
+        if (rollout.kind === 'DeploymentPair') {
+            url = `/api/rollout-pairs/${encodeURIComponent(namespace)}/${rollout.stable.name}/${rollout.canary.name}/${action}`;
+        } else if (action === 'promote' && window.confirm(`Promote ${rollout.name} fully, skipping the remaining steps?\n\nCancel promotes to the next step only.`)) {
+            url += '?full=true';
+        }
+        if (action === 'abort' && !window.confirm(`Abort ${rollout.name} and send all traffic back to stable?`)) return;
+        setBusy(rollout.name);
+        const res = await fetch(url, { method: 'POST' });
+        const body = await res.json().catch(() => ({}));
+        setBusy(null);
+        if (!res.ok) {
+            setError(body.error || `Failed to ${action} ${rollout.name}`);
+            return;
+        }
+        load();
+        if (rollout.kind === 'DeploymentPair') loadPair();
+    };
+
+    return (
+        <div className="p-8">
+            <div className="mb-8 flex items-start justify-between">
+                <div>
+                    <h2 className="text-2xl font-bold text-[var(--text-white)] mb-1">Rollouts</h2>
+                    <p className="text-[var(--text-secondary)] text-sm">
+                        {loading ? 'Loading...' : data.installed ? `${data.rollouts.length} Argo Rollout${data.rollouts.length !== 1 ? 's' : ''}` : 'Argo Rollouts is not installed; compare a Deployment pair below'}
+                    </p>
+                </div>
+                <div className="flex items-center gap-2">
+                    <NamespaceSelect namespaces={namespaces} selected={namespace} onChange={ns => setNamespace(ns || 'default')} />
+                    <button
+                        onClick={load}
+                        className="p-2 rounded-xl bg-[var(--bg-card)] border border-[var(--border-color)] text-[var(--text-muted)] hover:text-[var(--text-white)] transition-all"
+                        title="Refresh"
+                    >
+                        <RefreshCw size={16} />
+                    </button>
+                </div>
+            </div>
+
+            {error && (
+                <div className="mb-6 p-4 bg-red-900/30 border border-red-800 text-red-400 rounded-lg text-sm">{error}</div>
+            )}
+
+            <div className="grid grid-cols-1 lg:grid-cols-2 gap-4 mb-8">
+                {data.rollouts.map(r => (
+                    <RolloutCard key={r.name} rollout={r} canEdit={canEdit} busy={busy === r.name} onAction={act} />
+                ))}
+            </div>
+
+            <h3 className="text-lg font-bold text-[var(--text-white)] mb-3">Deployment pair</h3>
+            <div className="flex items-center gap-2 mb-4">
+                {['stable', 'canary'].map(field => (
+                    <input
+                        key={field}
+                        value={pair[field]}
+                        onChange={e => setPair({ ...pair, [field]: e.target.value })}
+                        placeholder={`${field} deployment`}
+                        className="px-3 py-2 rounded-lg bg-[var(--bg-card)] border border-[var(--border-color)] text-sm text-[var(--text-primary)]"
+                    />
+                ))}
+                <button
+                    onClick={loadPair}
+                    className="px-3 py-2 rounded-lg bg-blue-500/10 text-blue-400 border border-blue-500/20 hover:bg-blue-500/20 text-sm font-bold transition-all"
+                >
+                    Analyze
+                </button>
+            </div>
+            {pairStatus && (
+                <div className="lg:w-1/2">
+                    <RolloutCard rollout={pairStatus} canEdit={canEdit} busy={busy === pairStatus.name} onAction={act} />
+                </div>
+            )}
+        </div>
+    );
+}
@@ -1,5 +1,6 @@
 import React, { useEffect, useRef, useState, useCallback } from 'react';
 import { Terminal as TerminalIcon, X, Maximize2, Minimize2, CircleAlert, CheckCircle2 } from 'lucide-react';
+import { withBase } from '../basePath';
 
 export default function TerminalModal({ isOpen, onClose, pod, namespace, containers = [] }) {
     const [selectedContainer, setSelectedContainer] = useState(containers.length === 1 ? containers[0].name : "");
@@ -89,7 +90,7 @@ export default function TerminalModal({ isOpen, onClose, pod, namespace, contain
             // JWT token must be sent via query param since WebSocket API doesn't support custom headers
             const token = localStorage.getItem('token');
             const tokenParam = token ? `?token=${encodeURIComponent(token)}` : '';
-            const wsUrl = `${protocol}//${window.location.host}/api/exec/${namespace}/${pod}/${containerName}${tokenParam}`;
+            const wsUrl = `${protocol}//${window.location.host}${withBase('/api/exec/')}${namespace}/${pod}/${containerName}${tokenParam}`;
             const ws = new WebSocket(wsUrl);
 
             ws.onopen = () => {
@@ -0,0 +1,179 @@
+import React, { useState, useEffect, useCallback } from 'react';
+import { Hourglass, RefreshCw, X, AlertTriangle, Search } from 'lucide-react';
+
+function since(date) {
+    const minutes = Math.floor((Date.now() - new Date(date).getTime()) / 60000);
+    if (minutes < 60) return `${minutes}m`;
+    if (minutes < 48 * 60) return `${Math.floor(minutes / 60)}h`;
+    return `${Math.floor(minutes / 1440)}d`;
+}
+
+// Why a namespace is stuck: unavailable APIs, controller conditions and the resources left in it
+function NamespaceDiagnosis({ name }) {
+    const [diagnosis, setDiagnosis] = useState(null);
+    const [error, setError] = useState(null);
+
+    useEffect(() => {
+        fetch(`/api/diagnostics/namespaces/${name}/terminating`)
+            .then(r => r.ok ? r.json() : r.json().then(d => Promise.reject(new Error(d.error || 'Failed to diagnose namespace'))))
+            .then(setDiagnosis)
+            .catch(e => setError(e.message));
+    }, [name]);
+
+    if (error) return <div className="mt-2 text-xs text-red-400">{error}</div>;
+    if (!diagnosis) return <div className="mt-2 text-xs text-[var(--text-muted)] italic">Checking every API resource in {name}...</div>;
+    return (
+        <div className="mt-3 space-y-2 text-xs">
+            <ul className="space-y-1">
+                {diagnosis.findings.map((f, i) => (
+                    <li key={i} className="flex items-start gap-1 text-amber-400"><AlertTriangle size={11} className="shrink-0 mt-0.5" /> {f}</li>
+                ))}
+            </ul>
+            {diagnosis.remaining.length > 0 && (
+                <div className="text-[var(--text-muted)]">
+                    {diagnosis.remaining.map(r => (
+                        <div key={`${r.resource}.${r.group}`} className="font-mono truncate" title={r.names.join(', ')}>
+                            {r.count} {r.resource}{r.group ? `.${r.group}` : ''}: {r.names.join(', ')}{r.count > r.names.length ? ', ...' : ''}
+                        </div>
+                    ))}
+                </div>
+            )}
+        </div>
+    );
+}
+
+export default function Terminating({ user }) {
+    const [data, setData] = useState({ objects: [] });
+    const [loading, setLoading] = useState(true);
+    const [error, setError] = useState(null);
+    const [busy, setBusy] = useState(null);
+    const [diagnosed, setDiagnosed] = useState(null);
+    const isAdmin = user && (user.role === 'kview-cluster-admin' || user.role === 'admin');
+
+    const load = useCallback(() => {
+        setLoading(true);
+        fetch('/api/insights/terminating')
+            .then(r => r.ok ? r.json() : r.json().then(d => Promise.reject(new Error(d.error || 'Failed to fetch terminating objects'))))
+            .then(d => { setData(d); setError(null); })
+            .catch(e => setError(e.message))
+            .finally(() => setLoading(false));
+    }, []);
+
+    useEffect(() => { load(); }, [load]);
+
+    const remove = async (obj, finalizer) => {
+        const confirm = window.prompt(
+            `Removing ${finalizer} lets ${obj.name} be deleted without what the finalizer waits for, which can leave external resources or data behind.\n\nType the name of the ${obj.kind.replace(/s$/, '')} to confirm:`, '');
+        if (confirm === null) return;
+        setBusy(`${obj.kind}/${obj.namespace}/${obj.name}/${finalizer}`);
+        const res = await fetch(`/api/resources/${obj.kind}/${obj.namespace || '-'}/${obj.name}/finalizers/remove`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ finalizer, confirm }),
+        });
+        const body = await res.json().catch(() => ({}));
+        setBusy(null);
+        if (!res.ok) {
+            setError(body.error || `Failed to remove ${finalizer}`);
+            return;
+        }
+        load();
+    };
+
+    const stuck = data.objects.filter(o => o.stuck).length;
+
+    return (
+        <div className="p-8">
+            <div className="mb-8 flex items-start justify-between">
+                <div>
+                    <h2 className="text-2xl font-bold text-[var(--text-white)] mb-1">Stuck Deletions</h2>
+                    <p className="text-[var(--text-secondary)] text-sm">
+                        {loading ? 'Loading...' : `${stuck} stuck of ${data.objects.length} object${data.objects.length !== 1 ? 's' : ''} in Terminating`}
+                    </p>
+                </div>
+                <button
+                    onClick={load}
+                    className="p-2 rounded-xl bg-[var(--bg-card)] border border-[var(--border-color)] text-[var(--text-muted)] hover:text-[var(--text-white)] transition-all"
+                    title="Refresh"
+                >
+                    <RefreshCw size={16} />
+                </button>
+            </div>
+
+            {error && (
+                <div className="mb-6 p-4 bg-red-900/30 border border-red-800 text-red-400 rounded-lg text-sm">{error}</div>
+            )}
+            {data.errors && Object.keys(data.errors).length > 0 && (
+                <div className="mb-6 p-4 bg-amber-900/20 border border-amber-800 text-amber-400 rounded-lg text-xs">
+                    Not checked: {Object.keys(data.errors).join(', ')}
+                </div>
+            )}
+
+            <div className="bg-[var(--bg-glass)] glass rounded-2xl border border-[var(--border-color)] overflow-hidden shadow-xl">
+                <table className="w-full text-sm text-left text-[var(--text-primary)]">
+                    <thead className="text-xs text-[var(--text-muted)] bg-[var(--bg-muted)]/60 uppercase tracking-wider border-b border-[var(--border-color)]">
+                        <tr>
+                            <th className="px-4 py-3">Object</th>
+                            <th className="px-4 py-3">Terminating</th>
+                            <th className="px-4 py-3">Finalizers</th>
+                        </tr>
+                    </thead>
+                    <tbody>
+                        {loading ? (
+                            <tr><td colSpan="3" className="px-4 py-8 text-center text-[var(--text-muted)] italic">Loading terminating objects...</td></tr>
+                        ) : data.objects.length === 0 ? (
+                            <tr><td colSpan="3" className="px-4 py-8 text-center text-[var(--text-muted)]">No objects in Terminating.</td></tr>
+                        ) : (
+                            data.objects.map(obj => (
+                                <tr key={`${obj.kind}/${obj.namespace}/${obj.name}`} className="border-b border-[var(--border-color)] hover:bg-[var(--sidebar-hover)]/30 transition-colors align-top">
+                                    <td className="px-4 py-3">
+                                        <div className="flex items-center gap-2 font-mono text-[var(--text-white)]">
+                                            <Hourglass size={14} className="text-[var(--text-muted)] shrink-0" />
+                                            <span><span className="text-[var(--text-muted)]">{obj.kind}/</span>{obj.namespace ? `${obj.namespace}/` : ''}{obj.name}</span>
+                                        </div>
+                                        {obj.kind === 'namespaces' && (diagnosed === obj.name ? <NamespaceDiagnosis name={obj.name} /> : (
+                                            <button
+                                                onClick={() => setDiagnosed(obj.name)}
+                                                className="mt-2 ml-5 flex items-center gap-1 text-xs text-[var(--accent)] hover:text-[var(--text-white)] transition-colors"
+                                            >
+                                                <Search size={11} /> Diagnose
+                                            </button>
+                                        ))}
+                                    </td>
+                                    <td className="px-4 py-3 text-xs whitespace-nowrap">
+                                        <span className={obj.stuck ? 'text-amber-400 flex items-center gap-1' : 'text-[var(--text-muted)]'}>
+                                            {obj.stuck && <AlertTriangle size={11} />} {since(obj.deletedAt)}
+                                        </span>
+                                    </td>
+                                    <td className="px-4 py-3 space-y-2">
+                                        {[...obj.finalizers, ...(obj.specFinalizers || [])].map(f => (
+                                            <div key={f} className="text-xs">
+                                                <div className="flex items-center gap-2">
+                                                    <span className="font-mono">{f}</span>
+                                                    {isAdmin && (
+                                                        <button
+                                                            onClick={() => remove(obj, f)}
+                                                            disabled={busy === `${obj.kind}/${obj.namespace}/${obj.name}/${f}`}
+                                                            className="flex items-center gap-1 px-2 py-0.5 rounded-lg text-rose-400 hover:bg-rose-500/10 transition-colors disabled:opacity-50"
+                                                            title="Remove finalizer"
+                                                        >
+                                                            <X size={11} /> Remove
+                                                        </button>
+                                                    )}
+                                                </div>
+                                                {obj.hints?.[f] && <div className="text-[var(--text-muted)]">{obj.hints[f]}</div>}
+                                            </div>
+                                        ))}
+                                        {obj.finalizers.length === 0 && !obj.specFinalizers?.length && (
+                                            <span className="text-xs text-[var(--text-muted)]">none; deletion should complete shortly</span>
+                                        )}
+                                    </td>
+                                </tr>
+                            ))
+                        )}
+                    </tbody>
+                </table>
+            </div>
+        </div>
+    );
+}
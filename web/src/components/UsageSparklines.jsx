@@ -0,0 +1,93 @@
+import React, { useEffect, useState } from 'react';
+import { withBase } from '../basePath';
+
+const MAX_SAMPLES = 60;
+
+function formatCPU(m) {
+    return m >= 1000 ? `${(m / 1000).toFixed(2)}` : `${m}m`;
+}
+
+function formatMemory(bytes) {
+    const mib = bytes / (1024 * 1024);
+    return mib >= 1024 ? `${(mib / 1024).toFixed(2)} GiB` : `${Math.round(mib)} MiB`;
+}
+
+function Sparkline({ values, limit, color }) {
+    const width = 160, height = 32;
+    const max = Math.max(limit || 0, ...values, 1);
+    const points = values.map((v, i) => {
+        const x = values.length > 1 ? (i / (MAX_SAMPLES - 1)) * width : 0;
+        const y = height - (v / max) * (height - 2) - 1;
+        return `${x.toFixed(1)},${y.toFixed(1)}`;
+    }).join(' ');
+    return (
+        <svg width={width} height={height} className="bg-black/20 rounded">
+            {limit > 0 && <line x1="0" x2={width} y1="1" y2="1" stroke="rgb(248 113 113 / 0.5)" strokeDasharray="3 3" />}
+            <polyline points={points} fill="none" stroke={color} strokeWidth="1.5" />
+        </svg>
+    );
+}
+
+// UsageSparklines shows live per-container CPU and memory of a pod, from the usage stream.
+export default function UsageSparklines({ namespace, name, interval = 5 }) {
+    const [series, setSeries] = useState({}); // container -> { cpu: [], memory: [], cpuLimit, memoryLimit }
+    const [unavailable, setUnavailable] = useState(false);
+
+    useEffect(() => {
+        setSeries({});
+        const source = new EventSource(withBase(`/api/pods/${namespace}/${name}/usage/stream?interval=${interval}`));
+        source.addEventListener('usage', (e) => {
+            const sample = JSON.parse(e.data);
+            setUnavailable(false);
+            setSeries(prev => {
+                const next = { ...prev };
+                sample.containers.forEach(c => {
+                    const s = next[c.name] || { cpu: [], memory: [] };
+                    next[c.name] = {
+                        cpu: [...s.cpu, c.cpuMillicores].slice(-MAX_SAMPLES),
+                        memory: [...s.memory, c.memoryBytes].slice(-MAX_SAMPLES),
+                        cpuLimit: c.cpuLimitMillicores,
+                        memoryLimit: c.memoryLimitBytes,
+                    };
+                });
+                return next;
+            });
+        });
+        source.addEventListener('unavailable', () => setUnavailable(true));
+        return () => source.close();
+    }, [namespace, name, interval]);
+
+    const containers = Object.keys(series);
+    if (containers.length === 0) {
+        return (
+            <div className="text-xs text-[var(--text-muted)] italic">
+                {unavailable ? 'No metrics for this pod yet (is metrics-server installed?)' : 'Waiting for usage samples...'}
+            </div>
+        );
+    }
+
+    return (
+        <div className="space-y-3">
+            {containers.map(c => {
+                const s = series[c];
+                return (
+                    <div key={c} className="flex items-center gap-4 flex-wrap">
+                        <span className="text-xs font-mono text-[var(--text-white)] w-32 truncate" title={c}>{c}</span>
+                        <div className="flex items-center gap-2">
+                            <Sparkline values={s.cpu} limit={s.cpuLimit} color="rgb(96 165 250)" />
+                            <span className="text-xs font-mono text-blue-400 w-24">
+                                {formatCPU(s.cpu[s.cpu.length - 1])}{s.cpuLimit ? ` / ${formatCPU(s.cpuLimit)}` : ''}
+                            </span>
+                        </div>
+                        <div className="flex items-center gap-2">
+                            <Sparkline values={s.memory} limit={s.memoryLimit} color="rgb(45 212 191)" />
+                            <span className="text-xs font-mono text-teal-400 w-36">
+                                {formatMemory(s.memory[s.memory.length - 1])}{s.memoryLimit ? ` / ${formatMemory(s.memoryLimit)}` : ''}
+                            </span>
+                        </div>
+                    </div>
+                );
+            })}
+        </div>
+    );
+}
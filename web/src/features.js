@@ -0,0 +1,11 @@
+// Feature flags reported by /api/auth/me. Subsystems switched off on the server are hidden;
+// anything the server does not mention counts as on.
+let features = {};
+
+export function setFeatures(states) {
+    features = states || {};
+}
+
+export function featureEnabled(name) {
+    return features[name] !== false;
+}
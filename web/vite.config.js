@@ -4,6 +4,8 @@ import react from '@vitejs/plugin-react'
 // https://vitejs.dev/config/
 export default defineConfig({
   plugins: [react()],
+  // Relative asset URLs, so the build works under any base path (see KVIEW_BASE_PATH)
+  base: './',
   server: {
     proxy: {
       '/api': {